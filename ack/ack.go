@@ -0,0 +1,331 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ack stores alert acknowledgements. Acknowledgements are kept until
+// the retention period after their expiration has passed, so that expirations
+// are replicated across the cluster, and can be snapshotted to disk.
+package ack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/storage"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// ErrNotFound is returned if an acknowledgement was not found.
+var ErrNotFound = errors.New("acknowledgement not found")
+
+// Acks holds acknowledgements of alerts keyed by the alert fingerprint.
+type Acks struct {
+	logger    log.Logger
+	now       func() time.Time
+	retention time.Duration
+
+	mtx       sync.RWMutex
+	st        state
+	broadcast func([]byte)
+}
+
+type state map[model.Fingerprint]*types.Acknowledgement
+
+// merge adds the acknowledgement to the state if it is newer than the
+// existing one. It returns true if the state changed.
+func (s state) merge(a *types.Acknowledgement) bool {
+	fp, err := model.ParseFingerprint(a.Fingerprint)
+	if err != nil {
+		return false
+	}
+	if prev, ok := s[fp]; ok && !prev.UpdatedAt.Before(a.UpdatedAt) {
+		return false
+	}
+	s[fp] = a
+	return true
+}
+
+func (s state) MarshalBinary() ([]byte, error) {
+	acks := make([]*types.Acknowledgement, 0, len(s))
+	for _, a := range s {
+		acks = append(acks, a)
+	}
+	return json.Marshal(acks)
+}
+
+func decodeState(b []byte) (state, error) {
+	var acks []*types.Acknowledgement
+	if err := json.Unmarshal(b, &acks); err != nil {
+		return nil, err
+	}
+	st := state{}
+	for _, a := range acks {
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+		st.merge(a)
+	}
+	return st, nil
+}
+
+// Options exposes configuration options for creating a new Acks object.
+type Options struct {
+	// A snapshot file from which the initial state is loaded.
+	SnapshotFile string
+
+	// Retention time of expired acknowledgements.
+	Retention time.Duration
+
+	// A logger used by background processing.
+	Logger  log.Logger
+	Metrics prometheus.Registerer
+}
+
+// New returns a new Acks object with the given configuration.
+func New(o Options) (*Acks, error) {
+	a := &Acks{
+		logger:    log.NewNopLogger(),
+		now:       utcNow,
+		retention: o.Retention,
+		st:        state{},
+		broadcast: func([]byte) {},
+	}
+	if o.Logger != nil {
+		a.logger = o.Logger
+	}
+	if o.Metrics != nil {
+		o.Metrics.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "alertmanager_acknowledgements",
+			Help: "How many alerts are acknowledged.",
+		}, func() float64 {
+			return float64(len(a.List()))
+		}))
+	}
+
+	if o.SnapshotFile != "" {
+		b, err := ioutil.ReadFile(o.SnapshotFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if a.st, err = decodeState(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// Set creates or replaces the acknowledgement of an alert.
+func (a *Acks) Set(ack *types.Acknowledgement) error {
+	if err := ack.Validate(); err != nil {
+		return err
+	}
+	now := a.now()
+	if ack.Expired(now) {
+		return fmt.Errorf("acknowledgement already expired")
+	}
+	ack.UpdatedAt = now
+
+	return a.setState(ack)
+}
+
+// Expire expires the acknowledgement of the alert with the given fingerprint.
+func (a *Acks) Expire(fp model.Fingerprint) error {
+	now := a.now()
+
+	a.mtx.RLock()
+	prev, ok := a.st[fp]
+	a.mtx.RUnlock()
+
+	if !ok || prev.Expired(now) {
+		return ErrNotFound
+	}
+	ack := *prev
+	ack.UpdatedAt = now
+	ack.ExpiresAt = now
+
+	return a.setState(&ack)
+}
+
+func (a *Acks) setState(ack *types.Acknowledgement) error {
+	b, err := json.Marshal([]*types.Acknowledgement{ack})
+	if err != nil {
+		return err
+	}
+
+	a.mtx.Lock()
+	a.st.merge(ack)
+	broadcast := a.broadcast
+	a.mtx.Unlock()
+
+	broadcast(b)
+	return nil
+}
+
+// Get returns the active acknowledgement of the alert with the given
+// fingerprint.
+func (a *Acks) Get(fp model.Fingerprint) (*types.Acknowledgement, bool) {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	ack, ok := a.st[fp]
+	if !ok || ack.Expired(a.now()) {
+		return nil, false
+	}
+	return ack, true
+}
+
+// Acknowledged returns whether the alert with the given fingerprint has an
+// active acknowledgement.
+func (a *Acks) Acknowledged(fp model.Fingerprint) bool {
+	_, ok := a.Get(fp)
+	return ok
+}
+
+// List returns all active acknowledgements ordered by fingerprint.
+func (a *Acks) List() []*types.Acknowledgement {
+	now := a.now()
+	res := []*types.Acknowledgement{}
+
+	a.mtx.RLock()
+	for _, ack := range a.st {
+		if !ack.Expired(now) {
+			res = append(res, ack)
+		}
+	}
+	a.mtx.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
+
+// GC removes acknowledgements whose retention period after expiration has
+// passed. It returns the number of removed acknowledgements.
+func (a *Acks) GC() int {
+	now := a.now()
+	var n int
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for fp, ack := range a.st {
+		if !ack.ExpiresAt.Add(a.retention).After(now) {
+			delete(a.st, fp)
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot writes the full internal state into the writer and returns the
+// number of bytes written.
+func (a *Acks) Snapshot(w io.Writer) (int64, error) {
+	b, err := a.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Maintenance garbage collects the acknowledgements and snapshots them to
+// the file at the given interval until stopc is closed.
+func (a *Acks) Maintenance(interval time.Duration, snapf string, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	f := func() error {
+		a.GC()
+		if snapf == "" {
+			return nil
+		}
+		f, err := storage.OpenReplace(snapf)
+		if err != nil {
+			return err
+		}
+		if _, err := a.Snapshot(f); err != nil {
+			f.Abort()
+			return err
+		}
+		return f.Close()
+	}
+
+Loop:
+	for {
+		select {
+		case <-stopc:
+			break Loop
+		case <-t.C:
+			if err := f(); err != nil {
+				level.Info(a.logger).Log("msg", "Running maintenance failed", "err", err)
+			}
+		}
+	}
+	// No need for final maintenance if we don't want to snapshot.
+	if snapf == "" {
+		return
+	}
+	if err := f(); err != nil {
+		level.Info(a.logger).Log("msg", "Creating shutdown snapshot failed", "err", err)
+	}
+}
+
+// MarshalBinary serializes all acknowledgements.
+func (a *Acks) MarshalBinary() ([]byte, error) {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	return a.st.MarshalBinary()
+}
+
+// Merge merges acknowledgements received from the cluster with the local
+// state.
+func (a *Acks) Merge(b []byte) error {
+	st, err := decodeState(b)
+	if err != nil {
+		return err
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for _, ack := range st {
+		a.st.merge(ack)
+	}
+	return nil
+}
+
+// SetBroadcast sets the provided function as the one creating data to be
+// broadcast.
+func (a *Acks) SetBroadcast(f func([]byte)) {
+	a.mtx.Lock()
+	a.broadcast = f
+	a.mtx.Unlock()
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func newTestAcks(t *testing.T, now time.Time) *Acks {
+	a, err := New(Options{Retention: time.Hour})
+	require.NoError(t, err)
+	a.now = func() time.Time { return now }
+	return a
+}
+
+func TestAcksSetExpire(t *testing.T) {
+	now := utcNow()
+	a := newTestAcks(t, now)
+
+	var broadcasts int
+	a.SetBroadcast(func([]byte) { broadcasts++ })
+
+	require.Error(t, a.Set(&types.Acknowledgement{
+		Fingerprint: "invalid",
+		CreatedBy:   "alice",
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+	require.Error(t, a.Set(&types.Acknowledgement{
+		Fingerprint: "000000000000000a",
+		CreatedBy:   "alice",
+		ExpiresAt:   now.Add(-time.Minute),
+	}))
+
+	require.NoError(t, a.Set(&types.Acknowledgement{
+		Fingerprint: "000000000000000a",
+		CreatedBy:   "alice",
+		Comment:     "looking into it",
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+	require.True(t, a.Acknowledged(0xa))
+	require.False(t, a.Acknowledged(0xb))
+	require.Equal(t, []*types.Acknowledgement{{
+		Fingerprint: "000000000000000a",
+		CreatedBy:   "alice",
+		Comment:     "looking into it",
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}}, a.List())
+
+	a.now = func() time.Time { return now.Add(time.Second) }
+	require.NoError(t, a.Expire(0xa))
+	require.False(t, a.Acknowledged(0xa))
+	require.Empty(t, a.List())
+	require.Equal(t, ErrNotFound, a.Expire(0xa))
+	require.Equal(t, ErrNotFound, a.Expire(0xb))
+	require.Equal(t, 2, broadcasts)
+}
+
+func TestAcksGC(t *testing.T) {
+	now := utcNow()
+	a := newTestAcks(t, now)
+
+	a.st = state{
+		1: {Fingerprint: "0000000000000001", ExpiresAt: now.Add(time.Minute)},
+		2: {Fingerprint: "0000000000000002", ExpiresAt: now.Add(-time.Minute)},
+		3: {Fingerprint: "0000000000000003", ExpiresAt: now.Add(-2 * time.Hour)},
+	}
+	require.Equal(t, 1, a.GC())
+	require.Len(t, a.st, 2)
+	require.Contains(t, a.st, model.Fingerprint(1))
+	require.Contains(t, a.st, model.Fingerprint(2))
+}
+
+func TestAcksMerge(t *testing.T) {
+	now := utcNow()
+	a := newTestAcks(t, now)
+	b := newTestAcks(t, now.Add(time.Minute))
+
+	require.NoError(t, a.Set(&types.Acknowledgement{
+		Fingerprint: "0000000000000001",
+		CreatedBy:   "alice",
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+	require.NoError(t, b.Set(&types.Acknowledgement{
+		Fingerprint: "0000000000000001",
+		CreatedBy:   "bob",
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+
+	// The newer acknowledgement wins regardless of the merge order.
+	ab, err := a.MarshalBinary()
+	require.NoError(t, err)
+	bb, err := b.MarshalBinary()
+	require.NoError(t, err)
+
+	require.NoError(t, a.Merge(bb))
+	require.NoError(t, b.Merge(ab))
+	for _, acks := range []*Acks{a, b} {
+		ack, ok := acks.Get(1)
+		require.True(t, ok)
+		require.Equal(t, "bob", ack.CreatedBy)
+	}
+
+	require.Error(t, a.Merge([]byte("garbage")))
+}
+
+func TestAcksSnapshot(t *testing.T) {
+	now := utcNow()
+	a := newTestAcks(t, now)
+	require.NoError(t, a.Set(&types.Acknowledgement{
+		Fingerprint: "0000000000000001",
+		CreatedBy:   "alice",
+		ExpiresAt:   now.Add(time.Hour),
+	}))
+
+	f, err := ioutil.TempFile("", "snapshot")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	var buf bytes.Buffer
+	_, err = a.Snapshot(&buf)
+	require.NoError(t, err)
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	a2, err := New(Options{SnapshotFile: f.Name()})
+	require.NoError(t, err)
+	require.Equal(t, a.st, a2.st)
+}
@@ -14,30 +14,42 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/prometheus/pkg/labels"
 
+	"github.com/prometheus/alertmanager/ack"
+	"github.com/prometheus/alertmanager/audit"
 	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/delivery"
 	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/pkg/parse"
+	"github.com/prometheus/alertmanager/preset"
 	"github.com/prometheus/alertmanager/provider"
 	"github.com/prometheus/alertmanager/silence"
 	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 )
 
@@ -81,43 +93,121 @@ func setCORS(w http.ResponseWriter) {
 type API struct {
 	alerts         provider.Alerts
 	silences       *silence.Silences
+	acks           *ack.Acks
+	presets        *preset.Presets
 	config         *config.Config
+	configYAML     string
+	configJSON     json.RawMessage
 	route          *dispatch.Route
+	tmpl           *template.Template
 	resolveTimeout time.Duration
 	uptime         time.Time
 	peer           *cluster.Peer
+	states         map[string]cluster.State
+	auditor        audit.Logger
 	logger         log.Logger
 
+	// silencePollInterval is the interval at which event streams check
+	// the silences for changes.
+	silencePollInterval time.Duration
+	// groupPollInterval is the interval at which gRPC streams check the
+	// alert groups for changes.
+	groupPollInterval time.Duration
+
 	groups         groupsFn
+	aggrGroups     aggrGroupsFn
 	getAlertStatus getAlertStatusFn
+	history        historyFn
+	pushConfig     configPushFn
+	inhibitions    inhibitionsFn
+	suppressions   *notify.Suppressions
+	integrations   integrationStatusFn
 
 	mtx sync.RWMutex
 }
 
 type groupsFn func([]*labels.Matcher) dispatch.AlertOverview
+type aggrGroupsFn func([]*labels.Matcher) []*dispatch.AggregationGroup
 type getAlertStatusFn func(model.Fingerprint) types.AlertStatus
+type historyFn func(groupKey string) []*nflog.Attempt
+
+// configPushFn validates the configuration file content and, unless it is
+// a dry run, replaces the running configuration with it. It returns the
+// loaded configuration.
+type configPushFn func(content []byte, dryRun bool) (*config.Config, error)
+
+// inhibitionsFn returns the current inhibitions of the alerts.
+type inhibitionsFn func() []*inhibit.Inhibition
+
+// integrationStatusFn returns the result of the last notification of the
+// integration of a receiver and false if it did not notify yet.
+type integrationStatusFn func(receiver, integration string, idx int) (delivery.IntegrationStatus, bool)
+
+// Options configures an API. Alerts is required, the other fields may be
+// left unset if the endpoints that need them are not used.
+type Options struct {
+	// The alerts that are listed and to which received alerts are added.
+	Alerts   provider.Alerts
+	Silences *silence.Silences
+	Acks     *ack.Acks
+	Presets  *preset.Presets
+
+	// Groups returns the alert groups of the dispatcher matching the
+	// matchers and AggrGroups the aggregation groups.
+	Groups     func([]*labels.Matcher) dispatch.AlertOverview
+	AggrGroups func([]*labels.Matcher) []*dispatch.AggregationGroup
+	// AlertStatus returns the status of an alert.
+	AlertStatus func(model.Fingerprint) types.AlertStatus
+	// History returns the notification attempts of a group.
+	History func(groupKey string) []*nflog.Attempt
+	// PushConfig validates the configuration file content and, unless it
+	// is a dry run, replaces the running configuration with it.
+	PushConfig func(content []byte, dryRun bool) (*config.Config, error)
+	// Inhibitions returns the current inhibitions of the alerts.
+	Inhibitions  func() []*inhibit.Inhibition
+	Suppressions *notify.Suppressions
+	// IntegrationStatus returns the result of the last notification of the
+	// integration of a receiver and false if it did not notify yet.
+	IntegrationStatus func(receiver, integration string, idx int) (delivery.IntegrationStatus, bool)
+
+	// An optional logger of the changes made through the API.
+	Auditor audit.Logger
+	// The peer of the cluster and the states gossiped through it by their
+	// keys, which are saved in and restored from cluster snapshots.
+	Peer   *cluster.Peer
+	States map[string]cluster.State
+
+	Logger log.Logger
+}
 
 // New returns a new API.
-func New(
-	alerts provider.Alerts,
-	silences *silence.Silences,
-	gf groupsFn,
-	sf getAlertStatusFn,
-	peer *cluster.Peer,
-	l log.Logger,
-) *API {
+func New(o Options) *API {
+	l := o.Logger
 	if l == nil {
 		l = log.NewNopLogger()
 	}
 
 	return &API{
-		alerts:         alerts,
-		silences:       silences,
-		groups:         gf,
-		getAlertStatus: sf,
+		alerts:         o.Alerts,
+		silences:       o.Silences,
+		acks:           o.Acks,
+		presets:        o.Presets,
+		groups:         o.Groups,
+		aggrGroups:     o.AggrGroups,
+		getAlertStatus: o.AlertStatus,
+		history:        o.History,
+		pushConfig:     o.PushConfig,
+		inhibitions:    o.Inhibitions,
+		suppressions:   o.Suppressions,
+		integrations:   o.IntegrationStatus,
 		uptime:         time.Now(),
-		peer:           peer,
+		peer:           o.Peer,
+		states:         o.States,
+		auditor:        o.Auditor,
 		logger:         l,
+
+		silencePollInterval: 5 * time.Second,
+		groupPollInterval:   time.Second,
 	}
 }
 
@@ -135,34 +225,132 @@ func (api *API) Register(r *route.Router) {
 
 	r.Get("/status", wrap(api.status))
 	r.Get("/receivers", wrap(api.receivers))
+	r.Post("/receivers/test", wrap(api.testReceiver))
+	r.Get("/cluster/status", wrap(api.clusterStatus))
+	r.Get("/cluster/snapshot", wrap(api.saveSnapshot))
+	r.Post("/cluster/snapshot", wrap(api.restoreSnapshot))
+	r.Post("/state/gc", wrap(api.collectGarbage))
+	r.Post("/cluster/promote", wrap(api.promote))
+	r.Post("/cluster/demote", wrap(api.demote))
+	r.Post("/templates/render", wrap(api.renderTemplate))
+	r.Post("/config", wrap(api.pushConfigFile))
 
 	r.Get("/alerts/groups", wrap(api.alertGroups))
+	r.Get("/alerts/groups/timers", wrap(api.groupTimers))
 	r.Get("/alerts", wrap(api.listAlerts))
+	r.Get("/alerts/history", wrap(api.alertHistory))
+	r.Get("/alerts/inhibitions", wrap(api.listInhibitions))
+	r.Get("/suppressions", wrap(api.listSuppressions))
+	r.Get("/events", wrap(api.events))
 	r.Post("/alerts", wrap(api.addAlerts))
+	r.Post("/alerts/resolve", wrap(api.resolveAlerts))
 
 	r.Get("/silences", wrap(api.listSilences))
 	r.Post("/silences", wrap(api.setSilence))
+	r.Post("/silences/bulk", wrap(api.createSilences))
+	r.Get("/silences/metadata", wrap(api.silenceMetadataFields))
 	r.Get("/silence/:sid", wrap(api.getSilence))
+	r.Put("/silence/:sid", wrap(api.updateSilence))
 	r.Del("/silence/:sid", wrap(api.delSilence))
+
+	r.Get("/acks", wrap(api.listAcks))
+	r.Post("/acks", wrap(api.setAck))
+	r.Del("/ack/:fingerprint", wrap(api.delAck))
+
+	r.Get("/presets", wrap(api.listPresets))
+	r.Post("/presets", wrap(api.setPreset))
+	r.Get("/preset/:name", wrap(api.getPreset))
+	r.Del("/preset/:name", wrap(api.delPreset))
+
+	r.Post("/slack/command", api.slackCommand)
+	r.Post("/slack/action", api.slackAction)
+}
+
+// apiRoute is a route of the API handled by handler.
+type apiRoute struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// v2Routes returns the routes of the second API version. They implement the
+// paths of the OpenAPI specification in openapi.yaml, from which clients
+// can be generated.
+func (api *API) v2Routes() []apiRoute {
+	return []apiRoute{
+		{http.MethodGet, "/status", api.status},
+		{http.MethodGet, "/receivers", api.receivers},
+		{http.MethodGet, "/alerts", api.listAlerts},
+		{http.MethodPost, "/alerts", api.addAlerts},
+		{http.MethodGet, "/alerts/groups", api.aggregationGroups},
+		{http.MethodGet, "/silences", api.listSilences},
+		{http.MethodPost, "/silences", api.setSilence},
+		{http.MethodPost, "/silences/bulk", api.createSilences},
+		{http.MethodGet, "/silences/metadata", api.silenceMetadataFields},
+		{http.MethodGet, "/silence/:sid", api.getSilence},
+		{http.MethodPut, "/silence/:sid", api.updateSilence},
+		{http.MethodDelete, "/silence/:sid", api.delSilence},
+	}
+}
+
+// RegisterV2 registers the handlers of the second API version under their
+// correct routes in the given router.
+func (api *API) RegisterV2(r *route.Router) {
+	wrap := func(f http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			setCORS(w)
+			f(w, r)
+		})
+	}
+
+	r.Options("/*path", wrap(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, rt := range api.v2Routes() {
+		switch rt.method {
+		case http.MethodGet:
+			r.Get(rt.path, wrap(rt.handler))
+		case http.MethodPost:
+			r.Post(rt.path, wrap(rt.handler))
+		case http.MethodPut:
+			r.Put(rt.path, wrap(rt.handler))
+		case http.MethodDelete:
+			r.Del(rt.path, wrap(rt.handler))
+		}
+	}
 }
 
 // Update sets the configuration string to a new value.
-func (api *API) Update(cfg *config.Config, resolveTimeout time.Duration) error {
+func (api *API) Update(cfg *config.Config, tmpl *template.Template, resolveTimeout time.Duration) error {
+	configYAML, err := cfg.RedactedYAML()
+	if err != nil {
+		return err
+	}
+	configJSON, err := cfg.RedactedJSON()
+	if err != nil {
+		return err
+	}
+
 	api.mtx.Lock()
 	defer api.mtx.Unlock()
 
 	api.resolveTimeout = resolveTimeout
 	api.config = cfg
+	api.configYAML = configYAML
+	api.configJSON = configJSON
 	api.route = dispatch.NewRoute(cfg.Route, nil)
+	api.tmpl = tmpl
 	return nil
 }
 
 type errorType string
 
 const (
-	errorNone     errorType = ""
-	errorInternal errorType = "server_error"
-	errorBadData  errorType = "bad_data"
+	errorNone         errorType = ""
+	errorInternal     errorType = "server_error"
+	errorBadData      errorType = "bad_data"
+	errorUnauthorized errorType = "unauthorized"
+	errorForbidden    errorType = "forbidden"
+	errorTooLarge     errorType = "too_large"
 )
 
 type apiError struct {
@@ -175,9 +363,27 @@ func (e *apiError) Error() string {
 }
 
 func (api *API) receivers(w http.ResponseWriter, req *http.Request) {
+	var details bool
+	if v := req.FormValue("details"); v != "" {
+		var err error
+		details, err = strconv.ParseBool(v)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: fmt.Errorf("invalid details parameter: %v", err),
+			}, nil)
+			return
+		}
+	}
+
 	api.mtx.RLock()
 	defer api.mtx.RUnlock()
 
+	if details {
+		api.respond(w, api.receiverStatuses())
+		return
+	}
+
 	receivers := make([]string, 0, len(api.config.Receivers))
 	for _, r := range api.config.Receivers {
 		receivers = append(receivers, r.Name)
@@ -186,18 +392,325 @@ func (api *API) receivers(w http.ResponseWriter, req *http.Request) {
 	api.respond(w, receivers)
 }
 
+type receiverStatus struct {
+	Name         string              `json:"name"`
+	Integrations []integrationStatus `json:"integrations"`
+}
+
+type integrationStatus struct {
+	Name         string     `json:"name"`
+	Index        int        `json:"index"`
+	LastAttempt  *time.Time `json:"lastAttempt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	LastErrorAt  *time.Time `json:"lastErrorAt,omitempty"`
+	FailingSince *time.Time `json:"failingSince,omitempty"`
+}
+
+// receiverStatuses returns the receivers of the configuration with the
+// results of the last notifications of their integrations. The caller must
+// hold the read lock.
+func (api *API) receiverStatuses() []receiverStatus {
+	timePtr := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+
+	res := make([]receiverStatus, 0, len(api.config.Receivers))
+	for _, rc := range api.config.Receivers {
+		rs := receiverStatus{
+			Name:         rc.Name,
+			Integrations: []integrationStatus{},
+		}
+		for _, i := range notify.BuildReceiverIntegrations(rc, api.tmpl, nil, api.logger) {
+			is := integrationStatus{
+				Name:  i.Name(),
+				Index: i.Index(),
+			}
+			if api.integrations != nil {
+				if s, ok := api.integrations(rc.Name, i.Name(), i.Index()); ok {
+					is.LastAttempt = timePtr(s.LastAttempt)
+					is.LastError = s.LastError
+					is.LastErrorAt = timePtr(s.LastErrorAt)
+					is.FailingSince = timePtr(s.FailingSince)
+				}
+			}
+			rs.Integrations = append(rs.Integrations, is)
+		}
+		res = append(res, rs)
+	}
+	return res
+}
+
+// receiverTestTimeout limits the duration of test notifications.
+const receiverTestTimeout = time.Minute
+
+// receiverTestAlertName is the default alert name of test notifications.
+const receiverTestAlertName = "AlertmanagerReceiverTest"
+
+type receiverTestRequest struct {
+	Receiver    string         `json:"receiver"`
+	Labels      model.LabelSet `json:"labels"`
+	Annotations model.LabelSet `json:"annotations"`
+	// Resolve sends a resolved notification after the firing one, e.g. to
+	// close the incidents of the test.
+	Resolve bool `json:"resolve"`
+}
+
+type integrationTestResult struct {
+	Integration string  `json:"integration"`
+	Index       int     `json:"index"`
+	Status      string  `json:"status"`
+	Duration    float64 `json:"durationSeconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// testReceiver sends a test notification through the integrations of a
+// receiver of the running configuration.
+func (api *API) testReceiver(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	var req receiverTestRequest
+	if err := api.receive(r, &req); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	api.mtx.RLock()
+	var rc *config.Receiver
+	for _, c := range api.config.Receivers {
+		if c.Name == req.Receiver {
+			rc = c
+		}
+	}
+	tmpl := api.tmpl
+	api.mtx.RUnlock()
+
+	if rc == nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: fmt.Errorf("unknown receiver %q", req.Receiver),
+		}, nil)
+		return
+	}
+
+	labels := model.LabelSet{model.AlertNameLabel: receiverTestAlertName}
+	for ln, lv := range req.Labels {
+		labels[ln] = lv
+	}
+	annotations := model.LabelSet{
+		"summary": model.LabelValue(fmt.Sprintf("Test notification of receiver %s", rc.Name)),
+	}
+	for ln, lv := range req.Annotations {
+		annotations[ln] = lv
+	}
+	now := time.Now()
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    now,
+			EndsAt:      now.Add(receiverTestTimeout),
+		},
+		UpdatedAt: now,
+	}
+	if err := alert.Validate(); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), receiverTestTimeout)
+	defer cancel()
+
+	var (
+		res    = []integrationTestResult{}
+		failed int
+	)
+	send := func(status model.AlertStatus) {
+		for _, ir := range notify.TestReceiver(ctx, rc, tmpl, []*types.Alert{alert}, api.logger) {
+			tr := integrationTestResult{
+				Integration: ir.Integration,
+				Index:       ir.Index,
+				Status:      string(status),
+				Duration:    ir.Duration.Seconds(),
+			}
+			if ir.Err != nil {
+				tr.Error = ir.Err.Error()
+				failed++
+			}
+			res = append(res, tr)
+		}
+	}
+	send(model.AlertFiring)
+	if req.Resolve {
+		resolved := *alert
+		resolved.EndsAt = time.Now()
+		alert = &resolved
+		send(model.AlertResolved)
+	}
+
+	e := &audit.Event{
+		Action:     audit.ActionReceiverTest,
+		Actor:      audit.RequestActor(r),
+		RemoteAddr: r.RemoteAddr,
+		Target:     rc.Name,
+		Payload:    req,
+	}
+	if failed > 0 {
+		e.Error = fmt.Sprintf("%d of %d notifications failed", failed, len(res))
+	}
+	api.record(e)
+
+	api.respond(w, res)
+}
+
+type renderRequest struct {
+	Name        string         `json:"name"`
+	HTML        bool           `json:"html"`
+	Receiver    string         `json:"receiver"`
+	GroupLabels model.LabelSet `json:"groupLabels"`
+	Alerts      []*types.Alert `json:"alerts"`
+}
+
+// renderTemplate renders a template of the loaded template set with the
+// alerts of the request. Nothing is sent to any receiver.
+func (api *API) renderTemplate(w http.ResponseWriter, r *http.Request) {
+	var req renderRequest
+	if err := api.receive(r, &req); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if req.Name == "" {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: errors.New("template name missing"),
+		}, nil)
+		return
+	}
+
+	api.mtx.RLock()
+	tmpl := api.tmpl
+	api.mtx.RUnlock()
+	if tmpl == nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: errors.New("templates not loaded"),
+		}, nil)
+		return
+	}
+
+	var (
+		data = tmpl.Data(req.Receiver, req.GroupLabels, req.Alerts...)
+		res  string
+		err  error
+	)
+	if req.HTML {
+		res, err = tmpl.ExecuteHTMLTemplate(req.Name, data)
+	} else {
+		res, err = tmpl.ExecuteTextTemplate(req.Name, data)
+	}
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	api.respond(w, struct {
+		Rendered string `json:"rendered"`
+	}{res})
+}
+
+type configPushRequest struct {
+	Config string `json:"config"`
+	DryRun bool   `json:"dryRun"`
+}
+
+func (api *API) pushConfigFile(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	var req configPushRequest
+	if err := api.receive(r, &req); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if api.pushConfig == nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: errors.New("pushing the configuration is not supported"),
+		}, nil)
+		return
+	}
+
+	// The diff is computed before the running configuration is replaced.
+	api.mtx.RLock()
+	running := api.configYAML
+	api.mtx.RUnlock()
+
+	conf, err := api.pushConfig([]byte(req.Config), req.DryRun)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	candidate, err := conf.RedactedYAML()
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(running),
+		B:        difflib.SplitLines(candidate),
+		FromFile: "running",
+		ToFile:   "candidate",
+		Context:  3,
+	})
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+
+	api.respond(w, struct {
+		Diff    string `json:"diff"`
+		Applied bool   `json:"applied"`
+	}{diff, !req.DryRun})
+}
+
 func (api *API) status(w http.ResponseWriter, req *http.Request) {
 	api.mtx.RLock()
 
 	var status = struct {
 		ConfigYAML    string            `json:"configYAML"`
-		ConfigJSON    *config.Config    `json:"configJSON"`
+		ConfigJSON    json.RawMessage   `json:"configJSON"`
 		VersionInfo   map[string]string `json:"versionInfo"`
 		Uptime        time.Time         `json:"uptime"`
 		ClusterStatus *clusterStatus    `json:"clusterStatus"`
 	}{
-		ConfigYAML: api.config.String(),
-		ConfigJSON: api.config,
+		ConfigYAML: api.configYAML,
+		ConfigJSON: api.configJSON,
 		VersionInfo: map[string]string{
 			"version":   version.Version,
 			"revision":  version.Revision,
@@ -216,66 +729,616 @@ func (api *API) status(w http.ResponseWriter, req *http.Request) {
 }
 
 type peerStatus struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
+	Name     string     `json:"name"`
+	Address  string     `json:"address"`
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+	Zone     string     `json:"zone,omitempty"`
+	Region   string     `json:"region,omitempty"`
+	Standby  bool       `json:"standby,omitempty"`
 }
 
 type clusterStatus struct {
-	Name   string       `json:"name"`
-	Status string       `json:"status"`
-	Peers  []peerStatus `json:"peers"`
+	Name              string       `json:"name"`
+	Status            string       `json:"status"`
+	Standby           bool         `json:"standby"`
+	Peers             []peerStatus `json:"peers"`
+	MessagesQueued    int          `json:"messagesQueued"`
+	OversizedMessages int          `json:"oversizedMessages"`
 }
 
 func getClusterStatus(p *cluster.Peer) *clusterStatus {
 	if p == nil {
 		return nil
 	}
-	s := &clusterStatus{Name: p.Name(), Status: p.Status()}
+	s := &clusterStatus{
+		Name:              p.Name(),
+		Status:            p.Status(),
+		Standby:           p.Standby(),
+		MessagesQueued:    p.QueuedMessages(),
+		OversizedMessages: p.OversizedMessages(),
+	}
 
-	for _, n := range p.Peers() {
-		s.Peers = append(s.Peers, peerStatus{
-			Name:    n.Name,
-			Address: n.Address(),
-		})
+	for _, m := range p.Members() {
+		ps := peerStatus{
+			Name:    m.Name,
+			Address: m.Address,
+			Zone:    m.Zone,
+			Region:  m.Region,
+			Standby: m.Standby,
+		}
+		if !m.LastSeen.IsZero() {
+			lastSeen := m.LastSeen
+			ps.LastSeen = &lastSeen
+		}
+		s.Peers = append(s.Peers, ps)
 	}
 	return s
 }
 
-func (api *API) alertGroups(w http.ResponseWriter, r *http.Request) {
-	var err error
-	matchers := []*labels.Matcher{}
+func (api *API) clusterStatus(w http.ResponseWriter, req *http.Request) {
+	s := getClusterStatus(api.peer)
+	if s == nil {
+		s = &clusterStatus{Status: "disabled", Peers: []peerStatus{}}
+	}
+	api.respond(w, s)
+}
 
-	if filter := r.FormValue("filter"); filter != "" {
-		matchers, err = parse.Matchers(filter)
-		if err != nil {
-			api.respondError(w, apiError{
-				typ: errorBadData,
-				err: err,
-			}, nil)
-			return
-		}
+// saveSnapshot returns a snapshot of the replicated states, i.e. the
+// silences, the notification log, the acknowledgements and the message
+// references.
+func (api *API) saveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
 	}
+	snap, err := cluster.NewSnapshot(api.states)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	api.respond(w, snap)
+}
 
-	groups := api.groups(matchers)
+// restoreSnapshot merges a snapshot into the replicated states. The peers
+// receive the restored states with the next full state sync.
+func (api *API) restoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	var snap cluster.Snapshot
+	if err := api.receive(r, &snap); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
 
-	api.respond(w, groups)
+	restored, err := snap.Restore(api.states)
+	e := &audit.Event{
+		Action:     audit.ActionStateRestore,
+		Actor:      audit.RequestActor(r),
+		RemoteAddr: r.RemoteAddr,
+		Target:     snap.CreatedAt.Format(time.RFC3339),
+		Payload:    restored,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	api.record(e)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	api.respond(w, struct {
+		Restored []string `json:"restored"`
+	}{restored})
 }
 
-func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
-	var (
-		err            error
-		receiverFilter *regexp.Regexp
-		// Initialize result slice to prevent api returning `null` when there
-		// are no alerts present
-		res      = []*dispatch.APIAlert{}
-		matchers = []*labels.Matcher{}
+// garbageCollector is implemented by the replicated states whose expired
+// entries can be removed on demand.
+type garbageCollector interface {
+	GC() (int, error)
+}
 
-		showActive, showInhibited     bool
-		showSilenced, showUnprocessed bool
-	)
+// collectGarbage removes the expired entries of the replicated states, e.g.
+// after lowering their retention, instead of waiting for the next
+// maintenance. The compacted states are snapshotted with the next
+// maintenance.
+func (api *API) collectGarbage(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	names := make([]string, 0, len(api.states))
+	for name, st := range api.states {
+		if _, ok := st.(garbageCollector); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
 
-	getBoolParam := func(name string) (bool, error) {
-		v := r.FormValue(name)
+	removed := make(map[string]int, len(names))
+	var err error
+	for _, name := range names {
+		var n int
+		n, err = api.states[name].(garbageCollector).GC()
+		removed[name] = n
+		if err != nil {
+			err = fmt.Errorf("%s: %s", name, err)
+			break
+		}
+	}
+
+	e := &audit.Event{
+		Action:     audit.ActionStateGC,
+		Actor:      audit.RequestActor(r),
+		RemoteAddr: r.RemoteAddr,
+		Payload:    removed,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	api.record(e)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+
+	api.respond(w, struct {
+		Removed map[string]int `json:"removed"`
+	}{removed})
+}
+
+// promote makes a standby Alertmanager an active member of the cluster that
+// sends notifications.
+func (api *API) promote(w http.ResponseWriter, r *http.Request) {
+	api.setStandby(w, r, false)
+}
+
+// demote makes the Alertmanager a standby that receives the replicated state
+// but does not send notifications.
+func (api *API) demote(w http.ResponseWriter, r *http.Request) {
+	api.setStandby(w, r, true)
+}
+
+func (api *API) setStandby(w http.ResponseWriter, r *http.Request, standby bool) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	if api.peer == nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: fmt.Errorf("clustering is disabled"),
+		}, nil)
+		return
+	}
+
+	err := api.peer.SetStandby(standby)
+	e := &audit.Event{
+		Action:     audit.ActionClusterPromote,
+		Actor:      audit.RequestActor(r),
+		RemoteAddr: r.RemoteAddr,
+		Target:     api.peer.Name(),
+	}
+	if standby {
+		e.Action = audit.ActionClusterDemote
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	api.record(e)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+
+	api.respond(w, getClusterStatus(api.peer))
+}
+
+type notificationAttempt struct {
+	GroupKey       string    `json:"groupKey"`
+	Receiver       string    `json:"receiver"`
+	Integration    string    `json:"integration"`
+	Timestamp      time.Time `json:"timestamp"`
+	FiringAlerts   []string  `json:"firingAlerts"`
+	ResolvedAlerts []string  `json:"resolvedAlerts"`
+	Error          string    `json:"error,omitempty"`
+}
+
+func fingerprintStrings(fps []uint64) []string {
+	res := make([]string, 0, len(fps))
+	for _, fp := range fps {
+		res = append(res, model.Fingerprint(fp).String())
+	}
+	return res
+}
+
+// alertHistory returns the notification attempts for a group key and/or the
+// alert with a fingerprint, optionally limited to those made between a start
+// and end time.
+func (api *API) alertHistory(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupKey   = r.FormValue("groupKey")
+		fp         model.Fingerprint
+		start, end time.Time
+		err        error
+	)
+	if s := r.FormValue("fingerprint"); s != "" {
+		if fp, err = model.ParseFingerprint(s); err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return
+		}
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"start", &start}, {"end", &end}} {
+		s := r.FormValue(p.name)
+		if s == "" {
+			continue
+		}
+		if *p.t, err = time.Parse(time.RFC3339, s); err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: fmt.Errorf("invalid %s time %q", p.name, s),
+			}, nil)
+			return
+		}
+	}
+	if groupKey == "" && fp == 0 && start.IsZero() && end.IsZero() {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: errors.New("fingerprint, groupKey, start or end must be set"),
+		}, nil)
+		return
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: errors.New("start must not be after end"),
+		}, nil)
+		return
+	}
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+
+	res := []notificationAttempt{}
+	if api.history != nil {
+		for _, a := range api.history(groupKey) {
+			if fp != 0 && !a.HasAlert(uint64(fp)) {
+				continue
+			}
+			if (!start.IsZero() && a.Timestamp.Before(start)) || (!end.IsZero() && a.Timestamp.After(end)) {
+				continue
+			}
+			if tm != nil && !groupKeyHasTenant(a.GroupKey, tm) {
+				continue
+			}
+			res = append(res, notificationAttempt{
+				GroupKey:       a.GroupKey,
+				Receiver:       a.Receiver.GroupName,
+				Integration:    a.Receiver.Integration,
+				Timestamp:      a.Timestamp,
+				FiringAlerts:   fingerprintStrings(a.FiringAlerts),
+				ResolvedAlerts: fingerprintStrings(a.ResolvedAlerts),
+				Error:          a.Error,
+			})
+		}
+	}
+	api.respond(w, res)
+}
+
+// inhibition is an inhibited alert with the alert inhibiting it and the
+// inhibition rule.
+type inhibition struct {
+	Fingerprint string              `json:"fingerprint"`
+	Labels      model.LabelSet      `json:"labels"`
+	InhibitedBy inhibitingAlert     `json:"inhibitedBy"`
+	Rule        *config.InhibitRule `json:"rule"`
+	RuleIndex   int                 `json:"ruleIndex"`
+}
+
+type inhibitingAlert struct {
+	Fingerprint string         `json:"fingerprint"`
+	Labels      model.LabelSet `json:"labels"`
+	StartsAt    time.Time      `json:"startsAt"`
+}
+
+func (api *API) listInhibitions(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		matchers = []*labels.Matcher{}
+	)
+	if filter := r.FormValue("filter"); filter != "" {
+		matchers, err = parse.Matchers(filter)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return
+		}
+	}
+
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+
+	res := []inhibition{}
+	if api.inhibitions != nil {
+		for _, inh := range api.inhibitions() {
+			if !alertMatchesFilterLabels(&inh.Target.Alert, matchers) {
+				continue
+			}
+			// Alerts are not shown as inhibited by the alerts of other
+			// tenants.
+			if tm != nil && (!tm.Matches(string(inh.Target.Labels[model.LabelName(tm.Name)])) ||
+				!tm.Matches(string(inh.Source.Labels[model.LabelName(tm.Name)]))) {
+				continue
+			}
+			res = append(res, inhibition{
+				Fingerprint: inh.Target.Fingerprint().String(),
+				Labels:      inh.Target.Labels,
+				InhibitedBy: inhibitingAlert{
+					Fingerprint: inh.Source.Fingerprint().String(),
+					Labels:      inh.Source.Labels,
+					StartsAt:    inh.Source.StartsAt,
+				},
+				Rule:      inh.Rule,
+				RuleIndex: inh.RuleIndex,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Fingerprint != res[j].Fingerprint {
+			return res[i].Fingerprint < res[j].Fingerprint
+		}
+		return res[i].RuleIndex < res[j].RuleIndex
+	})
+	api.respond(w, res)
+}
+
+// listSuppressions responds with the number of times alerts were left out
+// of notifications by each silence, inhibition rule and mute time interval
+// since the start. As the counts span all tenants, they are only available
+// to admins.
+func (api *API) listSuppressions(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	if api.suppressions == nil {
+		api.respond(w, notify.SuppressionCounts{
+			Silences:          map[string]uint64{},
+			InhibitRules:      []notify.InhibitRuleSuppressions{},
+			MuteTimeIntervals: map[string]uint64{},
+		})
+		return
+	}
+	api.respond(w, api.suppressions.Counts())
+}
+
+func (api *API) alertGroups(w http.ResponseWriter, r *http.Request) {
+	var err error
+	matchers := []*labels.Matcher{}
+
+	if filter := r.FormValue("filter"); filter != "" {
+		matchers, err = parse.Matchers(filter)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return
+		}
+	}
+
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
+	groups := api.groups(matchers)
+
+	api.respond(w, groups)
+}
+
+// aggregationGroups returns the alerts grouped like the dispatcher sends
+// them to the receivers.
+func (api *API) aggregationGroups(w http.ResponseWriter, r *http.Request) {
+	res, ok := api.filterAggrGroups(w, r)
+	if !ok {
+		return
+	}
+	api.respond(w, res)
+}
+
+// filterAggrGroups returns the aggregation groups with the alerts matching
+// the filter of the request whose receiver matches its receiver regex. It
+// responds with an error and returns false if the request is invalid.
+func (api *API) filterAggrGroups(w http.ResponseWriter, r *http.Request) ([]*dispatch.AggregationGroup, bool) {
+	var (
+		err            error
+		receiverFilter *regexp.Regexp
+		matchers       = []*labels.Matcher{}
+	)
+
+	if filter := r.FormValue("filter"); filter != "" {
+		matchers, err = parse.Matchers(filter)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return nil, false
+		}
+	}
+
+	if receiverParam := r.FormValue("receiver"); receiverParam != "" {
+		receiverFilter, err = regexp.Compile("^(?:" + receiverParam + ")$")
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: fmt.Errorf("failed to parse receiver param: %s", receiverParam),
+			}, nil)
+			return nil, false
+		}
+	}
+
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return nil, false
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
+	res := []*dispatch.AggregationGroup{}
+	for _, ag := range api.aggrGroups(matchers) {
+		if receiverFilter != nil && !receiverFilter.MatchString(ag.Receiver) {
+			continue
+		}
+		res = append(res, ag)
+	}
+	return res, true
+}
+
+// groupTimers are the timers of an aggregation group, which tell when its
+// alerts are notified next.
+type groupTimers struct {
+	GroupKey       string         `json:"groupKey"`
+	Receiver       string         `json:"receiver"`
+	Labels         model.LabelSet `json:"labels"`
+	Fingerprints   []string       `json:"fingerprints"`
+	GroupWait      time.Duration  `json:"groupWait"`
+	GroupInterval  time.Duration  `json:"groupInterval"`
+	RepeatInterval time.Duration  `json:"repeatInterval"`
+	LastFlush      *time.Time     `json:"lastFlush,omitempty"`
+	NextFlush      time.Time      `json:"nextFlush"`
+	NextFlushIn    time.Duration  `json:"nextFlushIn"`
+	// LastNotified is the time of the last successful notification of the
+	// receiver. Unchanged alerts are notified again at the first flush after
+	// the repeat interval passed, which is NextRepeat.
+	LastNotified *time.Time    `json:"lastNotified,omitempty"`
+	NextRepeat   *time.Time    `json:"nextRepeat,omitempty"`
+	NextRepeatIn time.Duration `json:"nextRepeatIn,omitempty"`
+}
+
+// groupTimers returns the timers of the aggregation groups with the alerts
+// matching the filter whose receiver matches the receiver regex, to tell why
+// a group was not notified yet.
+func (api *API) groupTimers(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	ags, ok := api.filterAggrGroups(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	res := make([]*groupTimers, 0, len(ags))
+	for _, ag := range ags {
+		gt := &groupTimers{
+			GroupKey:       ag.GroupKey,
+			Receiver:       ag.Receiver,
+			Labels:         ag.Labels,
+			Fingerprints:   make([]string, 0, len(ag.Alerts)),
+			GroupWait:      ag.RouteOpts.GroupWait,
+			GroupInterval:  ag.RouteOpts.GroupInterval,
+			RepeatInterval: ag.RouteOpts.RepeatInterval,
+			NextFlush:      ag.NextFlush,
+			NextFlushIn:    untilTime(ag.NextFlush, now),
+		}
+		for _, a := range ag.Alerts {
+			gt.Fingerprints = append(gt.Fingerprints, a.Fingerprint)
+		}
+		if !ag.LastFlush.IsZero() {
+			lastFlush := ag.LastFlush
+			gt.LastFlush = &lastFlush
+		}
+		if last, ok := api.groupLastNotified(ag.GroupKey, ag.Receiver); ok {
+			next := nextRepeat(last, ag.NextFlush, ag.RouteOpts.GroupInterval, ag.RouteOpts.RepeatInterval)
+			gt.LastNotified = &last
+			gt.NextRepeat = &next
+			gt.NextRepeatIn = untilTime(next, now)
+		}
+		res = append(res, gt)
+	}
+	api.respond(w, res)
+}
+
+// groupLastNotified returns the time of the last successful notification of
+// the group to the receiver and false if there was none.
+func (api *API) groupLastNotified(groupKey, receiver string) (time.Time, bool) {
+	if api.history == nil {
+		return time.Time{}, false
+	}
+	// The attempts are sorted by time, most recent first.
+	for _, a := range api.history(groupKey) {
+		if a.Error == "" && a.Receiver.GroupName == receiver {
+			return a.Timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextRepeat returns the time of the first flush of a group, which is next
+// flushed at nextFlush and then every interval, after the repeat interval
+// passed since the last notification.
+func nextRepeat(lastNotified, nextFlush time.Time, interval, repeat time.Duration) time.Time {
+	due := lastNotified.Add(repeat)
+	if interval <= 0 || nextFlush.After(due) {
+		return nextFlush
+	}
+	n := due.Sub(nextFlush)/interval + 1
+	return nextFlush.Add(n * interval)
+}
+
+// untilTime returns the duration from now until t, zero if t passed.
+func untilTime(t, now time.Time) time.Duration {
+	if d := t.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
+	var (
+		err            error
+		receiverFilter *regexp.Regexp
+		// Initialize result slice to prevent api returning `null` when there
+		// are no alerts present
+		res      = []*dispatch.APIAlert{}
+		matchers = []*labels.Matcher{}
+
+		showActive, showInhibited     bool
+		showSilenced, showUnprocessed bool
+		showAcknowledged              bool
+	)
+
+	getBoolParam := func(name string) (bool, error) {
+		v := r.FormValue(name)
 		if v == "" {
 			return true, nil
 		}
@@ -304,6 +1367,23 @@ func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
+	opts, err := parseListOptions(r, "fingerprint", "startsAt", "lastNotified")
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
 	showActive, err = getBoolParam("active")
 	if err != nil {
 		return
@@ -324,6 +1404,11 @@ func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	showAcknowledged, err = getBoolParam("acknowledged")
+	if err != nil {
+		return
+	}
+
 	if receiverParam := r.FormValue("receiver"); receiverParam != "" {
 		receiverFilter, err = regexp.Compile("^(?:" + receiverParam + ")$")
 		if err != nil {
@@ -385,17 +1470,424 @@ func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		var acked *types.Acknowledgement
+		if api.acks != nil {
+			acked, _ = api.acks.Get(a.Fingerprint())
+		}
+		if !showAcknowledged && acked != nil {
+			continue
+		}
+
 		apiAlert := &dispatch.APIAlert{
-			Alert:       &a.Alert,
-			Status:      status,
-			Receivers:   receivers,
-			Fingerprint: a.Fingerprint().String(),
+			Alert:           &a.Alert,
+			Status:          status,
+			Receivers:       receivers,
+			Fingerprint:     a.Fingerprint().String(),
+			Acknowledgement: acked,
 		}
 
 		res = append(res, apiAlert)
 	}
-	api.mtx.RUnlock()
+	api.mtx.RUnlock()
+
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	sortAlerts(res, opts, api.lastNotified)
+	start, end := opts.page(len(res))
+	w.Header().Set(totalCountHeader, strconv.Itoa(len(res)))
+	api.respond(w, res[start:end])
+}
+
+// lastNotified returns the time of the last successful notification of the
+// alerts by fingerprint.
+func (api *API) lastNotified() map[string]time.Time {
+	res := map[string]time.Time{}
+	if api.history == nil {
+		return res
+	}
+	// The attempts are ordered most recent first.
+	for _, a := range api.history("") {
+		if a.Error != "" {
+			continue
+		}
+		for _, fps := range [][]uint64{a.FiringAlerts, a.ResolvedAlerts} {
+			for _, fp := range fps {
+				s := model.Fingerprint(fp).String()
+				if _, ok := res[s]; !ok {
+					res[s] = a.Timestamp
+				}
+			}
+		}
+	}
+	return res
+}
+
+func receiversMatchFilter(receivers []string, filter *regexp.Regexp) bool {
+	for _, r := range receivers {
+		if filter.MatchString(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func alertMatchesFilterLabels(a *model.Alert, matchers []*labels.Matcher) bool {
+	sms := make(map[string]string)
+	for name, value := range a.Labels {
+		sms[string(name)] = string(value)
+	}
+	return matchFilterLabels(matchers, sms)
+}
+
+func (api *API) addAlerts(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() { alertIngestDuration.Observe(time.Since(start).Seconds()) }()
+
+	alerts, err := api.receiveAlerts(r)
+	if err != nil {
+		typ := errorBadData
+		if _, ok := err.(*limitError); ok {
+			typ = errorTooLarge
+		}
+		api.respondError(w, apiError{
+			typ: typ,
+			err: err,
+		}, nil)
+		return
+	}
+
+	// Alerts carry their tenant label, so that clients such as Prometheus
+	// may send them without a tenant.
+	tm, err := api.tenantMatcher(r)
+	if err != nil && err != errNoTenant {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	if tm != nil {
+		if err := scopeAlerts(alerts, tm); err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return
+		}
+	}
+
+	api.insertAlerts(w, r, alerts...)
+}
+
+// alertChecker is implemented by alert providers that validate received
+// alerts against further rules than their own validation.
+type alertChecker interface {
+	Check(*types.Alert) error
+}
+
+func (api *API) insertAlerts(w http.ResponseWriter, r *http.Request, alerts ...*types.Alert) {
+	validAlerts, validationErrs, err := api.putAlerts(alerts...)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	if len(validAlerts) > 0 {
+		api.recordRequest(r, audit.ActionAlertsPost, "", validAlerts)
+	}
+
+	if validationErrs.Len() > 0 {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: validationErrs,
+		}, nil)
+		return
+	}
+
+	api.respond(w, nil)
+}
+
+// putAlerts completes the start and end times of the alerts and inserts
+// the valid ones. It returns the inserted alerts and the validation errors
+// of the others.
+func (api *API) putAlerts(alerts ...*types.Alert) ([]*types.Alert, *types.MultiError, error) {
+	now := time.Now()
+
+	api.mtx.RLock()
+	resolveTimeout := api.resolveTimeout
+	api.mtx.RUnlock()
+
+	for _, alert := range alerts {
+		alert.UpdatedAt = now
+
+		// Ensure StartsAt is set.
+		if alert.StartsAt.IsZero() {
+			if alert.EndsAt.IsZero() {
+				alert.StartsAt = now
+			} else {
+				alert.StartsAt = alert.EndsAt
+			}
+		}
+		// If no end time is defined, set a timeout after which an alert
+		// is marked resolved if it is not updated.
+		if alert.EndsAt.IsZero() {
+			alert.Timeout = true
+			alert.EndsAt = now.Add(resolveTimeout)
+		}
+		if alert.EndsAt.After(time.Now()) {
+			numReceivedAlerts.WithLabelValues("firing").Inc()
+		} else {
+			numReceivedAlerts.WithLabelValues("resolved").Inc()
+		}
+	}
+
+	// Make a best effort to insert all alerts that are valid.
+	var (
+		validAlerts    = make([]*types.Alert, 0, len(alerts))
+		validationErrs = &types.MultiError{}
+		validate       = (*types.Alert).Validate
+	)
+	if c, ok := api.alerts.(alertChecker); ok {
+		validate = c.Check
+	}
+	for _, a := range alerts {
+		removeEmptyLabels(a.Labels)
+
+		if err := validate(a); err != nil {
+			validationErrs.Add(err)
+			numInvalidAlerts.Inc()
+			continue
+		}
+		validAlerts = append(validAlerts, a)
+	}
+	if err := api.alerts.Put(validAlerts...); err != nil {
+		return nil, nil, err
+	}
+	return validAlerts, validationErrs, nil
+}
+
+func removeEmptyLabels(ls model.LabelSet) {
+	for k, v := range ls {
+		if string(v) == "" {
+			delete(ls, k)
+		}
+	}
+}
+
+// receiveSilence reads a silence from the request body and validates it
+// like prepareSilence.
+func (api *API) receiveSilence(r *http.Request, tm *labels.Matcher) (*silencepb.Silence, error) {
+	var sil types.Silence
+	if err := api.receive(r, &sil); err != nil {
+		return nil, err
+	}
+	force, err := boolParam(r, "force")
+	if err != nil {
+		return nil, err
+	}
+	return api.prepareSilence(&sil, tm, force)
+}
+
+// prepareSilence validates the silence against the silence limits, which
+// force relaxes, and converts it. The silence is restricted to the tenant
+// if the tenant matcher is not nil.
+func (api *API) prepareSilence(sil *types.Silence, tm *labels.Matcher, force bool) (*silencepb.Silence, error) {
+	if tm != nil {
+		if err := scopeSilence(sil, tm); err != nil {
+			return nil, err
+		}
+	}
+
+	// This is an API only validation, it cannot be done internally
+	// because the expired silence is semantically important.
+	// But one should not be able to create expired silences, that
+	// won't have any use.
+	if sil.Expired() {
+		return nil, errors.New("start time must not be equal to end time")
+	}
+
+	if sil.EndsAt.Before(time.Now()) {
+		return nil, errors.New("end time can't be in the past")
+	}
+
+	if err := api.checkSilenceLimits(sil, force); err != nil {
+		return nil, err
+	}
+
+	if err := api.checkSilenceMetadata(sil); err != nil {
+		return nil, err
+	}
+
+	return silenceToProto(sil)
+}
+
+func (api *API) setSilence(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	psil, err := api.receiveSilence(r, tm)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if tm != nil && psil.Id != "" && !api.silenceOfTenant(psil.Id, tm) {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: silence.ErrNotFound,
+		}, nil)
+		return
+	}
+
+	action := audit.ActionSilenceCreate
+	if psil.Id != "" {
+		action = audit.ActionSilenceUpdate
+	}
+	sid, err := api.silences.Set(psil)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	api.recordRequest(r, action, sid, psil)
+
+	api.respond(w, struct {
+		SilenceID string `json:"silenceId"`
+	}{
+		SilenceID: sid,
+	})
+}
+
+// createSilences creates the silences of the request at once: if one of
+// them is invalid, none is created.
+func (api *API) createSilences(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	var sils []types.Silence
+	if err := api.receive(r, &sils); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	force, err := boolParam(r, "force")
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	psils := make([]*silencepb.Silence, 0, len(sils))
+	for i := range sils {
+		psil, err := api.prepareSilence(&sils[i], tm, force)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: fmt.Errorf("silence %d: %s", i, err),
+			}, nil)
+			return
+		}
+		psils = append(psils, psil)
+	}
+
+	sids, err := api.silences.Create(psils...)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	for i, sid := range sids {
+		api.recordRequest(r, audit.ActionSilenceCreate, sid, psils[i])
+	}
+
+	api.respond(w, struct {
+		SilenceIDs []string `json:"silenceIds"`
+	}{
+		SilenceIDs: sids,
+	})
+}
+
+func (api *API) updateSilence(w http.ResponseWriter, r *http.Request) {
+	sid := route.Param(r.Context(), "sid")
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+
+	psil, err := api.receiveSilence(r, tm)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if psil.Id != "" && psil.Id != sid {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: fmt.Errorf("silence ID %q does not match %q", psil.Id, sid),
+		}, nil)
+		return
+	}
+	psil.Id = sid
+	if tm != nil && !api.silenceOfTenant(sid, tm) {
+		http.Error(w, fmt.Sprint("Error updating silence: ", silence.ErrNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err := api.silences.Update(psil); err != nil {
+		if err == silence.ErrNotFound {
+			http.Error(w, fmt.Sprint("Error updating silence: ", err), http.StatusNotFound)
+			return
+		}
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	api.recordRequest(r, audit.ActionSilenceUpdate, sid, psil)
+
+	api.respond(w, struct {
+		SilenceID string `json:"silenceId"`
+	}{
+		SilenceID: sid,
+	})
+}
+
+func (api *API) getSilence(w http.ResponseWriter, r *http.Request) {
+	sid := route.Param(r.Context(), "sid")
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
 
+	sils, err := api.silences.Query(silence.QIDs(sid))
+	if err != nil || len(sils) == 0 {
+		http.Error(w, fmt.Sprint("Error getting silence: ", err), http.StatusNotFound)
+		return
+	}
+	sil, err := silenceFromProto(sils[0])
 	if err != nil {
 		api.respondError(w, apiError{
 			typ: errorInternal,
@@ -403,206 +1895,278 @@ func (api *API) listAlerts(w http.ResponseWriter, r *http.Request) {
 		}, nil)
 		return
 	}
-	sort.Slice(res, func(i, j int) bool {
-		return res[i].Fingerprint < res[j].Fingerprint
-	})
-	api.respond(w, res)
-}
-
-func receiversMatchFilter(receivers []string, filter *regexp.Regexp) bool {
-	for _, r := range receivers {
-		if filter.MatchString(r) {
-			return true
-		}
+	if tm != nil && !silenceMatchesFilterLabels(sil, []*labels.Matcher{tm}) {
+		http.Error(w, fmt.Sprint("Error getting silence: ", silence.ErrNotFound), http.StatusNotFound)
+		return
+	}
+	showSuppressed, err := boolParam(r, "suppressed")
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if showSuppressed {
+		api.setSuppressedAlerts(sil)
 	}
 
-	return false
+	api.respond(w, sil)
 }
 
-func alertMatchesFilterLabels(a *model.Alert, matchers []*labels.Matcher) bool {
-	sms := make(map[string]string)
-	for name, value := range a.Labels {
-		sms[string(name)] = string(value)
+func (api *API) delSilence(w http.ResponseWriter, r *http.Request) {
+	sid := route.Param(r.Context(), "sid")
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if tm != nil && !api.silenceOfTenant(sid, tm) {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: silence.ErrNotFound,
+		}, nil)
+		return
 	}
-	return matchFilterLabels(matchers, sms)
-}
 
-func (api *API) addAlerts(w http.ResponseWriter, r *http.Request) {
-	var alerts []*types.Alert
-	if err := api.receive(r, &alerts); err != nil {
+	if err := api.silences.Expire(sid); err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
 			err: err,
 		}, nil)
 		return
 	}
-
-	api.insertAlerts(w, r, alerts...)
+	api.recordRequest(r, audit.ActionSilenceExpire, sid, nil)
+	api.respond(w, nil)
 }
 
-func (api *API) insertAlerts(w http.ResponseWriter, r *http.Request, alerts ...*types.Alert) {
-	now := time.Now()
-
-	api.mtx.RLock()
-	resolveTimeout := api.resolveTimeout
-	api.mtx.RUnlock()
-
-	for _, alert := range alerts {
-		alert.UpdatedAt = now
+func (api *API) listAcks(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if api.acks == nil {
+		api.respond(w, []*types.Acknowledgement{})
+		return
+	}
+	if tm == nil {
+		api.respond(w, api.acks.List())
+		return
+	}
 
-		// Ensure StartsAt is set.
-		if alert.StartsAt.IsZero() {
-			if alert.EndsAt.IsZero() {
-				alert.StartsAt = now
-			} else {
-				alert.StartsAt = alert.EndsAt
-			}
-		}
-		// If no end time is defined, set a timeout after which an alert
-		// is marked resolved if it is not updated.
-		if alert.EndsAt.IsZero() {
-			alert.Timeout = true
-			alert.EndsAt = now.Add(resolveTimeout)
-		}
-		if alert.EndsAt.After(time.Now()) {
-			numReceivedAlerts.WithLabelValues("firing").Inc()
-		} else {
-			numReceivedAlerts.WithLabelValues("resolved").Inc()
+	res := []*types.Acknowledgement{}
+	for _, a := range api.acks.List() {
+		fp, err := model.ParseFingerprint(a.Fingerprint)
+		if err == nil && api.alertOfTenant(fp, tm) {
+			res = append(res, a)
 		}
 	}
+	api.respond(w, res)
+}
 
-	// Make a best effort to insert all alerts that are valid.
-	var (
-		validAlerts    = make([]*types.Alert, 0, len(alerts))
-		validationErrs = &types.MultiError{}
-	)
-	for _, a := range alerts {
-		removeEmptyLabels(a.Labels)
-
-		if err := a.Validate(); err != nil {
-			validationErrs.Add(err)
-			numInvalidAlerts.Inc()
-			continue
-		}
-		validAlerts = append(validAlerts, a)
+func (api *API) setAck(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
 	}
-	if err := api.alerts.Put(validAlerts...); err != nil {
+	if api.acks == nil {
 		api.respondError(w, apiError{
 			typ: errorInternal,
-			err: err,
+			err: errors.New("acknowledgements are not enabled"),
 		}, nil)
 		return
 	}
 
-	if validationErrs.Len() > 0 {
+	var a types.Acknowledgement
+	if err := api.receive(r, &a); err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
-			err: validationErrs,
+			err: err,
 		}, nil)
 		return
 	}
-
-	api.respond(w, nil)
-}
-
-func removeEmptyLabels(ls model.LabelSet) {
-	for k, v := range ls {
-		if string(v) == "" {
-			delete(ls, k)
+	if tm != nil {
+		fp, err := model.ParseFingerprint(a.Fingerprint)
+		if err != nil || !api.alertOfTenant(fp, tm) {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: fmt.Errorf("unknown alert %q", a.Fingerprint),
+			}, nil)
+			return
 		}
 	}
-}
 
-func (api *API) setSilence(w http.ResponseWriter, r *http.Request) {
-	var sil types.Silence
-	if err := api.receive(r, &sil); err != nil {
+	if err := api.acks.Set(&a); err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
 			err: err,
 		}, nil)
 		return
 	}
+	api.recordRequest(r, audit.ActionAckCreate, a.Fingerprint, &a)
+	api.respond(w, nil)
+}
 
-	// This is an API only validation, it cannot be done internally
-	// because the expired silence is semantically important.
-	// But one should not be able to create expired silences, that
-	// won't have any use.
-	if sil.Expired() {
+func (api *API) delAck(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if api.acks == nil {
 		api.respondError(w, apiError{
-			typ: errorBadData,
-			err: errors.New("start time must not be equal to end time"),
+			typ: errorInternal,
+			err: errors.New("acknowledgements are not enabled"),
 		}, nil)
 		return
 	}
 
-	if sil.EndsAt.Before(time.Now()) {
+	fp, err := model.ParseFingerprint(route.Param(r.Context(), "fingerprint"))
+	if err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
-			err: errors.New("end time can't be in the past"),
+			err: err,
 		}, nil)
 		return
 	}
-
-	psil, err := silenceToProto(&sil)
-	if err != nil {
+	if tm != nil && !api.alertOfTenant(fp, tm) {
 		api.respondError(w, apiError{
 			typ: errorBadData,
-			err: err,
+			err: fmt.Errorf("unknown alert %q", fp),
 		}, nil)
 		return
 	}
-
-	sid, err := api.silences.Set(psil)
-	if err != nil {
+	if err := api.acks.Expire(fp); err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
 			err: err,
 		}, nil)
 		return
 	}
+	api.recordRequest(r, audit.ActionAckExpire, fp.String(), nil)
+	api.respond(w, nil)
+}
 
-	api.respond(w, struct {
-		SilenceID string `json:"silenceId"`
-	}{
-		SilenceID: sid,
-	})
+func (api *API) listPresets(w http.ResponseWriter, r *http.Request) {
+	if _, ok := api.tenant(w, r); !ok {
+		return
+	}
+	if api.presets == nil {
+		api.respond(w, []*types.FilterPreset{})
+		return
+	}
+	api.respond(w, api.presets.List())
 }
 
-func (api *API) getSilence(w http.ResponseWriter, r *http.Request) {
-	sid := route.Param(r.Context(), "sid")
+func (api *API) getPreset(w http.ResponseWriter, r *http.Request) {
+	if _, ok := api.tenant(w, r); !ok {
+		return
+	}
+	name := route.Param(r.Context(), "name")
+	if api.presets == nil {
+		http.Error(w, fmt.Sprint("Error getting preset: ", preset.ErrNotFound), http.StatusNotFound)
+		return
+	}
+	p, ok := api.presets.Get(name)
+	if !ok {
+		http.Error(w, fmt.Sprint("Error getting preset: ", preset.ErrNotFound), http.StatusNotFound)
+		return
+	}
+	api.respond(w, p)
+}
 
-	sils, err := api.silences.Query(silence.QIDs(sid))
-	if err != nil || len(sils) == 0 {
-		http.Error(w, fmt.Sprint("Error getting silence: ", err), http.StatusNotFound)
+// setPreset creates or replaces a filter preset. As presets are shared by
+// all tenants, only admin tenants may change them.
+func (api *API) setPreset(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
 		return
 	}
-	sil, err := silenceFromProto(sils[0])
-	if err != nil {
+	if api.presets == nil {
 		api.respondError(w, apiError{
 			typ: errorInternal,
-			err: err,
+			err: errors.New("filter presets are not enabled"),
 		}, nil)
 		return
 	}
 
-	api.respond(w, sil)
+	var p types.FilterPreset
+	if err := api.receive(r, &p); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	if err := api.presets.Set(&p); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	api.recordRequest(r, audit.ActionPresetSet, p.Name, &p)
+	api.respond(w, nil)
 }
 
-func (api *API) delSilence(w http.ResponseWriter, r *http.Request) {
-	sid := route.Param(r.Context(), "sid")
+func (api *API) delPreset(w http.ResponseWriter, r *http.Request) {
+	if !api.requireAdmin(w, r) {
+		return
+	}
+	if api.presets == nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: errors.New("filter presets are not enabled"),
+		}, nil)
+		return
+	}
 
-	if err := api.silences.Expire(sid); err != nil {
+	name := route.Param(r.Context(), "name")
+	if err := api.presets.Delete(name); err != nil {
 		api.respondError(w, apiError{
 			typ: errorBadData,
 			err: err,
 		}, nil)
 		return
 	}
+	api.recordRequest(r, audit.ActionPresetDelete, name, nil)
 	api.respond(w, nil)
 }
 
 func (api *API) listSilences(w http.ResponseWriter, r *http.Request) {
-	psils, err := api.silences.Query()
+	var params []silence.QueryParam
+	if state := r.FormValue("state"); state != "" {
+		var states []types.SilenceState
+		for _, s := range strings.Split(state, ",") {
+			switch st := types.SilenceState(s); st {
+			case types.SilenceStateActive, types.SilenceStatePending, types.SilenceStateExpired:
+				states = append(states, st)
+			default:
+				api.respondError(w, apiError{
+					typ: errorBadData,
+					err: fmt.Errorf("unknown silence state %q", s),
+				}, nil)
+				return
+			}
+		}
+		params = append(params, silence.QState(states...))
+	}
+
+	opts, err := parseListOptions(r, "startsAt", "endsAt", "updatedAt")
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	showSuppressed, err := boolParam(r, "suppressed")
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	psils, err := api.silences.Query(params...)
 	if err != nil {
 		api.respondError(w, apiError{
 			typ: errorInternal,
@@ -623,6 +2187,14 @@ func (api *API) listSilences(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
 	sils := []*types.Silence{}
 	for _, ps := range psils {
 		s, err := silenceFromProto(ps)
@@ -640,42 +2212,64 @@ func (api *API) listSilences(w http.ResponseWriter, r *http.Request) {
 		sils = append(sils, s)
 	}
 
-	var active, pending, expired []*types.Silence
+	silences := sortSilences(sils, opts)
+	start, end := opts.page(len(silences))
+	if showSuppressed {
+		api.setSuppressedAlerts(silences[start:end]...)
+	}
+	w.Header().Set(totalCountHeader, strconv.Itoa(len(silences)))
+	api.respond(w, silences[start:end])
+}
+
+// setSuppressedAlerts sets the unresolved alerts each silence currently
+// suppresses.
+func (api *API) setSuppressedAlerts(sils ...*types.Silence) {
+	bySilence := map[string][]string{}
 
-	for _, s := range sils {
-		switch s.Status.State {
-		case types.SilenceStateActive:
-			active = append(active, s)
-		case types.SilenceStatePending:
-			pending = append(pending, s)
-		case types.SilenceStateExpired:
-			expired = append(expired, s)
+	alerts := api.alerts.GetPending()
+	defer alerts.Close()
+
+	now := time.Now()
+	for a := range alerts.Next() {
+		if a.ResolvedAt(now) {
+			continue
+		}
+		fp := a.Fingerprint()
+		for _, id := range api.getAlertStatus(fp).SilencedBy {
+			bySilence[id] = append(bySilence[id], fp.String())
 		}
 	}
 
-	sort.Slice(active, func(i int, j int) bool {
-		return active[i].EndsAt.Before(active[j].EndsAt)
-	})
-	sort.Slice(pending, func(i int, j int) bool {
-		return pending[i].StartsAt.Before(pending[j].EndsAt)
-	})
-	sort.Slice(expired, func(i int, j int) bool {
-		return expired[i].EndsAt.After(expired[j].EndsAt)
-	})
-
-	// Initialize silences explicitly to an empty list (instead of nil)
-	// So that it does not get converted to "null" in JSON.
-	silences := []*types.Silence{}
-	silences = append(silences, active...)
-	silences = append(silences, pending...)
-	silences = append(silences, expired...)
+	for _, s := range sils {
+		fps := bySilence[s.ID]
+		if fps == nil {
+			fps = []string{}
+		}
+		sort.Strings(fps)
+		s.Status.Suppressed = &types.SuppressedAlerts{Count: len(fps), Fingerprints: fps}
+	}
+}
 
-	api.respond(w, silences)
+// boolParam returns the value of the optional boolean query parameter,
+// which defaults to false.
+func boolParam(r *http.Request, name string) (bool, error) {
+	switch v := r.FormValue(name); v {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("parameter %q can either be 'true' or 'false', not %q", name, v)
+	}
 }
 
 func silenceMatchesFilterLabels(s *types.Silence, matchers []*labels.Matcher) bool {
 	sms := make(map[string]string)
 	for _, m := range s.Matchers {
+		// Negative matchers do not restrict the silence to the value.
+		if m.IsNegative {
+			continue
+		}
 		sms[m.Name] = m.Value
 	}
 
@@ -708,21 +2302,37 @@ func matchFilterLabels(matchers []*labels.Matcher, sms map[string]string) bool {
 
 func silenceToProto(s *types.Silence) (*silencepb.Silence, error) {
 	sil := &silencepb.Silence{
-		Id:        s.ID,
-		StartsAt:  s.StartsAt,
-		EndsAt:    s.EndsAt,
-		UpdatedAt: s.UpdatedAt,
-		Comment:   s.Comment,
-		CreatedBy: s.CreatedBy,
+		Id:         s.ID,
+		StartsAt:   s.StartsAt,
+		EndsAt:     s.EndsAt,
+		UpdatedAt:  s.UpdatedAt,
+		Comment:    s.Comment,
+		CreatedBy:  s.CreatedBy,
+		ExternalId: s.ExternalID,
+		Metadata:   s.Metadata,
+	}
+	if s.Renewal != nil {
+		inc, err := model.ParseDuration(s.Renewal.Increment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renewal increment: %s", err)
+		}
+		increment, until := time.Duration(inc), s.Renewal.Until
+		sil.RenewIncrement, sil.RenewUntil = &increment, &until
 	}
 	for _, m := range s.Matchers {
 		matcher := &silencepb.Matcher{
 			Name:    m.Name,
 			Pattern: m.Value,
-			Type:    silencepb.Matcher_EQUAL,
 		}
-		if m.IsRegex {
+		switch {
+		case m.IsRegex && m.IsNegative:
+			matcher.Type = silencepb.Matcher_NOT_REGEXP
+		case m.IsRegex:
 			matcher.Type = silencepb.Matcher_REGEXP
+		case m.IsNegative:
+			matcher.Type = silencepb.Matcher_NOT_EQUAL
+		default:
+			matcher.Type = silencepb.Matcher_EQUAL
 		}
 		sil.Matchers = append(sil.Matchers, matcher)
 	}
@@ -738,8 +2348,24 @@ func silenceFromProto(s *silencepb.Silence) (*types.Silence, error) {
 		Status: types.SilenceStatus{
 			State: types.CalcSilenceState(s.StartsAt, s.EndsAt),
 		},
-		Comment:   s.Comment,
-		CreatedBy: s.CreatedBy,
+		Comment:    s.Comment,
+		CreatedBy:  s.CreatedBy,
+		ExternalID: s.ExternalId,
+		Metadata:   s.Metadata,
+		Version:    s.Version,
+	}
+	if s.RenewIncrement != nil && s.RenewUntil != nil {
+		sil.Renewal = &types.SilenceRenewal{
+			Increment: model.Duration(*s.RenewIncrement).String(),
+			Until:     *s.RenewUntil,
+		}
+	}
+	for _, h := range s.History {
+		prev, err := silenceFromProto(h)
+		if err != nil {
+			return nil, err
+		}
+		sil.History = append(sil.History, prev)
 	}
 	for _, m := range s.Matchers {
 		matcher := &types.Matcher{
@@ -750,6 +2376,11 @@ func silenceFromProto(s *silencepb.Silence) (*types.Silence, error) {
 		case silencepb.Matcher_EQUAL:
 		case silencepb.Matcher_REGEXP:
 			matcher.IsRegex = true
+		case silencepb.Matcher_NOT_EQUAL:
+			matcher.IsNegative = true
+		case silencepb.Matcher_NOT_REGEXP:
+			matcher.IsRegex = true
+			matcher.IsNegative = true
 		default:
 			return nil, fmt.Errorf("unknown matcher type")
 		}
@@ -773,6 +2404,28 @@ type response struct {
 	Error     string      `json:"error,omitempty"`
 }
 
+// recordRequest records a mutating operation performed by the request.
+func (api *API) recordRequest(r *http.Request, action, target string, payload interface{}) {
+	api.record(&audit.Event{
+		Action:     action,
+		Actor:      audit.RequestActor(r),
+		RemoteAddr: r.RemoteAddr,
+		Target:     target,
+		Payload:    payload,
+	})
+}
+
+// record records a mutating operation if auditing is enabled.
+func (api *API) record(e *audit.Event) {
+	if api.auditor == nil {
+		return
+	}
+	e.Time = time.Now()
+	if err := api.auditor.Log(e); err != nil {
+		level.Error(api.logger).Log("msg", "Recording audit event failed", "action", e.Action, "err", err)
+	}
+}
+
 func (api *API) respond(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(200)
@@ -796,6 +2449,12 @@ func (api *API) respondError(w http.ResponseWriter, apiErr apiError, data interf
 		w.WriteHeader(http.StatusBadRequest)
 	case errorInternal:
 		w.WriteHeader(http.StatusInternalServerError)
+	case errorUnauthorized:
+		w.WriteHeader(http.StatusUnauthorized)
+	case errorForbidden:
+		w.WriteHeader(http.StatusForbidden)
+	case errorTooLarge:
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
 	default:
 		panic(fmt.Sprintf("unknown error type %q", apiErr.Error()))
 	}
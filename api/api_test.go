@@ -15,23 +15,49 @@ package api
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/alertmanager/ack"
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/delivery"
 	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/ingest/validate"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/preset"
 	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // fakeAlerts is a struct implementing the provider.Alerts interface for tests.
@@ -61,6 +87,9 @@ func (f *fakeAlerts) Get(model.Fingerprint) (*types.Alert, error) { return nil,
 func (f *fakeAlerts) Put(alerts ...*types.Alert) error {
 	return f.err
 }
+func (f *fakeAlerts) Resolve(time.Time, ...model.Fingerprint) ([]*types.Alert, error) {
+	return nil, f.err
+}
 func (f *fakeAlerts) GetPending() provider.AlertIterator {
 	ch := make(chan *types.Alert)
 	done := make(chan struct{})
@@ -133,7 +162,11 @@ func TestAddAlerts(t *testing.T) {
 		}
 
 		alertsProvider := newFakeAlerts([]*types.Alert{}, tc.err)
-		api := New(alertsProvider, nil, groupAlerts, newGetAlertStatus(alertsProvider), nil, nil)
+		api := New(Options{
+			Alerts:      alertsProvider,
+			Groups:      groupAlerts,
+			AlertStatus: newGetAlertStatus(alertsProvider),
+		})
 
 		r, err := http.NewRequest("POST", "/api/v1/alerts", bytes.NewReader(b))
 		w := httptest.NewRecorder()
@@ -149,6 +182,222 @@ func TestAddAlerts(t *testing.T) {
 	}
 }
 
+func TestAddAlertsValidation(t *testing.T) {
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+alert_validation:
+  required_labels: [severity]
+`)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+	validator := validate.New(alerts, nil, nil, nil)
+	validator.ApplyConfig(conf)
+	api := New(Options{
+		Alerts: validator,
+	})
+
+	valid := model.LabelSet{"alertname": "a", "severity": "page"}
+	rejected := model.LabelSet{"alertname": "b"}
+	b, err := json.Marshal([]model.Alert{{Labels: valid}, {Labels: rejected}})
+	require.NoError(t, err)
+
+	r, err := http.NewRequest("POST", "/api/v1/alerts", bytes.NewReader(b))
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	api.addAlerts(w, r)
+	body, _ := ioutil.ReadAll(w.Result().Body)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, string(body), `missing required label \"severity\"`)
+	_, err = alerts.Get(valid.Fingerprint())
+	require.NoError(t, err)
+	_, err = alerts.Get(rejected.Fingerprint())
+	require.Equal(t, provider.ErrNotFound, err)
+}
+
+func TestAddAlertsEncoding(t *testing.T) {
+	b, err := json.Marshal([]model.Alert{{Labels: model.LabelSet{"alertname": "a"}}})
+	require.NoError(t, err)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err = gw.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for _, tc := range []struct {
+		encoding string
+		body     []byte
+		code     int
+	}{
+		{"", b, http.StatusOK},
+		{"identity", b, http.StatusOK},
+		{"gzip", gz.Bytes(), http.StatusOK},
+		{"snappy", snappy.Encode(nil, b), http.StatusOK},
+		{"gzip", b, http.StatusBadRequest},
+		{"snappy", b, http.StatusBadRequest},
+		{"br", b, http.StatusBadRequest},
+	} {
+		api := New(Options{
+			Alerts: newFakeAlerts(nil, false),
+		})
+
+		r, err := http.NewRequest("POST", "/api/v1/alerts", bytes.NewReader(tc.body))
+		require.NoError(t, err)
+		r.Header.Set("Content-Encoding", tc.encoding)
+		w := httptest.NewRecorder()
+
+		api.addAlerts(w, r)
+		require.Equal(t, tc.code, w.Code, "encoding %q: %s", tc.encoding, w.Body.String())
+	}
+}
+
+func TestAddAlertsLimits(t *testing.T) {
+	alerts := []model.Alert{
+		{Labels: model.LabelSet{"alertname": "a"}},
+		{Labels: model.LabelSet{"alertname": "b"}},
+		{Labels: model.LabelSet{"alertname": "c"}},
+	}
+	b, err := json.Marshal(alerts)
+	require.NoError(t, err)
+	size := int64(len(b))
+
+	for _, tc := range []struct {
+		limits   config.AlertIngestionConfig
+		encoding string
+		body     []byte
+		code     int
+	}{
+		{config.AlertIngestionConfig{}, "", b, http.StatusOK},
+		{config.AlertIngestionConfig{MaxBatchSize: 3}, "", b, http.StatusOK},
+		{config.AlertIngestionConfig{MaxBatchSize: 2}, "", b, http.StatusRequestEntityTooLarge},
+		{config.AlertIngestionConfig{MaxBodyBytes: size}, "", b, http.StatusOK},
+		{config.AlertIngestionConfig{MaxBodyBytes: size - 1}, "", b, http.StatusRequestEntityTooLarge},
+		{config.AlertIngestionConfig{MaxBodyBytes: size}, "snappy", snappy.Encode(nil, b), http.StatusOK},
+		{config.AlertIngestionConfig{MaxBodyBytes: size - 1}, "snappy", snappy.Encode(nil, b), http.StatusRequestEntityTooLarge},
+	} {
+		api := New(Options{
+			Alerts: newFakeAlerts(nil, false),
+		})
+		api.config = &config.Config{AlertIngestion: &tc.limits}
+
+		r, err := http.NewRequest("POST", "/api/v1/alerts", bytes.NewReader(tc.body))
+		require.NoError(t, err)
+		r.Header.Set("Content-Encoding", tc.encoding)
+		w := httptest.NewRecorder()
+
+		api.addAlerts(w, r)
+		require.Equal(t, tc.code, w.Code, "limits %+v: %s", tc.limits, w.Body.String())
+		if tc.code == http.StatusRequestEntityTooLarge {
+			require.Contains(t, w.Body.String(), `"errorType":"too_large"`)
+		}
+	}
+}
+
+func TestDecodeBodyDefaultLimit(t *testing.T) {
+	// Compressed bodies are limited even without a configured limit.
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(make([]byte, defaultMaxDecodedBytes+1))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	r, err := decodeBody(encodingGzip, &gz, 0)
+	require.NoError(t, err)
+	_, err = io.Copy(ioutil.Discard, r)
+	require.IsType(t, &limitError{}, err)
+
+	// Snappy blocks are rejected by their decoded length before they are
+	// decoded.
+	block := make([]byte, binary.MaxVarintLen64)
+	block = block[:binary.PutUvarint(block, defaultMaxDecodedBytes+1)]
+	_, err = decodeBody(encodingSnappy, bytes.NewReader(block), 0)
+	require.IsType(t, &limitError{}, err)
+
+	r, err = decodeBody(encodingIdentity, bytes.NewReader(make([]byte, defaultMaxDecodedBytes+1)), 0)
+	require.NoError(t, err)
+	_, err = io.Copy(ioutil.Discard, r)
+	require.NoError(t, err)
+}
+
+func TestResolveAlerts(t *testing.T) {
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	newAlert := func(name string) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": model.LabelValue(name)},
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   now.Add(time.Hour),
+			},
+			UpdatedAt: now,
+		}
+	}
+	a1, a2, a3 := newAlert("a"), newAlert("b"), newAlert("c")
+	require.NoError(t, alerts.Put(a1, a2, a3))
+
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:  alerts,
+		Groups:  groupAlerts,
+		Auditor: auditor,
+	})
+
+	do := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/v1/alerts/resolve", strings.NewReader(body))
+		r.SetBasicAuth("alice", "secret")
+		w := httptest.NewRecorder()
+		api.resolveAlerts(w, r)
+		return w
+	}
+	resolved := func(w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var res struct {
+			Data struct {
+				Fingerprints []string `json:"fingerprints"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		return res.Data.Fingerprints
+	}
+
+	w := do(`{"matchers":[{"name":"alertname","value":"a"}]}`)
+	require.Equal(t, []string{a1.Fingerprint().String()}, resolved(w))
+	a, err := alerts.Get(a1.Fingerprint())
+	require.NoError(t, err)
+	require.True(t, a.Resolved())
+
+	// Resolved alerts are not resolved again.
+	w = do(fmt.Sprintf(`{"fingerprints":["%s","%s"]}`, a1.Fingerprint(), a2.Fingerprint()))
+	require.Equal(t, []string{a2.Fingerprint().String()}, resolved(w))
+
+	require.Len(t, auditor.events, 2)
+	for _, e := range auditor.events {
+		require.Equal(t, audit.ActionAlertsResolve, e.Action)
+		require.Equal(t, "alice", e.Actor)
+	}
+
+	for _, body := range []string{
+		`{}`,
+		`{"fingerprints":["invalid"]}`,
+		`{"matchers":[{"name":"alertname","value":"c","isNegative":true}]}`,
+	} {
+		w = do(body)
+		require.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	a, err = alerts.Get(a3.Fingerprint())
+	require.NoError(t, err)
+	require.False(t, a.Resolved())
+}
+
 func TestListAlerts(t *testing.T) {
 	now := time.Now()
 	alerts := []*types.Alert{
@@ -260,7 +509,11 @@ func TestListAlerts(t *testing.T) {
 		},
 	} {
 		alertsProvider := newFakeAlerts(alerts, tc.err)
-		api := New(alertsProvider, nil, groupAlerts, newGetAlertStatus(alertsProvider), nil, nil)
+		api := New(Options{
+			Alerts:      alertsProvider,
+			Groups:      groupAlerts,
+			AlertStatus: newGetAlertStatus(alertsProvider),
+		})
 		api.route = dispatch.NewRoute(&config.Route{Receiver: "def-receiver"}, nil)
 
 		r, err := http.NewRequest("GET", "/api/v1/alerts", nil)
@@ -310,6 +563,746 @@ func TestListAlerts(t *testing.T) {
 	}
 }
 
+func TestListAlertsPagination(t *testing.T) {
+	now := time.Now()
+	var alerts []*types.Alert
+	for i, name := range []string{"alert1", "alert2", "alert3"} {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": model.LabelValue(name)},
+				StartsAt: now.Add(time.Duration(i-5) * time.Minute),
+			},
+		})
+	}
+	// alert3 was notified last, alert1 was never notified successfully.
+	attempts := []*nflog.Attempt{
+		{Timestamp: now, FiringAlerts: []uint64{uint64(alerts[2].Fingerprint())}},
+		{Timestamp: now.Add(-time.Minute), FiringAlerts: []uint64{uint64(alerts[0].Fingerprint())}, Error: "timeout"},
+		{Timestamp: now.Add(-2 * time.Minute), FiringAlerts: []uint64{uint64(alerts[1].Fingerprint()), uint64(alerts[2].Fingerprint())}},
+	}
+	alertsProvider := newFakeAlerts(alerts, false)
+	api := New(Options{
+		Alerts:      alertsProvider,
+		Groups:      groupAlerts,
+		AlertStatus: newGetAlertStatus(alertsProvider),
+		History: func(string) []*nflog.Attempt {
+			return attempts
+		},
+	})
+	api.route = dispatch.NewRoute(&config.Route{Receiver: "def-receiver"}, nil)
+
+	for _, tc := range []struct {
+		query  string
+		code   int
+		anames []string
+	}{
+		{query: "sort=startsAt", code: 200, anames: []string{"alert1", "alert2", "alert3"}},
+		{query: "sort=-startsAt&limit=2", code: 200, anames: []string{"alert3", "alert2"}},
+		{query: "sort=startsAt&limit=2&offset=2", code: 200, anames: []string{"alert3"}},
+		{query: "sort=lastNotified", code: 200, anames: []string{"alert2", "alert3", "alert1"}},
+		{query: "sort=-lastNotified", code: 200, anames: []string{"alert3", "alert2", "alert1"}},
+		{query: "sort=endsAt", code: 400},
+		{query: "offset=x", code: 400},
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/alerts?"+tc.query, nil)
+		w := httptest.NewRecorder()
+		api.listAlerts(w, r)
+		require.Equal(t, tc.code, w.Code, tc.query)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []*dispatch.APIAlert `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		var anames []string
+		for _, a := range res.Data {
+			anames = append(anames, string(a.Labels["alertname"]))
+		}
+		require.Equal(t, tc.anames, anames, tc.query)
+		require.Equal(t, "3", w.Header().Get(totalCountHeader), tc.query)
+	}
+}
+
+func TestAggregationGroups(t *testing.T) {
+	aggrGroups := func([]*labels.Matcher) []*dispatch.AggregationGroup {
+		return []*dispatch.AggregationGroup{
+			{Labels: model.LabelSet{"dc": "eu"}, Receiver: "team-X"},
+			{Labels: model.LabelSet{"dc": "eu"}, Receiver: "team-Y"},
+		}
+	}
+	api := New(Options{
+		Alerts:     newFakeAlerts([]*types.Alert{}, false),
+		Groups:     groupAlerts,
+		AggrGroups: aggrGroups,
+	})
+
+	for i, tc := range []struct {
+		params    map[string]string
+		code      int
+		receivers []string
+	}{
+		{map[string]string{}, 200, []string{"team-X", "team-Y"}},
+		{map[string]string{"receiver": "team-Y"}, 200, []string{"team-Y"}},
+		{map[string]string{"receiver": "team-("}, 400, nil},
+		{map[string]string{"filter": "{dc"}, 400, nil},
+	} {
+		r, err := http.NewRequest("GET", "/api/v2/alerts/groups", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		q := r.URL.Query()
+		for k, v := range tc.params {
+			q.Add(k, v)
+		}
+		r.URL.RawQuery = q.Encode()
+		w := httptest.NewRecorder()
+
+		api.aggregationGroups(w, r)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		require.Equal(t, tc.code, w.Code, fmt.Sprintf("test case: %d, response: %s", i, string(body)))
+		if w.Code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []*dispatch.AggregationGroup `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &res))
+		var receivers []string
+		for _, g := range res.Data {
+			receivers = append(receivers, g.Receiver)
+		}
+		require.Equal(t, tc.receivers, receivers, fmt.Sprintf("test case: %d", i))
+	}
+}
+
+func TestGroupTimers(t *testing.T) {
+	var (
+		now       = time.Now()
+		lastFlush = now.Add(-time.Minute)
+		nextFlush = now.Add(4 * time.Minute)
+		opts      = &dispatch.RouteOpts{
+			GroupWait:      30 * time.Second,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: time.Hour,
+		}
+	)
+	aggrGroups := func([]*labels.Matcher) []*dispatch.AggregationGroup {
+		return []*dispatch.AggregationGroup{
+			{
+				GroupKey:  "{}:{dc=\"eu\"}",
+				Receiver:  "team-X",
+				RouteOpts: opts,
+				LastFlush: lastFlush,
+				NextFlush: nextFlush,
+				Alerts:    []*dispatch.APIAlert{{Fingerprint: "0000000000000001"}},
+			},
+			{
+				GroupKey:  "{}:{dc=\"us\"}",
+				Receiver:  "team-X",
+				RouteOpts: opts,
+				NextFlush: now.Add(-time.Second),
+			},
+		}
+	}
+	history := func(gkey string) []*nflog.Attempt {
+		if gkey != "{}:{dc=\"eu\"}" {
+			return nil
+		}
+		return []*nflog.Attempt{
+			{GroupKey: gkey, Receiver: &nflogpb.Receiver{GroupName: "team-X"}, Timestamp: lastFlush, Error: "timeout"},
+			{GroupKey: gkey, Receiver: &nflogpb.Receiver{GroupName: "team-X"}, Timestamp: lastFlush.Add(-50 * time.Minute)},
+		}
+	}
+	api := New(Options{
+		Alerts:     newFakeAlerts([]*types.Alert{}, false),
+		Groups:     groupAlerts,
+		AggrGroups: aggrGroups,
+		History:    history,
+	})
+
+	r, err := http.NewRequest("GET", "/api/v1/alerts/groups/timers", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	api.groupTimers(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res struct {
+		Data []*groupTimers `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Data, 2)
+
+	eu := res.Data[0]
+	require.Equal(t, []string{"0000000000000001"}, eu.Fingerprints)
+	require.Equal(t, 5*time.Minute, eu.GroupInterval)
+	require.Equal(t, time.Hour, eu.RepeatInterval)
+	require.True(t, eu.LastFlush.Equal(lastFlush))
+	require.True(t, eu.NextFlush.Equal(nextFlush))
+	require.True(t, eu.NextFlushIn > 3*time.Minute && eu.NextFlushIn <= 4*time.Minute, eu.NextFlushIn.String())
+	// The failed attempt does not count as notified. The repeat interval
+	// passes 9 minutes after the next flush, which is at the second flush
+	// after it.
+	require.True(t, eu.LastNotified.Equal(lastFlush.Add(-50*time.Minute)))
+	require.True(t, eu.NextRepeat.Equal(nextFlush.Add(10*time.Minute)), eu.NextRepeat.String())
+	require.True(t, eu.NextRepeatIn > 13*time.Minute && eu.NextRepeatIn <= 14*time.Minute, eu.NextRepeatIn.String())
+
+	us := res.Data[1]
+	require.Nil(t, us.LastFlush)
+	require.Equal(t, time.Duration(0), us.NextFlushIn)
+	require.Nil(t, us.LastNotified)
+	require.Nil(t, us.NextRepeat)
+}
+
+func TestNextRepeat(t *testing.T) {
+	var (
+		last     = time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		interval = 5 * time.Minute
+		repeat   = time.Hour
+	)
+	for _, tc := range []struct {
+		nextFlush time.Time
+		exp       time.Time
+	}{
+		// The next flush is after the repeat interval passed.
+		{nextFlush: last.Add(61 * time.Minute), exp: last.Add(61 * time.Minute)},
+		// The alerts are notified again only after the repeat interval.
+		{nextFlush: last.Add(time.Hour), exp: last.Add(65 * time.Minute)},
+		{nextFlush: last.Add(5 * time.Minute), exp: last.Add(65 * time.Minute)},
+		{nextFlush: last.Add(7 * time.Minute), exp: last.Add(62 * time.Minute)},
+	} {
+		got := nextRepeat(last, tc.nextFlush, interval, repeat)
+		require.True(t, tc.exp.Equal(got), "next flush %s: expected %s, got %s", tc.nextFlush, tc.exp, got)
+	}
+}
+
+func TestClusterStatusDisabled(t *testing.T) {
+	api := New(Options{
+		Alerts: newFakeAlerts([]*types.Alert{}, false),
+		Groups: groupAlerts,
+	})
+
+	r, err := http.NewRequest("GET", "/api/v1/cluster/status", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+
+	api.clusterStatus(w, r)
+	require.Equal(t, 200, w.Code)
+
+	var res struct {
+		Data clusterStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, "disabled", res.Data.Status)
+	require.Equal(t, []peerStatus{}, res.Data.Peers)
+}
+
+func TestPromoteDemote(t *testing.T) {
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:  newFakeAlerts([]*types.Alert{}, false),
+		Groups:  groupAlerts,
+		Auditor: auditor,
+	})
+
+	// Without clustering there is nothing to promote.
+	w := httptest.NewRecorder()
+	api.promote(w, httptest.NewRequest("POST", "/api/v1/cluster/promote", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	peer, err := cluster.Join(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1:0", "", "", nil, false,
+		cluster.DefaultPushPullInterval, cluster.DefaultGossipInterval, cluster.DefaultTcpTimeout,
+		cluster.DefaultProbeTimeout, cluster.DefaultProbeInterval, nil, nil, nil, nil, true)
+	require.NoError(t, err)
+	defer peer.Leave(0)
+	api.peer = peer
+
+	do := func(h http.HandlerFunc, path string) clusterStatus {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", path, nil)
+		r.SetBasicAuth("alice", "secret")
+		h(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res struct {
+			Data clusterStatus `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Data
+	}
+
+	s := do(api.promote, "/api/v1/cluster/promote")
+	require.False(t, s.Standby)
+	require.False(t, peer.Standby())
+
+	s = do(api.demote, "/api/v1/cluster/demote")
+	require.True(t, s.Standby)
+	require.True(t, s.Peers[0].Standby)
+	require.True(t, peer.Standby())
+
+	require.Equal(t, 2, len(auditor.events))
+	for i, action := range []string{audit.ActionClusterPromote, audit.ActionClusterDemote} {
+		require.Equal(t, action, auditor.events[i].Action)
+		require.Equal(t, "alice", auditor.events[i].Actor)
+		require.Equal(t, peer.Name(), auditor.events[i].Target)
+	}
+}
+
+func TestAlertHistory(t *testing.T) {
+	now := time.Now()
+	attempts := []*nflog.Attempt{
+		{
+			GroupKey:     "{}:{a=\"1\"}",
+			Receiver:     &nflogpb.Receiver{GroupName: "team", Integration: "webhook"},
+			Timestamp:    now,
+			FiringAlerts: []uint64{1, 2},
+			Error:        "connection refused",
+		},
+		{
+			GroupKey:       "{}:{a=\"2\"}",
+			Receiver:       &nflogpb.Receiver{GroupName: "team", Integration: "email"},
+			Timestamp:      now.Add(-time.Minute),
+			ResolvedAlerts: []uint64{2},
+		},
+	}
+	api := New(Options{
+		Alerts: newFakeAlerts([]*types.Alert{}, false),
+		Groups: groupAlerts,
+		History: func(gkey string) []*nflog.Attempt {
+			var res []*nflog.Attempt
+			for _, a := range attempts {
+				if gkey == "" || a.GroupKey == gkey {
+					res = append(res, a)
+				}
+			}
+			return res
+		},
+	})
+
+	for _, tc := range []struct {
+		query        string
+		code         int
+		integrations []string
+	}{
+		{query: "", code: 400},
+		{query: "fingerprint=xyz", code: 400},
+		{query: "fingerprint=0000000000000002", code: 200, integrations: []string{"webhook", "email"}},
+		{query: "fingerprint=0000000000000001", code: 200, integrations: []string{"webhook"}},
+		{query: "fingerprint=0000000000000003", code: 200},
+		{query: "groupKey=" + url.QueryEscape("{}:{a=\"2\"}"), code: 200, integrations: []string{"email"}},
+		{query: "start=yesterday", code: 400},
+		{query: "start=" + url.QueryEscape(now.Format(time.RFC3339)) + "&end=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)), code: 400},
+		{query: "start=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)), code: 200, integrations: []string{"webhook", "email"}},
+		{query: "end=" + url.QueryEscape(now.Add(-30*time.Second).Format(time.RFC3339)), code: 200, integrations: []string{"email"}},
+		{query: "fingerprint=0000000000000001&end=" + url.QueryEscape(now.Add(-30*time.Second).Format(time.RFC3339)), code: 200},
+	} {
+		r, err := http.NewRequest("GET", "/api/v1/alerts/history?"+tc.query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+
+		api.alertHistory(w, r)
+		require.Equal(t, tc.code, w.Code, tc.query)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []notificationAttempt `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		var integrations []string
+		for _, a := range res.Data {
+			integrations = append(integrations, a.Integration)
+		}
+		require.Equal(t, tc.integrations, integrations, tc.query)
+	}
+}
+
+func TestListInhibitions(t *testing.T) {
+	now := time.Now()
+	newAlert := func(lset model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: lset, StartsAt: now}}
+	}
+	var (
+		node    = newAlert(model.LabelSet{"alertname": "NodeDown", "instance": "node0"})
+		service = newAlert(model.LabelSet{"alertname": "ServiceDown", "instance": "node0"})
+		disk    = newAlert(model.LabelSet{"alertname": "DiskFull", "instance": "node0"})
+		rules   = []*config.InhibitRule{
+			{SourceMatch: map[string]string{"alertname": "NodeDown"}, Equal: model.LabelNames{"instance"}},
+			{SourceMatch: map[string]string{"alertname": "ServiceDown"}, Equal: model.LabelNames{"instance"}},
+		}
+	)
+	inhibitions := []*inhibit.Inhibition{
+		{Target: service, Source: node, Rule: rules[0], RuleIndex: 0},
+		{Target: disk, Source: service, Rule: rules[1], RuleIndex: 1},
+		{Target: disk, Source: node, Rule: rules[0], RuleIndex: 0},
+	}
+	api := New(Options{
+		Alerts: newFakeAlerts([]*types.Alert{}, false),
+		Groups: groupAlerts,
+		Inhibitions: func() []*inhibit.Inhibition {
+			return inhibitions
+		},
+	})
+
+	for _, tc := range []struct {
+		query       string
+		code        int
+		inhibitions []string
+	}{
+		{query: "filter=" + url.QueryEscape("{alertname=~"), code: 400},
+		{
+			code:        200,
+			inhibitions: []string{"DiskFull<-NodeDown", "DiskFull<-ServiceDown", "ServiceDown<-NodeDown"},
+		},
+		{
+			query:       "filter=" + url.QueryEscape("{alertname=\"ServiceDown\"}"),
+			code:        200,
+			inhibitions: []string{"ServiceDown<-NodeDown"},
+		},
+	} {
+		r, err := http.NewRequest("GET", "/api/v1/alerts/inhibitions?"+tc.query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+
+		api.listInhibitions(w, r)
+		require.Equal(t, tc.code, w.Code, tc.query)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []inhibition `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		var inhibitions []string
+		for _, inh := range res.Data {
+			inhibitions = append(inhibitions, fmt.Sprintf("%s<-%s", inh.Labels["alertname"], inh.InhibitedBy.Labels["alertname"]))
+			require.Equal(t, rules[inh.RuleIndex].SourceMatch, inh.Rule.SourceMatch, tc.query)
+		}
+		sort.Strings(inhibitions)
+		require.Equal(t, tc.inhibitions, inhibitions, tc.query)
+	}
+}
+
+func TestListSuppressions(t *testing.T) {
+	sup := notify.NewSuppressions()
+	stage := notify.NewTimeMuteStage([]*config.MuteTimeInterval{{
+		Name:          "always",
+		TimeIntervals: []config.TimeInterval{{}},
+	}}, sup)
+	ctx := notify.WithMuteTimeIntervals(notify.WithNow(context.Background(), time.Now()), []string{"always"})
+	_, _, err := stage.Exec(ctx, log.NewNopLogger(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"a": "b"}}})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:       newFakeAlerts([]*types.Alert{}, false),
+		Groups:       groupAlerts,
+		Suppressions: sup,
+	})
+
+	r, err := http.NewRequest("GET", "/api/v1/suppressions", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	api.listSuppressions(w, r)
+	require.Equal(t, 200, w.Code)
+
+	var res struct {
+		Data notify.SuppressionCounts `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, map[string]uint64{"always": 1}, res.Data.MuteTimeIntervals)
+	require.Empty(t, res.Data.Silences)
+	require.Empty(t, res.Data.InhibitRules)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	api := New(Options{
+		Alerts: newFakeAlerts([]*types.Alert{}, false),
+		Groups: groupAlerts,
+	})
+	api.tmpl = tmpl
+
+	for _, tc := range []struct {
+		body     string
+		code     int
+		rendered string
+	}{
+		{
+			body:     `{"name": "slack.default.title", "groupLabels": {"alertname": "Test"}, "alerts": [{"labels": {"alertname": "Test", "job": "node"}}]}`,
+			code:     200,
+			rendered: "[FIRING:1] Test (node)",
+		},
+		{
+			body: `{"name": "missing", "alerts": [{"labels": {"alertname": "Test"}}]}`,
+			code: 400,
+		},
+		{
+			body: `{"alerts": []}`,
+			code: 400,
+		},
+	} {
+		r, err := http.NewRequest("POST", "/api/v1/templates/render", bytes.NewBufferString(tc.body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+
+		api.renderTemplate(w, r)
+		require.Equal(t, tc.code, w.Code, tc.body)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data struct {
+				Rendered string `json:"rendered"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, tc.rendered, res.Data.Rendered)
+	}
+}
+
+func TestTestReceiver(t *testing.T) {
+	var statuses []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Status string `json:"status"`
+			Alerts []struct {
+				Labels model.LabelSet `json:"labels"`
+			} `json:"alerts"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		require.Equal(t, model.LabelValue(receiverTestAlertName), msg.Alerts[0].Labels["alertname"])
+		require.Equal(t, model.LabelValue("db"), msg.Alerts[0].Labels["team"])
+		statuses = append(statuses, msg.Status)
+	}))
+	defer srv.Close()
+
+	conf, err := config.Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  webhook_configs:
+  - url: ` + srv.URL + `
+    send_resolved: true
+`)
+	require.NoError(t, err)
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:  newFakeAlerts([]*types.Alert{}, false),
+		Groups:  groupAlerts,
+		Auditor: auditor,
+	})
+	require.NoError(t, api.Update(conf, tmpl, 0))
+
+	for _, tc := range []struct {
+		body     string
+		code     int
+		statuses []string
+	}{
+		{
+			body:     `{"receiver": "team-X", "labels": {"team": "db"}}`,
+			code:     200,
+			statuses: []string{"firing"},
+		},
+		{
+			body:     `{"receiver": "team-X", "labels": {"team": "db"}, "resolve": true}`,
+			code:     200,
+			statuses: []string{"firing", "resolved"},
+		},
+		{
+			body: `{"receiver": "team-Y"}`,
+			code: 400,
+		},
+		{
+			body: `{"receiver": "team-X", "labels": {"0team": "db"}}`,
+			code: 400,
+		},
+	} {
+		statuses = nil
+		r, err := http.NewRequest("POST", "/api/v1/receivers/test", bytes.NewBufferString(tc.body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+
+		api.testReceiver(w, r)
+		require.Equal(t, tc.code, w.Code, tc.body)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []integrationTestResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Data, len(tc.statuses), tc.body)
+		for i, ir := range res.Data {
+			require.Equal(t, "webhook", ir.Integration)
+			require.Equal(t, tc.statuses[i], ir.Status)
+			require.Empty(t, ir.Error)
+		}
+		require.Equal(t, tc.statuses, statuses, tc.body)
+	}
+	require.Len(t, auditor.events, 2)
+	require.Equal(t, audit.ActionReceiverTest, auditor.events[0].Action)
+	require.Equal(t, "team-X", auditor.events[0].Target)
+}
+
+func TestReceivers(t *testing.T) {
+	conf, err := config.Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  webhook_configs:
+  - url: http://example.com/a
+  - url: http://example.com/b
+- name: team-Y
+`)
+	require.NoError(t, err)
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	failingSince := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	lastAttempt := failingSince.Add(2 * time.Hour)
+	integrations := func(receiver, integration string, idx int) (delivery.IntegrationStatus, bool) {
+		if receiver != "team-X" || integration != "webhook" || idx != 1 {
+			return delivery.IntegrationStatus{}, false
+		}
+		return delivery.IntegrationStatus{
+			LastAttempt:  lastAttempt,
+			LastError:    "unexpected status code 401",
+			LastErrorAt:  lastAttempt,
+			FailingSince: failingSince,
+		}, true
+	}
+	api := New(Options{
+		Alerts:            newFakeAlerts([]*types.Alert{}, false),
+		Groups:            groupAlerts,
+		IntegrationStatus: integrations,
+	})
+	require.NoError(t, api.Update(conf, tmpl, 0))
+
+	r, err := http.NewRequest("GET", "/api/v1/receivers", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	api.receivers(w, r)
+	require.Equal(t, 200, w.Code)
+	var names struct {
+		Data []string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &names))
+	require.Equal(t, []string{"team-X", "team-Y"}, names.Data)
+
+	r, err = http.NewRequest("GET", "/api/v1/receivers?details=true", nil)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	api.receivers(w, r)
+	require.Equal(t, 200, w.Code)
+	var details struct {
+		Data []receiverStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	require.Equal(t, []receiverStatus{
+		{
+			Name: "team-X",
+			Integrations: []integrationStatus{
+				{Name: "webhook", Index: 0},
+				{
+					Name:         "webhook",
+					Index:        1,
+					LastAttempt:  &lastAttempt,
+					LastError:    "unexpected status code 401",
+					LastErrorAt:  &lastAttempt,
+					FailingSince: &failingSince,
+				},
+			},
+		},
+		{Name: "team-Y", Integrations: []integrationStatus{}},
+	}, details.Data)
+
+	r, err = http.NewRequest("GET", "/api/v1/receivers?details=maybe", nil)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	api.receivers(w, r)
+	require.Equal(t, 400, w.Code)
+}
+
+func TestPushConfig(t *testing.T) {
+	running, err := config.Load("route:\n  receiver: team-X\nreceivers:\n- name: team-X\n")
+	require.NoError(t, err)
+
+	var pushed []bool
+	push := func(content []byte, dryRun bool) (*config.Config, error) {
+		conf, err := config.Load(string(content))
+		if err != nil {
+			return nil, err
+		}
+		pushed = append(pushed, dryRun)
+		return conf, nil
+	}
+	api := New(Options{
+		Alerts:     newFakeAlerts([]*types.Alert{}, false),
+		Groups:     groupAlerts,
+		PushConfig: push,
+	})
+	require.NoError(t, api.Update(running, nil, time.Minute))
+
+	for _, tc := range []struct {
+		body    string
+		code    int
+		diff    string
+		applied bool
+	}{
+		{
+			body: `{"config": "route:\n  receiver: team-Y\nreceivers:\n- name: team-Y\n", "dryRun": true}`,
+			code: 200,
+			diff: "-  receiver: team-X\n+  receiver: team-Y\n",
+		},
+		{
+			body:    `{"config": "route:\n  receiver: team-X\nreceivers:\n- name: team-X\n"}`,
+			code:    200,
+			applied: true,
+		},
+		{
+			body: `{"config": "route:\n  receiver: missing\n"}`,
+			code: 400,
+		},
+	} {
+		r, err := http.NewRequest("POST", "/api/v1/config", bytes.NewBufferString(tc.body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+
+		api.pushConfigFile(w, r)
+		require.Equal(t, tc.code, w.Code, tc.body)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data struct {
+				Diff    string `json:"diff"`
+				Applied bool   `json:"applied"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Contains(t, res.Data.Diff, tc.diff)
+		require.Equal(t, tc.applied, res.Data.Applied)
+	}
+	require.Equal(t, []bool{true, false}, pushed)
+}
+
 func TestAlertFiltering(t *testing.T) {
 	type test struct {
 		alert    *model.Alert
@@ -390,6 +1383,717 @@ func TestAlertFiltering(t *testing.T) {
 	}
 }
 
+func TestUpdateSilence(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	now := time.Now().Add(time.Minute)
+	sid, err := silences.Set(&silencepb.Silence{
+		Matchers: []*silencepb.Matcher{{Name: "job", Pattern: "api"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+
+	for i, tc := range []struct {
+		id   string
+		sil  types.Silence
+		code int
+	}{
+		{
+			id: sid,
+			sil: types.Silence{
+				Matchers: types.Matchers{{Name: "job", Value: "web"}},
+				StartsAt: now,
+				EndsAt:   now.Add(2 * time.Hour),
+			},
+			code: 200,
+		},
+		{
+			id: sid,
+			sil: types.Silence{
+				ID:       "other",
+				Matchers: types.Matchers{{Name: "job", Value: "web"}},
+				StartsAt: now,
+				EndsAt:   now.Add(2 * time.Hour),
+			},
+			code: 400,
+		},
+		{
+			id: sid,
+			sil: types.Silence{
+				Matchers: types.Matchers{{Name: "job", Value: "web"}},
+				StartsAt: now.Add(-3 * time.Hour),
+				EndsAt:   now.Add(-2 * time.Hour),
+			},
+			code: 400,
+		},
+		{
+			id: "unknown",
+			sil: types.Silence{
+				Matchers: types.Matchers{{Name: "job", Value: "web"}},
+				StartsAt: now,
+				EndsAt:   now.Add(2 * time.Hour),
+			},
+			code: 404,
+		},
+	} {
+		b, err := json.Marshal(&tc.sil)
+		require.NoError(t, err)
+
+		r, err := http.NewRequest("PUT", "/api/v1/silence/"+tc.id, bytes.NewReader(b))
+		require.NoError(t, err)
+		r = r.WithContext(route.WithParam(r.Context(), "sid", tc.id))
+		w := httptest.NewRecorder()
+
+		api.updateSilence(w, r)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		require.Equal(t, tc.code, w.Code, fmt.Sprintf("test case: %d, response: %s", i, string(body)))
+	}
+
+	sils, err := silences.Query(silence.QIDs(sid))
+	require.NoError(t, err)
+	sil, err := silenceFromProto(sils[0])
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), sil.Version)
+	require.Equal(t, "web", sil.Matchers[0].Value)
+	require.Equal(t, 1, len(sil.History))
+	require.Equal(t, "api", sil.History[0].Matchers[0].Value)
+}
+
+func TestSilenceLimits(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	newAlert := func(name string, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(name), "cluster": "eu"},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   endsAt,
+		}}
+	}
+	alerts := []*types.Alert{
+		newAlert("a", now.Add(time.Hour)),
+		newAlert("b", now.Add(time.Hour)),
+		newAlert("c", now.Add(time.Hour)),
+		newAlert("resolved", now.Add(-time.Minute)),
+	}
+	api := New(Options{
+		Alerts:   newFakeAlerts(alerts, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+	api.config = &config.Config{SilenceLimits: &config.SilenceLimitsConfig{
+		RequiredLabels:   model.LabelNames{"cluster"},
+		MaxDuration:      model.Duration(24 * time.Hour),
+		MaxMatchedAlerts: 2,
+	}}
+
+	for _, tc := range []struct {
+		query    string
+		matchers types.Matchers
+		endsAt   time.Time
+		renewal  *types.SilenceRenewal
+		code     int
+	}{
+		{
+			matchers: types.Matchers{{Name: "alertname", Value: "a"}},
+			code:     400,
+		},
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu|us", IsRegex: true}, {Name: "alertname", Value: "a"}},
+			code:     400,
+		},
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}, {Name: "alertname", Value: "a"}},
+			code:     200,
+		},
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}, {Name: "alertname", Value: "a"}},
+			endsAt:   now.Add(25 * time.Hour),
+			code:     400,
+		},
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}, {Name: "alertname", Value: "a"}},
+			renewal:  &types.SilenceRenewal{Increment: "1h", Until: now.Add(48 * time.Hour)},
+			code:     400,
+		},
+		// The resolved alert does not count.
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}, {Name: "alertname", Value: "a|b|resolved", IsRegex: true}},
+			code:     200,
+		},
+		{
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}},
+			code:     400,
+		},
+		{
+			query:    "?force=true",
+			matchers: types.Matchers{{Name: "cluster", Value: "eu"}},
+			code:     200,
+		},
+	} {
+		sil := types.Silence{
+			Matchers:  tc.matchers,
+			StartsAt:  now,
+			EndsAt:    tc.endsAt,
+			Renewal:   tc.renewal,
+			CreatedBy: "me",
+		}
+		if sil.EndsAt.IsZero() {
+			sil.EndsAt = now.Add(time.Hour)
+		}
+		b, err := json.Marshal(&sil)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("POST", "/api/v1/silences"+tc.query, bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		api.setSilence(w, r)
+		require.Equal(t, tc.code, w.Code, "%s: %s", tc.matchers, w.Body.String())
+	}
+}
+
+func TestSilenceMetadata(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+	api.config = &config.Config{SilenceMetadata: []*config.SilenceMetadataField{
+		{Name: "ticket", Required: true, Regex: &config.Regexp{Regexp: regexp.MustCompile("^(?:[A-Z]+-[0-9]+)$")}},
+		{Name: "team"},
+	}}
+
+	r := httptest.NewRequest("GET", "/api/v1/silences/metadata", nil)
+	w := httptest.NewRecorder()
+	api.silenceMetadataFields(w, r)
+	require.Equal(t, 200, w.Code)
+	require.Contains(t, w.Body.String(), `"name":"ticket"`)
+
+	now := time.Now()
+	for _, tc := range []struct {
+		metadata map[string]string
+		code     int
+	}{
+		{
+			metadata: map[string]string{"ticket": "OPS-123", "team": "db"},
+			code:     200,
+		},
+		{
+			metadata: map[string]string{"team": "db"},
+			code:     400,
+		},
+		{
+			metadata: map[string]string{"ticket": "ops 123"},
+			code:     400,
+		},
+		{
+			metadata: map[string]string{"ticket": "OPS-123", "owner": "me"},
+			code:     400,
+		},
+	} {
+		sil := types.Silence{
+			Matchers:  types.Matchers{{Name: "alertname", Value: "a"}},
+			StartsAt:  now,
+			EndsAt:    now.Add(time.Hour),
+			CreatedBy: "me",
+			Metadata:  tc.metadata,
+		}
+		b, err := json.Marshal(&sil)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest("POST", "/api/v1/silences", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		api.setSilence(w, r)
+		require.Equal(t, tc.code, w.Code, "%v: %s", tc.metadata, w.Body.String())
+	}
+
+	sils, err := silences.Query()
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	sil, err := silenceFromProto(sils[0])
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ticket": "OPS-123", "team": "db"}, sil.Metadata)
+}
+
+func TestCreateSilences(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+
+	now := time.Now()
+	newSilence := func(instance string, endsAt time.Time) types.Silence {
+		return types.Silence{
+			Matchers:  types.Matchers{{Name: "instance", Value: instance}},
+			StartsAt:  now,
+			EndsAt:    endsAt,
+			CreatedBy: "me",
+		}
+	}
+	post := func(sils ...types.Silence) *httptest.ResponseRecorder {
+		b, err := json.Marshal(sils)
+		require.NoError(t, err)
+		r := httptest.NewRequest("POST", "/api/v1/silences/bulk", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		api.createSilences(w, r)
+		return w
+	}
+
+	// No silence is created if one of them is invalid.
+	w := post(newSilence("db1", now.Add(time.Hour)), newSilence("db2", now.Add(-time.Hour)))
+	require.Equal(t, 400, w.Code)
+	require.Contains(t, w.Body.String(), "silence 1: end time can't be in the past")
+	sils, err := silences.Query()
+	require.NoError(t, err)
+	require.Len(t, sils, 0)
+
+	w = post(newSilence("db1", now.Add(time.Hour)), newSilence("db2", now.Add(time.Hour)))
+	require.Equal(t, 200, w.Code, w.Body.String())
+	var res struct {
+		Data struct {
+			SilenceIDs []string `json:"silenceIds"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Data.SilenceIDs, 2)
+	for i, instance := range []string{"db1", "db2"} {
+		sils, err := silences.Query(silence.QIDs(res.Data.SilenceIDs[i]))
+		require.NoError(t, err)
+		require.Len(t, sils, 1)
+		require.Equal(t, instance, sils[0].Matchers[0].Pattern)
+	}
+}
+
+func TestSilenceRenewalConversion(t *testing.T) {
+	until := time.Now().Add(24 * time.Hour).UTC()
+	sil := &types.Silence{
+		Matchers: types.Matchers{{Name: "job", Value: "api"}},
+		Renewal:  &types.SilenceRenewal{Increment: "2h", Until: until},
+	}
+
+	psil, err := silenceToProto(sil)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, *psil.RenewIncrement)
+	require.Equal(t, until, *psil.RenewUntil)
+
+	res, err := silenceFromProto(psil)
+	require.NoError(t, err)
+	require.Equal(t, sil.Renewal, res.Renewal)
+
+	sil.Renewal.Increment = "2 hours"
+	_, err = silenceToProto(sil)
+	require.Error(t, err)
+}
+
+func TestSilenceExternalIDConversion(t *testing.T) {
+	sil := &types.Silence{
+		Matchers:   types.Matchers{{Name: "job", Value: "api"}},
+		ExternalID: "db-upgrade",
+	}
+
+	psil, err := silenceToProto(sil)
+	require.NoError(t, err)
+	require.Equal(t, "db-upgrade", psil.ExternalId)
+
+	res, err := silenceFromProto(psil)
+	require.NoError(t, err)
+	require.Equal(t, "db-upgrade", res.ExternalID)
+}
+
+func TestSnapshot(t *testing.T) {
+	newSilences := func() *silence.Silences {
+		silences, err := silence.New(silence.Options{})
+		require.NoError(t, err)
+		return silences
+	}
+	newAPI := func(silences *silence.Silences, auditor audit.Logger) *API {
+		return New(Options{
+			Alerts:   newFakeAlerts([]*types.Alert{}, false),
+			Silences: silences,
+			Groups:   groupAlerts,
+			Auditor:  auditor,
+			States:   map[string]cluster.State{"sil": silences},
+		})
+	}
+
+	old := newSilences()
+	now := time.Now()
+	id, err := old.Set(&silencepb.Silence{
+		Matchers:  []*silencepb.Matcher{{Name: "job", Pattern: "api"}},
+		StartsAt:  now,
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "alice",
+		Comment:   "migration",
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	newAPI(old, nil).saveSnapshot(w, httptest.NewRequest("GET", "/api/v1/cluster/snapshot", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var res struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+	restored := newSilences()
+	auditor := &fakeAuditor{}
+	api := newAPI(restored, auditor)
+	w = httptest.NewRecorder()
+	api.restoreSnapshot(w, httptest.NewRequest("POST", "/api/v1/cluster/snapshot", bytes.NewReader(res.Data)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"status":"success","data":{"restored":["sil"]}}`, w.Body.String())
+
+	sil, err := restored.QueryOne(silence.QIDs(id))
+	require.NoError(t, err)
+	require.Equal(t, "migration", sil.Comment)
+	require.Len(t, auditor.events, 1)
+	require.Equal(t, audit.ActionStateRestore, auditor.events[0].Action)
+
+	// Snapshots with states unknown to the Alertmanager are rejected.
+	w = httptest.NewRecorder()
+	api.restoreSnapshot(w, httptest.NewRequest("POST", "/api/v1/cluster/snapshot", bytes.NewBufferString(`{"version": 1, "states": {"foo": ""}}`)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, auditor.events, 2)
+	require.Equal(t, `unknown state "foo"`, auditor.events[1].Error)
+}
+
+func TestCollectGarbage(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	now := time.Now()
+	for _, comment := range []string{"expired", "active"} {
+		_, err := silences.Set(&silencepb.Silence{
+			Matchers:  []*silencepb.Matcher{{Name: "job", Pattern: "api"}},
+			StartsAt:  now,
+			EndsAt:    now.Add(time.Hour),
+			CreatedBy: "alice",
+			Comment:   comment,
+		})
+		require.NoError(t, err)
+	}
+	sils, err := silences.Query(silence.QMatches(model.LabelSet{"job": "api"}))
+	require.NoError(t, err)
+	for _, sil := range sils {
+		if sil.Comment == "expired" {
+			require.NoError(t, silences.Expire(sil.Id))
+		}
+	}
+
+	// Acknowledgements have no expired entries to remove on demand.
+	acks, err := ack.New(ack.Options{})
+	require.NoError(t, err)
+
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Acks:     acks,
+		Groups:   groupAlerts,
+		Auditor:  auditor,
+		States:   map[string]cluster.State{"sil": silences, "ack": acks},
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/v1/state/gc", nil)
+	r.SetBasicAuth("alice", "secret")
+	api.collectGarbage(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"status":"success","data":{"removed":{"sil":1}}}`, w.Body.String())
+
+	sils, err = silences.Query()
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	require.Equal(t, "active", sils[0].Comment)
+
+	require.Len(t, auditor.events, 1)
+	require.Equal(t, audit.ActionStateGC, auditor.events[0].Action)
+	require.Equal(t, "alice", auditor.events[0].Actor)
+}
+
+// fakeAuditor records audit events for tests.
+type fakeAuditor struct {
+	events []*audit.Event
+}
+
+func (a *fakeAuditor) Log(e *audit.Event) error {
+	a.events = append(a.events, e)
+	return nil
+}
+
+func TestAuditSilences(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+		Auditor:  auditor,
+	})
+
+	do := func(method string, sid string, sil *types.Silence, h http.HandlerFunc) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if sil != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(sil))
+		}
+		r := httptest.NewRequest(method, "/api/v1/silence/"+sid, &body)
+		r.SetBasicAuth("alice", "secret")
+		r = r.WithContext(route.WithParam(r.Context(), "sid", sid))
+		w := httptest.NewRecorder()
+		h(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w
+	}
+
+	now := time.Now()
+	sil := &types.Silence{
+		Matchers:  types.Matchers{{Name: "job", Value: "api"}},
+		StartsAt:  now.Add(time.Minute),
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "alice",
+	}
+	w := do("POST", "", sil, api.setSilence)
+	var res struct {
+		Data struct {
+			SilenceID string `json:"silenceId"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	sid := res.Data.SilenceID
+
+	sil.Matchers = types.Matchers{{Name: "job", Value: "web"}}
+	do("PUT", sid, sil, api.updateSilence)
+	do("DELETE", sid, nil, api.delSilence)
+
+	require.Equal(t, 3, len(auditor.events))
+	for i, action := range []string{audit.ActionSilenceCreate, audit.ActionSilenceUpdate, audit.ActionSilenceExpire} {
+		e := auditor.events[i]
+		require.Equal(t, action, e.Action)
+		require.Equal(t, "alice", e.Actor)
+		require.Equal(t, sid, e.Target)
+		require.False(t, e.Time.IsZero())
+	}
+	require.Equal(t, "web", auditor.events[1].Payload.(*silencepb.Silence).Matchers[0].Pattern)
+}
+
+func TestPresets(t *testing.T) {
+	presets, err := preset.New(preset.Options{})
+	require.NoError(t, err)
+
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:  newFakeAlerts([]*types.Alert{}, false),
+		Presets: presets,
+		Auditor: auditor,
+	})
+
+	do := func(method, name string, p *types.FilterPreset, h http.HandlerFunc) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if p != nil {
+			require.NoError(t, json.NewEncoder(&body).Encode(p))
+		}
+		r := httptest.NewRequest(method, "/api/v1/preset/"+name, &body)
+		r.SetBasicAuth("alice", "secret")
+		r = r.WithContext(route.WithParam(r.Context(), "name", name))
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	p := &types.FilterPreset{
+		Name:      "db",
+		Matchers:  types.Matchers{{Name: "team", Value: "db"}},
+		GroupBy:   []string{"cluster"},
+		CreatedBy: "alice",
+	}
+	w := do("POST", "", p, api.setPreset)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do("POST", "", &types.FilterPreset{Name: "invalid"}, api.setPreset)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = do("GET", "db", nil, api.getPreset)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res struct {
+		Data *types.FilterPreset `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	require.Equal(t, p.Matchers, res.Data.Matchers)
+	require.Equal(t, p.GroupBy, res.Data.GroupBy)
+
+	w = do("GET", "", nil, api.listPresets)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list struct {
+		Data []*types.FilterPreset `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.Data, 1)
+
+	w = do("DELETE", "db", nil, api.delPreset)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do("GET", "db", nil, api.getPreset)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	w = do("DELETE", "db", nil, api.delPreset)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	require.Equal(t, 2, len(auditor.events))
+	for i, action := range []string{audit.ActionPresetSet, audit.ActionPresetDelete} {
+		require.Equal(t, action, auditor.events[i].Action)
+		require.Equal(t, "db", auditor.events[i].Target)
+	}
+}
+
+func TestListSilencesByState(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	matchers := []*silencepb.Matcher{{Name: "job", Pattern: "api"}}
+	active, err := silences.Set(&silencepb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	pending, err := silences.Set(&silencepb.Silence{Matchers: matchers, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:   newFakeAlerts([]*types.Alert{}, false),
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+
+	for _, tc := range []struct {
+		state string
+		code  int
+		ids   []string
+		total string
+	}{
+		{state: "", code: 200, ids: []string{active, pending}},
+		{state: "pending", code: 200, ids: []string{pending}},
+		{state: "active", code: 200, ids: []string{active}},
+		{state: "expired", code: 200},
+		{state: "scheduled", code: 400},
+		{state: "active,pending", code: 200, ids: []string{active, pending}},
+		{state: "active,pending&sort=-startsAt", code: 200, ids: []string{pending, active}},
+		{state: "active,pending&sort=-startsAt&limit=1", code: 200, ids: []string{pending}, total: "2"},
+		{state: "active,pending&sort=-startsAt&limit=1&offset=1", code: 200, ids: []string{active}, total: "2"},
+		{state: "active,pending&offset=5", code: 200, total: "2"},
+		{state: "active,pending&sort=createdBy", code: 400},
+		{state: "active,pending&limit=-1", code: 400},
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/silences?state="+tc.state, nil)
+		w := httptest.NewRecorder()
+		api.listSilences(w, r)
+		require.Equal(t, tc.code, w.Code, tc.state)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []*types.Silence `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		var ids []string
+		for _, s := range res.Data {
+			ids = append(ids, s.ID)
+		}
+		require.Equal(t, tc.ids, ids, tc.state)
+		if tc.total != "" {
+			require.Equal(t, tc.total, w.Header().Get(totalCountHeader), tc.state)
+		}
+	}
+}
+
+func TestListSilencesSuppressed(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	matchers := []*silencepb.Matcher{{Name: "job", Pattern: "api"}}
+	broad, err := silences.Set(&silencepb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	unused, err := silences.Set(&silencepb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(2 * time.Hour)})
+	require.NoError(t, err)
+
+	newAlert := func(name string, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(name), "silenced_by": model.LabelValue(broad)},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   endsAt,
+		}}
+	}
+	alerts := []*types.Alert{
+		newAlert("a", now.Add(time.Hour)),
+		newAlert("b", now.Add(time.Hour)),
+		newAlert("resolved", now.Add(-time.Minute)),
+	}
+	fa := newFakeAlerts(alerts, false)
+	api := New(Options{
+		Alerts:      fa,
+		Silences:    silences,
+		Groups:      groupAlerts,
+		AlertStatus: newGetAlertStatus(fa),
+	})
+
+	fps := []string{alerts[0].Fingerprint().String(), alerts[1].Fingerprint().String()}
+	sort.Strings(fps)
+
+	for _, tc := range []struct {
+		query    string
+		code     int
+		expected map[string]*types.SuppressedAlerts
+	}{
+		{
+			query:    "",
+			code:     200,
+			expected: map[string]*types.SuppressedAlerts{broad: nil, unused: nil},
+		},
+		{
+			query: "?suppressed=true",
+			code:  200,
+			expected: map[string]*types.SuppressedAlerts{
+				broad:  {Count: 2, Fingerprints: fps},
+				unused: {Count: 0, Fingerprints: []string{}},
+			},
+		},
+		{
+			query: "?suppressed=yes",
+			code:  400,
+		},
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/silences"+tc.query, nil)
+		w := httptest.NewRecorder()
+		api.listSilences(w, r)
+		require.Equal(t, tc.code, w.Code, tc.query)
+		if tc.code != 200 {
+			continue
+		}
+
+		var res struct {
+			Data []*types.Silence `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		suppressed := map[string]*types.SuppressedAlerts{}
+		for _, s := range res.Data {
+			suppressed[s.ID] = s.Status.Suppressed
+		}
+		require.Equal(t, tc.expected, suppressed, tc.query)
+	}
+}
+
 func TestSilenceFiltering(t *testing.T) {
 	type test struct {
 		silence  *types.Silence
@@ -573,3 +2277,34 @@ func newMatcher(labelSet model.LabelSet) types.Matchers {
 	}
 	return matchers
 }
+
+func TestOpenAPISpec(t *testing.T) {
+	b, err := ioutil.ReadFile("openapi.yaml")
+	require.NoError(t, err)
+
+	var spec struct {
+		BasePath string                            `yaml:"basePath"`
+		Paths    map[string]map[string]interface{} `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(b, &spec))
+	require.Equal(t, "/api/v2", spec.BasePath)
+
+	// Path parameters are named differently in the specification and the
+	// router.
+	param := regexp.MustCompile(`(\{[^}]+\}|:[^/]+)`)
+
+	specified := map[string]bool{}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			specified[strings.ToUpper(method)+" "+param.ReplaceAllString(path, "*")] = true
+		}
+	}
+	routed := map[string]bool{}
+	for _, rt := range (&API{}).v2Routes() {
+		routed[rt.method+" "+param.ReplaceAllString(rt.path, "*")] = true
+	}
+	require.Equal(t, specified, routed)
+}
@@ -0,0 +1,221 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/pkg/parse"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Names of the server-sent events of the events stream.
+const (
+	eventAlertAdded     = "alert_added"
+	eventAlertUpdated   = "alert_updated"
+	eventAlertResolved  = "alert_resolved"
+	eventSilenceUpdated = "silence_updated"
+)
+
+// events streams changes of alerts and silences as server-sent events. The
+// stream starts with the current alerts and silences. Only the alerts and
+// silences matching the matchers of the filter parameter are sent.
+func (api *API) events(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		matchers = []*labels.Matcher{}
+	)
+	if filter := r.FormValue("filter"); filter != "" {
+		matchers, err = parse.Matchers(filter)
+		if err != nil {
+			api.respondError(w, apiError{
+				typ: errorBadData,
+				err: err,
+			}, nil)
+			return
+		}
+	}
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: errors.New("streaming is not supported"),
+		}, nil)
+		return
+	}
+
+	alerts := api.alerts.Subscribe()
+	defer alerts.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, data interface{}) error {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	var (
+		// firing holds the firing alerts that were sent, so that they are
+		// reported as resolved once they time out.
+		firing = map[model.Fingerprint]*types.Alert{}
+		// silencesSince is the last update of the sent silences.
+		silencesSince time.Time
+	)
+	sendSilences := func() error {
+		if api.silences == nil {
+			return nil
+		}
+		psils, err := api.silences.Query()
+		if err != nil {
+			return err
+		}
+		since := silencesSince
+		for _, ps := range psils {
+			if !ps.UpdatedAt.After(since) {
+				continue
+			}
+			if ps.UpdatedAt.After(silencesSince) {
+				silencesSince = ps.UpdatedAt
+			}
+			s, err := silenceFromProto(ps)
+			if err != nil {
+				return err
+			}
+			// Silences that expired before the stream started are left out.
+			if since.IsZero() && s.Status.State == types.SilenceStateExpired {
+				continue
+			}
+			if !silenceMatchesFilterLabels(s, matchers) {
+				continue
+			}
+			if err := send(eventSilenceUpdated, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := sendSilences(); err != nil {
+		level.Error(api.logger).Log("msg", "Streaming silences failed", "err", err)
+		return
+	}
+
+	tick := time.NewTicker(api.silencePollInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case a, ok := <-alerts.Next():
+			if !ok {
+				if err := alerts.Err(); err != nil {
+					level.Error(api.logger).Log("msg", "Streaming alerts failed", "err", err)
+				}
+				return
+			}
+			if !alertMatchesFilterLabels(&a.Alert, matchers) {
+				continue
+			}
+			fp := a.Fingerprint()
+			_, known := firing[fp]
+
+			event := eventAlertAdded
+			switch {
+			case a.Resolved():
+				if !known {
+					continue
+				}
+				delete(firing, fp)
+				event = eventAlertResolved
+			case known:
+				event = eventAlertUpdated
+			}
+			if !a.Resolved() {
+				firing[fp] = a
+			}
+			if err := send(event, api.apiAlert(a)); err != nil {
+				return
+			}
+
+		case <-tick.C:
+			for fp, a := range firing {
+				if !a.Resolved() {
+					continue
+				}
+				delete(firing, fp)
+				if err := send(eventAlertResolved, api.apiAlert(a)); err != nil {
+					return
+				}
+			}
+			if err := sendSilences(); err != nil {
+				level.Error(api.logger).Log("msg", "Streaming silences failed", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// apiAlert returns the alert with its receivers and status.
+func (api *API) apiAlert(a *types.Alert) *dispatch.APIAlert {
+	api.mtx.RLock()
+	route := api.route
+	api.mtx.RUnlock()
+
+	receivers := []string{}
+	if route != nil {
+		for _, r := range route.Match(a.Labels) {
+			receivers = append(receivers, r.RouteOpts.Receiver)
+		}
+	}
+	res := &dispatch.APIAlert{
+		Alert:       &a.Alert,
+		Receivers:   receivers,
+		Fingerprint: a.Fingerprint().String(),
+	}
+	if api.getAlertStatus != nil {
+		res.Status = api.getAlertStatus(a.Fingerprint())
+	}
+	if api.acks != nil {
+		res.Acknowledgement, _ = api.acks.Get(a.Fingerprint())
+	}
+	return res
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestEvents(t *testing.T) {
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	now := time.Now()
+	newAlert := func(job string, endsAt time.Time) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "HighLatency", "job": model.LabelValue(job)},
+				StartsAt: now,
+				EndsAt:   endsAt,
+			},
+			UpdatedAt: time.Now(),
+		}
+	}
+	require.NoError(t, alerts.Put(newAlert("api", now.Add(time.Hour))))
+	_, err = silences.Set(&silencepb.Silence{
+		Matchers: []*silencepb.Matcher{{Name: "job", Pattern: "api"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:   alerts,
+		Silences: silences,
+		Groups:   groupAlerts,
+	})
+	api.silencePollInterval = 10 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(api.events))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?filter=" + url.QueryEscape(`{job="api"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan [2]string)
+	go func() {
+		var event string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				events <- [2]string{event, strings.TrimPrefix(line, "data: ")}
+			}
+		}
+		close(events)
+	}()
+	next := func() (string, string) {
+		select {
+		case e := <-events:
+			return e[0], e[1]
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+		return "", ""
+	}
+	nextAlert := func(expected string) *dispatch.APIAlert {
+		event, data := next()
+		require.Equal(t, expected, event)
+		var a dispatch.APIAlert
+		require.NoError(t, json.Unmarshal([]byte(data), &a))
+		return &a
+	}
+
+	// The stream starts with the current silences and alerts.
+	event, data := next()
+	require.Equal(t, eventSilenceUpdated, event)
+	var s types.Silence
+	require.NoError(t, json.Unmarshal([]byte(data), &s))
+	require.Equal(t, types.SilenceStateActive, s.Status.State)
+
+	a := nextAlert(eventAlertAdded)
+	require.Equal(t, model.LabelValue("api"), a.Labels["job"])
+
+	// Alerts not matching the filter are left out.
+	require.NoError(t, alerts.Put(newAlert("web", now.Add(time.Hour))))
+	require.NoError(t, alerts.Put(newAlert("api", now.Add(2*time.Hour))))
+	nextAlert(eventAlertUpdated)
+
+	require.NoError(t, alerts.Put(newAlert("api", now.Add(-time.Minute))))
+	nextAlert(eventAlertResolved)
+}
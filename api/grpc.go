@@ -0,0 +1,452 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/version"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/prometheus/alertmanager/api/apipb"
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/pkg/parse"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// grpcReadMethods are the methods of the gRPC API that do not modify any
+// state.
+var grpcReadMethods = map[string]bool{
+	"/apipb.Alertmanager/GetSilence":       true,
+	"/apipb.Alertmanager/ListSilences":     true,
+	"/apipb.Alertmanager/GetStatus":        true,
+	"/apipb.Alertmanager/WatchAlertGroups": true,
+}
+
+// GRPCReadOnly returns whether the method of the gRPC API with the full
+// name does not modify any state, so that clients with the read role may
+// call it.
+func GRPCReadOnly(fullMethod string) bool {
+	return grpcReadMethods[fullMethod]
+}
+
+// RegisterGRPC registers the gRPC API on the server. It is served from the
+// same alerts and silences as the HTTP API.
+func (api *API) RegisterGRPC(s *grpc.Server) {
+	apipb.RegisterAlertmanagerServer(s, &grpcServer{api: api})
+}
+
+// grpcServer implements the gRPC API.
+type grpcServer struct {
+	api *API
+}
+
+// grpcHeader returns the metadata of the call for the HTTP header.
+func grpcHeader(ctx context.Context, name string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md[strings.ToLower(name)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// tenant returns the tenant matcher of the call like the tenant matcher of
+// requests of the HTTP API.
+func (s *grpcServer) tenant(ctx context.Context) (*labels.Matcher, error) {
+	tm, err := s.api.tenantMatcherOf(grpcHeader(ctx, config.TenantHeader))
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Unauthenticated, err.Error())
+	}
+	return tm, nil
+}
+
+// record records a mutating operation performed by the call.
+func (s *grpcServer) record(ctx context.Context, action, target string, payload interface{}) {
+	e := &audit.Event{
+		Action:  action,
+		Actor:   grpcHeader(ctx, audit.ActorHeader),
+		Target:  target,
+		Payload: payload,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.RemoteAddr = p.Addr.String()
+	}
+	s.api.record(e)
+}
+
+// grpcMatchers parses the filter of a request.
+func grpcMatchers(filter string) ([]*labels.Matcher, error) {
+	if filter == "" {
+		return []*labels.Matcher{}, nil
+	}
+	ms, err := parse.Matchers(filter)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	return ms, nil
+}
+
+func (s *grpcServer) PutAlerts(ctx context.Context, req *apipb.PutAlertsRequest) (*apipb.PutAlertsResponse, error) {
+	start := time.Now()
+	defer func() { alertIngestDuration.Observe(time.Since(start).Seconds()) }()
+
+	var maxBatchSize int
+	s.api.mtx.RLock()
+	if s.api.config != nil && s.api.config.AlertIngestion != nil {
+		maxBatchSize = s.api.config.AlertIngestion.MaxBatchSize
+	}
+	s.api.mtx.RUnlock()
+
+	alertIngestBatchSize.Observe(float64(len(req.Alerts)))
+	if maxBatchSize > 0 && len(req.Alerts) > maxBatchSize {
+		alertIngestRejected.WithLabelValues(reasonBatchSize).Inc()
+		return nil, grpcstatus.Errorf(codes.ResourceExhausted, "request posts %d alerts, more than the limit of %d", len(req.Alerts), maxBatchSize)
+	}
+
+	alerts := make([]*types.Alert, 0, len(req.Alerts))
+	for _, a := range req.Alerts {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels:       labelSetFromMap(a.Labels),
+				Annotations:  labelSetFromMap(a.Annotations),
+				StartsAt:     a.StartsAt,
+				EndsAt:       a.EndsAt,
+				GeneratorURL: a.GeneratorURL,
+			},
+		})
+	}
+
+	// Alerts carry their tenant label like the alerts posted to the HTTP
+	// API, so that clients may send them without a tenant.
+	tm, err := s.api.tenantMatcherOf(grpcHeader(ctx, config.TenantHeader))
+	if err != nil && err != errNoTenant {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	if tm != nil {
+		if err := scopeAlerts(alerts, tm); err != nil {
+			return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	validAlerts, validationErrs, err := s.api.putAlerts(alerts...)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	if len(validAlerts) > 0 {
+		s.record(ctx, audit.ActionAlertsPost, "", validAlerts)
+	}
+	if validationErrs.Len() > 0 {
+		return nil, grpcstatus.Error(codes.InvalidArgument, validationErrs.Error())
+	}
+	return &apipb.PutAlertsResponse{}, nil
+}
+
+func (s *grpcServer) GetSilence(ctx context.Context, req *apipb.GetSilenceRequest) (*apipb.Silence, error) {
+	tm, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	psils, err := s.api.silences.Query(silence.QIDs(req.Id))
+	if err != nil || len(psils) == 0 {
+		return nil, grpcstatus.Error(codes.NotFound, silence.ErrNotFound.Error())
+	}
+	sil, err := silenceFromProto(psils[0])
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	if tm != nil && !silenceMatchesFilterLabels(sil, []*labels.Matcher{tm}) {
+		return nil, grpcstatus.Error(codes.NotFound, silence.ErrNotFound.Error())
+	}
+	return grpcSilence(sil), nil
+}
+
+func (s *grpcServer) ListSilences(ctx context.Context, req *apipb.ListSilencesRequest) (*apipb.ListSilencesResponse, error) {
+	matchers, err := grpcMatchers(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	tm, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+
+	psils, err := s.api.silences.Query()
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	res := &apipb.ListSilencesResponse{}
+	for _, ps := range psils {
+		sil, err := silenceFromProto(ps)
+		if err != nil {
+			return nil, grpcstatus.Error(codes.Internal, err.Error())
+		}
+		if !silenceMatchesFilterLabels(sil, matchers) {
+			continue
+		}
+		res.Silences = append(res.Silences, grpcSilence(sil))
+	}
+	return res, nil
+}
+
+func (s *grpcServer) SetSilence(ctx context.Context, req *apipb.SetSilenceRequest) (*apipb.SetSilenceResponse, error) {
+	if req.Silence == nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "missing silence")
+	}
+	tm, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	psil, err := s.api.prepareSilence(silenceFromGRPC(req.Silence), tm, req.Force)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	if tm != nil && psil.Id != "" && !s.api.silenceOfTenant(psil.Id, tm) {
+		return nil, grpcstatus.Error(codes.NotFound, silence.ErrNotFound.Error())
+	}
+
+	action := audit.ActionSilenceCreate
+	if psil.Id != "" {
+		action = audit.ActionSilenceUpdate
+	}
+	sid, err := s.api.silences.Set(psil)
+	if err != nil {
+		if err == silence.ErrNotFound {
+			return nil, grpcstatus.Error(codes.NotFound, err.Error())
+		}
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	s.record(ctx, action, sid, psil)
+	return &apipb.SetSilenceResponse{Id: sid}, nil
+}
+
+func (s *grpcServer) ExpireSilence(ctx context.Context, req *apipb.ExpireSilenceRequest) (*apipb.ExpireSilenceResponse, error) {
+	tm, err := s.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tm != nil && !s.api.silenceOfTenant(req.Id, tm) {
+		return nil, grpcstatus.Error(codes.NotFound, silence.ErrNotFound.Error())
+	}
+	if err := s.api.silences.Expire(req.Id); err != nil {
+		if err == silence.ErrNotFound {
+			return nil, grpcstatus.Error(codes.NotFound, err.Error())
+		}
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	s.record(ctx, audit.ActionSilenceExpire, req.Id, nil)
+	return &apipb.ExpireSilenceResponse{}, nil
+}
+
+func (s *grpcServer) GetStatus(ctx context.Context, req *apipb.GetStatusRequest) (*apipb.Status, error) {
+	if _, err := s.tenant(ctx); err != nil {
+		return nil, err
+	}
+	s.api.mtx.RLock()
+	res := &apipb.Status{
+		ConfigYaml: s.api.configYAML,
+		VersionInfo: map[string]string{
+			"version":   version.Version,
+			"revision":  version.Revision,
+			"branch":    version.Branch,
+			"buildUser": version.BuildUser,
+			"buildDate": version.BuildDate,
+			"goVersion": version.GoVersion,
+		},
+		Uptime:        s.api.uptime,
+		ClusterStatus: "disabled",
+	}
+	s.api.mtx.RUnlock()
+
+	if cs := getClusterStatus(s.api.peer); cs != nil {
+		res.ClusterStatus = cs.Status
+		for _, p := range cs.Peers {
+			res.Peers = append(res.Peers, p.Name)
+		}
+	}
+	return res, nil
+}
+
+// WatchAlertGroups sends the aggregation groups with the alerts matching the
+// filter and then polls the dispatcher for changes of them.
+func (s *grpcServer) WatchAlertGroups(req *apipb.WatchAlertGroupsRequest, stream apipb.Alertmanager_WatchAlertGroupsServer) error {
+	ctx := stream.Context()
+	matchers, err := grpcMatchers(req.Filter)
+	if err != nil {
+		return err
+	}
+	var receiverFilter *regexp.Regexp
+	if req.Receiver != "" {
+		receiverFilter, err = regexp.Compile("^(?:" + req.Receiver + ")$")
+		if err != nil {
+			return grpcstatus.Errorf(codes.InvalidArgument, "failed to parse receiver: %s", req.Receiver)
+		}
+	}
+	tm, err := s.tenant(ctx)
+	if err != nil {
+		return err
+	}
+	if tm != nil {
+		matchers = append(matchers, tm)
+	}
+	if s.api.aggrGroups == nil {
+		return grpcstatus.Error(codes.Unavailable, "alert groups are not available")
+	}
+
+	tick := time.NewTicker(s.api.groupPollInterval)
+	defer tick.Stop()
+
+	// sent holds the groups as they were sent last by their group key.
+	sent := map[string]*apipb.AlertGroup{}
+	for {
+		current := map[string]*apipb.AlertGroup{}
+		for _, ag := range s.api.aggrGroups(matchers) {
+			if receiverFilter != nil && !receiverFilter.MatchString(ag.Receiver) {
+				continue
+			}
+			current[ag.GroupKey] = grpcAlertGroup(ag)
+		}
+
+		// Groups are sent ordered by their key for stable streams.
+		keys := make([]string, 0, len(current)+len(sent))
+		for k := range current {
+			keys = append(keys, k)
+		}
+		for k := range sent {
+			if _, ok := current[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			g, prev := current[k], sent[k]
+			ev := &apipb.AlertGroupEvent{Type: apipb.AlertGroupEvent_UPDATED, Group: g}
+			switch {
+			case g == nil:
+				ev.Type, ev.Group = apipb.AlertGroupEvent_REMOVED, prev
+			case reflect.DeepEqual(g, prev):
+				continue
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+		sent = current
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+func labelSetFromMap(m map[string]string) model.LabelSet {
+	ls := make(model.LabelSet, len(m))
+	for k, v := range m {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls
+}
+
+func labelSetToMap(ls model.LabelSet) map[string]string {
+	m := make(map[string]string, len(ls))
+	for k, v := range ls {
+		m[string(k)] = string(v)
+	}
+	return m
+}
+
+// grpcAlertGroup converts an aggregation group with its alerts ordered by
+// their fingerprint.
+func grpcAlertGroup(ag *dispatch.AggregationGroup) *apipb.AlertGroup {
+	g := &apipb.AlertGroup{
+		GroupKey: ag.GroupKey,
+		Labels:   labelSetToMap(ag.Labels),
+		Receiver: ag.Receiver,
+	}
+	for _, a := range ag.Alerts {
+		g.Alerts = append(g.Alerts, &apipb.Alert{
+			Labels:       labelSetToMap(a.Labels),
+			Annotations:  labelSetToMap(a.Annotations),
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	sort.Slice(g.Alerts, func(i, j int) bool {
+		return g.Alerts[i].Fingerprint < g.Alerts[j].Fingerprint
+	})
+	return g
+}
+
+func grpcSilence(s *types.Silence) *apipb.Silence {
+	res := &apipb.Silence{
+		Id:        s.ID,
+		StartsAt:  s.StartsAt,
+		EndsAt:    s.EndsAt,
+		UpdatedAt: s.UpdatedAt,
+		CreatedBy: s.CreatedBy,
+		Comment:   s.Comment,
+		State:     string(s.Status.State),
+		Metadata:  s.Metadata,
+	}
+	for _, m := range s.Matchers {
+		res.Matchers = append(res.Matchers, &apipb.Matcher{
+			Name:       m.Name,
+			Value:      m.Value,
+			IsRegex:    m.IsRegex,
+			IsNegative: m.IsNegative,
+		})
+	}
+	return res
+}
+
+func silenceFromGRPC(s *apipb.Silence) *types.Silence {
+	res := &types.Silence{
+		ID:        s.Id,
+		StartsAt:  s.StartsAt,
+		EndsAt:    s.EndsAt,
+		CreatedBy: s.CreatedBy,
+		Comment:   s.Comment,
+		Metadata:  s.Metadata,
+	}
+	for _, m := range s.Matchers {
+		res.Matchers = append(res.Matchers, &types.Matcher{
+			Name:       m.Name,
+			Value:      m.Value,
+			IsRegex:    m.IsRegex,
+			IsNegative: m.IsNegative,
+		})
+	}
+	return res
+}
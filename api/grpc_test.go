@@ -0,0 +1,230 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/prometheus/alertmanager/api/apipb"
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func tenantContext(tenant string) context.Context {
+	if tenant == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(config.TenantHeader, tenant))
+}
+
+func TestGRPCAlertsAndSilences(t *testing.T) {
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	auditor := &fakeAuditor{}
+	api := New(Options{
+		Alerts:   alerts,
+		Silences: silences,
+		Groups:   groupAlerts,
+		Auditor:  auditor,
+	})
+
+	conf, err := config.Load(`
+route:
+  receiver: team
+receivers:
+- name: team
+tenancy:
+  label: team
+  admin_tenants: [ops]
+`)
+	require.NoError(t, err)
+	require.NoError(t, api.Update(conf, nil, time.Minute))
+	s := &grpcServer{api: api}
+
+	// Alerts are assigned to the tenant of the call and rejected if they
+	// belong to another one.
+	_, err = s.PutAlerts(tenantContext("a"), &apipb.PutAlertsRequest{Alerts: []*apipb.Alert{
+		{Labels: map[string]string{"alertname": "x"}},
+	}})
+	require.NoError(t, err)
+	a, err := alerts.Get(model.LabelSet{"alertname": "x", "team": "a"}.Fingerprint())
+	require.NoError(t, err)
+	require.False(t, a.Resolved())
+
+	_, err = s.PutAlerts(tenantContext("a"), &apipb.PutAlertsRequest{Alerts: []*apipb.Alert{
+		{Labels: map[string]string{"alertname": "x", "team": "b"}},
+	}})
+	require.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	// Invalid alerts are rejected.
+	_, err = s.PutAlerts(tenantContext(""), &apipb.PutAlertsRequest{Alerts: []*apipb.Alert{{}}})
+	require.Equal(t, codes.InvalidArgument, grpc.Code(err))
+
+	// Silences are restricted to the tenant.
+	now := time.Now()
+	res, err := s.SetSilence(tenantContext("a"), &apipb.SetSilenceRequest{Silence: &apipb.Silence{
+		Matchers: []*apipb.Matcher{{Name: "alertname", Value: "x"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	}})
+	require.NoError(t, err)
+	sil, err := s.GetSilence(tenantContext("a"), &apipb.GetSilenceRequest{Id: res.Id})
+	require.NoError(t, err)
+	require.Equal(t, "active", sil.State)
+	require.Contains(t, sil.Matchers, &apipb.Matcher{Name: "team", Value: "a"})
+
+	_, err = s.GetSilence(tenantContext("b"), &apipb.GetSilenceRequest{Id: res.Id})
+	require.Equal(t, codes.NotFound, grpc.Code(err))
+	_, err = s.ExpireSilence(tenantContext("b"), &apipb.ExpireSilenceRequest{Id: res.Id})
+	require.Equal(t, codes.NotFound, grpc.Code(err))
+	_, err = s.ListSilences(tenantContext(""), &apipb.ListSilencesRequest{})
+	require.Equal(t, codes.Unauthenticated, grpc.Code(err))
+
+	list, err := s.ListSilences(tenantContext("b"), &apipb.ListSilencesRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Silences, 0)
+	list, err = s.ListSilences(tenantContext("ops"), &apipb.ListSilencesRequest{Filter: `{alertname="x"}`})
+	require.NoError(t, err)
+	require.Len(t, list.Silences, 1)
+
+	_, err = s.ExpireSilence(tenantContext("a"), &apipb.ExpireSilenceRequest{Id: res.Id})
+	require.NoError(t, err)
+	sil, err = s.GetSilence(tenantContext("a"), &apipb.GetSilenceRequest{Id: res.Id})
+	require.NoError(t, err)
+	require.Equal(t, "expired", sil.State)
+
+	// Mutating calls are audited.
+	var actions []string
+	for _, e := range auditor.events {
+		actions = append(actions, e.Action)
+	}
+	require.Equal(t, []string{audit.ActionAlertsPost, audit.ActionSilenceCreate, audit.ActionSilenceExpire}, actions)
+}
+
+// watchStream records the events sent to a stream of WatchAlertGroups.
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	mtx    sync.Mutex
+	events []*apipb.AlertGroupEvent
+}
+
+func (s *watchStream) Context() context.Context { return s.ctx }
+
+func (s *watchStream) Send(ev *apipb.AlertGroupEvent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func (s *watchStream) received() []*apipb.AlertGroupEvent {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]*apipb.AlertGroupEvent(nil), s.events...)
+}
+
+func TestGRPCWatchAlertGroups(t *testing.T) {
+	var (
+		mtx    sync.Mutex
+		groups []*dispatch.AggregationGroup
+	)
+	setGroups := func(ags ...*dispatch.AggregationGroup) {
+		mtx.Lock()
+		groups = ags
+		mtx.Unlock()
+	}
+	aggrGroups := func(ms []*labels.Matcher) []*dispatch.AggregationGroup {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return groups
+	}
+	group := func(key string, alertnames ...string) *dispatch.AggregationGroup {
+		ag := &dispatch.AggregationGroup{GroupKey: key, Receiver: "team-X"}
+		for _, n := range alertnames {
+			ls := model.LabelSet{"alertname": model.LabelValue(n)}
+			ag.Alerts = append(ag.Alerts, &dispatch.APIAlert{
+				Alert:       &model.Alert{Labels: ls},
+				Fingerprint: ls.Fingerprint().String(),
+			})
+		}
+		return ag
+	}
+
+	api := New(Options{
+		Alerts:     newFakeAlerts(nil, false),
+		Groups:     groupAlerts,
+		AggrGroups: aggrGroups,
+	})
+	api.groupPollInterval = 10 * time.Millisecond
+	s := &grpcServer{api: api}
+
+	setGroups(group("g1", "a"))
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx}
+	done := make(chan error)
+	go func() {
+		done <- s.WatchAlertGroups(&apipb.WatchAlertGroupsRequest{Receiver: "team-.*"}, stream)
+	}()
+
+	waitEvents := func(n int) []*apipb.AlertGroupEvent {
+		for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+			if evs := stream.received(); len(evs) >= n {
+				return evs
+			}
+		}
+		t.Fatalf("Expected %d events, got %d", n, len(stream.received()))
+		return nil
+	}
+
+	evs := waitEvents(1)
+	require.Equal(t, apipb.AlertGroupEvent_UPDATED, evs[0].Type)
+	require.Equal(t, "g1", evs[0].Group.GroupKey)
+
+	setGroups(group("g1", "a", "b"), group("g2", "c"))
+	evs = waitEvents(3)
+	require.Len(t, evs[1].Group.Alerts, 2)
+	require.Equal(t, "g2", evs[2].Group.GroupKey)
+
+	setGroups(group("g2", "c"))
+	evs = waitEvents(4)
+	require.Equal(t, apipb.AlertGroupEvent_REMOVED, evs[3].Type)
+	require.Equal(t, "g1", evs[3].Group.GroupKey)
+
+	// Unchanged groups are not sent again.
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, stream.received(), 4)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	err := s.WatchAlertGroups(&apipb.WatchAlertGroupsRequest{Receiver: "team-("}, &watchStream{ctx: context.Background()})
+	require.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
@@ -0,0 +1,253 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+var (
+	alertIngestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "alertmanager",
+		Name:      "alerts_ingest_duration_seconds",
+		Help:      "Duration of the requests posting alerts.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	alertIngestBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "alerts_ingest_received_bytes_total",
+		Help:      "The total number of bytes of the request bodies posting alerts as they were received, by content encoding.",
+	}, []string{"encoding"})
+
+	alertIngestBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "alertmanager",
+		Name:      "alerts_ingest_batch_size",
+		Help:      "Number of alerts posted per request.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	alertIngestRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "alerts_ingest_rejected_total",
+		Help:      "The total number of requests posting alerts that were rejected for exceeding a limit.",
+	}, []string{"reason"})
+)
+
+func init() {
+	for _, enc := range []string{encodingIdentity, encodingGzip, encodingSnappy} {
+		alertIngestBytes.WithLabelValues(enc)
+	}
+	alertIngestRejected.WithLabelValues(reasonBatchSize)
+	alertIngestRejected.WithLabelValues(reasonBodySize)
+
+	prometheus.Register(alertIngestDuration)
+	prometheus.Register(alertIngestBytes)
+	prometheus.Register(alertIngestBatchSize)
+	prometheus.Register(alertIngestRejected)
+}
+
+// Content encodings of the request bodies posting alerts. Snappy bodies use
+// the block format, like the remote write requests of Prometheus.
+const (
+	encodingIdentity = "identity"
+	encodingGzip     = "gzip"
+	encodingSnappy   = "snappy"
+)
+
+// defaultMaxDecodedBytes limits the size of compressed request bodies once
+// decompressed if the configuration sets no limit. Without it, a small body
+// could decompress to an amount of data that exhausts the memory.
+const defaultMaxDecodedBytes = 64 << 20
+
+// Reasons for rejecting requests posting alerts.
+const (
+	reasonBatchSize = "batch_size"
+	reasonBodySize  = "body_size"
+)
+
+// limitError is returned for requests exceeding an ingestion limit.
+type limitError struct {
+	reason string
+	err    error
+}
+
+func (e *limitError) Error() string {
+	return e.err.Error()
+}
+
+// receiveAlerts reads the alerts from the request body, which may be
+// compressed according to its Content-Encoding header, and enforces the
+// ingestion limits of the configuration.
+func (api *API) receiveAlerts(r *http.Request) ([]*types.Alert, error) {
+	defer r.Body.Close()
+
+	var (
+		maxBatchSize int
+		maxBodyBytes int64
+	)
+	api.mtx.RLock()
+	if api.config != nil && api.config.AlertIngestion != nil {
+		maxBatchSize = api.config.AlertIngestion.MaxBatchSize
+		maxBodyBytes = api.config.AlertIngestion.MaxBodyBytes
+	}
+	api.mtx.RUnlock()
+
+	enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	switch enc {
+	case "":
+		enc = encodingIdentity
+	case encodingIdentity, encodingGzip, encodingSnappy:
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", enc)
+	}
+
+	cr := &countingReader{r: r.Body}
+	alerts, err := decodeAlerts(enc, cr, maxBodyBytes)
+	alertIngestBytes.WithLabelValues(enc).Add(float64(cr.n))
+	if err == nil {
+		alertIngestBatchSize.Observe(float64(len(alerts)))
+		if maxBatchSize > 0 && len(alerts) > maxBatchSize {
+			err = &limitError{
+				reason: reasonBatchSize,
+				err:    fmt.Errorf("request posts %d alerts, more than the limit of %d", len(alerts), maxBatchSize),
+			}
+		}
+	}
+	if err != nil {
+		if le, ok := err.(*limitError); ok {
+			alertIngestRejected.WithLabelValues(le.reason).Inc()
+		}
+		level.Debug(api.logger).Log("msg", "Decoding alerts failed", "encoding", enc, "err", err)
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func decodeAlerts(enc string, body io.Reader, maxBytes int64) ([]*types.Alert, error) {
+	r, err := decodeBody(enc, body, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	var alerts []*types.Alert
+	if err := json.NewDecoder(r).Decode(&alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// decodeBody returns a reader of the body decompressed according to the
+// content encoding. The reader fails once more than max bytes were read.
+// Uncompressed bodies are unlimited if max is zero, compressed ones are
+// limited to defaultMaxDecodedBytes.
+func decodeBody(enc string, body io.Reader, max int64) (io.Reader, error) {
+	if enc != encodingIdentity && max <= 0 {
+		max = defaultMaxDecodedBytes
+	}
+	switch enc {
+	case encodingIdentity:
+		return limitBody(body, max), nil
+	case encodingGzip:
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return limitBody(gr, max), nil
+	case encodingSnappy:
+		// A snappy block is only a little larger than its decoded content
+		// if it cannot be compressed.
+		if n := snappy.MaxEncodedLen(int(max)); n > 0 {
+			body = limitBody(body, int64(n))
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		n, err := snappy.DecodedLen(b)
+		if err != nil {
+			return nil, err
+		}
+		if int64(n) > max {
+			return nil, bodyTooLarge(max)
+		}
+		b, err = snappy.Decode(nil, b)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	}
+	return nil, fmt.Errorf("unsupported content encoding %q", enc)
+}
+
+func bodyTooLarge(max int64) error {
+	return &limitError{
+		reason: reasonBodySize,
+		err:    fmt.Errorf("request body is larger than the limit of %d bytes", max),
+	}
+}
+
+func limitBody(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &maxBytesReader{r: r, max: max, n: max}
+}
+
+// maxBytesReader fails once more than max bytes were read from r.
+type maxBytesReader struct {
+	r      io.Reader
+	max, n int64
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.n < 0 {
+		return 0, bodyTooLarge(r.max)
+	}
+	// Read one byte more than allowed to tell whether the limit was
+	// exceeded or the body ended right at it.
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+	if r.n < 0 {
+		return n + int(r.n), bodyTooLarge(r.max)
+	}
+	return n, err
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
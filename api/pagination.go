@@ -0,0 +1,187 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// totalCountHeader is the header of list responses holding the number of
+// results before pagination.
+const totalCountHeader = "X-Total-Count"
+
+// listOptions sorts and paginates the results of list requests.
+type listOptions struct {
+	// sort is the field to sort by, empty for the default order.
+	sort string
+	desc bool
+	// limit is the maximum number of results, zero means unlimited.
+	limit  int
+	offset int
+}
+
+// parseListOptions parses the sort, limit and offset parameters of the
+// request. The sort parameter must be one of the fields, optionally
+// prefixed with "-" for descending order.
+func parseListOptions(r *http.Request, fields ...string) (listOptions, error) {
+	var o listOptions
+	if s := r.FormValue("sort"); s != "" {
+		o.sort = strings.TrimPrefix(s, "-")
+		o.desc = o.sort != s
+		var ok bool
+		for _, f := range fields {
+			if f == o.sort {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return o, fmt.Errorf("unknown sort field %q, must be one of %s", o.sort, strings.Join(fields, ", "))
+		}
+	}
+	for _, p := range []struct {
+		name string
+		v    *int
+	}{
+		{name: "limit", v: &o.limit},
+		{name: "offset", v: &o.offset},
+	} {
+		s := r.FormValue(p.name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			return o, fmt.Errorf("parameter %q must be a non-negative integer, not %q", p.name, s)
+		}
+		*p.v = v
+	}
+	return o, nil
+}
+
+// page returns the bounds of the page within n results.
+func (o listOptions) page(n int) (start, end int) {
+	start, end = o.offset, n
+	if start > n {
+		start = n
+	}
+	if o.limit > 0 && start+o.limit < end {
+		end = start + o.limit
+	}
+	return start, end
+}
+
+// less orders the results i and j by a field whose values are compared
+// with before, keeping the previous order of equal values.
+func (o listOptions) less(before func(i, j int) bool) func(i, j int) bool {
+	if o.desc {
+		return func(i, j int) bool { return before(j, i) }
+	}
+	return before
+}
+
+// sortAlerts sorts the alerts by fingerprint or the sort field of the
+// options. Alerts that were never notified come last when sorting by the
+// time of the last notification.
+func sortAlerts(alerts []*dispatch.APIAlert, o listOptions, lastNotified func() map[string]time.Time) {
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Fingerprint < alerts[j].Fingerprint
+	})
+	switch o.sort {
+	case "fingerprint":
+		if o.desc {
+			sort.SliceStable(alerts, func(i, j int) bool {
+				return alerts[i].Fingerprint > alerts[j].Fingerprint
+			})
+		}
+	case "startsAt":
+		sort.SliceStable(alerts, o.less(func(i, j int) bool {
+			return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+		}))
+	case "lastNotified":
+		notified := lastNotified()
+		sort.SliceStable(alerts, func(i, j int) bool {
+			ti, tj := notified[alerts[i].Fingerprint], notified[alerts[j].Fingerprint]
+			if ti.IsZero() || tj.IsZero() {
+				return !ti.IsZero() && tj.IsZero()
+			}
+			if o.desc {
+				return ti.After(tj)
+			}
+			return ti.Before(tj)
+		})
+	}
+}
+
+// sortSilences returns the silences sorted by the sort field of the
+// options. By default, active silences ending first come first, followed by
+// the pending silences starting first and the most recently expired
+// silences.
+func sortSilences(sils []*types.Silence, o listOptions) []*types.Silence {
+	switch o.sort {
+	case "startsAt":
+		sort.SliceStable(sils, o.less(func(i, j int) bool {
+			return sils[i].StartsAt.Before(sils[j].StartsAt)
+		}))
+		return sils
+	case "endsAt":
+		sort.SliceStable(sils, o.less(func(i, j int) bool {
+			return sils[i].EndsAt.Before(sils[j].EndsAt)
+		}))
+		return sils
+	case "updatedAt":
+		sort.SliceStable(sils, o.less(func(i, j int) bool {
+			return sils[i].UpdatedAt.Before(sils[j].UpdatedAt)
+		}))
+		return sils
+	}
+
+	var active, pending, expired []*types.Silence
+	for _, s := range sils {
+		switch s.Status.State {
+		case types.SilenceStateActive:
+			active = append(active, s)
+		case types.SilenceStatePending:
+			pending = append(pending, s)
+		case types.SilenceStateExpired:
+			expired = append(expired, s)
+		}
+	}
+
+	sort.Slice(active, func(i int, j int) bool {
+		return active[i].EndsAt.Before(active[j].EndsAt)
+	})
+	sort.Slice(pending, func(i int, j int) bool {
+		return pending[i].StartsAt.Before(pending[j].EndsAt)
+	})
+	sort.Slice(expired, func(i int, j int) bool {
+		return expired[i].EndsAt.After(expired[j].EndsAt)
+	})
+
+	// Initialize silences explicitly to an empty list (instead of nil)
+	// So that it does not get converted to "null" in JSON.
+	silences := []*types.Silence{}
+	silences = append(silences, active...)
+	silences = append(silences, pending...)
+	silences = append(silences, expired...)
+	return silences
+}
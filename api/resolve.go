@@ -0,0 +1,152 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/types"
+)
+
+var numManuallyResolvedAlerts = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "alerts_manually_resolved_total",
+	Help:      "The total number of alerts resolved through the API instead of by their source.",
+})
+
+func init() {
+	prometheus.Register(numManuallyResolvedAlerts)
+}
+
+// resolveRequest selects the alerts to resolve by their fingerprints or by
+// matchers. Alerts selected by either are resolved.
+type resolveRequest struct {
+	Fingerprints []string       `json:"fingerprints"`
+	Matchers     types.Matchers `json:"matchers"`
+}
+
+// resolveAlerts resolves firing alerts manually, e.g. if their source stopped
+// sending them and they would only resolve after the resolve timeout. It
+// responds with the fingerprints of the resolved alerts.
+func (api *API) resolveAlerts(w http.ResponseWriter, r *http.Request) {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return
+	}
+
+	var req resolveRequest
+	if err := api.receive(r, &req); err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+	fps, err := api.selectAlerts(&req, tm)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorBadData,
+			err: err,
+		}, nil)
+		return
+	}
+
+	resolved, err := api.alerts.Resolve(time.Now(), fps...)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorInternal,
+			err: err,
+		}, nil)
+		return
+	}
+	numManuallyResolvedAlerts.Add(float64(len(resolved)))
+
+	res := make([]string, 0, len(resolved))
+	for _, a := range resolved {
+		res = append(res, a.Fingerprint().String())
+	}
+	sort.Strings(res)
+	if len(resolved) > 0 {
+		api.recordRequest(r, audit.ActionAlertsResolve, "", resolved)
+	}
+
+	api.respond(w, struct {
+		Fingerprints []string `json:"fingerprints"`
+	}{
+		Fingerprints: res,
+	})
+}
+
+// selectAlerts returns the fingerprints of the firing alerts selected by the
+// request that belong to the tenant, if any.
+func (api *API) selectAlerts(req *resolveRequest, tm *labels.Matcher) ([]model.Fingerprint, error) {
+	if len(req.Fingerprints) == 0 && len(req.Matchers) == 0 {
+		return nil, errors.New("fingerprints or matchers are required")
+	}
+
+	selected := map[model.Fingerprint]struct{}{}
+	for _, s := range req.Fingerprints {
+		fp, err := model.ParseFingerprint(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fingerprint %q", s)
+		}
+		selected[fp] = struct{}{}
+	}
+
+	if len(req.Matchers) > 0 {
+		positive := false
+		for _, m := range req.Matchers {
+			if err := m.Validate(); err != nil {
+				return nil, err
+			}
+			if err := m.Init(); err != nil {
+				return nil, err
+			}
+			positive = positive || !m.IsNegative
+		}
+		if !positive {
+			return nil, errors.New("at least one matcher must not be negative")
+		}
+
+		alerts := api.alerts.GetPending()
+		defer alerts.Close()
+		for a := range alerts.Next() {
+			if req.Matchers.Match(a.Labels) {
+				selected[a.Fingerprint()] = struct{}{}
+			}
+		}
+		if err := alerts.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	fps := make([]model.Fingerprint, 0, len(selected))
+	for fp := range selected {
+		if tm != nil && !api.alertOfTenant(fp, tm) {
+			continue
+		}
+		fps = append(fps, fp)
+	}
+	sort.Sort(model.Fingerprints(fps))
+	return fps, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// checkSilenceLimits returns an error if the silence exceeds the silence
+// limits of the configuration. The limit of matched firing alerts is not
+// enforced for forced silences.
+func (api *API) checkSilenceLimits(sil *types.Silence, force bool) error {
+	api.mtx.RLock()
+	conf := api.config
+	api.mtx.RUnlock()
+	if conf == nil || conf.SilenceLimits == nil {
+		return nil
+	}
+	sl := conf.SilenceLimits
+
+	for _, ln := range sl.RequiredLabels {
+		if !hasEqualityMatcher(sil.Matchers, string(ln)) {
+			return fmt.Errorf("silence must have an equality matcher for label %q", ln)
+		}
+	}
+
+	if sl.MaxDuration > 0 {
+		start, end := sil.StartsAt, sil.EndsAt
+		if now := time.Now(); start.Before(now) {
+			start = now
+		}
+		if sil.Renewal != nil && sil.Renewal.Until.After(end) {
+			end = sil.Renewal.Until
+		}
+		if end.Sub(start) > time.Duration(sl.MaxDuration) {
+			return fmt.Errorf("silence must not last longer than %s", sl.MaxDuration)
+		}
+	}
+
+	if sl.MaxMatchedAlerts > 0 && !force {
+		n, err := api.countFiringAlerts(sil.Matchers)
+		if err != nil {
+			return err
+		}
+		if n > sl.MaxMatchedAlerts {
+			return fmt.Errorf("silence matches %d firing alerts, more than the limit of %d; force it to create it anyway", n, sl.MaxMatchedAlerts)
+		}
+	}
+	return nil
+}
+
+func hasEqualityMatcher(ms types.Matchers, name string) bool {
+	for _, m := range ms {
+		if m.Name == name && !m.IsRegex && !m.IsNegative && m.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// countFiringAlerts returns the number of unresolved alerts the matchers
+// match.
+func (api *API) countFiringAlerts(ms types.Matchers) (int, error) {
+	matchers := make(types.Matchers, 0, len(ms))
+	for _, m := range ms {
+		m := *m
+		if err := m.Init(); err != nil {
+			return 0, err
+		}
+		matchers = append(matchers, &m)
+	}
+
+	alerts := api.alerts.GetPending()
+	defer alerts.Close()
+
+	var (
+		n   int
+		now = time.Now()
+	)
+	for a := range alerts.Next() {
+		if !a.ResolvedAt(now) && matchers.Match(a.Labels) {
+			n++
+		}
+	}
+	return n, nil
+}
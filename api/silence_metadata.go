@@ -0,0 +1,72 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// checkSilenceMetadata returns an error if the metadata of the silence does
+// not conform to the metadata fields of the configuration. Any metadata is
+// accepted if no fields are configured.
+func (api *API) checkSilenceMetadata(sil *types.Silence) error {
+	api.mtx.RLock()
+	conf := api.config
+	api.mtx.RUnlock()
+	if conf == nil || len(conf.SilenceMetadata) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]*config.SilenceMetadataField, len(conf.SilenceMetadata))
+	for _, f := range conf.SilenceMetadata {
+		fields[f.Name] = f
+		v, ok := sil.Metadata[f.Name]
+		if f.Required && (!ok || v == "") {
+			return fmt.Errorf("silence metadata field %q is required", f.Name)
+		}
+		if ok && v != "" && f.Regex != nil && !f.Regex.MatchString(v) {
+			return fmt.Errorf("value %q of silence metadata field %q does not match %s", v, f.Name, f.Regex)
+		}
+	}
+
+	names := make([]string, 0, len(sil.Metadata))
+	for name := range sil.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("unknown silence metadata field %q", name)
+		}
+	}
+	return nil
+}
+
+// silenceMetadataFields responds with the configured metadata fields of
+// silences, e.g. to render them in forms.
+func (api *API) silenceMetadataFields(w http.ResponseWriter, r *http.Request) {
+	api.mtx.RLock()
+	fields := []*config.SilenceMetadataField{}
+	if api.config != nil && api.config.SilenceMetadata != nil {
+		fields = api.config.SilenceMetadata
+	}
+	api.mtx.RUnlock()
+
+	api.respond(w, fields)
+}
@@ -0,0 +1,338 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/parse"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// slackMaxClockSkew is the maximum age of a Slack request to protect against
+// replay attacks.
+const slackMaxClockSkew = 5 * time.Minute
+
+const slackHelp = "Usage:\n" +
+	"• `silence [duration] <matchers>` silences the matching alerts\n" +
+	"• `ack [duration] <matchers>` acknowledges the matching alerts\n" +
+	"• `show <matchers>` shows the matching alert groups\n" +
+	"Matchers are separated by commas, e.g. `alertname=\"HighLatency\",instance=~\"web.*\"`."
+
+// slackResponse is the response to slash commands and interactive actions.
+type slackResponse struct {
+	ResponseType    string `json:"response_type,omitempty"`
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original"`
+}
+
+// slackActionPayload is the payload of interactive message actions.
+type slackActionPayload struct {
+	Type    string `json:"type"`
+	Actions []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"actions"`
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+// slackRequest verifies the signature of a Slack request and returns its
+// form values.
+func (api *API) slackRequest(w http.ResponseWriter, r *http.Request) (*config.SlackInteractiveConfig, url.Values, bool) {
+	api.mtx.RLock()
+	var conf *config.SlackInteractiveConfig
+	if api.config != nil {
+		conf = api.config.SlackInteractive
+	}
+	api.mtx.RUnlock()
+
+	if conf == nil {
+		http.Error(w, "Slack interactivity is not configured", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if err := verifySlackSignature(conf.SigningSecret, r.Header, body, time.Now()); err != nil {
+		level.Warn(api.logger).Log("msg", "Invalid Slack request", "err", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, nil, false
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return conf, form, true
+}
+
+// verifySlackSignature verifies the signature of a Slack request as described
+// in https://api.slack.com/docs/verifying-requests-from-slack.
+func verifySlackSignature(secret config.Secret, h http.Header, body []byte, now time.Time) error {
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request timestamp %q", ts)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > slackMaxClockSkew || d < -slackMaxClockSkew {
+		return fmt.Errorf("request timestamp too far from current time")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(h.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (api *API) slackCommand(w http.ResponseWriter, r *http.Request) {
+	conf, form, ok := api.slackRequest(w, r)
+	if !ok {
+		return
+	}
+	text, public := api.runSlackCommand(conf, form.Get("text"), form.Get("user_name"))
+
+	res := slackResponse{ResponseType: "ephemeral", Text: text}
+	if public {
+		res.ResponseType = "in_channel"
+	}
+	respondSlack(w, res)
+}
+
+func (api *API) slackAction(w http.ResponseWriter, r *http.Request) {
+	conf, form, ok := api.slackRequest(w, r)
+	if !ok {
+		return
+	}
+	var p slackActionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(p.Actions) == 0 {
+		http.Error(w, "no action in payload", http.StatusBadRequest)
+		return
+	}
+	// Buttons name the command and hold its arguments as their value.
+	a := p.Actions[0]
+	text, public := api.runSlackCommand(conf, a.Name+" "+a.Value, p.User.Name)
+
+	res := slackResponse{Text: text}
+	if public {
+		res.ResponseType = "in_channel"
+	}
+	respondSlack(w, res)
+}
+
+func respondSlack(w http.ResponseWriter, res slackResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// runSlackCommand executes a command and returns the response text and
+// whether the response is shown to the whole channel.
+func (api *API) runSlackCommand(conf *config.SlackInteractiveConfig, text, user string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return slackHelp, false
+	}
+	cmd, args := fields[0], strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), fields[0]))
+
+	var err error
+	switch cmd {
+	case "silence":
+		if text, err = api.slackSilence(args, user, time.Duration(conf.SilenceDuration)); err == nil {
+			return text, true
+		}
+	case "ack":
+		if text, err = api.slackAck(args, user, time.Duration(conf.AckDuration)); err == nil {
+			return text, true
+		}
+	case "show":
+		if text, err = api.slackShow(args); err == nil {
+			return text, false
+		}
+	default:
+		return slackHelp, false
+	}
+	return fmt.Sprintf("Error: %s", err), false
+}
+
+// slackArgs parses the optional leading duration and the matchers of a
+// command.
+func slackArgs(args string, d time.Duration) (time.Duration, []*labels.Matcher, error) {
+	if f := strings.Fields(args); len(f) > 0 {
+		if md, err := model.ParseDuration(f[0]); err == nil {
+			d = time.Duration(md)
+			args = strings.TrimSpace(strings.TrimPrefix(args, f[0]))
+		}
+	}
+	if args == "" {
+		return 0, nil, fmt.Errorf("no matchers given")
+	}
+	matchers, err := parse.Matchers(args)
+	if err != nil {
+		return 0, nil, err
+	}
+	if d <= 0 {
+		return 0, nil, fmt.Errorf("duration must be greater than 0")
+	}
+	return d, matchers, nil
+}
+
+func matchersString(matchers []*labels.Matcher) string {
+	s := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		s = append(s, m.String())
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+func (api *API) slackSilence(args, user string, d time.Duration) (string, error) {
+	d, matchers, err := slackArgs(args, d)
+	if err != nil {
+		return "", err
+	}
+
+	// Silences from Slack are subject to the same limits and metadata
+	// requirements as those created through the API.
+	now := time.Now()
+	sil := &types.Silence{
+		StartsAt:  now,
+		EndsAt:    now.Add(d),
+		CreatedBy: user,
+		Comment:   fmt.Sprintf("Silenced from Slack by @%s", user),
+	}
+	for _, m := range matchers {
+		sil.Matchers = append(sil.Matchers, &types.Matcher{
+			Name:       m.Name,
+			Value:      m.Value,
+			IsRegex:    m.Type == labels.MatchRegexp || m.Type == labels.MatchNotRegexp,
+			IsNegative: m.Type == labels.MatchNotEqual || m.Type == labels.MatchNotRegexp,
+		})
+	}
+	psil, err := api.prepareSilence(sil, nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	sid, err := api.silences.Set(psil)
+	if err != nil {
+		return "", err
+	}
+	api.record(&audit.Event{Action: audit.ActionSilenceCreate, Actor: slackActor(user), Target: sid, Payload: psil})
+	return fmt.Sprintf("@%s silenced `%s` for %s (silence %s)", user, matchersString(matchers), model.Duration(d), sid), nil
+}
+
+func (api *API) slackAck(args, user string, d time.Duration) (string, error) {
+	if api.acks == nil {
+		return "", fmt.Errorf("acknowledgements are not enabled")
+	}
+	d, matchers, err := slackArgs(args, d)
+	if err != nil {
+		return "", err
+	}
+
+	alerts := api.alerts.GetPending()
+	defer alerts.Close()
+
+	var (
+		now       = time.Now()
+		expiresAt = now.Add(d).UTC()
+		n         int
+	)
+	for a := range alerts.Next() {
+		if a.Resolved() || !alertMatchesFilterLabels(&a.Alert, matchers) {
+			continue
+		}
+		ack := &types.Acknowledgement{
+			Fingerprint: a.Fingerprint().String(),
+			CreatedBy:   user,
+			Comment:     fmt.Sprintf("Acknowledged from Slack by @%s", user),
+			ExpiresAt:   expiresAt,
+		}
+		if err := api.acks.Set(ack); err != nil {
+			return "", err
+		}
+		api.record(&audit.Event{Action: audit.ActionAckCreate, Actor: slackActor(user), Target: ack.Fingerprint, Payload: ack})
+		n++
+	}
+	if err := alerts.Err(); err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", fmt.Errorf("no firing alerts match `%s`", matchersString(matchers))
+	}
+	return fmt.Sprintf("@%s acknowledged %d alert(s) matching `%s` for %s", user, n, matchersString(matchers), model.Duration(d)), nil
+}
+
+// slackActor returns the actor of audit events for a Slack user.
+func slackActor(user string) string {
+	return "slack:" + user
+}
+
+func (api *API) slackShow(args string) (string, error) {
+	matchers, err := parse.Matchers(args)
+	if err != nil {
+		return "", err
+	}
+	groups := api.groups(matchers)
+	if len(groups) == 0 {
+		return "No alert groups match.", nil
+	}
+
+	var buf bytes.Buffer
+	for _, g := range groups {
+		names := map[string]int{}
+		for _, b := range g.Blocks {
+			for _, a := range b.Alerts {
+				names[string(a.Labels[model.AlertNameLabel])]++
+			}
+		}
+		sorted := make([]string, 0, len(names))
+		for name, n := range names {
+			sorted = append(sorted, fmt.Sprintf("%s (%d)", name, n))
+		}
+		sort.Strings(sorted)
+		fmt.Fprintf(&buf, "• `%s`: %s\n", g.Labels, strings.Join(sorted, ", "))
+	}
+	return buf.String(), nil
+}
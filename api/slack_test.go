@@ -0,0 +1,167 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/ack"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func signSlackRequest(r *http.Request, body string, ts time.Time) {
+	sts := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSigningSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", sts, body)
+
+	r.Header.Set("X-Slack-Request-Timestamp", sts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	now := time.Now()
+	body := "text=show+alertname%3DFoo"
+
+	for _, tc := range []struct {
+		name string
+		ts   time.Time
+		body string
+		err  bool
+	}{
+		{name: "valid", ts: now, body: body},
+		{name: "tampered body", ts: now, body: body + "x", err: true},
+		{name: "expired", ts: now.Add(-10 * time.Minute), body: body, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/slack/command", nil)
+			signSlackRequest(r, body, tc.ts)
+
+			err := verifySlackSignature(testSigningSecret, r.Header, []byte(tc.body), now)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSlackCommand(t *testing.T) {
+	firing := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "HighLatency", "instance": "web-1"},
+		StartsAt: time.Now().Add(-time.Hour),
+	}}
+	alerts := newFakeAlerts([]*types.Alert{firing}, false)
+
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	acks, err := ack.New(ack.Options{})
+	require.NoError(t, err)
+
+	api := New(Options{
+		Alerts:      alerts,
+		Silences:    silences,
+		Acks:        acks,
+		Groups:      groupAlerts,
+		AlertStatus: newGetAlertStatus(alerts),
+	})
+	conf := config.DefaultSlackInteractiveConfig
+	conf.SigningSecret = testSigningSecret
+	api.config = &config.Config{SlackInteractive: &conf}
+
+	do := func(text string) slackResponse {
+		body := url.Values{"text": {text}, "user_name": {"alice"}}.Encode()
+		r := httptest.NewRequest("POST", "/slack/command", strings.NewReader(body))
+		signSlackRequest(r, body, time.Now())
+		w := httptest.NewRecorder()
+
+		api.slackCommand(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res slackResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		return res
+	}
+
+	res := do("help")
+	require.Equal(t, slackHelp, res.Text)
+
+	res = do(`silence 3h alertname="HighLatency", instance=~"web-.*"`)
+	require.Equal(t, "in_channel", res.ResponseType)
+	require.Contains(t, res.Text, "@alice silenced")
+	sils, err := silences.Query()
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	require.Equal(t, "alice", sils[0].CreatedBy)
+	require.Len(t, sils[0].Matchers, 2)
+	require.WithinDuration(t, time.Now().Add(3*time.Hour), sils[0].EndsAt, time.Minute)
+
+	res = do(`silence alertname!="HighLatency"`)
+	require.Equal(t, "ephemeral", res.ResponseType)
+	require.Contains(t, res.Text, "Error")
+
+	res = do(`ack alertname="HighLatency"`)
+	require.Contains(t, res.Text, "acknowledged 1 alert(s)")
+	a, ok := acks.Get(firing.Fingerprint())
+	require.True(t, ok)
+	require.Equal(t, "alice", a.CreatedBy)
+
+	res = do(`ack alertname="Other"`)
+	require.Contains(t, res.Text, "Error: no firing alerts")
+
+	// Silences from Slack are subject to the silence limits.
+	api.config.SilenceLimits = &config.SilenceLimitsConfig{
+		RequiredLabels: model.LabelNames{"alertname"},
+		MaxDuration:    model.Duration(24 * time.Hour),
+	}
+	for _, text := range []string{
+		`silence 1y alertname=~".*"`,
+		`silence 1y alertname="HighLatency"`,
+	} {
+		res = do(text)
+		require.Equal(t, "ephemeral", res.ResponseType, text)
+		require.Contains(t, res.Text, "Error", text)
+	}
+	sils, err = silences.Query()
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+
+	res = do(`silence 3h alertname="HighLatency"`)
+	require.Contains(t, res.Text, "@alice silenced")
+
+	// Requests with an invalid signature are rejected.
+	r := httptest.NewRequest("POST", "/slack/command", strings.NewReader("text=help"))
+	signSlackRequest(r, "text=show", time.Now())
+	w := httptest.NewRecorder()
+	api.slackCommand(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
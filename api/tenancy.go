@@ -0,0 +1,160 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// errNoTenant is returned for requests without a tenant header if tenancy
+// is enabled.
+var errNoTenant = errors.New("missing tenant header")
+
+// tenantMatcher returns a matcher selecting the alerts and silences of the
+// tenant of the request. It returns nil if tenancy is disabled or the
+// tenant is an admin tenant.
+func (api *API) tenantMatcher(r *http.Request) (*labels.Matcher, error) {
+	return api.tenantMatcherOf(r.Header.Get(config.TenantHeader))
+}
+
+// tenantMatcherOf returns the matcher selecting the alerts and silences of
+// the tenant like tenantMatcher.
+func (api *API) tenantMatcherOf(tenant string) (*labels.Matcher, error) {
+	api.mtx.RLock()
+	conf := api.config
+	api.mtx.RUnlock()
+	if conf == nil || conf.Tenancy == nil {
+		return nil, nil
+	}
+	tc := conf.Tenancy
+
+	if tenant == "" {
+		return nil, errNoTenant
+	}
+	if tc.IsAdmin(tenant) {
+		return nil, nil
+	}
+	return labels.NewMatcher(labels.MatchEqual, string(tc.Label), tenant)
+}
+
+// tenant returns the tenant matcher of the request like tenantMatcher. It
+// responds with an error and returns false if the request has no tenant.
+func (api *API) tenant(w http.ResponseWriter, r *http.Request) (*labels.Matcher, bool) {
+	tm, err := api.tenantMatcher(r)
+	if err != nil {
+		api.respondError(w, apiError{
+			typ: errorUnauthorized,
+			err: err,
+		}, nil)
+		return nil, false
+	}
+	return tm, true
+}
+
+// requireAdmin responds with an error and returns false unless the request
+// may act on the data of all tenants.
+func (api *API) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	tm, ok := api.tenant(w, r)
+	if !ok {
+		return false
+	}
+	if tm != nil {
+		api.respondError(w, apiError{
+			typ: errorForbidden,
+			err: fmt.Errorf("tenant %q is not an admin tenant", tm.Value),
+		}, nil)
+		return false
+	}
+	return true
+}
+
+// scopeAlerts sets the tenant label of alerts without one and returns an
+// error if an alert belongs to another tenant.
+func scopeAlerts(alerts []*types.Alert, tm *labels.Matcher) error {
+	tc := &config.TenancyConfig{Label: model.LabelName(tm.Name)}
+	return tc.ScopeAlerts(tm.Value, alerts)
+}
+
+// scopeSilence restricts the silence to the alerts of the tenant. A matcher
+// on the tenant label is added if the silence has none, other matchers on
+// it must match the tenant exactly.
+func scopeSilence(s *types.Silence, tm *labels.Matcher) error {
+	var found bool
+	for _, m := range s.Matchers {
+		if m.Name != tm.Name {
+			continue
+		}
+		if m.IsRegex || m.IsNegative || m.Value != tm.Value {
+			return fmt.Errorf("silence must only match the alerts of tenant %q", tm.Value)
+		}
+		found = true
+	}
+	if !found {
+		s.Matchers = append(s.Matchers, &types.Matcher{Name: tm.Name, Value: tm.Value})
+	}
+	return nil
+}
+
+// silenceOfTenant returns whether the silence with the ID exists and belongs
+// to the tenant.
+func (api *API) silenceOfTenant(sid string, tm *labels.Matcher) bool {
+	psils, err := api.silences.Query(silence.QIDs(sid))
+	if err != nil || len(psils) == 0 {
+		return false
+	}
+	s, err := silenceFromProto(psils[0])
+	if err != nil {
+		return false
+	}
+	return silenceMatchesFilterLabels(s, []*labels.Matcher{tm})
+}
+
+// alertOfTenant returns whether the alert with the fingerprint exists and
+// belongs to the tenant.
+func (api *API) alertOfTenant(fp model.Fingerprint, tm *labels.Matcher) bool {
+	a, err := api.alerts.Get(fp)
+	if err != nil || a == nil {
+		return false
+	}
+	return alertMatchesFilterLabels(&a.Alert, []*labels.Matcher{tm})
+}
+
+// groupKeyHasTenant returns whether the group key of an aggregation group
+// belongs to the tenant. As the routes group alerts by the tenant label,
+// group keys hold it as a quoted label pair, which cannot appear inside
+// the quoted values of other labels.
+func groupKeyHasTenant(key string, tm *labels.Matcher) bool {
+	pair := fmt.Sprintf("%s=%q", tm.Name, tm.Value)
+	for i := 0; ; {
+		j := strings.Index(key[i:], pair)
+		if j < 0 {
+			return false
+		}
+		i += j
+		if i > 0 && strings.ContainsRune("{, ", rune(key[i-1])) {
+			return true
+		}
+		i += len(pair)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestTenancy(t *testing.T) {
+	now := time.Now()
+	alerts := newFakeAlerts([]*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a1", "team": "a"}, StartsAt: now}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "b1", "team": "b"}, StartsAt: now}},
+	}, false)
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	sidB, err := silences.Set(&silencepb.Silence{
+		Matchers: []*silencepb.Matcher{{Name: "team", Pattern: "b"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	conf, err := config.Load(`
+route:
+  receiver: team
+receivers:
+- name: team
+tenancy:
+  label: team
+  admin_tenants: [ops]
+`)
+	require.NoError(t, err)
+	api := New(Options{
+		Alerts:      alerts,
+		Silences:    silences,
+		Groups:      groupAlerts,
+		AlertStatus: newGetAlertStatus(alerts),
+	})
+	require.NoError(t, api.Update(conf, nil, time.Minute))
+
+	do := func(h http.HandlerFunc, method, tenant, sid string, body interface{}) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		r, err := http.NewRequest(method, "/", bytes.NewReader(b))
+		require.NoError(t, err)
+		if tenant != "" {
+			r.Header.Set("X-Scope-OrgID", tenant)
+		}
+		r = r.WithContext(route.WithParam(r.Context(), "sid", sid))
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+	listAlerts := func(tenant string) []string {
+		w := do(api.listAlerts, "GET", tenant, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var res struct {
+			Data []*types.Alert `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		names := []string{}
+		for _, a := range res.Data {
+			names = append(names, a.Name())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// Requests without a tenant are rejected.
+	require.Equal(t, http.StatusUnauthorized, do(api.listAlerts, "GET", "", "", nil).Code)
+
+	require.Equal(t, []string{"a1"}, listAlerts("a"))
+	require.Equal(t, []string{"a1", "b1"}, listAlerts("ops"))
+
+	// Silences are restricted to the tenant.
+	w := do(api.setSilence, "POST", "a", "", &types.Silence{
+		Matchers: types.Matchers{{Name: "alertname", Value: "a1"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var created struct {
+		Data struct {
+			SilenceID string `json:"silenceId"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	psils, err := silences.Query(silence.QIDs(created.Data.SilenceID))
+	require.NoError(t, err)
+	sil, err := silenceFromProto(psils[0])
+	require.NoError(t, err)
+	tm, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+	require.True(t, silenceMatchesFilterLabels(sil, []*labels.Matcher{tm}))
+
+	w = do(api.setSilence, "POST", "a", "", &types.Silence{
+		Matchers: types.Matchers{{Name: "team", Value: "a|b", IsRegex: true}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The silences of other tenants cannot be seen or modified.
+	require.Equal(t, http.StatusNotFound, do(api.getSilence, "GET", "a", sidB, nil).Code)
+	require.Equal(t, http.StatusOK, do(api.getSilence, "GET", "b", sidB, nil).Code)
+	require.Equal(t, http.StatusBadRequest, do(api.delSilence, "DELETE", "a", sidB, nil).Code)
+	w = do(api.updateSilence, "PUT", "a", sidB, &types.Silence{
+		Matchers: types.Matchers{{Name: "alertname", Value: "a1"}},
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(api.listSilences, "GET", "a", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data []*types.Silence `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	require.Equal(t, created.Data.SilenceID, listed.Data[0].ID)
+
+	// Alerts of other tenants are rejected, alerts without a tenant are
+	// assigned to the tenant of the request or accepted without one.
+	require.Equal(t, http.StatusBadRequest, do(api.addAlerts, "POST", "a", "", []model.Alert{{Labels: model.LabelSet{"alertname": "x", "team": "b"}}}).Code)
+	require.Equal(t, http.StatusOK, do(api.addAlerts, "POST", "a", "", []model.Alert{{Labels: model.LabelSet{"alertname": "x"}}}).Code)
+	require.Equal(t, http.StatusOK, do(api.addAlerts, "POST", "", "", []model.Alert{{Labels: model.LabelSet{"alertname": "x", "team": "b"}}}).Code)
+
+	// Administrative endpoints require an admin tenant.
+	require.Equal(t, http.StatusForbidden, do(api.saveSnapshot, "GET", "a", "", nil).Code)
+	require.Equal(t, http.StatusOK, do(api.saveSnapshot, "GET", "ops", "", nil).Code)
+}
+
+func TestScopeAlerts(t *testing.T) {
+	tm, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "x"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "y", "team": "a"}}},
+	}
+	require.NoError(t, scopeAlerts(alerts, tm))
+	for _, a := range alerts {
+		require.Equal(t, model.LabelValue("a"), a.Labels["team"])
+	}
+
+	require.Error(t, scopeAlerts([]*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "x", "team": "b"}}},
+	}, tm))
+}
+
+func TestGroupKeyHasTenant(t *testing.T) {
+	tm, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		key string
+		exp bool
+	}{
+		{key: `{}:{team="a"}`, exp: true},
+		{key: `{}:{alertname="x", team="a"}`, exp: true},
+		{key: `{}/{team="a"}:{alertname="x"}`, exp: true},
+		{key: `{}:{team="b"}`},
+		{key: `{}:{myteam="a"}`},
+		{key: `{}/{team!="a"}:{alertname="x"}`},
+		{key: `{}:{alertname="team=\"a\""}`},
+		{key: `{}:{alertname="x"}`},
+	} {
+		require.Equal(t, tc.exp, groupKeyHasTenant(tc.key, tm), tc.key)
+	}
+}
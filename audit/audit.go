@@ -0,0 +1,132 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records mutating operations, such as the creation of
+// silences, together with who performed them and when.
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions recorded in audit events.
+const (
+	ActionSilenceCreate  = "silence.create"
+	ActionSilenceUpdate  = "silence.update"
+	ActionSilenceExpire  = "silence.expire"
+	ActionAlertsPost     = "alerts.post"
+	ActionAlertsResolve  = "alerts.resolve"
+	ActionAckCreate      = "ack.create"
+	ActionAckExpire      = "ack.expire"
+	ActionPresetSet      = "preset.set"
+	ActionPresetDelete   = "preset.delete"
+	ActionConfigReload   = "config.reload"
+	ActionReceiverTest   = "receiver.test"
+	ActionStateRestore   = "state.restore"
+	ActionStateGC        = "state.gc"
+	ActionClusterPromote = "cluster.promote"
+	ActionClusterDemote  = "cluster.demote"
+)
+
+// ActorHeader is the request header the acting user is taken from if the
+// request carries no basic authentication. It is typically set by an
+// authenticating proxy in front of the Alertmanager.
+const ActorHeader = "X-Forwarded-User"
+
+// Event is a single mutating operation.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Actor is the user performing the operation, if known.
+	Actor string `json:"actor,omitempty"`
+	// RemoteAddr is the address the operation was requested from.
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	// Target identifies the modified object, e.g. the ID of a silence.
+	Target  string      `json:"target,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// RequestActor returns the user performing the request. It is the user
+// name of the basic authentication or the value of the ActorHeader.
+func RequestActor(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return r.Header.Get(ActorHeader)
+}
+
+// A Logger records audit events.
+type Logger interface {
+	Log(e *Event) error
+}
+
+type jsonLogger struct {
+	mtx sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLogger returns a logger writing each event as a JSON object on a
+// single line to w.
+func NewJSONLogger(w io.Writer) Logger {
+	return &jsonLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *jsonLogger) Log(e *Event) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.enc.Encode(e)
+}
+
+// FileLogger writes events as JSON lines to a file.
+type FileLogger struct {
+	Logger
+	f *os.File
+}
+
+// NewFileLogger returns a logger appending events to the named file, which
+// is created if it does not exist.
+func NewFileLogger(name string) (*FileLogger, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLogger{Logger: NewJSONLogger(f), f: f}, nil
+}
+
+// Close closes the underlying file.
+func (l *FileLogger) Close() error {
+	return l.f.Close()
+}
+
+type multiLogger []Logger
+
+// Multi returns a logger recording events to all given loggers.
+func Multi(ls ...Logger) Logger {
+	return multiLogger(ls)
+}
+
+func (ls multiLogger) Log(e *Event) error {
+	var firstErr error
+	for _, l := range ls {
+		if err := l.Log(e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
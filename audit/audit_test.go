@@ -0,0 +1,101 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "audit.log")
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Events are appended to existing ones across restarts.
+	for _, action := range []string{ActionSilenceCreate, ActionSilenceExpire} {
+		l, err := NewFileLogger(name)
+		require.NoError(t, err)
+		require.NoError(t, l.Log(&Event{
+			Time:       now,
+			Action:     action,
+			Actor:      "alice",
+			RemoteAddr: "10.0.0.1:4567",
+			Target:     "abc",
+			Payload:    map[string]string{"comment": "maintenance"},
+		}))
+		require.NoError(t, l.Close())
+	}
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []map[string]interface{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var v map[string]interface{}
+		require.NoError(t, json.Unmarshal(s.Bytes(), &v))
+		lines = append(lines, v)
+	}
+	require.NoError(t, s.Err())
+	require.Equal(t, 2, len(lines))
+	require.Equal(t, map[string]interface{}{
+		"time":       "2018-06-01T12:00:00Z",
+		"action":     "silence.create",
+		"actor":      "alice",
+		"remoteAddr": "10.0.0.1:4567",
+		"target":     "abc",
+		"payload":    map[string]interface{}{"comment": "maintenance"},
+	}, lines[0])
+	require.Equal(t, "silence.expire", lines[1]["action"])
+}
+
+func TestRequestActor(t *testing.T) {
+	r, err := http.NewRequest("POST", "/api/v1/silences", nil)
+	require.NoError(t, err)
+	require.Equal(t, "", RequestActor(r))
+
+	r.Header.Set(ActorHeader, "bob")
+	require.Equal(t, "bob", RequestActor(r))
+
+	r.SetBasicAuth("alice", "secret")
+	require.Equal(t, "alice", RequestActor(r))
+}
+
+type loggerFunc func(e *Event) error
+
+func (f loggerFunc) Log(e *Event) error { return f(e) }
+
+func TestMulti(t *testing.T) {
+	var n int
+	ok := loggerFunc(func(*Event) error { n++; return nil })
+	failing := loggerFunc(func(*Event) error { return errors.New("full disk") })
+
+	err := Multi(ok, failing, ok).Log(&Event{Action: ActionConfigReload})
+	require.EqualError(t, err, "full disk")
+	require.Equal(t, 2, n, "all loggers must be called")
+}
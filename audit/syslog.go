@@ -0,0 +1,29 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package audit
+
+import "log/syslog"
+
+// NewSyslogLogger returns a logger sending events as JSON to the local
+// syslog daemon with the given tag.
+func NewSyslogLogger(tag string) (Logger, error) {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLogger(w), nil
+}
@@ -17,20 +17,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"os"
+	"time"
 
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/client"
-	"github.com/prometheus/alertmanager/pkg/parse"
 )
 
 type alertQueryCmd struct {
 	inhibited, silenced, active, unprocessed bool
+	showInhibitor                            bool
 	receiver                                 string
 	matcherGroups                            []string
+	fields                                   string
+	sort                                     string
+	limit, offset                            int
+	watch                                    bool
+	watchInterval                            time.Duration
 }
 
 const alertHelp = `View and search through current alerts.
@@ -56,9 +62,56 @@ amtool alert query 'alertname=~foo.*'
 	(similar to prometheus) is used to represent a regex match. Regex matching
 	can be used in combination with a direct match.
 
+amtool alert query 'summary="disk full, {sda1}"' 'service=~"api|web"'
+
+	Values containing spaces, commas or braces are enclosed in double quotes,
+	in which \" and \\ escape quotes and backslashes. Label names consist of
+	ASCII letters, digits and underscores and do not start with a digit.
+
 Amtool supports several flags for filtering the returned alerts by state
 (inhibited, silenced, active, unprocessed). If none of these flags is given,
 only active alerts are returned.
+
+The "--fields" parameter selects and orders the columns of the output, e.g. for
+spreadsheets:
+
+amtool -o csv alert query --fields=alertname,labels.severity,startsAt,summary
+
+	Besides the fields alertname, fingerprint, labels, annotations, summary,
+	startsAt, endsAt, generatorURL, state, receivers, silencedBy, inhibitedBy
+	and instances, labels.<name> and annotations.<name> select a single label
+	or annotation.
+
+The Alertmanager sorts and paginates the alerts with the "--sort", "--limit"
+and "--offset" parameters:
+
+amtool alert query --sort=-lastNotified --limit=50
+
+	returns the 50 alerts notified most recently. The alerts can be sorted by
+	fingerprint, startsAt or lastNotified, a leading '-' reverses the order.
+
+The "--show-inhibitor" parameter shows why alerts are inhibited:
+
+amtool alert query --inhibited --show-inhibitor instance=node0
+
+	lists the inhibited alerts with the alerts inhibiting them and the indexes
+	of the inhibition rules in the configuration. Inhibiting alerts that are
+	inhibited themselves are followed by their inhibitors, e.g.
+	"NodeDown <- DatacenterDown". The extended output describes the rules.
+
+The "--watch" parameter refreshes the output until amtool is interrupted and
+highlights the alerts that started or stopped firing since the last refresh:
+
+amtool alert query --watch --watch-interval=10s severity=critical
+
+A comma-separated list of Alertmanagers queries all of them at once:
+
+amtool --alertmanager.url=http://am-eu:9093,http://am-us:9093 alert query
+
+	Alerts returned by several Alertmanagers are shown once, the instances
+	field lists the Alertmanagers that returned them. Unreachable
+	Alertmanagers are reported without failing the query unless none answers.
+	Sorting and pagination are not supported across Alertmanagers.
 `
 
 func configureAlertCmd(app *kingpin.Application) {
@@ -71,44 +124,186 @@ func configureAlertCmd(app *kingpin.Application) {
 	queryCmd.Flag("silenced", "Show silenced alerts").Short('s').BoolVar(&a.silenced)
 	queryCmd.Flag("active", "Show active alerts").Short('a').BoolVar(&a.active)
 	queryCmd.Flag("unprocessed", "Show unprocessed alerts").Short('u').BoolVar(&a.unprocessed)
+	queryCmd.Flag("show-inhibitor", "Show the alerts and rules inhibiting the inhibited alerts, implies --inhibited").BoolVar(&a.showInhibitor)
 	queryCmd.Flag("receiver", "Show alerts matching receiver (Supports regex syntax)").Short('r').StringVar(&a.receiver)
-	queryCmd.Arg("matcher-groups", "Query filter").StringsVar(&a.matcherGroups)
+	queryCmd.Flag("fields", "Comma-separated fields to output (not supported by json output)").StringVar(&a.fields)
+	queryCmd.Flag("sort", "Sort alerts by fingerprint, startsAt or lastNotified, prefixed with '-' for descending order").StringVar(&a.sort)
+	queryCmd.Flag("limit", "Maximum number of alerts to show").IntVar(&a.limit)
+	queryCmd.Flag("offset", "Number of alerts to skip").IntVar(&a.offset)
+	queryCmd.Flag("watch", "Refresh the output periodically until interrupted, highlighting newly firing and resolved alerts").BoolVar(&a.watch)
+	queryCmd.Flag("watch-interval", "Interval between the refreshes of --watch").Default("5s").DurationVar(&a.watchInterval)
+	queryCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&a.matcherGroups)
 	queryCmd.Action(a.queryAlerts)
+
+	configureAlertAddCmd(alertCmd)
+	configureAlertAckCmd(alertCmd)
+	configureAlertUnackCmd(alertCmd)
+	configureAlertResolveCmd(alertCmd)
+	configureAlertGroupsCmd(alertCmd)
+	configureAlertHistoryCmd(alertCmd)
 }
 
 func (a *alertQueryCmd) queryAlerts(ctx *kingpin.ParseContext) error {
-	var filterString = ""
-	if len(a.matcherGroups) == 1 {
-		// If the parser fails then we likely don't have a (=|=~|!=|!~) so lets
-		// assume that the user wants alertname=<arg> and prepend `alertname=`
-		// to the front.
-		_, err := parse.Matcher(a.matcherGroups[0])
+	var alertAPI client.AlertAPI
+	if len(alertmanagerURLs) > 1 {
+		m, err := newMultiAlertAPI(alertmanagerURLs)
+		if err != nil {
+			return err
+		}
+		alertAPI = m
+		if a.fields == "" && !a.showInhibitor && output == "simple" {
+			a.fields = multiInstanceAlertFields
+		}
+	} else {
+		c, err := NewAPIClient()
 		if err != nil {
-			filterString = fmt.Sprintf("{alertname=%s}", a.matcherGroups[0])
-		} else {
-			filterString = fmt.Sprintf("{%s}", strings.Join(a.matcherGroups, ","))
+			return err
 		}
-	} else if len(a.matcherGroups) > 1 {
-		filterString = fmt.Sprintf("{%s}", strings.Join(a.matcherGroups, ","))
+		alertAPI = client.NewAlertAPI(c)
 	}
-
-	c, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
-	if err != nil {
-		return err
+	if a.showInhibitor {
+		a.inhibited = true
 	}
-	alertAPI := client.NewAlertAPI(c)
 	// If no selector was passed, default to showing active alerts.
 	if !a.silenced && !a.inhibited && !a.active && !a.unprocessed {
 		a.active = true
 	}
-	fetchedAlerts, err := alertAPI.List(context.Background(), filterString, a.receiver, a.silenced, a.inhibited, a.active, a.unprocessed)
+
+	if a.watch {
+		return watch(a.watchInterval, alertWatchLabels, func(w io.Writer) (watchItems, error) {
+			alerts, err := a.render(alertAPI, w, w)
+			return alertWatchItems(alerts), err
+		})
+	}
+	if sf, ok := format.Formatters[output].(format.AlertStreamFormatter); ok && !a.showInhibitor && !quiet {
+		return a.stream(alertAPI, sf, os.Stderr)
+	}
+	_, err := a.render(alertAPI, os.Stdout, os.Stderr)
+	return err
+}
+
+// stream writes each queried alert as soon as it is received and the notes
+// about the output to stderr.
+func (a *alertQueryCmd) stream(alertAPI client.AlertAPI, sf format.AlertStreamFormatter, stderr io.Writer) error {
+	if a.fields != "" {
+		return fmt.Errorf("--fields is not supported by the %s output", output)
+	}
+	filterString, err := matcherGroupsFilter(a.matcherGroups)
+	if err != nil {
+		return err
+	}
+	var n int
+	opts := client.ListOptions{Sort: a.sort, Limit: a.limit, Offset: a.offset}
+	total, err := alertAPI.Stream(context.Background(), filterString, a.receiver, a.silenced, a.inhibited, a.active, a.unprocessed, opts, func(alert *client.ExtendedAlert) error {
+		n++
+		return sf.FormatAlert(alert)
+	})
 	if err != nil {
 		return err
 	}
+	printPageSummary(stderr, "alerts", n, total)
+	if n == 0 {
+		return noMatchError("no alerts matched")
+	}
+	return nil
+}
+
+// render writes the queried alerts to w and the notes about the output to
+// stderr and returns the alerts.
+func (a *alertQueryCmd) render(alertAPI client.AlertAPI, w, stderr io.Writer) ([]*client.ExtendedAlert, error) {
+	filterString, err := matcherGroupsFilter(a.matcherGroups)
+	if err != nil {
+		return nil, err
+	}
+	opts := client.ListOptions{Sort: a.sort, Limit: a.limit, Offset: a.offset}
+	fetchedAlerts, total, err := alertAPI.ListPage(context.Background(), filterString, a.receiver, a.silenced, a.inhibited, a.active, a.unprocessed, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.showInhibitor {
+		if a.fields != "" {
+			return nil, errors.New("--fields is not supported with --show-inhibitor")
+		}
+		// The inhibitions of all alerts are needed to follow the chains of
+		// inhibiting alerts.
+		inhibitions, err := alertAPI.Inhibitions(context.Background(), "")
+		if err != nil {
+			return nil, err
+		}
+		formatter, found := format.Formatters[output]
+		if !found {
+			return nil, errors.New("unknown output formatter")
+		}
+		formatter.SetOutput(w)
+		defer formatter.SetOutput(os.Stdout)
+		if err := formatter.FormatInhibitions(inhibitionChains(fetchedAlerts, inhibitions)); err != nil {
+			return nil, err
+		}
+		printPageSummary(stderr, "alerts", len(fetchedAlerts), total)
+		if len(fetchedAlerts) == 0 {
+			return nil, noMatchError("no alerts matched")
+		}
+		return fetchedAlerts, nil
+	}
 
-	formatter, found := format.Formatters[output]
-	if !found {
-		return errors.New("unknown output formatter")
+	if quiet {
+		for _, alert := range fetchedAlerts {
+			fmt.Fprintln(w, alert.Fingerprint)
+		}
+	} else {
+		formatter, err := fieldsFormatter(a.fields)
+		if err != nil {
+			return nil, err
+		}
+		if a.sort != "" {
+			keepOrder(formatter)
+		}
+		formatter.SetOutput(w)
+		defer formatter.SetOutput(os.Stdout)
+		if err := formatter.FormatAlerts(fetchedAlerts); err != nil {
+			return nil, err
+		}
+		printPageSummary(stderr, "alerts", len(fetchedAlerts), total)
+	}
+	if len(fetchedAlerts) == 0 {
+		return nil, noMatchError("no alerts matched")
+	}
+	return fetchedAlerts, nil
+}
+
+// alertWatchLabels describe the changes of the watched alerts.
+var alertWatchLabels = watchLabels{added: "firing", removed: "resolved"}
+
+// alertWatchItems returns the watched alerts by their fingerprints.
+func alertWatchItems(alerts []*client.ExtendedAlert) watchItems {
+	items := make(watchItems, len(alerts))
+	for _, a := range alerts {
+		items[a.Fingerprint] = modelLabelSet(a.Labels).String()
+	}
+	return items
+}
+
+// inhibitionChains returns the chains of the inhibitions of the alerts in
+// the order of the alerts. Each chain follows the first inhibition of each
+// inhibiting alert until an alert is not inhibited or was already visited.
+func inhibitionChains(alerts []*client.ExtendedAlert, inhibitions []*client.Inhibition) []format.InhibitionChain {
+	byFingerprint := map[string][]*client.Inhibition{}
+	for _, inh := range inhibitions {
+		byFingerprint[inh.Fingerprint] = append(byFingerprint[inh.Fingerprint], inh)
+	}
+
+	var chains []format.InhibitionChain
+	for _, a := range alerts {
+		for _, inh := range byFingerprint[a.Fingerprint] {
+			chain := format.InhibitionChain{inh}
+			visited := map[string]bool{a.Fingerprint: true}
+			for fp := inh.InhibitedBy.Fingerprint; !visited[fp] && len(byFingerprint[fp]) > 0; fp = chain[len(chain)-1].InhibitedBy.Fingerprint {
+				visited[fp] = true
+				chain = append(chain, byFingerprint[fp][0])
+			}
+			chains = append(chains, chain)
+		}
 	}
-	return formatter.FormatAlerts(fetchedAlerts)
+	return chains
 }
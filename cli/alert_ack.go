@@ -0,0 +1,149 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+type alertAckCmd struct {
+	author        string
+	duration      string
+	comment       string
+	matcherGroups []string
+}
+
+const alertAckHelp = `Acknowledge alerts
+
+  Acknowledges all active alerts matching the given matcher groups. Repeat
+  notifications for groups whose alerts are all acknowledged are paused until
+  the acknowledgement expires. New and resolved alerts are still notified.
+
+  amtool alert ack alertname=foo --comment="Looking into it"
+`
+
+const alertUnackHelp = `Remove the acknowledgement of alerts
+
+  amtool alert unack alertname=foo
+`
+
+func configureAlertAckCmd(cc *kingpin.CmdClause) {
+	var (
+		c      = &alertAckCmd{}
+		ackCmd = cc.Command("ack", alertAckHelp)
+	)
+	ackCmd.Flag("author", "Username for CreatedBy field").Short('a').Default(username()).StringVar(&c.author)
+	ackCmd.Flag("duration", "Duration of the acknowledgement").Short('d').Default("1h").StringVar(&c.duration)
+	ackCmd.Flag("comment", "A comment to help describe the acknowledgement").Short('c').StringVar(&c.comment)
+	ackCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).Required().StringsVar(&c.matcherGroups)
+	ackCmd.Action(c.ack)
+}
+
+func configureAlertUnackCmd(cc *kingpin.CmdClause) {
+	var (
+		c        = &alertAckCmd{}
+		unackCmd = cc.Command("unack", alertUnackHelp)
+	)
+	unackCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).Required().StringsVar(&c.matcherGroups)
+	unackCmd.Action(c.unack)
+}
+
+// activeAlerts returns the active alerts matching the matcher groups.
+func (c *alertAckCmd) activeAlerts(apiClient api.Client) ([]*client.ExtendedAlert, error) {
+	filterString, err := matcherGroupsFilter(c.matcherGroups)
+	if err != nil {
+		return nil, err
+	}
+	alertAPI := client.NewAlertAPI(apiClient)
+	alerts, err := alertAPI.List(context.Background(), filterString, "", false, false, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, noMatchError("no active alerts matched")
+	}
+	return alerts, nil
+}
+
+func (c *alertAckCmd) ack(ctx *kingpin.ParseContext) error {
+	d, err := model.ParseDuration(c.duration)
+	if err != nil {
+		return err
+	}
+	if d == 0 {
+		return fmt.Errorf("acknowledgement duration must be greater than 0")
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	alerts, err := c.activeAlerts(apiClient)
+	if err != nil {
+		return err
+	}
+
+	ackAPI := client.NewAckAPI(apiClient)
+	expiresAt := time.Now().UTC().Add(time.Duration(d))
+	for _, a := range alerts {
+		err := ackAPI.Set(context.Background(), types.Acknowledgement{
+			Fingerprint: a.Fingerprint,
+			CreatedBy:   c.author,
+			Comment:     c.comment,
+			ExpiresAt:   expiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(a.Fingerprint)
+	}
+	return nil
+}
+
+func (c *alertAckCmd) unack(ctx *kingpin.ParseContext) error {
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	alerts, err := c.activeAlerts(apiClient)
+	if err != nil {
+		return err
+	}
+
+	ackAPI := client.NewAckAPI(apiClient)
+	unacked := 0
+	for _, a := range alerts {
+		if a.Acknowledgement == nil {
+			continue
+		}
+		if err := ackAPI.Expire(context.Background(), a.Fingerprint); err != nil {
+			return err
+		}
+		fmt.Println(a.Fingerprint)
+		unacked++
+	}
+	if unacked == 0 {
+		return noMatchError("no acknowledged alerts matched")
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+type alertAddCmd struct {
+	annotations  []string
+	generatorURL string
+	labels       []string
+	start        string
+	end          string
+}
+
+const alertAddHelp = `Add a new alert.
+
+This command is used to send an alert to the Alertmanager, e.g. to test routing
+and receivers. The alert is built from the given labels and annotations:
+
+amtool alert add alertname=foo node=bar
+
+	This adds an alert with the labels alertname=foo and node=bar.
+
+amtool alert add foo node=bar
+
+	If alertname is omitted and the first argument does not contain a '=' then
+	it will be assumed to be the value of the alertname label.
+
+amtool alert add foo node=bar --annotation=summary='Node bar is down' --end=2018-01-01T00:00:00Z
+
+	Annotations are given as name=value pairs, the end time resolves the alert.
+`
+
+func configureAlertAddCmd(cc *kingpin.CmdClause) {
+	var (
+		a      = &alertAddCmd{}
+		addCmd = cc.Command("add", alertAddHelp)
+	)
+	addCmd.Arg("labels", "List of labels to be included with the alert").StringsVar(&a.labels)
+	addCmd.Flag("start", "Set when the alert should start. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&a.start)
+	addCmd.Flag("end", "Set when the alert should end. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&a.end)
+	addCmd.Flag("generator-url", "Set the URL of the source that generated the alert").StringVar(&a.generatorURL)
+	addCmd.Flag("annotation", "Set an annotation to be included with the alert, can be repeated").StringsVar(&a.annotations)
+	addCmd.Action(a.addAlert)
+}
+
+// parseLabelPairs parses name=value pairs into a label set.
+func parseLabelPairs(pairs []string) (client.LabelSet, error) {
+	ls := client.LabelSet{}
+	for _, p := range pairs {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid label pair %q, expected name=value", p)
+		}
+		name, value := parts[0], strings.Trim(parts[1], `"`)
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		ls[client.LabelName(name)] = client.LabelValue(value)
+	}
+	return ls, nil
+}
+
+func (a *alertAddCmd) addAlert(ctx *kingpin.ParseContext) error {
+	if len(a.labels) > 0 && !strings.Contains(a.labels[0], "=") {
+		a.labels[0] = fmt.Sprintf("alertname=%s", a.labels[0])
+	}
+	labels, err := parseLabelPairs(a.labels)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return errors.New("no labels specified")
+	}
+	annotations, err := parseLabelPairs(a.annotations)
+	if err != nil {
+		return err
+	}
+
+	var startsAt, endsAt time.Time
+	if a.start != "" {
+		startsAt, err = time.Parse(time.RFC3339, a.start)
+		if err != nil {
+			return err
+		}
+	}
+	if a.end != "" {
+		endsAt, err = time.Parse(time.RFC3339, a.end)
+		if err != nil {
+			return err
+		}
+	}
+	if !startsAt.IsZero() && !endsAt.IsZero() && startsAt.After(endsAt) {
+		return errors.New("alert cannot start after it ends")
+	}
+
+	c, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	alertAPI := client.NewAlertAPI(c)
+	return alertAPI.Push(context.Background(), client.Alert{
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		GeneratorURL: a.generatorURL,
+	})
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+func TestParseLabelPairs(t *testing.T) {
+	ls, err := parseLabelPairs([]string{"alertname=foo", `summary="a=b c"`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := client.LabelSet{"alertname": "foo", "summary": "a=b c"}
+	if !reflect.DeepEqual(expected, ls) {
+		t.Errorf("expected %v, got %v", expected, ls)
+	}
+
+	for _, pairs := range [][]string{{"foo"}, {"0foo=bar"}} {
+		if _, err := parseLabelPairs(pairs); err == nil {
+			t.Errorf("expected error for %v", pairs)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/cli/format"
+	"github.com/prometheus/alertmanager/client"
+)
+
+type alertGroupsCmd struct {
+	receiver      string
+	timers        bool
+	matcherGroups []string
+}
+
+const alertGroupsHelp = `View the alerts grouped like Alertmanager notifies the receivers.
+
+Each group lists the receiver it is sent to, its group labels, its alerts and
+the time it is flushed to the receiver next. The groups can be filtered by
+receiver and by the labels of their alerts:
+
+amtool alert groups --receiver=team-X alertname=foo
+
+	This query shows the groups of team-X containing alerts with the
+	alertname=foo label value pair set.
+
+amtool alert groups --timers --receiver=team-X
+
+	This query shows when the groups of team-X were flushed last and the
+	countdowns until they are flushed next and until their unchanged alerts
+	are notified again after the repeat interval, to tell why an alert was
+	not notified yet. It requires an admin tenant.
+`
+
+func configureAlertGroupsCmd(cc *kingpin.CmdClause) {
+	var (
+		a         = &alertGroupsCmd{}
+		groupsCmd = cc.Command("groups", alertGroupsHelp)
+	)
+	groupsCmd.Flag("receiver", "Show groups of receivers matching the regex").Short('r').StringVar(&a.receiver)
+	groupsCmd.Flag("timers", "Show the flush and repeat timers of the groups").BoolVar(&a.timers)
+	groupsCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&a.matcherGroups)
+	groupsCmd.Action(a.queryGroups)
+}
+
+func (a *alertGroupsCmd) queryGroups(ctx *kingpin.ParseContext) error {
+	c, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	filterString, err := matcherGroupsFilter(a.matcherGroups)
+	if err != nil {
+		return err
+	}
+	alertAPI := client.NewAlertAPI(c)
+	if a.timers {
+		return a.queryTimers(alertAPI, filterString)
+	}
+	groups, err := alertAPI.Groups(context.Background(), filterString, a.receiver)
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		for _, group := range groups {
+			for _, alert := range group.Alerts {
+				fmt.Println(alert.Fingerprint)
+			}
+		}
+	} else {
+		formatter, found := format.Formatters[output]
+		if !found {
+			return errors.New("unknown output formatter")
+		}
+		if err := formatter.FormatAlertGroups(groups); err != nil {
+			return err
+		}
+	}
+	if len(groups) == 0 {
+		return noMatchError("no alert groups matched")
+	}
+	return nil
+}
+
+func (a *alertGroupsCmd) queryTimers(alertAPI client.AlertAPI, filterString string) error {
+	timers, err := alertAPI.GroupTimers(context.Background(), filterString, a.receiver)
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		for _, t := range timers {
+			for _, fp := range t.Fingerprints {
+				fmt.Println(fp)
+			}
+		}
+	} else {
+		formatter, found := format.Formatters[output]
+		if !found {
+			return errors.New("unknown output formatter")
+		}
+		if err := formatter.FormatGroupTimers(timers); err != nil {
+			return err
+		}
+	}
+	if len(timers) == 0 {
+		return noMatchError("no alert groups matched")
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/cli/format"
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/nflog"
+)
+
+type alertHistoryCmd struct {
+	fingerprint string
+	groupKey    string
+	start       string
+	end         string
+	nflogFile   string
+}
+
+const alertHistoryHelp = `View the notification attempts for an alert or a group.
+
+The history lists when notifications were sent to which receiver and
+integration and whether they succeeded. It is kept by each Alertmanager for the
+notifications it sent itself within the notification log retention.
+
+amtool alert history 1c93eec3511dc156
+
+	Shows the notifications that included the alert with this fingerprint.
+
+amtool alert history --group-key='{}:{alertname="foo"}'
+
+	Shows the notifications sent for the group. The group keys are listed by
+	'amtool alert groups -o extended'.
+
+amtool alert history --start=2018-03-29T09:00:00Z --end=2018-03-29T10:00:00Z
+
+	Shows all notifications sent between the start and end time, e.g. to review
+	an incident whose alerts have long resolved.
+
+amtool alert history --nflog.file=data/nflog --start=2018-03-29T09:00:00Z
+
+	Reads the notification log snapshot of an Alertmanager instead of querying
+	it. The snapshot only holds the last successful notification of each group
+	and receiver.
+`
+
+func configureAlertHistoryCmd(cc *kingpin.CmdClause) {
+	var (
+		a          = &alertHistoryCmd{}
+		historyCmd = cc.Command("history", alertHistoryHelp)
+	)
+	historyCmd.Flag("group-key", "Show notifications of the group with the key").StringVar(&a.groupKey)
+	historyCmd.Flag("start", "Show notifications sent at or after the time. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&a.start)
+	historyCmd.Flag("end", "Show notifications sent at or before the time. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&a.end)
+	historyCmd.Flag("nflog.file", "Read the notifications from a notification log snapshot file").StringVar(&a.nflogFile)
+	historyCmd.Arg("fingerprint", "Fingerprint of the alert").StringVar(&a.fingerprint)
+	historyCmd.Action(a.queryHistory)
+}
+
+func (a *alertHistoryCmd) queryHistory(ctx *kingpin.ParseContext) error {
+	var (
+		start, end time.Time
+		err        error
+	)
+	if a.start != "" {
+		if start, err = time.Parse(time.RFC3339, a.start); err != nil {
+			return err
+		}
+	}
+	if a.end != "" {
+		if end, err = time.Parse(time.RFC3339, a.end); err != nil {
+			return err
+		}
+	}
+	if a.fingerprint == "" && a.groupKey == "" && start.IsZero() && end.IsZero() {
+		return errors.New("fingerprint, group key, start or end must be given")
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return errors.New("start must not be after end")
+	}
+
+	var attempts []*client.NotificationAttempt
+	if a.nflogFile != "" {
+		attempts, err = a.readHistory(start, end)
+	} else {
+		attempts, err = a.fetchHistory(start, end)
+	}
+	if err != nil {
+		return err
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	return formatter.FormatNotificationHistory(attempts)
+}
+
+// fetchHistory returns the notifications matching the command's filters
+// from the queried Alertmanager.
+func (a *alertHistoryCmd) fetchHistory(start, end time.Time) ([]*client.NotificationAttempt, error) {
+	c, err := NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.NewAlertAPI(c).History(context.Background(), a.fingerprint, a.groupKey, start, end)
+}
+
+// readHistory returns the notifications of the notification log snapshot
+// file that match the command's filters.
+func (a *alertHistoryCmd) readHistory(start, end time.Time) ([]*client.NotificationAttempt, error) {
+	var fp model.Fingerprint
+	if a.fingerprint != "" {
+		var err error
+		if fp, err = model.ParseFingerprint(a.fingerprint); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(a.nflogFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := nflog.ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading notification log %s: %v", a.nflogFile, err)
+	}
+
+	res := []*client.NotificationAttempt{}
+	for _, e := range entries {
+		if fp != 0 && !e.HasAlert(uint64(fp)) {
+			continue
+		}
+		if a.groupKey != "" && e.GroupKey != a.groupKey {
+			continue
+		}
+		if (!start.IsZero() && e.Timestamp.Before(start)) || (!end.IsZero() && e.Timestamp.After(end)) {
+			continue
+		}
+		res = append(res, &client.NotificationAttempt{
+			GroupKey:       e.GroupKey,
+			Receiver:       e.Receiver.GroupName,
+			Integration:    e.Receiver.Integration,
+			Timestamp:      e.Timestamp,
+			FiringAlerts:   fingerprintStrings(e.FiringAlerts),
+			ResolvedAlerts: fingerprintStrings(e.ResolvedAlerts),
+		})
+	}
+	return res, nil
+}
+
+func fingerprintStrings(fps []uint64) []string {
+	res := make([]string, 0, len(fps))
+	for _, fp := range fps {
+		res = append(res, model.Fingerprint(fp).String())
+	}
+	return res
+}
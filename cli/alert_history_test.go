@@ -0,0 +1,88 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+)
+
+func TestReadHistory(t *testing.T) {
+	now := time.Date(2018, 3, 29, 9, 0, 0, 0, time.UTC)
+	l, err := nflog.New(nflog.WithRetention(time.Hour), nflog.WithNow(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Log(&nflogpb.Receiver{GroupName: "team", Integration: "email"}, "{}:{a=\"1\"}", []uint64{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Minute)
+	if err := l.Log(&nflogpb.Receiver{GroupName: "team", Integration: "webhook"}, "{}:{a=\"2\"}", []uint64{2}, []uint64{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "nflog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := l.Snapshot(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, tc := range []struct {
+		cmd          alertHistoryCmd
+		start, end   time.Time
+		integrations []string
+	}{
+		{
+			cmd:          alertHistoryCmd{fingerprint: "0000000000000001"},
+			integrations: []string{"webhook", "email"},
+		},
+		{
+			cmd:          alertHistoryCmd{groupKey: "{}:{a=\"2\"}"},
+			integrations: []string{"webhook"},
+		},
+		{
+			cmd:          alertHistoryCmd{},
+			start:        now.Add(-5 * time.Minute),
+			integrations: []string{"webhook"},
+		},
+		{
+			cmd:          alertHistoryCmd{fingerprint: "0000000000000002"},
+			end:          now.Add(-5 * time.Minute),
+			integrations: []string{},
+		},
+	} {
+		tc.cmd.nflogFile = f.Name()
+		attempts, err := tc.cmd.readHistory(tc.start, tc.end)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		integrations := []string{}
+		for _, a := range attempts {
+			integrations = append(integrations, a.Integration)
+		}
+		if !reflect.DeepEqual(tc.integrations, integrations) {
+			t.Errorf("%+v: expected %v, got %v", tc.cmd, tc.integrations, integrations)
+		}
+	}
+}
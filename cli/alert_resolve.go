@@ -0,0 +1,92 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+type alertResolveCmd struct {
+	fingerprints  []string
+	matcherGroups []string
+}
+
+const alertResolveHelp = `Resolve firing alerts manually
+
+  Alerts whose source stopped sending them, e.g. because it was shut down,
+  keep firing until their end time or the resolve timeout passes. Resolving
+  them manually notifies the receivers about the resolution right away. An
+  alert fires again once its source sends it again.
+
+  amtool alert resolve alertname=foo instance=db1
+
+	Resolves all firing alerts matching the matchers.
+
+  amtool alert resolve --fingerprint=1c93eec3511dc156
+
+	Resolves the alert with the fingerprint, which can be repeated.
+
+  Manual resolutions are recorded in the audit log of the Alertmanager.
+`
+
+func configureAlertResolveCmd(cc *kingpin.CmdClause) {
+	var (
+		c          = &alertResolveCmd{}
+		resolveCmd = cc.Command("resolve", alertResolveHelp).Alias("expire")
+	)
+	resolveCmd.Flag("fingerprint", "Fingerprint of an alert to resolve, can be repeated").StringsVar(&c.fingerprints)
+	resolveCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&c.matcherGroups)
+	resolveCmd.Action(c.resolve)
+}
+
+func (c *alertResolveCmd) resolve(ctx *kingpin.ParseContext) error {
+	if len(c.fingerprints) == 0 && len(c.matcherGroups) == 0 {
+		return errors.New("no fingerprints or matchers specified")
+	}
+	var typeMatchers types.Matchers
+	if len(c.matcherGroups) > 0 {
+		matchers, err := parseMatchers(c.matcherGroups)
+		if err != nil {
+			return err
+		}
+		typeMatchers, err = TypeMatchers(matchers)
+		if err != nil {
+			return err
+		}
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	alertAPI := client.NewAlertAPI(apiClient)
+	resolved, err := alertAPI.Resolve(context.Background(), c.fingerprints, typeMatchers)
+	if err != nil {
+		return err
+	}
+	if len(resolved) == 0 {
+		return noMatchError("no firing alerts resolved")
+	}
+	for _, fp := range resolved {
+		fmt.Println(fp)
+	}
+	return nil
+}
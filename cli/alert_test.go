@@ -0,0 +1,88 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+func TestInhibitionChains(t *testing.T) {
+	inhibition := func(target, source string, rule int) *client.Inhibition {
+		return &client.Inhibition{
+			Fingerprint: target,
+			InhibitedBy: client.InhibitingAlert{Fingerprint: source},
+			RuleIndex:   rule,
+		}
+	}
+	var (
+		serviceByNode = inhibition("service", "node", 0)
+		nodeByDC      = inhibition("node", "dc", 1)
+		diskByNode    = inhibition("disk", "node", 0)
+		diskByService = inhibition("disk", "service", 2)
+		// The alerts a and b inhibit each other through different rules.
+		aByB = inhibition("a", "b", 3)
+		bByA = inhibition("b", "a", 4)
+	)
+	alerts := []*client.ExtendedAlert{
+		{Fingerprint: "disk"},
+		{Fingerprint: "service"},
+		{Fingerprint: "other"},
+		{Fingerprint: "a"},
+	}
+	inhibitions := []*client.Inhibition{serviceByNode, nodeByDC, diskByNode, diskByService, aByB, bByA}
+
+	chains := inhibitionChains(alerts, inhibitions)
+	expected := [][]*client.Inhibition{
+		{diskByNode, nodeByDC},
+		{diskByService, serviceByNode, nodeByDC},
+		{serviceByNode, nodeByDC},
+		{aByB, bByA},
+	}
+	if len(chains) != len(expected) {
+		t.Fatalf("expected %d chains, got %d", len(expected), len(chains))
+	}
+	for i := range expected {
+		if !reflect.DeepEqual([]*client.Inhibition(chains[i]), expected[i]) {
+			t.Errorf("chain %d: expected %v, got %v", i, expected[i], chains[i])
+		}
+	}
+}
+
+func TestMatcherGroupsFilter(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{args: nil, expected: ""},
+		{args: []string{"HighLatency"}, expected: `{alertname="HighLatency"}`},
+		{args: []string{"HighLatency", "job=api"}, expected: `{alertname="HighLatency",job="api"}`},
+		{args: []string{"summary=disk full, {sda1}", `"service"=~web|db`}, expected: `{summary="disk full, {sda1}",service=~"web|db"}`},
+		{args: []string{`msg="say \"hi\""`}, expected: `{msg="say \"hi\""}`},
+	} {
+		filter, err := matcherGroupsFilter(tc.args)
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", tc.args, err)
+		}
+		if filter != tc.expected {
+			t.Errorf("expected filter %s for %v, got %s", tc.expected, tc.args, filter)
+		}
+	}
+
+	if _, err := matcherGroupsFilter([]string{"job=api", "HighLatency"}); err == nil {
+		t.Error("expected error for a bare alert name after a matcher")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
 	"github.com/prometheus/alertmanager/template"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -31,6 +32,12 @@ const checkConfigHelp = `Validate alertmanager config files
 Will validate the syntax and schema for alertmanager config file
 and associated templates. Non existing templates will not trigger
 errors.
+
+The routing tree is checked for routes that no alert can reach because
+an earlier sibling route without continue matches all of their alerts,
+and for receivers that are neither used by a route nor by a digest.
+Any problem makes the command exit with a non-zero status, so that it
+can be used to check configurations before deploying them.
 `
 
 func configureCheckConfigCmd(app *kingpin.Application) {
@@ -70,8 +77,15 @@ func CheckConfig(args []string) error {
 			fmt.Printf(" - %d inhibit rules\n", len(cfg.InhibitRules))
 			fmt.Printf(" - %d receivers\n", len(cfg.Receivers))
 			fmt.Printf(" - %d templates\n", len(cfg.Templates))
-			if len(cfg.Templates) > 0 {
-				_, err = template.FromGlobs(cfg.Templates...)
+			var receiverTemplates int
+			for _, rc := range cfg.Receivers {
+				receiverTemplates += len(rc.Templates)
+			}
+			if receiverTemplates > 0 {
+				fmt.Printf(" - %d receiver templates\n", receiverTemplates)
+			}
+			if len(cfg.Templates) > 0 || receiverTemplates > 0 {
+				_, err = template.FromConfig(cfg)
 				if err != nil {
 					fmt.Printf("  FAILED: %s\n", err)
 					failed++
@@ -79,6 +93,12 @@ func CheckConfig(args []string) error {
 					fmt.Printf("  SUCCESS\n")
 				}
 			}
+			if problems := checkRouting(cfg); len(problems) > 0 {
+				for _, p := range problems {
+					fmt.Printf("  FAILED: %s\n", p)
+				}
+				failed++
+			}
 		}
 		fmt.Printf("\n")
 	}
@@ -87,3 +107,64 @@ func CheckConfig(args []string) error {
 	}
 	return nil
 }
+
+// checkRouting returns the unreachable routes and unused receivers of the
+// configuration.
+func checkRouting(cfg *config.Config) []string {
+	var (
+		problems []string
+		used     = map[string]struct{}{}
+	)
+	var walk func(r *dispatch.Route)
+	walk = func(r *dispatch.Route) {
+		used[r.RouteOpts.Receiver] = struct{}{}
+		for _, name := range r.RouteOpts.FailoverReceivers {
+			used[name] = struct{}{}
+		}
+		if e := r.RouteOpts.Escalation; e != nil {
+			used[e.Receiver] = struct{}{}
+		}
+		for i, sr := range r.Routes {
+			if prev := shadowingRoute(r.Routes[:i], sr); prev != nil {
+				problems = append(problems, fmt.Sprintf("route %s is unreachable, its alerts are matched by route %s before", sr.Key(), prev.Key()))
+			}
+			walk(sr)
+		}
+	}
+	walk(dispatch.NewRoute(cfg.Route, nil))
+
+	for _, d := range cfg.Digests {
+		used[d.Receiver] = struct{}{}
+	}
+	for _, rcv := range cfg.Receivers {
+		if _, ok := used[rcv.Name]; !ok {
+			problems = append(problems, fmt.Sprintf("receiver %q is not used", rcv.Name))
+		}
+	}
+	return problems
+}
+
+// shadowingRoute returns the first of the preceding sibling routes that
+// matches every alert the route matches without continuing, or nil.
+func shadowingRoute(siblings []*dispatch.Route, r *dispatch.Route) *dispatch.Route {
+	matchers := map[string]struct{}{}
+	for _, m := range r.Matchers {
+		matchers[m.String()] = struct{}{}
+	}
+	for _, prev := range siblings {
+		if prev.Continue {
+			continue
+		}
+		shadowed := true
+		for _, m := range prev.Matchers {
+			if _, ok := matchers[m.String()]; !ok {
+				shadowed = false
+				break
+			}
+		}
+		if shadowed {
+			return prev
+		}
+	}
+	return nil
+}
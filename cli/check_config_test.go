@@ -14,7 +14,10 @@
 package cli
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/prometheus/alertmanager/config"
 )
 
 func TestCheckConfig(t *testing.T) {
@@ -28,3 +31,22 @@ func TestCheckConfig(t *testing.T) {
 		t.Fatalf("failed to detect invalid file.")
 	}
 }
+
+func TestCheckRouting(t *testing.T) {
+	cfg, _, err := config.LoadFile("testdata/conf.unreachable.yml")
+	if err != nil {
+		t.Fatalf("loading config file failed with: %v", err)
+	}
+
+	expected := []string{
+		`route {}/{severity="critical",team="frontend"} is unreachable, its alerts are matched by route {}/{team="frontend"} before`,
+		`receiver "unused" is not used`,
+	}
+	if problems := checkRouting(cfg); !reflect.DeepEqual(expected, problems) {
+		t.Fatalf("expected problems %q, got %q", expected, problems)
+	}
+
+	if err := CheckConfig([]string{"testdata/conf.unreachable.yml"}); err == nil {
+		t.Fatalf("failed to detect unreachable route.")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/cli/format"
+	"github.com/prometheus/alertmanager/client"
+)
+
+const clusterHelp = `View the cluster status.
+
+The peers are listed as seen by the queried Alertmanager together with the time
+they last answered a probe. Comparing the output of several Alertmanagers helps
+diagnosing split clusters.
+
+The amount of output is controlled by the output selection flag:
+	- Simple: Print the peers
+	- Extended: Print the peers as well as the status and gossip queue of the Alertmanager
+	- Json: Print the entire cluster status as json
+`
+
+// clusterCmd represents the cluster command
+func configureClusterCmd(app *kingpin.Application) {
+	clusterCmd := app.Command("cluster", clusterHelp)
+	clusterCmd.Command("show", clusterHelp).Alias("status").Default().Action(queryCluster).PreAction(requireAlertManagerURL)
+	configureClusterSnapshotCmd(clusterCmd)
+}
+
+func queryCluster(ctx *kingpin.ParseContext) error {
+	c, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.NewClusterAPI(c).Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+
+	return formatter.FormatClusterStatus(status)
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+type clusterSnapshotCmd struct {
+	file string
+}
+
+const clusterSnapshotHelp = `Save and restore the replicated state of the cluster
+
+  A snapshot holds the silences, notification log, acknowledgements and
+  message references of the Alertmanager. It allows to migrate the state to
+  a fresh cluster.
+
+  amtool cluster snapshot save state.json
+
+	Saves the state of the Alertmanager to state.json.
+
+  amtool --alertmanager.url=http://new-alertmanager:9093 cluster snapshot restore state.json
+
+	Merges the state of state.json into the state of the new Alertmanager.
+	Its peers receive the restored state with the next full state
+	synchronization of the cluster.
+`
+
+func configureClusterSnapshotCmd(cc *kingpin.CmdClause) {
+	var (
+		c           = &clusterSnapshotCmd{}
+		snapshotCmd = cc.Command("snapshot", clusterSnapshotHelp)
+		saveCmd     = snapshotCmd.Command("save", "Save a snapshot of the state of the Alertmanager")
+		restoreCmd  = snapshotCmd.Command("restore", "Restore a snapshot into the state of the Alertmanager")
+	)
+	saveCmd.Arg("file", "File to write the snapshot to, - for stdout").Required().StringVar(&c.file)
+	saveCmd.Action(c.save).PreAction(requireAlertManagerURL)
+	restoreCmd.Arg("file", "Snapshot file to restore").Required().ExistingFileVar(&c.file)
+	restoreCmd.Action(c.restore).PreAction(requireAlertManagerURL)
+}
+
+func (c *clusterSnapshotCmd) save(ctx *kingpin.ParseContext) error {
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	snapshot, err := client.NewClusterAPI(apiClient).Snapshot(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if c.file == "-" {
+		_, err = os.Stdout.Write(snapshot)
+		return err
+	}
+	return ioutil.WriteFile(c.file, snapshot, 0600)
+}
+
+func (c *clusterSnapshotCmd) restore(ctx *kingpin.ParseContext) error {
+	snapshot, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	restored, err := client.NewClusterAPI(apiClient).Restore(context.Background(), snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored states: %s\n", strings.Join(restored, ", "))
+	return nil
+}
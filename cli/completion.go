@@ -0,0 +1,154 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"text/template"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// completionTimeout bounds the requests made to complete arguments, so that
+// an unreachable Alertmanager does not block the shell.
+const completionTimeout = 2 * time.Second
+
+const completionHelp = `Generate a shell completion script.
+
+The script completes commands and flags as well as the IDs of silences and the
+label names of firing alerts, which are queried from the configured
+Alertmanager.
+
+amtool completion bash > /etc/bash_completion.d/amtool
+
+	Install the completion for bash.
+
+source <(amtool completion zsh)
+
+	Enable the completion in the current zsh session.
+
+amtool completion fish > ~/.config/fish/completions/amtool.fish
+
+	Install the completion for fish.
+`
+
+// fishCompletionTemplate asks amtool for the completions of the current
+// command line the same way the bash and zsh scripts of kingpin do.
+const fishCompletionTemplate = `function __{{.App.Name}}_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    {{.App.Name}} --completion-bash $args (commandline -ct)
+end
+complete -c {{.App.Name}} -f -a '(__{{.App.Name}}_complete)'
+`
+
+func configureCompletionCmd(app *kingpin.Application) {
+	var (
+		shell string
+		cmd   = app.Command("completion", completionHelp)
+	)
+	cmd.Arg("shell", "Shell to generate the script for").Required().EnumVar(&shell, "bash", "zsh", "fish")
+	cmd.Action(func(*kingpin.ParseContext) error {
+		return writeCompletionScript(os.Stdout, app.Name, shell)
+	})
+}
+
+func writeCompletionScript(w io.Writer, name, shell string) error {
+	var text string
+	switch shell {
+	case "bash":
+		text = kingpin.BashCompletionTemplate
+	case "zsh":
+		text = kingpin.ZshCompletionTemplate
+	default:
+		text = fishCompletionTemplate
+	}
+	tmpl, err := template.New(shell).Parse(text)
+	if err != nil {
+		return err
+	}
+	var data struct {
+		App struct{ Name string }
+	}
+	data.App.Name = name
+	return tmpl.Execute(w, data)
+}
+
+// completeSilenceIDs returns the IDs of the silences that are not expired.
+func completeSilenceIDs() []string {
+	if alertmanagerURL == nil {
+		return nil
+	}
+	c, err := NewAPIClient()
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	silences, err := client.NewSilenceAPI(c).List(ctx, "")
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, s := range silences {
+		if s.Status.State != types.SilenceStateExpired {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// completeLabelNames returns the label names of the firing alerts as the
+// beginning of matchers.
+func completeLabelNames() []string {
+	if alertmanagerURL == nil {
+		return nil
+	}
+	c, err := NewAPIClient()
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	alerts, err := client.NewAlertAPI(c).List(ctx, "", "", false, false, true, false)
+	if err != nil {
+		return nil
+	}
+	return labelNameMatchers(alerts)
+}
+
+func labelNameMatchers(alerts []*client.ExtendedAlert) []string {
+	seen := map[client.LabelName]struct{}{}
+	var res []string
+	for _, a := range alerts {
+		for ln := range a.Labels {
+			if _, ok := seen[ln]; ok {
+				continue
+			}
+			seen[ln] = struct{}{}
+			res = append(res, string(ln)+"=")
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+func TestWriteCompletionScript(t *testing.T) {
+	for shell, want := range map[string]string{
+		"bash": "complete -F _amtool_bash_autocomplete amtool",
+		"zsh":  "#compdef amtool",
+		"fish": "complete -c amtool -f -a '(__amtool_complete)'",
+	} {
+		var buf bytes.Buffer
+		if err := writeCompletionScript(&buf, "amtool", shell); err != nil {
+			t.Fatalf("%s: unexpected error: %v", shell, err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("%s: expected script to contain %q, got:\n%s", shell, want, buf.String())
+		}
+	}
+}
+
+func TestLabelNameMatchers(t *testing.T) {
+	alerts := []*client.ExtendedAlert{
+		{Alert: client.Alert{Labels: client.LabelSet{"alertname": "A", "job": "node"}}},
+		{Alert: client.Alert{Labels: client.LabelSet{"alertname": "B", "instance": "h1"}}},
+	}
+	want := []string{"alertname=", "instance=", "job="}
+	if got := labelNameMatchers(alerts); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -16,10 +16,11 @@ package cli
 import (
 	"context"
 	"errors"
+	"fmt"
 
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/prometheus/alertmanager/cli/config"
 	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/client"
 )
@@ -34,12 +35,57 @@ The amount of output is controlled by the output selection flag:
 
 // configCmd represents the config command
 func configureConfigCmd(app *kingpin.Application) {
-	app.Command("config", configHelp).Action(queryConfig).PreAction(requireAlertManagerURL)
+	configCmd := app.Command("config", configHelp)
+	configCmd.Command("show", configHelp).Default().Action(queryConfig).PreAction(requireAlertManagerURL)
+	configureRoutingCmd(configCmd)
+	configureDiffCmd(configCmd)
+	configurePushCmd(configCmd)
+	configureContextCmds(configCmd)
+}
+
+const useContextHelp = `Set the current context of the config file.
+
+The current context is written to the config file in the home directory,
+which is created if it does not exist. Comments of the file are lost.
+
+	amtool config use-context staging
+`
+
+func configureContextCmds(configCmd *kingpin.CmdClause) {
+	var name string
+	useContextCmd := configCmd.Command("use-context", useContextHelp)
+	useContextCmd.Arg("name", "Name of the context").Required().StringVar(&name)
+	useContextCmd.Action(func(*kingpin.ParseContext) error {
+		return useContext(name)
+	})
+	configCmd.Command("get-contexts", "List the contexts of the config file, marking the current one").Action(getContexts)
+}
+
+func useContext(name string) error {
+	if !configResolver.HasContext(name) {
+		return fmt.Errorf("unknown context %q", name)
+	}
+	if err := config.SetCurrentContext(configFiles[0], name); err != nil {
+		return fmt.Errorf("failed to set the current context: %v", err)
+	}
+	fmt.Printf("Switched to context %q.\n", name)
+	return nil
+}
 
+func getContexts(*kingpin.ParseContext) error {
+	current := configResolver.CurrentContext()
+	for _, name := range configResolver.Contexts() {
+		mark := " "
+		if name == current {
+			mark = "*"
+		}
+		fmt.Println(mark, name)
+	}
+	return nil
 }
 
 func queryConfig(ctx *kingpin.ParseContext) error {
-	c, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+	c, err := NewAPIClient()
 	if err != nil {
 		return err
 	}
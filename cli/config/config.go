@@ -14,25 +14,53 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 	"gopkg.in/yaml.v2"
 )
 
+// ContextFlag is the name of the flag selecting a context of the
+// configuration files.
+const ContextFlag = "context"
+
+const (
+	contextsKey       = "contexts"
+	currentContextKey = "current-context"
+)
+
 type getFlagger interface {
 	GetFlag(name string) *kingpin.FlagClause
 }
 
 // Resolver represents a configuration file resolver for kingpin.
+//
+// Besides flags, the configuration files may contain named contexts, e.g.
+// for the Alertmanagers of different environments. The flags of the context
+// selected with the context flag or the current-context key override the
+// other flags of the files.
 type Resolver struct {
-	flags map[string]string
+	flags          map[string]string
+	contexts       map[string]map[string]string
+	currentContext string
+}
+
+// contextsFile is the part of a configuration file holding the contexts.
+type contextsFile struct {
+	CurrentContext string                       `yaml:"current-context"`
+	Contexts       map[string]map[string]string `yaml:"contexts"`
 }
 
 // NewResolver returns a Resolver structure.
 func NewResolver(files []string, legacyFlags map[string]string) (*Resolver, error) {
-	flags := map[string]string{}
+	r := &Resolver{
+		flags:    map[string]string{},
+		contexts: map[string]map[string]string{},
+	}
 	for _, f := range files {
 		b, err := ioutil.ReadFile(f)
 		if err != nil {
@@ -42,25 +70,112 @@ func NewResolver(files []string, legacyFlags map[string]string) (*Resolver, erro
 			return nil, err
 		}
 
-		var m map[string]string
+		var m map[string]interface{}
 		err = yaml.Unmarshal(b, &m)
 		if err != nil {
 			return nil, err
 		}
+		var cf contextsFile
+		if err = yaml.Unmarshal(b, &cf); err != nil {
+			return nil, err
+		}
+		delete(m, contextsKey)
+		delete(m, currentContextKey)
+
+		flags := map[string]string{}
 		for k, v := range m {
-			if flag, ok := legacyFlags[k]; ok {
-				if _, ok := m[flag]; ok {
-					continue
-				}
-				k = flag
+			switch v.(type) {
+			case map[interface{}]interface{}, []interface{}:
+				return nil, fmt.Errorf("%s: value of %q must be a scalar", f, k)
 			}
-			if _, ok := flags[k]; !ok {
-				flags[k] = v
+			flags[k] = fmt.Sprint(v)
+		}
+		mergeFlags(r.flags, flags, legacyFlags)
+
+		for name, ctx := range cf.Contexts {
+			if _, ok := r.contexts[name]; !ok {
+				r.contexts[name] = map[string]string{}
+				mergeFlags(r.contexts[name], ctx, legacyFlags)
 			}
 		}
+		if r.currentContext == "" {
+			r.currentContext = cf.CurrentContext
+		}
 	}
 
-	return &Resolver{flags: flags}, nil
+	return r, nil
+}
+
+// mergeFlags adds the flags of m missing in flags, renaming legacy flags.
+func mergeFlags(flags, m, legacyFlags map[string]string) {
+	for k, v := range m {
+		if flag, ok := legacyFlags[k]; ok {
+			if _, ok := m[flag]; ok {
+				continue
+			}
+			k = flag
+		}
+		if _, ok := flags[k]; !ok {
+			flags[k] = v
+		}
+	}
+}
+
+// Contexts returns the sorted names of the contexts.
+func (c *Resolver) Contexts() []string {
+	names := make([]string, 0, len(c.contexts))
+	for name := range c.contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CurrentContext returns the current context of the configuration files.
+func (c *Resolver) CurrentContext() string {
+	return c.currentContext
+}
+
+// HasContext returns whether the context is defined.
+func (c *Resolver) HasContext(name string) bool {
+	_, ok := c.contexts[name]
+	return ok
+}
+
+// SetCurrentContext sets the current context in the configuration file,
+// which is created if it does not exist. Comments of the file are lost.
+func SetCurrentContext(file, name string) error {
+	var m yaml.MapSlice
+	b, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			return err
+		}
+	}
+
+	found := false
+	for i := range m {
+		if m[i].Key == currentContextKey {
+			m[i].Value = name
+			found = true
+		}
+	}
+	if !found {
+		m = append(m, yaml.MapItem{Key: currentContextKey, Value: name})
+	}
+
+	b, err = yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0644)
 }
 
 func (c *Resolver) setDefault(v getFlagger) {
@@ -80,6 +195,19 @@ func (c *Resolver) Bind(app *kingpin.Application, args []string) error {
 		return err
 	}
 
+	if name := c.selectedContext(app, pc); name != "" {
+		ctx, ok := c.contexts[name]
+		if !ok {
+			return fmt.Errorf("unknown context %q", name)
+		}
+		for k, v := range c.flags {
+			if _, ok := ctx[k]; !ok {
+				ctx[k] = v
+			}
+		}
+		c.flags = ctx
+	}
+
 	c.setDefault(app)
 	if pc.SelectedCommand != nil {
 		c.setDefault(pc.SelectedCommand)
@@ -87,3 +215,23 @@ func (c *Resolver) Bind(app *kingpin.Application, args []string) error {
 
 	return nil
 }
+
+// selectedContext returns the context selected by the context flag, its
+// environment variable or the current-context key of the files.
+func (c *Resolver) selectedContext(app *kingpin.Application, pc *kingpin.ParseContext) string {
+	f := app.GetFlag(ContextFlag)
+	if f == nil {
+		return ""
+	}
+	for _, elem := range pc.Elements {
+		if fc, ok := elem.Clause.(*kingpin.FlagClause); ok && fc.Model().Name == ContextFlag && elem.Value != nil {
+			return *elem.Value
+		}
+	}
+	if envar := f.Model().Envar; envar != "" {
+		if name := os.Getenv(envar); name != "" {
+			return name
+		}
+	}
+	return c.currentContext
+}
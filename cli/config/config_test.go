@@ -15,6 +15,8 @@ package config
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -52,6 +54,8 @@ func TestNewConfigResolver(t *testing.T) {
 		{[]string{"testdata/amtool.good1.yml", "testdata/amtool.good2.yml"}, false},
 		{[]string{"testdata/amtool.good1.yml", "testdata/not_existing.yml"}, false},
 		{[]string{"testdata/amtool.good1.yml", "testdata/amtool.bad.yml"}, true},
+		{[]string{"testdata/amtool.contexts.yml"}, false},
+		{[]string{"testdata/amtool.bad-context.yml"}, true},
 	} {
 		_, err := NewResolver(tc.files, nil)
 		if tc.err != (err != nil) {
@@ -182,3 +186,102 @@ func TestConfigResolverBind(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigResolverContexts(t *testing.T) {
+	for i, tc := range []struct {
+		args   []string
+		envar  string
+		err    bool
+		expURL string
+		expID  string
+	}{
+		{
+			args:   []string{"silence", "del"},
+			expURL: "url-prod", // from the current context
+			expID:  "id0",      // from the top level
+		},
+		{
+			args:   []string{"--context", "staging", "silence", "del"},
+			expURL: "url-staging",
+			expID:  "id-staging",
+		},
+		{
+			args:   []string{"silence", "del"},
+			envar:  "staging",
+			expURL: "url-staging",
+			expID:  "id-staging",
+		},
+		{
+			args:   []string{"--context", "prod", "--url", "url3", "silence", "del"},
+			expURL: "url3", // from command line
+			expID:  "id0",
+		},
+		{
+			args: []string{"--context", "unknown", "silence", "del"},
+			err:  true,
+		},
+	} {
+		r, err := NewResolver([]string{"testdata/amtool.contexts.yml"}, map[string]string{"old-id": "id"})
+		if err != nil {
+			t.Fatalf("%d: expected no error but got: %v", i, err)
+		}
+
+		os.Setenv("APP_CONTEXT", tc.envar)
+		app := newApp()
+		app.Flag(ContextFlag, "").Envar("APP_CONTEXT").String()
+		err = r.Bind(app, tc.args)
+		if tc.err != (err != nil) {
+			t.Fatalf("%d: expected error %t from Bind() but got: %v", i, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		if _, err = app.Parse(tc.args); err != nil {
+			t.Fatalf("%d: expected Parse() to return no error but got: %v", i, err)
+		}
+		if *url != tc.expURL {
+			t.Fatalf("%d: expected url flag %q but got %q", i, tc.expURL, *url)
+		}
+		if *id != tc.expID {
+			t.Fatalf("%d: expected ID flag %q but got %q", i, tc.expID, *id)
+		}
+	}
+	os.Unsetenv("APP_CONTEXT")
+}
+
+func TestSetCurrentContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amtool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The file and its directory are created if they do not exist.
+	f := filepath.Join(dir, "amtool", "config.yml")
+	if err = SetCurrentContext(f, "prod"); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	b, err := ioutil.ReadFile("testdata/amtool.contexts.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(f, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetCurrentContext(f, "staging"); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	r, err := NewResolver([]string{f}, nil)
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if r.CurrentContext() != "staging" {
+		t.Fatalf("expected current context %q but got %q", "staging", r.CurrentContext())
+	}
+	if contexts := r.Contexts(); len(contexts) != 2 || contexts[0] != "prod" || contexts[1] != "staging" {
+		t.Fatalf("expected contexts prod and staging but got %v", contexts)
+	}
+}
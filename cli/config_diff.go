@@ -0,0 +1,320 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+)
+
+type configDiffCmd struct {
+	oldFile    string
+	newFile    string
+	alertsFile string
+}
+
+const configDiffHelp = `Compare two Alertmanager configurations
+
+  Reports the receivers that were added, removed or changed, the routes
+  whose receiver, grouping or timing changed, and the label sets that would
+  be routed differently.
+
+  amtool config diff alertmanager.yml alertmanager.new.yml
+
+	The label sets are derived from the equality matchers of the routes of
+	both configurations. Routes with regular expression or negative
+	matchers only appear in the comparison of the routes.
+
+  amtool config diff --alerts.file=alerts.json alertmanager.yml alertmanager.new.yml
+
+	Evaluates the labels of the alerts in the file instead. The file holds a
+	JSON list of alerts as accepted by the alerts API, e.g.
+	[{"labels": {"alertname": "foo", "team": "bar"}}].
+`
+
+func configureDiffCmd(cc *kingpin.CmdClause) {
+	var (
+		c       = &configDiffCmd{}
+		diffCmd = cc.Command("diff", configDiffHelp)
+	)
+	diffCmd.Flag("alerts.file", "JSON file of alerts whose routing is compared").ExistingFileVar(&c.alertsFile)
+	diffCmd.Arg("old", "The current configuration file").Required().ExistingFileVar(&c.oldFile)
+	diffCmd.Arg("new", "The changed configuration file").Required().ExistingFileVar(&c.newFile)
+	diffCmd.Action(c.diff)
+}
+
+func (c *configDiffCmd) diff(ctx *kingpin.ParseContext) error {
+	return c.run(os.Stdout)
+}
+
+func (c *configDiffCmd) run(w io.Writer) error {
+	oldCfg, _, err := config.LoadFile(c.oldFile)
+	if err != nil {
+		return fmt.Errorf("loading %s failed: %s", c.oldFile, err)
+	}
+	newCfg, _, err := config.LoadFile(c.newFile)
+	if err != nil {
+		return fmt.Errorf("loading %s failed: %s", c.newFile, err)
+	}
+	var (
+		oldRoute = dispatch.NewRoute(oldCfg.Route, nil)
+		newRoute = dispatch.NewRoute(newCfg.Route, nil)
+	)
+
+	var lsets []model.LabelSet
+	if c.alertsFile != "" {
+		lsets, err = readAlertLabels(c.alertsFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		lsets = sampleLabelSets(oldRoute, newRoute)
+	}
+
+	var (
+		receivers = diffReceivers(oldCfg.Receivers, newCfg.Receivers)
+		routes    = diffRoutes(oldRoute, newRoute)
+		routing   = diffRouting(oldRoute, newRoute, lsets)
+	)
+	if len(receivers)+len(routes)+len(routing) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return nil
+	}
+	printSection(w, "Receivers", receivers)
+	printSection(w, "Routes", routes)
+	printSection(w, "Routing", routing)
+	return nil
+}
+
+func printSection(w io.Writer, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, l := range lines {
+		fmt.Fprintf(w, "  %s\n", l)
+	}
+}
+
+// readAlertLabels returns the labels of the alerts in the JSON file.
+func readAlertLabels(file string) ([]model.LabelSet, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var alerts []struct {
+		Labels model.LabelSet `json:"labels"`
+	}
+	if err := json.NewDecoder(f).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("reading alerts from %s failed: %s", file, err)
+	}
+	lsets := make([]model.LabelSet, 0, len(alerts))
+	for _, a := range alerts {
+		lsets = append(lsets, a.Labels)
+	}
+	return lsets, nil
+}
+
+// diffReceivers returns the receivers that were added, removed or changed.
+func diffReceivers(oldRcvs, newRcvs []*config.Receiver) []string {
+	var (
+		res    []string
+		oldIdx = map[string]*config.Receiver{}
+		newIdx = map[string]*config.Receiver{}
+	)
+	for _, r := range oldRcvs {
+		oldIdx[r.Name] = r
+	}
+	for _, r := range newRcvs {
+		newIdx[r.Name] = r
+		prev, ok := oldIdx[r.Name]
+		switch {
+		case !ok:
+			res = append(res, "+ "+r.Name)
+		case !reflect.DeepEqual(prev, r):
+			res = append(res, "~ "+r.Name)
+		}
+	}
+	for _, r := range oldRcvs {
+		if _, ok := newIdx[r.Name]; !ok {
+			res = append(res, "- "+r.Name)
+		}
+	}
+	return res
+}
+
+// flattenRoutes returns the routes of the tree in depth-first order.
+func flattenRoutes(r *dispatch.Route) []*dispatch.Route {
+	res := []*dispatch.Route{r}
+	for _, cr := range r.Routes {
+		res = append(res, flattenRoutes(cr)...)
+	}
+	return res
+}
+
+// diffRoutes returns the routes that were added or removed and the changed
+// options of the routes in both trees, identified by their keys.
+func diffRoutes(oldRoute, newRoute *dispatch.Route) []string {
+	var (
+		res    []string
+		oldIdx = map[string]*dispatch.Route{}
+		newIdx = map[string]*dispatch.Route{}
+	)
+	for _, r := range flattenRoutes(oldRoute) {
+		oldIdx[r.Key()] = r
+	}
+	for _, r := range flattenRoutes(newRoute) {
+		newIdx[r.Key()] = r
+		prev, ok := oldIdx[r.Key()]
+		if !ok {
+			res = append(res, fmt.Sprintf("+ %s: %s", r.Key(), routeSummary(r)))
+			continue
+		}
+		if changes := diffRouteOpts(prev, r); len(changes) > 0 {
+			res = append(res, fmt.Sprintf("~ %s: %s", r.Key(), strings.Join(changes, ", ")))
+		}
+	}
+	for _, r := range flattenRoutes(oldRoute) {
+		if _, ok := newIdx[r.Key()]; !ok {
+			res = append(res, fmt.Sprintf("- %s: %s", r.Key(), routeSummary(r)))
+		}
+	}
+	return res
+}
+
+// routeFields returns the options of the route that determine where and
+// when alerts are sent, in a fixed order.
+func routeFields(r *dispatch.Route) [][2]string {
+	groupBy := make([]string, 0, len(r.RouteOpts.GroupBy))
+	for ln := range r.RouteOpts.GroupBy {
+		groupBy = append(groupBy, string(ln))
+	}
+	sort.Strings(groupBy)
+
+	return [][2]string{
+		{"receiver", r.RouteOpts.Receiver},
+		{"group_by", "[" + strings.Join(groupBy, ", ") + "]"},
+		{"group_wait", model.Duration(r.RouteOpts.GroupWait).String()},
+		{"group_interval", model.Duration(r.RouteOpts.GroupInterval).String()},
+		{"repeat_interval", model.Duration(r.RouteOpts.RepeatInterval).String()},
+		{"continue", fmt.Sprint(r.Continue)},
+	}
+}
+
+func routeSummary(r *dispatch.Route) string {
+	fields := routeFields(r)
+	res := make([]string, 0, len(fields))
+	for _, f := range fields {
+		res = append(res, f[0]+" "+f[1])
+	}
+	return strings.Join(res, ", ")
+}
+
+func diffRouteOpts(oldRoute, newRoute *dispatch.Route) []string {
+	var (
+		res       []string
+		oldFields = routeFields(oldRoute)
+		newFields = routeFields(newRoute)
+	)
+	for i := range oldFields {
+		if oldFields[i][1] != newFields[i][1] {
+			res = append(res, fmt.Sprintf("%s %s -> %s", oldFields[i][0], oldFields[i][1], newFields[i][1]))
+		}
+	}
+	return res
+}
+
+// sampleLabelSets returns the label sets reaching the routes of both trees
+// that only have equality matchers, sorted and without duplicates.
+func sampleLabelSets(roots ...*dispatch.Route) []model.LabelSet {
+	var (
+		res  []model.LabelSet
+		seen = map[model.Fingerprint]struct{}{}
+		walk func(r *dispatch.Route, lset model.LabelSet)
+	)
+	walk = func(r *dispatch.Route, lset model.LabelSet) {
+		lset = lset.Clone()
+		for _, m := range r.Matchers {
+			if m.IsRegex || m.IsNegative {
+				return
+			}
+			lset[model.LabelName(m.Name)] = model.LabelValue(m.Value)
+		}
+		if _, ok := seen[lset.Fingerprint()]; !ok {
+			seen[lset.Fingerprint()] = struct{}{}
+			res = append(res, lset)
+		}
+		for _, cr := range r.Routes {
+			walk(cr, lset)
+		}
+	}
+	for _, r := range roots {
+		walk(r, model.LabelSet{})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].String() < res[j].String()
+	})
+	return res
+}
+
+// diffRouting returns the label sets that are routed differently by the
+// two trees.
+func diffRouting(oldRoute, newRoute *dispatch.Route, lsets []model.LabelSet) []string {
+	var res []string
+	for _, lset := range lsets {
+		var (
+			oldRoutes = oldRoute.Match(lset)
+			newRoutes = newRoute.Match(lset)
+			changes   []string
+		)
+		if oldRcvs, newRcvs := routeReceivers(oldRoutes), routeReceivers(newRoutes); oldRcvs != newRcvs {
+			changes = append(changes, fmt.Sprintf("receivers %s -> %s", oldRcvs, newRcvs))
+		} else {
+			for i := range oldRoutes {
+				for _, c := range diffRouteOpts(oldRoutes[i], newRoutes[i]) {
+					// Whether a route continues only matters for the
+					// matched routes, which are compared above.
+					if !strings.HasPrefix(c, "continue ") {
+						changes = append(changes, c)
+					}
+				}
+			}
+		}
+		if len(changes) > 0 {
+			res = append(res, fmt.Sprintf("%s: %s", lset, strings.Join(changes, ", ")))
+		}
+	}
+	return res
+}
+
+func routeReceivers(routes []*dispatch.Route) string {
+	rcvs := make([]string, 0, len(routes))
+	for _, r := range routes {
+		rcvs = append(rcvs, r.RouteOpts.Receiver)
+	}
+	return "[" + strings.Join(rcvs, ", ") + "]"
+}
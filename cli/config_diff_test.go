@@ -0,0 +1,74 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConfigDiff(t *testing.T) {
+	for _, tc := range []struct {
+		cmd      *configDiffCmd
+		expected string
+	}{
+		{
+			cmd: &configDiffCmd{
+				oldFile: "testdata/conf.routing.yml",
+				newFile: "testdata/conf.routing.yml",
+			},
+			expected: "No changes\n",
+		},
+		{
+			cmd: &configDiffCmd{
+				oldFile: "testdata/conf.routing.yml",
+				newFile: "testdata/conf.routing.new.yml",
+			},
+			expected: `Receivers:
+  ~ frontend-pager
+  + frontend-mails
+Routes:
+  ~ {}/{team="frontend"}: group_wait 10s -> 30s
+  + {}/{severity="info",team="frontend"}: receiver frontend-mails, group_by [alertname], group_wait 30s, group_interval 5m, repeat_interval 4h, continue false
+Routing:
+  {severity="info", team="frontend"}: receivers [frontend-pager] -> [frontend-pager, frontend-mails]
+  {team="frontend"}: group_wait 10s -> 30s
+`,
+		},
+		{
+			cmd: &configDiffCmd{
+				oldFile:    "testdata/conf.routing.yml",
+				newFile:    "testdata/conf.routing.new.yml",
+				alertsFile: "testdata/alerts.routing.json",
+			},
+			expected: `Receivers:
+  ~ frontend-pager
+  + frontend-mails
+Routes:
+  ~ {}/{team="frontend"}: group_wait 10s -> 30s
+  + {}/{severity="info",team="frontend"}: receiver frontend-mails, group_by [alertname], group_wait 30s, group_interval 5m, repeat_interval 4h, continue false
+Routing:
+  {alertname="HighLatency", severity="info", team="frontend"}: receivers [frontend-pager] -> [frontend-pager, frontend-mails]
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		if err := tc.cmd.run(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("expected output:\n%s\ngot:\n%s", tc.expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+type configPushCmd struct {
+	file   string
+	dryRun bool
+}
+
+const configPushHelp = `Replace the configuration of the Alertmanager
+
+  The configuration file is validated by the Alertmanager, which then writes
+  it to its configuration file and reloads it. The difference to the running
+  configuration is printed.
+
+  amtool config push --dry-run alertmanager.yml
+
+	Validates alertmanager.yml and prints the difference to the running
+	configuration without applying it.
+`
+
+func configurePushCmd(cc *kingpin.CmdClause) {
+	var (
+		c       = &configPushCmd{}
+		pushCmd = cc.Command("push", configPushHelp)
+	)
+	pushCmd.Flag("dry-run", "Validate the configuration without applying it").BoolVar(&c.dryRun)
+	pushCmd.Arg("config-file", "Alertmanager configuration file").Required().ExistingFileVar(&c.file)
+	pushCmd.Action(c.push).PreAction(requireAlertManagerURL)
+}
+
+func (c *configPushCmd) push(ctx *kingpin.ParseContext) error {
+	content, err := ioutil.ReadFile(c.file)
+	if err != nil {
+		return err
+	}
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	res, err := client.NewConfigAPI(apiClient).Push(context.Background(), string(content), c.dryRun)
+	if err != nil {
+		return err
+	}
+
+	if res.Diff == "" {
+		fmt.Println("The configuration is unchanged")
+	} else {
+		fmt.Print(res.Diff)
+	}
+	if res.Applied {
+		fmt.Println("The configuration was applied")
+	}
+	return nil
+}
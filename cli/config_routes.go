@@ -0,0 +1,248 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+)
+
+type routingTestCmd struct {
+	configFile      string
+	labels          []string
+	expectReceivers string
+}
+
+const routingTestHelp = `Test the routing of an alert
+
+  Prints the routes an alert with the given labels matches, with their
+  receivers, grouping and timing parameters. The configuration is read from
+  the file given by --config.file, or fetched from the Alertmanager otherwise.
+
+  amtool config routes test --config.file=alertmanager.yml alertname=foo team=bar
+
+	Prints the routes of an alert with the labels alertname=foo and team=bar.
+
+  amtool config routes test --verify.receivers=team-bar-pager alertname=foo team=bar
+
+	Additionally fails unless the alert is routed to exactly the given
+	comma-separated receivers, which is useful to test configurations in CI.
+`
+
+type routingShowCmd struct {
+	configFile string
+}
+
+const routingShowHelp = `Show the routing tree
+
+  Prints the routes of the running configuration as a tree, with the
+  matchers, receiver, grouping and timing parameters of each route. The
+  configuration is fetched from the Alertmanager, or read from the file given
+  by --config.file.
+
+  amtool config routes show --alertmanager.url=http://localhost:9093
+
+	Prints the routing tree of the Alertmanager.
+
+  amtool config routes show -o json
+
+	Prints the routing tree as nested JSON objects for tooling.
+`
+
+func configureRoutingCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &routingTestCmd{}
+		sc        = &routingShowCmd{}
+		routesCmd = cc.Command("routes", "Inspect the routing tree")
+		showCmd   = routesCmd.Command("show", routingShowHelp).Default()
+		testCmd   = routesCmd.Command("test", routingTestHelp)
+	)
+	showCmd.Flag("config.file", "Alertmanager configuration file to show instead of the running configuration").ExistingFileVar(&sc.configFile)
+	showCmd.Action(sc.show)
+	testCmd.Flag("config.file", "Alertmanager configuration file to test instead of the running configuration").ExistingFileVar(&c.configFile)
+	testCmd.Flag("verify.receivers", "Comma-separated receivers the alert is expected to be routed to").StringVar(&c.expectReceivers)
+	testCmd.Arg("labels", "Labels of the alert").StringsVar(&c.labels)
+	testCmd.Action(c.test)
+}
+
+// loadRoutingConfig returns the configuration from the file, or from the
+// running Alertmanager if no file is given.
+func loadRoutingConfig(configFile string) (*config.Config, error) {
+	if configFile != "" {
+		cfg, _, err := config.LoadFile(configFile)
+		return cfg, err
+	}
+	if alertmanagerURL == nil {
+		return nil, errors.New("either --config.file or --alertmanager.url must be given")
+	}
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	status, err := client.NewStatusAPI(apiClient).Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(status.ConfigYAML)
+}
+
+func (c *routingTestCmd) test(ctx *kingpin.ParseContext) error {
+	return c.run(os.Stdout)
+}
+
+func (c *routingTestCmd) run(w io.Writer) error {
+	if len(c.labels) > 0 && !strings.Contains(c.labels[0], "=") {
+		c.labels[0] = fmt.Sprintf("alertname=%s", c.labels[0])
+	}
+	ls, err := parseLabelPairs(c.labels)
+	if err != nil {
+		return err
+	}
+	lset := model.LabelSet{}
+	for k, v := range ls {
+		lset[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	cfg, err := loadRoutingConfig(c.configFile)
+	if err != nil {
+		return err
+	}
+	routes := dispatch.NewRoute(cfg.Route, nil).Match(lset)
+	printRoutes(w, routes)
+
+	if c.expectReceivers == "" {
+		return nil
+	}
+	var receivers []string
+	for _, r := range routes {
+		receivers = append(receivers, r.RouteOpts.Receiver)
+	}
+	if got := strings.Join(receivers, ","); got != c.expectReceivers {
+		return fmt.Errorf("expected receivers %q, got %q", c.expectReceivers, got)
+	}
+	return nil
+}
+
+func printRoutes(w io.Writer, routes []*dispatch.Route) {
+	for _, r := range routes {
+		groupBy := make([]string, 0, len(r.RouteOpts.GroupBy))
+		for ln := range r.RouteOpts.GroupBy {
+			groupBy = append(groupBy, string(ln))
+		}
+		sort.Strings(groupBy)
+
+		fmt.Fprintf(w, "%s\n", r.Key())
+		fmt.Fprintf(w, "  receiver:        %s\n", r.RouteOpts.Receiver)
+		fmt.Fprintf(w, "  group_by:        [%s]\n", strings.Join(groupBy, ", "))
+		fmt.Fprintf(w, "  group_wait:      %s\n", model.Duration(r.RouteOpts.GroupWait))
+		fmt.Fprintf(w, "  group_interval:  %s\n", model.Duration(r.RouteOpts.GroupInterval))
+		fmt.Fprintf(w, "  repeat_interval: %s\n", model.Duration(r.RouteOpts.RepeatInterval))
+		fmt.Fprintf(w, "  continue:        %t\n", r.Continue)
+	}
+}
+
+func (c *routingShowCmd) show(ctx *kingpin.ParseContext) error {
+	cfg, err := loadRoutingConfig(c.configFile)
+	if err != nil {
+		return err
+	}
+	return printRouteTree(os.Stdout, dispatch.NewRoute(cfg.Route, nil), output)
+}
+
+// routeNode is the representation of a route of the tree in the json and
+// yaml output.
+type routeNode struct {
+	Matchers       []string     `json:"matchers" yaml:"matchers"`
+	Receiver       string       `json:"receiver" yaml:"receiver"`
+	GroupBy        []string     `json:"group_by" yaml:"group_by"`
+	GroupWait      string       `json:"group_wait" yaml:"group_wait"`
+	GroupInterval  string       `json:"group_interval" yaml:"group_interval"`
+	RepeatInterval string       `json:"repeat_interval" yaml:"repeat_interval"`
+	Continue       bool         `json:"continue" yaml:"continue"`
+	Routes         []*routeNode `json:"routes,omitempty" yaml:"routes,omitempty"`
+}
+
+func newRouteNode(r *dispatch.Route) *routeNode {
+	n := &routeNode{
+		Matchers:       make([]string, 0, len(r.Matchers)),
+		Receiver:       r.RouteOpts.Receiver,
+		GroupBy:        make([]string, 0, len(r.RouteOpts.GroupBy)),
+		GroupWait:      model.Duration(r.RouteOpts.GroupWait).String(),
+		GroupInterval:  model.Duration(r.RouteOpts.GroupInterval).String(),
+		RepeatInterval: model.Duration(r.RouteOpts.RepeatInterval).String(),
+		Continue:       r.Continue,
+	}
+	for _, m := range r.Matchers {
+		n.Matchers = append(n.Matchers, m.String())
+	}
+	for ln := range r.RouteOpts.GroupBy {
+		n.GroupBy = append(n.GroupBy, string(ln))
+	}
+	sort.Strings(n.GroupBy)
+	for _, cr := range r.Routes {
+		n.Routes = append(n.Routes, newRouteNode(cr))
+	}
+	return n
+}
+
+// printRouteTree prints the routing tree in the given output format. All
+// formats but json and yaml print the tree as text.
+func printRouteTree(w io.Writer, root *dispatch.Route, format string) error {
+	n := newRouteNode(root)
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(n)
+	case "yaml":
+		b, err := yaml.Marshal(n)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	printRouteNode(w, n, "", "")
+	return nil
+}
+
+func printRouteNode(w io.Writer, n *routeNode, prefix, childPrefix string) {
+	fmt.Fprintf(w, "%s{%s} receiver=%s group_by=[%s] group_wait=%s group_interval=%s repeat_interval=%s",
+		prefix, strings.Join(n.Matchers, ","), n.Receiver, strings.Join(n.GroupBy, ", "), n.GroupWait, n.GroupInterval, n.RepeatInterval)
+	if n.Continue {
+		fmt.Fprint(w, " continue")
+	}
+	fmt.Fprintln(w)
+	for i, c := range n.Routes {
+		if i == len(n.Routes)-1 {
+			printRouteNode(w, c, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printRouteNode(w, c, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
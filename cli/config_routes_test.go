@@ -0,0 +1,107 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+)
+
+func TestRoutingTest(t *testing.T) {
+	for _, tc := range []struct {
+		labels    []string
+		receivers string
+		err       bool
+	}{
+		{labels: []string{"foo", "team=frontend"}, receivers: "frontend-pager"},
+		{labels: []string{"foo", "team=frontend", "service=db"}, receivers: "frontend-pager,backend-pager"},
+		{labels: []string{"foo", "service=web"}, receivers: "default"},
+		{labels: []string{"foo", "service=db"}, receivers: "default", err: true},
+		{labels: []string{"foo", "invalid"}, err: true},
+	} {
+		c := &routingTestCmd{
+			configFile:      "testdata/conf.routing.yml",
+			labels:          tc.labels,
+			expectReceivers: tc.receivers,
+		}
+		err := c.run(ioutil.Discard)
+		if tc.err && err == nil {
+			t.Errorf("%v: expected error, got none", tc.labels)
+		}
+		if !tc.err && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.labels, err)
+		}
+	}
+}
+
+func TestPrintRoutes(t *testing.T) {
+	cfg, _, err := config.LoadFile("testdata/conf.routing.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := dispatch.NewRoute(cfg.Route, nil).Match(model.LabelSet{"team": "frontend"})
+
+	var buf bytes.Buffer
+	printRoutes(&buf, routes)
+	expected := `{}/{team="frontend"}
+  receiver:        frontend-pager
+  group_by:        [alertname, cluster]
+  group_wait:      10s
+  group_interval:  5m
+  repeat_interval: 4h
+  continue:        true
+`
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestPrintRouteTree(t *testing.T) {
+	cfg, _, err := config.LoadFile("testdata/conf.routing.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := dispatch.NewRoute(cfg.Route, nil)
+
+	var buf bytes.Buffer
+	if err := printRouteTree(&buf, root, "simple"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{} receiver=default group_by=[alertname] group_wait=30s group_interval=5m repeat_interval=4h
+├── {team="frontend"} receiver=frontend-pager group_by=[alertname, cluster] group_wait=10s group_interval=5m repeat_interval=4h continue
+└── {service=~"^(?:^(db|cache)$)$"} receiver=backend-pager group_by=[alertname] group_wait=30s group_interval=5m repeat_interval=1h
+`
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := printRouteTree(&buf, root, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var n routeNode
+	if err := json.Unmarshal(buf.Bytes(), &n); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Routes) != 2 || n.Routes[1].Receiver != "backend-pager" || n.Routes[1].RepeatInterval != "1h" {
+		t.Errorf("unexpected json tree %s", buf.String())
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+)
+
+// The exit codes of amtool, which scripts can rely on.
+const (
+	// exitOK is returned if the command succeeded.
+	exitOK = 0
+	// exitClientError is returned for invalid flags, arguments or input and
+	// for requests the Alertmanager rejected as invalid.
+	exitClientError = 1
+	// exitServerError is returned if the Alertmanager is unreachable or
+	// failed to handle the request.
+	exitServerError = 2
+	// exitNoMatch is returned if nothing matched the arguments of a command.
+	exitNoMatch = 3
+)
+
+// noMatchError is returned by commands that found nothing matching their
+// arguments.
+type noMatchError string
+
+func (e noMatchError) Error() string {
+	return string(e)
+}
+
+// statusCoder is implemented by the errors of the API client that carry the
+// HTTP status code of the response.
+type statusCoder interface {
+	StatusCode() int
+}
+
+// exitCode returns the exit code of a command that returned the error.
+func exitCode(err error) int {
+	switch err := err.(type) {
+	case nil:
+		return exitOK
+	case noMatchError:
+		return exitNoMatch
+	case statusCoder:
+		if err.StatusCode()/100 == 5 {
+			return exitServerError
+		}
+		return exitClientError
+	case *url.Error:
+		// The request failed before a response was received, e.g. because
+		// the Alertmanager is unreachable or the URL is invalid.
+		return exitCode(err.Err)
+	case net.Error:
+		return exitServerError
+	}
+	return exitClientError
+}
+
+// exitWithError prints the error and exits with its exit code.
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "amtool: error: %v\n", err)
+	os.Exit(exitCode(err))
+}
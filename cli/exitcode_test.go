@@ -0,0 +1,73 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/api"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+func TestExitCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/silence/invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"invalid silence id"}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("bad gateway"))
+		}
+	}))
+	defer srv.Close()
+	closedSrv := httptest.NewServer(http.NotFoundHandler())
+	closedSrv.Close()
+
+	silenceErr := func(addr, id string) error {
+		c, err := api.NewClient(api.Config{Address: addr})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = client.NewSilenceAPI(c).Get(context.Background(), id)
+		if err == nil {
+			t.Fatalf("expected error getting silence %q from %s", id, addr)
+		}
+		return err
+	}
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"success", nil, exitOK},
+		{"invalid input", errors.New("invalid matcher"), exitClientError},
+		{"bad request", silenceErr(srv.URL, "invalid"), exitClientError},
+		{"server error", silenceErr(srv.URL, "other"), exitServerError},
+		{"unreachable", silenceErr(closedSrv.URL, "other"), exitServerError},
+		{"invalid url", silenceErr("foo://localhost", "other"), exitClientError},
+		{"timeout", context.DeadlineExceeded, exitServerError},
+		{"no match", noMatchError("no silences matched"), exitNoMatch},
+	} {
+		if code := exitCode(tc.err); code != tc.code {
+			t.Errorf("%s: expected exit code %d for %v, got %d", tc.name, tc.code, tc.err, code)
+		}
+	}
+}
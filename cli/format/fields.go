@@ -0,0 +1,195 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// FieldsFormatter is implemented by formatters that output a selection of
+// the fields of alerts and silences.
+type FieldsFormatter interface {
+	// SetFields selects the fields in the order they are output. No fields
+	// select the default fields of the formatter.
+	SetFields([]string)
+}
+
+// OrderFormatter is implemented by formatters that sort alerts and
+// silences before output.
+type OrderFormatter interface {
+	// KeepOrder outputs alerts and silences in the order they are given,
+	// e.g. as sorted by the Alertmanager.
+	KeepOrder()
+}
+
+// alertFields are the fields of alerts that can be selected. Besides them,
+// labels.<name> and annotations.<name> select a single label or annotation.
+var alertFields = map[string]func(*client.ExtendedAlert) string{
+	"alertname":    func(a *client.ExtendedAlert) string { return string(a.Labels["alertname"]) },
+	"fingerprint":  func(a *client.ExtendedAlert) string { return a.Fingerprint },
+	"labels":       func(a *client.ExtendedAlert) string { return extendedFormatLabels(a.Labels) },
+	"annotations":  func(a *client.ExtendedAlert) string { return extendedFormatAnnotations(a.Annotations) },
+	"summary":      func(a *client.ExtendedAlert) string { return string(a.Annotations["summary"]) },
+	"startsAt":     func(a *client.ExtendedAlert) string { return FormatDate(a.StartsAt) },
+	"endsAt":       func(a *client.ExtendedAlert) string { return FormatDate(a.EndsAt) },
+	"generatorURL": func(a *client.ExtendedAlert) string { return a.GeneratorURL },
+	"state":        func(a *client.ExtendedAlert) string { return string(a.Status.State) },
+	"receivers":    func(a *client.ExtendedAlert) string { return strings.Join(a.Receivers, " ") },
+	"silencedBy":   func(a *client.ExtendedAlert) string { return strings.Join(a.Status.SilencedBy, " ") },
+	"inhibitedBy":  func(a *client.ExtendedAlert) string { return strings.Join(a.Status.InhibitedBy, " ") },
+	"instances":    func(a *client.ExtendedAlert) string { return strings.Join(a.Instances, " ") },
+}
+
+// silenceFields are the fields of silences that can be selected. Besides
+// them, metadata.<name> selects a single metadata field.
+var silenceFields = map[string]func(*types.Silence) string{
+	"id":        func(s *types.Silence) string { return s.ID },
+	"matchers":  func(s *types.Silence) string { return extendedFormatMatchers(s.Matchers) },
+	"startsAt":  func(s *types.Silence) string { return FormatDate(s.StartsAt) },
+	"endsAt":    func(s *types.Silence) string { return FormatDate(s.EndsAt) },
+	"updatedAt": func(s *types.Silence) string { return FormatDate(s.UpdatedAt) },
+	"createdBy": func(s *types.Silence) string { return s.CreatedBy },
+	"comment":   func(s *types.Silence) string { return s.Comment },
+	"metadata":  func(s *types.Silence) string { return FormatMetadata(s.Metadata) },
+	"state":     func(s *types.Silence) string { return string(s.Status.State) },
+	"instances": func(s *types.Silence) string { return strings.Join(s.Instances, " ") },
+	"suppressed": func(s *types.Silence) string {
+		if s.Status.Suppressed == nil {
+			return ""
+		}
+		return strconv.Itoa(s.Status.Suppressed.Count)
+	},
+	"suppressedAlerts": func(s *types.Silence) string {
+		if s.Status.Suppressed == nil {
+			return ""
+		}
+		return strings.Join(s.Status.Suppressed.Fingerprints, " ")
+	},
+}
+
+var (
+	defaultAlertFields   = []string{"labels", "annotations", "startsAt", "endsAt", "generatorURL"}
+	defaultSilenceFields = []string{"id", "matchers", "startsAt", "endsAt", "updatedAt", "createdBy", "comment"}
+)
+
+// alertField returns the function extracting the named field of alerts.
+func alertField(name string) (func(*client.ExtendedAlert) string, error) {
+	if ln := strings.TrimPrefix(name, "labels."); ln != name {
+		return func(a *client.ExtendedAlert) string { return string(a.Labels[client.LabelName(ln)]) }, nil
+	}
+	if an := strings.TrimPrefix(name, "annotations."); an != name {
+		return func(a *client.ExtendedAlert) string { return string(a.Annotations[client.LabelName(an)]) }, nil
+	}
+	f, ok := alertFields[name]
+	if !ok {
+		var names []string
+		for n := range alertFields {
+			names = append(names, n)
+		}
+		return nil, fmt.Errorf("unknown alert field %q, available fields are %s, labels.<name> and annotations.<name>", name, fieldNames(names))
+	}
+	return f, nil
+}
+
+// alertRows returns the values of the fields of the alerts sorted by their
+// start time.
+func alertRows(alerts []*client.ExtendedAlert, fields []string, keepOrder bool) ([][]string, error) {
+	fns := make([]func(*client.ExtendedAlert) string, 0, len(fields))
+	for _, name := range fields {
+		f, err := alertField(name)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, f)
+	}
+	if !keepOrder {
+		sort.Sort(ByStartsAt(alerts))
+	}
+
+	rows := make([][]string, 0, len(alerts))
+	for _, a := range alerts {
+		row := make([]string, 0, len(fns))
+		for _, f := range fns {
+			row = append(row, f(a))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// silenceField returns the function extracting the named field of silences.
+func silenceField(name string) (func(*types.Silence) string, error) {
+	if mn := strings.TrimPrefix(name, "metadata."); mn != name {
+		return func(s *types.Silence) string { return s.Metadata[mn] }, nil
+	}
+	f, ok := silenceFields[name]
+	if !ok {
+		var names []string
+		for n := range silenceFields {
+			names = append(names, n)
+		}
+		return nil, fmt.Errorf("unknown silence field %q, available fields are %s and metadata.<name>", name, fieldNames(names))
+	}
+	return f, nil
+}
+
+// silenceRows returns the values of the fields of the silences sorted by
+// their end time.
+func silenceRows(silences []types.Silence, fields []string, keepOrder bool) ([][]string, error) {
+	fns := make([]func(*types.Silence) string, 0, len(fields))
+	for _, name := range fields {
+		f, err := silenceField(name)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, f)
+	}
+	if !keepOrder {
+		sort.Sort(ByEndAt(silences))
+	}
+
+	rows := make([][]string, 0, len(silences))
+	for i := range silences {
+		row := make([]string, 0, len(fns))
+		for _, f := range fns {
+			row = append(row, f(&silences[i]))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// fieldNames returns the sorted names of the fields separated by commas.
+func fieldNames(names []string) string {
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// formatTable writes the rows as a table with the fields as header.
+func formatTable(writer io.Writer, fields []string, rows [][]string) error {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(fields, "\t")+"\t")
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	return w.Flush()
+}
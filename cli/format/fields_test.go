@@ -0,0 +1,303 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func init() {
+	f := time.RFC3339
+	dateFormat = &f
+	absoluteTime = true
+}
+
+func TestCSVFormatterFields(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := []*client.ExtendedAlert{
+		{
+			Alert: client.Alert{
+				Labels:      client.LabelSet{"alertname": "HighLatency", "severity": "page"},
+				Annotations: client.LabelSet{"summary": "Latency is high, really"},
+				StartsAt:    start.Add(time.Hour),
+			},
+		},
+		{
+			Alert: client.Alert{
+				Labels:   client.LabelSet{"alertname": "DiskFull"},
+				StartsAt: start,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &CSVFormatter{writer: &buf}
+	f.SetFields([]string{"alertname", "labels.severity", "startsAt", "summary"})
+	if err := f.FormatAlerts(alerts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `alertname,labels.severity,startsAt,summary
+DiskFull,,2018-01-01T00:00:00Z,
+HighLatency,page,2018-01-01T01:00:00Z,"Latency is high, really"
+`
+	if buf.String() != exp {
+		t.Errorf("expected output:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	f.SetFields([]string{"alertname", "unknown"})
+	if err := f.FormatAlerts(alerts); err == nil || !strings.Contains(err.Error(), `unknown alert field "unknown"`) {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestCSVFormatterMetadataFields(t *testing.T) {
+	silences := []types.Silence{
+		{ID: "1", Metadata: map[string]string{"ticket": "OPS-123", "team": "storage"}},
+		{ID: "2"},
+	}
+
+	var buf bytes.Buffer
+	f := &CSVFormatter{writer: &buf}
+	f.SetFields([]string{"id", "metadata.ticket", "metadata"})
+	if err := f.FormatSilences(silences); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `id,metadata.ticket,metadata
+1,OPS-123,"team=""storage"" ticket=""OPS-123"""
+2,,
+`
+	if buf.String() != exp {
+		t.Errorf("expected output:\n%s\ngot:\n%s", exp, buf.String())
+	}
+}
+
+func TestSimpleFormatterSuppressedFields(t *testing.T) {
+	silences := []types.Silence{
+		{ID: "broad", Status: types.SilenceStatus{Suppressed: &types.SuppressedAlerts{Count: 2, Fingerprints: []string{"1c93eec3511dc156", "6e8a0b1a2d2c9f5e"}}}},
+		{ID: "unused", EndsAt: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), Status: types.SilenceStatus{Suppressed: &types.SuppressedAlerts{Fingerprints: []string{}}}},
+		{ID: "unknown", EndsAt: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	f := &SimpleFormatter{writer: &buf}
+	f.SetFields([]string{"id", "suppressed", "suppressedAlerts"})
+	if err := f.FormatSilences(silences); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `id       suppressed  suppressedAlerts                   
+broad    2           1c93eec3511dc156 6e8a0b1a2d2c9f5e  
+unused   0                                              
+unknown                                                 
+`
+	if buf.String() != exp {
+		t.Errorf("expected output:\n%s\ngot:\n%s", exp, buf.String())
+	}
+}
+
+func TestYAMLFormatterFields(t *testing.T) {
+	silences := []types.Silence{
+		{ID: "2", CreatedBy: "bob", EndsAt: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "1", CreatedBy: "alice", EndsAt: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	f := &YAMLFormatter{writer: &buf}
+	f.SetFields([]string{"id", "createdBy"})
+	if err := f.FormatSilences(silences); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `- id: "1"
+  createdBy: alice
+- id: "2"
+  createdBy: bob
+`
+	if buf.String() != exp {
+		t.Errorf("expected output:\n%s\ngot:\n%s", exp, buf.String())
+	}
+
+	buf.Reset()
+	f.SetFields(nil)
+	if err := f.FormatSilences(silences[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "createdBy: alice") || !strings.Contains(buf.String(), "endsAt: \"2018-01-01T00:00:00Z\"") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestSimpleFormatterInhibitions(t *testing.T) {
+	inhibition := func(target, source string, rule int) *client.Inhibition {
+		return &client.Inhibition{
+			Fingerprint: target,
+			Labels:      client.LabelSet{"alertname": client.LabelValue(target)},
+			InhibitedBy: client.InhibitingAlert{
+				Fingerprint: source,
+				Labels:      client.LabelSet{"alertname": client.LabelValue(source)},
+			},
+			RuleIndex: rule,
+		}
+	}
+	chains := []InhibitionChain{
+		{inhibition("ServiceDown", "NodeDown", 0), inhibition("NodeDown", "DatacenterDown", 2)},
+	}
+
+	var buf bytes.Buffer
+	f := &SimpleFormatter{}
+	f.SetOutput(&buf)
+	if err := f.FormatInhibitions(chains); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Alertname    Inhibited By                Rules     \n" +
+		"ServiceDown  NodeDown <- DatacenterDown  #0 <- #2  \n"
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
+
+func TestSimpleFormatterReceivers(t *testing.T) {
+	lastAttempt := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	failingSince := time.Now().Add(-2 * time.Hour)
+	receivers := []*client.ReceiverStatus{
+		{
+			Name: "slack-critical",
+			Integrations: []client.IntegrationStatus{
+				{Name: "slack", LastAttempt: &lastAttempt, LastError: "unexpected status code 401", FailingSince: &failingSince},
+				{Name: "slack", Index: 1, LastAttempt: &lastAttempt},
+				{Name: "webhook"},
+			},
+		},
+		{Name: "blackhole"},
+	}
+
+	var buf bytes.Buffer
+	f := &SimpleFormatter{}
+	f.SetOutput(&buf)
+	if err := f.FormatReceivers(receivers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Receiver        Integration  Last Attempt          State               Last Error                  \n" +
+		"slack-critical  slack[0]     2018-01-01T12:00:00Z  failing for 2h0m0s  unexpected status code 401  \n" +
+		"slack-critical  slack[1]     2018-01-01T12:00:00Z  ok                                              \n" +
+		"slack-critical  webhook[0]   never                 not notified yet                                \n" +
+		"blackhole                                                                                          \n"
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
+
+func TestSimpleFormatterGroupTimers(t *testing.T) {
+	lastFlush := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	nextRepeat := lastFlush.Add(time.Hour)
+	timers := []*client.GroupTimers{
+		{
+			Receiver:     "team-X",
+			Labels:       client.LabelSet{"dc": "eu"},
+			Fingerprints: []string{"0000000000000001", "0000000000000002"},
+			LastFlush:    &lastFlush,
+			NextFlushIn:  4*time.Minute + 30*time.Second,
+			NextRepeat:   &nextRepeat,
+			NextRepeatIn: 54 * time.Minute,
+		},
+		{
+			Receiver:     "team-Y",
+			Labels:       client.LabelSet{"dc": "us"},
+			Fingerprints: []string{"0000000000000003"},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &SimpleFormatter{}
+	f.SetOutput(&buf)
+	if err := f.FormatGroupTimers(timers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "Receiver  Group Labels  Alerts  Last Flush            Next Flush  Next Repeat  \n" +
+		"team-X    dc=\"eu\"       2       2018-01-01T12:00:00Z  in 4m       in 54m       \n" +
+		"team-Y    dc=\"us\"       1       never                 now         -            \n"
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
+
+func TestFormatInhibitRule(t *testing.T) {
+	r := client.InhibitRule{
+		SourceMatch:   map[string]string{"alertname": "NodeDown"},
+		TargetMatchRE: map[string]string{"severity": "^(?:warning|info)$"},
+		Equal:         []string{"instance", "job"},
+		Receivers:     []string{"pager"},
+	}
+	expected := `#1 source{alertname="NodeDown"} target{severity=~"^(?:warning|info)$"} equal[instance,job] receivers[pager]`
+	if s := formatInhibitRule(1, r); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		t        time.Time
+		expected string
+	}{
+		{now, "in 0s"},
+		{now.Add(-45 * time.Second), "45s ago"},
+		{now.Add(-32*time.Minute - 30*time.Second), "32m ago"},
+		{now.Add(time.Hour + 59*time.Minute), "in 1h"},
+		{now.Add(-50 * time.Hour), "2d ago"},
+	} {
+		if got := formatRelative(tc.t, now, "", ""); got != tc.expected {
+			t.Errorf("expected %q for %v, got %q", tc.expected, tc.t, got)
+		}
+	}
+	if got := formatRelative(now.Add(time.Hour), now, "expires ", "expired "); got != "expires in 1h" {
+		t.Errorf("expected %q, got %q", "expires in 1h", got)
+	}
+	if got := formatRelative(now.Add(-time.Hour), now, "expires ", "expired "); got != "expired 1h ago" {
+		t.Errorf("expected %q, got %q", "expired 1h ago", got)
+	}
+}
+
+func TestSimpleFormatterColor(t *testing.T) {
+	defer func(mode string) { colorMode = mode }(colorMode)
+	alerts := []*client.ExtendedAlert{
+		{Alert: client.Alert{Labels: client.LabelSet{"alertname": "Critical", "severity": "critical"}}},
+		{Alert: client.Alert{Labels: client.LabelSet{"alertname": "Other"}}},
+	}
+
+	for mode, expected := range map[string]string{
+		// Output that is not written to a terminal is not colorized.
+		"auto": "Alertname  Starts At             Summary  \n" +
+			"Critical   0001-01-01T00:00:00Z           \n" +
+			"Other      0001-01-01T00:00:00Z           \n",
+		"always": "\x1b[39mAlertname\x1b[0m  Starts At             Summary  \n" +
+			"\x1b[31mCritical\x1b[0m   0001-01-01T00:00:00Z           \n" +
+			"\x1b[39mOther\x1b[0m      0001-01-01T00:00:00Z           \n",
+	} {
+		colorMode = mode
+		var buf bytes.Buffer
+		f := &SimpleFormatter{keepOrder: true}
+		f.SetOutput(&buf)
+		if err := f.FormatAlerts(alerts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != expected {
+			t.Errorf("%s: expected output:\n%q\ngot:\n%q", mode, expected, buf.String())
+		}
+	}
+}
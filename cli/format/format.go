@@ -14,7 +14,11 @@
 package format
 
 import (
+	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -27,10 +31,18 @@ const DefaultDateFormat = "2006-01-02 15:04:05 MST"
 
 var (
 	dateFormat *string
+
+	// absoluteTime disables the relative times of the simple and extended
+	// formatters.
+	absoluteTime bool
+	// colorMode is one of auto, always and never.
+	colorMode = "auto"
 )
 
 func InitFormatFlags(app *kingpin.Application) {
 	dateFormat = app.Flag("date.format", "Format of date output").Default(DefaultDateFormat).String()
+	app.Flag("absolute-time", "Show absolute dates instead of relative times in the simple and extended output").BoolVar(&absoluteTime)
+	app.Flag("color", "Colorize the simple and extended output. auto colorizes it if it is written to a terminal and NO_COLOR is not set").Default("auto").EnumVar(&colorMode, "auto", "always", "never")
 }
 
 // Formatter needs to be implemented for each new output formatter.
@@ -38,12 +50,277 @@ type Formatter interface {
 	SetOutput(io.Writer)
 	FormatSilences([]types.Silence) error
 	FormatAlerts([]*client.ExtendedAlert) error
+	FormatAlertGroups([]*client.AlertGroup) error
+	FormatGroupTimers([]*client.GroupTimers) error
 	FormatConfig(*client.ServerStatus) error
+	FormatClusterStatus(*client.ClusterStatus) error
+	FormatNotificationHistory([]*client.NotificationAttempt) error
+	FormatInhibitions([]InhibitionChain) error
+	FormatReceiverTestResults([]*client.IntegrationTestResult) error
+	FormatReceivers([]*client.ReceiverStatus) error
+	FormatStatus(*Status) error
+}
+
+// Status summarizes the state of an Alertmanager.
+type Status struct {
+	VersionInfo map[string]string `json:"versionInfo"`
+	// StartTime is the time the Alertmanager was started.
+	StartTime time.Time `json:"startTime"`
+	// Cluster is nil if the Alertmanager runs without a cluster.
+	Cluster *client.ClusterStatus `json:"cluster,omitempty"`
+	// ConfigHash identifies the loaded configuration. It is the same for
+	// Alertmanagers running the same configuration.
+	ConfigHash string `json:"configHash"`
+	// Config is nil if the configuration was not returned.
+	Config *ConfigSummary `json:"config,omitempty"`
 }
 
+// ConfigSummary counts the parts of a configuration.
+type ConfigSummary struct {
+	Receivers         int `json:"receivers"`
+	Routes            int `json:"routes"`
+	InhibitRules      int `json:"inhibitRules"`
+	MuteTimeIntervals int `json:"muteTimeIntervals"`
+	Templates         int `json:"templates"`
+}
+
+// InhibitionChain is the inhibition of an alert followed by the inhibitions
+// of the alerts inhibiting it, if any.
+type InhibitionChain []*client.Inhibition
+
 // Formatters is a map of cli argument names to formatter interface object.
 var Formatters = map[string]Formatter{}
 
 func FormatDate(input time.Time) string {
 	return input.Format(*dateFormat)
 }
+
+// formatTime formats the time relative to now, like "32m ago" or "in 1h",
+// unless absolute times are requested.
+func formatTime(t time.Time) string {
+	if absoluteTime || t.IsZero() {
+		return FormatDate(t)
+	}
+	return formatRelative(t, time.Now(), "", "")
+}
+
+// formatExpiry formats the time at which a silence ends, like "expires in
+// 1h" or "expired 2d ago", unless absolute times are requested.
+func formatExpiry(t time.Time) string {
+	if absoluteTime || t.IsZero() {
+		return FormatDate(t)
+	}
+	return formatRelative(t, time.Now(), "expires ", "expired ")
+}
+
+// formatRelative formats the time relative to now, prefixing future times
+// with future and past ones with past.
+func formatRelative(t, now time.Time, future, past string) string {
+	d := t.Sub(now)
+	if d >= 0 {
+		return future + "in " + formatAge(d)
+	}
+	return past + formatAge(-d) + " ago"
+}
+
+// formatAge formats the duration in its largest unit, rounded down.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+}
+
+// formatCountdown formats the duration until a timer fires, like "in 4m",
+// or "now" if it is due.
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "now"
+	}
+	return "in " + formatAge(d)
+}
+
+// formatLastFlush formats the time an aggregation group was flushed last or
+// "never" if it was not flushed yet.
+func formatLastFlush(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return formatTime(*t)
+}
+
+// formatUptime formats the time since the start of an Alertmanager, like
+// "3d (since 2018-06-01 12:00:00 UTC)".
+func formatUptime(start time.Time) string {
+	return fmt.Sprintf("%s (since %s)", formatAge(time.Since(start)), FormatDate(start))
+}
+
+// formatClusterSummary formats the state of a cluster and its number of
+// peers.
+func formatClusterSummary(status *client.ClusterStatus) string {
+	if status == nil {
+		return "disabled"
+	}
+	return fmt.Sprintf("%s, %d peers", status.Status, len(status.Peers))
+}
+
+// formatConfigSummary formats the hash of a configuration followed by the
+// counts of its parts.
+func formatConfigSummary(status *Status) string {
+	s := status.ConfigHash
+	if c := status.Config; c != nil {
+		s += fmt.Sprintf(
+			", %d receivers, %d routes, %d inhibition rules, %d mute time intervals, %d templates",
+			c.Receivers, c.Routes, c.InhibitRules, c.MuteTimeIntervals, c.Templates,
+		)
+	}
+	return s
+}
+
+// formatLastSeen formats the time a cluster peer was last seen with the
+// date function.
+func formatLastSeen(t *time.Time, date func(time.Time) string) string {
+	if t == nil {
+		return "never"
+	}
+	return date(*t)
+}
+
+// formatOptionalDate formats the time with the date function or returns an
+// empty string if it is nil.
+func formatOptionalDate(t *time.Time, date func(time.Time) string) string {
+	if t == nil {
+		return ""
+	}
+	return date(*t)
+}
+
+// ANSI colors of the severities. All codes have the same length, so that
+// colorized cells of a column stay aligned.
+const (
+	colorRed     = "31"
+	colorYellow  = "33"
+	colorBlue    = "34"
+	colorDefault = "39"
+)
+
+// useColor returns whether the output written to w is colorized.
+func useColor(w io.Writer) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorizeSeverity colorizes the string by the severity label if color is
+// set. Strings of unknown severities are wrapped in the default color to
+// keep the columns aligned.
+func colorizeSeverity(s string, labels client.LabelSet, color bool) string {
+	if !color {
+		return s
+	}
+	code := colorDefault
+	switch strings.ToLower(string(labels["severity"])) {
+	case "critical", "page", "error":
+		code = colorRed
+	case "warning", "warn":
+		code = colorYellow
+	case "info", "informational", "notice":
+		code = colorBlue
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// formatTestResult returns the outcome of a test notification.
+func formatTestResult(r *client.IntegrationTestResult) string {
+	if r.Error != "" {
+		return "failed"
+	}
+	return "sent"
+}
+
+// formatIntegrationState describes the state of an integration by the
+// results of its last notifications.
+func formatIntegrationState(is client.IntegrationStatus) string {
+	switch {
+	case is.LastAttempt == nil:
+		return "not notified yet"
+	case is.FailingSince != nil:
+		return fmt.Sprintf("failing for %s", time.Since(*is.FailingSince).Round(time.Second))
+	default:
+		return "ok"
+	}
+}
+
+// formatSeconds formats a duration given in seconds.
+func formatSeconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// formatInhibitRule describes the inhibition rule.
+func formatInhibitRule(index int, r client.InhibitRule) string {
+	parts := []string{fmt.Sprintf("#%d", index)}
+	for _, m := range []struct {
+		name string
+		op   string
+		m    map[string]string
+	}{
+		{"source", "=", r.SourceMatch},
+		{"source", "=~", r.SourceMatchRE},
+		{"target", "=", r.TargetMatch},
+		{"target", "=~", r.TargetMatchRE},
+		{"target", "=~", r.TargetMatchRETemplate},
+	} {
+		if len(m.m) == 0 {
+			continue
+		}
+		matchers := make([]string, 0, len(m.m))
+		for k, v := range m.m {
+			matchers = append(matchers, fmt.Sprintf("%s%s%q", k, m.op, v))
+		}
+		sort.Strings(matchers)
+		parts = append(parts, fmt.Sprintf("%s{%s}", m.name, strings.Join(matchers, ",")))
+	}
+	if len(r.Equal) > 0 {
+		parts = append(parts, fmt.Sprintf("equal[%s]", strings.Join(r.Equal, ",")))
+	}
+	if len(r.Receivers) > 0 {
+		parts = append(parts, fmt.Sprintf("receivers[%s]", strings.Join(r.Receivers, ",")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatInhibitionChain joins the values of the links of the chain with
+// arrows pointing from the inhibiting alerts to the inhibited ones.
+func formatInhibitionChain(chain InhibitionChain, value func(*client.Inhibition) string) string {
+	values := make([]string, 0, len(chain))
+	for _, inh := range chain {
+		values = append(values, value(inh))
+	}
+	return strings.Join(values, " <- ")
+}
+
+// formatResult returns the outcome of a notification attempt.
+func formatResult(a *client.NotificationAttempt) string {
+	if a.Error != "" {
+		return "failed"
+	}
+	return "sent"
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// CSVFormatter writes comma-separated values with a header row.
+type CSVFormatter struct {
+	writer io.Writer
+	fields []string
+
+	keepOrder bool
+}
+
+func init() {
+	Formatters["csv"] = &CSVFormatter{writer: os.Stdout}
+}
+
+func (formatter *CSVFormatter) SetOutput(writer io.Writer) {
+	formatter.writer = writer
+}
+
+func (formatter *CSVFormatter) SetFields(fields []string) {
+	formatter.fields = fields
+}
+
+func (formatter *CSVFormatter) KeepOrder() {
+	formatter.keepOrder = true
+}
+
+func (formatter *CSVFormatter) write(header []string, rows [][]string) error {
+	w := csv.NewWriter(formatter.writer)
+	w.Write(header)
+	w.WriteAll(rows)
+	return w.Error()
+}
+
+func (formatter *CSVFormatter) FormatSilences(silences []types.Silence) error {
+	fields := formatter.fields
+	if len(fields) == 0 {
+		fields = defaultSilenceFields
+	}
+	rows, err := silenceRows(silences, fields, formatter.keepOrder)
+	if err != nil {
+		return err
+	}
+	return formatter.write(fields, rows)
+}
+
+func (formatter *CSVFormatter) FormatAlerts(alerts []*client.ExtendedAlert) error {
+	fields := formatter.fields
+	if len(fields) == 0 {
+		fields = defaultAlertFields
+	}
+	rows, err := alertRows(alerts, fields, formatter.keepOrder)
+	if err != nil {
+		return err
+	}
+	return formatter.write(fields, rows)
+}
+
+func (formatter *CSVFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		alerts := make([]string, 0, len(group.Alerts))
+		for _, alert := range group.Alerts {
+			alerts = append(alerts, alert.Fingerprint)
+		}
+		rows = append(rows, []string{
+			group.Receiver,
+			group.GroupKey,
+			extendedFormatLabels(group.Labels),
+			strings.Join(alerts, " "),
+			FormatDate(group.NextFlush),
+		})
+	}
+	return formatter.write([]string{"receiver", "groupKey", "labels", "alerts", "nextFlush"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	rows := make([][]string, 0, len(timers))
+	for _, t := range timers {
+		var nextRepeatIn string
+		if t.NextRepeat != nil {
+			nextRepeatIn = strconv.FormatFloat(t.NextRepeatIn.Seconds(), 'f', -1, 64)
+		}
+		rows = append(rows, []string{
+			t.Receiver,
+			t.GroupKey,
+			strings.Join(t.Fingerprints, " "),
+			t.GroupInterval.String(),
+			t.RepeatInterval.String(),
+			formatOptionalDate(t.LastFlush, FormatDate),
+			FormatDate(t.NextFlush),
+			strconv.FormatFloat(t.NextFlushIn.Seconds(), 'f', -1, 64),
+			formatOptionalDate(t.LastNotified, FormatDate),
+			formatOptionalDate(t.NextRepeat, FormatDate),
+			nextRepeatIn,
+		})
+	}
+	return formatter.write([]string{
+		"receiver", "groupKey", "alerts", "groupInterval", "repeatInterval", "lastFlush",
+		"nextFlush", "nextFlushInSeconds", "lastNotified", "nextRepeat", "nextRepeatInSeconds",
+	}, rows)
+}
+
+func (formatter *CSVFormatter) FormatConfig(status *client.ServerStatus) error {
+	return errors.New("the configuration cannot be formatted as CSV")
+}
+
+func (formatter *CSVFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	rows := make([][]string, 0, len(status.Peers))
+	for _, peer := range status.Peers {
+		rows = append(rows, []string{
+			peer.Name,
+			peer.Address,
+			formatLastSeen(peer.LastSeen, FormatDate),
+			peer.Zone,
+			peer.Region,
+			strconv.FormatBool(peer.Name == status.Name),
+		})
+	}
+	return formatter.write([]string{"name", "address", "lastSeen", "zone", "region", "self"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	rows := make([][]string, 0, len(chains))
+	for _, c := range chains {
+		rows = append(rows, []string{
+			c[0].Fingerprint,
+			string(c[0].Labels["alertname"]),
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return inh.InhibitedBy.Fingerprint
+			}),
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return formatInhibitRule(inh.RuleIndex, inh.Rule)
+			}),
+		})
+	}
+	return formatter.write([]string{"fingerprint", "alertname", "inhibitedBy", "rules"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []string{
+			r.Integration,
+			strconv.Itoa(r.Index),
+			r.Status,
+			strconv.FormatFloat(r.Duration, 'f', -1, 64),
+			formatTestResult(r),
+			r.Error,
+		})
+	}
+	return formatter.write([]string{"integration", "index", "status", "durationSeconds", "result", "error"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	var rows [][]string
+	for _, r := range receivers {
+		for _, is := range r.Integrations {
+			rows = append(rows, []string{
+				r.Name,
+				is.Name,
+				strconv.Itoa(is.Index),
+				formatOptionalDate(is.LastAttempt, FormatDate),
+				formatOptionalDate(is.FailingSince, FormatDate),
+				formatOptionalDate(is.LastErrorAt, FormatDate),
+				is.LastError,
+			})
+		}
+	}
+	return formatter.write([]string{"receiver", "integration", "index", "lastAttempt", "failingSince", "lastErrorAt", "lastError"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	rows := make([][]string, 0, len(attempts))
+	for _, a := range attempts {
+		rows = append(rows, []string{
+			FormatDate(a.Timestamp),
+			a.GroupKey,
+			a.Receiver,
+			a.Integration,
+			strings.Join(a.FiringAlerts, " "),
+			strings.Join(a.ResolvedAlerts, " "),
+			formatResult(a),
+			a.Error,
+		})
+	}
+	return formatter.write([]string{"timestamp", "groupKey", "receiver", "integration", "firingAlerts", "resolvedAlerts", "result", "error"}, rows)
+}
+
+func (formatter *CSVFormatter) FormatStatus(status *Status) error {
+	header := []string{"version", "revision", "startTime", "cluster", "peers", "configHash"}
+	row := []string{
+		status.VersionInfo["version"],
+		status.VersionInfo["revision"],
+		FormatDate(status.StartTime),
+		"disabled",
+		"0",
+		status.ConfigHash,
+	}
+	if c := status.Cluster; c != nil {
+		row[3], row[4] = c.Status, strconv.Itoa(len(c.Peers))
+	}
+	if c := status.Config; c != nil {
+		header = append(header, "receivers", "routes", "inhibitRules", "muteTimeIntervals", "templates")
+		for _, n := range []int{c.Receivers, c.Routes, c.InhibitRules, c.MuteTimeIntervals, c.Templates} {
+			row = append(row, strconv.Itoa(n))
+		}
+	}
+	return formatter.write(header, [][]string{row})
+}
@@ -23,10 +23,14 @@ import (
 
 	"github.com/prometheus/alertmanager/client"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 )
 
 type ExtendedFormatter struct {
 	writer io.Writer
+	fields []string
+
+	keepOrder bool
 }
 
 func init() {
@@ -37,9 +41,26 @@ func (formatter *ExtendedFormatter) SetOutput(writer io.Writer) {
 	formatter.writer = writer
 }
 
+func (formatter *ExtendedFormatter) SetFields(fields []string) {
+	formatter.fields = fields
+}
+
+func (formatter *ExtendedFormatter) KeepOrder() {
+	formatter.keepOrder = true
+}
+
 func (formatter *ExtendedFormatter) FormatSilences(silences []types.Silence) error {
+	if len(formatter.fields) > 0 {
+		rows, err := silenceRows(silences, formatter.fields, formatter.keepOrder)
+		if err != nil {
+			return err
+		}
+		return formatTable(formatter.writer, formatter.fields, rows)
+	}
 	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
-	sort.Sort(ByEndAt(silences))
+	if !formatter.keepOrder {
+		sort.Sort(ByEndAt(silences))
+	}
 	fmt.Fprintln(w, "ID\tMatchers\tStarts At\tEnds At\tUpdated At\tCreated By\tComment\t")
 	for _, silence := range silences {
 		fmt.Fprintf(
@@ -47,9 +68,9 @@ func (formatter *ExtendedFormatter) FormatSilences(silences []types.Silence) err
 			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
 			silence.ID,
 			extendedFormatMatchers(silence.Matchers),
-			FormatDate(silence.StartsAt),
-			FormatDate(silence.EndsAt),
-			FormatDate(silence.UpdatedAt),
+			formatTime(silence.StartsAt),
+			formatExpiry(silence.EndsAt),
+			formatTime(silence.UpdatedAt),
 			silence.CreatedBy,
 			silence.Comment,
 		)
@@ -59,17 +80,29 @@ func (formatter *ExtendedFormatter) FormatSilences(silences []types.Silence) err
 }
 
 func (formatter *ExtendedFormatter) FormatAlerts(alerts []*client.ExtendedAlert) error {
+	if len(formatter.fields) > 0 {
+		rows, err := alertRows(alerts, formatter.fields, formatter.keepOrder)
+		if err != nil {
+			return err
+		}
+		return formatTable(formatter.writer, formatter.fields, rows)
+	}
 	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
-	sort.Sort(ByStartsAt(alerts))
-	fmt.Fprintln(w, "Labels\tAnnotations\tStarts At\tEnds At\tGenerator URL\t")
+	if !formatter.keepOrder {
+		sort.Sort(ByStartsAt(alerts))
+	}
+	color := useColor(formatter.writer)
+	// The header is wrapped in the default color like the labels to keep
+	// the column aligned.
+	fmt.Fprintf(w, "%s\tAnnotations\tStarts At\tEnds At\tGenerator URL\t\n", colorizeSeverity("Labels", nil, color))
 	for _, alert := range alerts {
 		fmt.Fprintf(
 			w,
 			"%s\t%s\t%s\t%s\t%s\t\n",
-			extendedFormatLabels(alert.Labels),
+			colorizeSeverity(extendedFormatLabels(alert.Labels), alert.Labels, color),
 			extendedFormatAnnotations(alert.Annotations),
-			FormatDate(alert.StartsAt),
-			FormatDate(alert.EndsAt),
+			formatTime(alert.StartsAt),
+			formatTime(alert.EndsAt),
 			alert.GeneratorURL,
 		)
 	}
@@ -77,6 +110,56 @@ func (formatter *ExtendedFormatter) FormatAlerts(alerts []*client.ExtendedAlert)
 	return nil
 }
 
+func (formatter *ExtendedFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tGroup Key\tAlerts\tNext Flush\t")
+	for _, group := range groups {
+		alerts := make([]string, 0, len(group.Alerts))
+		for _, alert := range group.Alerts {
+			alerts = append(alerts, fmt.Sprintf("%s[%s]", alert.Labels["alertname"], alert.Fingerprint))
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t\n",
+			group.Receiver,
+			group.GroupKey,
+			strings.Join(alerts, " "),
+			formatTime(group.NextFlush),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tGroup Key\tAlerts\tGroup Interval\tRepeat Interval\tLast Flush\tNext Flush\tLast Notified\tNext Repeat\t")
+	for _, t := range timers {
+		lastNotified, nextRepeat := "never", "-"
+		if t.LastNotified != nil {
+			lastNotified = formatTime(*t.LastNotified)
+		}
+		if t.NextRepeat != nil {
+			nextRepeat = fmt.Sprintf("%s (%s)", formatCountdown(t.NextRepeatIn), FormatDate(*t.NextRepeat))
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			t.Receiver,
+			t.GroupKey,
+			strings.Join(t.Fingerprints, " "),
+			model.Duration(t.GroupInterval),
+			model.Duration(t.RepeatInterval),
+			formatLastFlush(t.LastFlush),
+			fmt.Sprintf("%s (%s)", formatCountdown(t.NextFlushIn), FormatDate(t.NextFlush)),
+			lastNotified,
+			nextRepeat,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
 func (formatter *ExtendedFormatter) FormatConfig(status *client.ServerStatus) error {
 	fmt.Fprintln(formatter.writer, status.ConfigYAML)
 	fmt.Fprintln(formatter.writer, "buildUser", status.VersionInfo["buildUser"])
@@ -89,6 +172,134 @@ func (formatter *ExtendedFormatter) FormatConfig(status *client.ServerStatus) er
 	return nil
 }
 
+func (formatter *ExtendedFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	fmt.Fprintln(formatter.writer, "name", status.Name)
+	fmt.Fprintln(formatter.writer, "status", status.Status)
+	fmt.Fprintln(formatter.writer, "messagesQueued", status.MessagesQueued)
+	fmt.Fprintln(formatter.writer, "oversizedMessages", status.OversizedMessages)
+	fmt.Fprintln(formatter.writer)
+
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tAddress\tLast Seen\tZone\tRegion\tSelf\t")
+	for _, peer := range status.Peers {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%t\t\n",
+			peer.Name,
+			peer.Address,
+			formatLastSeen(peer.LastSeen, formatTime),
+			peer.Zone,
+			peer.Region,
+			peer.Name == status.Name,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tGroup Key\tReceiver\tIntegration\tFiring\tResolved\tResult\tError\t")
+	for _, a := range attempts {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			formatTime(a.Timestamp),
+			a.GroupKey,
+			a.Receiver,
+			a.Integration,
+			strings.Join(a.FiringAlerts, ","),
+			strings.Join(a.ResolvedAlerts, ","),
+			formatResult(a),
+			a.Error,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Fingerprint\tLabels\tInhibited By\tRules\t")
+	for _, c := range chains {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t\n",
+			c[0].Fingerprint,
+			extendedFormatLabels(c[0].Labels),
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return fmt.Sprintf("%s{%s}", inh.InhibitedBy.Fingerprint, extendedFormatLabels(inh.InhibitedBy.Labels))
+			}),
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return formatInhibitRule(inh.RuleIndex, inh.Rule)
+			}),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Integration\tIndex\tStatus\tDuration\tResult\tError\t")
+	for _, r := range results {
+		fmt.Fprintf(
+			w,
+			"%s\t%d\t%s\t%s\t%s\t%s\t\n",
+			r.Integration,
+			r.Index,
+			r.Status,
+			formatSeconds(r.Duration),
+			formatTestResult(r),
+			r.Error,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tIntegration\tIndex\tLast Attempt\tState\tFailing Since\tLast Error At\tLast Error\t")
+	for _, r := range receivers {
+		if len(r.Integrations) == 0 {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\t\t\t\n", r.Name)
+		}
+		for _, is := range r.Integrations {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t\n",
+				r.Name,
+				is.Name,
+				is.Index,
+				formatLastSeen(is.LastAttempt, formatTime),
+				formatIntegrationState(is),
+				formatOptionalDate(is.FailingSince, formatTime),
+				formatOptionalDate(is.LastErrorAt, formatTime),
+				is.LastError,
+			)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *ExtendedFormatter) FormatStatus(status *Status) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	for _, k := range []string{"version", "revision", "branch", "buildUser", "buildDate", "goVersion"} {
+		fmt.Fprintf(w, "%s\t%s\t\n", k, status.VersionInfo[k])
+	}
+	fmt.Fprintf(w, "uptime\t%s\t\n", formatUptime(status.StartTime))
+	fmt.Fprintf(w, "config\t%s\t\n", formatConfigSummary(status))
+	if status.Cluster == nil {
+		fmt.Fprintf(w, "cluster\t%s\t\n", formatClusterSummary(nil))
+		return w.Flush()
+	}
+	w.Flush()
+	fmt.Fprintln(formatter.writer)
+	return formatter.FormatClusterStatus(status.Cluster)
+}
+
 func extendedFormatLabels(labels client.LabelSet) string {
 	output := []string{}
 	for name, value := range labels {
@@ -107,6 +318,16 @@ func extendedFormatAnnotations(labels client.LabelSet) string {
 	return strings.Join(output, " ")
 }
 
+// FormatMetadata formats the metadata of a silence sorted by field name.
+func FormatMetadata(md map[string]string) string {
+	output := make([]string, 0, len(md))
+	for name, value := range md {
+		output = append(output, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(output)
+	return strings.Join(output, " ")
+}
+
 func extendedFormatMatchers(matchers types.Matchers) string {
 	output := []string{}
 	for _, matcher := range matchers {
@@ -116,8 +337,8 @@ func extendedFormatMatchers(matchers types.Matchers) string {
 }
 
 func extendedFormatMatcher(matcher types.Matcher) string {
-	if matcher.IsRegex {
+	if matcher.IsRegex && !matcher.IsNegative {
 		return fmt.Sprintf("%s~=%s", matcher.Name, matcher.Value)
 	}
-	return fmt.Sprintf("%s=%s", matcher.Name, matcher.Value)
+	return fmt.Sprintf("%s%s%s", matcher.Name, matcher.Operator(), matcher.Value)
 }
@@ -44,7 +44,47 @@ func (formatter *JSONFormatter) FormatAlerts(alerts []*client.ExtendedAlert) err
 	return enc.Encode(alerts)
 }
 
+func (formatter *JSONFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(groups)
+}
+
+func (formatter *JSONFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(timers)
+}
+
 func (formatter *JSONFormatter) FormatConfig(status *client.ServerStatus) error {
 	enc := json.NewEncoder(formatter.writer)
 	return enc.Encode(status)
 }
+
+func (formatter *JSONFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(status)
+}
+
+func (formatter *JSONFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(attempts)
+}
+
+func (formatter *JSONFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(chains)
+}
+
+func (formatter *JSONFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(receivers)
+}
+
+func (formatter *JSONFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(results)
+}
+
+func (formatter *JSONFormatter) FormatStatus(status *Status) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(status)
+}
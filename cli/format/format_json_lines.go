@@ -0,0 +1,141 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// AlertStreamFormatter is implemented by formatters that write alerts one at
+// a time as they are received instead of all of them at once.
+type AlertStreamFormatter interface {
+	FormatAlert(*client.ExtendedAlert) error
+}
+
+// JSONLinesFormatter writes one JSON object per line, so that the output
+// can be processed line by line.
+type JSONLinesFormatter struct {
+	writer io.Writer
+}
+
+func init() {
+	Formatters["json-lines"] = &JSONLinesFormatter{writer: os.Stdout}
+}
+
+func (formatter *JSONLinesFormatter) SetOutput(writer io.Writer) {
+	formatter.writer = writer
+}
+
+func (formatter *JSONLinesFormatter) FormatSilences(silences []types.Silence) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, s := range silences {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatAlerts(alerts []*client.ExtendedAlert) error {
+	for _, a := range alerts {
+		if err := formatter.FormatAlert(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatAlert(alert *client.ExtendedAlert) error {
+	return json.NewEncoder(formatter.writer).Encode(alert)
+}
+
+func (formatter *JSONLinesFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, g := range groups {
+		if err := enc.Encode(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, t := range timers {
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatConfig(status *client.ServerStatus) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(status)
+}
+
+func (formatter *JSONLinesFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(status)
+}
+
+func (formatter *JSONLinesFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, a := range attempts {
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, c := range chains {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, r := range receivers {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	enc := json.NewEncoder(formatter.writer)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (formatter *JSONLinesFormatter) FormatStatus(status *Status) error {
+	enc := json.NewEncoder(formatter.writer)
+	return enc.Encode(status)
+}
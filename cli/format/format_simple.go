@@ -27,6 +27,9 @@ import (
 
 type SimpleFormatter struct {
 	writer io.Writer
+	fields []string
+
+	keepOrder bool
 }
 
 func init() {
@@ -37,9 +40,26 @@ func (formatter *SimpleFormatter) SetOutput(writer io.Writer) {
 	formatter.writer = writer
 }
 
+func (formatter *SimpleFormatter) SetFields(fields []string) {
+	formatter.fields = fields
+}
+
+func (formatter *SimpleFormatter) KeepOrder() {
+	formatter.keepOrder = true
+}
+
 func (formatter *SimpleFormatter) FormatSilences(silences []types.Silence) error {
+	if len(formatter.fields) > 0 {
+		rows, err := silenceRows(silences, formatter.fields, formatter.keepOrder)
+		if err != nil {
+			return err
+		}
+		return formatTable(formatter.writer, formatter.fields, rows)
+	}
 	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
-	sort.Sort(ByEndAt(silences))
+	if !formatter.keepOrder {
+		sort.Sort(ByEndAt(silences))
+	}
 	fmt.Fprintln(w, "ID\tMatchers\tEnds At\tCreated By\tComment\t")
 	for _, silence := range silences {
 		fmt.Fprintf(
@@ -47,7 +67,7 @@ func (formatter *SimpleFormatter) FormatSilences(silences []types.Silence) error
 			"%s\t%s\t%s\t%s\t%s\t\n",
 			silence.ID,
 			simpleFormatMatchers(silence.Matchers),
-			FormatDate(silence.EndsAt),
+			formatExpiry(silence.EndsAt),
 			silence.CreatedBy,
 			silence.Comment,
 		)
@@ -57,15 +77,27 @@ func (formatter *SimpleFormatter) FormatSilences(silences []types.Silence) error
 }
 
 func (formatter *SimpleFormatter) FormatAlerts(alerts []*client.ExtendedAlert) error {
+	if len(formatter.fields) > 0 {
+		rows, err := alertRows(alerts, formatter.fields, formatter.keepOrder)
+		if err != nil {
+			return err
+		}
+		return formatTable(formatter.writer, formatter.fields, rows)
+	}
 	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
-	sort.Sort(ByStartsAt(alerts))
-	fmt.Fprintln(w, "Alertname\tStarts At\tSummary\t")
+	if !formatter.keepOrder {
+		sort.Sort(ByStartsAt(alerts))
+	}
+	color := useColor(formatter.writer)
+	// The header is wrapped in the default color like the alert names to
+	// keep the column aligned.
+	fmt.Fprintf(w, "%s\tStarts At\tSummary\t\n", colorizeSeverity("Alertname", nil, color))
 	for _, alert := range alerts {
 		fmt.Fprintf(
 			w,
 			"%s\t%s\t%s\t\n",
-			alert.Labels["alertname"],
-			FormatDate(alert.StartsAt),
+			colorizeSeverity(string(alert.Labels["alertname"]), alert.Labels, color),
+			formatTime(alert.StartsAt),
 			alert.Annotations["summary"],
 		)
 	}
@@ -73,11 +105,158 @@ func (formatter *SimpleFormatter) FormatAlerts(alerts []*client.ExtendedAlert) e
 	return nil
 }
 
+func (formatter *SimpleFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tGroup Labels\tAlerts\tNext Flush\t")
+	for _, group := range groups {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%d\t%s\t\n",
+			group.Receiver,
+			extendedFormatLabels(group.Labels),
+			len(group.Alerts),
+			formatTime(group.NextFlush),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tGroup Labels\tAlerts\tLast Flush\tNext Flush\tNext Repeat\t")
+	for _, t := range timers {
+		nextRepeat := "-"
+		if t.NextRepeat != nil {
+			nextRepeat = formatCountdown(t.NextRepeatIn)
+		}
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%d\t%s\t%s\t%s\t\n",
+			t.Receiver,
+			extendedFormatLabels(t.Labels),
+			len(t.Fingerprints),
+			formatLastFlush(t.LastFlush),
+			formatCountdown(t.NextFlushIn),
+			nextRepeat,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
 func (formatter *SimpleFormatter) FormatConfig(status *client.ServerStatus) error {
 	fmt.Fprintln(formatter.writer, status.ConfigYAML)
 	return nil
 }
 
+func (formatter *SimpleFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tAddress\tLast Seen\tZone\t")
+	for _, peer := range status.Peers {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t\n",
+			peer.Name,
+			peer.Address,
+			formatLastSeen(peer.LastSeen, formatTime),
+			peer.Zone,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tReceiver\tIntegration\tAlerts\tResult\t")
+	for _, a := range attempts {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%d\t%s\t\n",
+			formatTime(a.Timestamp),
+			a.Receiver,
+			a.Integration,
+			len(a.FiringAlerts)+len(a.ResolvedAlerts),
+			formatResult(a),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Alertname\tInhibited By\tRules\t")
+	for _, c := range chains {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t\n",
+			c[0].Labels["alertname"],
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return string(inh.InhibitedBy.Labels["alertname"])
+			}),
+			formatInhibitionChain(c, func(inh *client.Inhibition) string {
+				return fmt.Sprintf("#%d", inh.RuleIndex)
+			}),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Integration\tStatus\tDuration\tResult\t")
+	for _, r := range results {
+		fmt.Fprintf(
+			w,
+			"%s[%d]\t%s\t%s\t%s\t\n",
+			r.Integration,
+			r.Index,
+			r.Status,
+			formatSeconds(r.Duration),
+			formatTestResult(r),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Receiver\tIntegration\tLast Attempt\tState\tLast Error\t")
+	for _, r := range receivers {
+		if len(r.Integrations) == 0 {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\n", r.Name)
+		}
+		for _, is := range r.Integrations {
+			fmt.Fprintf(
+				w,
+				"%s\t%s[%d]\t%s\t%s\t%s\t\n",
+				r.Name,
+				is.Name,
+				is.Index,
+				formatLastSeen(is.LastAttempt, formatTime),
+				formatIntegrationState(is),
+				is.LastError,
+			)
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+func (formatter *SimpleFormatter) FormatStatus(status *Status) error {
+	w := tabwriter.NewWriter(formatter.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s (revision %s)\t\n", status.VersionInfo["version"], status.VersionInfo["revision"])
+	fmt.Fprintf(w, "Uptime:\t%s\t\n", formatUptime(status.StartTime))
+	fmt.Fprintf(w, "Cluster:\t%s\t\n", formatClusterSummary(status.Cluster))
+	fmt.Fprintf(w, "Config:\t%s\t\n", formatConfigSummary(status))
+	w.Flush()
+	return nil
+}
+
 func simpleFormatMatchers(matchers types.Matchers) string {
 	output := []string{}
 	for _, matcher := range matchers {
@@ -87,8 +266,5 @@ func simpleFormatMatchers(matchers types.Matchers) string {
 }
 
 func simpleFormatMatcher(matcher types.Matcher) string {
-	if matcher.IsRegex {
-		return fmt.Sprintf("%s=~%s", matcher.Name, matcher.Value)
-	}
-	return fmt.Sprintf("%s=%s", matcher.Name, matcher.Value)
+	return fmt.Sprintf("%s%s%s", matcher.Name, matcher.Operator(), matcher.Value)
 }
@@ -0,0 +1,145 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// YAMLFormatter writes the objects as YAML. The keys are the same as the
+// ones of the JSON output.
+type YAMLFormatter struct {
+	writer io.Writer
+	fields []string
+
+	keepOrder bool
+}
+
+func init() {
+	Formatters["yaml"] = &YAMLFormatter{writer: os.Stdout}
+}
+
+func (formatter *YAMLFormatter) SetOutput(writer io.Writer) {
+	formatter.writer = writer
+}
+
+func (formatter *YAMLFormatter) SetFields(fields []string) {
+	formatter.fields = fields
+}
+
+func (formatter *YAMLFormatter) KeepOrder() {
+	formatter.keepOrder = true
+}
+
+// encode writes v as YAML. It is encoded as JSON first to use the JSON
+// keys and formats of the API types.
+func (formatter *YAMLFormatter) encode(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var obj interface{}
+	if err := yaml.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	return formatter.encodeYAML(obj)
+}
+
+func (formatter *YAMLFormatter) encodeYAML(v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = formatter.writer.Write(b)
+	return err
+}
+
+// encodeRows writes the rows as a list of mappings from the fields to the
+// values.
+func (formatter *YAMLFormatter) encodeRows(rows [][]string) error {
+	objs := make([]yaml.MapSlice, 0, len(rows))
+	for _, row := range rows {
+		obj := make(yaml.MapSlice, 0, len(row))
+		for i, v := range row {
+			obj = append(obj, yaml.MapItem{Key: formatter.fields[i], Value: v})
+		}
+		objs = append(objs, obj)
+	}
+	return formatter.encodeYAML(objs)
+}
+
+func (formatter *YAMLFormatter) FormatSilences(silences []types.Silence) error {
+	if len(formatter.fields) == 0 {
+		return formatter.encode(silences)
+	}
+	rows, err := silenceRows(silences, formatter.fields, formatter.keepOrder)
+	if err != nil {
+		return err
+	}
+	return formatter.encodeRows(rows)
+}
+
+func (formatter *YAMLFormatter) FormatAlerts(alerts []*client.ExtendedAlert) error {
+	if len(formatter.fields) == 0 {
+		return formatter.encode(alerts)
+	}
+	rows, err := alertRows(alerts, formatter.fields, formatter.keepOrder)
+	if err != nil {
+		return err
+	}
+	return formatter.encodeRows(rows)
+}
+
+func (formatter *YAMLFormatter) FormatAlertGroups(groups []*client.AlertGroup) error {
+	return formatter.encode(groups)
+}
+
+func (formatter *YAMLFormatter) FormatGroupTimers(timers []*client.GroupTimers) error {
+	return formatter.encode(timers)
+}
+
+func (formatter *YAMLFormatter) FormatConfig(status *client.ServerStatus) error {
+	return formatter.encode(status)
+}
+
+func (formatter *YAMLFormatter) FormatClusterStatus(status *client.ClusterStatus) error {
+	return formatter.encode(status)
+}
+
+func (formatter *YAMLFormatter) FormatNotificationHistory(attempts []*client.NotificationAttempt) error {
+	return formatter.encode(attempts)
+}
+
+func (formatter *YAMLFormatter) FormatInhibitions(chains []InhibitionChain) error {
+	return formatter.encode(chains)
+}
+
+func (formatter *YAMLFormatter) FormatReceivers(receivers []*client.ReceiverStatus) error {
+	return formatter.encode(receivers)
+}
+
+func (formatter *YAMLFormatter) FormatReceiverTestResults(results []*client.IntegrationTestResult) error {
+	return formatter.encode(results)
+}
+
+func (formatter *YAMLFormatter) FormatStatus(status *Status) error {
+	return formatter.encode(status)
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/api"
+	commoncfg "github.com/prometheus/common/config"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// httpFlags holds the flags configuring the connection to the Alertmanager.
+type httpFlags struct {
+	tlsCert            string
+	tlsKey             string
+	tlsCA              string
+	tlsServerName      string
+	insecureSkipVerify bool
+	basicAuth          string
+	bearerToken        string
+	bearerTokenFile    string
+}
+
+var httpConfig = &httpFlags{}
+
+func configureHTTPFlags(app *kingpin.Application) {
+	app.Flag("tls.cert", "Client certificate file for TLS connections to the Alertmanager").StringVar(&httpConfig.tlsCert)
+	app.Flag("tls.key", "Client key file for TLS connections to the Alertmanager").StringVar(&httpConfig.tlsKey)
+	app.Flag("tls.ca", "CA certificate file to verify the Alertmanager with").StringVar(&httpConfig.tlsCA)
+	app.Flag("tls.server-name", "Server name to verify the Alertmanager certificate with").StringVar(&httpConfig.tlsServerName)
+	app.Flag("tls.insecure-skip-verify", "Disable verification of the Alertmanager certificate").BoolVar(&httpConfig.insecureSkipVerify)
+	app.Flag("http.basic-auth", "Basic authentication credentials in the form <username>:<password>").StringVar(&httpConfig.basicAuth)
+	app.Flag("http.bearer-token", "Bearer token to authenticate with").StringVar(&httpConfig.bearerToken)
+	app.Flag("http.bearer-token-file", "File to read the bearer token to authenticate with from").StringVar(&httpConfig.bearerTokenFile)
+}
+
+// clientConfig returns the HTTP client configuration for the flags, or nil
+// if none of them is set.
+func (f *httpFlags) clientConfig() (*commoncfg.HTTPClientConfig, error) {
+	if *f == (httpFlags{}) {
+		return nil, nil
+	}
+	if (f.tlsCert == "") != (f.tlsKey == "") {
+		return nil, fmt.Errorf("--tls.cert and --tls.key must be given together")
+	}
+	cfg := &commoncfg.HTTPClientConfig{
+		BearerToken:     commoncfg.Secret(f.bearerToken),
+		BearerTokenFile: f.bearerTokenFile,
+		TLSConfig: commoncfg.TLSConfig{
+			CertFile:           f.tlsCert,
+			KeyFile:            f.tlsKey,
+			CAFile:             f.tlsCA,
+			ServerName:         f.tlsServerName,
+			InsecureSkipVerify: f.insecureSkipVerify,
+		},
+	}
+	if f.basicAuth != "" {
+		parts := strings.SplitN(f.basicAuth, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--http.basic-auth must be in the form <username>:<password>")
+		}
+		cfg.BasicAuth = &commoncfg.BasicAuth{Username: parts[0], Password: commoncfg.Secret(parts[1])}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// environ returns the flags that are set as the environment variables amtool
+// reads them from.
+func (f *httpFlags) environ() []string {
+	var env []string
+	for name, v := range map[string]string{
+		"AMTOOL_TLS_CERT":               f.tlsCert,
+		"AMTOOL_TLS_KEY":                f.tlsKey,
+		"AMTOOL_TLS_CA":                 f.tlsCA,
+		"AMTOOL_TLS_SERVER_NAME":        f.tlsServerName,
+		"AMTOOL_HTTP_BASIC_AUTH":        f.basicAuth,
+		"AMTOOL_HTTP_BEARER_TOKEN":      f.bearerToken,
+		"AMTOOL_HTTP_BEARER_TOKEN_FILE": f.bearerTokenFile,
+	} {
+		if v != "" {
+			env = append(env, name+"="+v)
+		}
+	}
+	if f.insecureSkipVerify {
+		env = append(env, "AMTOOL_TLS_INSECURE_SKIP_VERIFY=true")
+	}
+	return env
+}
+
+// NewAPIClient returns a client for the configured Alertmanager using the
+// configured TLS settings and credentials.
+func NewAPIClient() (api.Client, error) {
+	return newAPIClient(alertmanagerURL)
+}
+
+// newAPIClient returns a client for the Alertmanager at the URL.
+func newAPIClient(u *url.URL) (api.Client, error) {
+	cfg := api.Config{Address: u.String()}
+
+	httpCfg, err := httpConfig.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if httpCfg != nil {
+		c, err := commoncfg.NewHTTPClientFromConfig(httpCfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RoundTripper = c.Transport
+	}
+	return api.NewClient(cfg)
+}
@@ -0,0 +1,46 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+)
+
+func TestHTTPFlagsClientConfig(t *testing.T) {
+	cfg, err := (&httpFlags{}).clientConfig()
+	if err != nil || cfg != nil {
+		t.Fatalf("expected no config without flags, got %v, %v", cfg, err)
+	}
+
+	cfg, err = (&httpFlags{basicAuth: "user:pass:word", tlsCA: "ca.crt"}).clientConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BasicAuth.Username != "user" || string(cfg.BasicAuth.Password) != "pass:word" {
+		t.Errorf("unexpected basic auth %v", cfg.BasicAuth)
+	}
+	if cfg.TLSConfig.CAFile != "ca.crt" {
+		t.Errorf("unexpected CA file %q", cfg.TLSConfig.CAFile)
+	}
+
+	for _, f := range []httpFlags{
+		{basicAuth: "user"},
+		{tlsCert: "cert.pem"},
+		{basicAuth: "user:pass", bearerToken: "token"},
+	} {
+		if _, err := f.clientConfig(); err == nil {
+			t.Errorf("expected error for %+v", f)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// errMultiPage is returned for sorted or paginated queries of several
+// Alertmanagers, whose results cannot be merged into a consistent page.
+var errMultiPage = errors.New("--sort, --limit and --offset are not supported when querying several Alertmanagers")
+
+// multiInstanceFields are the default fields of the simple output when
+// several Alertmanagers are queried.
+const (
+	multiInstanceAlertFields   = "alertname,startsAt,summary,instances"
+	multiInstanceSilenceFields = "id,matchers,endsAt,createdBy,comment,instances"
+)
+
+// multiInstance holds the Alertmanagers queried together.
+type multiInstance struct {
+	urls []string
+	// stderr receives the errors of the Alertmanagers that failed while
+	// others answered.
+	stderr io.Writer
+}
+
+// query calls f with the index of each Alertmanager concurrently. It only
+// fails if f fails for all of them, with the error of the first one.
+func (m *multiInstance) query(f func(i int) error) error {
+	errs := make([]error, len(m.urls))
+	var wg sync.WaitGroup
+	for i := range m.urls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f(i)
+		}(i)
+	}
+	wg.Wait()
+
+	var failed int
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(errs) {
+		return errs[0]
+	}
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(m.stderr, "Querying %s failed: %v\n", m.urls[i], err)
+		}
+	}
+	return nil
+}
+
+// multiAlertAPI is an AlertAPI listing the alerts of several Alertmanagers.
+// Alerts returned by several of them are merged by fingerprint. The other
+// methods use the first Alertmanager.
+type multiAlertAPI struct {
+	client.AlertAPI
+	multiInstance
+	apis []client.AlertAPI
+}
+
+// newMultiAlertAPI returns an AlertAPI for the Alertmanagers.
+func newMultiAlertAPI(urls []*url.URL) (*multiAlertAPI, error) {
+	m := &multiAlertAPI{multiInstance: multiInstance{stderr: os.Stderr}}
+	for _, u := range urls {
+		c, err := newAPIClient(u)
+		if err != nil {
+			return nil, err
+		}
+		m.urls = append(m.urls, u.String())
+		m.apis = append(m.apis, client.NewAlertAPI(c))
+	}
+	m.AlertAPI = m.apis[0]
+	return m, nil
+}
+
+func (m *multiAlertAPI) List(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool) ([]*client.ExtendedAlert, error) {
+	alerts, _, err := m.ListPage(ctx, filter, receiver, silenced, inhibited, active, unprocessed, client.ListOptions{})
+	return alerts, err
+}
+
+func (m *multiAlertAPI) ListPage(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts client.ListOptions) ([]*client.ExtendedAlert, int, error) {
+	if opts.Sort != "" || opts.Limit > 0 || opts.Offset > 0 {
+		return nil, 0, errMultiPage
+	}
+	results := make([][]*client.ExtendedAlert, len(m.apis))
+	err := m.query(func(i int) error {
+		var err error
+		results[i], err = m.apis[i].List(ctx, filter, receiver, silenced, inhibited, active, unprocessed)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	alerts := mergeAlerts(m.urls, results)
+	return alerts, len(alerts), nil
+}
+
+func (m *multiAlertAPI) Stream(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts client.ListOptions, f func(*client.ExtendedAlert) error) (int, error) {
+	// The alerts of all Alertmanagers are needed to merge them.
+	alerts, total, err := m.ListPage(ctx, filter, receiver, silenced, inhibited, active, unprocessed, opts)
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range alerts {
+		if err := f(a); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// mergeAlerts merges the alerts returned by the Alertmanagers at the URLs.
+// Of the alerts with the same fingerprint, the first one returned is kept
+// and all of them are recorded in its instances.
+func mergeAlerts(urls []string, results [][]*client.ExtendedAlert) []*client.ExtendedAlert {
+	var (
+		merged []*client.ExtendedAlert
+		byFp   = map[string]*client.ExtendedAlert{}
+	)
+	for i, alerts := range results {
+		for _, a := range alerts {
+			if prev, ok := byFp[a.Fingerprint]; ok {
+				prev.Instances = append(prev.Instances, urls[i])
+				continue
+			}
+			a.Instances = []string{urls[i]}
+			byFp[a.Fingerprint] = a
+			merged = append(merged, a)
+		}
+	}
+	return merged
+}
+
+// multiSilenceAPI is a SilenceAPI listing the silences of several
+// Alertmanagers. Silences returned by several of them are merged by ID. The
+// other methods use the first Alertmanager.
+type multiSilenceAPI struct {
+	client.SilenceAPI
+	multiInstance
+	apis []client.SilenceAPI
+}
+
+// newMultiSilenceAPI returns a SilenceAPI for the Alertmanagers.
+func newMultiSilenceAPI(urls []*url.URL) (*multiSilenceAPI, error) {
+	m := &multiSilenceAPI{multiInstance: multiInstance{stderr: os.Stderr}}
+	for _, u := range urls {
+		c, err := newAPIClient(u)
+		if err != nil {
+			return nil, err
+		}
+		m.urls = append(m.urls, u.String())
+		m.apis = append(m.apis, client.NewSilenceAPI(c))
+	}
+	m.SilenceAPI = m.apis[0]
+	return m, nil
+}
+
+func (m *multiSilenceAPI) List(ctx context.Context, filter string) ([]*types.Silence, error) {
+	sils, _, err := m.ListPage(ctx, filter, nil, client.ListOptions{})
+	return sils, err
+}
+
+func (m *multiSilenceAPI) ListPage(ctx context.Context, filter string, states []types.SilenceState, opts client.ListOptions) ([]*types.Silence, int, error) {
+	if opts.Sort != "" || opts.Limit > 0 || opts.Offset > 0 {
+		return nil, 0, errMultiPage
+	}
+	results := make([][]*types.Silence, len(m.apis))
+	err := m.query(func(i int) error {
+		var err error
+		results[i], _, err = m.apis[i].ListPage(ctx, filter, states, opts)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sils := mergeSilences(m.urls, results)
+	return sils, len(sils), nil
+}
+
+// mergeSilences merges the silences returned by the Alertmanagers at the
+// URLs. Of the silences with the same ID, the most recently updated one is
+// kept and all of them are recorded in its instances.
+func mergeSilences(urls []string, results [][]*types.Silence) []*types.Silence {
+	var (
+		merged []*types.Silence
+		byID   = map[string]int{}
+	)
+	for i, sils := range results {
+		for _, s := range sils {
+			j, ok := byID[s.ID]
+			if !ok {
+				s.Instances = []string{urls[i]}
+				byID[s.ID] = len(merged)
+				merged = append(merged, s)
+				continue
+			}
+			prev := merged[j]
+			if s.UpdatedAt.After(prev.UpdatedAt) {
+				s.Instances = prev.Instances
+				merged[j], prev = s, s
+			}
+			prev.Instances = append(prev.Instances, urls[i])
+		}
+	}
+	return merged
+}
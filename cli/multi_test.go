@@ -0,0 +1,148 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// fakeAlertAPI lists fixed alerts or fails.
+type fakeAlertAPI struct {
+	client.AlertAPI
+	alerts []*client.ExtendedAlert
+	err    error
+}
+
+func (f *fakeAlertAPI) List(context.Context, string, string, bool, bool, bool, bool) ([]*client.ExtendedAlert, error) {
+	return f.alerts, f.err
+}
+
+// fakeSilenceAPI lists fixed silences or fails.
+type fakeSilenceAPI struct {
+	client.SilenceAPI
+	silences []*types.Silence
+	err      error
+}
+
+func (f *fakeSilenceAPI) ListPage(context.Context, string, []types.SilenceState, client.ListOptions) ([]*types.Silence, int, error) {
+	return f.silences, len(f.silences), f.err
+}
+
+func TestMultiAlertAPI(t *testing.T) {
+	var stderr bytes.Buffer
+	m := &multiAlertAPI{
+		multiInstance: multiInstance{urls: []string{"http://am1", "http://am2", "http://am3"}, stderr: &stderr},
+		apis: []client.AlertAPI{
+			&fakeAlertAPI{alerts: []*client.ExtendedAlert{{Fingerprint: "a"}, {Fingerprint: "b"}}},
+			&fakeAlertAPI{alerts: []*client.ExtendedAlert{{Fingerprint: "b"}, {Fingerprint: "c"}}},
+			&fakeAlertAPI{err: errors.New("connection refused")},
+		},
+	}
+
+	alerts, total, err := m.ListPage(context.Background(), "", "", false, false, true, false, client.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("Expected total 3, got %d", total)
+	}
+	instances := map[string][]string{}
+	for _, a := range alerts {
+		instances[a.Fingerprint] = a.Instances
+	}
+	expected := map[string][]string{
+		"a": {"http://am1"},
+		"b": {"http://am1", "http://am2"},
+		"c": {"http://am2"},
+	}
+	if !reflect.DeepEqual(instances, expected) {
+		t.Errorf("Expected instances %v, got %v", expected, instances)
+	}
+	if !strings.Contains(stderr.String(), "Querying http://am3 failed: connection refused") {
+		t.Errorf("Expected failed Alertmanager to be reported, got %q", stderr.String())
+	}
+
+	if _, _, err := m.ListPage(context.Background(), "", "", false, false, true, false, client.ListOptions{Limit: 10}); err != errMultiPage {
+		t.Errorf("Expected error %q for pagination, got %v", errMultiPage, err)
+	}
+
+	m.apis = []client.AlertAPI{
+		&fakeAlertAPI{err: errors.New("connection refused")},
+		&fakeAlertAPI{err: errors.New("timeout")},
+	}
+	m.urls = m.urls[:2]
+	if _, _, err := m.ListPage(context.Background(), "", "", false, false, true, false, client.ListOptions{}); err == nil || err.Error() != "connection refused" {
+		t.Errorf("Expected error of the first Alertmanager, got %v", err)
+	}
+}
+
+func TestMultiSilenceAPI(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &multiSilenceAPI{
+		multiInstance: multiInstance{urls: []string{"http://am1", "http://am2"}, stderr: &bytes.Buffer{}},
+		apis: []client.SilenceAPI{
+			&fakeSilenceAPI{silences: []*types.Silence{
+				{ID: "s1", Comment: "old", UpdatedAt: now},
+				{ID: "s2", UpdatedAt: now},
+			}},
+			&fakeSilenceAPI{silences: []*types.Silence{
+				{ID: "s1", Comment: "new", UpdatedAt: now.Add(time.Minute)},
+			}},
+		},
+	}
+
+	sils, err := m.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sils) != 2 {
+		t.Fatalf("Expected 2 silences, got %d", len(sils))
+	}
+	if sils[0].ID != "s1" || sils[0].Comment != "new" {
+		t.Errorf("Expected most recently updated version of s1, got %+v", sils[0])
+	}
+	if expected := []string{"http://am1", "http://am2"}; !reflect.DeepEqual(sils[0].Instances, expected) {
+		t.Errorf("Expected instances %v of s1, got %v", expected, sils[0].Instances)
+	}
+	if expected := []string{"http://am1"}; !reflect.DeepEqual(sils[1].Instances, expected) {
+		t.Errorf("Expected instances %v of s2, got %v", expected, sils[1].Instances)
+	}
+}
+
+func TestURLListValue(t *testing.T) {
+	defer func() { alertmanagerURL, alertmanagerURLs = nil, nil }()
+
+	v := urlListValue{}
+	if err := v.Set("http://am1:9093, http://am2:9093"); err != nil {
+		t.Fatal(err)
+	}
+	if len(alertmanagerURLs) != 2 || alertmanagerURL.Host != "am1:9093" {
+		t.Errorf("Unexpected URLs %v", alertmanagerURLs)
+	}
+	if s := v.String(); s != "http://am1:9093,http://am2:9093" {
+		t.Errorf("Unexpected value %q", s)
+	}
+	if err := v.Set(" , "); err == nil {
+		t.Errorf("Expected error for empty list")
+	}
+}
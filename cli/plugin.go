@@ -0,0 +1,176 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// pluginPrefix is the prefix of executables on the PATH that are run as
+// amtool subcommands.
+const pluginPrefix = "amtool-"
+
+// A CommandConfigurer adds commands to the amtool application.
+type CommandConfigurer func(app *kingpin.Application)
+
+var commandConfigurers []CommandConfigurer
+
+// RegisterCommand registers a function adding commands to amtool. It must be
+// called before Execute, usually from the init function of a package linked
+// into a custom amtool build.
+func RegisterCommand(f CommandConfigurer) {
+	commandConfigurers = append(commandConfigurers, f)
+}
+
+// AlertmanagerURL returns the URL of the Alertmanager amtool talks to. It is
+// nil if none was configured.
+func AlertmanagerURL() *url.URL {
+	return alertmanagerURL
+}
+
+// RequireAlertmanagerURL is a kingpin action failing if no Alertmanager URL
+// was configured.
+func RequireAlertmanagerURL(pc *kingpin.ParseContext) error {
+	return requireAlertManagerURL(pc)
+}
+
+// Output returns the selected output format.
+func Output() string {
+	return output
+}
+
+// Verbose returns whether verbose output is enabled.
+func Verbose() bool {
+	return verbose
+}
+
+// findPlugins returns the executables with the plugin prefix in the given
+// directories by plugin name. Executables in earlier directories take
+// precedence.
+func findPlugins(dirs []string) map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), pluginPrefix)
+			if name == f.Name() || name == "" || f.IsDir() || f.Mode()&0111 == 0 {
+				continue
+			}
+			if _, ok := plugins[name]; !ok {
+				plugins[name] = filepath.Join(dir, f.Name())
+			}
+		}
+	}
+	return plugins
+}
+
+// configurePluginCmds adds a command for each plugin not shadowed by a
+// built-in command and returns the added plugins.
+func configurePluginCmds(app *kingpin.Application, plugins map[string]string) map[string]string {
+	added := map[string]string{}
+	for name, path := range plugins {
+		if app.GetCommand(name) != nil {
+			continue
+		}
+		var (
+			cmd  = app.Command(name, fmt.Sprintf("Run the %s plugin", path))
+			args = cmd.Arg("args", "Arguments passed to the plugin").Strings()
+			exe  = path
+		)
+		cmd.Action(func(*kingpin.ParseContext) error {
+			return runPlugin(exe, *args)
+		})
+		added[name] = path
+	}
+	return added
+}
+
+// pluginArgs returns the arguments with a "--" inserted after the name of
+// the plugin command so that its flags are passed to the plugin unparsed.
+func pluginArgs(app *kingpin.Application, args []string, plugins map[string]string) []string {
+	valueFlags := map[string]bool{}
+	for _, f := range app.Model().Flags {
+		if f.IsBoolFlag() {
+			continue
+		}
+		valueFlags["--"+f.Name] = true
+		if f.Short != 0 {
+			valueFlags["-"+string(f.Short)] = true
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			// Skip the value of flags given as separate argument.
+			if valueFlags[arg] {
+				i++
+			}
+			continue
+		}
+		if _, ok := plugins[arg]; !ok {
+			break
+		}
+		res := make([]string, 0, len(args)+1)
+		res = append(res, args[:i+1]...)
+		res = append(res, "--")
+		return append(res, args[i+1:]...)
+	}
+	return args
+}
+
+// runPlugin runs the plugin executable. The global configuration is passed
+// on in the environment variables amtool reads its flags from.
+func runPlugin(path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"AMTOOL_OUTPUT="+output,
+		"AMTOOL_VERBOSE="+strconv.FormatBool(verbose),
+		"AMTOOL_QUIET="+strconv.FormatBool(quiet),
+	)
+	if alertmanagerURL != nil {
+		cmd.Env = append(cmd.Env, "AMTOOL_ALERTMANAGER_URL="+urlListValue{}.String())
+	}
+	cmd.Env = append(cmd.Env, httpConfig.environ()...)
+
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			os.Exit(status.ExitStatus())
+		}
+	}
+	return err
+}
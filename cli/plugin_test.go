@@ -0,0 +1,98 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestFindPlugins(t *testing.T) {
+	dir1, err := ioutil.TempDir("", "amtool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "amtool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir2)
+
+	for path, mode := range map[string]os.FileMode{
+		filepath.Join(dir1, "amtool-oncall"):   0755,
+		filepath.Join(dir1, "amtool-notexec"):  0644,
+		filepath.Join(dir1, "other"):           0755,
+		filepath.Join(dir2, "amtool-oncall"):   0755,
+		filepath.Join(dir2, "amtool-escalate"): 0755,
+	} {
+		if err := ioutil.WriteFile(path, nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"oncall":   filepath.Join(dir1, "amtool-oncall"),
+		"escalate": filepath.Join(dir2, "amtool-escalate"),
+	}
+	if plugins := findPlugins([]string{dir1, "", dir2, "/nonexistent"}); !reflect.DeepEqual(expected, plugins) {
+		t.Errorf("expected plugins %v, got %v", expected, plugins)
+	}
+}
+
+func TestPluginArgs(t *testing.T) {
+	app := kingpin.New("amtool", "")
+	app.Flag("verbose", "").Short('v').Bool()
+	app.Flag("output", "").Short('o').String()
+	app.Command("silence", "")
+
+	plugins := configurePluginCmds(app, map[string]string{
+		"oncall":  "/bin/amtool-oncall",
+		"silence": "/bin/amtool-silence",
+	})
+	if _, ok := plugins["silence"]; ok {
+		t.Errorf("plugin shadowing a built-in command was added")
+	}
+
+	for _, tc := range []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			args:     []string{"-o", "json", "-v", "oncall", "--team", "x"},
+			expected: []string{"-o", "json", "-v", "oncall", "--", "--team", "x"},
+		},
+		{
+			args:     []string{"--output=json", "oncall"},
+			expected: []string{"--output=json", "oncall", "--"},
+		},
+		{
+			args:     []string{"silence", "oncall"},
+			expected: []string{"silence", "oncall"},
+		},
+		{
+			args:     []string{"-o", "oncall", "silence"},
+			expected: []string{"-o", "oncall", "silence"},
+		},
+	} {
+		if args := pluginArgs(app, tc.args, plugins); !reflect.DeepEqual(tc.expected, args) {
+			t.Errorf("expected arguments %v, got %v", tc.expected, args)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/cli/format"
+	"github.com/prometheus/alertmanager/client"
+)
+
+type receiverShowCmd struct {
+	receivers []string
+}
+
+type receiverTestCmd struct {
+	receiver    string
+	labels      []string
+	annotations []string
+	resolve     bool
+}
+
+const receiverTestHelp = `Send a test notification through a receiver.
+
+The Alertmanager sends a test alert through each integration of the receiver
+with the templates and HTTP configuration of its running configuration, so that
+new receivers can be validated when they are deployed. The notifications are
+not retried and the command fails if any of them failed.
+
+amtool receiver test team-X-pager
+
+	Sends a firing alert named AlertmanagerReceiverTest to the receiver
+	team-X-pager.
+
+amtool receiver test team-X-pager severity=critical --annotation=runbook='https://example.com' --resolve
+
+	Adds the labels and annotations to the test alert and resolves it after
+	the firing notification, e.g. to close the incidents opened by the test.
+`
+
+const receiverShowHelp = `Show the receivers and the state of their integrations.
+
+For each integration of the receivers of the running configuration, the time
+of its last notification is shown together with the last error and since when
+its notifications fail, if they do.
+
+amtool receivers show
+
+	Lists the integrations of all receivers, e.g. to find out that the slack
+	integration of slack-critical has failed for 2 hours with status code 401.
+
+amtool receivers show slack-critical
+
+	Only shows the integrations of the receiver slack-critical.
+`
+
+func configureReceiverCmd(app *kingpin.Application) {
+	var (
+		c           = &receiverTestCmd{}
+		s           = &receiverShowCmd{}
+		receiverCmd = app.Command("receiver", "Operate on receivers").Alias("receivers").PreAction(requireAlertManagerURL)
+		testCmd     = receiverCmd.Command("test", receiverTestHelp)
+		showCmd     = receiverCmd.Command("show", receiverShowHelp)
+	)
+	showCmd.Arg("receiver", "Names of the receivers to show, all if none are given").StringsVar(&s.receivers)
+	showCmd.Action(s.show)
+	testCmd.Arg("receiver", "Name of the receiver").Required().StringVar(&c.receiver)
+	testCmd.Arg("labels", "Labels added to the test alert as name=value pairs").StringsVar(&c.labels)
+	testCmd.Flag("annotation", "Annotation added to the test alert as name=value pair, can be repeated").StringsVar(&c.annotations)
+	testCmd.Flag("resolve", "Send a resolved notification after the firing one").BoolVar(&c.resolve)
+	testCmd.Action(c.test)
+}
+
+func (c *receiverTestCmd) test(ctx *kingpin.ParseContext) error {
+	labels, err := parseLabelPairs(c.labels)
+	if err != nil {
+		return err
+	}
+	annotations, err := parseLabelPairs(c.annotations)
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	results, err := client.NewReceiverAPI(apiClient).Test(context.Background(), client.ReceiverTestRequest{
+		Receiver:    c.receiver,
+		Labels:      labels,
+		Annotations: annotations,
+		Resolve:     c.resolve,
+	})
+	if err != nil {
+		return err
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	if err := formatter.FormatReceiverTestResults(results); err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("receiver %s has no integrations", c.receiver)
+	}
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notifications failed", failed, len(results))
+	}
+	return nil
+}
+
+func (c *receiverShowCmd) show(ctx *kingpin.ParseContext) error {
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	receivers, err := client.NewReceiverAPI(apiClient).Status(context.Background())
+	if err != nil {
+		return err
+	}
+	receivers, err = selectReceivers(receivers, c.receivers)
+	if err != nil {
+		return err
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	return formatter.FormatReceivers(receivers)
+}
+
+// selectReceivers returns the receivers with the names in the order of the
+// names, or all receivers if no names are given.
+func selectReceivers(receivers []*client.ReceiverStatus, names []string) ([]*client.ReceiverStatus, error) {
+	if len(names) == 0 {
+		return receivers, nil
+	}
+	byName := make(map[string]*client.ReceiverStatus, len(receivers))
+	for _, r := range receivers {
+		byName[r.Name] = r
+	}
+	res := make([]*client.ReceiverStatus, 0, len(names))
+	for _, n := range names {
+		r, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown receiver %q", n)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/client"
+)
+
+func TestSelectReceivers(t *testing.T) {
+	receivers := []*client.ReceiverStatus{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	res, err := selectReceivers(receivers, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 3 {
+		t.Errorf("expected all receivers, got %d", len(res))
+	}
+
+	res, err = selectReceivers(receivers, []string{"c", "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 2 || res[0].Name != "c" || res[1].Name != "a" {
+		t.Errorf("expected receivers c and a, got %v", res)
+	}
+
+	if _, err := selectReceivers(receivers, []string{"d"}); err == nil {
+		t.Error("expected error for unknown receiver")
+	}
+}
@@ -14,8 +14,11 @@
 package cli
 
 import (
+	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/prometheus/common/version"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -26,22 +29,36 @@ import (
 
 var (
 	verbose         bool
+	quiet           bool
 	alertmanagerURL *url.URL
 	output          string
 
+	// alertmanagerURLs are all Alertmanagers given by the alertmanager.url
+	// flag. Queries of alerts and silences are sent to all of them, other
+	// commands talk to the first one, alertmanagerURL.
+	alertmanagerURLs []*url.URL
+
+	// configResolver resolves the flags of the config files.
+	configResolver *config.Resolver
+
 	configFiles = []string{os.ExpandEnv("$HOME/.config/amtool/config.yml"), "/etc/amtool/config.yml"}
 	legacyFlags = map[string]string{"comment_required": "require-comment"}
+
+	// offlineFlags are the flags with which commands do not talk to an
+	// Alertmanager.
+	offlineFlags = map[*kingpin.FlagClause]bool{}
 )
 
 func requireAlertManagerURL(pc *kingpin.ParseContext) error {
-	// Return without error if any help flag is set.
+	// Return without error if any help flag or flag making the command work
+	// offline is set.
 	for _, elem := range pc.Elements {
 		f, ok := elem.Clause.(*kingpin.FlagClause)
 		if !ok {
 			continue
 		}
 		name := f.Model().Name
-		if name == "help" || name == "help-long" || name == "help-man" {
+		if name == "help" || name == "help-long" || name == "help-man" || offlineFlags[f] {
 			return nil
 		}
 	}
@@ -57,15 +74,19 @@ func Execute() {
 	)
 
 	format.InitFormatFlags(app)
+	configureHTTPFlags(app)
 
 	app.Flag("verbose", "Verbose running information").Short('v').BoolVar(&verbose)
-	app.Flag("alertmanager.url", "Alertmanager to talk to").URLVar(&alertmanagerURL)
-	app.Flag("output", "Output formatter (simple, extended, json)").Short('o').Default("simple").EnumVar(&output, "simple", "extended", "json")
+	app.Flag("quiet", "Only print the IDs of silences and the fingerprints of alerts").Short('q').BoolVar(&quiet)
+	app.Flag("alertmanager.url", "Alertmanager to talk to, or a comma-separated list of Alertmanagers to query alerts and silences of").SetValue(urlListValue{})
+	app.Flag("output", "Output formatter (simple, extended, json, json-lines, csv, yaml)").Short('o').Default("simple").EnumVar(&output, "simple", "extended", "json", "json-lines", "csv", "yaml")
+	app.Flag(config.ContextFlag, "Context of the config file to use instead of its current context").String()
 	app.Version(version.Print("amtool"))
 	app.GetFlag("help").Short('h')
 	app.UsageTemplate(kingpin.CompactUsageTemplate)
 
-	resolver, err := config.NewResolver(configFiles, legacyFlags)
+	var err error
+	configResolver, err = config.NewResolver(configFiles, legacyFlags)
 	if err != nil {
 		kingpin.Fatalf("could not load config file: %v\n", err)
 	}
@@ -74,16 +95,57 @@ func Execute() {
 	configureSilenceCmd(app)
 	configureCheckConfigCmd(app)
 	configureConfigCmd(app)
+	configureClusterCmd(app)
+	configureStatusCmd(app)
+	configureReceiverCmd(app)
+	configureTemplateCmd(app)
+	configureCompletionCmd(app)
+	for _, f := range commandConfigurers {
+		f(app)
+	}
+	plugins := configurePluginCmds(app, findPlugins(filepath.SplitList(os.Getenv("PATH"))))
+	args := pluginArgs(app, os.Args[1:], plugins)
 
-	err = resolver.Bind(app, os.Args[1:])
+	err = configResolver.Bind(app, args)
 	if err != nil {
 		kingpin.Fatalf("%v\n", err)
 	}
 
-	_, err = app.Parse(os.Args[1:])
+	_, err = app.Parse(args)
 	if err != nil {
-		kingpin.Fatalf("%v\n", err)
+		exitWithError(err)
+	}
+}
+
+// urlListValue is the kingpin value of the alertmanager.url flag.
+type urlListValue struct{}
+
+func (urlListValue) Set(v string) error {
+	var urls []*url.URL
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q: %s", s, err)
+		}
+		urls = append(urls, u)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no URL given")
+	}
+	alertmanagerURLs = urls
+	alertmanagerURL = urls[0]
+	return nil
+}
+
+func (urlListValue) String() string {
+	s := make([]string, 0, len(alertmanagerURLs))
+	for _, u := range alertmanagerURLs {
+		s = append(s, u.String())
 	}
+	return strings.Join(s, ",")
 }
 
 const (
@@ -98,7 +160,8 @@ All flags can be given in the config file, but the following are the suited for
 static configuration:
 
 	alertmanager.url
-		Set a default alertmanager url for each request
+		Set a default alertmanager url for each request. A comma-separated
+		list of urls queries the alerts and silences of all of them
 
 	author
 		Set a default author value for new silences. If this argument is not
@@ -108,9 +171,41 @@ static configuration:
 		Bool, whether to require a comment on silence creation. Defaults to true
 
 	output
-		Set a default output type. Options are (simple, extended, json)
+		Set a default output type. Options are (simple, extended, json, json-lines, csv, yaml)
+
+	tls.cert, tls.key, tls.ca
+		Client certificate, key and CA certificate for TLS connections
+
+	http.basic-auth, http.bearer-token, http.bearer-token-file
+		Credentials for Alertmanagers behind an authenticating proxy
 
 	date.format
 		Sets the output format for dates. Defaults to "2006-01-02 15:04:05 MST"
+
+Contexts:
+The config file can define named contexts with the flags of different
+Alertmanagers, which override the other flags of the file:
+
+	current-context: prod
+	contexts:
+	  prod:
+	    alertmanager.url: https://alertmanager.prod.example.com
+	  staging:
+	    alertmanager.url: https://alertmanager.staging.example.com
+
+The --context flag or the AMTOOL_CONTEXT environment variable selects another
+context than the current one, which is set with 'amtool config use-context'.
+
+Plugins:
+Executables named amtool-<name> on the PATH are available as the <name>
+subcommand. The global flags are passed to them in the AMTOOL_ALERTMANAGER_URL,
+AMTOOL_OUTPUT, AMTOOL_VERBOSE and AMTOOL_QUIET environment variables.
+
+Exit Codes:
+	0	The command succeeded
+	1	Invalid flags, arguments or input, or a request rejected by the
+		Alertmanager
+	2	The Alertmanager is unreachable or failed to handle the request
+	3	Nothing matched the arguments of the command
 `
 )
@@ -14,16 +14,20 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/user"
+	"strings"
 	"time"
 
-	"github.com/prometheus/client_golang/api"
 	"github.com/prometheus/common/model"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/client"
 	"github.com/prometheus/alertmanager/types"
 )
@@ -41,9 +45,17 @@ type silenceAddCmd struct {
 	requireComment bool
 	duration       string
 	start          string
+	startAt        string
 	end            string
 	comment        string
+	metadata       map[string]string
 	matchers       []string
+	renew          string
+	renewUntil     string
+	dryRun         bool
+	confirm        bool
+	force          bool
+	file           string
 }
 
 const silenceAddHelp = `Add a new alertmanager silence
@@ -68,6 +80,65 @@ const silenceAddHelp = `Add a new alertmanager silence
 	As well as direct equality, regex matching is also supported. The '=~' syntax
 	(similar to Prometheus) is used to represent a regex match. Regex matching
 	can be used in combination with a direct match.
+
+  amtool silence add team=foo 'severity!=critical'
+
+	Negative matchers ('!=' and '!~') exclude alerts from the silence. This
+	silences all alerts of team foo except the critical ones. A silence needs
+	at least one matcher that is not negative.
+
+  amtool silence add --start-at=2h --duration=4h alertname=foo
+
+	Schedules a silence that starts in two hours and lasts four hours, e.g.
+	for a maintenance window. The silence is pending until it starts.
+
+  amtool silence add --dry-run alertname=foo node=bar
+
+	Prints the silence that would be added without adding it. With --confirm
+	the silence is printed and only added after confirming the prompt.
+
+  amtool silence add --duration=2h --renew=2h --renew-until=3d alertname=foo
+
+	Adds a silence that is extended by another two hours whenever less than
+	an hour is left while it still mutes firing alerts, but not beyond three
+	days from now. Once the alerts resolve the silence expires as usual.
+
+  amtool silence add alertname=foo 'instance={db1,db2}' 'dc={eu,us}'
+
+	Values listing alternatives in braces add one silence for each combination
+	of the alternatives, four in this example. The silences are added at once:
+	if one of them is invalid, none is added.
+
+  amtool silence add --force cluster=eu
+
+	The silence limits of the Alertmanager configuration may require matchers
+	for some labels, limit the duration of silences and the number of firing
+	alerts a silence may match. With --force the silence is added even if it
+	matches more firing alerts than allowed.
+
+  amtool silence add --meta=ticket=OPS-123 --meta=team=storage alertname=foo
+
+	Sets metadata fields of the silence, e.g. the ticket tracking the work
+	being silenced. The Alertmanager configuration may define the fields a
+	silence has and which of them are required.
+
+  amtool silence add --file=maintenance.yml
+
+	Adds the silences defined in a YAML or JSON file, e.g. maintenance windows
+	kept in git. The file lists silences with their matchers, start, end or
+	duration, comment, author and metadata, which default to the flags:
+
+	- external_id: db-upgrade-2018-06
+	  matchers: ['cluster=db', 'severity!=critical']
+	  start: 2018-06-02T22:00:00Z
+	  end: 2018-06-03T02:00:00Z
+	  comment: Upgrade of the database cluster
+	  metadata:
+	    ticket: OPS-123
+
+	Silences with an external_id are added once: applying the file again
+	updates the silence with the same external_id if it changed, unless it
+	expired.
 `
 
 func configureSilenceAddCmd(cc *kingpin.CmdClause) {
@@ -79,23 +150,50 @@ func configureSilenceAddCmd(cc *kingpin.CmdClause) {
 	addCmd.Flag("require-comment", "Require comment to be set").Hidden().Default("true").BoolVar(&c.requireComment)
 	addCmd.Flag("duration", "Duration of silence").Short('d').Default("1h").StringVar(&c.duration)
 	addCmd.Flag("start", "Set when the silence should start. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&c.start)
+	addCmd.Flag("start-at", "Schedule the silence to start at a time in RFC3339 format or after a duration from now").StringVar(&c.startAt)
 	addCmd.Flag("end", "Set when the silence should end (overwrites duration). RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&c.end)
 	addCmd.Flag("comment", "A comment to help describe the silence").Short('c').StringVar(&c.comment)
-	addCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
+	addCmd.Flag("meta", "Metadata field of the silence as name=value, can be repeated").StringMapVar(&c.metadata)
+	addCmd.Flag("renew", "Extend the silence by this duration while it mutes firing alerts").StringVar(&c.renew)
+	addCmd.Flag("renew-until", "Do not extend the silence beyond a time in RFC3339 format or a duration from now").StringVar(&c.renewUntil)
+	addCmd.Flag("dry-run", "Print the silence instead of adding it").BoolVar(&c.dryRun)
+	addCmd.Flag("confirm", "Ask for confirmation before adding the silence").BoolVar(&c.confirm)
+	addCmd.Flag("force", "Add the silence even if it matches more firing alerts than the configured limit").BoolVar(&c.force)
+	addCmd.Flag("file", "Add the silences defined in a YAML or JSON file").ExistingFileVar(&c.file)
+	addCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&c.matchers)
 	addCmd.Action(c.add)
 
 }
 
 func (c *silenceAddCmd) add(ctx *kingpin.ParseContext) error {
-	var err error
+	if c.file != "" {
+		return c.addFromFile()
+	}
 
-	matchers, err := parseMatchers(c.matchers)
+	matcherGroups, err := silenceMatchers(c.matchers)
 	if err != nil {
 		return err
 	}
+	silences := make([]types.Silence, 0, len(matcherGroups))
+	for _, ms := range matcherGroups {
+		silences = append(silences, types.Silence{Matchers: ms})
+	}
 
-	if len(matchers) < 1 {
-		return fmt.Errorf("no matchers specified")
+	now := time.Now().UTC()
+	startsAt := now
+	switch {
+	case c.start != "" && c.startAt != "":
+		return errors.New("only one of --start and --start-at may be given")
+	case c.start != "":
+		startsAt, err = time.Parse(time.RFC3339, c.start)
+		if err != nil {
+			return err
+		}
+	case c.startAt != "":
+		startsAt, err = parseStartAt(c.startAt, now)
+		if err != nil {
+			return err
+		}
 	}
 
 	var endsAt time.Time
@@ -112,47 +210,72 @@ func (c *silenceAddCmd) add(ctx *kingpin.ParseContext) error {
 		if d == 0 {
 			return fmt.Errorf("silence duration must be greater than 0")
 		}
-		endsAt = time.Now().UTC().Add(time.Duration(d))
+		endsAt = startsAt.Add(time.Duration(d))
 	}
 
 	if c.requireComment && c.comment == "" {
 		return errors.New("comment required by config")
 	}
 
-	var startsAt time.Time
-	if c.start != "" {
-		startsAt, err = time.Parse(time.RFC3339, c.start)
-		if err != nil {
-			return err
-		}
-
-	} else {
-		startsAt = time.Now().UTC()
-	}
-
 	if startsAt.After(endsAt) {
 		return errors.New("silence cannot start after it ends")
 	}
 
-	typeMatchers, err := TypeMatchers(matchers)
+	renewal, err := c.renewal(now, endsAt)
 	if err != nil {
 		return err
 	}
 
-	silence := types.Silence{
-		Matchers:  typeMatchers,
-		StartsAt:  startsAt,
-		EndsAt:    endsAt,
-		CreatedBy: c.author,
-		Comment:   c.comment,
+	for i := range silences {
+		silences[i].StartsAt = startsAt
+		silences[i].EndsAt = endsAt
+		silences[i].CreatedBy = c.author
+		silences[i].Comment = c.comment
+		silences[i].Metadata = c.metadata
+		silences[i].Renewal = renewal
 	}
 
-	apiClient, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+	if c.dryRun {
+		for i := range silences {
+			describeSilence(os.Stdout, &silences[i])
+		}
+		return nil
+	}
+	if c.confirm {
+		for i := range silences {
+			describeSilence(os.Stderr, &silences[i])
+		}
+		prompt := "Add this silence?"
+		if len(silences) > 1 {
+			prompt = fmt.Sprintf("Add these %d silences?", len(silences))
+		}
+		ok, err := confirm(os.Stdin, os.Stderr, prompt)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+
+	apiClient, err := NewAPIClient()
 	if err != nil {
 		return err
 	}
 	silenceAPI := client.NewSilenceAPI(apiClient)
-	silenceID, err := silenceAPI.Set(context.Background(), silence)
+	if len(silences) > 1 {
+		silenceIDs, err := silenceAPI.Create(context.Background(), silences, c.force)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(strings.Join(silenceIDs, "\n"))
+		return err
+	}
+	set := silenceAPI.Set
+	if c.force {
+		set = silenceAPI.SetForce
+	}
+	silenceID, err := set(context.Background(), silences[0])
 	if err != nil {
 		return err
 	}
@@ -160,3 +283,119 @@ func (c *silenceAddCmd) add(ctx *kingpin.ParseContext) error {
 	_, err = fmt.Println(silenceID)
 	return err
 }
+
+// expandMatchers expands the matcher arguments whose unquoted value lists
+// alternatives in braces, e.g. instance={db1,db2}, into one group of
+// matcher arguments per combination of the alternatives.
+func expandMatchers(args []string) [][]string {
+	groups := [][]string{{}}
+	for _, arg := range args {
+		alts := expandMatcher(arg)
+		expanded := make([][]string, 0, len(groups)*len(alts))
+		for _, g := range groups {
+			for _, alt := range alts {
+				expanded = append(expanded, append(g[:len(g):len(g)], alt))
+			}
+		}
+		groups = expanded
+	}
+	return groups
+}
+
+// expandMatcher returns the alternatives of the matcher argument, or only
+// the argument if its value lists no alternatives.
+func expandMatcher(arg string) []string {
+	i := strings.IndexAny(arg, "=!")
+	if i < 0 || strings.HasPrefix(arg, `"`) {
+		return []string{arg}
+	}
+	op := arg[i : i+1]
+	if len(arg) > i+1 && (arg[i+1] == '=' || arg[i+1] == '~') {
+		op = arg[i : i+2]
+	}
+	prefix, value := arg[:i]+op, arg[i+len(op):]
+	if len(value) < 2 || value[0] != '{' || value[len(value)-1] != '}' || !strings.Contains(value, ",") {
+		return []string{arg}
+	}
+	alts := strings.Split(value[1:len(value)-1], ",")
+	for j, alt := range alts {
+		alts[j] = prefix + alt
+	}
+	return alts
+}
+
+// renewal returns the renewal of the silence ending at endsAt given by the
+// --renew and --renew-until flags, or nil if neither is set.
+func (c *silenceAddCmd) renewal(now, endsAt time.Time) (*types.SilenceRenewal, error) {
+	if c.renew == "" && c.renewUntil == "" {
+		return nil, nil
+	}
+	if c.renew == "" || c.renewUntil == "" {
+		return nil, errors.New("--renew and --renew-until must be given together")
+	}
+	d, err := model.ParseDuration(c.renew)
+	if err != nil {
+		return nil, err
+	}
+	if d == 0 {
+		return nil, errors.New("renewal increment must be greater than 0")
+	}
+	until, err := parseTimeOrDuration(c.renewUntil, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid renewal limit %q, expected a time in RFC3339 format or a duration", c.renewUntil)
+	}
+	if endsAt.After(until) {
+		return nil, errors.New("silence cannot end after the time to renew it until")
+	}
+	return &types.SilenceRenewal{Increment: c.renew, Until: until}, nil
+}
+
+// parseStartAt parses the start of a scheduled silence given as a time in
+// RFC3339 format or as a duration after now.
+func parseStartAt(s string, now time.Time) (time.Time, error) {
+	t, err := parseTimeOrDuration(s, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start %q, expected a time in RFC3339 format or a duration", s)
+	}
+	return t, nil
+}
+
+// parseTimeOrDuration parses a time in RFC3339 format or a duration after now.
+func parseTimeOrDuration(s string, now time.Time) (time.Time, error) {
+	if d, err := model.ParseDuration(s); err == nil {
+		return now.Add(time.Duration(d)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// describeSilence prints a silence that is about to be added.
+func describeSilence(w io.Writer, s *types.Silence) {
+	if s.ExternalID != "" {
+		fmt.Fprintf(w, "External ID: %s\n", s.ExternalID)
+	}
+	fmt.Fprintf(w, "Matchers:   %s\n", s.Matchers)
+	fmt.Fprintf(w, "Starts at:  %s\n", s.StartsAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Ends at:    %s (%s)\n", s.EndsAt.Format(time.RFC3339), model.Duration(s.EndsAt.Sub(s.StartsAt).Round(time.Second)))
+	if s.Renewal != nil {
+		fmt.Fprintf(w, "Renews by:  %s until %s\n", s.Renewal.Increment, s.Renewal.Until.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Created by: %s\n", s.CreatedBy)
+	fmt.Fprintf(w, "Comment:    %s\n", s.Comment)
+	if len(s.Metadata) > 0 {
+		fmt.Fprintf(w, "Metadata:   %s\n", format.FormatMetadata(s.Metadata))
+	}
+}
+
+// confirm asks the question and returns whether it was answered with yes.
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// silenceSpec is the definition of a silence in a file read by
+// `amtool silence add --file`.
+type silenceSpec struct {
+	// ExternalID identifies the silence across runs, so that applying the
+	// file again updates the silence instead of adding another one.
+	ExternalID string   `yaml:"external_id"`
+	Matchers   []string `yaml:"matchers"`
+	// Start and End are times in RFC3339 format or durations from now.
+	Start    string            `yaml:"start"`
+	End      string            `yaml:"end"`
+	Duration string            `yaml:"duration"`
+	Comment  string            `yaml:"comment"`
+	Author   string            `yaml:"author"`
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// fileSilence is a silence defined in a file.
+type fileSilence struct {
+	silence types.Silence
+	// fixedStart and fixedEnd are set if the silence starts or ends at a
+	// time rather than after a duration from now.
+	fixedStart, fixedEnd bool
+	// applied is set if the silence with the external ID does not need
+	// to change.
+	applied bool
+}
+
+// readSilenceFile reads the list of silence specs from the YAML or JSON
+// input and returns their silences. The flags of the command provide the
+// defaults of the duration, comment, author and metadata.
+func (c *silenceAddCmd) readSilenceFile(r io.Reader, now time.Time) ([]*fileSilence, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var specs []silenceSpec
+	if err := yaml.UnmarshalStrict(b, &specs); err != nil {
+		return nil, fmt.Errorf("invalid silence file: %s", err)
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("no silences in silence file")
+	}
+
+	externalIDs := map[string]bool{}
+	res := make([]*fileSilence, 0, len(specs))
+	for i, spec := range specs {
+		fs, err := c.fileSilence(spec, now)
+		if err != nil {
+			return nil, fmt.Errorf("silence %d: %s", i+1, err)
+		}
+		if spec.ExternalID != "" {
+			if externalIDs[spec.ExternalID] {
+				return nil, fmt.Errorf("silence %d: duplicate external_id %q", i+1, spec.ExternalID)
+			}
+			externalIDs[spec.ExternalID] = true
+		}
+		res = append(res, fs)
+	}
+	return res, nil
+}
+
+func (c *silenceAddCmd) fileSilence(spec silenceSpec, now time.Time) (*fileSilence, error) {
+	matchers, err := parseMatchers(spec.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	if len(matchers) < 1 {
+		return nil, errors.New("no matchers specified")
+	}
+	typeMatchers, err := TypeMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := &fileSilence{}
+	startsAt := now
+	if spec.Start != "" {
+		if startsAt, err = parseStartAt(spec.Start, now); err != nil {
+			return nil, err
+		}
+		_, err := model.ParseDuration(spec.Start)
+		fs.fixedStart = err != nil
+	}
+
+	var endsAt time.Time
+	switch {
+	case spec.End != "" && spec.Duration != "":
+		return nil, errors.New("only one of end and duration may be given")
+	case spec.End != "":
+		if endsAt, err = parseTimeOrDuration(spec.End, now); err != nil {
+			return nil, fmt.Errorf("invalid end %q, expected a time in RFC3339 format or a duration", spec.End)
+		}
+		_, err := model.ParseDuration(spec.End)
+		fs.fixedEnd = err != nil
+	default:
+		duration := spec.Duration
+		if duration == "" {
+			duration = c.duration
+		}
+		d, err := model.ParseDuration(duration)
+		if err != nil {
+			return nil, err
+		}
+		if d == 0 {
+			return nil, errors.New("silence duration must be greater than 0")
+		}
+		endsAt = startsAt.Add(time.Duration(d))
+		fs.fixedEnd = fs.fixedStart
+	}
+	if startsAt.After(endsAt) {
+		return nil, errors.New("silence cannot start after it ends")
+	}
+
+	comment, author := spec.Comment, spec.Author
+	if comment == "" {
+		comment = c.comment
+	}
+	if author == "" {
+		author = c.author
+	}
+	if c.requireComment && comment == "" {
+		return nil, errors.New("comment required by config")
+	}
+	// Fields of the file override the fields given by flags.
+	var metadata map[string]string
+	if len(c.metadata)+len(spec.Metadata) > 0 {
+		metadata = make(map[string]string, len(c.metadata)+len(spec.Metadata))
+		for k, v := range c.metadata {
+			metadata[k] = v
+		}
+		for k, v := range spec.Metadata {
+			metadata[k] = v
+		}
+	}
+
+	fs.silence = types.Silence{
+		Matchers:   typeMatchers,
+		StartsAt:   startsAt,
+		EndsAt:     endsAt,
+		CreatedBy:  author,
+		Comment:    comment,
+		ExternalID: spec.ExternalID,
+		Metadata:   metadata,
+	}
+	return fs, nil
+}
+
+// reconcile matches the file silences to the existing silences that are not
+// expired by their external IDs. A file silence whose silence does not need
+// to change is marked as applied with the ID of the silence, the others get
+// the ID of their silence to update it.
+func reconcile(fss []*fileSilence, existing []*types.Silence) {
+	byExternalID := map[string]*types.Silence{}
+	for _, s := range existing {
+		if s.ExternalID != "" && s.Status.State != types.SilenceStateExpired {
+			byExternalID[s.ExternalID] = s
+		}
+	}
+	for _, fs := range fss {
+		if fs.silence.ExternalID == "" {
+			continue
+		}
+		s, ok := byExternalID[fs.silence.ExternalID]
+		if !ok {
+			continue
+		}
+		fs.silence.ID = s.ID
+		fs.applied = fs.unchanged(s)
+	}
+}
+
+// unchanged reports whether the silence is the one defined in the file.
+// Times given as durations from now are not compared, as they differ every
+// time the file is applied.
+func (fs *fileSilence) unchanged(s *types.Silence) bool {
+	if fs.silence.Matchers.String() != s.Matchers.String() ||
+		fs.silence.Comment != s.Comment ||
+		fs.silence.CreatedBy != s.CreatedBy ||
+		!equalMetadata(fs.silence.Metadata, s.Metadata) {
+		return false
+	}
+	if fs.fixedEnd && !fs.silence.EndsAt.Equal(s.EndsAt) {
+		return false
+	}
+	// Silences start when they are added at the latest.
+	if fs.fixedStart && s.Status.State == types.SilenceStatePending && !fs.silence.StartsAt.Equal(s.StartsAt) {
+		return false
+	}
+	return true
+}
+
+// equalMetadata reports whether both silences have the same metadata.
+func equalMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// addFromFile adds the silences defined in the file, updating those with
+// an external ID that were added before and changed since, and prints
+// their IDs.
+func (c *silenceAddCmd) addFromFile() error {
+	if len(c.matchers) > 0 {
+		return errors.New("matchers cannot be given together with --file")
+	}
+	if c.start != "" || c.startAt != "" || c.end != "" || c.renew != "" || c.renewUntil != "" {
+		return errors.New("--start, --start-at, --end, --renew and --renew-until cannot be given together with --file")
+	}
+
+	f, err := os.Open(c.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fss, err := c.readSilenceFile(f, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if c.dryRun {
+		for _, fs := range fss {
+			describeSilence(os.Stdout, &fs.silence)
+		}
+		return nil
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	silenceAPI := client.NewSilenceAPI(apiClient)
+	existing, err := silenceAPI.List(context.Background(), "")
+	if err != nil {
+		return err
+	}
+	reconcile(fss, existing)
+
+	var pending int
+	for _, fs := range fss {
+		if !fs.applied {
+			pending++
+		}
+	}
+	if c.confirm && pending > 0 {
+		for _, fs := range fss {
+			if !fs.applied {
+				describeSilence(os.Stderr, &fs.silence)
+			}
+		}
+		ok, err := confirm(os.Stdin, os.Stderr, fmt.Sprintf("Add or update these %d silences?", pending))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+
+	// New silences are added at once, so that none is added if one of them
+	// is invalid.
+	var created []types.Silence
+	for _, fs := range fss {
+		if fs.silence.ID == "" {
+			created = append(created, fs.silence)
+		}
+	}
+	var createdIDs []string
+	if len(created) > 0 {
+		createdIDs, err = silenceAPI.Create(context.Background(), created, c.force)
+		if err != nil {
+			return err
+		}
+	}
+
+	set := silenceAPI.Set
+	if c.force {
+		set = silenceAPI.SetForce
+	}
+	for _, fs := range fss {
+		switch {
+		case fs.silence.ID == "":
+			fs.silence.ID, createdIDs = createdIDs[0], createdIDs[1:]
+		case !fs.applied:
+			// The silence gets a new ID if it cannot be updated in place.
+			if fs.silence.ID, err = set(context.Background(), fs.silence); err != nil {
+				return err
+			}
+		}
+		fmt.Println(fs.silence.ID)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestConfirm(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"y\n":   true,
+		"Yes\n": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+		"yes":   true,
+	} {
+		var out bytes.Buffer
+		ok, err := confirm(strings.NewReader(input), &out, "Add this silence?")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ok != expected {
+			t.Errorf("input %q: expected %v, got %v", input, expected, ok)
+		}
+		if out.String() != "Add this silence? [y/N] " {
+			t.Errorf("unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestParseStartAt(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	for input, expected := range map[string]time.Time{
+		"2h":                   now.Add(2 * time.Hour),
+		"1d":                   now.Add(24 * time.Hour),
+		"2018-06-02T20:00:00Z": time.Date(2018, 6, 2, 20, 0, 0, 0, time.UTC),
+	} {
+		start, err := parseStartAt(input, now)
+		if err != nil {
+			t.Fatalf("input %q: unexpected error: %v", input, err)
+		}
+		if !start.Equal(expected) {
+			t.Errorf("input %q: expected %v, got %v", input, expected, start)
+		}
+	}
+
+	if _, err := parseStartAt("tomorrow", now); err == nil {
+		t.Errorf("expected error for invalid start")
+	}
+}
+
+func TestSilenceAddRenewal(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	endsAt := now.Add(2 * time.Hour)
+
+	c := &silenceAddCmd{}
+	renewal, err := c.renewal(now, endsAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renewal != nil {
+		t.Errorf("expected no renewal, got %v", renewal)
+	}
+
+	c = &silenceAddCmd{renew: "2h", renewUntil: "3d"}
+	renewal, err = c.renewal(now, endsAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renewal.Increment != "2h" || !renewal.Until.Equal(now.Add(72*time.Hour)) {
+		t.Errorf("unexpected renewal %v", renewal)
+	}
+
+	for _, c := range []*silenceAddCmd{
+		{renew: "2h"},
+		{renew: "0s", renewUntil: "3d"},
+		{renew: "2h", renewUntil: "tomorrow"},
+		{renew: "2h", renewUntil: "1h"},
+	} {
+		if _, err := c.renewal(now, endsAt); err == nil {
+			t.Errorf("expected error for --renew=%q --renew-until=%q", c.renew, c.renewUntil)
+		}
+	}
+}
+
+func TestTypeMatchersNegative(t *testing.T) {
+	matchers, err := parseMatchers([]string{"team=foo", "severity!=critical", "instance!~web-.*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	typeMatchers, err := TypeMatchers(matchers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := typeMatchers.String(); s != `{team="foo",severity!="critical",instance!~"web-.*"}` {
+		t.Errorf("unexpected matchers %s", s)
+	}
+}
+
+func TestExpandMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		expected [][]string
+	}{
+		{
+			args:     []string{"foo", "instance=db1"},
+			expected: [][]string{{"foo", "instance=db1"}},
+		},
+		{
+			args: []string{"alertname=foo", "instance={db1,db2}", "dc!~{eu.*,us}"},
+			expected: [][]string{
+				{"alertname=foo", "instance=db1", "dc!~eu.*"},
+				{"alertname=foo", "instance=db1", "dc!~us"},
+				{"alertname=foo", "instance=db2", "dc!~eu.*"},
+				{"alertname=foo", "instance=db2", "dc!~us"},
+			},
+		},
+		// Quoted values and braces without alternatives are kept.
+		{
+			args:     []string{`summary="{a,b}"`, "instance={db1}"},
+			expected: [][]string{{`summary="{a,b}"`, "instance={db1}"}},
+		},
+	} {
+		if got := expandMatchers(tc.args); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%v: expected %v, got %v", tc.args, tc.expected, got)
+		}
+	}
+}
+
+func TestReadSilenceFile(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := &silenceAddCmd{duration: "1h", author: "alice", requireComment: true}
+
+	fss, err := c.readSilenceFile(strings.NewReader(`
+- external_id: db-upgrade
+  matchers: ['cluster=db', 'severity!=critical']
+  start: 2018-06-02T22:00:00Z
+  end: 2018-06-03T02:00:00Z
+  comment: Upgrade of the database cluster
+- matchers: [DiskFull]
+  start: 2h
+  comment: Disk replacement
+  author: bob
+`), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fss) != 2 {
+		t.Fatalf("expected 2 silences, got %d", len(fss))
+	}
+
+	s := fss[0].silence
+	if s.ExternalID != "db-upgrade" || s.Matchers.String() != `{cluster="db",severity!="critical"}` || s.CreatedBy != "alice" {
+		t.Errorf("unexpected silence %+v", s)
+	}
+	if !s.StartsAt.Equal(time.Date(2018, 6, 2, 22, 0, 0, 0, time.UTC)) || !s.EndsAt.Equal(time.Date(2018, 6, 3, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time range %v - %v", s.StartsAt, s.EndsAt)
+	}
+	if !fss[0].fixedStart || !fss[0].fixedEnd {
+		t.Errorf("expected fixed time range")
+	}
+
+	s = fss[1].silence
+	if s.Matchers.String() != `{alertname="DiskFull"}` || s.CreatedBy != "bob" {
+		t.Errorf("unexpected silence %+v", s)
+	}
+	if !s.StartsAt.Equal(now.Add(2*time.Hour)) || !s.EndsAt.Equal(now.Add(3*time.Hour)) {
+		t.Errorf("unexpected time range %v - %v", s.StartsAt, s.EndsAt)
+	}
+	if fss[1].fixedStart || fss[1].fixedEnd {
+		t.Errorf("expected relative time range")
+	}
+
+	for _, in := range []string{
+		``,
+		`- matchers: [foo]`,
+		`- {matchers: [foo], comment: a, duration: 1h, end: 2h}`,
+		`- {matchers: [foo], comment: a, startsAt: 2h}`,
+		`[{external_id: a, matchers: [foo], comment: a}, {external_id: a, matchers: [bar], comment: b}]`,
+	} {
+		if _, err := c.readSilenceFile(strings.NewReader(in), now); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestReadSilenceFileMetadata(t *testing.T) {
+	c := &silenceAddCmd{duration: "1h", metadata: map[string]string{"team": "storage", "ticket": "OPS-1"}}
+
+	fss, err := c.readSilenceFile(strings.NewReader(`
+- matchers: [DiskFull]
+  metadata:
+    ticket: OPS-123
+- matchers: [DiskSlow]
+`), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []map[string]string{
+		{"team": "storage", "ticket": "OPS-123"},
+		{"team": "storage", "ticket": "OPS-1"},
+	}
+	for i, fs := range fss {
+		if !reflect.DeepEqual(fs.silence.Metadata, expected[i]) {
+			t.Errorf("silence %d: expected metadata %v, got %v", i, expected[i], fs.silence.Metadata)
+		}
+	}
+
+	// Silences whose metadata changed are updated.
+	existing := fss[0].silence
+	existing.Metadata = map[string]string{"team": "storage", "ticket": "OPS-122"}
+	if fss[0].unchanged(&existing) {
+		t.Errorf("expected changed metadata")
+	}
+	existing.Metadata = expected[0]
+	if !fss[0].unchanged(&existing) {
+		t.Errorf("expected unchanged metadata")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	start := time.Date(2018, 6, 2, 22, 0, 0, 0, time.UTC)
+	newFileSilence := func(externalID, comment string) *fileSilence {
+		return &fileSilence{
+			silence: types.Silence{
+				Matchers:   types.Matchers{{Name: "cluster", Value: "db"}},
+				StartsAt:   start,
+				EndsAt:     start.Add(4 * time.Hour),
+				Comment:    comment,
+				ExternalID: externalID,
+			},
+			fixedStart: true,
+			fixedEnd:   true,
+		}
+	}
+	existing := func(id, externalID string, state types.SilenceState) *types.Silence {
+		s := newFileSilence(externalID, "upgrade").silence
+		s.ID = id
+		s.Status.State = state
+		return &s
+	}
+
+	var (
+		unchanged = newFileSilence("a", "upgrade")
+		changed   = newFileSilence("b", "upgrade of the cluster")
+		expired   = newFileSilence("c", "upgrade")
+		added     = newFileSilence("", "upgrade")
+	)
+	reconcile([]*fileSilence{unchanged, changed, expired, added}, []*types.Silence{
+		existing("1", "a", types.SilenceStatePending),
+		existing("2", "b", types.SilenceStatePending),
+		existing("3", "c", types.SilenceStateExpired),
+		existing("4", "", types.SilenceStatePending),
+	})
+
+	for _, tc := range []struct {
+		fs      *fileSilence
+		id      string
+		applied bool
+	}{
+		{fs: unchanged, id: "1", applied: true},
+		{fs: changed, id: "2"},
+		{fs: expired},
+		{fs: added},
+	} {
+		if tc.fs.silence.ID != tc.id || tc.fs.applied != tc.applied {
+			t.Errorf("silence %q: expected ID %q and applied %v, got %q and %v", tc.fs.silence.ExternalID, tc.id, tc.applied, tc.fs.silence.ID, tc.fs.applied)
+		}
+	}
+}
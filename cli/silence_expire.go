@@ -16,38 +16,90 @@ package cli
 import (
 	"context"
 	"errors"
+	"time"
 
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
 )
 
 type silenceExpireCmd struct {
-	ids []string
+	ids      []string
+	matchers []string
+	author   string
+	within   time.Duration
+	dryRun   bool
 }
 
+const expireSilenceHelp = `Expire Alertmanager silences.
+
+Silences are expired either by their IDs or in bulk by filters. The filters
+select the active silences that match all of the given matcher groups, that
+are created by the author and that expire within the duration:
+
+amtool silence expire --matchers='env=staging' --author=me@example.com
+
+	expires all active silences of staging created by me@example.com.
+
+The "--dry-run" parameter lists the silences that would be expired.
+`
+
 func configureSilenceExpireCmd(cc *kingpin.CmdClause) {
 	var (
 		c         = &silenceExpireCmd{}
-		expireCmd = cc.Command("expire", "expire an alertmanager silence")
+		expireCmd = cc.Command("expire", expireSilenceHelp)
 	)
-	expireCmd.Arg("silence-ids", "Ids of silences to expire").StringsVar(&c.ids)
+	expireCmd.Arg("silence-ids", "Ids of silences to expire").HintAction(completeSilenceIDs).StringsVar(&c.ids)
+	expireCmd.Flag("matchers", "Expire silences matching the matcher group, can be repeated").HintAction(completeLabelNames).StringsVar(&c.matchers)
+	expireCmd.Flag("author", "Expire silences created by the author").StringVar(&c.author)
+	expireCmd.Flag("within", "Expire silences that will expire within a duration").DurationVar(&c.within)
+	expireCmd.Flag("dry-run", "List the silences that would be expired without expiring them").BoolVar(&c.dryRun)
 	expireCmd.Action(c.expire)
 }
 
 func (c *silenceExpireCmd) expire(ctx *kingpin.ParseContext) error {
-	if len(c.ids) < 1 {
-		return errors.New("no silence IDs specified")
+	author := explicitAuthor(ctx, c.author)
+	filtered := len(c.matchers) > 0 || author != "" || c.within > 0
+	if len(c.ids) > 0 && filtered {
+		return errors.New("silence IDs and filters cannot be combined")
+	}
+	if len(c.ids) < 1 && !filtered {
+		return errors.New("no silence IDs or filters specified")
 	}
 
-	apiClient, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+	apiClient, err := NewAPIClient()
 	if err != nil {
 		return err
 	}
 	silenceAPI := client.NewSilenceAPI(apiClient)
 
-	for _, id := range c.ids {
+	ids := c.ids
+	if filtered {
+		filterString, err := matcherGroupsFilter(c.matchers)
+		if err != nil {
+			return err
+		}
+		fetchedSilences, err := silenceAPI.List(context.Background(), filterString)
+		if err != nil {
+			return err
+		}
+		silences := selectExpirableSilences(fetchedSilences, author, c.within, time.Now())
+		if c.dryRun {
+			formatter, found := format.Formatters[output]
+			if !found {
+				return errors.New("unknown output formatter")
+			}
+			return formatter.FormatSilences(silences)
+		}
+		ids = nil
+		for _, s := range silences {
+			ids = append(ids, s.ID)
+		}
+	}
+
+	for _, id := range ids {
 		err := silenceAPI.Expire(context.Background(), id)
 		if err != nil {
 			return err
@@ -56,3 +108,22 @@ func (c *silenceExpireCmd) expire(ctx *kingpin.ParseContext) error {
 
 	return nil
 }
+
+// selectExpirableSilences returns the active silences that are created by
+// the author, if set, and that expire within the duration, if set.
+func selectExpirableSilences(silences []*types.Silence, author string, within time.Duration, now time.Time) []types.Silence {
+	res := []types.Silence{}
+	for _, s := range silences {
+		if !s.EndsAt.After(now) {
+			continue
+		}
+		if within > 0 && s.EndsAt.After(now.Add(within)) {
+			continue
+		}
+		if author != "" && s.CreatedBy != author {
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestSelectExpirableSilences(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	silences := []*types.Silence{
+		{ID: "expired", CreatedBy: "alice", EndsAt: now.Add(-time.Minute)},
+		{ID: "soon", CreatedBy: "alice", EndsAt: now.Add(time.Hour)},
+		{ID: "later", CreatedBy: "alice", EndsAt: now.Add(48 * time.Hour)},
+		{ID: "other", CreatedBy: "bob", EndsAt: now.Add(time.Hour)},
+	}
+
+	for _, tc := range []struct {
+		author string
+		within time.Duration
+		ids    []string
+	}{
+		{ids: []string{"soon", "later", "other"}},
+		{author: "alice", ids: []string{"soon", "later"}},
+		{within: 2 * time.Hour, ids: []string{"soon", "other"}},
+		{author: "alice", within: 2 * time.Hour, ids: []string{"soon"}},
+	} {
+		var ids []string
+		for _, s := range selectExpirableSilences(silences, tc.author, tc.within, now) {
+			ids = append(ids, s.ID)
+		}
+		if len(ids) != len(tc.ids) {
+			t.Errorf("author %q within %s: expected %v, got %v", tc.author, tc.within, tc.ids, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tc.ids[i] {
+				t.Errorf("author %q within %s: expected %v, got %v", tc.author, tc.within, tc.ids, ids)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+type silenceExportCmd struct {
+	format   string
+	expired  bool
+	matchers []string
+}
+
+const silenceExportHelp = `Export alertmanager silences as JSON or YAML
+
+This command dumps the active and pending silences so that they can be
+recreated against another Alertmanager with the import command. For example:
+
+amtool silence export > silences.json
+
+amtool --alertmanager.url=http://new-alertmanager:9093 silence import silences.json
+
+The silences can be filtered with matcher groups like in the query command.
+`
+
+func configureSilenceExportCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceExportCmd{}
+		exportCmd = cc.Command("export", silenceExportHelp)
+	)
+
+	exportCmd.Flag("format", "Format of the exported silences").Default("json").EnumVar(&c.format, "json", "yaml")
+	exportCmd.Flag("expired", "Also export expired silences").BoolVar(&c.expired)
+	exportCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&c.matchers)
+	exportCmd.Action(c.export)
+}
+
+func (c *silenceExportCmd) export(ctx *kingpin.ParseContext) error {
+	filterString, err := matcherGroupsFilter(c.matchers)
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	fetchedSilences, err := client.NewSilenceAPI(apiClient).List(context.Background(), filterString)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	silences := []types.Silence{}
+	for _, s := range fetchedSilences {
+		if !c.expired && s.EndsAt.Before(now) {
+			continue
+		}
+		// The history of a silence cannot be imported.
+		s.History = nil
+		silences = append(silences, *s)
+	}
+	return encodeSilences(os.Stdout, c.format, silences)
+}
+
+// encodeSilences writes the silences in the given format. YAML uses the
+// same field names as JSON so that both can be imported alike.
+func encodeSilences(w io.Writer, format string, silences []types.Silence) error {
+	b, err := json.MarshalIndent(silences, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == "yaml" {
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		if b, err = yaml.Marshal(v); err != nil {
+			return err
+		}
+	} else {
+		b = append(b, '\n')
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// decodeYAMLSilences reads silences encoded by encodeSilences as YAML.
+func decodeYAMLSilences(r io.Reader) ([]types.Silence, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	// YAML decodes maps with interface keys, which JSON cannot encode.
+	if b, err = json.Marshal(stringKeys(v)); err != nil {
+		return nil, err
+	}
+	var silences []types.Silence
+	if err := json.Unmarshal(b, &silences); err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = stringKeys(e)
+		}
+	}
+	return v
+}
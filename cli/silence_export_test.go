@@ -0,0 +1,69 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestEncodeYAMLSilences(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	silences := []types.Silence{
+		{
+			ID:        "abc",
+			Matchers:  types.Matchers{{Name: "job", Value: "node.*", IsRegex: true}},
+			StartsAt:  now,
+			EndsAt:    now.Add(time.Hour),
+			UpdatedAt: now,
+			CreatedBy: "alice",
+			Comment:   "maintenance",
+			Status:    types.SilenceStatus{State: types.SilenceStateActive},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := encodeSilences(&buf, "yaml", silences); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := decodeYAMLSilences(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(silences, got) {
+		t.Errorf("expected %+v, got %+v", silences, got)
+	}
+}
+
+func TestSilenceImportPrepare(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := &silenceImportCmd{force: true, skipExpired: true, shift: 24 * time.Hour}
+
+	s := &types.Silence{ID: "abc", StartsAt: now.Add(-25 * time.Hour), EndsAt: now.Add(-23 * time.Hour)}
+	if !c.prepare(s, now) {
+		t.Fatalf("expected shifted silence to be imported")
+	}
+	if s.ID != "" || !s.StartsAt.Equal(now.Add(-time.Hour)) || !s.EndsAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected silence %+v", s)
+	}
+
+	s = &types.Silence{StartsAt: now.Add(-50 * time.Hour), EndsAt: now.Add(-48 * time.Hour)}
+	if c.prepare(s, now) {
+		t.Errorf("expected expired silence to be skipped")
+	}
+}
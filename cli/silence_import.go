@@ -20,9 +20,9 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/prometheus/alertmanager/client"
@@ -30,9 +30,12 @@ import (
 )
 
 type silenceImportCmd struct {
-	force   bool
-	workers int
-	file    string
+	force       bool
+	workers     int
+	file        string
+	format      string
+	skipExpired bool
+	shift       time.Duration
 }
 
 const silenceImportHelp = `Import alertmanager silences from JSON file or stdin
@@ -44,7 +47,16 @@ amtool silence query -o json foo > foo.json
 
 amtool silence import foo.json
 
-JSON data can also come from stdin if no param is specified.
+JSON data can also come from stdin if no param is specified. Files created by
+the export command can be imported in either format:
+
+amtool silence import --format=yaml silences.yml
+
+Silences that already expired cannot be imported, "--skip-expired" leaves them
+out instead of failing. The "--shift" parameter moves the start and end times of
+all silences by a duration, for example to restore silences exported a day ago:
+
+amtool silence import --skip-expired --shift=24h silences.json
 `
 
 func configureSilenceImportCmd(cc *kingpin.CmdClause) {
@@ -55,6 +67,9 @@ func configureSilenceImportCmd(cc *kingpin.CmdClause) {
 
 	importCmd.Flag("force", "Force adding new silences even if it already exists").Short('f').BoolVar(&c.force)
 	importCmd.Flag("worker", "Number of concurrent workers to use for import").Short('w').Default("8").IntVar(&c.workers)
+	importCmd.Flag("format", "Format of the imported silences").Default("json").EnumVar(&c.format, "json", "yaml")
+	importCmd.Flag("skip-expired", "Skip silences that are expired, after shifting them").BoolVar(&c.skipExpired)
+	importCmd.Flag("shift", "Duration to shift the start and end times of the silences by").DurationVar(&c.shift)
 	importCmd.Arg("input-file", "JSON file with silences").ExistingFileVar(&c.file)
 	importCmd.Action(c.bulkImport)
 }
@@ -89,14 +104,43 @@ func (c *silenceImportCmd) bulkImport(ctx *kingpin.ParseContext) error {
 		defer input.Close()
 	}
 
-	dec := json.NewDecoder(input)
-	// read open square bracket
-	_, err = dec.Token()
-	if err != nil {
-		return errors.Wrap(err, "couldn't unmarshal input data, is it JSON?")
+	var (
+		next func() (*types.Silence, error)
+		dec  *json.Decoder
+	)
+	if c.format == "yaml" {
+		silences, err := decodeYAMLSilences(input)
+		if err != nil {
+			return errors.Wrap(err, "couldn't unmarshal input data, is it YAML?")
+		}
+		next = func() (*types.Silence, error) {
+			if len(silences) == 0 {
+				return nil, nil
+			}
+			s := &silences[0]
+			silences = silences[1:]
+			return s, nil
+		}
+	} else {
+		dec = json.NewDecoder(input)
+		// read open square bracket
+		_, err = dec.Token()
+		if err != nil {
+			return errors.Wrap(err, "couldn't unmarshal input data, is it JSON?")
+		}
+		next = func() (*types.Silence, error) {
+			if !dec.More() {
+				return nil, nil
+			}
+			var s types.Silence
+			if err := dec.Decode(&s); err != nil {
+				return nil, errors.Wrap(err, "couldn't unmarshal input data, is it JSON?")
+			}
+			return &s, nil
+		}
 	}
 
-	apiClient, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+	apiClient, err := NewAPIClient()
 	if err != nil {
 		return err
 	}
@@ -122,19 +166,20 @@ func (c *silenceImportCmd) bulkImport(ctx *kingpin.ParseContext) error {
 	}()
 
 	count := 0
-	for dec.More() {
-		var s types.Silence
-		err := dec.Decode(&s)
+	for {
+		s, err := next()
 		if err != nil {
-			return errors.Wrap(err, "couldn't unmarshal input data, is it JSON?")
+			close(silencec)
+			return err
 		}
-
-		if c.force {
-			// reset the silence ID so Alertmanager will always create new silence
-			s.ID = ""
+		if s == nil {
+			break
+		}
+		if !c.prepare(s, time.Now()) {
+			continue
 		}
 
-		silencec <- &s
+		silencec <- s
 		count++
 	}
 
@@ -147,3 +192,16 @@ func (c *silenceImportCmd) bulkImport(ctx *kingpin.ParseContext) error {
 	}
 	return nil
 }
+
+// prepare adapts an imported silence and returns false if it should be
+// skipped.
+func (c *silenceImportCmd) prepare(s *types.Silence, now time.Time) bool {
+	if c.force {
+		// reset the silence ID so Alertmanager will always create new silence
+		s.ID = ""
+	}
+	s.StartsAt = s.StartsAt.Add(c.shift)
+	s.EndsAt = s.EndsAt.Add(c.shift)
+	s.History = nil
+	return !c.skipExpired || s.EndsAt.After(now)
+}
@@ -15,25 +15,38 @@ package cli
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"os"
+	"regexp"
 	"time"
 
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
-	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/client"
-	"github.com/prometheus/alertmanager/pkg/parse"
 	"github.com/prometheus/alertmanager/types"
 )
 
+// suppressedSilenceFields are the fields of silences output with their
+// suppressed alerts.
+const suppressedSilenceFields = "id,matchers,endsAt,createdBy,comment,suppressed,suppressedAlerts"
+
 type silenceQueryCmd struct {
-	expired  bool
-	quiet    bool
-	matchers []string
-	within   time.Duration
+	expired       bool
+	pending       bool
+	matchers      []string
+	within        time.Duration
+	endsBefore    string
+	endsAfter     string
+	author        string
+	commentRegex  *regexp.Regexp
+	suppressed    bool
+	fields        string
+	sort          string
+	limit         int
+	offset        int
+	watch         bool
+	watchInterval time.Duration
 }
 
 const querySilenceHelp = `Query Alertmanager silences.
@@ -59,6 +72,12 @@ amtool silence query 'alertname=~foo.*'
 	(similar to prometheus) is used to represent a regex match. Regex matching
 	can be used in combination with a direct match.
 
+amtool silence query 'summary="disk full, {sda1}"' 'service=~"api|web"'
+
+	Values containing spaces, commas or braces are enclosed in double quotes,
+	in which \" and \\ escape quotes and backslashes. Label names consist of
+	ASCII letters, digits and underscores and do not start with a digit.
+
 In addition to filtering by silence labels, one can also query for silences
 that are due to expire soon with the "--within" parameter. In the event that
 you want to preemptively act upon expiring silences by either fixing them or
@@ -66,8 +85,15 @@ extending them. For example:
 
 amtool silence query --within 8h
 
-returns all the silences due to expire within the next 8 hours. This syntax can
-also be combined with the label based filtering above for more flexibility.
+returns all the silences due to expire or scheduled to start within the next 8
+hours. This syntax can also be combined with the label based filtering above for
+more flexibility.
+
+The "--ends-before" and "--ends-after" parameters restrict the result to the
+silences ending before or after a time, given in RFC3339 format or as a duration
+from now. Together they show the silences lapsing during a maintenance window:
+
+amtool silence query --ends-after=2018-06-02T20:00:00Z --ends-before=2018-06-02T23:00:00Z
 
 The "--expired" parameter returns only expired silences. Used in combination
 with "--within=TIME", amtool returns the silences that expired within the
@@ -76,6 +102,51 @@ preceding duration.
 amtool silence query --within 2h --expired
 
 returns all silences that expired within the preceeding 2 hours.
+
+The "--pending" parameter returns only silences scheduled to start in the
+future, e.g. for booked maintenance windows.
+
+amtool silence query --pending
+
+The "--author" and "--comment-regex" parameters restrict the result to the
+silences created by an author or with a comment matching a regular expression.
+
+amtool silence query --author=me@example.com --comment-regex='(?i)maintenance'
+
+returns all silences created by me@example.com mentioning maintenance.
+
+The "--show-suppressed" parameter adds the number and the fingerprints of the
+unresolved alerts each silence currently suppresses to the output, so that
+silences muting nothing or far more than intended stand out:
+
+amtool silence query --show-suppressed
+
+The "--fields" parameter selects and orders the columns of the output out of
+id, matchers, startsAt, endsAt, updatedAt, createdBy, comment, metadata, state,
+instances and, with "--show-suppressed", suppressed and suppressedAlerts. A
+single metadata field is selected with metadata.<name>.
+
+amtool -o csv silence query --fields=id,createdBy,endsAt,metadata.ticket
+
+The Alertmanager sorts and paginates the silences with the "--sort", "--limit"
+and "--offset" parameters. The silences can be sorted by startsAt, endsAt or
+updatedAt, a leading '-' reverses the order. The "--within", "--ends-before",
+"--ends-after", "--author" and "--comment-regex" parameters filter the returned
+page.
+
+amtool silence query --sort=-updatedAt --limit=20
+
+The "--watch" parameter refreshes the output until amtool is interrupted and
+highlights the silences that were created or expired since the last refresh:
+
+amtool silence query --watch --watch-interval=30s
+
+A comma-separated list of Alertmanagers queries all of them at once. Silences
+returned by several Alertmanagers are shown once in their most recently
+updated version, the instances field lists the Alertmanagers that returned
+them:
+
+amtool --alertmanager.url=http://am-eu:9093,http://am-us:9093 silence query
 `
 
 func configureSilenceQueryCmd(cc *kingpin.CmdClause) {
@@ -85,70 +156,212 @@ func configureSilenceQueryCmd(cc *kingpin.CmdClause) {
 	)
 
 	queryCmd.Flag("expired", "Show expired silences instead of active").BoolVar(&c.expired)
-	queryCmd.Flag("quiet", "Only show silence ids").Short('q').BoolVar(&c.quiet)
-	queryCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
-	queryCmd.Flag("within", "Show silences that will expire or have expired within a duration").DurationVar(&c.within)
+	queryCmd.Flag("pending", "Show only silences that have not started yet").BoolVar(&c.pending)
+	queryCmd.Arg("matcher-groups", "Query filter").HintAction(completeLabelNames).StringsVar(&c.matchers)
+	queryCmd.Flag("within", "Show silences that will start, will expire or have expired within a duration").DurationVar(&c.within)
+	queryCmd.Flag("ends-before", "Show silences ending before a time in RFC3339 format or a duration from now").StringVar(&c.endsBefore)
+	queryCmd.Flag("ends-after", "Show silences ending after a time in RFC3339 format or a duration from now").StringVar(&c.endsAfter)
+	queryCmd.Flag("author", "Show silences created by the author").StringVar(&c.author)
+	queryCmd.Flag("comment-regex", "Show silences with a comment matching the regular expression").RegexpVar(&c.commentRegex)
+	queryCmd.Flag("show-suppressed", "Show the alerts each silence currently suppresses").BoolVar(&c.suppressed)
+	queryCmd.Flag("fields", "Comma-separated fields to output (not supported by json output)").StringVar(&c.fields)
+	queryCmd.Flag("sort", "Sort silences by startsAt, endsAt or updatedAt, prefixed with '-' for descending order").StringVar(&c.sort)
+	queryCmd.Flag("limit", "Maximum number of silences to show").IntVar(&c.limit)
+	queryCmd.Flag("offset", "Number of silences to skip").IntVar(&c.offset)
+	queryCmd.Flag("watch", "Refresh the output periodically until interrupted, highlighting created and expired silences").BoolVar(&c.watch)
+	queryCmd.Flag("watch-interval", "Interval between the refreshes of --watch").Default("5s").DurationVar(&c.watchInterval)
 	queryCmd.Action(c.query)
 }
 
+// explicitAuthor returns the author if the flag is given explicitly. The
+// author flag defaults to the configured author of new silences, so it
+// only filters silences if it is given explicitly.
+func explicitAuthor(ctx *kingpin.ParseContext, author string) string {
+	for _, elem := range ctx.Elements {
+		if f, ok := elem.Clause.(*kingpin.FlagClause); ok && f.Model().Name == "author" {
+			return author
+		}
+	}
+	return ""
+}
+
+// silenceFilter selects the silences to display out of the fetched ones.
+type silenceFilter struct {
+	now        time.Time
+	expired    bool
+	pending    bool
+	within     time.Duration
+	endsBefore time.Time
+	endsAfter  time.Time
+	author     string
+	comment    *regexp.Regexp
+}
+
+func (c *silenceQueryCmd) filter(ctx *kingpin.ParseContext) (*silenceFilter, error) {
+	f := &silenceFilter{
+		now:     time.Now().UTC(),
+		expired: c.expired,
+		pending: c.pending,
+		within:  c.within,
+		author:  explicitAuthor(ctx, c.author),
+		comment: c.commentRegex,
+	}
+	var err error
+	if c.endsBefore != "" {
+		if f.endsBefore, err = parseTimeOrDuration(c.endsBefore, f.now); err != nil {
+			return nil, fmt.Errorf("invalid --ends-before %q, expected a time in RFC3339 format or a duration", c.endsBefore)
+		}
+	}
+	if c.endsAfter != "" {
+		if f.endsAfter, err = parseTimeOrDuration(c.endsAfter, f.now); err != nil {
+			return nil, fmt.Errorf("invalid --ends-after %q, expected a time in RFC3339 format or a duration", c.endsAfter)
+		}
+	}
+	if !f.endsBefore.IsZero() && !f.endsAfter.IsZero() && !f.endsAfter.Before(f.endsBefore) {
+		return nil, fmt.Errorf("--ends-after must be before --ends-before")
+	}
+	return f, nil
+}
+
+// match returns whether the silence is to be displayed.
+func (f *silenceFilter) match(silence *types.Silence) bool {
+	// skip expired silences if --expired is not set
+	if !f.expired && silence.EndsAt.Before(f.now) {
+		return false
+	}
+	// skip active silences if --expired is set
+	if f.expired && silence.EndsAt.After(f.now) {
+		return false
+	}
+	// skip silences neither expiring nor starting within "--within"
+	if !f.expired && f.within > 0 {
+		horizon := f.now.Add(f.within)
+		if silence.EndsAt.After(horizon) && (!silence.StartsAt.After(f.now) || silence.StartsAt.After(horizon)) {
+			return false
+		}
+	}
+	// skip silences that expired before "--within"
+	if f.expired && f.within > 0 && silence.EndsAt.Before(f.now.Add(-f.within)) {
+		return false
+	}
+	if !f.endsBefore.IsZero() && !silence.EndsAt.Before(f.endsBefore) {
+		return false
+	}
+	if !f.endsAfter.IsZero() && !silence.EndsAt.After(f.endsAfter) {
+		return false
+	}
+	// skip started silences if --pending is set
+	if f.pending && silence.Status.State != types.SilenceStatePending {
+		return false
+	}
+	if f.author != "" && silence.CreatedBy != f.author {
+		return false
+	}
+	if f.comment != nil && !f.comment.MatchString(silence.Comment) {
+		return false
+	}
+	return true
+}
+
 func (c *silenceQueryCmd) query(ctx *kingpin.ParseContext) error {
-	var filterString = ""
-	if len(c.matchers) == 1 {
-		// If the parser fails then we likely don't have a (=|=~|!=|!~) so lets
-		// assume that the user wants alertname=<arg> and prepend `alertname=`
-		// to the front.
-		_, err := parse.Matcher(c.matchers[0])
+	var silenceAPI client.SilenceAPI
+	if len(alertmanagerURLs) > 1 {
+		m, err := newMultiSilenceAPI(alertmanagerURLs)
+		if err != nil {
+			return err
+		}
+		silenceAPI = m
+		if c.fields == "" && !c.suppressed && output == "simple" {
+			c.fields = multiInstanceSilenceFields
+		}
+	} else {
+		apiClient, err := NewAPIClient()
 		if err != nil {
-			filterString = fmt.Sprintf("{alertname=%s}", c.matchers[0])
-		} else {
-			filterString = fmt.Sprintf("{%s}", strings.Join(c.matchers, ","))
+			return err
 		}
-	} else if len(c.matchers) > 1 {
-		filterString = fmt.Sprintf("{%s}", strings.Join(c.matchers, ","))
+		silenceAPI = client.NewSilenceAPI(apiClient)
+	}
+
+	if c.watch {
+		return watch(c.watchInterval, silenceWatchLabels, func(w io.Writer) (watchItems, error) {
+			silences, err := c.render(ctx, silenceAPI, w, w)
+			return silenceWatchItems(silences), err
+		})
 	}
+	_, err := c.render(ctx, silenceAPI, os.Stdout, os.Stderr)
+	return err
+}
 
-	apiClient, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+// render writes the queried silences to w and the notes about the output to
+// stderr and returns the silences.
+func (c *silenceQueryCmd) render(ctx *kingpin.ParseContext, silenceAPI client.SilenceAPI, w, stderr io.Writer) ([]types.Silence, error) {
+	// The filter is created for every rendering as it is relative to the
+	// current time.
+	filter, err := c.filter(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	states := []types.SilenceState{types.SilenceStateActive, types.SilenceStatePending}
+	switch {
+	case c.expired:
+		states = []types.SilenceState{types.SilenceStateExpired}
+	case c.pending:
+		states = []types.SilenceState{types.SilenceStatePending}
 	}
-	silenceAPI := client.NewSilenceAPI(apiClient)
-	fetchedSilences, err := silenceAPI.List(context.Background(), filterString)
+	opts := client.ListOptions{Sort: c.sort, Limit: c.limit, Offset: c.offset, Suppressed: c.suppressed}
+	filterString, err := matcherGroupsFilter(c.matchers)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	fetchedSilences, total, err := silenceAPI.ListPage(context.Background(), filterString, states, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	displaySilences := []types.Silence{}
 	for _, silence := range fetchedSilences {
-		// skip expired silences if --expired is not set
-		if !c.expired && silence.EndsAt.Before(time.Now()) {
-			continue
-		}
-		// skip active silences if --expired is set
-		if c.expired && silence.EndsAt.After(time.Now()) {
-			continue
+		if filter.match(silence) {
+			displaySilences = append(displaySilences, *silence)
 		}
-		// skip active silences expiring after "--within"
-		if !c.expired && int64(c.within) > 0 && silence.EndsAt.After(time.Now().UTC().Add(c.within)) {
-			continue
-		}
-		// skip silences that expired before "--within"
-		if c.expired && int64(c.within) > 0 && silence.EndsAt.Before(time.Now().UTC().Add(-c.within)) {
-			continue
-		}
-
-		displaySilences = append(displaySilences, *silence)
 	}
 
-	if c.quiet {
+	if quiet {
 		for _, silence := range displaySilences {
-			fmt.Println(silence.ID)
+			fmt.Fprintln(w, silence.ID)
 		}
 	} else {
-		formatter, found := format.Formatters[output]
-		if !found {
-			return errors.New("unknown output formatter")
+		fields := c.fields
+		if fields == "" && c.suppressed && output != "json" && output != "yaml" {
+			fields = suppressedSilenceFields
 		}
-		formatter.FormatSilences(displaySilences)
+		formatter, err := fieldsFormatter(fields)
+		if err != nil {
+			return nil, err
+		}
+		if c.sort != "" {
+			keepOrder(formatter)
+		}
+		formatter.SetOutput(w)
+		defer formatter.SetOutput(os.Stdout)
+		if err := formatter.FormatSilences(displaySilences); err != nil {
+			return nil, err
+		}
+		printPageSummary(stderr, "silences", len(fetchedSilences), total)
+	}
+	if len(displaySilences) == 0 {
+		return nil, noMatchError("no silences matched")
+	}
+	return displaySilences, nil
+}
+
+// silenceWatchLabels describe the changes of the watched silences.
+var silenceWatchLabels = watchLabels{added: "created", removed: "expired"}
+
+// silenceWatchItems returns the watched silences by their IDs.
+func silenceWatchItems(silences []types.Silence) watchItems {
+	items := make(watchItems, len(silences))
+	for _, s := range silences {
+		items[s.ID] = fmt.Sprintf("%s %s", s.ID, s.Matchers)
 	}
-	return nil
+	return items
 }
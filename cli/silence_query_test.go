@@ -0,0 +1,151 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestSilenceFilter(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	silences := []*types.Silence{
+		{ID: "ending", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		{ID: "starting", StartsAt: now.Add(time.Hour), EndsAt: now.Add(5 * time.Hour)},
+		{ID: "long", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(24 * time.Hour)},
+		{ID: "later", StartsAt: now.Add(3 * time.Hour), EndsAt: now.Add(5 * time.Hour)},
+		{ID: "expired", StartsAt: now.Add(-3 * time.Hour), EndsAt: now.Add(-time.Hour)},
+		{ID: "expired-long-ago", StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour)},
+	}
+
+	for _, tc := range []struct {
+		filter   silenceFilter
+		expected []string
+	}{
+		{
+			filter:   silenceFilter{},
+			expected: []string{"ending", "starting", "long", "later"},
+		},
+		{
+			filter:   silenceFilter{within: 2 * time.Hour},
+			expected: []string{"ending", "starting"},
+		},
+		{
+			filter:   silenceFilter{endsAfter: now.Add(2 * time.Hour), endsBefore: now.Add(6 * time.Hour)},
+			expected: []string{"starting", "later"},
+		},
+		{
+			filter:   silenceFilter{endsBefore: now.Add(2 * time.Hour)},
+			expected: []string{"ending"},
+		},
+		{
+			filter:   silenceFilter{expired: true},
+			expected: []string{"expired", "expired-long-ago"},
+		},
+		{
+			filter:   silenceFilter{expired: true, within: 2 * time.Hour},
+			expected: []string{"expired"},
+		},
+		{
+			filter:   silenceFilter{expired: true, endsAfter: now.Add(-48 * time.Hour), endsBefore: now.Add(-2 * time.Hour)},
+			expected: []string{"expired-long-ago"},
+		},
+	} {
+		tc.filter.now = now
+		matched := []string{}
+		for _, s := range silences {
+			if tc.filter.match(s) {
+				matched = append(matched, s.ID)
+			}
+		}
+		if !reflect.DeepEqual(matched, tc.expected) {
+			t.Errorf("filter %+v: expected %v, got %v", tc.filter, tc.expected, matched)
+		}
+	}
+}
+
+func TestSilenceQueryAuthorComment(t *testing.T) {
+	defer func(q bool) { quiet = q }(quiet)
+	quiet = true
+
+	now := time.Now().UTC()
+	silenceAPI := &fakeSilenceAPI{silences: []*types.Silence{
+		{ID: "disk", CreatedBy: "alice", Comment: "Disk Maintenance", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		{ID: "deploy", CreatedBy: "bob", Comment: "Deployment of the API", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+	}}
+
+	for _, tc := range []struct {
+		args []string
+		// The author is the value of the flag, which defaults to the
+		// configured author if the flag is not given.
+		author   string
+		comment  string
+		expected []string
+		err      bool
+	}{
+		{author: "alice", expected: []string{"disk", "deploy"}},
+		{args: []string{"--author=alice"}, author: "alice", expected: []string{"disk"}},
+		{args: []string{"--author=carol"}, author: "carol", err: true},
+		{comment: "(?i)maintenance", expected: []string{"disk"}},
+		{comment: "maintenance", err: true},
+		{args: []string{"--author=bob"}, author: "bob", comment: "^Deploy", expected: []string{"deploy"}},
+	} {
+		app := kingpin.New("amtool", "")
+		configureSilenceQueryCmd(app.Command("silence", ""))
+		ctx, err := app.ParseContext(append([]string{"silence", "query"}, tc.args...))
+		if err != nil {
+			t.Fatalf("unexpected error parsing %v: %v", tc.args, err)
+		}
+
+		c := &silenceQueryCmd{author: tc.author}
+		if tc.comment != "" {
+			c.commentRegex = regexp.MustCompile(tc.comment)
+		}
+		silences, err := c.render(ctx, silenceAPI, ioutil.Discard, ioutil.Discard)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%v %q: expected no silences to match", tc.args, tc.comment)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v %q: unexpected error: %v", tc.args, tc.comment, err)
+			continue
+		}
+		ids := []string{}
+		for _, s := range silences {
+			ids = append(ids, s.ID)
+		}
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Errorf("%v %q: expected %v, got %v", tc.args, tc.comment, tc.expected, ids)
+		}
+	}
+}
+
+func TestSilenceQueryInvalidCommentRegex(t *testing.T) {
+	app := kingpin.New("amtool", "")
+	configureSilenceQueryCmd(app.Command("silence", ""))
+	_, err := app.Parse([]string{"silence", "query", "--comment-regex=(maintenance"})
+	if err == nil || !strings.Contains(err.Error(), "missing closing )") {
+		t.Errorf("expected an error for an invalid comment regex, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+type silenceTestCmd struct {
+	alertsFile string
+	fields     string
+	matchers   []string
+}
+
+const silenceTestHelp = `Test which alerts a silence would mute
+
+This command evaluates the matchers of a proposed silence against the current
+alerts and lists the alerts that would be muted, without adding the silence:
+
+amtool silence test 'alertname=~foo.*' cluster=eu
+
+	Lists the alerts of the Alertmanager that a silence with these matchers
+	would mute. Matchers are given like for the add command, values listing
+	alternatives in braces test one silence for each combination.
+
+amtool -o json alert query -a -s -i > alerts.json
+amtool silence test --alerts=alerts.json 'alertname=~foo.*'
+
+	Tests the matchers against a dump of alerts instead, e.g. taken before a
+	maintenance. The file is a JSON list of alerts with their labels like the
+	JSON output of the alert query command. No Alertmanager is needed.
+
+The command exits with status 3 if the silence would mute no alert.
+`
+
+func configureSilenceTestCmd(cc *kingpin.CmdClause) {
+	var (
+		c       = &silenceTestCmd{}
+		testCmd = cc.Command("test", silenceTestHelp)
+	)
+
+	alertsFlag := testCmd.Flag("alerts", "JSON file of alerts to test the silence against instead of the current alerts")
+	alertsFlag.ExistingFileVar(&c.alertsFile)
+	offlineFlags[alertsFlag] = true
+	testCmd.Flag("fields", "Comma-separated fields to output (not supported by json output)").StringVar(&c.fields)
+	testCmd.Arg("matcher-groups", "Matchers of the silence").Required().HintAction(completeLabelNames).StringsVar(&c.matchers)
+	testCmd.Action(c.test)
+}
+
+func (c *silenceTestCmd) test(ctx *kingpin.ParseContext) error {
+	silences, err := silenceMatchers(c.matchers)
+	if err != nil {
+		return err
+	}
+
+	var alerts []*client.ExtendedAlert
+	if c.alertsFile != "" {
+		alerts, err = readAlerts(c.alertsFile)
+	} else {
+		alerts, err = fetchAllAlerts()
+	}
+	if err != nil {
+		return err
+	}
+
+	muted := mutedAlerts(silences, alerts)
+	if quiet {
+		for _, a := range muted {
+			fmt.Println(a.Fingerprint)
+		}
+	} else {
+		formatter, err := fieldsFormatter(c.fields)
+		if err != nil {
+			return err
+		}
+		if err := formatter.FormatAlerts(muted); err != nil {
+			return err
+		}
+		printMutedSummary(os.Stderr, len(muted), len(alerts))
+	}
+	if len(muted) == 0 {
+		return noMatchError("the silence would mute no alerts")
+	}
+	return nil
+}
+
+// silenceMatchers returns the matchers of the silences the matcher
+// arguments describe, one for each combination of alternatives in braces.
+func silenceMatchers(args []string) ([]types.Matchers, error) {
+	groups := expandMatchers(args)
+	res := make([]types.Matchers, 0, len(groups))
+	for _, mg := range groups {
+		matchers, err := parseMatchers(mg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matchers) < 1 {
+			return nil, fmt.Errorf("no matchers specified")
+		}
+		typeMatchers, err := TypeMatchers(matchers)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range typeMatchers {
+			if err := m.Init(); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, typeMatchers)
+	}
+	return res, nil
+}
+
+// mutedAlerts returns the alerts matched by any of the silences.
+func mutedAlerts(silences []types.Matchers, alerts []*client.ExtendedAlert) []*client.ExtendedAlert {
+	res := []*client.ExtendedAlert{}
+	for _, a := range alerts {
+		lset := modelLabelSet(a.Labels)
+		for _, ms := range silences {
+			if ms.Match(lset) {
+				res = append(res, a)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func printMutedSummary(w io.Writer, muted, total int) {
+	fmt.Fprintf(w, "The silence would mute %d of %d alerts\n", muted, total)
+}
+
+// fetchAllAlerts returns the alerts of the Alertmanager in any state.
+func fetchAllAlerts() ([]*client.ExtendedAlert, error) {
+	c, err := NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.NewAlertAPI(c).List(context.Background(), "", "", true, true, true, true)
+}
+
+// readAlerts returns the alerts in the JSON file.
+func readAlerts(file string) ([]*client.ExtendedAlert, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var alerts []*client.ExtendedAlert
+	if err := json.NewDecoder(f).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("reading alerts from %s failed: %s", file, err)
+	}
+	return alerts, nil
+}
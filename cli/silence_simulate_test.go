@@ -0,0 +1,48 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+)
+
+func TestMutedAlerts(t *testing.T) {
+	alerts, err := readAlerts("testdata/alerts.routing.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		matchers []string
+		expected int
+	}{
+		{[]string{"HighLatency"}, 2},
+		{[]string{"alertname=~High.*", "team=frontend"}, 1},
+		{[]string{"alertname=HighLatency", "severity!=info"}, 1},
+		{[]string{"alertname=HighLatency", "team={frontend,backend}"}, 1},
+		{[]string{"alertname=~Low.*"}, 0},
+	} {
+		silences, err := silenceMatchers(tc.matchers)
+		if err != nil {
+			t.Fatalf("unexpected error for %v: %v", tc.matchers, err)
+		}
+		if got := len(mutedAlerts(silences, alerts)); got != tc.expected {
+			t.Errorf("expected %v to mute %d alerts, got %d", tc.matchers, tc.expected, got)
+		}
+	}
+
+	if _, err := silenceMatchers([]string{"alertname=~("}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/prometheus/client_golang/api"
 	"gopkg.in/alecthomas/kingpin.v2"
 
 	"github.com/prometheus/alertmanager/cli/format"
@@ -29,25 +28,48 @@ import (
 )
 
 type silenceUpdateCmd struct {
-	quiet    bool
 	duration string
 	start    string
 	end      string
+	expires  string
 	comment  string
+	metadata map[string]string
+	matchers []string
 	ids      []string
 }
 
+const silenceUpdateHelp = `Update alertmanager silences
+
+  The silences are fetched by their IDs and posted again with the given changes.
+  Silences whose end time, comment or metadata are changed keep their ID, other
+  changes expire the original silence and create a new one with a new ID.
+
+  amtool silence update --expires=4h 8f9a1e9c-1e45-4dc1-9a12-6b0cc1bb7acf
+
+	Extends the silence so that it ends in 4 hours.
+
+  amtool silence update --matcher='alertname=foo' --matcher='node=~bar.*' 8f9a1e9c-1e45-4dc1-9a12-6b0cc1bb7acf
+
+	Replaces the matchers of the silence.
+
+  amtool silence update --meta=ticket=OPS-124 --meta=team= 8f9a1e9c-1e45-4dc1-9a12-6b0cc1bb7acf
+
+	Sets the ticket metadata field of the silence and removes the team field.
+`
+
 func configureSilenceUpdateCmd(cc *kingpin.CmdClause) {
 	var (
 		c         = &silenceUpdateCmd{}
-		updateCmd = cc.Command("update", "Update silences")
+		updateCmd = cc.Command("update", silenceUpdateHelp)
 	)
-	updateCmd.Flag("quiet", "Only show silence ids").Short('q').BoolVar(&c.quiet)
 	updateCmd.Flag("duration", "Duration of silence").Short('d').StringVar(&c.duration)
 	updateCmd.Flag("start", "Set when the silence should start. RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&c.start)
 	updateCmd.Flag("end", "Set when the silence should end (overwrites duration). RFC3339 format 2006-01-02T15:04:05Z07:00").StringVar(&c.end)
+	updateCmd.Flag("expires", "Duration from now after which the silence should end (overwrites end and duration)").Short('e').StringVar(&c.expires)
 	updateCmd.Flag("comment", "A comment to help describe the silence").Short('c').StringVar(&c.comment)
-	updateCmd.Arg("update-ids", "Silence IDs to update").StringsVar(&c.ids)
+	updateCmd.Flag("meta", "Metadata field to set as name=value, an empty value removes the field, can be repeated").StringMapVar(&c.metadata)
+	updateCmd.Flag("matcher", "Matcher replacing the matchers of the silence, can be repeated").Short('m').StringsVar(&c.matchers)
+	updateCmd.Arg("update-ids", "Silence IDs to update").HintAction(completeSilenceIDs).StringsVar(&c.ids)
 
 	updateCmd.Action(c.update)
 }
@@ -57,68 +79,121 @@ func (c *silenceUpdateCmd) update(ctx *kingpin.ParseContext) error {
 		return fmt.Errorf("no silence IDs specified")
 	}
 
-	apiClient, err := api.NewClient(api.Config{Address: alertmanagerURL.String()})
+	var typeMatchers types.Matchers
+	if len(c.matchers) > 0 {
+		matchers, err := parseMatchers(c.matchers)
+		if err != nil {
+			return err
+		}
+		typeMatchers, err = TypeMatchers(matchers)
+		if err != nil {
+			return err
+		}
+	}
+
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	updatedSilences, err := c.updateSilences(client.NewSilenceAPI(apiClient), typeMatchers)
 	if err != nil {
 		return err
 	}
-	silenceAPI := client.NewSilenceAPI(apiClient)
 
+	if quiet {
+		for _, silence := range updatedSilences {
+			fmt.Println(silence.ID)
+		}
+	} else {
+		formatter, found := format.Formatters[output]
+		if !found {
+			return fmt.Errorf("unknown output formatter")
+		}
+		formatter.FormatSilences(updatedSilences)
+	}
+	return nil
+}
+
+// updateSilences fetches the silences, applies the changes and posts them
+// again. It returns the updated silences.
+func (c *silenceUpdateCmd) updateSilences(silenceAPI client.SilenceAPI, typeMatchers types.Matchers) ([]types.Silence, error) {
 	var updatedSilences []types.Silence
 	for _, silenceID := range c.ids {
 		silence, err := silenceAPI.Get(context.Background(), silenceID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if c.start != "" {
 			silence.StartsAt, err = time.Parse(time.RFC3339, c.start)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
-		if c.end != "" {
+		if c.expires != "" {
+			d, err := model.ParseDuration(c.expires)
+			if err != nil {
+				return nil, err
+			}
+			if d == 0 {
+				return nil, fmt.Errorf("silence expiry must be greater than 0")
+			}
+			silence.EndsAt = time.Now().UTC().Add(time.Duration(d))
+		} else if c.end != "" {
 			silence.EndsAt, err = time.Parse(time.RFC3339, c.end)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		} else if c.duration != "" {
 			d, err := model.ParseDuration(c.duration)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if d == 0 {
-				return fmt.Errorf("silence duration must be greater than 0")
+				return nil, fmt.Errorf("silence duration must be greater than 0")
 			}
 			silence.EndsAt = silence.StartsAt.UTC().Add(time.Duration(d))
 		}
 
 		if silence.StartsAt.After(silence.EndsAt) {
-			return errors.New("silence cannot start after it ends")
+			return nil, errors.New("silence cannot start after it ends")
 		}
 
 		if c.comment != "" {
 			silence.Comment = c.comment
 		}
+		if typeMatchers != nil {
+			silence.Matchers = typeMatchers
+		}
+		silence.Metadata = updateMetadata(silence.Metadata, c.metadata)
 
 		newID, err := silenceAPI.Set(context.Background(), *silence)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		silence.ID = newID
 
 		updatedSilences = append(updatedSilences, *silence)
 	}
+	return updatedSilences, nil
+}
 
-	if c.quiet {
-		for _, silence := range updatedSilences {
-			fmt.Println(silence.ID)
-		}
-	} else {
-		formatter, found := format.Formatters[output]
-		if !found {
-			return fmt.Errorf("unknown output formatter")
+// updateMetadata returns the metadata with the fields set to the given
+// values, removing the fields whose value is empty.
+func updateMetadata(md, fields map[string]string) map[string]string {
+	if len(fields) == 0 {
+		return md
+	}
+	res := make(map[string]string, len(md)+len(fields))
+	for k, v := range md {
+		res[k] = v
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(res, k)
+			continue
 		}
-		formatter.FormatSilences(updatedSilences)
+		res[k] = v
 	}
-	return nil
+	return res
 }
@@ -0,0 +1,151 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// storeSilenceAPI returns a fixed silence and records the silences set.
+type storeSilenceAPI struct {
+	client.SilenceAPI
+	silence types.Silence
+	set     []types.Silence
+}
+
+func (s *storeSilenceAPI) Get(context.Context, string) (*types.Silence, error) {
+	silence := s.silence
+	return &silence, nil
+}
+
+func (s *storeSilenceAPI) Set(_ context.Context, silence types.Silence) (string, error) {
+	s.set = append(s.set, silence)
+	return "new", nil
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		md, fields, expected map[string]string
+	}{
+		{
+			md:       map[string]string{"ticket": "OPS-1"},
+			expected: map[string]string{"ticket": "OPS-1"},
+		},
+		{
+			fields:   map[string]string{"ticket": "OPS-1"},
+			expected: map[string]string{"ticket": "OPS-1"},
+		},
+		{
+			md:       map[string]string{"ticket": "OPS-1", "team": "storage"},
+			fields:   map[string]string{"ticket": "OPS-2", "team": ""},
+			expected: map[string]string{"ticket": "OPS-2"},
+		},
+	} {
+		if md := updateMetadata(tc.md, tc.fields); !reflect.DeepEqual(md, tc.expected) {
+			t.Errorf("expected %v, got %v", tc.expected, md)
+		}
+	}
+}
+
+func TestSilenceUpdate(t *testing.T) {
+	start := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	silence := types.Silence{
+		ID:        "old",
+		Matchers:  types.Matchers{{Name: "alertname", Value: "DiskFull"}},
+		StartsAt:  start,
+		EndsAt:    start.Add(time.Hour),
+		CreatedBy: "alice",
+		Comment:   "Disk replacement",
+		Metadata:  map[string]string{"ticket": "OPS-1"},
+	}
+	matchers := types.Matchers{{Name: "alertname", Value: "Disk.*", IsRegex: true}}
+
+	for _, tc := range []struct {
+		cmd      silenceUpdateCmd
+		matchers types.Matchers
+		expected func(s *types.Silence)
+	}{
+		{
+			cmd:      silenceUpdateCmd{},
+			expected: func(s *types.Silence) {},
+		},
+		{
+			cmd: silenceUpdateCmd{comment: "Disk replacement, second attempt"},
+			expected: func(s *types.Silence) {
+				s.Comment = "Disk replacement, second attempt"
+			},
+		},
+		{
+			cmd: silenceUpdateCmd{duration: "2h"},
+			expected: func(s *types.Silence) {
+				s.EndsAt = start.Add(2 * time.Hour)
+			},
+		},
+		{
+			cmd: silenceUpdateCmd{end: "2018-06-01T15:00:00Z", metadata: map[string]string{"ticket": "", "team": "storage"}},
+			expected: func(s *types.Silence) {
+				s.EndsAt = start.Add(3 * time.Hour)
+				s.Metadata = map[string]string{"team": "storage"}
+			},
+		},
+		{
+			cmd:      silenceUpdateCmd{},
+			matchers: matchers,
+			expected: func(s *types.Silence) {
+				s.Matchers = matchers
+			},
+		},
+	} {
+		api := &storeSilenceAPI{silence: silence}
+		tc.cmd.ids = []string{"old"}
+		updated, err := tc.cmd.updateSilences(api, tc.matchers)
+		if err != nil {
+			t.Errorf("%+v: unexpected error: %v", tc.cmd, err)
+			continue
+		}
+
+		expected := silence
+		tc.expected(&expected)
+		if len(api.set) != 1 || !reflect.DeepEqual(api.set[0], expected) {
+			t.Errorf("%+v: expected %+v to be sent, got %+v", tc.cmd, expected, api.set)
+		}
+		expected.ID = "new"
+		if len(updated) != 1 || !reflect.DeepEqual(updated[0], expected) {
+			t.Errorf("%+v: expected %+v to be returned, got %+v", tc.cmd, expected, updated)
+		}
+	}
+}
+
+func TestSilenceUpdateExpires(t *testing.T) {
+	now := time.Now().UTC()
+	api := &storeSilenceAPI{silence: types.Silence{ID: "old", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}}
+	cmd := silenceUpdateCmd{ids: []string{"old"}, expires: "4h"}
+	if _, err := cmd.updateSilences(api, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if end := api.set[0].EndsAt; end.Before(now.Add(4*time.Hour)) || end.After(time.Now().Add(4*time.Hour)) {
+		t.Errorf("expected the silence to end in 4h, got %v", end)
+	}
+
+	cmd = silenceUpdateCmd{ids: []string{"old"}, end: now.Add(-2 * time.Hour).Format(time.RFC3339)}
+	if _, err := cmd.updateSilences(api, nil); err == nil {
+		t.Errorf("expected an error for a silence ending before it starts")
+	}
+}
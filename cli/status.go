@@ -0,0 +1,93 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/cli/format"
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/config"
+)
+
+const statusHelp = `Show the status of the Alertmanager.
+
+Prints the version and uptime of the Alertmanager, the state of its cluster and
+a summary of its configuration as a quick health check. The configuration hash
+is the same for Alertmanagers running the same configuration, e.g. to verify
+that a new configuration was loaded by all peers of a cluster.
+
+The amount of output is controlled by the output selection flag:
+	- Simple: Print the summary
+	- Extended: Print the summary with the build information and the cluster peers
+	- Json: Print the summary as json
+`
+
+func configureStatusCmd(app *kingpin.Application) {
+	app.Command("status", statusHelp).PreAction(requireAlertManagerURL).Action(queryStatus)
+}
+
+func queryStatus(ctx *kingpin.ParseContext) error {
+	c, err := NewAPIClient()
+	if err != nil {
+		return err
+	}
+	status, err := client.NewStatusAPI(c).Get(context.Background())
+	if err != nil {
+		return err
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	return formatter.FormatStatus(summarizeStatus(status))
+}
+
+// summarizeStatus returns the summary of the server status.
+func summarizeStatus(status *client.ServerStatus) *format.Status {
+	sum := sha256.Sum256([]byte(status.ConfigYAML))
+	s := &format.Status{
+		VersionInfo: status.VersionInfo,
+		StartTime:   status.Uptime,
+		Cluster:     status.ClusterStatus,
+		ConfigHash:  hex.EncodeToString(sum[:6]),
+	}
+	if cfg := status.ConfigJSON; cfg != nil {
+		s.Config = &format.ConfigSummary{
+			Receivers:         len(cfg.Receivers),
+			InhibitRules:      len(cfg.InhibitRules),
+			MuteTimeIntervals: len(cfg.MuteTimeIntervals),
+			Templates:         len(cfg.Templates),
+		}
+		if cfg.Route != nil {
+			s.Config.Routes = countRoutes(cfg.Route.Routes)
+		}
+	}
+	return s
+}
+
+// countRoutes returns the number of the routes and their descendants.
+func countRoutes(routes []*config.Route) int {
+	n := len(routes)
+	for _, r := range routes {
+		n += countRoutes(r.Routes)
+	}
+	return n
+}
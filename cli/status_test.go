@@ -0,0 +1,87 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/config"
+)
+
+func TestSummarizeStatus(t *testing.T) {
+	cfg, err := config.Load(`
+route:
+  receiver: default
+  routes:
+  - match:
+      team: a
+    receiver: team-a
+    routes:
+    - match:
+        severity: critical
+      receiver: team-a
+  - match:
+      team: b
+    receiver: default
+receivers:
+- name: default
+- name: team-a
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    severity: warning
+templates:
+- '*.tmpl'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The status is decoded like it is received from the API.
+	b, err := json.Marshal(map[string]interface{}{
+		"configYAML": cfg.String(),
+		"configJSON": cfg,
+		"versionInfo": map[string]string{
+			"version": "0.15.0",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status client.ServerStatus
+	if err := json.Unmarshal(b, &status); err != nil {
+		t.Fatal(err)
+	}
+
+	s := summarizeStatus(&status)
+	if s.VersionInfo["version"] != "0.15.0" {
+		t.Errorf("Unexpected version info %v", s.VersionInfo)
+	}
+	if len(s.ConfigHash) != 12 {
+		t.Errorf("Expected config hash of 12 characters, got %q", s.ConfigHash)
+	}
+	if s.Config == nil {
+		t.Fatalf("Expected config summary")
+	}
+	if c := *s.Config; c.Receivers != 2 || c.Routes != 3 || c.InhibitRules != 1 || c.MuteTimeIntervals != 0 || c.Templates != 1 {
+		t.Errorf("Unexpected config summary %+v", c)
+	}
+
+	status.ConfigYAML += "\n"
+	if summarizeStatus(&status).ConfigHash == s.ConfigHash {
+		t.Errorf("Expected config hash to change with the config")
+	}
+}
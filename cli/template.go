@@ -0,0 +1,168 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+type templateRenderCmd struct {
+	name          string
+	templateFiles []string
+	data          string
+	receiver      string
+	groupLabels   []string
+	html          bool
+}
+
+const templateHelp = `Render notification templates.
+
+Templates are rendered with sample alerts instead of waiting for a real alert
+to fire. Nothing is sent to any receiver.
+
+amtool template render --template.file=slack.tmpl --data='{"labels": {"alertname": "foo"}}' slack.custom.title
+
+	Renders the template slack.custom.title defined in slack.tmpl for a
+	firing alert with the label alertname=foo. The data is an alert or a list
+	of alerts in the format of the alerts API.
+
+amtool template render --alertmanager.url=http://localhost:9093 --data='{"labels": {"alertname": "foo"}}' slack.custom.title
+
+	Without template files, the template is rendered by the Alertmanager with
+	the templates of its running configuration.
+`
+
+func configureTemplateCmd(app *kingpin.Application) {
+	var (
+		c           = &templateRenderCmd{}
+		templateCmd = app.Command("template", templateHelp)
+		renderCmd   = templateCmd.Command("render", templateHelp)
+	)
+	renderCmd.Flag("template.file", "Template files to render the template from instead of the running configuration, can be repeated").ExistingFilesVar(&c.templateFiles)
+	renderCmd.Flag("data", "Alert or list of alerts as JSON").Required().StringVar(&c.data)
+	renderCmd.Flag("receiver", "Name of the receiver").StringVar(&c.receiver)
+	renderCmd.Flag("group-label", "Group label as name=value pair, can be repeated").StringsVar(&c.groupLabels)
+	renderCmd.Flag("html", "Render the HTML template instead of the text template").BoolVar(&c.html)
+	renderCmd.Arg("name", "Name of the template").Required().StringVar(&c.name)
+	renderCmd.Action(c.render)
+}
+
+// parseAlerts parses a single alert or a list of alerts.
+func parseAlerts(data string) ([]client.Alert, error) {
+	var alerts []client.Alert
+	if strings.HasPrefix(strings.TrimSpace(data), "[") {
+		if err := json.Unmarshal([]byte(data), &alerts); err != nil {
+			return nil, err
+		}
+		return alerts, nil
+	}
+	var a client.Alert
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		return nil, err
+	}
+	return append(alerts, a), nil
+}
+
+func modelLabelSet(ls client.LabelSet) model.LabelSet {
+	res := make(model.LabelSet, len(ls))
+	for k, v := range ls {
+		res[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return res
+}
+
+func (c *templateRenderCmd) render(ctx *kingpin.ParseContext) error {
+	return c.run(os.Stdout)
+}
+
+func (c *templateRenderCmd) run(w io.Writer) error {
+	alerts, err := parseAlerts(c.data)
+	if err != nil {
+		return fmt.Errorf("invalid alert data: %s", err)
+	}
+	groupLabels, err := parseLabelPairs(c.groupLabels)
+	if err != nil {
+		return err
+	}
+
+	var res string
+	if len(c.templateFiles) > 0 {
+		res, err = c.renderLocal(alerts, groupLabels)
+	} else {
+		res, err = c.renderRemote(alerts, groupLabels)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, res)
+	return nil
+}
+
+func (c *templateRenderCmd) renderLocal(alerts []client.Alert, groupLabels client.LabelSet) (string, error) {
+	tmpl, err := template.FromGlobs(c.templateFiles...)
+	if err != nil {
+		return "", err
+	}
+	tmpl.ExternalURL = &url.URL{}
+	if alertmanagerURL != nil {
+		tmpl.ExternalURL = alertmanagerURL
+	}
+
+	as := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		as = append(as, &types.Alert{Alert: model.Alert{
+			Labels:       modelLabelSet(a.Labels),
+			Annotations:  modelLabelSet(a.Annotations),
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		}})
+	}
+	data := tmpl.Data(c.receiver, modelLabelSet(groupLabels), as...)
+	if c.html {
+		return tmpl.ExecuteHTMLTemplate(c.name, data)
+	}
+	return tmpl.ExecuteTextTemplate(c.name, data)
+}
+
+func (c *templateRenderCmd) renderRemote(alerts []client.Alert, groupLabels client.LabelSet) (string, error) {
+	if alertmanagerURL == nil {
+		return "", errors.New("either --template.file or --alertmanager.url must be given")
+	}
+	apiClient, err := NewAPIClient()
+	if err != nil {
+		return "", err
+	}
+	return client.NewTemplateAPI(apiClient).Render(context.Background(), client.RenderRequest{
+		Name:        c.name,
+		HTML:        c.html,
+		Receiver:    c.receiver,
+		GroupLabels: groupLabels,
+		Alerts:      alerts,
+	})
+}
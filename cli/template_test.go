@@ -0,0 +1,64 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplateRender(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     string
+		rendered string
+		err      bool
+	}{
+		{
+			name:     "test.title",
+			data:     `{"labels": {"alertname": "foo", "instance": "a"}}`,
+			rendered: "[firing] foo on a \n",
+		},
+		{
+			name:     "test.title",
+			data:     `[{"labels": {"alertname": "foo", "instance": "a"}}, {"labels": {"alertname": "foo", "instance": "b"}, "endsAt": "2018-01-01T00:00:00Z"}]`,
+			rendered: "[firing] foo on a b \n",
+		},
+		{name: "test.title", data: `{"labels": `, err: true},
+		{name: "test.missing", data: `{"labels": {"alertname": "foo"}}`, err: true},
+		{name: "test.broken", data: `{"labels": {"alertname": "foo"}}`, err: true},
+	} {
+		c := &templateRenderCmd{
+			name:          tc.name,
+			templateFiles: []string{"testdata/test.tmpl"},
+			data:          tc.data,
+			groupLabels:   []string{"alertname=foo"},
+		}
+		var buf bytes.Buffer
+		err := c.run(&buf)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got none", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if buf.String() != tc.rendered {
+			t.Errorf("%s: want %q, got %q", tc.name, tc.rendered, buf.String())
+		}
+	}
+}
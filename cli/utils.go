@@ -14,10 +14,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"path"
+	"strings"
 
+	"github.com/prometheus/alertmanager/cli/format"
 	"github.com/prometheus/alertmanager/pkg/parse"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
@@ -45,14 +49,19 @@ func GetAlertmanagerURL(p string) url.URL {
 	return amURL
 }
 
-// Parse a list of labels (cli arguments)
+// parseMatchers parses the matcher arguments of a command. If the first
+// argument has no operator, it is the value of the alertname label, e.g.
+// "amtool silence add Foo".
 func parseMatchers(inputLabels []string) ([]labels.Matcher, error) {
 	matchers := make([]labels.Matcher, 0)
 
-	for _, v := range inputLabels {
+	for i, v := range inputLabels {
 		name, value, matchType, err := parse.Input(v)
 		if err != nil {
-			return []labels.Matcher{}, err
+			if i > 0 || strings.ContainsAny(v, "=!~") {
+				return []labels.Matcher{}, err
+			}
+			name, value, matchType = string(model.AlertNameLabel), v, labels.MatchEqual
 		}
 
 		matchers = append(matchers, labels.Matcher{
@@ -65,6 +74,24 @@ func parseMatchers(inputLabels []string) ([]labels.Matcher, error) {
 	return matchers, nil
 }
 
+// matcherGroupsFilter returns the filter of the alerts and silences APIs for
+// the matcher arguments of a command. The matchers are formatted again, so
+// that values with spaces, commas or braces reach the Alertmanager quoted.
+func matcherGroupsFilter(matcherGroups []string) (string, error) {
+	matchers, err := parseMatchers(matcherGroups)
+	if err != nil {
+		return "", err
+	}
+	if len(matchers) == 0 {
+		return "", nil
+	}
+	parts := make([]string, 0, len(matchers))
+	for i := range matchers {
+		parts = append(parts, parse.FormatMatcher(&matchers[i]))
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
 // Only valid for when you are going to add a silence
 func TypeMatchers(matchers []labels.Matcher) (types.Matchers, error) {
 	typeMatchers := types.Matchers{}
@@ -79,7 +106,6 @@ func TypeMatchers(matchers []labels.Matcher) (types.Matchers, error) {
 }
 
 // Only valid for when you are going to add a silence
-// Doesn't allow negative operators
 func TypeMatcher(matcher labels.Matcher) (types.Matcher, error) {
 	typeMatcher := types.NewMatcher(model.LabelName(matcher.Name), matcher.Value)
 
@@ -88,8 +114,53 @@ func TypeMatcher(matcher labels.Matcher) (types.Matcher, error) {
 		typeMatcher.IsRegex = false
 	case labels.MatchRegexp:
 		typeMatcher.IsRegex = true
+	case labels.MatchNotEqual:
+		typeMatcher.IsNegative = true
+	case labels.MatchNotRegexp:
+		typeMatcher.IsRegex = true
+		typeMatcher.IsNegative = true
 	default:
 		return types.Matcher{}, fmt.Errorf("invalid match type for creation operation: %s", matcher.Type)
 	}
 	return *typeMatcher, nil
 }
+
+// keepOrder makes the formatter output alerts and silences in the order
+// sorted by the Alertmanager if it supports it.
+func keepOrder(formatter format.Formatter) {
+	if of, ok := formatter.(format.OrderFormatter); ok {
+		of.KeepOrder()
+	}
+}
+
+// printPageSummary notes on the writer, usually standard error, that the
+// output is a page of a total number of results.
+func printPageSummary(w io.Writer, kind string, n, total int) {
+	if n < total && !quiet {
+		fmt.Fprintf(w, "Showing %d of %d %s, use --offset and --limit for more\n", n, total, kind)
+	}
+}
+
+// fieldsFormatter returns the formatter of the output selecting the fields
+// given as a comma-separated list. An empty list keeps the default fields.
+func fieldsFormatter(fields string) (format.Formatter, error) {
+	formatter, found := format.Formatters[output]
+	if !found {
+		return nil, errors.New("unknown output formatter")
+	}
+	if fields == "" {
+		return formatter, nil
+	}
+	ff, ok := formatter.(format.FieldsFormatter)
+	if !ok {
+		return nil, fmt.Errorf("--fields is not supported by the %s output", output)
+	}
+	var names []string
+	for _, name := range strings.Split(fields, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	ff.SetFields(names)
+	return formatter, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+// ANSI escape sequences of the watch mode.
+const (
+	clearScreen = "\033[H\033[2J"
+	colorRed    = "\033[1;31m"
+	colorGreen  = "\033[1;32m"
+	colorReset  = "\033[0m"
+)
+
+// watchItems are the items displayed by a watched query, e.g. alerts, by
+// a unique key with a short description.
+type watchItems map[string]string
+
+// watchLabels name the items that appeared and disappeared since the last
+// refresh of a watched query.
+type watchLabels struct {
+	added, removed string
+}
+
+// watch renders the output of a query every interval on a cleared screen
+// until amtool is interrupted. The items appearing and disappearing between
+// two refreshes are highlighted above the output. A failing first rendering
+// is returned, later failures are shown and retried with the next refresh.
+func watch(interval time.Duration, labels watchLabels, render func(w io.Writer) (watchItems, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("--watch-interval must be positive")
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	var prev watchItems
+	for {
+		var body bytes.Buffer
+		items, err := render(&body)
+		if _, ok := err.(noMatchError); ok {
+			err = nil
+		}
+		if err != nil && prev == nil {
+			return err
+		}
+
+		var frame bytes.Buffer
+		fmt.Fprint(&frame, clearScreen)
+		fmt.Fprintf(&frame, "Every %s: amtool %s  %s\n\n", interval, strings.Join(os.Args[1:], " "), format.FormatDate(time.Now()))
+		if err != nil {
+			// Keep the previous items to highlight the changes since the
+			// last successful refresh.
+			fmt.Fprintf(&frame, "%serror: %v%s\n", colorRed, err, colorReset)
+		} else {
+			if prev != nil {
+				writeWatchChanges(&frame, labels, prev, items)
+			}
+			prev = items
+			frame.Write(body.Bytes())
+		}
+		os.Stdout.Write(frame.Bytes())
+
+		select {
+		case <-sigc:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// writeWatchChanges writes the items added since the previous refresh in red
+// and the removed ones in green, followed by an empty line if there are any.
+func writeWatchChanges(w io.Writer, labels watchLabels, prev, cur watchItems) {
+	var added, removed []string
+	for k, desc := range cur {
+		if _, ok := prev[k]; !ok {
+			added = append(added, desc)
+		}
+	}
+	for k, desc := range prev {
+		if _, ok := cur[k]; !ok {
+			removed = append(removed, desc)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	for _, desc := range added {
+		fmt.Fprintf(w, "%s+ %s: %s%s\n", colorRed, labels.added, desc, colorReset)
+	}
+	for _, desc := range removed {
+		fmt.Fprintf(w, "%s- %s: %s%s\n", colorGreen, labels.removed, desc, colorReset)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,37 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteWatchChanges(t *testing.T) {
+	prev := watchItems{"a": `{alertname="A"}`, "b": `{alertname="B"}`}
+
+	var buf bytes.Buffer
+	writeWatchChanges(&buf, alertWatchLabels, prev, watchItems{"a": `{alertname="A"}`, "b": `{alertname="B"}`})
+	if buf.Len() != 0 {
+		t.Fatalf("expected no changes, got %q", buf.String())
+	}
+
+	writeWatchChanges(&buf, alertWatchLabels, prev, watchItems{"a": `{alertname="A"}`, "d": `{alertname="D"}`, "c": `{alertname="C"}`})
+	exp := colorRed + `+ firing: {alertname="C"}` + colorReset + "\n" +
+		colorRed + `+ firing: {alertname="D"}` + colorReset + "\n" +
+		colorGreen + `- resolved: {alertname="B"}` + colorReset + "\n\n"
+	if buf.String() != exp {
+		t.Fatalf("expected changes\n%q\ngot\n%q", exp, buf.String())
+	}
+}
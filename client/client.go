@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -29,18 +31,104 @@ import (
 )
 
 const (
-	apiPrefix = "/api/v1"
-
-	epStatus      = apiPrefix + "/status"
-	epSilence     = apiPrefix + "/silence/:id"
-	epSilences    = apiPrefix + "/silences"
-	epAlerts      = apiPrefix + "/alerts"
-	epAlertGroups = apiPrefix + "/alerts/groups"
+	apiPrefix   = "/api/v1"
+	apiV2Prefix = "/api/v2"
+
+	epClusterStatus = apiPrefix + "/cluster/status"
+	epSnapshot      = apiPrefix + "/cluster/snapshot"
+	epConfig        = apiPrefix + "/config"
+	epRender        = apiPrefix + "/templates/render"
+	epReceiverTest  = apiPrefix + "/receivers/test"
+	epReceiverState = apiPrefix + "/receivers"
+	epAlertGroups   = apiPrefix + "/alerts/groups"
+	epGroupTimers   = apiPrefix + "/alerts/groups/timers"
+	epAlertHistory  = apiPrefix + "/alerts/history"
+	epInhibitions   = apiPrefix + "/alerts/inhibitions"
+	epAlertsResolve = apiPrefix + "/alerts/resolve"
+	epAck           = apiPrefix + "/ack/:fingerprint"
+	epAcks          = apiPrefix + "/acks"
+
+	// The endpoints of the second API version are described by the OpenAPI
+	// specification in api/openapi.yaml.
+	epStatus            = apiV2Prefix + "/status"
+	epReceivers         = apiV2Prefix + "/receivers"
+	epSilence           = apiV2Prefix + "/silence/:id"
+	epSilences          = apiV2Prefix + "/silences"
+	epSilencesBulk      = apiV2Prefix + "/silences/bulk"
+	epAlerts            = apiV2Prefix + "/alerts"
+	epAggregationGroups = apiV2Prefix + "/alerts/groups"
 
 	statusSuccess = "success"
 	statusError   = "error"
+
+	totalCountHeader = "X-Total-Count"
 )
 
+// ListOptions sorts and paginates the results of list requests.
+type ListOptions struct {
+	// Sort is the field to sort by, prefixed with "-" for descending order.
+	// Empty means the default order of the API.
+	Sort string
+	// Limit is the maximum number of results, zero means unlimited.
+	Limit int
+	// Offset is the number of results to skip.
+	Offset int
+	// Suppressed requests the alerts each silence currently suppresses. It
+	// only applies to silences.
+	Suppressed bool
+}
+
+// addTo adds the options to the query parameters.
+func (o ListOptions) addTo(params url.Values) {
+	if o.Sort != "" {
+		params.Add("sort", o.Sort)
+	}
+	if o.Limit > 0 {
+		params.Add("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		params.Add("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Suppressed {
+		params.Add("suppressed", "true")
+	}
+}
+
+// totalCount returns the number of results of a list request before
+// pagination, defaulting to n for Alertmanagers that do not report it.
+func totalCount(resp *http.Response, n int) int {
+	if resp != nil {
+		if total, err := strconv.Atoi(resp.Header.Get(totalCountHeader)); err == nil {
+			return total
+		}
+	}
+	return n
+}
+
+// decodeEach calls f with the decoder positioned at each element of the JSON
+// array in data, so that the elements are decoded one at a time. A null
+// array has no elements.
+func decodeEach(data []byte, f func(*json.Decoder) error) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("expected JSON array, got %v", tok)
+	}
+	for dec.More() {
+		if err := f(dec); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
 // ServerStatus represents the status of the AlertManager endpoint.
 type ServerStatus struct {
 	ConfigYAML    string            `json:"configYAML"`
@@ -54,13 +142,20 @@ type ServerStatus struct {
 type PeerStatus struct {
 	Name    string `json:"name"`
 	Address string `json:"address"`
+	// LastSeen is the time the peer last answered a probe. It is nil if the
+	// peer was not seen yet.
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+	Zone     string     `json:"zone,omitempty"`
+	Region   string     `json:"region,omitempty"`
 }
 
 // ClusterStatus represents the status of the cluster.
 type ClusterStatus struct {
-	Name   string       `json:"name"`
-	Status string       `json:"status"`
-	Peers  []PeerStatus `json:"peers"`
+	Name              string       `json:"name"`
+	Status            string       `json:"status"`
+	Peers             []PeerStatus `json:"peers"`
+	MessagesQueued    int          `json:"messagesQueued"`
+	OversizedMessages int          `json:"oversizedMessages"`
 }
 
 // apiClient wraps a regular client and processes successful API responses.
@@ -85,8 +180,20 @@ func (e *clientError) Error() string {
 	return fmt.Sprintf("%s (code: %d)", e.msg, e.code)
 }
 
+// StatusCode returns the HTTP status code of the response.
+func (e *clientError) StatusCode() int {
+	return e.code
+}
+
 func (c apiClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
 	resp, body, err := c.Client.Do(ctx, req)
+	if err == nil && resp.StatusCode == http.StatusNotFound && !json.Valid(body) {
+		// Alertmanagers that predate the second API version answer its
+		// endpoints with the plain text 404 of their router.
+		if v1, ok := v1Request(req); ok {
+			resp, body, err = c.Client.Do(ctx, v1)
+		}
+	}
 	if err != nil {
 		return resp, body, err
 	}
@@ -120,6 +227,34 @@ func (c apiClient) Do(ctx context.Context, req *http.Request) (*http.Response, [
 	return resp, []byte(result.Data), err
 }
 
+// v1Request returns the request to the first API version that is
+// equivalent to a request to the second one. Only the endpoints of the
+// second version that were moved from the first one have an equivalent.
+func v1Request(req *http.Request) (*http.Request, bool) {
+	i := strings.LastIndex(req.URL.Path, apiV2Prefix+"/")
+	if i < 0 || req.URL.Path[i:] == epAggregationGroups {
+		return nil, false
+	}
+
+	u := *req.URL
+	u.Path = req.URL.Path[:i] + apiPrefix + req.URL.Path[i+len(apiV2Prefix):]
+	u.RawPath = ""
+
+	v1 := req.WithContext(req.Context())
+	v1.URL = &u
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return nil, false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		v1.Body = body
+	}
+	return v1, true
+}
+
 // StatusAPI provides bindings for the Alertmanager's status API.
 type StatusAPI interface {
 	// Get returns the server's configuration, version, uptime and cluster information.
@@ -151,12 +286,317 @@ func (h *httpStatusAPI) Get(ctx context.Context) (*ServerStatus, error) {
 	return ss, err
 }
 
+// ConfigAPI provides bindings for the Alertmanager's configuration API.
+type ConfigAPI interface {
+	// Push validates the configuration file content and replaces the
+	// configuration of the Alertmanager with it unless dryRun is set.
+	Push(ctx context.Context, content string, dryRun bool) (*ConfigPushResult, error)
+}
+
+// ConfigPushResult is the result of pushing a configuration.
+type ConfigPushResult struct {
+	// Diff is the unified diff between the running and the pushed
+	// configuration.
+	Diff    string `json:"diff"`
+	Applied bool   `json:"applied"`
+}
+
+// NewConfigAPI returns a configuration API client.
+func NewConfigAPI(c api.Client) ConfigAPI {
+	return &httpConfigAPI{client: apiClient{c}}
+}
+
+type httpConfigAPI struct {
+	client api.Client
+}
+
+func (h *httpConfigAPI) Push(ctx context.Context, content string, dryRun bool) (*ConfigPushResult, error) {
+	u := h.client.URL(epConfig, nil)
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(struct {
+		Config string `json:"config"`
+		DryRun bool   `json:"dryRun"`
+	}{
+		Config: content,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *ConfigPushResult
+	err = json.Unmarshal(body, &res)
+
+	return res, err
+}
+
+// ClusterAPI provides bindings for the Alertmanager's cluster API.
+type ClusterAPI interface {
+	// Status returns the peers of the cluster and the gossip health as seen
+	// by the Alertmanager.
+	Status(ctx context.Context) (*ClusterStatus, error)
+	// Snapshot returns a JSON snapshot of the replicated state of the
+	// Alertmanager, i.e. its silences, notification log, acknowledgements
+	// and message references.
+	Snapshot(ctx context.Context) (json.RawMessage, error)
+	// Restore merges a snapshot into the replicated state of the
+	// Alertmanager and returns the keys of the restored states.
+	Restore(ctx context.Context, snapshot []byte) ([]string, error)
+}
+
+// NewClusterAPI returns a cluster API client.
+func NewClusterAPI(c api.Client) ClusterAPI {
+	return &httpClusterAPI{client: apiClient{c}}
+}
+
+type httpClusterAPI struct {
+	client api.Client
+}
+
+func (h *httpClusterAPI) Status(ctx context.Context) (*ClusterStatus, error) {
+	u := h.client.URL(epClusterStatus, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var cs *ClusterStatus
+	err = json.Unmarshal(body, &cs)
+
+	return cs, err
+}
+
+func (h *httpClusterAPI) Snapshot(ctx context.Context) (json.RawMessage, error) {
+	u := h.client.URL(epSnapshot, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	return body, err
+}
+
+func (h *httpClusterAPI) Restore(ctx context.Context, snapshot []byte) ([]string, error) {
+	u := h.client.URL(epSnapshot, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(snapshot))
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Restored []string `json:"restored"`
+	}
+	err = json.Unmarshal(body, &res)
+
+	return res.Restored, err
+}
+
+// ReceiverAPI provides bindings for the Alertmanager's receiver API.
+type ReceiverAPI interface {
+	// List returns the names of the receivers of the configuration.
+	List(ctx context.Context) ([]string, error)
+	// Test sends a test notification through the integrations of a receiver
+	// and returns the result of each notification.
+	Test(ctx context.Context, r ReceiverTestRequest) ([]*IntegrationTestResult, error)
+	// Status returns the receivers of the configuration with the results
+	// of the last notifications of their integrations.
+	Status(ctx context.Context) ([]*ReceiverStatus, error)
+}
+
+// ReceiverStatus is a receiver with the status of its integrations.
+type ReceiverStatus struct {
+	Name         string              `json:"name"`
+	Integrations []IntegrationStatus `json:"integrations"`
+}
+
+// IntegrationStatus is the result of the last notification of an
+// integration of a receiver. The times are nil if there was no such
+// notification.
+type IntegrationStatus struct {
+	Name        string     `json:"name"`
+	Index       int        `json:"index"`
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	// LastError is the error of the last failed notification, which may
+	// have been followed by successful ones.
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	// FailingSince is the time of the first failed notification since the
+	// last successful one.
+	FailingSince *time.Time `json:"failingSince,omitempty"`
+}
+
+// ReceiverTestRequest selects the receiver to test and the labels and
+// annotations added to the test alert.
+type ReceiverTestRequest struct {
+	Receiver    string   `json:"receiver"`
+	Labels      LabelSet `json:"labels,omitempty"`
+	Annotations LabelSet `json:"annotations,omitempty"`
+	// Resolve sends a resolved notification after the firing one.
+	Resolve bool `json:"resolve"`
+}
+
+// IntegrationTestResult is the result of a test notification of an
+// integration of a receiver.
+type IntegrationTestResult struct {
+	Integration string  `json:"integration"`
+	Index       int     `json:"index"`
+	Status      string  `json:"status"`
+	Duration    float64 `json:"durationSeconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// NewReceiverAPI returns a receiver API client.
+func NewReceiverAPI(c api.Client) ReceiverAPI {
+	return &httpReceiverAPI{client: apiClient{c}}
+}
+
+type httpReceiverAPI struct {
+	client api.Client
+}
+
+func (h *httpReceiverAPI) List(ctx context.Context) ([]string, error) {
+	u := h.client.URL(epReceivers, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var receivers []string
+	err = json.Unmarshal(body, &receivers)
+	return receivers, err
+}
+
+func (h *httpReceiverAPI) Test(ctx context.Context, r ReceiverTestRequest) ([]*IntegrationTestResult, error) {
+	u := h.client.URL(epReceiverTest, nil)
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&r); err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*IntegrationTestResult
+	err = json.Unmarshal(body, &results)
+
+	return results, err
+}
+
+func (h *httpReceiverAPI) Status(ctx context.Context) ([]*ReceiverStatus, error) {
+	u := h.client.URL(epReceiverState, nil)
+	u.RawQuery = url.Values{"details": []string{"true"}}.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var receivers []*ReceiverStatus
+	err = json.Unmarshal(body, &receivers)
+	return receivers, err
+}
+
+// TemplateAPI provides bindings for the Alertmanager's template API.
+type TemplateAPI interface {
+	// Render renders a template of the Alertmanager's loaded templates.
+	Render(ctx context.Context, r RenderRequest) (string, error)
+}
+
+// RenderRequest selects the template to render and the alerts it is
+// rendered with.
+type RenderRequest struct {
+	Name        string   `json:"name"`
+	HTML        bool     `json:"html"`
+	Receiver    string   `json:"receiver"`
+	GroupLabels LabelSet `json:"groupLabels"`
+	Alerts      []Alert  `json:"alerts"`
+}
+
+// NewTemplateAPI returns a template API client.
+func NewTemplateAPI(c api.Client) TemplateAPI {
+	return &httpTemplateAPI{client: apiClient{c}}
+}
+
+type httpTemplateAPI struct {
+	client api.Client
+}
+
+func (h *httpTemplateAPI) Render(ctx context.Context, r RenderRequest) (string, error) {
+	u := h.client.URL(epRender, nil)
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&r); err != nil {
+		return "", err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		Rendered string `json:"rendered"`
+	}
+	err = json.Unmarshal(body, &res)
+
+	return res.Rendered, err
+}
+
 // AlertAPI provides bindings for the Alertmanager's alert API.
 type AlertAPI interface {
 	// List returns all the active alerts.
 	List(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool) ([]*ExtendedAlert, error)
+	// ListPage returns a page of the active alerts sorted by the options
+	// and the total number of alerts matching the filters.
+	ListPage(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts ListOptions) ([]*ExtendedAlert, int, error)
+	// Stream is like ListPage but calls f with each alert as soon as it is
+	// decoded instead of returning all alerts at once. It stops at the first
+	// error returned by f.
+	Stream(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts ListOptions, f func(*ExtendedAlert) error) (int, error)
 	// Push sends a list of alerts to the Alertmanager.
 	Push(ctx context.Context, alerts ...Alert) error
+	// Groups returns the alerts grouped like the dispatcher sends them to
+	// the receivers.
+	Groups(ctx context.Context, filter, receiver string) ([]*AlertGroup, error)
+	// GroupTimers returns the timers of the aggregation groups, which tell
+	// when their alerts are notified next. It requires an admin tenant.
+	GroupTimers(ctx context.Context, filter, receiver string) ([]*GroupTimers, error)
+	// History returns the notification attempts for the alert with the
+	// fingerprint and/or the group key made between start and end, most
+	// recent first. Empty arguments and zero times are not filtered on.
+	History(ctx context.Context, fingerprint, groupKey string, start, end time.Time) ([]*NotificationAttempt, error)
+	// Inhibitions returns the inhibited alerts matching the filter with the
+	// alerts inhibiting them and the inhibition rules.
+	Inhibitions(ctx context.Context, filter string) ([]*Inhibition, error)
+	// Resolve resolves the firing alerts with the fingerprints or matching
+	// the matchers, regardless of their source, and returns the
+	// fingerprints of the resolved alerts.
+	Resolve(ctx context.Context, fingerprints []string, matchers types.Matchers) ([]string, error)
 }
 
 // Alert represents an alert as expected by the AlertManager's push alert API.
@@ -174,6 +614,84 @@ type ExtendedAlert struct {
 	Status      types.AlertStatus `json:"status"`
 	Receivers   []string          `json:"receivers"`
 	Fingerprint string            `json:"fingerprint"`
+
+	Acknowledgement *types.Acknowledgement `json:"acknowledgement,omitempty"`
+
+	// Instances are the URLs of the Alertmanagers that returned the alert
+	// when several of them are queried together.
+	Instances []string `json:"instances,omitempty"`
+}
+
+// AlertGroup represents an aggregation group of alerts as returned by the
+// Alertmanager's alert groups API.
+type AlertGroup struct {
+	Labels    LabelSet         `json:"labels"`
+	GroupKey  string           `json:"groupKey"`
+	Receiver  string           `json:"receiver"`
+	NextFlush time.Time        `json:"nextFlush"`
+	Alerts    []*ExtendedAlert `json:"alerts"`
+}
+
+// GroupTimers represents the timers of an aggregation group as returned by
+// the Alertmanager's group timers API. The durations until the next flush
+// and repeat are computed by the Alertmanager.
+type GroupTimers struct {
+	GroupKey       string        `json:"groupKey"`
+	Receiver       string        `json:"receiver"`
+	Labels         LabelSet      `json:"labels"`
+	Fingerprints   []string      `json:"fingerprints"`
+	GroupWait      time.Duration `json:"groupWait"`
+	GroupInterval  time.Duration `json:"groupInterval"`
+	RepeatInterval time.Duration `json:"repeatInterval"`
+	LastFlush      *time.Time    `json:"lastFlush,omitempty"`
+	NextFlush      time.Time     `json:"nextFlush"`
+	NextFlushIn    time.Duration `json:"nextFlushIn"`
+	LastNotified   *time.Time    `json:"lastNotified,omitempty"`
+	NextRepeat     *time.Time    `json:"nextRepeat,omitempty"`
+	NextRepeatIn   time.Duration `json:"nextRepeatIn,omitempty"`
+}
+
+// NotificationAttempt represents an attempt to send a notification as
+// returned by the Alertmanager's alert history API.
+type NotificationAttempt struct {
+	GroupKey       string    `json:"groupKey"`
+	Receiver       string    `json:"receiver"`
+	Integration    string    `json:"integration"`
+	Timestamp      time.Time `json:"timestamp"`
+	FiringAlerts   []string  `json:"firingAlerts"`
+	ResolvedAlerts []string  `json:"resolvedAlerts"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// Inhibition represents an inhibited alert as returned by the
+// Alertmanager's inhibitions API. An alert inhibited through several rules
+// has an inhibition per rule.
+type Inhibition struct {
+	Fingerprint string          `json:"fingerprint"`
+	Labels      LabelSet        `json:"labels"`
+	InhibitedBy InhibitingAlert `json:"inhibitedBy"`
+	Rule        InhibitRule     `json:"rule"`
+	// RuleIndex is the index of the rule in the configuration.
+	RuleIndex int `json:"ruleIndex"`
+}
+
+// InhibitingAlert represents the source alert of an inhibition.
+type InhibitingAlert struct {
+	Fingerprint string    `json:"fingerprint"`
+	Labels      LabelSet  `json:"labels"`
+	StartsAt    time.Time `json:"startsAt"`
+}
+
+// InhibitRule represents an inhibition rule of the configuration. The
+// regular expressions are anchored.
+type InhibitRule struct {
+	SourceMatch           map[string]string `json:"source_match,omitempty"`
+	SourceMatchRE         map[string]string `json:"source_match_re,omitempty"`
+	TargetMatch           map[string]string `json:"target_match,omitempty"`
+	TargetMatchRE         map[string]string `json:"target_match_re,omitempty"`
+	TargetMatchRETemplate map[string]string `json:"target_match_re_template,omitempty"`
+	Equal                 []string          `json:"equal,omitempty"`
+	Receivers             []string          `json:"receivers,omitempty"`
 }
 
 // LabelSet represents a collection of label names and values as a map.
@@ -195,6 +713,46 @@ type httpAlertAPI struct {
 }
 
 func (h *httpAlertAPI) List(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool) ([]*ExtendedAlert, error) {
+	alts, _, err := h.ListPage(ctx, filter, receiver, silenced, inhibited, active, unprocessed, ListOptions{})
+	return alts, err
+}
+
+func (h *httpAlertAPI) ListPage(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts ListOptions) ([]*ExtendedAlert, int, error) {
+	req := h.listRequest(filter, receiver, silenced, inhibited, active, unprocessed, opts)
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var alts []*ExtendedAlert
+	err = json.Unmarshal(body, &alts)
+
+	return alts, totalCount(resp, len(alts)), err
+}
+
+func (h *httpAlertAPI) Stream(ctx context.Context, filter, receiver string, silenced, inhibited, active, unprocessed bool, opts ListOptions, f func(*ExtendedAlert) error) (int, error) {
+	req := h.listRequest(filter, receiver, silenced, inhibited, active, unprocessed, opts)
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = decodeEach(body, func(dec *json.Decoder) error {
+		var a ExtendedAlert
+		if err := dec.Decode(&a); err != nil {
+			return err
+		}
+		n++
+		return f(&a)
+	})
+
+	return totalCount(resp, n), err
+}
+
+func (h *httpAlertAPI) listRequest(filter, receiver string, silenced, inhibited, active, unprocessed bool, opts ListOptions) *http.Request {
 	u := h.client.URL(epAlerts, nil)
 	params := url.Values{}
 	if filter != "" {
@@ -205,6 +763,36 @@ func (h *httpAlertAPI) List(ctx context.Context, filter, receiver string, silenc
 	params.Add("active", fmt.Sprintf("%t", active))
 	params.Add("unprocessed", fmt.Sprintf("%t", unprocessed))
 	params.Add("receiver", receiver)
+	opts.addTo(params)
+	u.RawQuery = params.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+	return req
+}
+
+func (h *httpAlertAPI) Push(ctx context.Context, alerts ...Alert) error {
+	u := h.client.URL(epAlerts, nil)
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&alerts); err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, _, err := h.client.Do(ctx, req)
+	return err
+}
+
+func (h *httpAlertAPI) Groups(ctx context.Context, filter, receiver string) ([]*AlertGroup, error) {
+	u := h.client.URL(epAggregationGroups, nil)
+	params := url.Values{}
+	if filter != "" {
+		params.Add("filter", filter)
+	}
+	if receiver != "" {
+		params.Add("receiver", receiver)
+	}
 	u.RawQuery = params.Encode()
 
 	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
@@ -214,24 +802,115 @@ func (h *httpAlertAPI) List(ctx context.Context, filter, receiver string, silenc
 		return nil, err
 	}
 
-	var alts []*ExtendedAlert
-	err = json.Unmarshal(body, &alts)
+	var groups []*AlertGroup
+	err = json.Unmarshal(body, &groups)
 
-	return alts, err
+	return groups, err
 }
 
-func (h *httpAlertAPI) Push(ctx context.Context, alerts ...Alert) error {
-	u := h.client.URL(epAlerts, nil)
+func (h *httpAlertAPI) GroupTimers(ctx context.Context, filter, receiver string) ([]*GroupTimers, error) {
+	u := h.client.URL(epGroupTimers, nil)
+	params := url.Values{}
+	if filter != "" {
+		params.Add("filter", filter)
+	}
+	if receiver != "" {
+		params.Add("receiver", receiver)
+	}
+	u.RawQuery = params.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var timers []*GroupTimers
+	err = json.Unmarshal(body, &timers)
+
+	return timers, err
+}
+
+func (h *httpAlertAPI) History(ctx context.Context, fingerprint, groupKey string, start, end time.Time) ([]*NotificationAttempt, error) {
+	u := h.client.URL(epAlertHistory, nil)
+	params := url.Values{}
+	if fingerprint != "" {
+		params.Add("fingerprint", fingerprint)
+	}
+	if groupKey != "" {
+		params.Add("groupKey", groupKey)
+	}
+	if !start.IsZero() {
+		params.Add("start", start.Format(time.RFC3339))
+	}
+	if !end.IsZero() {
+		params.Add("end", end.Format(time.RFC3339))
+	}
+	u.RawQuery = params.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []*NotificationAttempt
+	err = json.Unmarshal(body, &attempts)
+
+	return attempts, err
+}
+
+func (h *httpAlertAPI) Inhibitions(ctx context.Context, filter string) ([]*Inhibition, error) {
+	u := h.client.URL(epInhibitions, nil)
+	params := url.Values{}
+	if filter != "" {
+		params.Add("filter", filter)
+	}
+	u.RawQuery = params.Encode()
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var inhibitions []*Inhibition
+	err = json.Unmarshal(body, &inhibitions)
+
+	return inhibitions, err
+}
+
+func (h *httpAlertAPI) Resolve(ctx context.Context, fingerprints []string, matchers types.Matchers) ([]string, error) {
+	u := h.client.URL(epAlertsResolve, nil)
 
 	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(&alerts); err != nil {
-		return err
+	err := json.NewEncoder(&buf).Encode(struct {
+		Fingerprints []string       `json:"fingerprints,omitempty"`
+		Matchers     types.Matchers `json:"matchers,omitempty"`
+	}{
+		Fingerprints: fingerprints,
+		Matchers:     matchers,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
 
-	_, _, err := h.client.Do(ctx, req)
-	return err
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Fingerprints []string `json:"fingerprints"`
+	}
+	err = json.Unmarshal(body, &res)
+
+	return res.Fingerprints, err
 }
 
 // SilenceAPI provides bindings for the Alertmanager's silence API.
@@ -240,10 +919,24 @@ type SilenceAPI interface {
 	Get(ctx context.Context, id string) (*types.Silence, error)
 	// Set updates or creates the given silence and returns its ID.
 	Set(ctx context.Context, sil types.Silence) (string, error)
+	// SetForce is like Set but does not enforce the limit of firing alerts
+	// a silence may match.
+	SetForce(ctx context.Context, sil types.Silence) (string, error)
+	// Create creates the new silences at once, or none if one of them is
+	// invalid, and returns their IDs. Like SetForce, force does not enforce
+	// the limit of firing alerts a silence may match.
+	Create(ctx context.Context, sils []types.Silence, force bool) ([]string, error)
+	// Update modifies the silence with the given ID in place, including its
+	// matchers. The previous version is kept in the silence's history.
+	Update(ctx context.Context, id string, sil types.Silence) error
 	// Expire expires the silence with the given ID.
 	Expire(ctx context.Context, id string) error
 	// List returns silences matching the given filter.
 	List(ctx context.Context, filter string) ([]*types.Silence, error)
+	// ListPage returns a page of the silences matching the filter and in
+	// one of the states, or any state if none is given, sorted by the
+	// options and the total number of matching silences.
+	ListPage(ctx context.Context, filter string, states []types.SilenceState, opts ListOptions) ([]*types.Silence, int, error)
 }
 
 // NewSilenceAPI returns a new SilenceAPI for the client.
@@ -285,7 +978,18 @@ func (h *httpSilenceAPI) Expire(ctx context.Context, id string) error {
 }
 
 func (h *httpSilenceAPI) Set(ctx context.Context, sil types.Silence) (string, error) {
+	return h.set(ctx, sil, false)
+}
+
+func (h *httpSilenceAPI) SetForce(ctx context.Context, sil types.Silence) (string, error) {
+	return h.set(ctx, sil, true)
+}
+
+func (h *httpSilenceAPI) set(ctx context.Context, sil types.Silence, force bool) (string, error) {
 	u := h.client.URL(epSilences, nil)
+	if force {
+		u.RawQuery = url.Values{"force": []string{"true"}}.Encode()
+	}
 
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(&sil); err != nil {
@@ -307,23 +1011,139 @@ func (h *httpSilenceAPI) Set(ctx context.Context, sil types.Silence) (string, er
 	return res.SilenceID, err
 }
 
+func (h *httpSilenceAPI) Create(ctx context.Context, sils []types.Silence, force bool) ([]string, error) {
+	u := h.client.URL(epSilencesBulk, nil)
+	if force {
+		u.RawQuery = url.Values{"force": []string{"true"}}.Encode()
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(sils); err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		SilenceIDs []string `json:"silenceIds"`
+	}
+	err = json.Unmarshal(body, &res)
+
+	return res.SilenceIDs, err
+}
+
+func (h *httpSilenceAPI) Update(ctx context.Context, id string, sil types.Silence) error {
+	u := h.client.URL(epSilence, map[string]string{
+		"id": id,
+	})
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&sil); err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, u.String(), &buf)
+
+	_, _, err := h.client.Do(ctx, req)
+	return err
+}
+
 func (h *httpSilenceAPI) List(ctx context.Context, filter string) ([]*types.Silence, error) {
+	sils, _, err := h.ListPage(ctx, filter, nil, ListOptions{})
+	return sils, err
+}
+
+func (h *httpSilenceAPI) ListPage(ctx context.Context, filter string, states []types.SilenceState, opts ListOptions) ([]*types.Silence, int, error) {
 	u := h.client.URL(epSilences, nil)
 	params := url.Values{}
 	if filter != "" {
 		params.Add("filter", filter)
 	}
+	if len(states) > 0 {
+		ss := make([]string, 0, len(states))
+		for _, st := range states {
+			ss = append(ss, string(st))
+		}
+		params.Add("state", strings.Join(ss, ","))
+	}
+	opts.addTo(params)
 	u.RawQuery = params.Encode()
 
 	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
 
-	_, body, err := h.client.Do(ctx, req)
+	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var sils []*types.Silence
 	err = json.Unmarshal(body, &sils)
 
-	return sils, err
+	return sils, totalCount(resp, len(sils)), err
+}
+
+// AckAPI provides bindings for the Alertmanager's acknowledgement API.
+type AckAPI interface {
+	// Set creates or replaces the acknowledgement of an alert.
+	Set(ctx context.Context, ack types.Acknowledgement) error
+	// Expire expires the acknowledgement of the alert with the given
+	// fingerprint.
+	Expire(ctx context.Context, fingerprint string) error
+	// List returns all active acknowledgements.
+	List(ctx context.Context) ([]*types.Acknowledgement, error)
+}
+
+// NewAckAPI returns a new AckAPI for the client.
+func NewAckAPI(c api.Client) AckAPI {
+	return &httpAckAPI{client: apiClient{c}}
+}
+
+type httpAckAPI struct {
+	client api.Client
+}
+
+func (h *httpAckAPI) Set(ctx context.Context, ack types.Acknowledgement) error {
+	u := h.client.URL(epAcks, nil)
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&ack); err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, u.String(), &buf)
+
+	_, _, err := h.client.Do(ctx, req)
+	return err
+}
+
+func (h *httpAckAPI) Expire(ctx context.Context, fingerprint string) error {
+	u := h.client.URL(epAck, map[string]string{
+		"fingerprint": fingerprint,
+	})
+
+	req, _ := http.NewRequest(http.MethodDelete, u.String(), nil)
+
+	_, _, err := h.client.Do(ctx, req)
+	return err
+}
+
+func (h *httpAckAPI) List(ctx context.Context) ([]*types.Acknowledgement, error) {
+	u := h.client.URL(epAcks, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+
+	_, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var acks []*types.Acknowledgement
+	err = json.Unmarshal(body, &acks)
+
+	return acks, err
 }
@@ -18,12 +18,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/api"
+	yaml "gopkg.in/yaml.v2"
+
 	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/types"
 )
@@ -100,6 +105,32 @@ func TestAPI(t *testing.T) {
 		api := httpStatusAPI{client: client}
 		return api.Get(context.Background())
 	}
+	clusterData := &ClusterStatus{
+		Name:   "01CB1BGB3S0QBRCRTDMQ6YWH3Q",
+		Status: "ready",
+		Peers: []PeerStatus{
+			{Name: "01CB1BGB3S0QBRCRTDMQ6YWH3Q", Address: "10.0.0.1:9094", LastSeen: &now},
+			{Name: "01CB1BJ5MSS2SMS98HHW6H9Y6E", Address: "10.0.0.2:9094"},
+		},
+		MessagesQueued:    2,
+		OversizedMessages: 1,
+	}
+	doClusterStatus := func() (interface{}, error) {
+		api := httpClusterAPI{client: client}
+		return api.Status(context.Background())
+	}
+	snapshot := map[string]interface{}{
+		"version": 1,
+		"states":  map[string]string{"sil": "c2lsZW5jZXM="},
+	}
+	doClusterSnapshot := func() (interface{}, error) {
+		api := httpClusterAPI{client: client}
+		return api.Snapshot(context.Background())
+	}
+	doClusterRestore := func() (interface{}, error) {
+		api := httpClusterAPI{client: client}
+		return api.Restore(context.Background(), []byte(`{"version":1}`))
+	}
 
 	alertOne := Alert{
 		StartsAt:    now,
@@ -120,6 +151,118 @@ func TestAPI(t *testing.T) {
 		api := httpAlertAPI{client: client}
 		return api.List(context.Background(), "", "", false, false, false, false)
 	}
+	doAlertStream := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		var streamed []*ExtendedAlert
+		_, err := api.Stream(context.Background(), "", "", false, false, false, false, ListOptions{}, func(a *ExtendedAlert) error {
+			streamed = append(streamed, a)
+			return nil
+		})
+		return streamed, err
+	}
+	groups := []*AlertGroup{
+		{
+			Labels:    LabelSet{"label1": "test1"},
+			GroupKey:  "{}:{label1=\"test1\"}",
+			Receiver:  "team-X",
+			NextFlush: now.Add(time.Minute),
+			Alerts:    alerts,
+		},
+	}
+	doAlertGroups := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		return api.Groups(context.Background(), "", "team-X")
+	}
+	lastFlush := now.Add(-4 * time.Minute)
+	timers := []*GroupTimers{
+		{
+			GroupKey:       "{}:{label1=\"test1\"}",
+			Receiver:       "team-X",
+			Labels:         LabelSet{"label1": "test1"},
+			Fingerprints:   []string{"1c93eec3511dc156"},
+			GroupWait:      30 * time.Second,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: time.Hour,
+			LastFlush:      &lastFlush,
+			NextFlush:      now.Add(time.Minute),
+			NextFlushIn:    time.Minute,
+		},
+	}
+	doGroupTimers := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		return api.GroupTimers(context.Background(), "", "team-X")
+	}
+	attempts := []*NotificationAttempt{
+		{
+			GroupKey:     "{}:{label1=\"test1\"}",
+			Receiver:     "team-X",
+			Integration:  "webhook",
+			Timestamp:    now,
+			FiringAlerts: []string{"1c93eec3511dc156"},
+			Error:        "connection refused",
+		},
+	}
+	doAlertHistory := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		return api.History(context.Background(), "1c93eec3511dc156", "", now.Add(-time.Hour), time.Time{})
+	}
+	inhibitions := []*Inhibition{
+		{
+			Fingerprint: "1c93eec3511dc156",
+			Labels:      LabelSet{"label1": "test1"},
+			InhibitedBy: InhibitingAlert{
+				Fingerprint: "0000000000000001",
+				Labels:      LabelSet{"alertname": "NodeDown"},
+				StartsAt:    now,
+			},
+			Rule: InhibitRule{
+				SourceMatch: map[string]string{"alertname": "NodeDown"},
+				Equal:       []string{"label1"},
+			},
+			RuleIndex: 1,
+		},
+	}
+	doInhibitions := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		return api.Inhibitions(context.Background(), "{label1=\"test1\"}")
+	}
+	doAlertResolve := func() (interface{}, error) {
+		api := httpAlertAPI{client: client}
+		return api.Resolve(context.Background(), []string{"1c93eec3511dc156"}, nil)
+	}
+	testResults := []*IntegrationTestResult{
+		{Integration: "webhook", Status: "firing", Duration: 0.5},
+		{Integration: "webhook", Index: 1, Status: "firing", Duration: 1, Error: "unexpected status code 500"},
+	}
+	doReceiverList := func() (interface{}, error) {
+		api := httpReceiverAPI{client: client}
+		return api.List(context.Background())
+	}
+	failingSince := now.Add(-2 * time.Hour)
+	receiverStatus := []*ReceiverStatus{
+		{
+			Name: "team-X",
+			Integrations: []IntegrationStatus{
+				{Name: "slack", LastAttempt: &now, LastError: "unexpected status code 401", LastErrorAt: &now, FailingSince: &failingSince},
+			},
+		},
+	}
+	doReceiverStatus := func() (interface{}, error) {
+		api := httpReceiverAPI{client: client}
+		return api.Status(context.Background())
+	}
+	doReceiverTest := func() (interface{}, error) {
+		api := httpReceiverAPI{client: client}
+		return api.Test(context.Background(), ReceiverTestRequest{Receiver: "team-X", Labels: LabelSet{"team": "X"}})
+	}
+	doRender := func() (interface{}, error) {
+		api := httpTemplateAPI{client: client}
+		return api.Render(context.Background(), RenderRequest{Name: "slack.default.title", Alerts: []Alert{alertOne}})
+	}
+	doConfigPush := func() (interface{}, error) {
+		api := httpConfigAPI{client: client}
+		return api.Push(context.Background(), "route:\n  receiver: team-X\n", true)
+	}
 	doAlertPush := func() (interface{}, error) {
 		api := httpAlertAPI{client: client}
 		return nil, api.Push(context.Background(), []Alert{alertOne}...)
@@ -155,6 +298,18 @@ func TestAPI(t *testing.T) {
 			return api.Set(context.Background(), sil)
 		}
 	}
+	doSilenceSetForce := func(sil types.Silence) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			api := httpSilenceAPI{client: client}
+			return api.SetForce(context.Background(), sil)
+		}
+	}
+	doSilenceUpdate := func(id string, sil types.Silence) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			api := httpSilenceAPI{client: client}
+			return nil, api.Update(context.Background(), id, sil)
+		}
+	}
 	doSilenceExpire := func(id string) func() (interface{}, error) {
 		return func() (interface{}, error) {
 			api := httpSilenceAPI{client: client}
@@ -166,12 +321,36 @@ func TestAPI(t *testing.T) {
 		return api.List(context.Background(), "")
 	}
 
+	ackOne := &types.Acknowledgement{
+		Fingerprint: "1c93eec3511dc156",
+		CreatedBy:   "alice",
+		Comment:     "looking into it",
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+	doAckSet := func(ack types.Acknowledgement) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			api := httpAckAPI{client: client}
+			return nil, api.Set(context.Background(), ack)
+		}
+	}
+	doAckExpire := func(fp string) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			api := httpAckAPI{client: client}
+			return nil, api.Expire(context.Background(), fp)
+		}
+	}
+	doAckList := func() (interface{}, error) {
+		api := httpAckAPI{client: client}
+		return api.List(context.Background())
+	}
+
 	tests := []apiTest{
 		{
 			do: doStatus,
 			apiRes: fakeAPIResponse{
 				res:    statusData,
-				path:   "/api/v1/status",
+				path:   "/api/v2/status",
 				method: http.MethodGet,
 			},
 			res: statusData,
@@ -180,16 +359,43 @@ func TestAPI(t *testing.T) {
 			do: doStatus,
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/status",
+				path:   "/api/v2/status",
 				method: http.MethodGet,
 			},
 			err: fmt.Errorf("some error"),
 		},
+		{
+			do: doClusterStatus,
+			apiRes: fakeAPIResponse{
+				res:    clusterData,
+				path:   "/api/v1/cluster/status",
+				method: http.MethodGet,
+			},
+			res: clusterData,
+		},
+		{
+			do: doClusterSnapshot,
+			apiRes: fakeAPIResponse{
+				res:    snapshot,
+				path:   "/api/v1/cluster/snapshot",
+				method: http.MethodGet,
+			},
+			res: snapshot,
+		},
+		{
+			do: doClusterRestore,
+			apiRes: fakeAPIResponse{
+				res:    map[string][]string{"restored": {"sil"}},
+				path:   "/api/v1/cluster/snapshot",
+				method: http.MethodPost,
+			},
+			res: []string{"sil"},
+		},
 		{
 			do: doAlertList,
 			apiRes: fakeAPIResponse{
 				res:    alerts,
-				path:   "/api/v1/alerts",
+				path:   "/api/v2/alerts",
 				method: http.MethodGet,
 			},
 			res: alerts,
@@ -198,16 +404,133 @@ func TestAPI(t *testing.T) {
 			do: doAlertList,
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/alerts",
+				path:   "/api/v2/alerts",
 				method: http.MethodGet,
 			},
 			err: fmt.Errorf("some error"),
 		},
+		{
+			do: doAlertStream,
+			apiRes: fakeAPIResponse{
+				res:    alerts,
+				path:   "/api/v2/alerts",
+				method: http.MethodGet,
+			},
+			res: alerts,
+		},
+		{
+			do: doAlertStream,
+			apiRes: fakeAPIResponse{
+				res:    map[string]string{"status": "success"},
+				path:   "/api/v2/alerts",
+				method: http.MethodGet,
+			},
+			err: fmt.Errorf("expected JSON array, got {"),
+		},
+		{
+			do: doAlertGroups,
+			apiRes: fakeAPIResponse{
+				res:    groups,
+				path:   "/api/v2/alerts/groups",
+				method: http.MethodGet,
+			},
+			res: groups,
+		},
+		{
+			do: doGroupTimers,
+			apiRes: fakeAPIResponse{
+				res:    timers,
+				path:   "/api/v1/alerts/groups/timers",
+				method: http.MethodGet,
+			},
+			res: timers,
+		},
+		{
+			do: doRender,
+			apiRes: fakeAPIResponse{
+				res:    map[string]string{"rendered": "[FIRING:1] test1"},
+				path:   "/api/v1/templates/render",
+				method: http.MethodPost,
+			},
+			res: "[FIRING:1] test1",
+		},
+		{
+			do: doRender,
+			apiRes: fakeAPIResponse{
+				err:    fmt.Errorf("template \"slack.default.title\" not defined"),
+				path:   "/api/v1/templates/render",
+				method: http.MethodPost,
+			},
+			err: fmt.Errorf("template \"slack.default.title\" not defined"),
+		},
+		{
+			do: doConfigPush,
+			apiRes: fakeAPIResponse{
+				res:    &ConfigPushResult{Diff: "-  receiver: team-Y\n+  receiver: team-X\n"},
+				path:   "/api/v1/config",
+				method: http.MethodPost,
+			},
+			res: &ConfigPushResult{Diff: "-  receiver: team-Y\n+  receiver: team-X\n"},
+		},
+		{
+			do: doAlertHistory,
+			apiRes: fakeAPIResponse{
+				res:    attempts,
+				path:   "/api/v1/alerts/history",
+				method: http.MethodGet,
+			},
+			res: attempts,
+		},
+		{
+			do: doReceiverList,
+			apiRes: fakeAPIResponse{
+				res:    []string{"team-X", "team-Y"},
+				path:   "/api/v2/receivers",
+				method: http.MethodGet,
+			},
+			res: []string{"team-X", "team-Y"},
+		},
+		{
+			do: doReceiverStatus,
+			apiRes: fakeAPIResponse{
+				res:    receiverStatus,
+				path:   "/api/v1/receivers",
+				method: http.MethodGet,
+			},
+			res: receiverStatus,
+		},
+		{
+			do: doReceiverTest,
+			apiRes: fakeAPIResponse{
+				res:    testResults,
+				path:   "/api/v1/receivers/test",
+				method: http.MethodPost,
+			},
+			res: testResults,
+		},
+		{
+			do: doInhibitions,
+			apiRes: fakeAPIResponse{
+				res:    inhibitions,
+				path:   "/api/v1/alerts/inhibitions",
+				method: http.MethodGet,
+			},
+			res: inhibitions,
+		},
+		{
+			do: doAlertResolve,
+			apiRes: fakeAPIResponse{
+				res:    map[string][]string{"fingerprints": {"1c93eec3511dc156"}},
+				path:   "/api/v1/alerts/resolve",
+				method: http.MethodPost,
+			},
+			res: []string{"1c93eec3511dc156"},
+		},
 		{
 			do: doAlertPush,
 			apiRes: fakeAPIResponse{
 				res:    nil,
-				path:   "/api/v1/alerts",
+				path:   "/api/v2/alerts",
 				method: http.MethodPost,
 			},
 			res: nil,
@@ -216,7 +539,7 @@ func TestAPI(t *testing.T) {
 			do: doAlertPush,
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/alerts",
+				path:   "/api/v2/alerts",
 				method: http.MethodPost,
 			},
 			err: fmt.Errorf("some error"),
@@ -225,7 +548,7 @@ func TestAPI(t *testing.T) {
 			do: doSilenceGet("abc"),
 			apiRes: fakeAPIResponse{
 				res:    silOne,
-				path:   "/api/v1/silence/abc",
+				path:   "/api/v2/silence/abc",
 				method: http.MethodGet,
 			},
 			res: silOne,
@@ -234,7 +557,7 @@ func TestAPI(t *testing.T) {
 			do: doSilenceGet("abc"),
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/silence/abc",
+				path:   "/api/v2/silence/abc",
 				method: http.MethodGet,
 			},
 			err: fmt.Errorf("some error"),
@@ -243,7 +566,7 @@ func TestAPI(t *testing.T) {
 			do: doSilenceSet(*silOne),
 			apiRes: fakeAPIResponse{
 				res:    map[string]string{"SilenceId": "abc"},
-				path:   "/api/v1/silences",
+				path:   "/api/v2/silences",
 				method: http.MethodPost,
 			},
 			res: "abc",
@@ -252,15 +575,40 @@ func TestAPI(t *testing.T) {
 			do: doSilenceSet(*silOne),
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/silences",
+				path:   "/api/v2/silences",
 				method: http.MethodPost,
 			},
 			err: fmt.Errorf("some error"),
 		},
+		{
+			do: doSilenceSetForce(*silOne),
+			apiRes: fakeAPIResponse{
+				res:    map[string]string{"SilenceId": "abc"},
+				path:   "/api/v2/silences",
+				method: http.MethodPost,
+			},
+			res: "abc",
+		},
+		{
+			do: doSilenceUpdate("abc", *silOne),
+			apiRes: fakeAPIResponse{
+				path:   "/api/v2/silence/abc",
+				method: http.MethodPut,
+			},
+		},
+		{
+			do: doSilenceUpdate("abc", *silOne),
+			apiRes: fakeAPIResponse{
+				err:    fmt.Errorf("some error"),
+				path:   "/api/v2/silence/abc",
+				method: http.MethodPut,
+			},
+			err: fmt.Errorf("some error"),
+		},
 		{
 			do: doSilenceExpire("abc"),
 			apiRes: fakeAPIResponse{
-				path:   "/api/v1/silence/abc",
+				path:   "/api/v2/silence/abc",
 				method: http.MethodDelete,
 			},
 		},
@@ -268,7 +616,7 @@ func TestAPI(t *testing.T) {
 			do: doSilenceExpire("abc"),
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/silence/abc",
+				path:   "/api/v2/silence/abc",
 				method: http.MethodDelete,
 			},
 			err: fmt.Errorf("some error"),
@@ -277,7 +625,7 @@ func TestAPI(t *testing.T) {
 			do: doSilenceList,
 			apiRes: fakeAPIResponse{
 				res:    []*types.Silence{silOne},
-				path:   "/api/v1/silences",
+				path:   "/api/v2/silences",
 				method: http.MethodGet,
 			},
 			res: []*types.Silence{silOne},
@@ -286,11 +634,43 @@ func TestAPI(t *testing.T) {
 			do: doSilenceList,
 			apiRes: fakeAPIResponse{
 				err:    fmt.Errorf("some error"),
-				path:   "/api/v1/silences",
+				path:   "/api/v2/silences",
 				method: http.MethodGet,
 			},
 			err: fmt.Errorf("some error"),
 		},
+		{
+			do: doAckSet(*ackOne),
+			apiRes: fakeAPIResponse{
+				path:   "/api/v1/acks",
+				method: http.MethodPost,
+			},
+		},
+		{
+			do: doAckExpire("1c93eec3511dc156"),
+			apiRes: fakeAPIResponse{
+				path:   "/api/v1/ack/1c93eec3511dc156",
+				method: http.MethodDelete,
+			},
+		},
+		{
+			do: doAckExpire("1c93eec3511dc156"),
+			apiRes: fakeAPIResponse{
+				err:    fmt.Errorf("some error"),
+				path:   "/api/v1/ack/1c93eec3511dc156",
+				method: http.MethodDelete,
+			},
+			err: fmt.Errorf("some error"),
+		},
+		{
+			do: doAckList,
+			apiRes: fakeAPIResponse{
+				res:    []*types.Acknowledgement{ackOne},
+				path:   "/api/v1/acks",
+				method: http.MethodGet,
+			},
+			res: []*types.Acknowledgement{ackOne},
+		},
 	}
 	for _, test := range tests {
 		test := test
@@ -430,7 +810,7 @@ func TestAPIClientDo(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			fake.ch <- test.response
 
-			_, body, err := client.Do(context.Background(), &http.Request{})
+			_, body, err := client.Do(context.Background(), &http.Request{URL: &url.URL{}})
 			if test.err != nil {
 				if err == nil {
 					t.Errorf("expected error %q but got none", test.err)
@@ -455,3 +835,91 @@ func TestAPIClientDo(t *testing.T) {
 		})
 	}
 }
+
+func TestV1Fallback(t *testing.T) {
+	// The server only knows the first API version.
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/prefix/api/v1/silences":
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil || len(b) == 0 {
+				t.Errorf("unexpected request body %q: %v", b, err)
+			}
+			fmt.Fprint(w, `{"status":"success","data":{"silenceId":"abc"}}`)
+		case "/prefix/api/v1/silence/def":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status":"error","errorType":"not_found","error":"silence not found"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := api.NewClient(api.Config{Address: srv.URL + "/prefix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	silenceAPI := NewSilenceAPI(c)
+
+	id, err := silenceAPI.Set(context.Background(), types.Silence{})
+	if err != nil {
+		t.Fatalf("unexpected error %q", err)
+	}
+	if id != "abc" {
+		t.Errorf("unexpected silence ID: want %q, got %q", "abc", id)
+	}
+
+	// Errors of the API are not retried.
+	if _, err = silenceAPI.Get(context.Background(), "def"); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	// Endpoints without an equivalent are not retried.
+	if _, err = NewAlertAPI(c).Groups(context.Background(), "", ""); err == nil {
+		t.Errorf("expected error but got none")
+	}
+
+	want := []string{
+		"POST /prefix/api/v2/silences",
+		"POST /prefix/api/v1/silences",
+		"GET /prefix/api/v2/silence/def",
+		"GET /prefix/api/v1/silence/def",
+		"GET /prefix/api/v2/alerts/groups",
+	}
+	if strings.Join(want, "\n") != strings.Join(paths, "\n") {
+		t.Errorf("unexpected requests: want %q, got %q", want, paths)
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	b, err := ioutil.ReadFile("../api/openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		BasePath string                 `yaml:"basePath"`
+		Paths    map[string]interface{} `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	// The endpoints of the second API version the client uses must be
+	// specified.
+	for _, ep := range []string{
+		epStatus,
+		epReceivers,
+		epSilence,
+		epSilences,
+		epSilencesBulk,
+		epAlerts,
+		epAggregationGroups,
+	} {
+		path := strings.Replace(strings.TrimPrefix(ep, spec.BasePath), ":id", "{silenceID}", 1)
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("endpoint %s is not in the OpenAPI specification", ep)
+		}
+	}
+}
@@ -20,42 +20,196 @@ import (
 	"github.com/pkg/errors"
 )
 
+var (
+	// interfaceAddrs returns the addresses of the network interface with the
+	// name, or of all interfaces if it is empty.
+	interfaceAddrs = func(name string) ([]net.IP, error) {
+		var (
+			addrs []net.Addr
+			err   error
+		)
+		if name == "" {
+			addrs, err = net.InterfaceAddrs()
+		} else {
+			var iface *net.Interface
+			if iface, err = net.InterfaceByName(name); err != nil {
+				return nil, err
+			}
+			addrs, err = iface.Addrs()
+		}
+		if err != nil {
+			return nil, err
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				ips = append(ips, ipnet.IP)
+			}
+		}
+		return ips, nil
+	}
+	// privateIP returns the first private IPv4 address of the interfaces,
+	// empty if there is none.
+	privateIP = sockaddr.GetPrivateIP
+)
+
 // calculateAdvertiseAddress attempts to clone logic from deep within memberlist
 // (NetTransport.FinalAdvertiseAddr) in order to surface its conclusions to the
 // application, so we can provide more actionable error messages if the user has
 // inadvertantly misconfigured their cluster.
 //
+// Unlike memberlist, it also picks a global unicast IPv6 address if bound to
+// "::" and the address of the advertise interface if one is given. Link-local
+// addresses are rejected, as the peers cannot reach them without knowing the
+// interface they are on.
+//
 // https://github.com/hashicorp/memberlist/blob/022f081/net_transport.go#L126
-func calculateAdvertiseAddress(bindAddr, advertiseAddr string) (net.IP, error) {
+func calculateAdvertiseAddress(bindAddr, advertiseAddr, advertiseInterface string) (net.IP, error) {
 	if advertiseAddr != "" {
 		ip := net.ParseIP(advertiseAddr)
 		if ip == nil {
 			return nil, errors.Errorf("failed to parse advertise addr '%s'", advertiseAddr)
 		}
+		if isLinkLocal(ip) {
+			return nil, errors.Errorf("advertise addr '%s' is a link-local address, provide a routable address", advertiseAddr)
+		}
 		if ip4 := ip.To4(); ip4 != nil {
 			ip = ip4
 		}
 		return ip, nil
 	}
 
-	if bindAddr == "0.0.0.0" {
-		privateIP, err := sockaddr.GetPrivateIP()
+	bindIP := net.ParseIP(bindAddr)
+	if advertiseInterface != "" {
+		ips, err := interfaceAddrs(advertiseInterface)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to get private IP")
+			return nil, errors.Wrapf(err, "failed to get addresses of advertise interface '%s'", advertiseInterface)
 		}
-		if privateIP == "" {
-			return nil, errors.Wrap(err, "no private IP found, explicit advertise addr not provided")
+		var ip net.IP
+		if bindIP == nil || bindIP.Equal(net.IPv6unspecified) {
+			ip, err = selectDualStackIP(ips)
+		} else {
+			ip, err = selectAdvertiseIP(ips, bindIP.To4() != nil)
 		}
-		ip := net.ParseIP(privateIP)
-		if ip == nil {
-			return nil, errors.Errorf("failed to parse private IP '%s'", privateIP)
+		if err != nil {
+			return nil, errors.Wrapf(err, "advertise interface '%s'", advertiseInterface)
 		}
 		return ip, nil
 	}
 
-	ip := net.ParseIP(bindAddr)
-	if ip == nil {
+	switch {
+	case bindIP == nil:
 		return nil, errors.Errorf("failed to parse bind addr '%s'", bindAddr)
+	case bindIP.Equal(net.IPv4zero):
+		ip, err := privateIP()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get private IP")
+		}
+		if ip == "" {
+			return nil, errors.New("no private IP found, explicit advertise addr not provided")
+		}
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, errors.Errorf("failed to parse private IP '%s'", ip)
+		}
+		return parsed, nil
+	case bindIP.Equal(net.IPv6unspecified):
+		ips, err := interfaceAddrs("")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get interface addresses")
+		}
+		ip, err := selectDualStackIP(ips)
+		if err != nil {
+			return nil, errors.Wrap(err, "explicit advertise addr not provided")
+		}
+		return ip, nil
+	case isLinkLocal(bindIP):
+		return nil, errors.Errorf("bind addr '%s' is a link-local address, provide a routable advertise addr", bindAddr)
+	}
+	return bindIP, nil
+}
+
+// isIPv6Unspecified returns whether the host is the IPv6 unspecified
+// address in any of its notations.
+func isIPv6Unspecified(host string) bool {
+	ip := net.ParseIP(host)
+	return ip != nil && ip.Equal(net.IPv6unspecified)
+}
+
+// selectAdvertiseIP returns the first IPv4 address, preferring private ones,
+// or the first global unicast IPv6 address. Loopback addresses are skipped.
+// The error tells whether only link-local addresses were found.
+func selectAdvertiseIP(ips []net.IP, v4 bool) (net.IP, error) {
+	var public net.IP
+	linkLocal := false
+	for _, ip := range ips {
+		if ip.IsLoopback() || (ip.To4() != nil) != v4 {
+			continue
+		}
+		if isLinkLocal(ip) {
+			linkLocal = true
+			continue
+		}
+		if !ip.IsGlobalUnicast() {
+			continue
+		}
+		if !v4 {
+			return ip, nil
+		}
+		if isPrivateIPv4(ip) {
+			return ip.To4(), nil
+		}
+		if public == nil {
+			public = ip.To4()
+		}
+	}
+	if public != nil {
+		return public, nil
+	}
+
+	family := "IPv4"
+	if !v4 {
+		family = "global unicast IPv6"
+	}
+	if linkLocal {
+		return nil, errors.Errorf("only link-local addresses found, which cannot be advertised, no %s address", family)
+	}
+	return nil, errors.Errorf("no %s address found", family)
+}
+
+// selectDualStackIP returns the first global unicast IPv6 address for hosts
+// bound to "::". Hosts without one are reached over IPv4 on the dual-stack
+// socket.
+func selectDualStackIP(ips []net.IP) (net.IP, error) {
+	ip, err := selectAdvertiseIP(ips, false)
+	if err == nil {
+		return ip, nil
+	}
+	if ip, err4 := selectAdvertiseIP(ips, true); err4 == nil {
+		return ip, nil
+	}
+	return nil, err
+}
+
+func isLinkLocal(ip net.IP) bool {
+	return ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+var privateIPv4Nets = []*net.IPNet{
+	{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(172, 16, 0, 0), Mask: net.CIDRMask(12, 32)},
+	{IP: net.IPv4(192, 168, 0, 0), Mask: net.CIDRMask(16, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+// isPrivateIPv4 returns whether the address is in a private network of RFC
+// 1918 or the shared address space of RFC 6598, like the addresses picked
+// by memberlist.
+func isPrivateIPv4(ip net.IP) bool {
+	for _, n := range privateIPv4Nets {
+		if n.Contains(ip) {
+			return true
+		}
 	}
-	return ip, nil
+	return false
 }
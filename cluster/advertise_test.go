@@ -0,0 +1,129 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateAdvertiseAddress(t *testing.T) {
+	defer func(addrs func(string) ([]net.IP, error), private func() (string, error)) {
+		interfaceAddrs, privateIP = addrs, private
+	}(interfaceAddrs, privateIP)
+
+	ifaces := map[string][]string{
+		"":         {"127.0.0.1", "::1", "192.168.1.10", "fe80::1", "2001:db8::10"},
+		"eth0":     {"203.0.113.5", "10.0.0.5", "fe80::2", "2001:db8::5"},
+		"eth1":     {"198.51.100.7", "2001:db8::7"},
+		"ll0":      {"169.254.0.3", "fe80::3"},
+		"v6only0":  {"fe80::4", "2001:db8::4"},
+		"loopback": {"127.0.0.1", "::1"},
+	}
+	interfaceAddrs = func(name string) ([]net.IP, error) {
+		addrs, ok := ifaces[name]
+		if !ok {
+			return nil, errors.New("no such network interface")
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, a := range addrs {
+			ips = append(ips, net.ParseIP(a))
+		}
+		return ips, nil
+	}
+	privateIP = func() (string, error) { return "192.168.1.10", nil }
+
+	for _, tc := range []struct {
+		bind, advertise, iface string
+
+		addr string
+		err  string
+	}{
+		{bind: "0.0.0.0", addr: "192.168.1.10"},
+		{bind: "10.1.2.3", addr: "10.1.2.3"},
+		{bind: "0.0.0.0", advertise: "203.0.113.1", addr: "203.0.113.1"},
+		{bind: "::", advertise: "2001:db8::1", addr: "2001:db8::1"},
+		{bind: "0.0.0.0", advertise: "fe80::1", err: "link-local"},
+		{bind: "0.0.0.0", advertise: "invalid", err: "failed to parse advertise addr"},
+
+		{bind: "::", addr: "2001:db8::10"},
+		{bind: "0:0:0:0:0:0:0:0", addr: "2001:db8::10"},
+		{bind: "0:0:0:0:0:0:0:0", iface: "eth1", addr: "2001:db8::7"},
+		{bind: "2001:db8::20", addr: "2001:db8::20"},
+		{bind: "fe80::1", err: "link-local"},
+		{bind: "alertmanager.local", err: "failed to parse bind addr"},
+
+		{bind: "0.0.0.0", iface: "eth0", addr: "10.0.0.5"},
+		{bind: "0.0.0.0", iface: "eth1", addr: "198.51.100.7"},
+		{bind: "::", iface: "eth0", addr: "2001:db8::5"},
+		{bind: "::", iface: "eth1", addr: "2001:db8::7"},
+		{bind: "2001:db8::30", iface: "eth0", addr: "2001:db8::5"},
+		{bind: "::", iface: "v6only0", addr: "2001:db8::4"},
+		{bind: "0.0.0.0", iface: "v6only0", err: "no IPv4 address found"},
+		{bind: "0.0.0.0", iface: "ll0", err: "only link-local addresses found, which cannot be advertised, no IPv4 address"},
+		{bind: "::", iface: "ll0", err: "only link-local addresses found"},
+		{bind: "0.0.0.0", iface: "loopback", err: "no IPv4 address found"},
+		{bind: "0.0.0.0", iface: "eth9", err: "no such network interface"},
+	} {
+		addr, err := calculateAdvertiseAddress(tc.bind, tc.advertise, tc.iface)
+		if tc.err != "" {
+			require.Error(t, err, "%+v", tc)
+			require.Contains(t, err.Error(), tc.err, "%+v", tc)
+			continue
+		}
+		require.NoError(t, err, "%+v", tc)
+		require.Equal(t, tc.addr, addr.String(), "%+v", tc)
+	}
+}
+
+func TestCalculateAdvertiseAddressFallback(t *testing.T) {
+	defer func(addrs func(string) ([]net.IP, error), private func() (string, error)) {
+		interfaceAddrs, privateIP = addrs, private
+	}(interfaceAddrs, privateIP)
+
+	privateIP = func() (string, error) { return "", nil }
+	_, err := calculateAdvertiseAddress("0.0.0.0", "", "")
+	require.EqualError(t, err, "no private IP found, explicit advertise addr not provided")
+
+	// Hosts bound to "::" without global IPv6 addresses advertise IPv4.
+	interfaceAddrs = func(string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("fe80::1"), net.ParseIP("10.0.0.1")}, nil
+	}
+	addr, err := calculateAdvertiseAddress("::", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", addr.String())
+
+	interfaceAddrs = func(string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("::1"), net.ParseIP("fe80::1")}, nil
+	}
+	_, err = calculateAdvertiseAddress("::", "", "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only link-local addresses found")
+}
+
+func TestIsIPv6Unspecified(t *testing.T) {
+	for host, exp := range map[string]bool{
+		"::":               true,
+		"0:0:0:0:0:0:0:0":  true,
+		"0::0":             true,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"alertmanager.svc": false,
+	} {
+		require.Equal(t, exp, isIPv6Unspecified(host), host)
+	}
+}
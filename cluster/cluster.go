@@ -15,6 +15,7 @@ package cluster
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"math/rand"
 	"net"
@@ -40,14 +41,68 @@ type Peer struct {
 	mlist    *memberlist.Memberlist
 	delegate *delegate
 
+	// keyring holds the keys encrypting the gossip traffic if encryption
+	// is enabled.
+	keyring *memberlist.Keyring
+
 	mtx    sync.RWMutex
 	states map[string]State
 	stopc  chan struct{}
 	readyc chan struct{}
+	// meta is announced to the other peers. It is guarded by mtx as the
+	// standby mode of the peer changes at runtime.
+	meta nodeMeta
+	// knownSize is the number of distinct addresses of the known peers and
+	// the peer itself, which is the least size of the cluster a quorum is
+	// counted of. It is guarded by mtx.
+	knownSize int
 
 	logger log.Logger
 }
 
+// ZoneConfig places the peer in a zone and region of a deployment spanning
+// several zones.
+type ZoneConfig struct {
+	Zone   string
+	Region string
+	// CrossZonePushPullInterval is the interval of full state syncs with
+	// peers in other zones. If it is set, the push/pull interval only applies
+	// to full state syncs with peers in the same zone.
+	CrossZonePushPullInterval time.Duration
+}
+
+// WANConfig tunes the gossip of a cluster spanning networks with high latency
+// or packet loss. Zero values keep the defaults.
+type WANConfig struct {
+	// TCPOnly sends the gossip packets over TCP connections instead of UDP,
+	// for networks that block UDP between the peers.
+	TCPOnly bool
+	// SuspicionMult is the multiplier of the probe interval and the
+	// logarithm of the cluster size a peer is suspected for before it is
+	// declared dead.
+	SuspicionMult int
+	// IndirectChecks is the number of peers asked to probe a peer that did
+	// not answer a direct probe.
+	IndirectChecks int
+}
+
+// nodeMeta is the metadata a peer announces to the cluster.
+type nodeMeta struct {
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+	// Standby peers receive the replicated state but do not send
+	// notifications.
+	Standby bool `json:"standby,omitempty"`
+}
+
+func decodeNodeMeta(b []byte) nodeMeta {
+	var m nodeMeta
+	if len(b) > 0 {
+		json.Unmarshal(b, &m)
+	}
+	return m
+}
+
 const (
 	DefaultPushPullInterval = 60 * time.Second
 	DefaultGossipInterval   = 200 * time.Millisecond
@@ -56,11 +111,20 @@ const (
 	DefaultProbeInterval    = 1 * time.Second
 )
 
+// updateNodeTimeout is the time to wait for the changed metadata of the peer
+// to be broadcast.
+const updateNodeTimeout = 10 * time.Second
+
+// Join creates a peer and joins the known peers. A standby peer receives the
+// replicated state but does not send notifications until it is promoted.
+// Without an advertise address, the address of the advertise interface is
+// advertised if one is given.
 func Join(
 	l log.Logger,
 	reg prometheus.Registerer,
 	bindAddr string,
 	advertiseAddr string,
+	advertiseInterface string,
 	knownPeers []string,
 	waitIfEmpty bool,
 	pushPullInterval time.Duration,
@@ -68,6 +132,11 @@ func Join(
 	tcpTimeout time.Duration,
 	probeTimeout time.Duration,
 	probeInterval time.Duration,
+	zoneConfig *ZoneConfig,
+	wanConfig *WANConfig,
+	tlsConfig *TLSConfig,
+	gossipKeys [][]byte,
+	standby bool,
 ) (*Peer, error) {
 	bindHost, bindPortStr, err := net.SplitHostPort(bindAddr)
 	if err != nil {
@@ -92,15 +161,21 @@ func Join(
 		}
 	}
 
-	resolvedPeers, err := resolvePeers(context.Background(), knownPeers, advertiseAddr, net.Resolver{}, waitIfEmpty)
+	resolvedPeers, err := resolvePeers(context.Background(), knownPeers, advertiseAddr, &net.Resolver{}, waitIfEmpty)
 	if err != nil {
 		return nil, errors.Wrap(err, "resolve peers")
 	}
 	level.Debug(l).Log("msg", "resolved peers to following addresses", "peers", strings.Join(resolvedPeers, ","))
 
 	// Initial validation of user-specified advertise address.
-	addr, err := calculateAdvertiseAddress(bindHost, advertiseHost)
+	addr, err := calculateAdvertiseAddress(bindHost, advertiseHost, advertiseInterface)
+	bindUnspecified6 := isIPv6Unspecified(bindHost)
 	if err != nil {
+		// Memberlist cannot deduce an address either if the interface was
+		// selected or the bind address is IPv6 unspecified.
+		if advertiseInterface != "" || bindUnspecified6 {
+			return nil, errors.Wrap(err, "couldn't deduce an advertise address")
+		}
 		level.Warn(l).Log("err", "couldn't deduce an advertise address: "+err.Error())
 	} else if hasNonlocal(resolvedPeers) && isUnroutable(addr.String()) {
 		level.Warn(l).Log("err", "this node advertises itself on an unroutable address", "addr", addr.String())
@@ -119,6 +194,11 @@ func Join(
 		stopc:  make(chan struct{}),
 		readyc: make(chan struct{}),
 		logger: l,
+		meta:   nodeMeta{Standby: standby},
+	}
+	if zoneConfig != nil {
+		p.meta.Zone = zoneConfig.Zone
+		p.meta.Region = zoneConfig.Region
 	}
 	p.delegate = newDelegate(l, reg, p)
 
@@ -128,16 +208,52 @@ func Join(
 	cfg.BindPort = bindPort
 	cfg.Delegate = p.delegate
 	cfg.Events = p.delegate
+	cfg.Ping = p.delegate
 	cfg.GossipInterval = gossipInterval
 	cfg.PushPullInterval = pushPullInterval
 	cfg.TCPTimeout = tcpTimeout
 	cfg.ProbeTimeout = probeTimeout
 	cfg.ProbeInterval = probeInterval
 	cfg.LogOutput = ioutil.Discard
+	p.delegate.maxMessageSize = cfg.UDPBufferSize
+
+	// Memberlist syncs with random members regardless of their zone, so
+	// the syncs are scheduled by the peer if cross-zone syncs have their
+	// own interval.
+	zoneSync := zoneConfig != nil && zoneConfig.Zone != "" && zoneConfig.CrossZonePushPullInterval > 0
+	if zoneSync {
+		cfg.PushPullInterval = 0
+	}
 
 	if advertiseAddr != "" {
 		cfg.AdvertiseAddr = advertiseHost
 		cfg.AdvertisePort = advertisePort
+	} else if addr != nil && bindPort != 0 && (advertiseInterface != "" || bindUnspecified6) {
+		// Memberlist would advertise the unspecified address or ignore the
+		// interface.
+		cfg.AdvertiseAddr = addr.String()
+		cfg.AdvertisePort = bindPort
+	}
+	if len(gossipKeys) > 0 {
+		p.keyring, err = memberlist.NewKeyring(gossipKeys, gossipKeys[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "create gossip keyring")
+		}
+		cfg.Keyring = p.keyring
+	}
+	if wanConfig != nil {
+		if wanConfig.SuspicionMult > 0 {
+			cfg.SuspicionMult = wanConfig.SuspicionMult
+		}
+		if wanConfig.IndirectChecks > 0 {
+			cfg.IndirectChecks = wanConfig.IndirectChecks
+		}
+	}
+	if tlsConfig != nil || (wanConfig != nil && wanConfig.TCPOnly) {
+		cfg.Transport, err = NewTCPTransport(log.With(l, "component", "tcp_transport"), reg, bindHost, bindPort, advertiseInterface, tcpTimeout, tlsConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "create TCP transport")
+		}
 	}
 
 	ml, err := memberlist.Create(cfg)
@@ -145,6 +261,7 @@ func Join(
 		return nil, errors.Wrap(err, "create memberlist")
 	}
 	p.mlist = ml
+	p.setKnownSize(resolvedPeers)
 
 	n, err := ml.Join(resolvedPeers)
 	if err != nil {
@@ -156,9 +273,115 @@ func Join(
 	if n > 0 {
 		go p.warnIfAlone(l, 10*time.Second)
 	}
+	if zoneSync {
+		go p.syncZones(pushPullInterval, zoneConfig.CrossZonePushPullInterval)
+	}
 	return p, nil
 }
 
+// syncZones runs full state syncs with peers of the same zone every local
+// interval and with peers of other zones every cross-zone interval until the
+// peer leaves the cluster.
+func (p *Peer) syncZones(local, crossZone time.Duration) {
+	var localc <-chan time.Time
+	if local > 0 {
+		tick := time.NewTicker(local)
+		defer tick.Stop()
+		localc = tick.C
+	}
+	crossZoneTick := time.NewTicker(crossZone)
+	defer crossZoneTick.Stop()
+
+	for {
+		select {
+		case <-p.stopc:
+			return
+		case <-localc:
+			p.pushPull(p.zonePeers(true))
+		case <-crossZoneTick.C:
+			p.pushPull(p.zonePeers(false))
+		}
+	}
+}
+
+// zonePeers returns the other members of the cluster that are in the same
+// zone as the peer or, if sameZone is false, in other zones.
+func (p *Peer) zonePeers(sameZone bool) []*memberlist.Node {
+	self := p.Self().Name
+
+	var res []*memberlist.Node
+	for _, n := range p.Peers() {
+		if n.Name == self {
+			continue
+		}
+		if (decodeNodeMeta(n.Meta).Zone == p.meta.Zone) == sameZone {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// pushPull runs a full state sync with a random node of the given ones.
+func (p *Peer) pushPull(nodes []*memberlist.Node) {
+	if len(nodes) == 0 {
+		return
+	}
+	n := nodes[rand.Intn(len(nodes))]
+	if _, err := p.mlist.Join([]string{n.Address()}); err != nil {
+		level.Debug(p.logger).Log("msg", "full state sync failed", "peer", n.Name, "addr", n.Address(), "err", err)
+	}
+}
+
+// RefreshPeers resolves the known peers every interval and joins the ones that
+// are not members of the cluster, until the peer leaves the cluster. This picks
+// up new replicas announced in DNS.
+func (p *Peer) RefreshPeers(knownPeers []string, interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-p.stopc:
+			return
+		case <-tick.C:
+			p.refreshPeers(knownPeers, interval)
+		}
+	}
+}
+
+func (p *Peer) refreshPeers(knownPeers []string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resolvedPeers, err := resolvePeers(ctx, knownPeers, p.Self().Address(), &net.Resolver{}, false)
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to refresh peers", "err", err)
+		return
+	}
+	p.setKnownSize(resolvedPeers)
+
+	members := map[string]struct{}{}
+	for _, n := range p.mlist.Members() {
+		members[n.Address()] = struct{}{}
+	}
+	var newPeers []string
+	for _, peer := range resolvedPeers {
+		if _, ok := members[peer]; !ok {
+			newPeers = append(newPeers, peer)
+		}
+	}
+	if len(newPeers) == 0 {
+		return
+	}
+
+	n, err := p.mlist.Join(newPeers)
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to join refreshed peers", "peers", strings.Join(newPeers, ","), "err", err)
+		return
+	}
+	level.Debug(p.logger).Log("msg", "joined refreshed peers", "peers", n)
+}
+
 func (p *Peer) warnIfAlone(logger log.Logger, d time.Duration) {
 	tick := time.NewTicker(d)
 	defer tick.Stop()
@@ -179,7 +402,7 @@ func (p *Peer) warnIfAlone(logger log.Logger, d time.Duration) {
 // broadcast messages for the state can be sent.
 func (p *Peer) AddState(key string, s State) *Channel {
 	p.states[key] = s
-	return &Channel{key: key, bcast: p.delegate.bcast}
+	return &Channel{key: key, bcast: p.delegate.bcast, d: p.delegate}
 }
 
 // Leave the cluster, waiting up to timeout.
@@ -198,6 +421,43 @@ func (p *Peer) ClusterSize() int {
 	return p.mlist.NumMembers()
 }
 
+// setKnownSize sets the known size of the cluster to the number of distinct
+// addresses of the peers and the peer itself.
+func (p *Peer) setKnownSize(peers []string) {
+	addrs := map[string]struct{}{p.Self().Address(): {}}
+	for _, addr := range peers {
+		addrs[addr] = struct{}{}
+	}
+
+	p.mtx.Lock()
+	p.knownSize = len(addrs)
+	p.mtx.Unlock()
+}
+
+// Quorum returns whether the peer and the members it heard from within the
+// timeout form a majority of the cluster. The size of the cluster is the
+// number of its members, but at least the number of known peers given to
+// join it, so that the peers of a minority partition do not count a quorum
+// of their own once the other members were declared dead.
+func (p *Peer) Quorum(timeout time.Duration) bool {
+	members := p.Members()
+	size := len(members)
+	p.mtx.RLock()
+	if p.knownSize > size {
+		size = p.knownSize
+	}
+	p.mtx.RUnlock()
+
+	now := time.Now()
+	reached := 0
+	for _, m := range members {
+		if now.Sub(m.LastSeen) <= timeout {
+			reached++
+		}
+	}
+	return 2*reached > size
+}
+
 // Return true when router has settled.
 func (p *Peer) Ready() bool {
 	select {
@@ -234,6 +494,29 @@ func (p *Peer) Info() map[string]interface{} {
 	}
 }
 
+// Standby returns whether the peer is a standby that does not send
+// notifications.
+func (p *Peer) Standby() bool {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return p.meta.Standby
+}
+
+// SetStandby promotes the peer to an active member of the cluster or demotes
+// it to a standby and announces the change to the other peers.
+func (p *Peer) SetStandby(standby bool) error {
+	p.mtx.Lock()
+	changed := p.meta.Standby != standby
+	p.meta.Standby = standby
+	p.mtx.Unlock()
+
+	if !changed {
+		return nil
+	}
+	level.Info(p.logger).Log("msg", "changed standby mode", "standby", standby)
+	return p.mlist.UpdateNode(updateNodeTimeout)
+}
+
 // Self returns the node information about the peer itself.
 func (p *Peer) Self() *memberlist.Node {
 	return p.mlist.LocalNode()
@@ -244,16 +527,76 @@ func (p *Peer) Peers() []*memberlist.Node {
 	return p.mlist.Members()
 }
 
-// Position returns the position of the peer in the cluster.
+// MemberStatus is the state of a cluster member as seen by the peer.
+type MemberStatus struct {
+	Name    string
+	Address string
+	// LastSeen is the time the member last answered a probe of the peer
+	// or its membership was updated. It is zero if the member was not seen
+	// yet.
+	LastSeen time.Time
+	Zone     string
+	Region   string
+	Standby  bool
+}
+
+// Members returns the status of the members of the cluster including the
+// peer itself.
+func (p *Peer) Members() []MemberStatus {
+	self := p.Self().Name
+	now := time.Now()
+
+	var res []MemberStatus
+	for _, n := range p.Peers() {
+		meta := decodeNodeMeta(n.Meta)
+		ms := MemberStatus{Name: n.Name, Address: n.Address(), Zone: meta.Zone, Region: meta.Region, Standby: meta.Standby}
+		if n.Name == self {
+			ms.LastSeen = now
+			ms.Standby = p.Standby()
+		} else {
+			ms.LastSeen = p.delegate.lastSeenTime(n.Name)
+		}
+		res = append(res, ms)
+	}
+	return res
+}
+
+// QueuedMessages returns the number of messages waiting to be gossiped.
+func (p *Peer) QueuedMessages() int {
+	return p.delegate.bcast.NumQueued()
+}
+
+// OversizedMessages returns the number of broadcasts that exceeded the
+// maximum size of gossip messages so far. They are unlikely to reach the
+// other peers before the next full state sync.
+func (p *Peer) OversizedMessages() int {
+	return p.delegate.oversizedCount()
+}
+
+// Position returns the position of the peer in the cluster. The active
+// peers precede the standby peers, so that the positions of the active peers
+// do not depend on the standby peers.
 func (p *Peer) Position() int {
+	self := p.Self().Name
 	all := p.Peers()
+	standby := make(map[string]bool, len(all))
+	for _, n := range all {
+		if n.Name == self {
+			standby[n.Name] = p.Standby()
+		} else {
+			standby[n.Name] = decodeNodeMeta(n.Meta).Standby
+		}
+	}
 	sort.Slice(all, func(i, j int) bool {
+		if standby[all[i].Name] != standby[all[j].Name] {
+			return !standby[all[i].Name]
+		}
 		return all[i].Name < all[j].Name
 	})
 
 	k := 0
 	for _, n := range all {
-		if n.Name == p.Self().Name {
+		if n.Name == self {
 			break
 		}
 		k++
@@ -261,6 +604,12 @@ func (p *Peer) Position() int {
 	return k
 }
 
+// Leader returns whether the peer is the first active peer of the cluster,
+// which runs the tasks that only a single peer may run.
+func (p *Peer) Leader() bool {
+	return !p.Standby() && p.Position() == 0
+}
+
 // Settle waits until the mesh is ready (and sets the appropriate internal state when it is).
 // The idea is that we don't want to start "working" before we get a chance to know most of the alerts and/or silences.
 // Inspired from https://github.com/apache/cassandra/blob/7a40abb6a5108688fb1b10c375bb751cbb782ea4/src/java/org/apache/cassandra/gms/Gossiper.java
@@ -316,6 +665,7 @@ type State interface {
 type Channel struct {
 	key   string
 	bcast *memberlist.TransmitLimitedQueue
+	d     *delegate
 }
 
 // We use a simple broadcast implementation in which items are never invalidated by others.
@@ -331,6 +681,9 @@ func (c *Channel) Broadcast(b []byte) {
 	if err != nil {
 		return
 	}
+	if c.d != nil && c.d.maxMessageSize > 0 && len(b) > c.d.maxMessageSize {
+		c.d.oversized()
+	}
 	c.bcast.QueueBroadcast(simpleBroadcast(b))
 }
 
@@ -346,6 +699,15 @@ type delegate struct {
 	messagesReceivedSize *prometheus.CounterVec
 	messagesSent         *prometheus.CounterVec
 	messagesSentSize     *prometheus.CounterVec
+	probeRTT             prometheus.Histogram
+
+	// maxMessageSize is the size above which broadcasts do not fit into a
+	// gossip packet.
+	maxMessageSize int
+
+	statsMtx          sync.Mutex
+	lastSeen          map[string]time.Time
+	oversizedMessages int
 }
 
 func newDelegate(l log.Logger, reg prometheus.Registerer, p *Peer) *delegate {
@@ -381,6 +743,15 @@ func newDelegate(l log.Logger, reg prometheus.Registerer, p *Peer) *delegate {
 	}, func() float64 {
 		return float64(p.Position())
 	})
+	standby := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alertmanager_peer_standby",
+		Help: "Whether the Alertmanager instance is a standby that does not send notifications.",
+	}, func() float64 {
+		if p.Standby() {
+			return 1
+		}
+		return 0
+	})
 	healthScore := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "alertmanager_cluster_health_score",
 		Help: "Health score of the cluster. Lower values are better and zero means 'totally healthy'.",
@@ -394,6 +765,24 @@ func newDelegate(l log.Logger, reg prometheus.Registerer, p *Peer) *delegate {
 		return float64(bcast.NumQueued())
 	})
 
+	d := &delegate{
+		logger:   l,
+		Peer:     p,
+		bcast:    bcast,
+		lastSeen: map[string]time.Time{},
+	}
+	probeRTT := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "alertmanager_cluster_probe_rtt_seconds",
+		Help:    "Round-trip time of probes answered by cluster peers.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	})
+	oversizedMessages := prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "alertmanager_cluster_oversized_messages_total",
+		Help: "Total number of broadcasts exceeding the maximum size of gossip messages.",
+	}, func() float64 {
+		return float64(d.oversizedCount())
+	})
+
 	messagesReceived.WithLabelValues("full_state")
 	messagesReceivedSize.WithLabelValues("full_state")
 	messagesReceived.WithLabelValues("update")
@@ -404,22 +793,55 @@ func newDelegate(l log.Logger, reg prometheus.Registerer, p *Peer) *delegate {
 	messagesSentSize.WithLabelValues("update")
 
 	reg.MustRegister(messagesReceived, messagesReceivedSize, messagesSent, messagesSentSize,
-		gossipClusterMembers, peerPosition, healthScore, messagesQueued)
+		gossipClusterMembers, peerPosition, standby, healthScore, messagesQueued, oversizedMessages, probeRTT)
+
+	d.messagesReceived = messagesReceived
+	d.messagesReceivedSize = messagesReceivedSize
+	d.messagesSent = messagesSent
+	d.messagesSentSize = messagesSentSize
+	d.probeRTT = probeRTT
+	return d
+}
 
-	return &delegate{
-		logger:               l,
-		Peer:                 p,
-		bcast:                bcast,
-		messagesReceived:     messagesReceived,
-		messagesReceivedSize: messagesReceivedSize,
-		messagesSent:         messagesSent,
-		messagesSentSize:     messagesSentSize,
-	}
+func (d *delegate) seen(name string) {
+	d.statsMtx.Lock()
+	d.lastSeen[name] = time.Now()
+	d.statsMtx.Unlock()
+}
+
+func (d *delegate) lastSeenTime(name string) time.Time {
+	d.statsMtx.Lock()
+	defer d.statsMtx.Unlock()
+	return d.lastSeen[name]
+}
+
+func (d *delegate) oversized() {
+	d.statsMtx.Lock()
+	d.oversizedMessages++
+	d.statsMtx.Unlock()
+}
+
+func (d *delegate) oversizedCount() int {
+	d.statsMtx.Lock()
+	defer d.statsMtx.Unlock()
+	return d.oversizedMessages
 }
 
 // NodeMeta retrieves meta-data about the current node when broadcasting an alive message.
 func (d *delegate) NodeMeta(limit int) []byte {
-	return []byte{}
+	d.mtx.RLock()
+	meta := d.meta
+	d.mtx.RUnlock()
+
+	if meta == (nodeMeta{}) {
+		return []byte{}
+	}
+	b, err := json.Marshal(meta)
+	if err != nil || len(b) > limit {
+		level.Warn(d.logger).Log("msg", "zone and region exceed the size of the node metadata", "limit", limit)
+		return []byte{}
+	}
+	return b
 }
 
 // NotifyMsg is the callback invoked when a user-level gossip message is received.
@@ -502,74 +924,146 @@ func (d *delegate) MergeRemoteState(buf []byte, _ bool) {
 // NotifyJoin is called if a peer joins the cluster.
 func (d *delegate) NotifyJoin(n *memberlist.Node) {
 	level.Debug(d.logger).Log("received", "NotifyJoin", "node", n.Name, "addr", n.Address())
+	d.seen(n.Name)
 }
 
 // NotifyLeave is called if a peer leaves the cluster.
 func (d *delegate) NotifyLeave(n *memberlist.Node) {
 	level.Debug(d.logger).Log("received", "NotifyLeave", "node", n.Name, "addr", n.Address())
+
+	d.statsMtx.Lock()
+	delete(d.lastSeen, n.Name)
+	d.statsMtx.Unlock()
 }
 
 // NotifyUpdate is called if a cluster peer gets updated.
 func (d *delegate) NotifyUpdate(n *memberlist.Node) {
 	level.Debug(d.logger).Log("received", "NotifyUpdate", "node", n.Name, "addr", n.Address())
+	d.seen(n.Name)
+}
+
+// AckPayload is called when an ack for a probe is sent.
+func (d *delegate) AckPayload() []byte {
+	return nil
+}
+
+// NotifyPingComplete is called when a cluster peer answered a probe.
+func (d *delegate) NotifyPingComplete(n *memberlist.Node, rtt time.Duration, _ []byte) {
+	d.seen(n.Name)
+	d.probeRTT.Observe(rtt.Seconds())
+}
+
+// dnsSRVPrefix marks peers that are resolved from DNS SRV records.
+const dnsSRVPrefix = "dnssrv+"
+
+// resolver is the subset of net.Resolver used to resolve peers.
+type resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
 }
 
-func resolvePeers(ctx context.Context, peers []string, myAddress string, res net.Resolver, waitIfEmpty bool) ([]string, error) {
+func resolvePeers(ctx context.Context, peers []string, myAddress string, res resolver, waitIfEmpty bool) ([]string, error) {
 	var resolvedPeers []string
 
 	for _, peer := range peers {
-		host, port, err := net.SplitHostPort(peer)
-		if err != nil {
-			return nil, errors.Wrapf(err, "split host/port for peer %s", peer)
+		if strings.HasPrefix(peer, dnsSRVPrefix) {
+			srvPeers, err := resolveSRV(ctx, strings.TrimPrefix(peer, dnsSRVPrefix), myAddress, res)
+			if err != nil {
+				return nil, err
+			}
+			resolvedPeers = append(resolvedPeers, srvPeers...)
+			continue
 		}
 
-		retryCtx, cancel := context.WithCancel(ctx)
-
-		ips, err := res.LookupIPAddr(ctx, host)
+		addrs, err := resolvePeer(ctx, peer, myAddress, res, waitIfEmpty)
 		if err != nil {
-			// Assume direct address.
-			resolvedPeers = append(resolvedPeers, peer)
-			continue
+			return nil, err
 		}
+		resolvedPeers = append(resolvedPeers, addrs...)
+	}
 
-		if len(ips) == 0 {
-			var lookupErrSpotted bool
+	return resolvedPeers, nil
+}
 
-			err := retry(2*time.Second, retryCtx.Done(), func() error {
-				if lookupErrSpotted {
-					// We need to invoke cancel in next run of retry when lookupErrSpotted to preserve LookupIPAddr error.
-					cancel()
-				}
+// resolvePeer resolves the addresses of a host:port peer.
+func resolvePeer(ctx context.Context, peer string, myAddress string, res resolver, waitIfEmpty bool) ([]string, error) {
+	host, port, err := net.SplitHostPort(peer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "split host/port for peer %s", peer)
+	}
 
-				ips, err = res.LookupIPAddr(retryCtx, host)
-				if err != nil {
-					lookupErrSpotted = true
-					return errors.Wrapf(err, "IP Addr lookup for peer %s", peer)
-				}
+	retryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-				ips = removeMyAddr(ips, port, myAddress)
-				if len(ips) == 0 {
-					if !waitIfEmpty {
-						return nil
-					}
-					return errors.New("empty IPAddr result. Retrying")
-				}
+	ips, err := res.LookupIPAddr(ctx, host)
+	if err != nil {
+		// Assume direct address.
+		return []string{peer}, nil
+	}
 
-				return nil
-			})
+	if len(ips) == 0 {
+		var lookupErrSpotted bool
+
+		err := retry(2*time.Second, retryCtx.Done(), func() error {
+			if lookupErrSpotted {
+				// We need to invoke cancel in next run of retry when lookupErrSpotted to preserve LookupIPAddr error.
+				cancel()
+			}
+
+			ips, err = res.LookupIPAddr(retryCtx, host)
 			if err != nil {
-				return nil, err
+				lookupErrSpotted = true
+				return errors.Wrapf(err, "IP Addr lookup for peer %s", peer)
+			}
+
+			ips = removeMyAddr(ips, port, myAddress)
+			if len(ips) == 0 {
+				if !waitIfEmpty {
+					return nil
+				}
+				return errors.New("empty IPAddr result. Retrying")
 			}
-		}
 
-		for _, ip := range ips {
-			resolvedPeers = append(resolvedPeers, net.JoinHostPort(ip.String(), port))
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	var resolvedPeers []string
+	for _, ip := range ips {
+		resolvedPeers = append(resolvedPeers, net.JoinHostPort(ip.String(), port))
+	}
 	return resolvedPeers, nil
 }
 
+// resolveSRV resolves the peers announced in the SRV records of name. A name
+// without records resolves to no peers as they may be announced later, e.g.
+// when the first replica of a Kubernetes StatefulSet becomes ready.
+func resolveSRV(ctx context.Context, name string, myAddress string, res resolver) ([]string, error) {
+	_, srvs, err := res.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "SRV lookup for peer %s", name)
+	}
+
+	var peers []string
+	for _, srv := range srvs {
+		port := strconv.Itoa(int(srv.Port))
+		ips, err := res.LookupIPAddr(ctx, srv.Target)
+		if err != nil {
+			return nil, errors.Wrapf(err, "IP Addr lookup for SRV target %s", srv.Target)
+		}
+		for _, ip := range removeMyAddr(ips, port, myAddress) {
+			peers = append(peers, net.JoinHostPort(ip.String(), port))
+		}
+	}
+	return peers, nil
+}
+
 func removeMyAddr(ips []net.IPAddr, targetPort string, myAddr string) []net.IPAddr {
 	var result []net.IPAddr
 
@@ -15,6 +15,7 @@ package cluster
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestJoin(t *testing.T) {
@@ -30,6 +32,7 @@ func TestJoin(t *testing.T) {
 		prometheus.DefaultRegisterer,
 		"0.0.0.0:0",
 		"",
+		"",
 		[]string{},
 		true,
 		0*time.Second,
@@ -37,6 +40,11 @@ func TestJoin(t *testing.T) {
 		0*time.Second,
 		0*time.Second,
 		0*time.Second,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
 	)
 	require.NoError(t, err)
 	require.False(t, p == nil)
@@ -46,3 +54,241 @@ func TestJoin(t *testing.T) {
 	p.WaitReady()
 	require.Equal(t, p.Status(), "ready")
 }
+
+func TestPeerStatus(t *testing.T) {
+	p, err := Join(log.NewNopLogger(),
+		prometheus.NewRegistry(),
+		"127.0.0.1:0",
+		"",
+		"",
+		[]string{},
+		true,
+		DefaultPushPullInterval,
+		DefaultGossipInterval,
+		DefaultTcpTimeout,
+		DefaultProbeTimeout,
+		DefaultProbeInterval,
+		nil,
+		nil,
+		nil,
+		nil,
+		false,
+	)
+	require.NoError(t, err)
+	defer p.Leave(0)
+
+	members := p.Members()
+	require.Equal(t, 1, len(members))
+	require.Equal(t, p.Name(), members[0].Name)
+	require.False(t, members[0].LastSeen.IsZero())
+
+	// Without other peers, the broadcasts remain queued.
+	c := p.AddState("test", nil)
+	c.Broadcast([]byte("small"))
+	require.Equal(t, 0, p.OversizedMessages())
+	c.Broadcast(make([]byte, 2000))
+	require.Equal(t, 1, p.OversizedMessages())
+	require.Equal(t, 2, p.QueuedMessages())
+}
+
+func TestZones(t *testing.T) {
+	join := func(zone string, peers []string) *Peer {
+		p, err := Join(log.NewNopLogger(),
+			prometheus.NewRegistry(),
+			"127.0.0.1:0",
+			"",
+			"",
+			peers,
+			false,
+			DefaultPushPullInterval,
+			DefaultGossipInterval,
+			DefaultTcpTimeout,
+			DefaultProbeTimeout,
+			DefaultProbeInterval,
+			&ZoneConfig{Zone: zone, Region: "eu", CrossZonePushPullInterval: time.Hour},
+			nil,
+			nil,
+			nil,
+			false,
+		)
+		require.NoError(t, err)
+		return p
+	}
+	a := join("a", nil)
+	defer a.Leave(0)
+	b := join("b", []string{a.Self().Address()})
+	defer b.Leave(0)
+	c := join("a", []string{a.Self().Address()})
+	defer c.Leave(0)
+
+	zones := map[string]string{}
+	for _, m := range c.Members() {
+		require.Equal(t, "eu", m.Region)
+		zones[m.Name] = m.Zone
+	}
+	require.Equal(t, map[string]string{a.Name(): "a", b.Name(): "b", c.Name(): "a"}, zones)
+
+	local := c.zonePeers(true)
+	require.Equal(t, 1, len(local))
+	require.Equal(t, a.Name(), local[0].Name)
+	crossZone := c.zonePeers(false)
+	require.Equal(t, 1, len(crossZone))
+	require.Equal(t, b.Name(), crossZone[0].Name)
+}
+
+func TestStandby(t *testing.T) {
+	join := func(standby bool, peers []string) *Peer {
+		p, err := Join(log.NewNopLogger(),
+			prometheus.NewRegistry(),
+			"127.0.0.1:0",
+			"",
+			"",
+			peers,
+			false,
+			DefaultPushPullInterval,
+			DefaultGossipInterval,
+			DefaultTcpTimeout,
+			DefaultProbeTimeout,
+			DefaultProbeInterval,
+			nil,
+			nil,
+			nil,
+			nil,
+			standby,
+		)
+		require.NoError(t, err)
+		return p
+	}
+	a := join(false, nil)
+	defer a.Leave(0)
+	b := join(true, []string{a.Self().Address()})
+	defer b.Leave(0)
+
+	// The standby peer follows the active peers regardless of its name.
+	require.True(t, b.Standby())
+	require.Equal(t, 0, a.Position())
+	require.Equal(t, 1, b.Position())
+	require.True(t, a.Leader())
+	require.False(t, b.Leader())
+	for _, m := range a.Members() {
+		require.Equal(t, m.Name == b.Name(), m.Standby)
+	}
+
+	// Promoting the standby and demoting the active peer swaps them.
+	require.NoError(t, b.SetStandby(false))
+	require.NoError(t, a.SetStandby(true))
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && (a.Position() != 1 || b.Position() != 0) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 1, a.Position())
+	require.Equal(t, 0, b.Position())
+	require.False(t, a.Leader())
+	require.True(t, b.Leader())
+}
+
+func TestTCPOnly(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	join := func(reg prometheus.Registerer, peers []string) *Peer {
+		p, err := Join(log.NewNopLogger(),
+			reg,
+			"127.0.0.1:0",
+			"",
+			"",
+			peers,
+			false,
+			DefaultPushPullInterval,
+			DefaultGossipInterval,
+			DefaultTcpTimeout,
+			DefaultProbeTimeout,
+			100*time.Millisecond,
+			nil,
+			&WANConfig{TCPOnly: true, SuspicionMult: 6, IndirectChecks: 1},
+			nil,
+			nil,
+			false,
+		)
+		require.NoError(t, err)
+		return p
+	}
+	a := join(reg, nil)
+	defer a.Leave(0)
+	b := join(prometheus.NewRegistry(), []string{a.Self().Address()})
+	defer b.Leave(0)
+
+	require.Equal(t, 2, a.ClusterSize())
+	require.Equal(t, 2, b.ClusterSize())
+
+	// Probes are answered over TCP and their round-trip time is recorded.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && histogramCount(t, a.delegate.probeRTT) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NotZero(t, histogramCount(t, a.delegate.probeRTT))
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	var sent float64
+	for _, mf := range mfs {
+		if mf.GetName() == "alertmanager_cluster_packets_sent_total" {
+			sent = mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	require.NotZero(t, sent)
+}
+
+func histogramCount(t *testing.T, h prometheus.Histogram) uint64 {
+	var m dto.Metric
+	require.NoError(t, h.Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+type fakeResolver struct {
+	srv map[string][]*net.SRV
+	ips map[string][]net.IPAddr
+}
+
+func (r fakeResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	srvs, ok := r.srv[name]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return "", srvs, nil
+}
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r.ips[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return ips, nil
+}
+
+func TestResolvePeersSRV(t *testing.T) {
+	res := fakeResolver{
+		srv: map[string][]*net.SRV{
+			"_mesh._tcp.alertmanager.svc": {
+				{Target: "alertmanager-0.alertmanager.svc", Port: 9094},
+				{Target: "alertmanager-1.alertmanager.svc", Port: 9094},
+			},
+		},
+		ips: map[string][]net.IPAddr{
+			"alertmanager-0.alertmanager.svc": {{IP: net.ParseIP("10.0.0.1")}},
+			"alertmanager-1.alertmanager.svc": {{IP: net.ParseIP("10.0.0.2")}},
+		},
+	}
+
+	peers, err := resolvePeers(context.Background(), []string{"dnssrv+_mesh._tcp.alertmanager.svc", "10.0.1.1:9094"}, "10.0.0.1:9094", res, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.2:9094", "10.0.1.1:9094"}, peers)
+
+	// Names without records resolve to no peers.
+	peers, err = resolvePeers(context.Background(), []string{"dnssrv+_mesh._tcp.other.svc"}, "", res, true)
+	require.NoError(t, err)
+	require.Empty(t, peers)
+
+	// Failing lookups of targets are errors.
+	res.srv["_mesh._tcp.broken.svc"] = []*net.SRV{{Target: "missing.svc", Port: 9094}}
+	_, err = resolvePeers(context.Background(), []string{"dnssrv+_mesh._tcp.broken.svc"}, "", res, false)
+	require.Error(t, err)
+}
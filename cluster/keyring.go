@@ -0,0 +1,95 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+)
+
+// LoadGossipKeys reads the keys encrypting the gossip traffic from the file.
+// It holds one base64 encoded key of 16, 24 or 32 bytes per line. The first
+// key encrypts the traffic, all keys are accepted to decrypt it, so that the
+// key can be rotated with rolling restarts or reloads:
+//
+//  1. add the new key after the old one on all peers,
+//  2. move the new key to the front on all peers,
+//  3. remove the old key from all peers.
+func LoadGossipKeys(filename string) ([][]byte, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]byte
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode gossip key in line %d", i+1)
+		}
+		if err := memberlist.ValidateKey(key); err != nil {
+			return nil, errors.Wrapf(err, "invalid gossip key in line %d", i+1)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.Errorf("no gossip keys found in %q", filename)
+	}
+	return keys, nil
+}
+
+// SetGossipKeys replaces the keys of the gossip encryption. The first key
+// becomes the one encrypting the traffic. Encryption cannot be enabled or
+// disabled once the peer joined the cluster.
+func (p *Peer) SetGossipKeys(keys [][]byte) error {
+	if p.keyring == nil {
+		return errors.New("gossip encryption was not enabled on startup")
+	}
+	if len(keys) == 0 {
+		return errors.New("gossip encryption cannot be disabled while running")
+	}
+	for _, k := range keys {
+		if err := p.keyring.AddKey(k); err != nil {
+			return err
+		}
+	}
+	if err := p.keyring.UseKey(keys[0]); err != nil {
+		return err
+	}
+	for _, old := range p.keyring.GetKeys() {
+		keep := false
+		for _, k := range keys {
+			if bytes.Equal(old, k) {
+				keep = true
+				break
+			}
+		}
+		if !keep {
+			if err := p.keyring.RemoveKey(old); err != nil {
+				return err
+			}
+		}
+	}
+	level.Debug(p.logger).Log("msg", "gossip keys updated", "keys", len(keys))
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGossipKeys(t *testing.T) {
+	var (
+		oldKey = bytes.Repeat([]byte{1}, 16)
+		newKey = bytes.Repeat([]byte{2}, 32)
+	)
+	load := func(content string) ([][]byte, error) {
+		f, err := ioutil.TempFile("", "gossip-keys")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		return LoadGossipKeys(f.Name())
+	}
+
+	keys, err := load("# rotation in progress\n" +
+		base64.StdEncoding.EncodeToString(newKey) + "\n\n" +
+		base64.StdEncoding.EncodeToString(oldKey) + "\n")
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{newKey, oldKey}, keys)
+
+	_, err = load("")
+	require.Error(t, err)
+	_, err = load("not base64\n")
+	require.Error(t, err)
+	_, err = load(base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+}
+
+func TestGossipKeyRotation(t *testing.T) {
+	var (
+		oldKey = bytes.Repeat([]byte{1}, 16)
+		newKey = bytes.Repeat([]byte{2}, 16)
+	)
+	join := func(peers []string, keys ...[]byte) *Peer {
+		p, err := Join(log.NewNopLogger(),
+			prometheus.NewRegistry(),
+			"127.0.0.1:0",
+			"",
+			"",
+			peers,
+			false,
+			DefaultPushPullInterval,
+			DefaultGossipInterval,
+			DefaultTcpTimeout,
+			DefaultProbeTimeout,
+			DefaultProbeInterval,
+			nil,
+			nil,
+			nil,
+			keys,
+			false,
+		)
+		require.NoError(t, err)
+		return p
+	}
+
+	a := join(nil, oldKey)
+	defer a.Leave(0)
+
+	// Peers with another key cannot join.
+	b := join([]string{a.Self().Address()}, newKey)
+	require.Equal(t, 1, b.ClusterSize())
+	b.Leave(0)
+
+	// Peers accepting both keys join during the rotation.
+	require.NoError(t, a.SetGossipKeys([][]byte{oldKey, newKey}))
+	c := join([]string{a.Self().Address()}, newKey, oldKey)
+	defer c.Leave(0)
+	require.Equal(t, 2, c.ClusterSize())
+
+	// Once the old key is removed, only peers with the new key can join.
+	require.NoError(t, a.SetGossipKeys([][]byte{newKey}))
+	require.Equal(t, newKey, a.keyring.GetPrimaryKey())
+	require.Equal(t, [][]byte{newKey}, a.keyring.GetKeys())
+	d := join([]string{a.Self().Address()}, oldKey)
+	require.Equal(t, 1, d.ClusterSize())
+	d.Leave(0)
+	e := join([]string{a.Self().Address()}, newKey)
+	defer e.Leave(0)
+	require.Equal(t, 3, e.ClusterSize())
+
+	unencrypted := join(nil)
+	defer unencrypted.Leave(0)
+	require.Error(t, unencrypted.SetGossipKeys([][]byte{newKey}))
+}
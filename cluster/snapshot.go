@@ -0,0 +1,73 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format.
+const SnapshotVersion = 1
+
+// Snapshot holds the serialized replicated states of an Alertmanager by the
+// keys they are gossiped with, e.g. to move a cluster to new hosts.
+type Snapshot struct {
+	Version   int               `json:"version"`
+	CreatedAt time.Time         `json:"createdAt"`
+	States    map[string][]byte `json:"states"`
+}
+
+// NewSnapshot returns a snapshot of the states.
+func NewSnapshot(states map[string]State) (*Snapshot, error) {
+	s := &Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		States:    make(map[string][]byte, len(states)),
+	}
+	for key, st := range states {
+		b, err := st.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("serializing state %q: %s", key, err)
+		}
+		s.States[key] = b
+	}
+	return s, nil
+}
+
+// Restore merges the states of the snapshot into the given states like
+// states received from peers, so newer local entries are kept. It returns
+// the sorted keys of the restored states. Nothing is restored if the
+// snapshot has an unknown version or state.
+func (s *Snapshot) Restore(states map[string]State) ([]string, error) {
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	keys := make([]string, 0, len(s.States))
+	for key := range s.States {
+		if _, ok := states[key]; !ok {
+			return nil, fmt.Errorf("unknown state %q", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := states[key].Merge(s.States[key]); err != nil {
+			return nil, fmt.Errorf("merging state %q: %s", key, err)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeState concatenates the merged states.
+type fakeState struct {
+	b []byte
+}
+
+func (s *fakeState) MarshalBinary() ([]byte, error) { return s.b, nil }
+
+func (s *fakeState) Merge(b []byte) error {
+	s.b = append(s.b, b...)
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	sil, nfl := &fakeState{b: []byte("sil")}, &fakeState{b: []byte("nfl")}
+	snap, err := NewSnapshot(map[string]State{"sil": sil, "nfl": nfl})
+	require.NoError(t, err)
+	require.Equal(t, SnapshotVersion, snap.Version)
+
+	// The snapshot survives its JSON encoding.
+	b, err := json.Marshal(snap)
+	require.NoError(t, err)
+	var decoded Snapshot
+	require.NoError(t, json.Unmarshal(b, &decoded))
+
+	newSil, newNfl := &fakeState{}, &fakeState{}
+	keys, err := decoded.Restore(map[string]State{"sil": newSil, "nfl": newNfl, "ack": &fakeState{}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nfl", "sil"}, keys)
+	require.Equal(t, "sil", string(newSil.b))
+	require.Equal(t, "nfl", string(newNfl.b))
+
+	// Unknown states are not restored at all.
+	newSil = &fakeState{}
+	_, err = decoded.Restore(map[string]State{"sil": newSil})
+	require.EqualError(t, err, `unknown state "nfl"`)
+	require.Empty(t, newSil.b)
+
+	decoded.Version = 2
+	_, err = decoded.Restore(map[string]State{"sil": newSil, "nfl": newNfl})
+	require.EqualError(t, err, "unsupported snapshot version 2")
+}
@@ -0,0 +1,474 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Connection types sent as first byte on each connection.
+	connTypePacket byte = 'p'
+	connTypeStream byte = 's'
+
+	// maxPacketSize limits the size of a single gossip packet.
+	maxPacketSize = 10 << 20
+)
+
+// TLSConfig configures the mutual TLS authentication of the gossip traffic.
+// Peers are authenticated by their certificate being signed by the CA, host
+// names are not verified.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// Restrict is applied to the TLS configuration of every connection if
+	// not nil, e.g. to enforce the crypto policy of the configuration.
+	Restrict func(*tls.Config)
+}
+
+// tlsConfigs returns the TLS configurations of the server and the client side
+// of connections between peers.
+func (c *TLSConfig) tlsConfigs() (*tls.Config, *tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load certificate")
+	}
+	ca, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load CA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, nil, errors.Errorf("no certificates found in CA file %q", c.CAFile)
+	}
+
+	verify := func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no peer certificate")
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			c, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, c)
+		}
+		opts := x509.VerifyOptions{
+			Roots:         pool,
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		for _, c := range certs[1:] {
+			opts.Intermediates.AddCert(c)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+
+	server := &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		ClientAuth:            tls.RequireAnyClientCert,
+		VerifyPeerCertificate: verify,
+		MinVersion:            tls.VersionTLS12,
+	}
+	if c.Restrict != nil {
+		base := server
+		server = &tls.Config{
+			GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+				cfg := base.Clone()
+				c.Restrict(cfg)
+				return cfg, nil
+			},
+		}
+	}
+	// Peers are addressed by IP, so the default verification of the host
+	// name is replaced by verifying the chain only.
+	client := &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: verify,
+		MinVersion:            tls.VersionTLS12,
+	}
+	return server, client, nil
+}
+
+// peerAddr is the address a peer listens on.
+type peerAddr string
+
+func (a peerAddr) Network() string { return "tcp" }
+func (a peerAddr) String() string  { return string(a) }
+
+// packetConn is an outgoing connection carrying packets to a peer.
+type packetConn struct {
+	mtx  sync.Mutex
+	conn net.Conn
+}
+
+// TCPTransport is a memberlist.Transport sending all gossip traffic over TCP
+// connections, optionally mutually authenticated with TLS. Packets to a peer
+// are framed and sent over a long-lived connection instead of UDP, which
+// works across networks that block or drop UDP.
+type TCPTransport struct {
+	logger   log.Logger
+	bindAddr string
+	// advertiseInterface is the network interface whose address is
+	// advertised if no address is given.
+	advertiseInterface string
+	timeout            time.Duration
+	server             *tls.Config
+	client             *tls.Config
+	restrict           func(*tls.Config)
+	listener           net.Listener
+	packetCh           chan *memberlist.Packet
+	streamCh           chan net.Conn
+	done               chan struct{}
+	wg                 sync.WaitGroup
+
+	packetsSent       prometheus.Counter
+	packetsReceived   prometheus.Counter
+	packetsFailed     prometheus.Counter
+	streamDialsFailed prometheus.Counter
+
+	mtx       sync.Mutex
+	advertise string
+	outgoing  map[string]*packetConn
+	incoming  map[net.Conn]struct{}
+}
+
+// NewTCPTransport returns a transport listening on the bind address. The
+// connections are plain TCP if the TLS configuration is nil. The address of
+// the advertise interface is advertised if no address is given, unless it is
+// empty.
+func NewTCPTransport(l log.Logger, reg prometheus.Registerer, bindAddr string, bindPort int, advertiseInterface string, timeout time.Duration, cfg *TLSConfig) (*TCPTransport, error) {
+	var (
+		server, client *tls.Config
+		restrict       func(*tls.Config)
+		err            error
+	)
+	if cfg != nil {
+		server, client, err = cfg.tlsConfigs()
+		if err != nil {
+			return nil, err
+		}
+		restrict = cfg.Restrict
+	}
+	if timeout <= 0 {
+		timeout = DefaultTcpTimeout
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(bindAddr, strconv.Itoa(bindPort)))
+	if err != nil {
+		return nil, errors.Wrap(err, "listen")
+	}
+	if server != nil {
+		ln = tls.NewListener(ln, server)
+	}
+
+	t := &TCPTransport{
+		logger:             l,
+		bindAddr:           bindAddr,
+		advertiseInterface: advertiseInterface,
+		timeout:            timeout,
+		server:             server,
+		client:             client,
+		restrict:           restrict,
+		listener:           ln,
+		packetCh:           make(chan *memberlist.Packet),
+		streamCh:           make(chan net.Conn),
+		done:               make(chan struct{}),
+		outgoing:           map[string]*packetConn{},
+		incoming:           map[net.Conn]struct{}{},
+		packetsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cluster_packets_sent_total",
+			Help: "Total number of gossip packets sent over TCP.",
+		}),
+		packetsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cluster_packets_received_total",
+			Help: "Total number of gossip packets received over TCP.",
+		}),
+		packetsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cluster_packets_failed_total",
+			Help: "Total number of gossip packets that could not be sent to a peer over TCP.",
+		}),
+		streamDialsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cluster_stream_dials_failed_total",
+			Help: "Total number of stream connections to peers that could not be established.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(t.packetsSent, t.packetsReceived, t.packetsFailed, t.streamDialsFailed)
+	}
+	t.wg.Add(1)
+	go t.accept()
+
+	return t, nil
+}
+
+func (t *TCPTransport) accept() {
+	defer t.wg.Done()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			select {
+			case <-t.done:
+				return
+			default:
+			}
+			level.Warn(t.logger).Log("msg", "Error accepting gossip connection", "err", err)
+			continue
+		}
+		go t.handle(conn)
+	}
+}
+
+// handle reads the connection type and passes streams on to memberlist or
+// reads packets until the connection is closed.
+func (t *TCPTransport) handle(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(t.timeout))
+
+	var typ [1]byte
+	if _, err := io.ReadFull(conn, typ[:]); err != nil {
+		level.Debug(t.logger).Log("msg", "Error reading gossip connection type", "err", err)
+		conn.Close()
+		return
+	}
+	switch typ[0] {
+	case connTypeStream:
+		conn.SetReadDeadline(time.Time{})
+		select {
+		case t.streamCh <- conn:
+		case <-t.done:
+			conn.Close()
+		}
+	case connTypePacket:
+		t.readPackets(conn)
+	default:
+		level.Debug(t.logger).Log("msg", "Unknown gossip connection type", "type", typ[0])
+		conn.Close()
+	}
+}
+
+func (t *TCPTransport) readPackets(conn net.Conn) {
+	t.mtx.Lock()
+	t.incoming[conn] = struct{}{}
+	t.mtx.Unlock()
+
+	defer func() {
+		t.mtx.Lock()
+		delete(t.incoming, conn)
+		t.mtx.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	// The connection starts with the address the sending peer listens on,
+	// which is used to reply to its packets.
+	from, err := readFrame(r)
+	if err != nil {
+		level.Debug(t.logger).Log("msg", "Error reading gossip peer address", "err", err)
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	for {
+		buf, err := readFrame(r)
+		if err != nil {
+			if err != io.EOF {
+				level.Debug(t.logger).Log("msg", "Error reading gossip packet", "from", string(from), "err", err)
+			}
+			return
+		}
+		t.packetsReceived.Inc()
+		select {
+		case t.packetCh <- &memberlist.Packet{Buf: buf, From: peerAddr(from), Timestamp: time.Now()}:
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > maxPacketSize {
+		return nil, errors.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func writeFrame(w io.Writer, b []byte) error {
+	buf := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(buf, uint32(len(b)))
+	copy(buf[4:], b)
+	_, err := w.Write(buf)
+	return err
+}
+
+// FinalAdvertiseAddr implements memberlist.Transport.
+func (t *TCPTransport) FinalAdvertiseAddr(ip string, port int) (net.IP, int, error) {
+	addr, err := calculateAdvertiseAddress(t.bindAddr, ip, t.advertiseInterface)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ip == "" {
+		port = t.listener.Addr().(*net.TCPAddr).Port
+	}
+
+	t.mtx.Lock()
+	t.advertise = net.JoinHostPort(addr.String(), strconv.Itoa(port))
+	t.mtx.Unlock()
+
+	return addr, port, nil
+}
+
+// WriteTo implements memberlist.Transport.
+func (t *TCPTransport) WriteTo(b []byte, addr string) (time.Time, error) {
+	ts, err := t.writeTo(b, addr)
+	if err != nil {
+		t.packetsFailed.Inc()
+		return ts, err
+	}
+	t.packetsSent.Inc()
+	return ts, nil
+}
+
+func (t *TCPTransport) writeTo(b []byte, addr string) (time.Time, error) {
+	t.mtx.Lock()
+	pc, ok := t.outgoing[addr]
+	if !ok {
+		pc = &packetConn{}
+		t.outgoing[addr] = pc
+	}
+	advertise := t.advertise
+	t.mtx.Unlock()
+
+	pc.mtx.Lock()
+	defer pc.mtx.Unlock()
+
+	// A previously used connection may have been closed by the peer, so
+	// retry once on a new connection.
+	for attempt := 0; ; attempt++ {
+		if pc.conn == nil {
+			conn, err := t.dial(addr, connTypePacket, t.timeout)
+			if err != nil {
+				return time.Time{}, err
+			}
+			conn.SetWriteDeadline(time.Now().Add(t.timeout))
+			if err := writeFrame(conn, []byte(advertise)); err != nil {
+				conn.Close()
+				return time.Time{}, err
+			}
+			pc.conn = conn
+		}
+
+		pc.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+		err := writeFrame(pc.conn, b)
+		if err == nil {
+			return time.Now(), nil
+		}
+		pc.conn.Close()
+		pc.conn = nil
+		if attempt > 0 {
+			return time.Time{}, err
+		}
+	}
+}
+
+// PacketCh implements memberlist.Transport.
+func (t *TCPTransport) PacketCh() <-chan *memberlist.Packet {
+	return t.packetCh
+}
+
+// DialTimeout implements memberlist.Transport.
+func (t *TCPTransport) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	conn, err := t.dial(addr, connTypeStream, timeout)
+	if err != nil {
+		t.streamDialsFailed.Inc()
+	}
+	return conn, err
+}
+
+func (t *TCPTransport) dial(addr string, typ byte, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if t.client != nil {
+		cfg := t.client
+		if t.restrict != nil {
+			cfg = cfg.Clone()
+			t.restrict(cfg)
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write([]byte{typ}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetWriteDeadline(time.Time{})
+	return conn, nil
+}
+
+// StreamCh implements memberlist.Transport.
+func (t *TCPTransport) StreamCh() <-chan net.Conn {
+	return t.streamCh
+}
+
+// Shutdown implements memberlist.Transport.
+func (t *TCPTransport) Shutdown() error {
+	close(t.done)
+	err := t.listener.Close()
+	t.wg.Wait()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, pc := range t.outgoing {
+		if pc.conn != nil {
+			pc.conn.Close()
+		}
+	}
+	for conn := range t.incoming {
+		conn.Close()
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificates writes a CA and a certificate signed by it to dir and
+// returns the TLS configuration using them.
+func writeCertificates(t *testing.T, dir, name string) *TLSConfig {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return key
+	}
+	writePEM := func(file, typ string, b []byte) string {
+		path := filepath.Join(dir, file)
+		require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600))
+		return path
+	}
+
+	caKey := newKey()
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name + " CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	key := newKey()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caTmpl, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &TLSConfig{
+		CAFile:   writePEM(name+"-ca.pem", "CERTIFICATE", caDER),
+		CertFile: writePEM(name+".pem", "CERTIFICATE", der),
+		KeyFile:  writePEM(name+"-key.pem", "EC PRIVATE KEY", keyDER),
+	}
+}
+
+func TestTCPTransportTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_transport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := writeCertificates(t, dir, "cluster")
+
+	t1, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, cfg)
+	require.NoError(t, err)
+	defer t1.Shutdown()
+	t2, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, cfg)
+	require.NoError(t, err)
+	defer t2.Shutdown()
+
+	ip1, port1, err := t1.FinalAdvertiseAddr("", 0)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ip1.String())
+	_, port2, err := t2.FinalAdvertiseAddr("", 0)
+	require.NoError(t, err)
+	addr2 := t2.listener.Addr().String()
+	require.Equal(t, t2.listener.Addr().(interface{ String() string }).String(), addr2)
+	require.NotEqual(t, port1, port2)
+
+	// Packets are delivered with the address of the sending peer.
+	for _, msg := range []string{"ping", "ping again"} {
+		_, err = t1.WriteTo([]byte(msg), addr2)
+		require.NoError(t, err)
+		select {
+		case p := <-t2.PacketCh():
+			require.Equal(t, msg, string(p.Buf))
+			require.Equal(t, t1.listener.Addr().String(), p.From.String())
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for packet")
+		}
+	}
+
+	// Streams are passed on unmodified.
+	conn, err := t1.DialTimeout(addr2, time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("stream"))
+	require.NoError(t, err)
+	select {
+	case c := <-t2.StreamCh():
+		defer c.Close()
+		buf := make([]byte, 6)
+		_, err := io.ReadFull(c, buf)
+		require.NoError(t, err)
+		require.Equal(t, "stream", string(buf))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for stream")
+	}
+
+	// Peers with certificates of another CA are rejected.
+	other, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, writeCertificates(t, dir, "other"))
+	require.NoError(t, err)
+	defer other.Shutdown()
+	_, err = other.DialTimeout(addr2, time.Second)
+	require.Error(t, err)
+}
+
+func TestTCPTransportTLSRestrict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls_transport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg1 := writeCertificates(t, dir, "cluster")
+	cfg1.Restrict = func(c *tls.Config) { c.MaxVersion = tls.VersionTLS12 }
+	cfg2 := *cfg1
+	cfg2.Restrict = func(c *tls.Config) { c.MinVersion = tls.VersionTLS13 }
+
+	t1, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, cfg1)
+	require.NoError(t, err)
+	defer t1.Shutdown()
+	t2, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, &cfg2)
+	require.NoError(t, err)
+	defer t2.Shutdown()
+
+	// The restrictions of both sides apply, so that no TLS version is left.
+	_, err = t1.DialTimeout(t2.listener.Addr().String(), time.Second)
+	require.Error(t, err)
+
+	conn, err := t2.DialTimeout(t2.listener.Addr().String(), time.Second)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestTCPTransport(t *testing.T) {
+	t1, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, nil)
+	require.NoError(t, err)
+	defer t1.Shutdown()
+	t2, err := NewTCPTransport(log.NewNopLogger(), prometheus.NewRegistry(), "127.0.0.1", 0, "", time.Second, nil)
+	require.NoError(t, err)
+	defer t2.Shutdown()
+
+	_, _, err = t1.FinalAdvertiseAddr("", 0)
+	require.NoError(t, err)
+	addr2 := t2.listener.Addr().String()
+
+	_, err = t1.WriteTo([]byte("ping"), addr2)
+	require.NoError(t, err)
+	select {
+	case p := <-t2.PacketCh():
+		require.Equal(t, "ping", string(p.Buf))
+		require.Equal(t, t1.listener.Addr().String(), p.From.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for packet")
+	}
+	require.Equal(t, 1.0, counterValue(t, t1.packetsSent))
+	require.Equal(t, 1.0, counterValue(t, t2.packetsReceived))
+
+	// Packets to peers that cannot be reached are counted as failed.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	unreachable := ln.Addr().String()
+	ln.Close()
+	_, err = t1.WriteTo([]byte("ping"), unreachable)
+	require.Error(t, err)
+	require.Equal(t, 1.0, counterValue(t, t1.packetsFailed))
+	_, err = t1.DialTimeout(unreachable, time.Second)
+	require.Error(t, err)
+	require.Equal(t, 1.0, counterValue(t, t1.streamDialsFailed))
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	var m dto.Metric
+	require.NoError(t, c.Write(&m))
+	return m.GetCounter().GetValue()
+}
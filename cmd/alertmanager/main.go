@@ -14,10 +14,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
+	"crypto/tls"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -27,29 +32,50 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/alertmanager/ack"
 	"github.com/prometheus/alertmanager/api"
+	"github.com/prometheus/alertmanager/audit"
 	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/deadman"
+	"github.com/prometheus/alertmanager/delivery"
+	"github.com/prometheus/alertmanager/digest"
 	"github.com/prometheus/alertmanager/dispatch"
-	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/engine"
+	"github.com/prometheus/alertmanager/escalation"
+	"github.com/prometheus/alertmanager/heartbeat"
+	"github.com/prometheus/alertmanager/hook"
+	"github.com/prometheus/alertmanager/ingest/cloudevents"
+	"github.com/prometheus/alertmanager/ingest/email"
+	"github.com/prometheus/alertmanager/ingest/enrich"
+	"github.com/prometheus/alertmanager/ingest/snmp"
+	"github.com/prometheus/alertmanager/ingest/validate"
+	"github.com/prometheus/alertmanager/msgref"
 	"github.com/prometheus/alertmanager/nflog"
 	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/sigv4"
+	"github.com/prometheus/alertmanager/preset"
 	"github.com/prometheus/alertmanager/provider/mem"
 	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/pdsync"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/spool"
+	"github.com/prometheus/alertmanager/storage"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/alertmanager/ui"
+	"github.com/prometheus/alertmanager/web"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/promlog"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/common/version"
-	"github.com/prometheus/prometheus/pkg/labels"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -139,26 +165,69 @@ func main() {
 		panic(err)
 	}
 	var (
-		configFile      = kingpin.Flag("config.file", "Alertmanager configuration file name.").Default("alertmanager.yml").String()
-		dataDir         = kingpin.Flag("storage.path", "Base path for data storage.").Default("data/").String()
-		retention       = kingpin.Flag("data.retention", "How long to keep data for.").Default("120h").Duration()
-		alertGCInterval = kingpin.Flag("alerts.gc-interval", "Interval between alert GC.").Default("30m").Duration()
-		logLevelString  = kingpin.Flag("log.level", "Only log messages with the given severity or above.").Default("info").Enum("debug", "info", "warn", "error")
+		configFile        = kingpin.Flag("config.file", "Alertmanager configuration file name.").Default("alertmanager.yml").String()
+		secretsInterval   = kingpin.Flag("config.secrets-check-interval", "Interval at which the secrets referenced by the configuration are loaded again. The configuration is reloaded if any of them changed. 0 disables the check.").Default("1m").Duration()
+		dataDir           = kingpin.Flag("storage.path", "Base path for data storage.").Default("data/").String()
+		storageBackend    = kingpin.Flag("storage.backend", "Backend the silences and notification log are snapshotted to. The file backend uses the storage path.").Default("file").Enum("file", "s3", "etcd")
+		s3Endpoint        = kingpin.Flag("storage.s3.endpoint", "Base URL of the S3-compatible object storage.").Default("https://s3.amazonaws.com").String()
+		s3Bucket          = kingpin.Flag("storage.s3.bucket", "Bucket to store the snapshots in. Credentials are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.").String()
+		s3Prefix          = kingpin.Flag("storage.s3.prefix", "Prefix of the object names of the snapshots.").Default("").String()
+		s3Region          = kingpin.Flag("storage.s3.region", "Region of the bucket.").Default("us-east-1").String()
+		etcdEndpoints     = kingpin.Flag("storage.etcd.endpoint", "Client URL of an etcd member (may be repeated).").Strings()
+		etcdPrefix        = kingpin.Flag("storage.etcd.prefix", "Prefix of the keys of the snapshots.").Default("alertmanager/").String()
+		retention         = kingpin.Flag("data.retention", "How long to keep data for.").Default("120h").Duration()
+		maintInterval     = kingpin.Flag("data.maintenance-interval", "Interval between garbage collecting and snapshotting the silences, the notification log and the other persisted state.").Default("15m").Duration()
+		silRetention      = kingpin.Flag("silences.retention", "How long to keep expired silences for. Defaults to --data.retention.").Duration()
+		nflRetention      = kingpin.Flag("nflog.retention", "How long to keep notification log entries for. Defaults to --data.retention.").Duration()
+		silenceWebhookURL = kingpin.Flag("silences.activation-webhook-url", "URL to post silences scheduled to start in the future to as JSON once they become active. Only the leader of a cluster posts them. Empty disables the webhook.").Default("").String()
+		alertGCInterval   = kingpin.Flag("alerts.gc-interval", "Interval between alert GC.").Default("30m").Duration()
+		persistAlerts     = kingpin.Flag("alerts.persist", "Persist the alerts in the storage path so that they survive restarts.").Default("false").Bool()
+		dispatchShards    = kingpin.Flag("dispatch.shards", "Number of shards inserting alerts into aggregation groups in parallel. Groups are assigned to shards by their labels. 0 uses one shard per CPU.").Default("0").Int()
+		quarantineFile    = kingpin.Flag("alerts.quarantine-log-file", "File to record received alerts rejected by the alert validation to as JSON lines. Empty disables the quarantine log.").Default("").String()
+		alertStateMetrics = kingpin.Flag("alerts.state-metrics", "Export firing alerts labeled by alert name, severity, receiver and state. The number of series grows with the number of distinct alerts.").Default("false").Bool()
+		logLevelString    = kingpin.Flag("log.level", "Only log messages with the given severity or above.").Default("info").Enum("debug", "info", "warn", "error")
+		auditLogFile      = kingpin.Flag("audit.log-file", "File to record changes of silences, alerts and acknowledgements and configuration reloads to as JSON lines. Empty disables the audit log file.").Default("").String()
+		auditSyslog       = kingpin.Flag("audit.syslog", "Record the audit log to the local syslog daemon.").Default("false").Bool()
 
 		externalURL   = kingpin.Flag("web.external-url", "The URL under which Alertmanager is externally reachable (for example, if Alertmanager is served via a reverse proxy). Used for generating relative and absolute links back to Alertmanager itself. If the URL has a path portion, it will be used to prefix all HTTP endpoints served by Alertmanager. If omitted, relevant URL components will be derived automatically.").String()
 		routePrefix   = kingpin.Flag("web.route-prefix", "Prefix for the internal routes of web endpoints. Defaults to path of --web.external-url.").String()
 		listenAddress = kingpin.Flag("web.listen-address", "Address to listen on for the web interface and API.").Default(":9093").String()
+		webConfigFile = kingpin.Flag("web.config.file", "Web config file enabling TLS, authentication and authorization of the web interface and API.").Default("").String()
+		readTimeout   = kingpin.Flag("web.read-timeout", "Maximum duration for reading a request, including its body. It also ends event streams. 0 disables the timeout.").Default("0s").Duration()
+		writeTimeout  = kingpin.Flag("web.write-timeout", "Maximum duration for writing a response, which also ends event streams. 0 disables the timeout.").Default("0s").Duration()
+		idleTimeout   = kingpin.Flag("web.idle-timeout", "Maximum duration to wait for the next request on keep-alive connections. 0 uses the read timeout.").Default("5m").Duration()
+		maxBodySize   = kingpin.Flag("web.max-request-body-size", "Maximum size of request bodies, e.g. 10MB. 0 disables the limit.").Default("0").Bytes()
+		accessLog     = kingpin.Flag("web.access-log", "Log every request with its status and latency.").Default("false").Bool()
+		drainTimeout  = kingpin.Flag("web.shutdown-timeout", "Maximum duration to wait on shutdown for API requests and notifications in flight to complete.").Default("30s").Duration()
+
+		grpcListenAddress = kingpin.Flag("grpc.listen-address", "Address to listen on for the gRPC API, which uses the TLS and authorization of the web config file. Empty disables the gRPC API.").Default("").String()
+
+		emailGatewayAddr = kingpin.Flag("email-gateway.listen-address", "Address to accept SMTP connections on for converting emails into alerts. Empty disables the email gateway.").Default("").String()
+		snmpTrapAddr     = kingpin.Flag("snmp-traps.listen-address", "UDP address to receive SNMP traps on for converting them into alerts. Empty disables the SNMP trap listener.").Default("").String()
 
 		clusterBindAddr = kingpin.Flag("cluster.listen-address", "Listen address for cluster.").
 				Default(defaultClusterAddr).String()
 		clusterAdvertiseAddr = kingpin.Flag("cluster.advertise-address", "Explicit address to advertise in cluster.").String()
-		peers                = kingpin.Flag("cluster.peer", "Initial peers (may be repeated).").Strings()
+		clusterAdvertiseIf   = kingpin.Flag("cluster.advertise-interface", "Name of the network interface whose address is advertised in cluster if no advertise address is given. IPv6 listen addresses advertise a global unicast IPv6 address of it.").String()
+		peers                = kingpin.Flag("cluster.peer", "Initial peers (may be repeated). Peers prefixed with dnssrv+ are resolved from the DNS SRV records of the name.").Strings()
+		peerRefreshInterval  = kingpin.Flag("cluster.peer-refresh-interval", "Interval for resolving the initial peers again and joining new ones. 0 disables refreshing.").Default("0s").Duration()
 		peerTimeout          = kingpin.Flag("cluster.peer-timeout", "Time to wait between peers to send notifications.").Default("15s").Duration()
 		gossipInterval       = kingpin.Flag("cluster.gossip-interval", "Interval between sending gossip messages. By lowering this value (more frequent) gossip messages are propagated across the cluster more quickly at the expense of increased bandwidth.").Default(cluster.DefaultGossipInterval.String()).Duration()
 		pushPullInterval     = kingpin.Flag("cluster.pushpull-interval", "Interval for gossip state syncs. Setting this interval lower (more frequent) will increase convergence speeds across larger clusters at the expense of increased bandwidth usage.").Default(cluster.DefaultPushPullInterval.String()).Duration()
 		tcpTimeout           = kingpin.Flag("cluster.tcp-timeout", "Timeout for establishing a stream connection with a remote node for a full state sync, and for stream read and write operations.").Default(cluster.DefaultTcpTimeout.String()).Duration()
 		probeTimeout         = kingpin.Flag("cluster.probe-timeout", "Timeout to wait for an ack from a probed node before assuming it is unhealthy. This should be set to 99-percentile of RTT (round-trip time) on your network.").Default(cluster.DefaultProbeTimeout.String()).Duration()
 		probeInterval        = kingpin.Flag("cluster.probe-interval", "Interval between random node probes. Setting this lower (more frequent) will cause the cluster to detect failed nodes more quickly at the expense of increased bandwidth usage.").Default(cluster.DefaultProbeInterval.String()).Duration()
+		clusterZone          = kingpin.Flag("cluster.zone", "Zone of the peer, announced to the other peers and shown in the cluster status.").Default("").String()
+		clusterRegion        = kingpin.Flag("cluster.region", "Region of the peer, announced to the other peers and shown in the cluster status.").Default("").String()
+		crossZonePushPull    = kingpin.Flag("cluster.cross-zone-pushpull-interval", "Interval for gossip state syncs with peers in other zones. If set with --cluster.zone, --cluster.pushpull-interval only applies to peers in the same zone. 0 syncs with peers of all zones alike.").Default("0s").Duration()
+		clusterTCPOnly       = kingpin.Flag("cluster.tcp-only", "Send all gossip traffic over TCP connections instead of UDP, e.g. for peers in networks that block UDP between each other.").Default("false").Bool()
+		suspicionMult        = kingpin.Flag("cluster.suspicion-multiplier", "Multiplier of the probe interval and the logarithm of the cluster size a peer that failed a probe is suspected for before it is declared dead. Raise it for networks with high latency or packet loss.").Default("4").Int()
+		indirectChecks       = kingpin.Flag("cluster.indirect-checks", "Number of peers asked to probe a peer that did not answer a direct probe.").Default("3").Int()
+		clusterTLSCert       = kingpin.Flag("cluster.tls-cert", "Certificate file for mutual TLS authentication and encryption of the gossip traffic.").String()
+		clusterTLSKey        = kingpin.Flag("cluster.tls-key", "Key file for mutual TLS authentication and encryption of the gossip traffic.").String()
+		clusterTLSCA         = kingpin.Flag("cluster.tls-ca", "CA certificate file to verify the certificates of other peers with.").String()
+		gossipKeyFile        = kingpin.Flag("cluster.gossip-key-file", "File with the base64 encoded keys encrypting the gossip traffic, one per line. The first key encrypts, all keys decrypt. The file is read again on reload to rotate keys.").String()
+		clusterStandby       = kingpin.Flag("cluster.standby", "Join the cluster as a standby that receives the replicated state and serves the UI and API but does not send notifications until it is promoted with the /api/v1/cluster/promote endpoint.").Default("false").Bool()
 		settleTimeout        = kingpin.Flag("cluster.settle-timeout", "Maximum time to wait for cluster connections to settle before evaluating notifications.").Default(cluster.DefaultPushPullInterval.String()).Duration()
 	)
 
@@ -172,17 +241,112 @@ func main() {
 	level.Info(logger).Log("msg", "Starting Alertmanager", "version", version.Info())
 	level.Info(logger).Log("build_context", version.BuildContext())
 
+	if *maintInterval <= 0 {
+		level.Error(logger).Log("msg", "Maintenance interval must be positive", "interval", *maintInterval)
+		os.Exit(1)
+	}
+	if *silRetention == 0 {
+		*silRetention = *retention
+	}
+	if *nflRetention == 0 {
+		*nflRetention = *retention
+	}
+
 	err := os.MkdirAll(*dataDir, 0777)
 	if err != nil {
 		level.Error(logger).Log("msg", "Unable to create data directory", "err", err)
 		os.Exit(1)
 	}
 
+	// The silences and the notification log are snapshotted to the storage
+	// backend.
+	var stateStorage storage.Backend
+	switch *storageBackend {
+	case "file":
+		stateStorage = storage.NewFile(*dataDir)
+	case "s3":
+		if *s3Bucket == "" {
+			level.Error(logger).Log("msg", "The S3 storage backend requires a bucket")
+			os.Exit(1)
+		}
+		creds, err := sigv4.EnvCredentials()
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to read S3 credentials", "err", err)
+			os.Exit(1)
+		}
+		stateStorage = storage.NewS3(storage.S3Config{
+			Endpoint:    *s3Endpoint,
+			Bucket:      *s3Bucket,
+			Prefix:      *s3Prefix,
+			Region:      *s3Region,
+			Credentials: creds,
+		}, nil)
+	case "etcd":
+		if len(*etcdEndpoints) == 0 {
+			level.Error(logger).Log("msg", "The etcd storage backend requires at least one endpoint")
+			os.Exit(1)
+		}
+		stateStorage = storage.NewEtcd(storage.EtcdConfig{
+			Endpoints: *etcdEndpoints,
+			Prefix:    *etcdPrefix,
+		}, nil)
+	}
+
+	var auditLoggers []audit.Logger
+	if *auditLogFile != "" {
+		l, err := audit.NewFileLogger(*auditLogFile)
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to open audit log file", "err", err)
+			os.Exit(1)
+		}
+		defer l.Close()
+		auditLoggers = append(auditLoggers, l)
+	}
+	if *auditSyslog {
+		l, err := audit.NewSyslogLogger("alertmanager")
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to connect to syslog for the audit log", "err", err)
+			os.Exit(1)
+		}
+		auditLoggers = append(auditLoggers, l)
+	}
+	var auditor audit.Logger
+	if len(auditLoggers) > 0 {
+		auditor = audit.Multi(auditLoggers...)
+	}
+
+	// The crypto policy of the last loaded configuration restricts the TLS
+	// connections of the cluster and the web server.
+	var cryptoPolicy atomic.Value
+	restrictTLS := func(cfg *tls.Config) {
+		if p, ok := cryptoPolicy.Load().(*config.CryptoPolicy); ok {
+			p.Apply(cfg)
+		}
+	}
+
+	var clusterTLS *cluster.TLSConfig
+	if *clusterTLSCert != "" || *clusterTLSKey != "" || *clusterTLSCA != "" {
+		if *clusterTLSCert == "" || *clusterTLSKey == "" || *clusterTLSCA == "" {
+			level.Error(logger).Log("msg", "--cluster.tls-cert, --cluster.tls-key and --cluster.tls-ca must be given together")
+			os.Exit(1)
+		}
+		clusterTLS = &cluster.TLSConfig{CertFile: *clusterTLSCert, KeyFile: *clusterTLSKey, CAFile: *clusterTLSCA, Restrict: restrictTLS}
+	}
+	var gossipKeys [][]byte
+	if *gossipKeyFile != "" {
+		gossipKeys, err = cluster.LoadGossipKeys(*gossipKeyFile)
+		if err != nil {
+			level.Error(logger).Log("msg", "Loading gossip keys failed", "file", *gossipKeyFile, "err", err)
+			os.Exit(1)
+		}
+	}
+
 	var peer *cluster.Peer
 	if *clusterBindAddr != "" {
 		peer, err = cluster.Join(log.With(logger, "component", "cluster"), prometheus.DefaultRegisterer,
 			*clusterBindAddr,
 			*clusterAdvertiseAddr,
+			*clusterAdvertiseIf,
 			*peers,
 			true,
 			*pushPullInterval,
@@ -190,6 +354,19 @@ func main() {
 			*tcpTimeout,
 			*probeTimeout,
 			*probeInterval,
+			&cluster.ZoneConfig{
+				Zone:                      *clusterZone,
+				Region:                    *clusterRegion,
+				CrossZonePushPullInterval: *crossZonePushPull,
+			},
+			&cluster.WANConfig{
+				TCPOnly:        *clusterTCPOnly,
+				SuspicionMult:  *suspicionMult,
+				IndirectChecks: *indirectChecks,
+			},
+			clusterTLS,
+			gossipKeys,
+			*clusterStandby,
 		)
 		if err != nil {
 			level.Error(logger).Log("msg", "Unable to initialize gossip mesh", "err", err)
@@ -201,6 +378,9 @@ func main() {
 			peer.Leave(10 * time.Second)
 		}()
 		go peer.Settle(ctx, *gossipInterval*10)
+		if *peerRefreshInterval > 0 {
+			go peer.RefreshPeers(*peers, *peerRefreshInterval)
+		}
 	}
 
 	stopc := make(chan struct{})
@@ -208,11 +388,11 @@ func main() {
 	wg.Add(1)
 
 	notificationLogOpts := []nflog.Option{
-		nflog.WithRetention(*retention),
-		nflog.WithSnapshot(filepath.Join(*dataDir, "nflog")),
-		nflog.WithMaintenance(15*time.Minute, stopc, wg.Done),
+		nflog.WithRetention(*nflRetention),
+		nflog.WithMaintenance(*maintInterval, stopc, wg.Done),
 		nflog.WithMetrics(prometheus.DefaultRegisterer),
 		nflog.WithLogger(log.With(logger, "component", "nflog")),
+		nflog.WithStorage(stateStorage, "nflog"),
 	}
 
 	notificationLog, err := nflog.New(notificationLogOpts...)
@@ -229,10 +409,26 @@ func main() {
 	newMarkerMetrics(marker)
 
 	silenceOpts := silence.Options{
-		SnapshotFile: filepath.Join(*dataDir, "silences"),
-		Retention:    *retention,
-		Logger:       log.With(logger, "component", "silences"),
-		Metrics:      prometheus.DefaultRegisterer,
+		Retention:  *silRetention,
+		Marker:     marker,
+		Logger:     log.With(logger, "component", "silences"),
+		Metrics:    prometheus.DefaultRegisterer,
+		Storage:    stateStorage,
+		StorageKey: "silences",
+	}
+	// The client of the silence activation webhook is built with the crypto
+	// policy of each loaded configuration.
+	var silenceWebhookClient atomic.Value
+	if *silenceWebhookURL != "" {
+		silenceOpts.OnActivate = silenceActivationWebhook(
+			*silenceWebhookURL,
+			func() bool { return peer == nil || peer.Leader() },
+			func() *http.Client {
+				c, _ := silenceWebhookClient.Load().(*http.Client)
+				return c
+			},
+			log.With(logger, "component", "silences"),
+		)
 	}
 
 	silences, err := silence.New(silenceOpts)
@@ -248,7 +444,113 @@ func main() {
 	// Start providers before router potentially sends updates.
 	wg.Add(1)
 	go func() {
-		silences.Maintenance(15*time.Minute, filepath.Join(*dataDir, "silences"), stopc)
+		silences.Maintenance(*maintInterval, "", stopc)
+		wg.Done()
+	}()
+	wg.Add(1)
+	go func() {
+		silences.Activations(15*time.Second, stopc)
+		wg.Done()
+	}()
+	wg.Add(1)
+	go func() {
+		silences.Renewals(func() bool { return peer == nil || peer.Leader() }, stopc)
+		wg.Done()
+	}()
+
+	acks, err := ack.New(ack.Options{
+		SnapshotFile: filepath.Join(*dataDir, "acks"),
+		Retention:    *retention,
+		Logger:       log.With(logger, "component", "acks"),
+		Metrics:      prometheus.DefaultRegisterer,
+	})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	if peer != nil {
+		c := peer.AddState("ack", acks)
+		acks.SetBroadcast(c.Broadcast)
+	}
+
+	wg.Add(1)
+	go func() {
+		acks.Maintenance(*maintInterval, filepath.Join(*dataDir, "acks"), stopc)
+		wg.Done()
+	}()
+
+	presets, err := preset.New(preset.Options{
+		SnapshotFile: filepath.Join(*dataDir, "presets"),
+		Retention:    *retention,
+		Logger:       log.With(logger, "component", "presets"),
+		Metrics:      prometheus.DefaultRegisterer,
+	})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	if peer != nil {
+		c := peer.AddState("pre", presets)
+		presets.SetBroadcast(c.Broadcast)
+	}
+
+	wg.Add(1)
+	go func() {
+		presets.Maintenance(*maintInterval, filepath.Join(*dataDir, "presets"), stopc)
+		wg.Done()
+	}()
+
+	refs, err := msgref.New(msgref.Options{
+		SnapshotFile: filepath.Join(*dataDir, "msgrefs"),
+		Retention:    *retention,
+		Logger:       log.With(logger, "component", "msgrefs"),
+	})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	if peer != nil {
+		c := peer.AddState("msr", refs)
+		refs.SetBroadcast(c.Broadcast)
+	}
+
+	wg.Add(1)
+	go func() {
+		refs.Maintenance(*maintInterval, filepath.Join(*dataDir, "msgrefs"), stopc)
+		wg.Done()
+	}()
+
+	notificationSpool, err := spool.New(spool.Options{
+		SnapshotFile: filepath.Join(*dataDir, "spool"),
+		Logger:       log.With(logger, "component", "spool"),
+		Metrics:      prometheus.DefaultRegisterer,
+	})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+	spooler := notify.NewSpooler(notificationSpool, log.With(logger, "component", "spool"))
+
+	wg.Add(1)
+	go func() {
+		notificationSpool.Maintenance(*maintInterval, filepath.Join(*dataDir, "spool"), stopc)
+		wg.Done()
+	}()
+	wg.Add(1)
+	go func() {
+		spooler.Run(time.Minute, stopc)
+		wg.Done()
+	}()
+
+	maintenanceSyncer := pdsync.New(
+		silences,
+		func() bool { return peer == nil || peer.Leader() },
+		prometheus.DefaultRegisterer,
+		log.With(logger, "component", "pdsync"),
+	)
+	wg.Add(1)
+	go func() {
+		maintenanceSyncer.Run(stopc)
 		wg.Done()
 	}()
 
@@ -264,24 +566,160 @@ func main() {
 	}
 	defer alerts.Close()
 
-	var (
-		inhibitor *inhibit.Inhibitor
-		tmpl      *template.Template
-		pipeline  notify.Stage
-		disp      *dispatch.Dispatcher
+	if *persistAlerts {
+		if err := alerts.Restore(filepath.Join(*dataDir, "alerts"), filepath.Join(*dataDir, "alerts.wal")); err != nil {
+			level.Error(logger).Log("msg", "Unable to restore alerts", "err", err)
+			os.Exit(1)
+		}
+		wg.Add(1)
+		go func() {
+			alerts.Maintenance(*maintInterval, stopc, log.With(logger, "component", "alerts"))
+			wg.Done()
+		}()
+	}
+
+	var alertStateCollector *dispatch.AlertStateCollector
+	if *alertStateMetrics {
+		alertStateCollector = dispatch.NewAlertStateCollector(alerts, marker)
+		prometheus.MustRegister(alertStateCollector)
+	}
+
+	var quarantine io.Writer
+	if *quarantineFile != "" {
+		f, err := os.OpenFile(*quarantineFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			level.Error(logger).Log("msg", "Unable to open quarantine log file", "err", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		quarantine = f
+	}
+
+	// Received alerts are validated and enriched before they are put into
+	// the provider.
+	enricher := enrich.New(alerts, prometheus.DefaultRegisterer, log.With(logger, "component", "alert-enricher"))
+	validator := validate.New(enricher, quarantine, prometheus.DefaultRegisterer, log.With(logger, "component", "alert-validation"))
+	emailGateway := email.New(validator, prometheus.DefaultRegisterer, log.With(logger, "component", "email-gateway"))
+	snmpListener := snmp.New(validator, prometheus.DefaultRegisterer, log.With(logger, "component", "snmp-traps"))
+	cloudEvents := cloudevents.New(validator, prometheus.DefaultRegisterer, log.With(logger, "component", "cloudevents"))
+	deliveries := delivery.New(alerts, prometheus.DefaultRegisterer, log.With(logger, "component", "deliveries"))
+
+	digests := digest.New(
+		alerts,
+		func() bool { return peer == nil || peer.Leader() },
+		prometheus.DefaultRegisterer,
+		log.With(logger, "component", "digests"),
 	)
-	defer disp.Stop()
+	wg.Add(1)
+	go func() {
+		digests.Run(stopc)
+		wg.Done()
+	}()
 
-	apiv := api.New(
+	deadMansSwitches := deadman.New(
 		alerts,
-		silences,
-		func(matchers []*labels.Matcher) dispatch.AlertOverview {
-			return disp.Groups(matchers)
+		func() bool { return peer == nil || peer.Leader() },
+		prometheus.DefaultRegisterer,
+		log.With(logger, "component", "dead-mans-switches"),
+	)
+	wg.Add(1)
+	go func() {
+		deadMansSwitches.Run(15*time.Second, stopc)
+		wg.Done()
+	}()
+
+	hooks := hook.New(
+		alerts,
+		func() bool { return peer == nil || peer.Leader() },
+		prometheus.DefaultRegisterer,
+		log.With(logger, "component", "lifecycle-hooks"),
+	)
+	wg.Add(1)
+	go func() {
+		hooks.Run(15*time.Second, stopc)
+		wg.Done()
+	}()
+
+	escalations := escalation.New(log.With(logger, "component", "escalation"))
+
+	// reloadErr is the error of the last configuration reload. Heartbeats are
+	// not sent while it is set or the cluster has not settled yet.
+	var (
+		reloadMtx sync.Mutex
+		reloadErr error
+	)
+	watchdog := heartbeat.New(
+		func() error {
+			reloadMtx.Lock()
+			defer reloadMtx.Unlock()
+			if reloadErr != nil {
+				return fmt.Errorf("loading configuration failed: %s", reloadErr)
+			}
+			if peer != nil && !peer.Ready() {
+				return fmt.Errorf("cluster has not settled")
+			}
+			return nil
 		},
-		marker.Status,
-		peer,
-		logger,
+		prometheus.DefaultRegisterer,
+		log.With(logger, "component", "heartbeat"),
 	)
+	wg.Add(1)
+	go func() {
+		watchdog.Run(stopc)
+		wg.Done()
+	}()
+
+	var (
+		tmpl *template.Template
+		// activeConf is the configuration loaded by the last successful reload.
+		activeConf *config.Config
+	)
+
+	webReload := make(chan chan error)
+	suppressions := notify.NewSuppressions()
+
+	eng := engine.New(engine.Options{
+		Alerts:          alerts,
+		Marker:          marker,
+		Silences:        silences,
+		Acks:            acks,
+		Refs:            refs,
+		NotificationLog: notificationLog,
+		Deliveries:      deliveries,
+		Spooler:         spooler,
+		Suppressions:    suppressions,
+		Peer:            peer,
+		PeerTimeout:     *peerTimeout,
+		Shards:          *dispatchShards,
+		Observer:        hooks,
+		Logger:          logger,
+	})
+	defer eng.Stop()
+
+	apiv := api.New(api.Options{
+		Alerts:            validator,
+		Silences:          silences,
+		Acks:              acks,
+		Presets:           presets,
+		Groups:            eng.Groups,
+		AggrGroups:        eng.AggregationGroups,
+		AlertStatus:       marker.Status,
+		History:           notificationLog.History,
+		PushConfig:        configPusher(*configFile, webReload),
+		Inhibitions:       eng.Inhibitions,
+		Suppressions:      suppressions,
+		IntegrationStatus: deliveries.IntegrationStatus,
+		Auditor:           auditor,
+		Peer:              peer,
+		States: map[string]cluster.State{
+			"nfl": notificationLog,
+			"sil": silences,
+			"ack": acks,
+			"pre": presets,
+			"msr": refs,
+		},
+		Logger: logger,
+	})
 
 	amURL, err := extURL(*listenAddress, *externalURL)
 	if err != nil {
@@ -289,21 +727,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	waitFunc := func() time.Duration { return 0 }
-	if peer != nil {
-		waitFunc = clusterWait(peer, *peerTimeout)
-	}
-	timeoutFunc := func(d time.Duration) time.Duration {
-		if d < notify.MinTimeout {
-			d = notify.MinTimeout
-		}
-		return d + waitFunc()
-	}
-
 	var hash float64
 	reload := func() (err error) {
 		level.Info(logger).Log("msg", "Loading configuration file", "file", *configFile)
 		defer func() {
+			reloadMtx.Lock()
+			reloadErr = err
+			reloadMtx.Unlock()
+
 			if err != nil {
 				level.Error(logger).Log("msg", "Loading configuration file failed", "file", *configFile, "err", err)
 				configSuccess.Set(0)
@@ -312,46 +743,84 @@ func main() {
 				configSuccessTime.Set(float64(time.Now().Unix()))
 				configHash.Set(hash)
 			}
+
+			if auditor != nil {
+				e := &audit.Event{Time: time.Now(), Action: audit.ActionConfigReload, Target: *configFile}
+				if err != nil {
+					e.Error = err.Error()
+				}
+				if err := auditor.Log(e); err != nil {
+					level.Error(logger).Log("msg", "Recording audit event failed", "action", e.Action, "err", err)
+				}
+			}
 		}()
 
 		conf, plainCfg, err := config.LoadFile(*configFile)
 		if err != nil {
 			return err
 		}
+		var webhookClient *http.Client
+		if *silenceWebhookURL != "" {
+			if err := conf.Global.CryptoPolicy.CheckURL(*silenceWebhookURL); err != nil {
+				return fmt.Errorf("silence activation webhook violates the crypto policy: %s", err)
+			}
+			webhookClient, err = config.NewHTTPClient(nil, conf.Global.CryptoPolicy, nil)
+			if err != nil {
+				return err
+			}
+			webhookClient.Timeout = 30 * time.Second
+		}
 
 		hash = md5HashAsMetricValue(plainCfg)
 
-		err = apiv.Update(conf, time.Duration(conf.Global.ResolveTimeout))
+		// The gossip keys are read again to rotate them without restarts.
+		if peer != nil && *gossipKeyFile != "" {
+			keys, err := cluster.LoadGossipKeys(*gossipKeyFile)
+			if err != nil {
+				return err
+			}
+			if err := peer.SetGossipKeys(keys); err != nil {
+				return err
+			}
+		}
+
+		maintenanceSyncer.ApplyConfig(conf.PagerdutyMaintenance)
+		enricher.ApplyConfig(conf)
+		validator.ApplyConfig(conf)
+		emailGateway.ApplyConfig(conf)
+		snmpListener.ApplyConfig(conf)
+		cloudEvents.ApplyConfig(conf)
+		deliveries.ApplyConfig(conf)
+
+		tmpl, err = template.FromConfig(conf)
 		if err != nil {
 			return err
 		}
+		tmpl.ExternalURL = amURL
 
-		tmpl, err = template.FromGlobs(conf.Templates...)
+		watchdog.ApplyConfig(conf.Heartbeats)
+		hooks.ApplyConfig(conf.LifecycleHooks)
+		escalations.ApplyConfig(conf.EscalationProviders)
+		tmpl.Funcs(escalations.FuncMap())
+
+		err = apiv.Update(conf, tmpl, time.Duration(conf.Global.ResolveTimeout))
 		if err != nil {
 			return err
 		}
-		tmpl.ExternalURL = amURL
 
-		inhibitor.Stop()
-		disp.Stop()
-
-		inhibitor = inhibit.NewInhibitor(alerts, conf.InhibitRules, marker, logger)
-		pipeline = notify.BuildPipeline(
-			conf.Receivers,
-			tmpl,
-			waitFunc,
-			inhibitor,
-			silences,
-			notificationLog,
-			marker,
-			peer,
-			logger,
-		)
-		disp = dispatch.NewDispatcher(alerts, dispatch.NewRoute(conf.Route, nil), pipeline, marker, timeoutFunc, logger)
+		digests.ApplyConfig(conf, tmpl)
+		deadMansSwitches.ApplyConfig(conf, tmpl)
 
-		go disp.Run()
-		go inhibitor.Run()
+		routes := eng.ApplyConfig(conf, tmpl)
+		if alertStateCollector != nil {
+			alertStateCollector.SetRoute(routes)
+		}
 
+		cryptoPolicy.Store(conf.Global.CryptoPolicy)
+		if webhookClient != nil {
+			silenceWebhookClient.Store(webhookClient)
+		}
+		activeConf = conf
 		return nil
 	}
 
@@ -372,14 +841,83 @@ func main() {
 		router = router.WithPrefix(*routePrefix)
 	}
 
-	webReload := make(chan chan error)
-
 	ui.Register(router, webReload, logger)
 
 	apiv.Register(router.WithPrefix("/api/v1"))
+	cloudEvents.Register(router.WithPrefix("/api/v1"))
+	apiv.RegisterV2(router.WithPrefix("/api/v2"))
+
+	webConfig := &web.Config{AnonymousRole: web.RoleWrite}
+	if *webConfigFile != "" {
+		if webConfig, err = web.LoadFile(*webConfigFile); err != nil {
+			level.Error(logger).Log("msg", "Loading web config file failed", "file", *webConfigFile, "err", err)
+			os.Exit(1)
+		}
+	}
+	// Health checks and Slack callbacks, which are verified by their
+	// signing secret, do not require authentication.
+	prefix := strings.TrimSuffix(*routePrefix, "/")
+	publicPaths := []string{prefix + "/-/healthy", prefix + "/-/ready", prefix + "/api/v1/slack/"}
 
+	serverOpts := web.ServerOptions{
+		ReadTimeout:        *readTimeout,
+		WriteTimeout:       *writeTimeout,
+		IdleTimeout:        *idleTimeout,
+		MaxRequestBodySize: int64(*maxBodySize),
+		RestrictTLS:        restrictTLS,
+	}
+	if *accessLog {
+		serverOpts.AccessLogger = log.With(logger, "component", "access")
+	}
+	srv, err := web.NewServer(*listenAddress, router, webConfig, serverOpts, publicPaths...)
+	if err != nil {
+		level.Error(logger).Log("msg", "Creating web server failed", "err", err)
+		os.Exit(1)
+	}
 	level.Info(logger).Log("msg", "Listening", "address", *listenAddress)
-	go listen(*listenAddress, router, logger)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			level.Error(logger).Log("msg", "Listen error", "err", err)
+			os.Exit(1)
+		}
+	}()
+
+	var grpcSrv *web.GRPCServer
+	if *grpcListenAddress != "" {
+		grpcSrv, err = web.NewGRPCServer(*grpcListenAddress, webConfig, serverOpts, api.GRPCReadOnly)
+		if err != nil {
+			level.Error(logger).Log("msg", "Creating gRPC server failed", "err", err)
+			os.Exit(1)
+		}
+		apiv.RegisterGRPC(grpcSrv.Server())
+		level.Info(logger).Log("msg", "Listening for gRPC", "address", *grpcListenAddress)
+		go func() {
+			if err := grpcSrv.ListenAndServe(); err != nil {
+				level.Error(logger).Log("msg", "gRPC listen error", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if *emailGatewayAddr != "" {
+		level.Info(logger).Log("msg", "Listening for emails", "address", *emailGatewayAddr)
+		go func() {
+			if err := emailGateway.ListenAndServe(*emailGatewayAddr); err != nil {
+				level.Error(logger).Log("msg", "Email gateway listen error", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if *snmpTrapAddr != "" {
+		level.Info(logger).Log("msg", "Listening for SNMP traps", "address", *snmpTrapAddr)
+		go func() {
+			if err := snmpListener.ListenAndServe(*snmpTrapAddr); err != nil {
+				level.Error(logger).Log("msg", "SNMP trap listen error", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
 
 	var (
 		hup      = make(chan os.Signal)
@@ -389,6 +927,13 @@ func main() {
 	signal.Notify(hup, syscall.SIGHUP)
 	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
 
+	var secretsCheck <-chan time.Time
+	if *secretsInterval > 0 {
+		ticker := time.NewTicker(*secretsInterval)
+		defer ticker.Stop()
+		secretsCheck = ticker.C
+	}
+
 	go func() {
 		<-hupReady
 		for {
@@ -397,6 +942,16 @@ func main() {
 				reload()
 			case errc := <-webReload:
 				errc <- reload()
+			case <-secretsCheck:
+				changed, err := activeConf.SecretsChanged()
+				if err != nil {
+					level.Error(logger).Log("msg", "Loading referenced secrets failed", "err", err)
+					continue
+				}
+				if changed {
+					level.Info(logger).Log("msg", "Referenced secrets changed, reloading configuration")
+					reload()
+				}
 			}
 		}
 	}()
@@ -407,13 +962,143 @@ func main() {
 	<-term
 
 	level.Info(logger).Log("msg", "Received SIGTERM, exiting gracefully...")
+
+	// API requests complete before the notifications in flight, as they may
+	// still add alerts.
+	ctx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		level.Warn(logger).Log("msg", "Canceling API requests in flight on shutdown", "err", err)
+	}
+	if grpcSrv != nil {
+		if err := grpcSrv.Shutdown(ctx); err != nil {
+			level.Warn(logger).Log("msg", "Canceling gRPC calls in flight on shutdown", "err", err)
+		}
+	}
+	eng.Shutdown(ctx)
+}
+
+// configPusher returns a function that validates configuration file content
+// and replaces the configuration file with it. The configuration is reloaded
+// via reloadc and the previous file is restored if reloading fails.
+func configPusher(filename string, reloadc chan<- chan error) func([]byte, bool) (*config.Config, error) {
+	var mtx sync.Mutex
+
+	reload := func() error {
+		errc := make(chan error)
+		reloadc <- errc
+		return <-errc
+	}
+
+	return func(content []byte, dryRun bool) (*config.Config, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		// The candidate is written next to the configuration file, so that
+		// relative paths are resolved the same way and it can be renamed.
+		f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(content); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Chmod(fi.Mode()); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+
+		conf, _, err := config.LoadFile(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := template.FromConfig(conf); err != nil {
+			return nil, err
+		}
+		if dryRun {
+			return conf, nil
+		}
+
+		previous, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Rename(f.Name(), filename); err != nil {
+			return nil, err
+		}
+		if err := reload(); err != nil {
+			if werr := ioutil.WriteFile(filename, previous, fi.Mode()); werr != nil {
+				return nil, fmt.Errorf("%s; restoring the previous configuration failed: %s", err, werr)
+			}
+			if rerr := reload(); rerr != nil {
+				return nil, fmt.Errorf("%s; reloading the previous configuration failed: %s", err, rerr)
+			}
+			return nil, err
+		}
+		return conf, nil
+	}
 }
 
-// clusterWait returns a function that inspects the current peer state and returns
-// a duration of one base timeout for each peer with a higher ID than ourselves.
-func clusterWait(p *cluster.Peer, timeout time.Duration) func() time.Duration {
-	return func() time.Duration {
-		return time.Duration(p.Position()) * timeout
+// silenceActivationWebhook returns a function posting activated silences to
+// the URL in the format of the silences API while leader returns true, so
+// that only one peer of a cluster posts each activation. The silences are
+// posted with the client of the configuration loaded last, which client
+// returns.
+func silenceActivationWebhook(u string, leader func() bool, client func() *http.Client, logger log.Logger) func(*silencepb.Silence) {
+	return func(sil *silencepb.Silence) {
+		if !leader() {
+			return
+		}
+		s := &types.Silence{
+			ID:        sil.Id,
+			StartsAt:  sil.StartsAt,
+			EndsAt:    sil.EndsAt,
+			UpdatedAt: sil.UpdatedAt,
+			CreatedBy: sil.CreatedBy,
+			Comment:   sil.Comment,
+		}
+		s.Status.State = types.SilenceStateActive
+		for _, m := range sil.Matchers {
+			s.Matchers = append(s.Matchers, &types.Matcher{
+				Name:       m.Name,
+				Value:      m.Pattern,
+				IsRegex:    m.Type == silencepb.Matcher_REGEXP || m.Type == silencepb.Matcher_NOT_REGEXP,
+				IsNegative: m.Type == silencepb.Matcher_NOT_EQUAL || m.Type == silencepb.Matcher_NOT_REGEXP,
+			})
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			level.Error(logger).Log("msg", "Encoding activated silence failed", "id", sil.Id, "err", err)
+			return
+		}
+
+		c := client()
+		if c == nil {
+			level.Error(logger).Log("msg", "Posting activated silence failed", "id", sil.Id, "err", "no configuration loaded")
+			return
+		}
+
+		go func() {
+			resp, err := c.Post(u, "application/json", bytes.NewReader(b))
+			if err != nil {
+				level.Error(logger).Log("msg", "Posting activated silence failed", "id", sil.Id, "err", err)
+				return
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				level.Error(logger).Log("msg", "Posting activated silence failed", "id", sil.Id, "err", fmt.Sprintf("unexpected status code %v", resp.StatusCode))
+			}
+		}()
 	}
 }
 
@@ -445,13 +1130,6 @@ func extURL(listen, external string) (*url.URL, error) {
 	return u, nil
 }
 
-func listen(listen string, router *route.Router, logger log.Logger) {
-	if err := http.ListenAndServe(listen, router); err != nil {
-		level.Error(logger).Log("msg", "Listen error", "err", err)
-		os.Exit(1)
-	}
-}
-
 func md5HashAsMetricValue(data []byte) float64 {
 	sum := md5.Sum(data)
 	// We only want 48 bits as a float64 only has a 53 bit mantissa.
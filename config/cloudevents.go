@@ -0,0 +1,81 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// CloudEventsIngestConfig configures how CloudEvents posted to the API are
+// converted into alerts.
+type CloudEventsIngestConfig struct {
+	Rules []*CloudEventsIngestRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CloudEventsIngestConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CloudEventsIngestConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("no rules in cloudevents_ingest config")
+	}
+	return nil
+}
+
+// CloudEventsIngestRule converts CloudEvents into alerts. An event matches a rule
+// if its type and source match the patterns. Label, annotation and generator
+// URL values can reference the attributes of the event as $name, e.g. $type
+// or $subject, and the fields of its JSON data as ${data.path.to.field}.
+type CloudEventsIngestRule struct {
+	Type   Regexp `yaml:"type,omitempty" json:"type,omitempty"`
+	Source Regexp `yaml:"source,omitempty" json:"source,omitempty"`
+	// Resolve marks the alert as resolved if the value of the field, given
+	// like a reference without the $, matches the regex.
+	Resolve *CloudEventsIngestResolve `yaml:"resolve,omitempty" json:"resolve,omitempty"`
+
+	Labels       map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations  map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	GeneratorURL string            `yaml:"generator_url,omitempty" json:"generator_url,omitempty"`
+}
+
+// CloudEventsIngestResolve resolves the alert of an event whose field matches the
+// regex.
+type CloudEventsIngestResolve struct {
+	Field string `yaml:"field" json:"field"`
+	Regex Regexp `yaml:"regex" json:"regex"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *CloudEventsIngestRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain CloudEventsIngestRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if _, ok := r.Labels[model.AlertNameLabel]; !ok {
+		return fmt.Errorf("missing %s label in cloudevents_ingest rule", model.AlertNameLabel)
+	}
+	for k := range r.Labels {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	if r.Resolve != nil && (r.Resolve.Field == "" || r.Resolve.Regex.Regexp == nil) {
+		return fmt.Errorf("resolve of cloudevents_ingest rule requires a field and a regex")
+	}
+	return nil
+}
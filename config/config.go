@@ -18,9 +18,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"text/template"
 	"time"
 
 	commoncfg "github.com/prometheus/common/config"
@@ -39,10 +41,28 @@ func (s Secret) MarshalYAML() (interface{}, error) {
 	return nil, nil
 }
 
-//UnmarshalYAML implements the yaml.Unmarshaler interface for Secrets.
+// UnmarshalYAML implements the yaml.Unmarshaler interface for Secrets. A
+// secret is either given inline or as a reference to an external secret,
+// which is loaded immediately.
 func (s *Secret) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type plain Secret
-	return unmarshal((*plain)(s))
+	if err := unmarshal((*plain)(s)); err == nil {
+		return nil
+	}
+	var ref SecretRef
+	if err := unmarshal(&ref); err != nil {
+		return err
+	}
+	sr := resolver
+	if sr == nil {
+		sr = newSecretResolver("", nil)
+	}
+	v, err := sr.resolve(ref)
+	if err != nil {
+		return err
+	}
+	*s = Secret(v)
+	return nil
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -52,8 +72,30 @@ func (s Secret) MarshalJSON() ([]byte, error) {
 
 // Load parses the YAML input s into a Config.
 func Load(s string) (*Config, error) {
+	return load(s, "")
+}
+
+// load parses the YAML input s into a Config. Secrets referenced by relative
+// file paths are read relative to baseDir.
+func load(s, baseDir string) (*Config, error) {
+	// The crypto policy restricts the connections to Vault, which are made
+	// while the configuration is unmarshaled.
+	var pre struct {
+		Global struct {
+			CryptoPolicy *CryptoPolicy `yaml:"crypto_policy"`
+		} `yaml:"global"`
+	}
+	if err := yaml.Unmarshal([]byte(s), &pre); err != nil {
+		return nil, err
+	}
+
+	sr := newSecretResolver(baseDir, pre.Global.CryptoPolicy)
+	resolverMtx.Lock()
+	resolver = sr
 	cfg := &Config{}
 	err := yaml.UnmarshalStrict([]byte(s), cfg)
+	resolver = nil
+	resolverMtx.Unlock()
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +112,7 @@ func Load(s string) (*Config, error) {
 	}
 
 	cfg.original = s
+	cfg.secrets = sr.loaded
 	return cfg, nil
 }
 
@@ -79,7 +122,7 @@ func LoadFile(filename string) (*Config, []byte, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	cfg, err := Load(string(content))
+	cfg, err := load(string(content), filepath.Dir(filename))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -101,6 +144,16 @@ func resolveFilepaths(baseDir string, cfg *Config) {
 	for i, tf := range cfg.Templates {
 		cfg.Templates[i] = join(tf)
 	}
+	for _, rcv := range cfg.Receivers {
+		for i, tf := range rcv.Templates {
+			rcv.Templates[i] = join(tf)
+		}
+		for _, ec := range rcv.EmailConfigs {
+			if ec.DKIM != nil {
+				ec.DKIM.KeyFile = join(ec.DKIM.KeyFile)
+			}
+		}
+	}
 }
 
 // Config is the top-level configuration for Alertmanager's config files.
@@ -110,9 +163,33 @@ type Config struct {
 	InhibitRules []*InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
 	Receivers    []*Receiver    `yaml:"receivers,omitempty" json:"receivers,omitempty"`
 	Templates    []string       `yaml:"templates" json:"templates"`
+	// Include are glob patterns of files with routes, receivers, inhibition
+	// rules, mute time intervals and templates merged into the
+	// configuration when it is loaded.
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+
+	PagerdutyMaintenance *PagerdutyMaintenanceConfig `yaml:"pagerduty_maintenance,omitempty" json:"pagerduty_maintenance,omitempty"`
+	EmailGateway         *EmailGatewayConfig         `yaml:"email_gateway,omitempty" json:"email_gateway,omitempty"`
+	SNMPTraps            *SNMPTrapConfig             `yaml:"snmp_traps,omitempty" json:"snmp_traps,omitempty"`
+	CloudEventsIngest    *CloudEventsIngestConfig    `yaml:"cloudevents_ingest,omitempty" json:"cloudevents_ingest,omitempty"`
+	Digests              []*DigestConfig             `yaml:"digests,omitempty" json:"digests,omitempty"`
+	DeadMansSwitches     []*DeadMansSwitchConfig     `yaml:"dead_mans_switches,omitempty" json:"dead_mans_switches,omitempty"`
+	SlackInteractive     *SlackInteractiveConfig     `yaml:"slack_interactive,omitempty" json:"slack_interactive,omitempty"`
+	EscalationProviders  []*EscalationProviderConfig `yaml:"escalation_providers,omitempty" json:"escalation_providers,omitempty"`
+	Heartbeats           []*HeartbeatConfig          `yaml:"heartbeats,omitempty" json:"heartbeats,omitempty"`
+	LifecycleHooks       []*LifecycleHookConfig      `yaml:"lifecycle_hooks,omitempty" json:"lifecycle_hooks,omitempty"`
+	MuteTimeIntervals    []*MuteTimeInterval         `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty"`
+	Tenancy              *TenancyConfig              `yaml:"tenancy,omitempty" json:"tenancy,omitempty"`
+	SilenceLimits        *SilenceLimitsConfig        `yaml:"silence_limits,omitempty" json:"silence_limits,omitempty"`
+	SilenceMetadata      []*SilenceMetadataField     `yaml:"silence_metadata,omitempty" json:"silence_metadata,omitempty"`
+	AlertEnrichers       []*AlertEnricherConfig      `yaml:"alert_enrichers,omitempty" json:"alert_enrichers,omitempty"`
+	AlertValidation      *AlertValidationConfig      `yaml:"alert_validation,omitempty" json:"alert_validation,omitempty"`
+	AlertIngestion       *AlertIngestionConfig       `yaml:"alert_ingestion,omitempty" json:"alert_ingestion,omitempty"`
 
 	// original is the input from which the config was parsed.
 	original string
+	// secrets are the secrets loaded from references.
+	secrets []*loadedSecret
 }
 
 func (c Config) String() string {
@@ -132,6 +209,9 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
 	}
+	if err := c.mergeIncludes(); err != nil {
+		return err
+	}
 
 	// If a global block was open but empty the default global config is overwritten.
 	// We have to restore it here.
@@ -179,6 +259,14 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if ec.AuthIdentity == "" {
 				ec.AuthIdentity = c.Global.SMTPAuthIdentity
 			}
+			if ec.AuthOAuth2 == nil {
+				ec.AuthOAuth2 = c.Global.SMTPAuthOAuth2
+			}
+			for _, a := range ec.Attachments {
+				if a.HTTPConfig == nil {
+					a.HTTPConfig = c.Global.HTTPConfig
+				}
+			}
 			if ec.RequireTLS == nil {
 				ec.RequireTLS = new(bool)
 				*ec.RequireTLS = c.Global.SMTPRequireTLS
@@ -188,7 +276,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if sc.HTTPConfig == nil {
 				sc.HTTPConfig = c.Global.HTTPConfig
 			}
-			if sc.APIURL == "" {
+			if sc.APIURL == "" && sc.BotToken == "" {
 				if c.Global.SlackAPIURL == "" {
 					return fmt.Errorf("no global Slack API URL set")
 				}
@@ -301,9 +389,197 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 				voc.APIKey = c.Global.VictorOpsAPIKey
 			}
 		}
+		for _, spc := range rcv.StatuspageConfigs {
+			if spc.HTTPConfig == nil {
+				spc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, goc := range rcv.GrafanaOnCallConfigs {
+			if goc.HTTPConfig == nil {
+				goc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, kc := range rcv.KubernetesConfigs {
+			if kc.HTTPConfig == nil {
+				kc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, mtc := range rcv.MSTeamsConfigs {
+			if mtc.HTTPConfig == nil {
+				mtc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, tc := range rcv.TelegramConfigs {
+			if tc.HTTPConfig == nil {
+				tc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, gcc := range rcv.GoogleChatConfigs {
+			if gcc.HTTPConfig == nil {
+				gcc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, dc := range rcv.DiscordConfigs {
+			if dc.HTTPConfig == nil {
+				dc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, jc := range rcv.JiraConfigs {
+			if jc.HTTPConfig == nil {
+				jc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		for _, sc := range rcv.SNSConfigs {
+			if sc.HTTPConfig == nil {
+				sc.HTTPConfig = c.Global.HTTPConfig
+			}
+		}
+		if df := rcv.DeliveryFailure; df != nil && df.HTTPConfig == nil {
+			df.HTTPConfig = c.Global.HTTPConfig
+		}
+		if rcv.HTTPTransport == nil {
+			rcv.HTTPTransport = c.Global.HTTPTransport
+		}
+		for _, nc := range rcv.notifierConfigs() {
+			nc.HTTPTransport = rcv.HTTPTransport
+		}
+		if err := c.Global.CryptoPolicy.checkReceiver(rcv); err != nil {
+			return err
+		}
 		names[rcv.Name] = struct{}{}
 	}
 
+	digests := map[string]struct{}{}
+	for _, d := range c.Digests {
+		if _, ok := digests[d.Name]; ok {
+			return fmt.Errorf("digest name %q is not unique", d.Name)
+		}
+		if _, ok := names[d.Receiver]; !ok {
+			return fmt.Errorf("undefined receiver %q used in digest %q", d.Receiver, d.Name)
+		}
+		digests[d.Name] = struct{}{}
+	}
+
+	switches := map[string]struct{}{}
+	for _, dms := range c.DeadMansSwitches {
+		if _, ok := switches[dms.Name]; ok {
+			return fmt.Errorf("dead man's switch name %q is not unique", dms.Name)
+		}
+		if _, ok := names[dms.Receiver]; !ok {
+			return fmt.Errorf("undefined receiver %q used in dead man's switch %q", dms.Receiver, dms.Name)
+		}
+		switches[dms.Name] = struct{}{}
+	}
+
+	providers := map[string]struct{}{}
+	for _, ep := range c.EscalationProviders {
+		if _, ok := providers[ep.Name]; ok {
+			return fmt.Errorf("escalation provider name %q is not unique", ep.Name)
+		}
+		if goc := ep.GrafanaOnCall; goc != nil && goc.HTTPConfig == nil {
+			goc.HTTPConfig = c.Global.HTTPConfig
+		}
+		providers[ep.Name] = struct{}{}
+	}
+
+	heartbeats := map[string]struct{}{}
+	for _, hb := range c.Heartbeats {
+		if _, ok := heartbeats[hb.Name]; ok {
+			return fmt.Errorf("heartbeat name %q is not unique", hb.Name)
+		}
+		if hb.HTTPConfig == nil {
+			hb.HTTPConfig = c.Global.HTTPConfig
+		}
+		if og := hb.OpsGenie; og != nil {
+			if og.APIURL == "" {
+				if c.Global.OpsGenieAPIURL == "" {
+					return fmt.Errorf("no global OpsGenie URL set")
+				}
+				og.APIURL = c.Global.OpsGenieAPIURL
+			}
+			if !strings.HasSuffix(og.APIURL, "/") {
+				og.APIURL += "/"
+			}
+			if og.APIKey == "" {
+				if c.Global.OpsGenieAPIKey == "" {
+					return fmt.Errorf("no global OpsGenie API Key set")
+				}
+				og.APIKey = c.Global.OpsGenieAPIKey
+			}
+		}
+		heartbeats[hb.Name] = struct{}{}
+	}
+
+	hooks := map[string]struct{}{}
+	for _, lh := range c.LifecycleHooks {
+		if _, ok := hooks[lh.Name]; ok {
+			return fmt.Errorf("lifecycle hook name %q is not unique", lh.Name)
+		}
+		if lh.HTTPConfig == nil {
+			lh.HTTPConfig = c.Global.HTTPConfig
+		}
+		hooks[lh.Name] = struct{}{}
+	}
+
+	enrichers := map[string]struct{}{}
+	for _, ae := range c.AlertEnrichers {
+		if _, ok := enrichers[ae.Name]; ok {
+			return fmt.Errorf("alert enricher name %q is not unique", ae.Name)
+		}
+		if ae.URL != "" && ae.HTTPConfig == nil {
+			ae.HTTPConfig = c.Global.HTTPConfig
+		}
+		enrichers[ae.Name] = struct{}{}
+	}
+
+	metadataFields := map[string]struct{}{}
+	for _, f := range c.SilenceMetadata {
+		if _, ok := metadataFields[f.Name]; ok {
+			return fmt.Errorf("silence metadata field %q is not unique", f.Name)
+		}
+		metadataFields[f.Name] = struct{}{}
+	}
+
+	byName := map[string]*Receiver{}
+	for _, rcv := range c.Receivers {
+		byName[rcv.Name] = rcv
+	}
+	for _, rcv := range c.Receivers {
+		if rcv.TemplateFallbackReceiver == "" {
+			continue
+		}
+		fallback, ok := byName[rcv.TemplateFallbackReceiver]
+		if !ok {
+			return fmt.Errorf("undefined template fallback receiver %q used in receiver %q", rcv.TemplateFallbackReceiver, rcv.Name)
+		}
+		if fallback.TemplateFallbackReceiver != "" {
+			return fmt.Errorf("template fallback receiver %q of receiver %q must not have a template fallback receiver itself", fallback.Name, rcv.Name)
+		}
+	}
+
+	for _, ir := range c.InhibitRules {
+		for _, name := range ir.Receivers {
+			if _, ok := names[name]; !ok {
+				return fmt.Errorf("undefined receiver %q used in inhibit rule", name)
+			}
+		}
+	}
+
+	muteTimeIntervals := map[string]struct{}{}
+	for _, mt := range c.MuteTimeIntervals {
+		if _, ok := muteTimeIntervals[mt.Name]; ok {
+			return fmt.Errorf("mute time interval name %q is not unique", mt.Name)
+		}
+		muteTimeIntervals[mt.Name] = struct{}{}
+	}
+
+	if pm := c.PagerdutyMaintenance; pm != nil && pm.HTTPConfig == nil {
+		pm.HTTPConfig = c.Global.HTTPConfig
+	}
+	if err := c.Global.CryptoPolicy.checkClients(c); err != nil {
+		return err
+	}
+
 	// The root route must not have any matchers as it is the fallback node
 	// for all alerts.
 	if c.Route == nil {
@@ -315,14 +591,67 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if len(c.Route.Match) > 0 || len(c.Route.MatchRE) > 0 {
 		return fmt.Errorf("root route must not have any matchers")
 	}
+	if err := c.setEnrichmentDefaults(c.Route); err != nil {
+		return err
+	}
+
+	if err := checkMuteTimeIntervals(c.Route, muteTimeIntervals); err != nil {
+		return err
+	}
+	if c.Tenancy != nil {
+		if err := c.Tenancy.checkGroupRelabelConfigs(c.Route); err != nil {
+			return err
+		}
+		c.Tenancy.setGroupBy(c.Route, true)
+	}
 
 	// Validate that all receivers used in the routing tree are defined.
 	return checkReceiver(c.Route, names)
 }
 
+// checkMuteTimeIntervals returns an error if a node in the routing tree
+// references a mute time interval not in the given map, either to mute or
+// to activate its notifications.
+func checkMuteTimeIntervals(r *Route, intervals map[string]struct{}) error {
+	for _, name := range r.MuteTimeIntervals {
+		if _, ok := intervals[name]; !ok {
+			return fmt.Errorf("undefined mute time interval %q used in route", name)
+		}
+	}
+	for _, name := range r.ActiveTimeIntervals {
+		if _, ok := intervals[name]; !ok {
+			return fmt.Errorf("undefined active time interval %q used in route", name)
+		}
+	}
+	for _, sr := range r.Routes {
+		if err := checkMuteTimeIntervals(sr, intervals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // checkReceiver returns an error if a node in the routing tree
 // references a receiver not in the given map.
 func checkReceiver(r *Route, receivers map[string]struct{}) error {
+	chain := map[string]struct{}{r.Receiver: {}}
+	for _, name := range r.FailoverReceivers {
+		if _, ok := receivers[name]; !ok {
+			return fmt.Errorf("undefined failover receiver %q used in route", name)
+		}
+		if _, ok := chain[name]; ok {
+			return fmt.Errorf("receiver %q is used twice in the failover chain of a route", name)
+		}
+		chain[name] = struct{}{}
+	}
+	if e := r.Escalation; e != nil {
+		if _, ok := receivers[e.Receiver]; !ok {
+			return fmt.Errorf("undefined escalation receiver %q used in route", e.Receiver)
+		}
+		if e.Receiver == r.Receiver {
+			return fmt.Errorf("receiver %q cannot escalate to itself", e.Receiver)
+		}
+	}
 	if r.Receiver == "" {
 		return nil
 	}
@@ -359,6 +688,9 @@ type GlobalConfig struct {
 	ResolveTimeout model.Duration `yaml:"resolve_timeout" json:"resolve_timeout"`
 
 	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+	// HTTPTransport tunes the connections of all integrations of receivers
+	// that do not set their own.
+	HTTPTransport *HTTPTransportConfig `yaml:"http_transport,omitempty" json:"http_transport,omitempty"`
 
 	SMTPFrom         string `yaml:"smtp_from,omitempty" json:"smtp_from,omitempty"`
 	SMTPHello        string `yaml:"smtp_hello,omitempty" json:"smtp_hello,omitempty"`
@@ -379,6 +711,24 @@ type GlobalConfig struct {
 	WeChatAPICorpID  string `yaml:"wechat_api_corp_id,omitempty" json:"wechat_api_corp_id,omitempty"`
 	VictorOpsAPIURL  string `yaml:"victorops_api_url,omitempty" json:"victorops_api_url,omitempty"`
 	VictorOpsAPIKey  Secret `yaml:"victorops_api_key,omitempty" json:"victorops_api_key,omitempty"`
+	PrometheusURL    string `yaml:"prometheus_url,omitempty" json:"prometheus_url,omitempty"`
+
+	// SMTPAuthOAuth2 is the default OAuth 2.0 configuration of email
+	// receivers authenticating with XOAUTH2.
+	SMTPAuthOAuth2 *OAuth2Config `yaml:"smtp_auth_oauth2,omitempty" json:"smtp_auth_oauth2,omitempty"`
+
+	// SecretRedaction controls how secrets are rendered in the configuration
+	// exposed by the API.
+	SecretRedaction *SecretRedaction `yaml:"secret_redaction,omitempty" json:"secret_redaction,omitempty"`
+
+	// CryptoPolicy restricts the TLS parameters of the web listener, the
+	// cluster transport, all integrations and all other outbound HTTP
+	// clients, including the one reading secrets from Vault.
+	CryptoPolicy *CryptoPolicy `yaml:"crypto_policy,omitempty" json:"crypto_policy,omitempty"`
+
+	// GroupLimits limit the aggregation groups of all routes together and
+	// the alerts of every group.
+	GroupLimits *GroupLimitsConfig `yaml:"group_limits,omitempty" json:"group_limits,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -392,6 +742,15 @@ func (c *GlobalConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 type Route struct {
 	Receiver string            `yaml:"receiver,omitempty" json:"receiver,omitempty"`
 	GroupBy  []model.LabelName `yaml:"group_by,omitempty" json:"group_by,omitempty"`
+	// GroupRelabelConfigs transform the labels of alerts before they are
+	// grouped. They are inherited by child routes that do not set their own.
+	GroupRelabelConfigs []*GroupRelabelConfig `yaml:"group_relabel_configs,omitempty" json:"group_relabel_configs,omitempty"`
+	// FailoverReceivers are notified in order if notifying the receiver
+	// fails. Child routes setting their own receiver do not inherit them.
+	FailoverReceivers []string `yaml:"failover_receivers,omitempty" json:"failover_receivers,omitempty"`
+	// Escalation notifies another receiver about the groups that keep
+	// firing. Child routes setting their own receiver do not inherit it.
+	Escalation *EscalationConfig `yaml:"escalation,omitempty" json:"escalation,omitempty"`
 
 	Match    map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
 	MatchRE  map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
@@ -401,6 +760,32 @@ type Route struct {
 	GroupWait      *model.Duration `yaml:"group_wait,omitempty" json:"group_wait,omitempty"`
 	GroupInterval  *model.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
 	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+	// GroupFlushJitter is the maximum random delay added to each flush of
+	// an aggregation group, which spreads the notifications of groups that
+	// share their timers. It is inherited by child routes.
+	GroupFlushJitter *model.Duration `yaml:"group_flush_jitter,omitempty" json:"group_flush_jitter,omitempty"`
+	// TimingOverrides change the timers of the aggregation groups whose
+	// labels match the first override. They are inherited by child routes
+	// that do not set their own.
+	TimingOverrides []*TimingOverride `yaml:"timing_overrides,omitempty" json:"timing_overrides,omitempty"`
+	// UrgentGroupWait shortens the group_wait of the aggregation groups
+	// containing matching alerts. It is inherited by child routes that do
+	// not set their own.
+	UrgentGroupWait *UrgentGroupWait `yaml:"urgent_group_wait,omitempty" json:"urgent_group_wait,omitempty"`
+
+	// Enrichments are inherited by child routes that do not set their own.
+	Enrichments []*Enrichment `yaml:"enrichments,omitempty" json:"enrichments,omitempty"`
+	// MuteTimeIntervals are the names of the mute time intervals during
+	// which notifications are not sent. They are inherited by child routes
+	// that do not set their own.
+	MuteTimeIntervals []string `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty"`
+	// ActiveTimeIntervals are the names of the mute time intervals outside
+	// of which notifications are not sent. They are inherited by child
+	// routes that do not set their own.
+	ActiveTimeIntervals []string `yaml:"active_time_intervals,omitempty" json:"active_time_intervals,omitempty"`
+	// GroupLimits limit the aggregation groups of each route. They are
+	// inherited by child routes that do not set their own.
+	GroupLimits *GroupLimitsConfig `yaml:"group_limits,omitempty" json:"group_limits,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -438,6 +823,14 @@ func (r *Route) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return fmt.Errorf("repeat_interval cannot be zero")
 	}
 
+	enrichments := map[string]struct{}{}
+	for _, e := range r.Enrichments {
+		if _, ok := enrichments[e.Name]; ok {
+			return fmt.Errorf("duplicated enrichment %q", e.Name)
+		}
+		enrichments[e.Name] = struct{}{}
+	}
+
 	return nil
 }
 
@@ -457,9 +850,17 @@ type InhibitRule struct {
 	// TargetMatchRE defines pairs like TargetMatch but does regular expression
 	// matching.
 	TargetMatchRE map[string]Regexp `yaml:"target_match_re,omitempty" json:"target_match_re,omitempty"`
+	// TargetMatchRETemplate defines pairs like TargetMatchRE whose regular
+	// expressions are templates expanded with the labels of each source
+	// alert, e.g. '{{ .Labels.datacenter }}-.*'. The label values are
+	// quoted so that they match literally.
+	TargetMatchRETemplate map[string]string `yaml:"target_match_re_template,omitempty" json:"target_match_re_template,omitempty"`
 	// A set of labels that must be equal between the source and target alert
 	// for them to be a match.
 	Equal model.LabelNames `yaml:"equal,omitempty" json:"equal,omitempty"`
+	// Receivers scopes the rule to the notifications of the routes sending
+	// to the given receivers. The rule applies to all routes if empty.
+	Receivers []string `yaml:"receivers,omitempty" json:"receivers,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -493,6 +894,97 @@ func (r *InhibitRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 	}
 
+	for k, v := range r.TargetMatchRETemplate {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+		if _, err := template.New(k).Parse(v); err != nil {
+			return fmt.Errorf("invalid target_match_re_template for label %q: %s", k, err)
+		}
+	}
+
+	return nil
+}
+
+// DefaultPagerdutyMaintenanceConfig provides the defaults for synchronizing
+// PagerDuty maintenance windows.
+var DefaultPagerdutyMaintenanceConfig = PagerdutyMaintenanceConfig{
+	APIURL:       "https://api.pagerduty.com/",
+	PollInterval: model.Duration(1 * time.Minute),
+}
+
+// PagerdutyMaintenanceConfig configures the synchronization of PagerDuty
+// maintenance windows into silences.
+type PagerdutyMaintenanceConfig struct {
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	APIURL       string                         `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	APIKey       Secret                         `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	PollInterval model.Duration                 `yaml:"poll_interval,omitempty" json:"poll_interval,omitempty"`
+	Services     []*PagerdutyMaintenanceService `yaml:"services,omitempty" json:"services,omitempty"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *PagerdutyMaintenanceConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultPagerdutyMaintenanceConfig
+	type plain PagerdutyMaintenanceConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.APIKey == "" {
+		return fmt.Errorf("missing API key in PagerDuty maintenance config")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("poll_interval must be positive in PagerDuty maintenance config")
+	}
+	if len(c.Services) == 0 {
+		return fmt.Errorf("no services in PagerDuty maintenance config")
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	seen := map[string]struct{}{}
+	for _, s := range c.Services {
+		if _, ok := seen[s.ServiceID]; ok {
+			return fmt.Errorf("PagerDuty service %q mapped more than once", s.ServiceID)
+		}
+		seen[s.ServiceID] = struct{}{}
+	}
+	return nil
+}
+
+// PagerdutyMaintenanceService maps a PagerDuty service to the alerts that are
+// silenced during its maintenance windows.
+type PagerdutyMaintenanceService struct {
+	ServiceID string            `yaml:"service_id" json:"service_id"`
+	Match     map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE   map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (s *PagerdutyMaintenanceService) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PagerdutyMaintenanceService
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+	if s.ServiceID == "" {
+		return fmt.Errorf("missing service_id in PagerDuty maintenance service")
+	}
+	if len(s.Match)+len(s.MatchRE) == 0 {
+		return fmt.Errorf("PagerDuty service %q has no matchers", s.ServiceID)
+	}
+	for k := range s.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range s.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
 	return nil
 }
 
@@ -501,15 +993,60 @@ type Receiver struct {
 	// A unique identifier for this receiver.
 	Name string `yaml:"name" json:"name"`
 
-	EmailConfigs     []*EmailConfig     `yaml:"email_configs,omitempty" json:"email_configs,omitempty"`
-	PagerdutyConfigs []*PagerdutyConfig `yaml:"pagerduty_configs,omitempty" json:"pagerduty_configs,omitempty"`
-	HipchatConfigs   []*HipchatConfig   `yaml:"hipchat_configs,omitempty" json:"hipchat_configs,omitempty"`
-	SlackConfigs     []*SlackConfig     `yaml:"slack_configs,omitempty" json:"slack_configs,omitempty"`
-	WebhookConfigs   []*WebhookConfig   `yaml:"webhook_configs,omitempty" json:"webhook_configs,omitempty"`
-	OpsGenieConfigs  []*OpsGenieConfig  `yaml:"opsgenie_configs,omitempty" json:"opsgenie_configs,omitempty"`
-	WechatConfigs    []*WechatConfig    `yaml:"wechat_configs,omitempty" json:"wechat_configs,omitempty"`
-	PushoverConfigs  []*PushoverConfig  `yaml:"pushover_configs,omitempty" json:"pushover_configs,omitempty"`
-	VictorOpsConfigs []*VictorOpsConfig `yaml:"victorops_configs,omitempty" json:"victorops_configs,omitempty"`
+	EmailConfigs         []*EmailConfig         `yaml:"email_configs,omitempty" json:"email_configs,omitempty"`
+	PagerdutyConfigs     []*PagerdutyConfig     `yaml:"pagerduty_configs,omitempty" json:"pagerduty_configs,omitempty"`
+	HipchatConfigs       []*HipchatConfig       `yaml:"hipchat_configs,omitempty" json:"hipchat_configs,omitempty"`
+	SlackConfigs         []*SlackConfig         `yaml:"slack_configs,omitempty" json:"slack_configs,omitempty"`
+	WebhookConfigs       []*WebhookConfig       `yaml:"webhook_configs,omitempty" json:"webhook_configs,omitempty"`
+	OpsGenieConfigs      []*OpsGenieConfig      `yaml:"opsgenie_configs,omitempty" json:"opsgenie_configs,omitempty"`
+	WechatConfigs        []*WechatConfig        `yaml:"wechat_configs,omitempty" json:"wechat_configs,omitempty"`
+	PushoverConfigs      []*PushoverConfig      `yaml:"pushover_configs,omitempty" json:"pushover_configs,omitempty"`
+	VictorOpsConfigs     []*VictorOpsConfig     `yaml:"victorops_configs,omitempty" json:"victorops_configs,omitempty"`
+	StatuspageConfigs    []*StatuspageConfig    `yaml:"statuspage_configs,omitempty" json:"statuspage_configs,omitempty"`
+	GrafanaOnCallConfigs []*GrafanaOnCallConfig `yaml:"grafana_oncall_configs,omitempty" json:"grafana_oncall_configs,omitempty"`
+	KubernetesConfigs    []*KubernetesConfig    `yaml:"kubernetes_configs,omitempty" json:"kubernetes_configs,omitempty"`
+	MSTeamsConfigs       []*MSTeamsConfig       `yaml:"msteams_configs,omitempty" json:"msteams_configs,omitempty"`
+	TelegramConfigs      []*TelegramConfig      `yaml:"telegram_configs,omitempty" json:"telegram_configs,omitempty"`
+	GoogleChatConfigs    []*GoogleChatConfig    `yaml:"googlechat_configs,omitempty" json:"googlechat_configs,omitempty"`
+	DiscordConfigs       []*DiscordConfig       `yaml:"discord_configs,omitempty" json:"discord_configs,omitempty"`
+	JiraConfigs          []*JiraConfig          `yaml:"jira_configs,omitempty" json:"jira_configs,omitempty"`
+	SNSConfigs           []*SNSConfig           `yaml:"sns_configs,omitempty" json:"sns_configs,omitempty"`
+	ExecConfigs          []*ExecConfig          `yaml:"exec_configs,omitempty" json:"exec_configs,omitempty"`
+	MQTTConfigs          []*MQTTConfig          `yaml:"mqtt_configs,omitempty" json:"mqtt_configs,omitempty"`
+
+	// RateLimit limits the notifications sent by each of the integrations.
+	RateLimit *RateLimitConfig `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+	// DigestWindow makes the integrations accumulate the notifications of
+	// all groups over the window and send them as a single notification
+	// listing the groups at its end. Zero sends notifications immediately.
+	DigestWindow model.Duration `yaml:"digest_window,omitempty" json:"digest_window,omitempty"`
+	// FlapDetection holds or marks notifications of flapping alerts.
+	FlapDetection *FlapDetectionConfig `yaml:"flap_detection,omitempty" json:"flap_detection,omitempty"`
+	// DeliveryFailure reports the receiver when too many of its
+	// notifications fail.
+	DeliveryFailure *DeliveryFailureConfig `yaml:"delivery_failure,omitempty" json:"delivery_failure,omitempty"`
+	// MaxConcurrentNotifications limits the notifications the integrations
+	// of the receiver send at the same time. Zero means unlimited.
+	MaxConcurrentNotifications int `yaml:"max_concurrent_notifications,omitempty" json:"max_concurrent_notifications,omitempty"`
+	// TemplateFallbackReceiver is notified instead if an integration of the
+	// receiver fails to template the fields identifying the recipient, such
+	// as the routing key of VictorOps or the channel of Slack.
+	TemplateFallbackReceiver string `yaml:"template_fallback_receiver,omitempty" json:"template_fallback_receiver,omitempty"`
+	// Templates are the template files only used by the integrations of
+	// the receiver, in addition to the global template files.
+	Templates []string `yaml:"templates,omitempty" json:"templates,omitempty"`
+	// RepeatAcknowledged keeps sending repeat notifications for groups
+	// whose firing alerts are all acknowledged.
+	RepeatAcknowledged bool `yaml:"repeat_acknowledged,omitempty" json:"repeat_acknowledged,omitempty"`
+	// HTTPTransport tunes the connections of the integrations of the
+	// receiver. It overrides the global http_transport.
+	HTTPTransport *HTTPTransportConfig `yaml:"http_transport,omitempty" json:"http_transport,omitempty"`
+	// Spool keeps notifications that failed after all retries on disk and
+	// replays them once the integration recovers.
+	Spool *SpoolConfig `yaml:"spool,omitempty" json:"spool,omitempty"`
+	// Coordination configures how the peers of a cluster avoid notifying
+	// the receiver twice.
+	Coordination *CoordinationConfig `yaml:"coordination,omitempty" json:"coordination,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -521,6 +1058,260 @@ func (c *Receiver) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Name == "" {
 		return fmt.Errorf("missing name in receiver")
 	}
+	if c.MaxConcurrentNotifications < 0 {
+		return fmt.Errorf("max_concurrent_notifications cannot be negative")
+	}
+	if c.TemplateFallbackReceiver == c.Name {
+		return fmt.Errorf("receiver %q cannot be its own template fallback receiver", c.Name)
+	}
+	return nil
+}
+
+// DefaultSpoolConfig provides the defaults for spooling failed notifications.
+var DefaultSpoolConfig = SpoolConfig{
+	MaxAge:  model.Duration(24 * time.Hour),
+	MaxSize: 16 << 20,
+}
+
+// SpoolConfig limits the failed notifications spooled for a receiver. The
+// oldest notifications are dropped first once the spool exceeds its size.
+type SpoolConfig struct {
+	// MaxAge is how long a failed notification is replayed.
+	MaxAge model.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+	// MaxSize is the maximum size in bytes of the spooled notifications of
+	// the receiver.
+	MaxSize int64 `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SpoolConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultSpoolConfig
+	type plain SpoolConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxAge <= 0 {
+		return fmt.Errorf("max_age of the spool must be positive")
+	}
+	if c.MaxSize <= 0 {
+		return fmt.Errorf("max_size of the spool must be positive")
+	}
+	return nil
+}
+
+// DefaultRateLimitConfig provides the defaults for rate limits of receivers.
+var DefaultRateLimitConfig = RateLimitConfig{
+	Period: model.Duration(1 * time.Hour),
+}
+
+// RateLimitConfig limits the number of notifications an integration sends
+// per period. Notifications over the limit are summarized in a single
+// notification at the end of the period.
+type RateLimitConfig struct {
+	Max    int            `yaml:"max" json:"max"`
+	Period model.Duration `yaml:"period,omitempty" json:"period,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *RateLimitConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultRateLimitConfig
+	type plain RateLimitConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Max <= 0 {
+		return fmt.Errorf("max must be positive in rate limit config")
+	}
+	if c.Period <= 0 {
+		return fmt.Errorf("period must be positive in rate limit config")
+	}
+	return nil
+}
+
+// Actions taken on notifications of flapping alerts.
+const (
+	FlapActionHold     = "hold"
+	FlapActionAnnotate = "annotate"
+)
+
+// DefaultFlapDetectionConfig provides the defaults for flap detection.
+var DefaultFlapDetectionConfig = FlapDetectionConfig{
+	Window:    model.Duration(1 * time.Hour),
+	Threshold: 4,
+	Action:    FlapActionHold,
+}
+
+// FlapDetectionConfig configures the detection of alerts changing between
+// firing and resolved too often. An alert is flapping if it changed its
+// state at least threshold times within the window.
+type FlapDetectionConfig struct {
+	Window    model.Duration `yaml:"window,omitempty" json:"window,omitempty"`
+	Threshold int            `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	// Action is either hold, which leaves flapping alerts out of
+	// notifications, or annotate, which adds the flapping annotation.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *FlapDetectionConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultFlapDetectionConfig
+	type plain FlapDetectionConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive in flap detection config")
+	}
+	if c.Threshold < 2 {
+		return fmt.Errorf("threshold must be at least 2 in flap detection config")
+	}
+	switch c.Action {
+	case FlapActionHold, FlapActionAnnotate:
+	default:
+		return fmt.Errorf("unknown flap detection action %q, must be one of %q or %q", c.Action, FlapActionHold, FlapActionAnnotate)
+	}
+	return nil
+}
+
+// Modes coordinating the notifications of the peers of a cluster.
+const (
+	// CoordinationBestEffort notifies unless another peer logged the
+	// notification before, even if the peer is cut off from the cluster.
+	CoordinationBestEffort = "best_effort"
+	// CoordinationStrict only notifies if the peer reaches a quorum of the
+	// cluster, so that only one side of a partition notifies.
+	CoordinationStrict = "strict"
+)
+
+// DefaultCoordinationConfig provides the defaults for the coordination of
+// notifications.
+var DefaultCoordinationConfig = CoordinationConfig{
+	Mode:          CoordinationBestEffort,
+	QuorumTimeout: model.Duration(1 * time.Minute),
+}
+
+// CoordinationConfig configures how the peers of a cluster coordinate the
+// notifications of a receiver.
+type CoordinationConfig struct {
+	// Mode is either best_effort or strict.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// PeerWait is the time each peer waits for the notification log of the
+	// previous peer before notifying. It overrides --cluster.peer-timeout.
+	PeerWait model.Duration `yaml:"peer_wait,omitempty" json:"peer_wait,omitempty"`
+	// QuorumTimeout is the time since which the peer must have heard from
+	// the members counted for the quorum of the strict mode.
+	QuorumTimeout model.Duration `yaml:"quorum_timeout,omitempty" json:"quorum_timeout,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CoordinationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultCoordinationConfig
+	type plain CoordinationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	switch c.Mode {
+	case CoordinationBestEffort, CoordinationStrict:
+	default:
+		return fmt.Errorf("unknown coordination mode %q, must be one of %q or %q", c.Mode, CoordinationBestEffort, CoordinationStrict)
+	}
+	if c.PeerWait < 0 {
+		return fmt.Errorf("peer_wait must not be negative in coordination config")
+	}
+	if c.QuorumTimeout <= 0 {
+		return fmt.Errorf("quorum_timeout must be positive in coordination config")
+	}
+	return nil
+}
+
+// DefaultDeliveryFailureConfig provides the defaults for delivery failure
+// reports.
+var DefaultDeliveryFailureConfig = DeliveryFailureConfig{
+	Window:      model.Duration(15 * time.Minute),
+	Threshold:   0.5,
+	MinAttempts: 3,
+}
+
+// DeliveryFailureConfig configures the report of a receiver whose ratio of
+// failed notifications within the window reaches the threshold. The
+// receiver is reported by a synthetic alert, a request to an admin
+// webhook or both.
+type DeliveryFailureConfig struct {
+	Window      model.Duration `yaml:"window,omitempty" json:"window,omitempty"`
+	Threshold   float64        `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	MinAttempts int            `yaml:"min_attempts,omitempty" json:"min_attempts,omitempty"`
+
+	// Alert fires the ReceiverDeliveryFailing alert for the receiver.
+	Alert bool `yaml:"alert,omitempty" json:"alert,omitempty"`
+	// WebhookURL is sent the state of the receiver when it starts and stops
+	// failing.
+	WebhookURL string                      `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *DeliveryFailureConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDeliveryFailureConfig
+	type plain DeliveryFailureConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive in delivery failure config")
+	}
+	if c.Threshold <= 0 || c.Threshold > 1 {
+		return fmt.Errorf("threshold must be in (0, 1] in delivery failure config")
+	}
+	if c.MinAttempts < 1 {
+		return fmt.Errorf("min_attempts must be positive in delivery failure config")
+	}
+	if !c.Alert && c.WebhookURL == "" {
+		return fmt.Errorf("alert or webhook_url must be set in delivery failure config")
+	}
+	if c.WebhookURL != "" {
+		if _, err := url.Parse(c.WebhookURL); err != nil {
+			return fmt.Errorf("invalid webhook_url in delivery failure config: %s", err)
+		}
+	}
+	return nil
+}
+
+// Actions taken on alerts exceeding group limits.
+const (
+	GroupOverflowDrop     = "drop"
+	GroupOverflowCollapse = "collapse"
+)
+
+// GroupLimitsConfig limits the number of aggregation groups and the number
+// of alerts per group. Zero means no limit.
+type GroupLimitsConfig struct {
+	MaxGroups         int `yaml:"max_groups,omitempty" json:"max_groups,omitempty"`
+	MaxAlertsPerGroup int `yaml:"max_alerts_per_group,omitempty" json:"max_alerts_per_group,omitempty"`
+	// Overflow is either drop, which discards alerts that would create a
+	// group over the limit, or collapse, which adds them to a catch-all
+	// group without group labels. Alerts over the limit of alerts per
+	// group are always dropped.
+	Overflow string `yaml:"overflow,omitempty" json:"overflow,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GroupLimitsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GroupLimitsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxGroups < 0 || c.MaxAlertsPerGroup < 0 {
+		return fmt.Errorf("group limits must not be negative")
+	}
+	switch c.Overflow {
+	case "":
+		c.Overflow = GroupOverflowDrop
+	case GroupOverflowDrop, GroupOverflowCollapse:
+	default:
+		return fmt.Errorf("unknown group limits overflow %q, must be one of %q or %q", c.Overflow, GroupOverflowDrop, GroupOverflowCollapse)
+	}
 	return nil
 }
 
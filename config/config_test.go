@@ -143,6 +143,839 @@ receivers:
 
 }
 
+func TestEnrichmentDefaultPrometheusURL(t *testing.T) {
+	in := `
+global:
+  prometheus_url: 'http://prometheus:9090/'
+route:
+  receiver: team-X
+  routes:
+  - match:
+      service: db
+    enrichments:
+    - name: up
+      query: 'up{service="db"}'
+
+receivers:
+- name: 'team-X'
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if url := conf.Route.Routes[0].Enrichments[0].PrometheusURL; url != "http://prometheus:9090" {
+		t.Errorf("expected Prometheus URL %q, got %q", "http://prometheus:9090", url)
+	}
+}
+
+func TestEnrichmentNoPrometheusURL(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+  enrichments:
+  - name: up
+    query: 'up'
+
+receivers:
+- name: 'team-X'
+`
+	_, err := Load(in)
+
+	expected := "no global Prometheus URL set for enrichment \"up\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestDigestUndefinedReceiver(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+digests:
+- name: daily
+  receiver: team-Y
+  times: ['09:00']
+`
+	_, err := Load(in)
+
+	expected := "undefined receiver \"team-Y\" used in digest \"daily\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestDeadMansSwitchUndefinedReceiver(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+dead_mans_switches:
+- name: prometheus
+  receiver: team-Y
+  match:
+    alertname: Watchdog
+`
+	_, err := Load(in)
+
+	expected := "undefined receiver \"team-Y\" used in dead man's switch \"prometheus\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestDeadMansSwitchDefaults(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+dead_mans_switches:
+- name: prometheus
+  receiver: team-X
+  match:
+    alertname: Watchdog
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	d := conf.DeadMansSwitches[0]
+	if d.Timeout != DefaultDeadMansSwitchConfig.Timeout {
+		t.Errorf("unexpected timeout %s", d.Timeout)
+	}
+	if d.RepeatInterval != DefaultDeadMansSwitchConfig.RepeatInterval {
+		t.Errorf("unexpected repeat interval %s", d.RepeatInterval)
+	}
+}
+
+func TestDeadMansSwitchMissingMatchers(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+dead_mans_switches:
+- name: prometheus
+  receiver: team-X
+`
+	_, err := Load(in)
+
+	expected := "missing match or match_re in dead man's switch \"prometheus\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestDigestSchedule(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+digests:
+- name: weekly
+  receiver: team-X
+  times: ['09:30', '17:00']
+  weekdays: [monday, Friday]
+  time_zone: Europe/Berlin
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	d := conf.Digests[0]
+	if !reflect.DeepEqual(d.Times, []TimeOfDay{{9, 30}, {17, 0}}) {
+		t.Errorf("unexpected times %v", d.Times)
+	}
+	if !reflect.DeepEqual(d.Weekdays, []Weekday{Weekday(time.Monday), Weekday(time.Friday)}) {
+		t.Errorf("unexpected weekdays %v", d.Weekdays)
+	}
+	if d.TimeZone.String() != "Europe/Berlin" {
+		t.Errorf("unexpected time zone %s", d.TimeZone)
+	}
+}
+
+func TestHeartbeatDefaults(t *testing.T) {
+	in := `
+global:
+  opsgenie_api_key: secret
+
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+heartbeats:
+- name: opsgenie
+  opsgenie:
+    heartbeat: alertmanager
+- name: healthchecks
+  url: https://hc-ping.com/5f5b4b2e
+  method: get
+  interval: 5m
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	og := conf.Heartbeats[0]
+	if og.Interval != model.Duration(time.Minute) {
+		t.Errorf("unexpected interval %s", og.Interval)
+	}
+	if og.OpsGenie.APIURL != "https://api.opsgenie.com/" || og.OpsGenie.APIKey != "secret" {
+		t.Errorf("global OpsGenie settings not applied: %+v", og.OpsGenie)
+	}
+	if og.HTTPConfig == nil {
+		t.Errorf("global HTTP config not applied")
+	}
+	hc := conf.Heartbeats[1]
+	if hc.Method != "GET" || hc.Interval != model.Duration(5*time.Minute) {
+		t.Errorf("unexpected heartbeat %+v", hc)
+	}
+}
+
+func TestHeartbeatTarget(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+heartbeats:
+- name: both
+  url: https://hc-ping.com/5f5b4b2e
+  opsgenie:
+    heartbeat: alertmanager
+`
+	_, err := Load(in)
+
+	expected := "heartbeat \"both\" must have exactly one of url and opsgenie"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestLifecycleHookUnknownEvent(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+lifecycle_hooks:
+- name: tracker
+  url: https://tracker.example.com/hooks/alertmanager
+  events: [alert_first_seen, alert_silenced]
+`
+	_, err := Load(in)
+
+	expected := "unknown event \"alert_silenced\" in lifecycle hook \"tracker\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestAlertValidationUnknownInvalidUTF8(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+alert_validation:
+  max_labels: 20
+  invalid_utf8: ignore
+`
+	_, err := Load(in)
+
+	expected := "unknown invalid_utf8 action \"ignore\", must be one of \"reject\", \"drop\" or \"normalize\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestLifecycleHookNameIsUnique(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+lifecycle_hooks:
+- name: tracker
+  url: https://tracker.example.com/hooks/alertmanager
+- name: tracker
+  url: https://tracker.example.com/hooks/other
+`
+	_, err := Load(in)
+
+	expected := "lifecycle hook name \"tracker\" is not unique"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestReceiverRateLimit(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  rate_limit:
+    max: 10
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	rl := conf.Receivers[0].RateLimit
+	if rl.Max != 10 || rl.Period != model.Duration(time.Hour) {
+		t.Errorf("unexpected rate limit %+v", rl)
+	}
+
+	in = `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  rate_limit:
+    period: 1h
+`
+	_, err = Load(in)
+
+	expected := "max must be positive in rate limit config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestReceiverDigestWindow(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  digest_window: 15m
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w := conf.Receivers[0].DigestWindow; w != model.Duration(15*time.Minute) {
+		t.Errorf("unexpected digest window %s", w)
+	}
+}
+
+func TestReceiverFlapDetection(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  flap_detection:
+    window: 30m
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	fd := conf.Receivers[0].FlapDetection
+	if fd.Window != model.Duration(30*time.Minute) || fd.Threshold != 4 || fd.Action != FlapActionHold {
+		t.Errorf("unexpected flap detection %+v", fd)
+	}
+
+	in = `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  flap_detection:
+    action: drop
+`
+	_, err = Load(in)
+
+	expected := "unknown flap detection action \"drop\", must be one of \"hold\" or \"annotate\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestReceiverDeliveryFailure(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  delivery_failure:
+    alert: true
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	df := conf.Receivers[0].DeliveryFailure
+	if df.Window != model.Duration(15*time.Minute) || df.Threshold != 0.5 || df.MinAttempts != 3 {
+		t.Errorf("unexpected delivery failure %+v", df)
+	}
+
+	in = `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  delivery_failure:
+    threshold: 0.9
+`
+	_, err = Load(in)
+
+	expected := "alert or webhook_url must be set in delivery failure config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestRouteGroupLimits(t *testing.T) {
+	in := `
+global:
+  group_limits:
+    max_groups: 1000
+route:
+  receiver: team-X
+  group_limits:
+    max_groups: 100
+    max_alerts_per_group: 50
+
+receivers:
+- name: 'team-X'
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gl := conf.Route.GroupLimits
+	if gl.MaxGroups != 100 || gl.MaxAlertsPerGroup != 50 || gl.Overflow != GroupOverflowDrop {
+		t.Errorf("unexpected group limits %+v", gl)
+	}
+	if conf.Global.GroupLimits.MaxGroups != 1000 {
+		t.Errorf("unexpected global group limits %+v", conf.Global.GroupLimits)
+	}
+
+	in = `
+route:
+  receiver: team-X
+  group_limits:
+    max_groups: 100
+    overflow: merge
+
+receivers:
+- name: 'team-X'
+`
+	_, err = Load(in)
+
+	expected := "unknown group limits overflow \"merge\", must be one of \"drop\" or \"collapse\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestFailoverReceivers(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+route:
+  receiver: team-X-slack
+  failover_receivers: [team-X-mails, team-Y]
+
+receivers:
+- name: 'team-X-slack'
+- name: 'team-X-mails'
+`,
+			expected: "undefined failover receiver \"team-Y\" used in route",
+		},
+		{
+			in: `
+route:
+  receiver: team-X-slack
+  routes:
+  - match:
+      severity: page
+    receiver: team-X-mails
+    failover_receivers: [team-X-slack, team-X-mails]
+
+receivers:
+- name: 'team-X-slack'
+- name: 'team-X-mails'
+`,
+			expected: "receiver \"team-X-mails\" is used twice in the failover chain of a route",
+		},
+	} {
+		_, err := Load(tc.in)
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%q", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%q\ngot:\n%q", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestEscalation(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+route:
+  receiver: team-X
+  escalation:
+    receiver: team-X
+    after_repeats: 3
+
+receivers:
+- name: 'team-X'
+`,
+			expected: "receiver \"team-X\" cannot escalate to itself",
+		},
+		{
+			in: `
+route:
+  receiver: team-X
+  escalation:
+    receiver: team-Y
+    after_repeats: 3
+
+receivers:
+- name: 'team-X'
+`,
+			expected: "undefined escalation receiver \"team-Y\" used in route",
+		},
+		{
+			in: `
+route:
+  receiver: team-X
+  escalation:
+    receiver: team-Y
+
+receivers:
+- name: 'team-X'
+- name: 'team-Y'
+`,
+			expected: "after_repeats must be positive in escalation config",
+		},
+	} {
+		_, err := Load(tc.in)
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%q", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%q\ngot:\n%q", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestTemplateFallbackReceiver(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  template_fallback_receiver: team-Y
+`,
+			expected: "undefined template fallback receiver \"team-Y\" used in receiver \"team-X\"",
+		},
+		{
+			in: `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  template_fallback_receiver: team-X
+`,
+			expected: "receiver \"team-X\" cannot be its own template fallback receiver",
+		},
+		{
+			in: `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  template_fallback_receiver: team-Y
+- name: 'team-Y'
+  template_fallback_receiver: team-Z
+- name: 'team-Z'
+`,
+			expected: "template fallback receiver \"team-Y\" of receiver \"team-X\" must not have a template fallback receiver itself",
+		},
+	} {
+		_, err := Load(tc.in)
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%q", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%q\ngot:\n%q", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestNegativeMaxConcurrentNotifications(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+  max_concurrent_notifications: -1
+`
+	_, err := Load(in)
+
+	expected := "max_concurrent_notifications cannot be negative"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestMuteTimeIntervalUndefined(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+  routes:
+  - match:
+      severity: page
+    mute_time_intervals: [nights]
+
+receivers:
+- name: 'team-X'
+
+mute_time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+`
+	_, err := Load(in)
+
+	expected := "undefined mute time interval \"nights\" used in route"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestActiveTimeIntervalUndefined(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+  routes:
+  - receiver: team-X-chat
+    active_time_intervals: [business-hours]
+
+receivers:
+- name: 'team-X'
+- name: 'team-X-chat'
+
+mute_time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+`
+	_, err := Load(in)
+
+	expected := "undefined active time interval \"business-hours\" used in route"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestMuteTimeIntervalContainsTime(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+  mute_time_intervals: [off-hours]
+
+receivers:
+- name: 'team-X'
+
+mute_time_intervals:
+- name: off-hours
+  time_intervals:
+  - times:
+    - start_time: '22:00'
+      end_time: '06:00'
+    weekdays: [saturday, sunday]
+    time_zone: Europe/Berlin
+`
+	conf, err := Load(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	mt := conf.MuteTimeIntervals[0]
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, tc := range []struct {
+		t        time.Time
+		expected bool
+	}{
+		// Friday night is not muted.
+		{time.Date(2018, 6, 1, 23, 0, 0, 0, loc), false},
+		{time.Date(2018, 6, 2, 21, 59, 0, 0, loc), false},
+		{time.Date(2018, 6, 2, 22, 0, 0, 0, loc), true},
+		// The night from Saturday to Sunday, given in UTC.
+		{time.Date(2018, 6, 3, 1, 0, 0, 0, time.UTC), true},
+		{time.Date(2018, 6, 3, 6, 0, 0, 0, loc), false},
+		// The night from Sunday to Monday belongs to Sunday.
+		{time.Date(2018, 6, 4, 5, 59, 0, 0, loc), true},
+		{time.Date(2018, 6, 4, 23, 0, 0, 0, loc), false},
+	} {
+		if got := mt.ContainsTime(tc.t); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.t, tc.expected, got)
+		}
+	}
+}
+
+func TestInhibitRuleUndefinedReceiver(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+inhibit_rules:
+- source_match:
+    alertname: DatacenterDown
+  target_match_re_template:
+    instance: '{{ .Labels.dc }}-.*'
+  receivers: [team-Y]
+`
+	_, err := Load(in)
+
+	expected := "undefined receiver \"team-Y\" used in inhibit rule"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%q", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%q\ngot:\n%q", expected, err.Error())
+	}
+}
+
+func TestInhibitRuleInvalidTargetTemplate(t *testing.T) {
+	in := `
+route:
+  receiver: team-X
+
+receivers:
+- name: 'team-X'
+
+inhibit_rules:
+- source_match:
+    alertname: DatacenterDown
+  target_match_re_template:
+    instance: '{{ .Labels.dc -.*'
+`
+	_, err := Load(in)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid target_match_re_template for label \"instance\"") {
+		t.Fatalf("expected invalid template error, got %v", err)
+	}
+}
+
 func TestRootRouteExists(t *testing.T) {
 	in := `
 receivers:
@@ -449,3 +1282,320 @@ func TestOpsGenieNoAPIKey(t *testing.T) {
 		t.Errorf("Expected: %s\nGot: %s", "no global OpsGenie API Key set", err.Error())
 	}
 }
+
+func TestTenancy(t *testing.T) {
+	conf, err := Load(`
+route:
+  receiver: team-X
+  routes:
+  - match:
+      service: api
+    group_by: [alertname]
+  - match:
+      service: web
+tenancy:
+  label: team
+receivers:
+- name: team-X
+`)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	for i, exp := range [][]model.LabelName{
+		{"team"},
+		{"alertname", "team"},
+		nil,
+	} {
+		r := conf.Route
+		if i > 0 {
+			r = conf.Route.Routes[i-1]
+		}
+		if !reflect.DeepEqual(exp, r.GroupBy) {
+			t.Errorf("Expected group_by %v of route %d, got %v", exp, i, r.GroupBy)
+		}
+	}
+
+	_, err = Load(`
+route:
+  receiver: team-X
+tenancy:
+  admin_tenants: [ops]
+receivers:
+- name: team-X
+`)
+	if err == nil || err.Error() != "missing label in tenancy config" {
+		t.Errorf("Expected missing label error, got %v", err)
+	}
+}
+
+func TestGroupRelabelConfigs(t *testing.T) {
+	conf, err := Load(`
+route:
+  receiver: team-X
+  group_relabel_configs:
+  - source_annotations: [owner]
+    target_label: owner
+  - source_labels: [instance]
+    regex: '([^:]+):.*'
+    target_label: host
+receivers:
+- name: team-X
+`)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	rcs := conf.Route.GroupRelabelConfigs
+	if len(rcs) != 2 {
+		t.Fatalf("Expected 2 group relabel configs, got %d", len(rcs))
+	}
+	if rcs[0].Action != RelabelReplace || rcs[0].Replacement != "$1" || rcs[0].Separator != ";" {
+		t.Errorf("Expected defaults to be set, got %+v", rcs[0])
+	}
+
+	for in, expErr := range map[string]string{
+		`target_label: host`: "missing source_labels or source_annotations in group relabel config",
+		`{source_labels: [instance], target_label: host, action: drop}`: `unknown group relabel action "drop"`,
+		`source_labels: [instance]`:                                     "missing target_label in group relabel config",
+	} {
+		_, err := Load(`
+route:
+  receiver: team-X
+  group_relabel_configs:
+  - ` + in + `
+receivers:
+- name: team-X
+`)
+		if err == nil || err.Error() != expErr {
+			t.Errorf("Expected error %q for %s, got %v", expErr, in, err)
+		}
+	}
+
+	_, err = Load(`
+route:
+  receiver: team-X
+  group_relabel_configs:
+  - source_labels: [namespace]
+    target_label: team
+tenancy:
+  label: team
+receivers:
+- name: team-X
+`)
+	if err == nil || err.Error() != `group relabel config must not change the tenant label "team"` {
+		t.Errorf("Expected tenant label error, got %v", err)
+	}
+}
+
+func TestSpoolConfig(t *testing.T) {
+	c, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  spool:
+    max_size: 1048576
+  webhook_configs:
+  - url: https://example.com/
+`)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	expected := &SpoolConfig{MaxAge: model.Duration(24 * time.Hour), MaxSize: 1 << 20}
+	if sc := c.Receivers[0].Spool; sc == nil || *sc != *expected {
+		t.Errorf("Expected spool config %+v, got %+v", expected, sc)
+	}
+
+	for in, expErr := range map[string]string{
+		`{max_age: 0s}`:  "max_age of the spool must be positive",
+		`{max_size: -1}`: "max_size of the spool must be positive",
+	} {
+		_, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  spool: ` + in + `
+`)
+		if err == nil || err.Error() != expErr {
+			t.Errorf("Expected error %q for %s, got %v", expErr, in, err)
+		}
+	}
+}
+
+func TestCoordinationConfig(t *testing.T) {
+	c, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  coordination:
+    mode: strict
+    peer_wait: 30s
+  webhook_configs:
+  - url: https://example.com/
+`)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	expected := &CoordinationConfig{
+		Mode:          CoordinationStrict,
+		PeerWait:      model.Duration(30 * time.Second),
+		QuorumTimeout: model.Duration(time.Minute),
+	}
+	if cc := c.Receivers[0].Coordination; cc == nil || *cc != *expected {
+		t.Errorf("Expected coordination config %+v, got %+v", expected, cc)
+	}
+
+	for in, expErr := range map[string]string{
+		`{mode: leader}`:       `unknown coordination mode "leader", must be one of "best_effort" or "strict"`,
+		`{quorum_timeout: 0s}`: "quorum_timeout must be positive in coordination config",
+	} {
+		_, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  coordination: ` + in + `
+`)
+		if err == nil || err.Error() != expErr {
+			t.Errorf("Expected error %q for %s, got %v", expErr, in, err)
+		}
+	}
+}
+
+func TestTimingOverrideInvalid(t *testing.T) {
+	for in, expErr := range map[string]string{
+		`{repeat_interval: 30m}`:                            "missing match or match_re in timing override",
+		`{match: {severity: critical}}`:                     "timing override must set group_wait, group_interval or repeat_interval",
+		`{match: {severity: critical}, group_interval: 0s}`: "group_interval cannot be zero",
+		`{match: {0sev: critical}, repeat_interval: 1h}`:    `invalid label name "0sev"`,
+	} {
+		_, err := Load(`
+route:
+  receiver: team-X
+  timing_overrides:
+  - ` + in + `
+receivers:
+- name: team-X
+`)
+		if err == nil || err.Error() != expErr {
+			t.Errorf("Expected error %q for %s, got %v", expErr, in, err)
+		}
+	}
+}
+
+func TestAlertEnricherConfig(t *testing.T) {
+	c, err := Load(`
+global:
+  http_config:
+    bearer_token: secret
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+alert_enrichers:
+- name: cmdb
+  match_re:
+    service: .+
+  url: https://cmdb.example.com/enrich
+- name: runbooks
+  match:
+    team: frontend
+  annotations:
+    runbook: https://wiki.example.com/frontend
+`)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	cmdb := c.AlertEnrichers[0]
+	if cmdb.Timeout != DefaultAlertEnricherConfig.Timeout || cmdb.CacheTTL != DefaultAlertEnricherConfig.CacheTTL {
+		t.Errorf("Expected default timeout and cache TTL, got %v and %v", cmdb.Timeout, cmdb.CacheTTL)
+	}
+	if cmdb.HTTPConfig != c.Global.HTTPConfig {
+		t.Errorf("Expected the global HTTP config, got %+v", cmdb.HTTPConfig)
+	}
+	if c.AlertEnrichers[1].HTTPConfig != nil {
+		t.Errorf("Expected no HTTP config for static enricher, got %+v", c.AlertEnrichers[1].HTTPConfig)
+	}
+
+	for in, expErr := range map[string]string{
+		`{url: http://cmdb}`: "missing name in alert enricher config",
+		`{name: a}`:          `alert enricher "a" must have exactly one of url and labels or annotations`,
+		`{name: a, url: http://cmdb, labels: {team: x}}`: `alert enricher "a" must have exactly one of url and labels or annotations`,
+		`{name: a, url: ftp://cmdb}`:                     `invalid URL scheme "ftp" in alert enricher "a"`,
+		`{name: a, url: http://cmdb, timeout: 0s}`:       `timeout must be positive in alert enricher "a"`,
+		`{name: a, match: {0team: x}, url: http://cmdb}`: `invalid label name "0team"`,
+	} {
+		_, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+alert_enrichers:
+- ` + in + `
+`)
+		if err == nil || err.Error() != expErr {
+			t.Errorf("Expected error %q for %s, got %v", expErr, in, err)
+		}
+	}
+
+	_, err = Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+alert_enrichers:
+- {name: a, labels: {team: x}}
+- {name: a, labels: {team: y}}
+`)
+	if expErr := `alert enricher name "a" is not unique`; err == nil || err.Error() != expErr {
+		t.Errorf("Expected error %q, got %v", expErr, err)
+	}
+}
+
+func TestSNMPUserEngineIDs(t *testing.T) {
+	for _, tc := range []struct {
+		user string
+		err  string
+	}{
+		{
+			user: `{name: u, auth_protocol: SHA, auth_password: authpassword}`,
+			err:  `snmp user "u": engine_ids are required with auth_protocol`,
+		},
+		{
+			user: `{name: u, auth_protocol: SHA, auth_password: authpassword, engine_ids: ['8000']}`,
+			err:  `snmp user "u": invalid engine ID "8000"`,
+		},
+		{
+			user: `{name: u, auth_protocol: SHA, auth_password: authpassword, engine_ids: ['0x80001F880474657374']}`,
+		},
+		{
+			user: `{name: u}`,
+		},
+	} {
+		c, err := Load(`
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+snmp_traps:
+  users: [` + tc.user + `]
+  rules:
+  - trap_oid: 1.3.6.1.6.3.1.1.5.3
+    labels:
+      alertname: LinkDown
+`)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Expected error %q for %s, got %v", tc.err, tc.user, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", tc.user, err)
+		}
+		if ids := c.SNMPTraps.Users[0].EngineIDs; len(ids) > 0 && ids[0] != "80001f880474657374" {
+			t.Errorf("Expected normalized engine ID, got %q", ids[0])
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	commoncfg "github.com/prometheus/common/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// TLSVersion is a TLS protocol version given by name, e.g. "TLS12".
+type TLSVersion uint16
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (v *TLSVersion) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if u, ok := tlsVersions[s]; ok {
+		*v = TLSVersion(u)
+		return nil
+	}
+	return fmt.Errorf("unknown TLS version %q", s)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (v TLSVersion) MarshalYAML() (interface{}, error) {
+	return v.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (v TLSVersion) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", v.String())), nil
+}
+
+func (v TLSVersion) String() string {
+	for name, u := range tlsVersions {
+		if u == uint16(v) {
+			return name
+		}
+	}
+	return fmt.Sprintf("%#04x", uint16(v))
+}
+
+// CipherSuite is a TLS cipher suite given by its IANA name, e.g.
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+type CipherSuite uint16
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CipherSuite) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == s {
+			*c = CipherSuite(cs.ID)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown or insecure cipher suite %q", s)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (c CipherSuite) MarshalYAML() (interface{}, error) {
+	return tls.CipherSuiteName(uint16(c)), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c CipherSuite) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", tls.CipherSuiteName(uint16(c)))), nil
+}
+
+// CryptoPolicy restricts the TLS parameters used for all connections made
+// and accepted by the Alertmanager. When set, integrations that would send
+// notifications over unencrypted or unverified connections are rejected.
+type CryptoPolicy struct {
+	// MinTLSVersion is the minimum TLS version that is negotiated.
+	MinTLSVersion TLSVersion `yaml:"min_tls_version,omitempty" json:"min_tls_version,omitempty"`
+	// CipherSuites restricts the cipher suites negotiated for TLS 1.2 and
+	// below. TLS 1.3 cipher suites are not configurable.
+	CipherSuites []CipherSuite `yaml:"cipher_suites,omitempty" json:"cipher_suites,omitempty"`
+}
+
+// DefaultCryptoPolicy provides the defaults for an enabled crypto policy.
+var DefaultCryptoPolicy = CryptoPolicy{
+	MinTLSVersion: TLSVersion(tls.VersionTLS12),
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *CryptoPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*p = DefaultCryptoPolicy
+	type plain CryptoPolicy
+	return unmarshal((*plain)(p))
+}
+
+// Apply restricts the given TLS configuration to the policy. A nil policy
+// leaves the configuration untouched.
+func (p *CryptoPolicy) Apply(cfg *tls.Config) {
+	if p == nil {
+		return
+	}
+	if cfg.MinVersion < uint16(p.MinTLSVersion) {
+		cfg.MinVersion = uint16(p.MinTLSVersion)
+	}
+	if len(p.CipherSuites) > 0 {
+		cfg.CipherSuites = make([]uint16, 0, len(p.CipherSuites))
+		for _, cs := range p.CipherSuites {
+			cfg.CipherSuites = append(cfg.CipherSuites, uint16(cs))
+		}
+	}
+}
+
+// checkReceiver returns an error if any integration of the receiver would
+// violate the policy and attaches the policy to all of them.
+func (p *CryptoPolicy) checkReceiver(rcv *Receiver) error {
+	if p == nil {
+		return nil
+	}
+	var errs []string
+	check := func(integration string, nc *NotifierConfig, u string, hc *commoncfg.HTTPClientConfig) {
+		nc.CryptoPolicy = p
+		errs = append(errs, p.checkClient(integration, u, hc)...)
+	}
+
+	for _, c := range rcv.WebhookConfigs {
+		check("webhook", &c.NotifierConfig, c.URL, c.HTTPConfig)
+	}
+	for _, c := range rcv.EmailConfigs {
+		check("email", &c.NotifierConfig, "", nil)
+		_, port, _ := net.SplitHostPort(c.Smarthost)
+		if port != "465" && (c.RequireTLS == nil || !*c.RequireTLS) {
+			errs = append(errs, "email: require_tls must be enabled")
+		}
+	}
+	for _, c := range rcv.PagerdutyConfigs {
+		check("pagerduty", &c.NotifierConfig, c.URL, c.HTTPConfig)
+	}
+	for _, c := range rcv.HipchatConfigs {
+		check("hipchat", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.SlackConfigs {
+		check("slack", &c.NotifierConfig, string(c.APIURL), c.HTTPConfig)
+	}
+	for _, c := range rcv.WechatConfigs {
+		check("wechat", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.OpsGenieConfigs {
+		check("opsgenie", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.PushoverConfigs {
+		check("pushover", &c.NotifierConfig, "", c.HTTPConfig)
+	}
+	for _, c := range rcv.VictorOpsConfigs {
+		check("victorops", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.StatuspageConfigs {
+		check("statuspage", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.GrafanaOnCallConfigs {
+		check("grafana_oncall", &c.NotifierConfig, string(c.URL), c.HTTPConfig)
+	}
+	for _, c := range rcv.KubernetesConfigs {
+		check("kubernetes", &c.NotifierConfig, c.APIServer, c.HTTPConfig)
+	}
+	for _, c := range rcv.MSTeamsConfigs {
+		check("msteams", &c.NotifierConfig, string(c.WebhookURL), c.HTTPConfig)
+	}
+	for _, c := range rcv.TelegramConfigs {
+		check("telegram", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.GoogleChatConfigs {
+		check("googlechat", &c.NotifierConfig, string(c.WebhookURL), c.HTTPConfig)
+	}
+	for _, c := range rcv.DiscordConfigs {
+		check("discord", &c.NotifierConfig, string(c.WebhookURL), c.HTTPConfig)
+	}
+	for _, c := range rcv.JiraConfigs {
+		check("jira", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.SNSConfigs {
+		check("sns", &c.NotifierConfig, c.APIURL, c.HTTPConfig)
+	}
+	for _, c := range rcv.MQTTConfigs {
+		check("mqtt", &c.NotifierConfig, "", nil)
+		if c.TLSConfig.InsecureSkipVerify {
+			errs = append(errs, "mqtt: insecure_skip_verify is not allowed")
+		}
+		if u, err := url.Parse(c.URL); err == nil && !MQTTSecureSchemes[u.Scheme] {
+			errs = append(errs, fmt.Sprintf("mqtt: URL scheme %q is not allowed", u.Scheme))
+		}
+	}
+
+	if df := rcv.DeliveryFailure; df != nil {
+		df.CryptoPolicy = p
+		errs = append(errs, p.checkClient("delivery_failure", df.WebhookURL, df.HTTPConfig)...)
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("receiver %q violates the crypto policy: %s", rcv.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkClients returns an error if any HTTP client of the configuration
+// outside of the receivers would violate the policy and attaches the policy
+// to the configuration of all of them.
+func (p *CryptoPolicy) checkClients(c *Config) error {
+	if p == nil {
+		return nil
+	}
+	var errs []string
+	for _, lh := range c.LifecycleHooks {
+		lh.CryptoPolicy = p
+		errs = append(errs, p.checkClient(fmt.Sprintf("lifecycle hook %q", lh.Name), lh.URL, lh.HTTPConfig)...)
+	}
+	for _, hb := range c.Heartbeats {
+		hb.CryptoPolicy = p
+		name := fmt.Sprintf("heartbeat %q", hb.Name)
+		errs = append(errs, p.checkClient(name, hb.URL, hb.HTTPConfig)...)
+		if hb.OpsGenie != nil {
+			errs = append(errs, p.checkClient(name, hb.OpsGenie.APIURL, nil)...)
+		}
+	}
+	for _, ae := range c.AlertEnrichers {
+		ae.CryptoPolicy = p
+		errs = append(errs, p.checkClient(fmt.Sprintf("alert enricher %q", ae.Name), ae.URL, ae.HTTPConfig)...)
+	}
+	for _, ep := range c.EscalationProviders {
+		if goc := ep.GrafanaOnCall; goc != nil {
+			goc.CryptoPolicy = p
+			errs = append(errs, p.checkClient(fmt.Sprintf("escalation provider %q", ep.Name), goc.APIURL, goc.HTTPConfig)...)
+		}
+	}
+	if pm := c.PagerdutyMaintenance; pm != nil {
+		pm.CryptoPolicy = p
+		errs = append(errs, p.checkClient("pagerduty_maintenance", pm.APIURL, pm.HTTPConfig)...)
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("configuration violates the crypto policy: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkClient returns the violations of the policy by the HTTP client of a
+// component sending requests to the URL with the client configuration.
+func (p *CryptoPolicy) checkClient(component, u string, hc *commoncfg.HTTPClientConfig) []string {
+	var errs []string
+	if hc != nil && hc.TLSConfig.InsecureSkipVerify {
+		errs = append(errs, fmt.Sprintf("%s: insecure_skip_verify is not allowed", component))
+	}
+	if err := p.CheckURL(u); err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %s", component, err))
+	}
+	return errs
+}
+
+// CheckURL returns an error if requests to the URL would violate the
+// policy or cannot be parsed. Empty URLs and a nil policy allow any URL.
+func (p *CryptoPolicy) CheckURL(u string) error {
+	if p == nil || u == "" {
+		return nil
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("URL scheme %q is not allowed", parsed.Scheme)
+	}
+	return nil
+}
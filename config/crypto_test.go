@@ -0,0 +1,260 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCryptoPolicy(t *testing.T) {
+	in := `
+global:
+  crypto_policy:
+    min_tls_version: TLS13
+    cipher_suites: [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]
+route:
+  receiver: team-X
+  enrichments:
+  - name: up
+    query: up
+    prometheus_url: https://prometheus.example.com
+receivers:
+- name: team-X
+  webhook_configs:
+  - url: https://example.com/
+lifecycle_hooks:
+- name: deploy
+  url: https://example.com/
+`
+	c, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+
+	p := c.Receivers[0].WebhookConfigs[0].CryptoPolicy
+	if p != c.Global.CryptoPolicy {
+		t.Fatalf("Expected crypto policy to be inherited by the integration")
+	}
+	if c.Route.Enrichments[0].CryptoPolicy != p {
+		t.Fatalf("Expected crypto policy to be inherited by the enrichment")
+	}
+	if c.LifecycleHooks[0].CryptoPolicy != p {
+		t.Fatalf("Expected crypto policy to be inherited by the lifecycle hook")
+	}
+
+	cfg := &tls.Config{}
+	p.Apply(cfg)
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Expected minimum TLS version %x, got %x", tls.VersionTLS13, cfg.MinVersion)
+	}
+	if len(cfg.CipherSuites) != 1 || cfg.CipherSuites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("Unexpected cipher suites %v", cfg.CipherSuites)
+	}
+}
+
+func TestCryptoPolicyViolations(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+  webhook_configs:
+  - url: http://example.com/`,
+			err: `webhook: URL scheme "http" is not allowed`,
+		},
+		{
+			in: `
+  slack_configs:
+  - api_url: https://example.com/
+    http_config:
+      tls_config:
+        insecure_skip_verify: true`,
+			err: "slack: insecure_skip_verify is not allowed",
+		},
+		{
+			in: `
+  email_configs:
+  - to: foo@example.com
+    from: bar@example.com
+    smarthost: localhost:25
+    require_tls: false`,
+			err: "email: require_tls must be enabled",
+		},
+		{
+			in: `
+  mqtt_configs:
+  - url: tcp://broker.example.com:1883`,
+			err: `mqtt: URL scheme "tcp" is not allowed`,
+		},
+	}
+
+	for _, tc := range tests {
+		in := `
+global:
+  crypto_policy: {}
+route:
+  receiver: team-X
+receivers:
+- name: team-X` + tc.in
+
+		_, err := Load(in)
+		if err == nil {
+			t.Errorf("Expected error for %s", tc.in)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected error containing %q, got %q", tc.err, err)
+		}
+	}
+}
+
+func TestCryptoPolicyUnknownTLSVersion(t *testing.T) {
+	in := `
+global:
+  crypto_policy:
+    min_tls_version: SSL3
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+`
+	if _, err := Load(in); err == nil {
+		t.Fatal("Expected error for unknown TLS version")
+	}
+}
+
+func TestCryptoPolicyClientViolations(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+lifecycle_hooks:
+- name: deploy
+  url: http://example.com/`,
+			err: `lifecycle hook "deploy": URL scheme "http" is not allowed`,
+		},
+		{
+			in: `
+heartbeats:
+- name: healthchecks
+  url: https://example.com/
+  http_config:
+    tls_config:
+      insecure_skip_verify: true`,
+			err: `heartbeat "healthchecks": insecure_skip_verify is not allowed`,
+		},
+		{
+			in: `
+alert_enrichers:
+- name: owner
+  url: http://example.com/`,
+			err: `alert enricher "owner": URL scheme "http" is not allowed`,
+		},
+		{
+			in: `
+escalation_providers:
+- name: oncall
+  grafana_oncall:
+    api_url: http://example.com/
+    api_token: secret`,
+			err: `escalation provider "oncall": URL scheme "http" is not allowed`,
+		},
+		{
+			in: `
+pagerduty_maintenance:
+  api_url: http://example.com/
+  api_key: secret
+  services:
+  - service_id: P123
+    match:
+      service: web`,
+			err: `pagerduty_maintenance: URL scheme "http" is not allowed`,
+		},
+		{
+			in: `
+  delivery_failure:
+    webhook_url: http://example.com/`,
+			err: `delivery_failure: URL scheme "http" is not allowed`,
+		},
+	}
+
+	for _, tc := range tests {
+		in := `
+global:
+  crypto_policy: {}
+route:
+  receiver: team-X
+receivers:
+- name: team-X` + tc.in
+
+		_, err := Load(in)
+		if err == nil {
+			t.Errorf("Expected error for %s", tc.in)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected error containing %q, got %q", tc.err, err)
+		}
+	}
+}
+
+func TestCryptoPolicyVault(t *testing.T) {
+	os.Setenv("VAULT_ADDR", "http://vault.example.com")
+	defer os.Unsetenv("VAULT_ADDR")
+
+	_, err := Load(`
+global:
+  crypto_policy: {}
+  slack_api_url:
+    vault:
+      path: secret/data/alertmanager
+      key: slack
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+`)
+	if err == nil || !strings.Contains(err.Error(), `VAULT_ADDR violates the crypto policy: URL scheme "http" is not allowed`) {
+		t.Errorf("Expected crypto policy violation of Vault, got %v", err)
+	}
+}
+
+func TestCryptoPolicyCheckURL(t *testing.T) {
+	p := &DefaultCryptoPolicy
+	for _, tc := range []struct {
+		url string
+		err bool
+	}{
+		{url: ""},
+		{url: "https://example.com/hook"},
+		{url: "http://example.com/hook", err: true},
+		{url: "https://example.com:port/hook", err: true},
+		{url: "%zz", err: true},
+	} {
+		if err := p.CheckURL(tc.url); (err != nil) != tc.err {
+			t.Errorf("%q: unexpected error %v", tc.url, err)
+		}
+	}
+
+	var nilPolicy *CryptoPolicy
+	if err := nilPolicy.CheckURL("http://example.com:port/hook"); err != nil {
+		t.Errorf("unexpected error for a nil policy: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// DefaultDeadMansSwitchConfig provides the defaults for dead man's switches.
+var DefaultDeadMansSwitchConfig = DeadMansSwitchConfig{
+	Timeout:        model.Duration(10 * time.Minute),
+	RepeatInterval: model.Duration(1 * time.Hour),
+}
+
+// DeadMansSwitchConfig configures a dead man's switch expecting an alert
+// matching its matchers, such as the Watchdog alert of Prometheus, to fire
+// continuously. Its receiver is notified independently of the routing tree
+// once no such alert was received for the timeout.
+type DeadMansSwitchConfig struct {
+	Name     string            `yaml:"name" json:"name"`
+	Receiver string            `yaml:"receiver" json:"receiver"`
+	Match    map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE  map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+
+	// Timeout is how long no matching firing alert may be received before
+	// the receiver is notified.
+	Timeout model.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// RepeatInterval is how long to wait before notifying the receiver
+	// again while the alert is still missing.
+	RepeatInterval model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+	// Annotations are added to the alert the receiver is notified about.
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *DeadMansSwitchConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDeadMansSwitchConfig
+	type plain DeadMansSwitchConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in dead man's switch config")
+	}
+	if c.Receiver == "" {
+		return fmt.Errorf("missing receiver in dead man's switch %q", c.Name)
+	}
+	if len(c.Match) == 0 && len(c.MatchRE) == 0 {
+		return fmt.Errorf("missing match or match_re in dead man's switch %q", c.Name)
+	}
+	for k := range c.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range c.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range c.Annotations {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid annotation name %q", k)
+		}
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive in dead man's switch %q", c.Name)
+	}
+	if c.RepeatInterval <= 0 {
+		return fmt.Errorf("repeat_interval must be positive in dead man's switch %q", c.Name)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// DigestConfig configures a digest, a scheduled summary of the currently
+// firing and recently resolved alerts matching its matchers. Digests are sent
+// to their receiver independently of the routing tree.
+type DigestConfig struct {
+	Name     string            `yaml:"name" json:"name"`
+	Receiver string            `yaml:"receiver" json:"receiver"`
+	Match    map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE  map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+
+	// Times are the times of day at which the digest is sent.
+	Times []TimeOfDay `yaml:"times" json:"times"`
+	// Weekdays restricts sending to the given days of the week. The digest
+	// is sent every day if empty.
+	Weekdays []Weekday `yaml:"weekdays,omitempty" json:"weekdays,omitempty"`
+	// TimeZone is the location the times and weekdays refer to.
+	TimeZone *Location `yaml:"time_zone,omitempty" json:"time_zone,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *DigestConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain DigestConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in digest config")
+	}
+	if c.Receiver == "" {
+		return fmt.Errorf("missing receiver in digest %q", c.Name)
+	}
+	if len(c.Times) == 0 {
+		return fmt.Errorf("missing times in digest %q", c.Name)
+	}
+	for k := range c.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range c.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	if c.TimeZone == nil {
+		c.TimeZone = &Location{time.UTC}
+	}
+	return nil
+}
+
+// TimeOfDay is a time of day in the format HH:MM.
+type TimeOfDay struct {
+	Hour, Minute int
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (t *TimeOfDay) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	tt, err := time.Parse("15:04", s)
+	if err != nil {
+		return fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	t.Hour, t.Minute = tt.Hour(), tt.Minute()
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t TimeOfDay) MarshalYAML() (interface{}, error) {
+	return t.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + t.String() + `"`), nil
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// Weekday is a day of the week given by its English name.
+type Weekday time.Weekday
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (d *Weekday) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.EqualFold(s, wd.String()) {
+			*d = Weekday(wd)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid weekday %q", s)
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (d Weekday) MarshalYAML() (interface{}, error) {
+	return strings.ToLower(time.Weekday(d).String()), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d Weekday) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strings.ToLower(time.Weekday(d).String()) + `"`), nil
+}
+
+// Location is a time zone given by its IANA name.
+type Location struct {
+	*time.Location
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *Location) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return err
+	}
+	l.Location = loc
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (l Location) MarshalYAML() (interface{}, error) {
+	return l.String(), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l Location) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + l.String() + `"`), nil
+}
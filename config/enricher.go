@@ -0,0 +1,99 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultAlertEnricherConfig provides the defaults for alert enrichers.
+var DefaultAlertEnricherConfig = AlertEnricherConfig{
+	Timeout:  model.Duration(2 * time.Second),
+	CacheTTL: model.Duration(5 * time.Minute),
+}
+
+// AlertEnricherConfig adds labels and annotations to the received alerts
+// that match it before they are routed. They are either static or looked up
+// with the labels of the alert from an HTTP service, e.g. the team owning a
+// service from a CMDB. Exactly one of url and static labels or annotations
+// must be set. Labels and annotations the alert already has are kept.
+type AlertEnricherConfig struct {
+	Name string `yaml:"name" json:"name"`
+
+	Match   map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+
+	Labels      model.LabelSet `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations model.LabelSet `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+
+	// URL is sent the labels of an alert and responds with the labels and
+	// annotations to add. The response is cached for CacheTTL and reused if
+	// later lookups fail.
+	URL        string                      `yaml:"url,omitempty" json:"url,omitempty"`
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+	Timeout    model.Duration              `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	CacheTTL   model.Duration              `yaml:"cache_ttl,omitempty" json:"cache_ttl,omitempty"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *AlertEnricherConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultAlertEnricherConfig
+	type plain AlertEnricherConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in alert enricher config")
+	}
+	for k := range c.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range c.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	static := len(c.Labels) > 0 || len(c.Annotations) > 0
+	if static == (c.URL != "") {
+		return fmt.Errorf("alert enricher %q must have exactly one of url and labels or annotations", c.Name)
+	}
+	if err := c.Labels.Validate(); err != nil {
+		return fmt.Errorf("invalid labels in alert enricher %q: %s", c.Name, err)
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return fmt.Errorf("invalid URL in alert enricher %q: %s", c.Name, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid URL scheme %q in alert enricher %q", u.Scheme, c.Name)
+		}
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive in alert enricher %q", c.Name)
+	}
+	if c.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative in alert enricher %q", c.Name)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Enrichment runs a PromQL query against Prometheus when a notification is
+// created and exposes the result to the notification templates as
+// .Enrichments.<name>.
+type Enrichment struct {
+	Name string `yaml:"name" json:"name"`
+	// Query is a template of the PromQL query that is executed with the
+	// notification data.
+	Query         string `yaml:"query" json:"query"`
+	PrometheusURL string `yaml:"prometheus_url,omitempty" json:"prometheus_url,omitempty"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (e *Enrichment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Enrichment
+	if err := unmarshal((*plain)(e)); err != nil {
+		return err
+	}
+	if !model.LabelNameRE.MatchString(e.Name) {
+		return fmt.Errorf("invalid enrichment name %q", e.Name)
+	}
+	if e.Query == "" {
+		return fmt.Errorf("missing query in enrichment %q", e.Name)
+	}
+	if e.PrometheusURL != "" {
+		if err := checkPrometheusURL(e.PrometheusURL); err != nil {
+			return err
+		}
+		e.PrometheusURL = strings.TrimSuffix(e.PrometheusURL, "/")
+	}
+	return nil
+}
+
+func checkPrometheusURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid Prometheus URL %q", s)
+	}
+	return nil
+}
+
+// setEnrichmentDefaults sets the global Prometheus URL, HTTP client
+// configuration and crypto policy for all enrichments in the routing tree.
+func (c *Config) setEnrichmentDefaults(r *Route) error {
+	for _, e := range r.Enrichments {
+		e.CryptoPolicy = c.Global.CryptoPolicy
+		if e.HTTPConfig == nil {
+			e.HTTPConfig = c.Global.HTTPConfig
+		}
+		if e.PrometheusURL == "" {
+			if c.Global.PrometheusURL == "" {
+				return fmt.Errorf("no global Prometheus URL set for enrichment %q", e.Name)
+			}
+			if err := checkPrometheusURL(c.Global.PrometheusURL); err != nil {
+				return err
+			}
+			e.PrometheusURL = strings.TrimSuffix(c.Global.PrometheusURL, "/")
+		}
+	}
+	for _, sr := range r.Routes {
+		if err := c.setEnrichmentDefaults(sr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
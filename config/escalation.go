@@ -0,0 +1,112 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	commoncfg "github.com/prometheus/common/config"
+)
+
+// EscalationProviderConfig configures a provider that is queried for the
+// people on call of a schedule by the oncall template function. Exactly one
+// provider type must be set.
+type EscalationProviderConfig struct {
+	Name string `yaml:"name" json:"name"`
+
+	Static        *StaticEscalationConfig        `yaml:"static,omitempty" json:"static,omitempty"`
+	GrafanaOnCall *GrafanaOnCallEscalationConfig `yaml:"grafana_oncall,omitempty" json:"grafana_oncall,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *EscalationProviderConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain EscalationProviderConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in escalation provider config")
+	}
+	var n int
+	if c.Static != nil {
+		n++
+	}
+	if c.GrafanaOnCall != nil {
+		n++
+	}
+	if n != 1 {
+		return fmt.Errorf("escalation provider %q must have exactly one provider type", c.Name)
+	}
+	return nil
+}
+
+// StaticEscalationConfig maps schedule names to the people on call.
+type StaticEscalationConfig struct {
+	Schedules map[string][]string `yaml:"schedules" json:"schedules"`
+}
+
+// GrafanaOnCallEscalationConfig configures the lookup of on-call schedules
+// via the Grafana OnCall API.
+type GrafanaOnCallEscalationConfig struct {
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	APIURL   string `yaml:"api_url" json:"api_url"`
+	APIToken Secret `yaml:"api_token" json:"api_token"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GrafanaOnCallEscalationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GrafanaOnCallEscalationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.APIURL == "" {
+		return fmt.Errorf("missing API URL in Grafana OnCall escalation config")
+	}
+	if c.APIToken == "" {
+		return fmt.Errorf("missing API token in Grafana OnCall escalation config")
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	return nil
+}
+
+// EscalationConfig configures a route to escalate the notifications of
+// its groups that keep firing to another receiver.
+type EscalationConfig struct {
+	// Receiver is notified in addition to the receiver of the route.
+	Receiver string `yaml:"receiver" json:"receiver"`
+	// AfterRepeats is the number of repeat intervals a group must keep
+	// firing for before it is escalated.
+	AfterRepeats int `yaml:"after_repeats" json:"after_repeats"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *EscalationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain EscalationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Receiver == "" {
+		return fmt.Errorf("missing receiver in escalation config")
+	}
+	if c.AfterRepeats <= 0 {
+		return fmt.Errorf("after_repeats must be positive in escalation config")
+	}
+	return nil
+}
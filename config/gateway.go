@@ -0,0 +1,76 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// EmailGatewayConfig configures how incoming emails are converted into alerts.
+type EmailGatewayConfig struct {
+	Rules []*EmailGatewayRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *EmailGatewayConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain EmailGatewayConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("no rules in email gateway config")
+	}
+	return nil
+}
+
+// EmailGatewayRule converts emails into alerts. An email matches a rule if
+// all of its patterns match. Named capture groups of the patterns can be
+// referenced as $name in label and annotation values, as can $from,
+// $subject and $body.
+type EmailGatewayRule struct {
+	From    Regexp `yaml:"from,omitempty" json:"from,omitempty"`
+	Subject Regexp `yaml:"subject,omitempty" json:"subject,omitempty"`
+	Body    Regexp `yaml:"body,omitempty" json:"body,omitempty"`
+	// Resolve marks the alert as resolved if it matches the subject.
+	Resolve Regexp `yaml:"resolve,omitempty" json:"resolve,omitempty"`
+
+	// Headers maps email header names to the labels they are copied to.
+	Headers     map[string]model.LabelName `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Labels      map[string]string          `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string          `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *EmailGatewayRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain EmailGatewayRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if _, ok := r.Labels[model.AlertNameLabel]; !ok {
+		return fmt.Errorf("missing %s label in email gateway rule", model.AlertNameLabel)
+	}
+	for k := range r.Labels {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for _, v := range r.Headers {
+		if !v.IsValid() {
+			return fmt.Errorf("invalid label name %q", v)
+		}
+	}
+	return nil
+}
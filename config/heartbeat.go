@@ -0,0 +1,94 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultHeartbeatConfig provides the defaults for heartbeats.
+var DefaultHeartbeatConfig = HeartbeatConfig{
+	Interval: model.Duration(1 * time.Minute),
+	Method:   "POST",
+}
+
+// HeartbeatConfig configures a heartbeat that is periodically sent to an
+// external service as long as the Alertmanager is healthy. Exactly one of
+// url and opsgenie must be set.
+type HeartbeatConfig struct {
+	Name       string                      `yaml:"name" json:"name"`
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+	Interval   model.Duration              `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// URL is requested with Method for each heartbeat, e.g. a healthchecks.io
+	// check URL.
+	URL    string `yaml:"url,omitempty" json:"url,omitempty"`
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+
+	OpsGenie *OpsGenieHeartbeatConfig `yaml:"opsgenie,omitempty" json:"opsgenie,omitempty"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *HeartbeatConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultHeartbeatConfig
+	type plain HeartbeatConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in heartbeat config")
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive in heartbeat %q", c.Name)
+	}
+	if (c.URL == "") == (c.OpsGenie == nil) {
+		return fmt.Errorf("heartbeat %q must have exactly one of url and opsgenie", c.Name)
+	}
+	if c.URL != "" {
+		if _, err := url.Parse(c.URL); err != nil {
+			return fmt.Errorf("invalid URL in heartbeat %q: %s", c.Name, err)
+		}
+	}
+	c.Method = strings.ToUpper(c.Method)
+	return nil
+}
+
+// OpsGenieHeartbeatConfig configures pings of an OpsGenie heartbeat. The API
+// URL and key default to the global OpsGenie settings.
+type OpsGenieHeartbeatConfig struct {
+	APIURL string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	APIKey Secret `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	// Heartbeat is the name of the heartbeat in OpsGenie.
+	Heartbeat string `yaml:"heartbeat" json:"heartbeat"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *OpsGenieHeartbeatConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain OpsGenieHeartbeatConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Heartbeat == "" {
+		return fmt.Errorf("missing heartbeat name in OpsGenie heartbeat config")
+	}
+	return nil
+}
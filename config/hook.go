@@ -0,0 +1,94 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Lifecycle events of alerts and aggregation groups.
+const (
+	HookAlertFirstSeen = "alert_first_seen"
+	HookAlertResolved  = "alert_resolved"
+	HookGroupCreated   = "group_created"
+	HookGroupDeleted   = "group_deleted"
+)
+
+// DefaultLifecycleHookConfig provides the defaults for lifecycle hooks.
+var DefaultLifecycleHookConfig = LifecycleHookConfig{
+	Events:  []string{HookAlertFirstSeen, HookAlertResolved, HookGroupCreated, HookGroupDeleted},
+	Timeout: model.Duration(10 * time.Second),
+}
+
+// LifecycleHookConfig configures an HTTP callback on lifecycle events of
+// alerts and aggregation groups. Unlike receivers, hooks are called for all
+// alerts regardless of the routing tree.
+type LifecycleHookConfig struct {
+	Name       string                      `yaml:"name" json:"name"`
+	URL        string                      `yaml:"url" json:"url"`
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+	// Events are the lifecycle events the hook is called for.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+	// Timeout is the maximum time spent on calling the hook for an event.
+	Timeout model.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *LifecycleHookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultLifecycleHookConfig
+	type plain LifecycleHookConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in lifecycle hook config")
+	}
+	if c.URL == "" {
+		return fmt.Errorf("missing url in lifecycle hook %q", c.Name)
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return fmt.Errorf("invalid url in lifecycle hook %q: %s", c.Name, err)
+	}
+	if len(c.Events) == 0 {
+		return fmt.Errorf("missing events in lifecycle hook %q", c.Name)
+	}
+	for _, e := range c.Events {
+		switch e {
+		case HookAlertFirstSeen, HookAlertResolved, HookGroupCreated, HookGroupDeleted:
+		default:
+			return fmt.Errorf("unknown event %q in lifecycle hook %q", e, c.Name)
+		}
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive in lifecycle hook %q", c.Name)
+	}
+	return nil
+}
+
+// HasEvent returns whether the hook is called for the event.
+func (c *LifecycleHookConfig) HasEvent(event string) bool {
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// defaultIdleConnTimeout is how long idle connections are kept open if the
+// HTTP transport configuration does not set it.
+const defaultIdleConnTimeout = 90 * time.Second
+
+// HTTPTransportConfig tunes the connections of the HTTP clients the
+// integrations send notifications with. Without it every notification
+// opens a new connection.
+type HTTPTransportConfig struct {
+	// KeepAlive reuses connections across notifications of an integration
+	// until the configuration is reloaded.
+	KeepAlive bool `yaml:"keep_alive,omitempty" json:"keep_alive,omitempty"`
+	// MaxIdleConns limits the idle connections kept open in total and
+	// MaxIdleConnsPerHost those to a single host. Zero means the defaults of
+	// the Go HTTP client.
+	MaxIdleConns        int `yaml:"max_idle_conns,omitempty" json:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host,omitempty" json:"max_idle_conns_per_host,omitempty"`
+	// MaxConnsPerHost limits the connections to a single host, including
+	// those in use. Zero means unlimited.
+	MaxConnsPerHost int `yaml:"max_conns_per_host,omitempty" json:"max_conns_per_host,omitempty"`
+	// IdleConnTimeout is how long an idle connection is kept open.
+	IdleConnTimeout model.Duration `yaml:"idle_conn_timeout,omitempty" json:"idle_conn_timeout,omitempty"`
+	// EnableHTTP2 negotiates HTTP/2 with servers supporting it.
+	EnableHTTP2 bool `yaml:"enable_http2,omitempty" json:"enable_http2,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *HTTPTransportConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain HTTPTransportConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 {
+		return fmt.Errorf("connection limits of the HTTP transport cannot be negative")
+	}
+	if c.IdleConnTimeout < 0 {
+		return fmt.Errorf("idle_conn_timeout of the HTTP transport cannot be negative")
+	}
+	if !c.KeepAlive && (c.MaxIdleConns > 0 || c.MaxIdleConnsPerHost > 0 || c.IdleConnTimeout > 0) {
+		return fmt.Errorf("idle connection settings of the HTTP transport require keep_alive")
+	}
+	return nil
+}
+
+// NewHTTPClient returns a new HTTP client for the given configuration whose
+// TLS parameters are restricted by the crypto policy and whose connections
+// are tuned by the transport configuration. All outbound HTTP clients of the
+// Alertmanager are created with it, so that the crypto policy applies to
+// them.
+func NewHTTPClient(cfg *commoncfg.HTTPClientConfig, policy *CryptoPolicy, transport *HTTPTransportConfig) (*http.Client, error) {
+	if cfg == nil {
+		cfg = &commoncfg.HTTPClientConfig{}
+	}
+	if policy == nil && transport == nil {
+		return commoncfg.NewHTTPClientFromConfig(cfg)
+	}
+	tlsConfig, err := commoncfg.NewTLSConfig(&cfg.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	policy.Apply(tlsConfig)
+
+	t := &http.Transport{
+		Proxy:             http.ProxyURL(cfg.ProxyURL.URL),
+		DisableKeepAlives: true,
+		TLSClientConfig:   tlsConfig,
+	}
+	if transport != nil {
+		t.DisableKeepAlives = !transport.KeepAlive
+		t.MaxIdleConns = transport.MaxIdleConns
+		t.MaxIdleConnsPerHost = transport.MaxIdleConnsPerHost
+		t.MaxConnsPerHost = transport.MaxConnsPerHost
+		t.IdleConnTimeout = time.Duration(transport.IdleConnTimeout)
+		t.ForceAttemptHTTP2 = transport.EnableHTTP2
+		// Close idle connections eventually, including those of clients
+		// replaced by reloading the configuration.
+		if t.IdleConnTimeout == 0 {
+			t.IdleConnTimeout = defaultIdleConnTimeout
+		}
+	}
+
+	var rt http.RoundTripper = t
+
+	bearerToken := cfg.BearerToken
+	if len(bearerToken) == 0 && len(cfg.BearerTokenFile) > 0 {
+		b, err := ioutil.ReadFile(cfg.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read bearer token file %s: %s", cfg.BearerTokenFile, err)
+		}
+		bearerToken = commoncfg.Secret(strings.TrimSpace(string(b)))
+	}
+	if len(bearerToken) > 0 {
+		rt = commoncfg.NewBearerAuthRoundTripper(bearerToken, rt)
+	}
+	if cfg.BasicAuth != nil {
+		rt = commoncfg.NewBasicAuthRoundTripper(cfg.BasicAuth.Username, cfg.BasicAuth.Password, rt)
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// notifierConfigs returns the common configuration of all integrations of
+// the receiver.
+func (rcv *Receiver) notifierConfigs() []*NotifierConfig {
+	var ncs []*NotifierConfig
+	for _, c := range rcv.EmailConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.PagerdutyConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.HipchatConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.SlackConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.WebhookConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.OpsGenieConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.WechatConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.PushoverConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.VictorOpsConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.StatuspageConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.GrafanaOnCallConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.KubernetesConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.MSTeamsConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.TelegramConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.GoogleChatConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.DiscordConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.JiraConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.SNSConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.ExecConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	for _, c := range rcv.MQTTConfigs {
+		ncs = append(ncs, &c.NotifierConfig)
+	}
+	return ncs
+}
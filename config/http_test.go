@@ -0,0 +1,97 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	in := `
+global:
+  http_transport:
+    keep_alive: true
+    max_idle_conns_per_host: 4
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  webhook_configs:
+  - url: https://example.com/
+- name: team-Y
+  http_transport:
+    keep_alive: true
+    max_conns_per_host: 2
+    idle_conn_timeout: 30s
+    enable_http2: true
+  slack_configs:
+  - api_url: https://example.com/
+`
+	c, err := Load(in)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+
+	if tr := c.Receivers[0].WebhookConfigs[0].HTTPTransport; tr != c.Global.HTTPTransport {
+		t.Errorf("Expected HTTP transport to be inherited from the global configuration")
+	}
+	tr := c.Receivers[1].SlackConfigs[0].HTTPTransport
+	expected := &HTTPTransportConfig{
+		KeepAlive:       true,
+		MaxConnsPerHost: 2,
+		IdleConnTimeout: model.Duration(30 * time.Second),
+		EnableHTTP2:     true,
+	}
+	if tr == nil || *tr != *expected {
+		t.Errorf("Expected HTTP transport %+v of the receiver, got %+v", expected, tr)
+	}
+}
+
+func TestHTTPTransportInvalid(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{
+			in:  `max_conns_per_host: -1`,
+			err: "connection limits of the HTTP transport cannot be negative",
+		},
+		{
+			in:  `idle_conn_timeout: 1m`,
+			err: "idle connection settings of the HTTP transport require keep_alive",
+		},
+	}
+	for _, test := range tests {
+		in := `
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  http_transport:
+    ` + test.in + `
+`
+		_, err := Load(in)
+		if err == nil {
+			t.Errorf("Expected error %q for %q", test.err, test.in)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected error %q for %q, got %q", test.err, test.in, err)
+		}
+	}
+}
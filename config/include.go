@@ -0,0 +1,124 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// includedConfig is the content of a file included by a configuration. Its
+// routes are appended to the child routes of the root route and the other
+// sections to the sections of the configuration.
+type includedConfig struct {
+	Routes            []*Route            `yaml:"routes,omitempty"`
+	Receivers         []*Receiver         `yaml:"receivers,omitempty"`
+	InhibitRules      []*InhibitRule      `yaml:"inhibit_rules,omitempty"`
+	MuteTimeIntervals []*MuteTimeInterval `yaml:"mute_time_intervals,omitempty"`
+	Templates         []string            `yaml:"templates,omitempty"`
+}
+
+// mergeIncludes merges the files matching the include patterns of the
+// configuration into it in the order of the patterns and of the file names.
+// Relative patterns and the relative paths in the included files are
+// resolved against the directory of the including and the included file.
+func (c *Config) mergeIncludes() error {
+	var baseDir string
+	if resolver != nil {
+		baseDir = resolver.baseDir
+	}
+
+	// The files defining the receivers and mute time intervals are recorded
+	// to report conflicts.
+	receivers := map[string]string{}
+	for _, rcv := range c.Receivers {
+		receivers[rcv.Name] = "the main configuration"
+	}
+	intervals := map[string]string{}
+	for _, mt := range c.MuteTimeIntervals {
+		intervals[mt.Name] = "the main configuration"
+	}
+
+	for _, pattern := range c.Include {
+		if baseDir != "" && !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %s", pattern, err)
+		}
+		for _, file := range files {
+			inc, err := loadIncluded(file)
+			if err != nil {
+				return fmt.Errorf("including %s: %s", file, err)
+			}
+
+			for _, rcv := range inc.Receivers {
+				if prev, ok := receivers[rcv.Name]; ok {
+					return fmt.Errorf("receiver %q included from %s is already defined in %s", rcv.Name, file, prev)
+				}
+				receivers[rcv.Name] = file
+			}
+			for _, mt := range inc.MuteTimeIntervals {
+				if prev, ok := intervals[mt.Name]; ok {
+					return fmt.Errorf("mute time interval %q included from %s is already defined in %s", mt.Name, file, prev)
+				}
+				intervals[mt.Name] = file
+			}
+			if len(inc.Routes) > 0 {
+				if c.Route == nil {
+					return fmt.Errorf("routes included from %s require a root route", file)
+				}
+				c.Route.Routes = append(c.Route.Routes, inc.Routes...)
+			}
+			c.Receivers = append(c.Receivers, inc.Receivers...)
+			c.InhibitRules = append(c.InhibitRules, inc.InhibitRules...)
+			c.MuteTimeIntervals = append(c.MuteTimeIntervals, inc.MuteTimeIntervals...)
+			c.Templates = append(c.Templates, inc.Templates...)
+		}
+	}
+	// The merged configuration is self-contained, e.g. to be loaded again
+	// from its marshaled form.
+	c.Include = nil
+	return nil
+}
+
+// loadIncluded loads an included file. Its relative paths are resolved
+// against its directory.
+func loadIncluded(file string) (*includedConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+
+	// Secret references in the file are resolved against its directory too.
+	if resolver != nil {
+		prev := resolver.baseDir
+		resolver.baseDir = dir
+		defer func() { resolver.baseDir = prev }()
+	}
+	inc := &includedConfig{}
+	if err := yaml.UnmarshalStrict(content, inc); err != nil {
+		return nil, err
+	}
+	resolveFilepaths(dir, &Config{Receivers: inc.Receivers, Templates: inc.Templates})
+	return inc, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"alertmanager.yml": `
+include:
+- teams/*.yml
+route:
+  receiver: default
+  routes:
+  - match:
+      severity: critical
+    receiver: default
+receivers:
+- name: default
+`,
+		"teams/a.yml": `
+routes:
+- match:
+    team: a
+  receiver: team-a
+  mute_time_intervals: [weekends]
+receivers:
+- name: team-a
+templates:
+- a.tmpl
+mute_time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: ['saturday', 'sunday']
+`,
+		"teams/b.yml": `
+routes:
+- match:
+    team: b
+  receiver: team-b
+receivers:
+- name: team-b
+inhibit_rules:
+- source_match:
+    severity: critical
+  target_match:
+    team: b
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, _, err := LoadFile(filepath.Join(dir, "alertmanager.yml"))
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	var receivers []string
+	for _, r := range c.Route.Routes {
+		receivers = append(receivers, r.Receiver)
+	}
+	if s := strings.Join(receivers, ","); s != "default,team-a,team-b" {
+		t.Errorf("Unexpected receivers of the child routes %s", s)
+	}
+	if n := len(c.Receivers); n != 3 {
+		t.Errorf("Expected 3 receivers, got %d", n)
+	}
+	if n := len(c.InhibitRules); n != 1 {
+		t.Errorf("Expected 1 inhibition rule, got %d", n)
+	}
+	if n := len(c.MuteTimeIntervals); n != 1 {
+		t.Errorf("Expected 1 mute time interval, got %d", n)
+	}
+	if tmpl := filepath.Join(dir, "teams", "a.tmpl"); len(c.Templates) != 1 || c.Templates[0] != tmpl {
+		t.Errorf("Expected templates [%s], got %v", tmpl, c.Templates)
+	}
+	if len(c.Include) != 0 {
+		t.Errorf("Expected merged configuration without includes, got %v", c.Include)
+	}
+
+	// The marshaled configuration is self-contained.
+	if _, err := Load(c.String()); err != nil {
+		t.Errorf("Error loading marshaled config: %s", err)
+	}
+
+	// Receivers defined twice are rejected.
+	dup := filepath.Join(dir, "teams", "c.yml")
+	if err := ioutil.WriteFile(dup, []byte("receivers:\n- name: team-a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = LoadFile(filepath.Join(dir, "alertmanager.yml"))
+	if err == nil {
+		t.Fatalf("Expected error for duplicate receiver")
+	}
+	expected := `receiver "team-a" included from ` + dup + ` is already defined in ` + filepath.Join(dir, "teams", "a.yml")
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		included string
+		err      string
+	}{
+		{
+			included: "route:\n  receiver: team-X\n",
+			err:      "field route not found",
+		},
+		{
+			included: "include: [other.yml]\n",
+			err:      "field include not found",
+		},
+		{
+			included: "receivers:\n- name: team-X\n",
+			err:      `receiver "team-X" included from ` + filepath.Join(dir, "team.yml") + ` is already defined in the main configuration`,
+		},
+		{
+			included: "routes:\n- receiver: team-Y\n",
+			err:      `undefined receiver "team-Y" used in route`,
+		},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "team.yml"), []byte(tc.included), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(`
+include: [` + filepath.Join(dir, "*.yml") + `]
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+`)
+		if err == nil {
+			t.Errorf("Expected error for %q", tc.included)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected error containing %q for %q, got %q", tc.err, tc.included, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "fmt"
+
+// AlertIngestionConfig limits the alert batches accepted by the API, so that
+// a single request cannot exhaust the memory of the Alertmanager.
+type AlertIngestionConfig struct {
+	// MaxBatchSize is the largest number of alerts a request may post. Zero
+	// means unlimited.
+	MaxBatchSize int `yaml:"max_batch_size,omitempty" json:"max_batch_size,omitempty"`
+	// MaxBodyBytes is the largest size of a request body after it was
+	// decompressed. Zero means unlimited for uncompressed bodies and 64MiB
+	// for compressed ones.
+	MaxBodyBytes int64 `yaml:"max_body_bytes,omitempty" json:"max_body_bytes,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *AlertIngestionConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain AlertIngestionConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxBatchSize < 0 {
+		return fmt.Errorf("max_batch_size of alert ingestion must not be negative")
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes of alert ingestion must not be negative")
+	}
+	return nil
+}
@@ -17,9 +17,11 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"text/template/parse"
 	"time"
 
 	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 )
 
 var (
@@ -28,6 +30,8 @@ var (
 		NotifierConfig: NotifierConfig{
 			VSendResolved: true,
 		},
+		Version: "4",
+		Payload: WebhookPayloadFull,
 	}
 
 	// DefaultEmailConfig defines default values for Email configurations.
@@ -73,6 +77,8 @@ var (
 		Text:      `{{ template "slack.default.text" . }}`,
 		Fallback:  `{{ template "slack.default.fallback" . }}`,
 		Footer:    `{{ template "slack.default.footer" . }}`,
+		WebAPIURL: "https://slack.com/api/",
+		FollowUp:  SlackFollowUpNew,
 	}
 
 	// DefaultHipchatConfig defines default values for Hipchat configurations.
@@ -134,11 +140,145 @@ var (
 		Retry:    duration(1 * time.Minute),
 		Expire:   duration(1 * time.Hour),
 	}
+
+	// DefaultStatuspageConfig defines default values for Statuspage configurations.
+	DefaultStatuspageConfig = StatuspageConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		APIURL:          "https://api.statuspage.io/v1/",
+		Name:            `{{ template "statuspage.default.name" . }}`,
+		Body:            `{{ template "statuspage.default.body" . }}`,
+		ComponentLabel:  "component",
+		ComponentStatus: "major_outage",
+	}
+
+	// DefaultGrafanaOnCallConfig defines default values for Grafana OnCall
+	// configurations.
+	DefaultGrafanaOnCallConfig = GrafanaOnCallConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Title:                 `{{ template "grafana_oncall.default.title" . }}`,
+		Message:               `{{ template "grafana_oncall.default.message" . }}`,
+		LinkToUpstreamDetails: `{{ template "__alertmanagerURL" . }}`,
+	}
+
+	// DefaultMSTeamsConfig defines default values for Microsoft Teams
+	// configurations.
+	DefaultMSTeamsConfig = MSTeamsConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Title:   `{{ template "msteams.default.title" . }}`,
+		Summary: `{{ template "msteams.default.summary" . }}`,
+		Text:    `{{ template "msteams.default.text" . }}`,
+	}
+
+	// DefaultTelegramConfig defines default values for Telegram
+	// configurations.
+	DefaultTelegramConfig = TelegramConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		APIURL:    "https://api.telegram.org/",
+		Message:   `{{ template "telegram.default.message" . }}`,
+		ParseMode: "HTML",
+	}
+
+	// DefaultGoogleChatConfig defines default values for Google Chat
+	// configurations.
+	DefaultGoogleChatConfig = GoogleChatConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Title: `{{ template "googlechat.default.title" . }}`,
+		Text:  `{{ template "googlechat.default.text" . }}`,
+	}
+
+	// DefaultDiscordConfig defines default values for Discord
+	// configurations.
+	DefaultDiscordConfig = DiscordConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Title:   `{{ template "discord.default.title" . }}`,
+		Message: `{{ template "discord.default.message" . }}`,
+	}
+
+	// DefaultJiraConfig defines default values for Jira configurations.
+	DefaultJiraConfig = JiraConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Summary:        `{{ template "jira.default.summary" . }}`,
+		Description:    `{{ template "jira.default.description" . }}`,
+		ResolveComment: `{{ template "jira.default.resolve_comment" . }}`,
+	}
+
+	// DefaultSNSConfig defines default values for SNS configurations.
+	DefaultSNSConfig = SNSConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Subject: `{{ template "sns.default.subject" . }}`,
+		Message: `{{ template "sns.default.message" . }}`,
+	}
+
+	// DefaultExecConfig defines default values for exec configurations.
+	DefaultExecConfig = ExecConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Timeout:        model.Duration(30 * time.Second),
+		MaxConcurrency: 1,
+	}
+
+	// DefaultMQTTConfig defines default values for MQTT configurations.
+	DefaultMQTTConfig = MQTTConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		Topic:     `{{ template "mqtt.default.topic" . }}`,
+		QoS:       1,
+		KeepAlive: model.Duration(30 * time.Second),
+	}
+
+	// DefaultKubernetesConfig defines default values for Kubernetes
+	// configurations.
+	DefaultKubernetesConfig = KubernetesConfig{
+		NotifierConfig: NotifierConfig{
+			VSendResolved: true,
+		},
+		NamespaceLabel: "namespace",
+		Reason:         `{{ template "kubernetes.default.reason" . }}`,
+		Message:        `{{ template "kubernetes.default.message" . }}`,
+	}
+)
+
+const (
+	// kubernetesInClusterAPIServer is the address of the API server from
+	// within a cluster.
+	kubernetesInClusterAPIServer = "https://kubernetes.default.svc/"
+	// kubernetesServiceAccountDir holds the credentials of the pod's service
+	// account.
+	kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
 )
 
 // NotifierConfig contains base options common across all notifier configurations.
 type NotifierConfig struct {
 	VSendResolved bool `yaml:"send_resolved" json:"send_resolved"`
+
+	// CryptoPolicy is inherited from the global configuration.
+	CryptoPolicy *CryptoPolicy `yaml:"-" json:"-"`
+	// HTTPTransport is inherited from the receiver or the global
+	// configuration.
+	HTTPTransport *HTTPTransportConfig `yaml:"-" json:"-"`
+
+	// OAuth2 authenticates the requests of HTTP-based notifiers with an
+	// access token obtained from the token URL. It takes precedence over
+	// other Authorization headers of the requests.
+	OAuth2 *OAuth2Config `yaml:"oauth2,omitempty" json:"oauth2,omitempty"`
 }
 
 func (nc *NotifierConfig) SendResolved() bool {
@@ -162,6 +302,18 @@ type EmailConfig struct {
 	HTML         string            `yaml:"html,omitempty" json:"html,omitempty"`
 	Text         string            `yaml:"text,omitempty" json:"text,omitempty"`
 	RequireTLS   *bool             `yaml:"require_tls,omitempty" json:"require_tls,omitempty"`
+
+	// AuthOAuth2 enables the XOAUTH2 authentication mechanism with
+	// auth_username and an access token obtained from the token URL.
+	AuthOAuth2 *OAuth2Config `yaml:"auth_oauth2,omitempty" json:"auth_oauth2,omitempty"`
+
+	// Attachments are fetched for each alert and attached to the email or
+	// embedded into its HTML body.
+	Attachments []*EmailAttachment `yaml:"attachments,omitempty" json:"attachments,omitempty"`
+
+	// DKIM signs the emails, so that they can be delivered without a relay
+	// signing them.
+	DKIM *EmailDKIMConfig `yaml:"dkim,omitempty" json:"dkim,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -174,6 +326,9 @@ func (c *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.To == "" {
 		return fmt.Errorf("missing to address in email config")
 	}
+	if c.OAuth2 != nil {
+		return fmt.Errorf("oauth2 is not supported in email config, use auth_oauth2")
+	}
 	// Header names are case-insensitive, check for collisions.
 	normalizedHeaders := map[string]string{}
 	for h, v := range c.Headers {
@@ -185,6 +340,135 @@ func (c *EmailConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 	c.Headers = normalizedHeaders
 
+	names := map[string]struct{}{}
+	for _, a := range c.Attachments {
+		if _, ok := names[a.Name]; ok {
+			return fmt.Errorf("duplicate attachment name %q in email config", a.Name)
+		}
+		names[a.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// DefaultEmailDKIMConfig defines default values for DKIM signing.
+var DefaultEmailDKIMConfig = EmailDKIMConfig{
+	Headers: []string{"From", "To", "Cc", "Subject", "Date", "Reply-To", "Content-Type", "MIME-Version"},
+}
+
+// EmailDKIMConfig configures the DKIM signature of emails (RFC 6376). The
+// public key is published in the TXT record <selector>._domainkey.<domain>.
+type EmailDKIMConfig struct {
+	// KeyFile is the PEM encoded RSA or Ed25519 private key. It is read
+	// for every email, so that the key can be replaced without a reload.
+	KeyFile  string `yaml:"key_file" json:"key_file"`
+	Selector string `yaml:"selector" json:"selector"`
+	Domain   string `yaml:"domain" json:"domain"`
+	// Headers are the names of the headers to sign if the email has them.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *EmailDKIMConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultEmailDKIMConfig
+	type plain EmailDKIMConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.KeyFile == "" {
+		return fmt.Errorf("missing key_file in email DKIM config")
+	}
+	if c.Selector == "" {
+		return fmt.Errorf("missing selector in email DKIM config")
+	}
+	if c.Domain == "" {
+		return fmt.Errorf("missing domain in email DKIM config")
+	}
+	// The From header must be signed (RFC 6376, section 5.4).
+	for _, h := range c.Headers {
+		if strings.EqualFold(h, "From") {
+			return nil
+		}
+	}
+	return fmt.Errorf("the headers of the email DKIM config must include From")
+}
+
+// DefaultEmailAttachment defines default values for email attachments.
+var DefaultEmailAttachment = EmailAttachment{
+	Inline:  true,
+	MaxSize: 1 << 20,
+	Timeout: model.Duration(10 * time.Second),
+}
+
+// EmailAttachment is content fetched for each alert of an email, e.g. a
+// graph rendered by Grafana. Inline attachments are referenced in the HTML
+// body by their content ID "<name>-<index>", where index is the index of
+// the alert in .Alerts, e.g. <img src="cid:graph-{{ $i }}">.
+type EmailAttachment struct {
+	// Name is the name of the attachment, used in its file name and
+	// content ID.
+	Name string `yaml:"name" json:"name"`
+	// URL is a template executed with each alert. Alerts for which it is
+	// empty have no attachment.
+	URL    string `yaml:"url" json:"url"`
+	Inline bool   `yaml:"inline" json:"inline"`
+	// MaxSize is the maximum size in bytes. Larger content is left out.
+	MaxSize int64 `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+	// Timeout is the maximum time spent fetching the content.
+	Timeout model.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *EmailAttachment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultEmailAttachment
+	type plain EmailAttachment
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in email attachment")
+	}
+	if strings.ContainsAny(c.Name, " \t<>\"/@") {
+		return fmt.Errorf("invalid email attachment name %q", c.Name)
+	}
+	if c.URL == "" {
+		return fmt.Errorf("missing url in email attachment %q", c.Name)
+	}
+	if c.MaxSize <= 0 {
+		return fmt.Errorf("max_size of email attachment %q must be positive", c.Name)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout of email attachment %q must be positive", c.Name)
+	}
+	return nil
+}
+
+// OAuth2Config configures obtaining access tokens with the OAuth 2.0 client
+// credentials grant.
+type OAuth2Config struct {
+	ClientID     string   `yaml:"client_id" json:"client_id"`
+	ClientSecret Secret   `yaml:"client_secret" json:"client_secret"`
+	TokenURL     string   `yaml:"token_url" json:"token_url"`
+	Scopes       []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *OAuth2Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain OAuth2Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("missing client_id or client_secret in oauth2 config")
+	}
+	if c.TokenURL == "" {
+		return fmt.Errorf("missing token_url in oauth2 config")
+	}
+	if _, err := url.Parse(c.TokenURL); err != nil {
+		return fmt.Errorf("invalid token_url in oauth2 config: %s", err)
+	}
 	return nil
 }
 
@@ -194,7 +478,7 @@ type PagerdutyConfig struct {
 
 	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
 
-	ServiceKey  Secret            `yaml:"service_key,omitempty" json"service_key,omitempty"`
+	ServiceKey  Secret            `yaml:"service_key,omitempty" json:"service_key,omitempty"`
 	RoutingKey  Secret            `yaml:"routing_key,omitempty" json:"routing_key,omitempty"`
 	URL         string            `yaml:"url,omitempty" json:"url,omitempty"`
 	Client      string            `yaml:"client,omitempty" json:"client,omitempty"`
@@ -205,6 +489,61 @@ type PagerdutyConfig struct {
 	Class       string            `yaml:"class,omitempty" json:"class,omitempty"`
 	Component   string            `yaml:"component,omitempty" json:"component,omitempty"`
 	Group       string            `yaml:"group,omitempty" json:"group,omitempty"`
+	// DedupKey is a template of the key PagerDuty deduplicates events by
+	// instead of the hash of the group key. It must render the same for the
+	// trigger and resolve events of a group.
+	DedupKey string            `yaml:"dedup_key,omitempty" json:"dedup_key,omitempty"`
+	Links    []*PagerdutyLink  `yaml:"links,omitempty" json:"links,omitempty"`
+	Images   []*PagerdutyImage `yaml:"images,omitempty" json:"images,omitempty"`
+	// SeverityLabel is the label of the firing alerts the severity is
+	// taken from, mapped through SeverityMapping. The highest severity of the
+	// alerts wins. The severity template is used if no alert has a valid
+	// severity.
+	SeverityLabel   model.LabelName   `yaml:"severity_label,omitempty" json:"severity_label,omitempty"`
+	SeverityMapping map[string]string `yaml:"severity_mapping,omitempty" json:"severity_mapping,omitempty"`
+}
+
+// PagerdutySeverities are the severities of the PagerDuty Events API v2,
+// most severe first.
+var PagerdutySeverities = []string{"critical", "error", "warning", "info"}
+
+// PagerdutyLink is a link attached to a PagerDuty event. Links whose href
+// renders empty are left out.
+type PagerdutyLink struct {
+	Href string `yaml:"href" json:"href"`
+	Text string `yaml:"text,omitempty" json:"text,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *PagerdutyLink) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PagerdutyLink
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Href == "" {
+		return fmt.Errorf("missing href in PagerDuty link")
+	}
+	return nil
+}
+
+// PagerdutyImage is an image attached to a PagerDuty event. Images whose src
+// renders empty are left out.
+type PagerdutyImage struct {
+	Src  string `yaml:"src" json:"src"`
+	Alt  string `yaml:"alt,omitempty" json:"alt,omitempty"`
+	Href string `yaml:"href,omitempty" json:"href,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *PagerdutyImage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain PagerdutyImage
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Src == "" {
+		return fmt.Errorf("missing src in PagerDuty image")
+	}
+	return nil
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -217,17 +556,40 @@ func (c *PagerdutyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	if c.RoutingKey == "" && c.ServiceKey == "" {
 		return fmt.Errorf("missing service or routing key in PagerDuty config")
 	}
-	return nil
+	if c.SeverityLabel == "" && len(c.SeverityMapping) > 0 {
+		return fmt.Errorf("severity_mapping requires a severity_label in PagerDuty config")
+	}
+	for k, v := range c.SeverityMapping {
+		if !isPagerdutySeverity(v) {
+			return fmt.Errorf("invalid PagerDuty severity %q for %q, must be one of %q", v, k, PagerdutySeverities)
+		}
+	}
+	if err := checkTemplate("service_key", string(c.ServiceKey)); err != nil {
+		return err
+	}
+	return checkTemplate("routing_key", string(c.RoutingKey))
+}
+
+func isPagerdutySeverity(s string) bool {
+	for _, sev := range PagerdutySeverities {
+		if s == sev {
+			return true
+		}
+	}
+	return false
 }
 
 // SlackAction configures a single Slack action that is sent with each notification.
-// Each action must contain a type, text, and url.
+// Each action must contain a type, text, and either a url or, for interactive
+// buttons handled by the Alertmanager, a name.
 // See https://api.slack.com/docs/message-attachments#action_fields for more information.
 type SlackAction struct {
 	Type  string `yaml:"type,omitempty"  json:"type,omitempty"`
 	Text  string `yaml:"text,omitempty"  json:"text,omitempty"`
 	URL   string `yaml:"url,omitempty"   json:"url,omitempty"`
 	Style string `yaml:"style,omitempty" json:"style,omitempty"`
+	Name  string `yaml:"name,omitempty"  json:"name,omitempty"`
+	Value string `yaml:"value,omitempty" json:"value,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface for SlackAction.
@@ -242,7 +604,7 @@ func (c *SlackAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Text == "" {
 		return fmt.Errorf("missing value in Slack text configuration")
 	}
-	if c.URL == "" {
+	if c.URL == "" && c.Name == "" {
 		return fmt.Errorf("missing value in Slack url configuration")
 	}
 	return nil
@@ -298,13 +660,46 @@ type SlackConfig struct {
 	IconURL     string         `yaml:"icon_url,omitempty" json:"icon_url,omitempty"`
 	LinkNames   bool           `yaml:"link_names,omitempty" json:"link_names,omitempty"`
 	Actions     []*SlackAction `yaml:"actions,omitempty" json:"actions,omitempty"`
+	CallbackID  string         `yaml:"callback_id,omitempty" json:"callback_id,omitempty"`
+
+	// BotToken authenticates with the Slack Web API at the web API URL,
+	// which is used instead of the incoming webhook at the API URL if set.
+	BotToken  Secret `yaml:"bot_token,omitempty" json:"bot_token,omitempty"`
+	WebAPIURL string `yaml:"web_api_url,omitempty" json:"web_api_url,omitempty"`
+	// FollowUp determines how later notifications of a group are sent: as
+	// a new message, by updating the first message of the group or as
+	// replies in its thread. The first message is forgotten once the group
+	// resolves.
+	FollowUp string `yaml:"follow_up,omitempty" json:"follow_up,omitempty"`
 }
 
+// Follow-up modes of Slack notifications.
+const (
+	SlackFollowUpNew    = "new"
+	SlackFollowUpUpdate = "update"
+	SlackFollowUpThread = "thread"
+)
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *SlackConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultSlackConfig
 	type plain SlackConfig
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	switch c.FollowUp {
+	case SlackFollowUpNew:
+	case SlackFollowUpUpdate, SlackFollowUpThread:
+		if c.BotToken == "" {
+			return fmt.Errorf("follow_up %q requires a bot_token in Slack config", c.FollowUp)
+		}
+	default:
+		return fmt.Errorf("unknown follow_up %q in Slack config", c.FollowUp)
+	}
+	if c.BotToken != "" && c.Channel == "" {
+		return fmt.Errorf("missing channel in Slack config with bot_token")
+	}
+	return checkTemplate("channel", c.Channel)
 }
 
 // HipchatConfig configures notifications via Hipchat.
@@ -342,8 +737,44 @@ type WebhookConfig struct {
 
 	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
 
-	// URL to send POST request to.
+	// URL to send the request to.
 	URL string `yaml:"url" json:"url"`
+	// Method is the HTTP method of the request, POST by default.
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+	// Headers are added to the request, e.g. to pass authentication tokens.
+	Headers map[string]Secret `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Timeout limits the duration of a single request.
+	Timeout model.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Retry overrides the retry policy of failed requests.
+	Retry *RetryConfig `yaml:"retry,omitempty" json:"retry,omitempty"`
+
+	// CloudEvents wraps the payload into a CloudEvents 1.0 envelope.
+	CloudEvents *CloudEventsConfig `yaml:"cloudevents,omitempty" json:"cloudevents,omitempty"`
+	// Signing signs the requests, so that the receiver can verify that they
+	// were sent by the Alertmanager.
+	Signing *WebhookSigningConfig `yaml:"signing,omitempty" json:"signing,omitempty"`
+
+	// Version is the version of the payload the receiver understands.
+	// Version "5" adds the number of truncated alerts and the compact
+	// payload, which version "4" consumers do not expect.
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	// MaxAlerts limits the number of alerts in the payload, 0 means
+	// unlimited.
+	MaxAlerts int `yaml:"max_alerts,omitempty" json:"max_alerts,omitempty"`
+	// Payload is either "full" or "compact", which only has the common
+	// labels of the group and the fingerprints of the alerts.
+	Payload string `yaml:"payload,omitempty" json:"payload,omitempty"`
+}
+
+// Webhook payload schemas.
+const (
+	WebhookPayloadFull    = "full"
+	WebhookPayloadCompact = "compact"
+)
+
+// RetryPolicy returns the retry policy of the webhook.
+func (c *WebhookConfig) RetryPolicy() *RetryConfig {
+	return c.Retry
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -364,6 +795,140 @@ func (c *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return fmt.Errorf("scheme required for webhook url")
 	}
 	c.URL = url.String()
+	switch c.Method = strings.ToUpper(c.Method); c.Method {
+	case "", "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("unsupported method %q in webhook config", c.Method)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative in webhook config")
+	}
+	if c.Version != "4" && c.Version != "5" {
+		return fmt.Errorf("unsupported payload version %q in webhook config", c.Version)
+	}
+	if c.Payload != WebhookPayloadFull && c.Payload != WebhookPayloadCompact {
+		return fmt.Errorf("unknown payload %q in webhook config", c.Payload)
+	}
+	if c.MaxAlerts < 0 {
+		return fmt.Errorf("max_alerts must not be negative in webhook config")
+	}
+	if c.Version == "4" && (c.MaxAlerts > 0 || c.Payload != WebhookPayloadFull) {
+		return fmt.Errorf("max_alerts and the compact payload require payload version 5 in webhook config")
+	}
+	return nil
+}
+
+// RetryConfig configures how failed notifications are retried.
+type RetryConfig struct {
+	// MaxAttempts limits the number of attempts, 0 means unlimited.
+	MaxAttempts    int            `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty"`
+	InitialBackoff model.Duration `yaml:"initial_backoff,omitempty" json:"initial_backoff,omitempty"`
+	MaxBackoff     model.Duration `yaml:"max_backoff,omitempty" json:"max_backoff,omitempty"`
+	// StatusCodes are the response status codes that are retried instead of
+	// all 5xx status codes.
+	StatusCodes []int `yaml:"status_codes,omitempty" json:"status_codes,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *RetryConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain RetryConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative in retry config")
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return fmt.Errorf("backoff must not be negative in retry config")
+	}
+	if c.InitialBackoff > 0 && c.MaxBackoff > 0 && c.InitialBackoff > c.MaxBackoff {
+		return fmt.Errorf("initial_backoff must not exceed max_backoff in retry config")
+	}
+	for _, code := range c.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid status code %d in retry config", code)
+		}
+	}
+	return nil
+}
+
+// CloudEvents content modes as defined by the CloudEvents HTTP protocol binding.
+const (
+	CloudEventsStructured = "structured"
+	CloudEventsBinary     = "binary"
+)
+
+// DefaultCloudEventsConfig defines default values for CloudEvents envelopes.
+var DefaultCloudEventsConfig = CloudEventsConfig{
+	Mode:   CloudEventsStructured,
+	Source: `{{ .ExternalURL }}`,
+	Type:   "io.prometheus.alertmanager.notification",
+}
+
+// CloudEventsConfig configures the CloudEvents envelope of webhook
+// notifications.
+type CloudEventsConfig struct {
+	// Mode is either "structured" or "binary".
+	Mode    string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Source  string `yaml:"source,omitempty" json:"source,omitempty"`
+	Type    string `yaml:"type,omitempty" json:"type,omitempty"`
+	Subject string `yaml:"subject,omitempty" json:"subject,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *CloudEventsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultCloudEventsConfig
+	type plain CloudEventsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Mode != CloudEventsStructured && c.Mode != CloudEventsBinary {
+		return fmt.Errorf("unknown cloudevents mode %q", c.Mode)
+	}
+	if c.Source == "" {
+		return fmt.Errorf("missing source in cloudevents config")
+	}
+	if c.Type == "" {
+		return fmt.Errorf("missing type in cloudevents config")
+	}
+	return nil
+}
+
+// DefaultWebhookSigningConfig defines default values for the signatures of
+// webhook requests.
+var DefaultWebhookSigningConfig = WebhookSigningConfig{
+	SignatureHeader: "X-Alertmanager-Signature",
+	TimestampHeader: "X-Alertmanager-Timestamp",
+}
+
+// WebhookSigningConfig configures the HMAC-SHA256 signatures of webhook
+// requests. The signature covers the timestamp of the request and its body,
+// so that receivers can reject replayed requests by their age.
+type WebhookSigningConfig struct {
+	// Secret is the key shared with the receiver.
+	Secret Secret `yaml:"secret" json:"secret"`
+	// SignatureHeader and TimestampHeader name the headers carrying the
+	// signature and the Unix time the request was signed at.
+	SignatureHeader string `yaml:"signature_header,omitempty" json:"signature_header,omitempty"`
+	TimestampHeader string `yaml:"timestamp_header,omitempty" json:"timestamp_header,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *WebhookSigningConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultWebhookSigningConfig
+	type plain WebhookSigningConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("missing secret in webhook signing config")
+	}
+	if c.SignatureHeader == "" || c.TimestampHeader == "" {
+		return fmt.Errorf("signature and timestamp headers must not be empty in webhook signing config")
+	}
+	if strings.EqualFold(c.SignatureHeader, c.TimestampHeader) {
+		return fmt.Errorf("signature and timestamp headers must differ in webhook signing config")
+	}
 	return nil
 }
 
@@ -424,6 +989,18 @@ func (c *OpsGenieConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return unmarshal((*plain)(c))
 }
 
+// checkTemplate returns an error if the text of the field is not a valid
+// template. Functions are not checked as the functions available to
+// templates are only known once the templates are loaded.
+func checkTemplate(field, text string) error {
+	t := parse.New(field)
+	t.Mode = parse.SkipFuncCheck
+	if _, err := t.Parse(text, "", "", map[string]*parse.Tree{}); err != nil {
+		return fmt.Errorf("invalid template in %s: %s", field, err)
+	}
+	return nil
+}
+
 // VictorOpsConfig configures notifications via VictorOps.
 type VictorOpsConfig struct {
 	NotifierConfig `yaml:",inline" json:",inline"`
@@ -437,8 +1014,15 @@ type VictorOpsConfig struct {
 	StateMessage      string `yaml:"state_message" json:"state_message"`
 	EntityDisplayName string `yaml:"entity_display_name" json:"entity_display_name"`
 	MonitoringTool    string `yaml:"monitoring_tool" json:"monitoring_tool"`
+	// CustomFields are templated and added to the fields of the alert sent
+	// to VictorOps.
+	CustomFields map[string]string `yaml:"custom_fields,omitempty" json:"custom_fields,omitempty"`
 }
 
+// victorOpsReservedFields are the fields of VictorOps alerts set by the
+// configuration that custom fields cannot override.
+var victorOpsReservedFields = []string{"routing_key", "message_type", "state_message", "entity_display_name", "monitoring_tool", "entity_id", "entity_state"}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (c *VictorOpsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultVictorOpsConfig
@@ -449,7 +1033,12 @@ func (c *VictorOpsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	if c.RoutingKey == "" {
 		return fmt.Errorf("missing Routing key in VictorOps config")
 	}
-	return nil
+	for _, f := range victorOpsReservedFields {
+		if _, ok := c.CustomFields[f]; ok {
+			return fmt.Errorf("custom field %q conflicts with a fixed field of VictorOps alerts", f)
+		}
+	}
+	return checkTemplate("routing_key", c.RoutingKey)
 }
 
 type duration time.Duration
@@ -496,3 +1085,447 @@ func (c *PushoverConfig) UnmarshalYAML(unmarshal func(interface{}) error) error
 	}
 	return nil
 }
+
+// StatuspageConfig configures incidents on Statuspage pages.
+type StatuspageConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	APIKey Secret `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	APIURL string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	PageID string `yaml:"page_id,omitempty" json:"page_id,omitempty"`
+	// Name identifies the incident of an alert group and must not change
+	// while the group is firing.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	Body string `yaml:"body,omitempty" json:"body,omitempty"`
+	// ComponentLabel is the label whose values determine the affected
+	// components. Values are mapped to component IDs via Components or
+	// used as component IDs if no mapping is given.
+	ComponentLabel  string            `yaml:"component_label,omitempty" json:"component_label,omitempty"`
+	Components      map[string]string `yaml:"components,omitempty" json:"components,omitempty"`
+	ComponentStatus string            `yaml:"component_status,omitempty" json:"component_status,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *StatuspageConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultStatuspageConfig
+	type plain StatuspageConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.APIKey == "" {
+		return fmt.Errorf("missing API key in Statuspage config")
+	}
+	if c.PageID == "" {
+		return fmt.Errorf("missing page ID in Statuspage config")
+	}
+	switch c.ComponentStatus {
+	case "degraded_performance", "partial_outage", "major_outage", "under_maintenance":
+	default:
+		return fmt.Errorf("unknown component status %q in Statuspage config", c.ComponentStatus)
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	return nil
+}
+
+// GrafanaOnCallConfig configures notifications via the formatted webhook
+// integration of Grafana OnCall.
+type GrafanaOnCallConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// URL is the integration URL, which contains the integration token.
+	URL                   Secret `yaml:"url,omitempty" json:"url,omitempty"`
+	Title                 string `yaml:"title,omitempty" json:"title,omitempty"`
+	Message               string `yaml:"message,omitempty" json:"message,omitempty"`
+	ImageURL              string `yaml:"image_url,omitempty" json:"image_url,omitempty"`
+	LinkToUpstreamDetails string `yaml:"link_to_upstream_details,omitempty" json:"link_to_upstream_details,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GrafanaOnCallConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultGrafanaOnCallConfig
+	type plain GrafanaOnCallConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.URL == "" {
+		return fmt.Errorf("missing URL in Grafana OnCall config")
+	}
+	return nil
+}
+
+// MSTeamsConfig configures notifications via Microsoft Teams incoming
+// webhooks. A proxy can be set in the HTTP config.
+type MSTeamsConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	WebhookURL Secret `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	Title      string `yaml:"title,omitempty" json:"title,omitempty"`
+	Summary    string `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Text       string `yaml:"text,omitempty" json:"text,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *MSTeamsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultMSTeamsConfig
+	type plain MSTeamsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("missing webhook URL in Microsoft Teams config")
+	}
+	return nil
+}
+
+// TelegramConfig configures notifications via a Telegram bot.
+type TelegramConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	APIURL               string `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	BotToken             Secret `yaml:"bot_token,omitempty" json:"bot_token,omitempty"`
+	ChatID               int64  `yaml:"chat_id,omitempty" json:"chat_id,omitempty"`
+	Message              string `yaml:"message,omitempty" json:"message,omitempty"`
+	ParseMode            string `yaml:"parse_mode,omitempty" json:"parse_mode,omitempty"`
+	DisableNotifications bool   `yaml:"disable_notifications,omitempty" json:"disable_notifications,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TelegramConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultTelegramConfig
+	type plain TelegramConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.BotToken == "" {
+		return fmt.Errorf("missing bot token in Telegram config")
+	}
+	if c.ChatID == 0 {
+		return fmt.Errorf("missing chat ID in Telegram config")
+	}
+	switch c.ParseMode {
+	case "", "HTML", "Markdown", "MarkdownV2":
+	default:
+		return fmt.Errorf("unknown parse mode %q in Telegram config", c.ParseMode)
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	return nil
+}
+
+// GoogleChatConfig configures notifications via Google Chat incoming
+// webhooks. Notifications are posted as cards with the title as header.
+type GoogleChatConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	WebhookURL Secret `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	Title      string `yaml:"title,omitempty" json:"title,omitempty"`
+	Text       string `yaml:"text,omitempty" json:"text,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GoogleChatConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultGoogleChatConfig
+	type plain GoogleChatConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("missing webhook URL in Google Chat config")
+	}
+	return nil
+}
+
+// DiscordConfig configures notifications via Discord webhooks.
+// Notifications are posted as embeds with the title and message.
+type DiscordConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	WebhookURL Secret `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	Title      string `yaml:"title,omitempty" json:"title,omitempty"`
+	Message    string `yaml:"message,omitempty" json:"message,omitempty"`
+	// Username and AvatarURL override the defaults of the webhook.
+	Username  string `yaml:"username,omitempty" json:"username,omitempty"`
+	AvatarURL string `yaml:"avatar_url,omitempty" json:"avatar_url,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *DiscordConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultDiscordConfig
+	type plain DiscordConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("missing webhook URL in Discord config")
+	}
+	return nil
+}
+
+// JiraConfig configures notifications via Jira issues. An issue is opened
+// for each aggregation group and updated by the later notifications of the
+// group while it is open.
+type JiraConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// APIURL is the URL of the Jira REST API, e.g.
+	// https://example.atlassian.net/rest/api/2/.
+	APIURL      string   `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	Project     string   `yaml:"project,omitempty" json:"project,omitempty"`
+	IssueType   string   `yaml:"issue_type,omitempty" json:"issue_type,omitempty"`
+	Summary     string   `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Labels      []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// ResolveTransition is the name of the transition applied to the issue
+	// once the group resolves. Empty leaves the issue open.
+	ResolveTransition string `yaml:"resolve_transition,omitempty" json:"resolve_transition,omitempty"`
+	// ResolveComment is commented on the issue once the group resolves.
+	// Empty comments nothing.
+	ResolveComment string `yaml:"resolve_comment,omitempty" json:"resolve_comment,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *JiraConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultJiraConfig
+	type plain JiraConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.APIURL == "" {
+		return fmt.Errorf("missing api_url in Jira config")
+	}
+	if _, err := url.Parse(c.APIURL); err != nil {
+		return fmt.Errorf("invalid api_url in Jira config: %s", err)
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	if c.Project == "" {
+		return fmt.Errorf("missing project in Jira config")
+	}
+	if c.IssueType == "" {
+		return fmt.Errorf("missing issue_type in Jira config")
+	}
+	return nil
+}
+
+// SigV4Config configures the AWS credentials requests are signed with. The
+// credentials are read from the environment if no access key is set.
+type SigV4Config struct {
+	Region    string `yaml:"region,omitempty" json:"region,omitempty"`
+	AccessKey string `yaml:"access_key,omitempty" json:"access_key,omitempty"`
+	SecretKey Secret `yaml:"secret_key,omitempty" json:"secret_key,omitempty"`
+	// RoleARN is the role assumed with the credentials.
+	RoleARN string `yaml:"role_arn,omitempty" json:"role_arn,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SigV4Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SigV4Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return fmt.Errorf("access_key and secret_key must be set together in SigV4 config")
+	}
+	return nil
+}
+
+// SNSConfig configures notifications via AWS SNS. Messages are published to
+// either a topic or a phone number.
+type SNSConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// APIURL defaults to the SNS endpoint of the region.
+	APIURL string      `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	SigV4  SigV4Config `yaml:"sigv4,omitempty" json:"sigv4,omitempty"`
+
+	TopicARN    string `yaml:"topic_arn,omitempty" json:"topic_arn,omitempty"`
+	PhoneNumber string `yaml:"phone_number,omitempty" json:"phone_number,omitempty"`
+	Subject     string `yaml:"subject,omitempty" json:"subject,omitempty"`
+	Message     string `yaml:"message,omitempty" json:"message,omitempty"`
+	// Attributes are templated message attributes, e.g. to filter
+	// subscriptions by labels. Attributes that are empty are not sent.
+	Attributes map[string]string `yaml:"attributes,omitempty" json:"attributes,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SNSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultSNSConfig
+	type plain SNSConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if (c.TopicARN == "") == (c.PhoneNumber == "") {
+		return fmt.Errorf("exactly one of topic_arn and phone_number must be set in SNS config")
+	}
+	if c.SigV4.Region == "" && c.TopicARN != "" {
+		// Topic ARNs are of the form arn:aws:sns:<region>:<account>:<name>.
+		if parts := strings.Split(c.TopicARN, ":"); len(parts) == 6 {
+			c.SigV4.Region = parts[3]
+		}
+	}
+	if c.SigV4.Region == "" {
+		return fmt.Errorf("missing region in SNS config")
+	}
+	if c.APIURL == "" {
+		c.APIURL = fmt.Sprintf("https://sns.%s.amazonaws.com/", c.SigV4.Region)
+	}
+	if !strings.HasSuffix(c.APIURL, "/") {
+		c.APIURL += "/"
+	}
+	return nil
+}
+
+// ExecConfig configures notifications via a local command, which reads the
+// JSON payload of webhooks from its standard input.
+type ExecConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	Command string   `yaml:"command" json:"command"`
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+	// Timeout limits the duration of a single run of the command.
+	Timeout model.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// MaxConcurrency limits the number of runs of the command at the same
+	// time. Zero means no limit.
+	MaxConcurrency int `yaml:"max_concurrency,omitempty" json:"max_concurrency,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ExecConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultExecConfig
+	type plain ExecConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Command == "" {
+		return fmt.Errorf("missing command in exec config")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive in exec config")
+	}
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative in exec config")
+	}
+	return nil
+}
+
+// MQTTConfig configures notifications via an MQTT broker. The connection to
+// the broker is kept open between notifications.
+type MQTTConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	// URL of the broker, e.g. tcp://broker:1883 or ssl://broker:8883.
+	URL       string              `yaml:"url" json:"url"`
+	TLSConfig commoncfg.TLSConfig `yaml:"tls_config,omitempty" json:"tls_config,omitempty"`
+	// ClientID defaults to an identifier derived from the configuration
+	// that is unique to the Alertmanager process.
+	ClientID string `yaml:"client_id,omitempty" json:"client_id,omitempty"`
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password Secret `yaml:"password,omitempty" json:"password,omitempty"`
+
+	Topic string `yaml:"topic,omitempty" json:"topic,omitempty"`
+	// Payload defaults to the JSON payload of webhooks.
+	Payload string `yaml:"payload,omitempty" json:"payload,omitempty"`
+	QoS     int    `yaml:"qos" json:"qos"`
+	Retain  bool   `yaml:"retain,omitempty" json:"retain,omitempty"`
+	// KeepAlive is the keep alive interval negotiated with the broker.
+	// Connections idle for longer are reopened before publishing.
+	KeepAlive model.Duration `yaml:"keep_alive,omitempty" json:"keep_alive,omitempty"`
+}
+
+// MQTTSecureSchemes are the schemes of broker URLs connected to with TLS.
+var MQTTSecureSchemes = map[string]bool{"ssl": true, "tls": true, "mqtts": true}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *MQTTConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultMQTTConfig
+	type plain MQTTConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.URL == "" {
+		return fmt.Errorf("missing url in MQTT config")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url in MQTT config: %s", err)
+	}
+	if u.Scheme != "tcp" && u.Scheme != "mqtt" && !MQTTSecureSchemes[u.Scheme] {
+		return fmt.Errorf("unsupported scheme %q of url in MQTT config", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host of url in MQTT config")
+	}
+	if c.QoS < 0 || c.QoS > 2 {
+		return fmt.Errorf("qos must be 0, 1 or 2 in MQTT config")
+	}
+	if c.KeepAlive < model.Duration(time.Second) || c.KeepAlive > model.Duration(0xffff*time.Second) {
+		return fmt.Errorf("keep_alive must be between 1s and 18h12m15s in MQTT config")
+	}
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("password requires a username in MQTT config")
+	}
+	return nil
+}
+
+// KubernetesConfig configures the creation of Kubernetes events for the
+// objects referenced by alert labels.
+type KubernetesConfig struct {
+	NotifierConfig `yaml:",inline" json:",inline"`
+
+	// HTTPConfig defaults to the service account credentials if the API
+	// server is not set.
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// APIServer defaults to the in-cluster API server.
+	APIServer      string `yaml:"api_server,omitempty" json:"api_server,omitempty"`
+	NamespaceLabel string `yaml:"namespace_label,omitempty" json:"namespace_label,omitempty"`
+	Reason         string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Message        string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *KubernetesConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultKubernetesConfig
+	type plain KubernetesConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.NamespaceLabel == "" {
+		return fmt.Errorf("missing namespace label in Kubernetes config")
+	}
+	if c.APIServer == "" {
+		c.APIServer = kubernetesInClusterAPIServer
+		if c.HTTPConfig == nil {
+			c.HTTPConfig = &commoncfg.HTTPClientConfig{
+				BearerTokenFile: kubernetesServiceAccountDir + "token",
+				TLSConfig: commoncfg.TLSConfig{
+					CAFile: kubernetesServiceAccountDir + "ca.crt",
+				},
+			}
+		}
+	}
+	if !strings.HasSuffix(c.APIServer, "/") {
+		c.APIServer += "/"
+	}
+	return nil
+}
@@ -57,6 +57,163 @@ headers:
 	}
 }
 
+func TestEmailOAuth2IsValid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+to: 'to@email.com'
+auth_oauth2:
+  client_secret: secret
+  token_url: https://login.example.com/token
+`,
+			expected: "missing client_id or client_secret in oauth2 config",
+		},
+		{
+			in: `
+to: 'to@email.com'
+auth_oauth2:
+  client_id: alertmanager
+  client_secret: secret
+`,
+			expected: "missing token_url in oauth2 config",
+		},
+		{
+			in: `
+to: 'to@email.com'
+oauth2:
+  client_id: alertmanager
+  client_secret: secret
+  token_url: https://login.example.com/token
+`,
+			expected: "oauth2 is not supported in email config, use auth_oauth2",
+		},
+	} {
+		var cfg EmailConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestEmailDKIMIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+to: 'to@email.com'
+dkim:
+  selector: alertmanager
+  domain: example.com
+`,
+			expected: "missing key_file in email DKIM config",
+		},
+		{
+			in: `
+to: 'to@email.com'
+dkim:
+  key_file: dkim.pem
+  domain: example.com
+`,
+			expected: "missing selector in email DKIM config",
+		},
+		{
+			in: `
+to: 'to@email.com'
+dkim:
+  key_file: dkim.pem
+  selector: alertmanager
+`,
+			expected: "missing domain in email DKIM config",
+		},
+		{
+			in: `
+to: 'to@email.com'
+dkim:
+  key_file: dkim.pem
+  selector: alertmanager
+  domain: example.com
+  headers: [To, Subject]
+`,
+			expected: "the headers of the email DKIM config must include From",
+		},
+	} {
+		var cfg EmailConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestEmailAttachmentsAreValid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+to: 'to@email.com'
+attachments:
+- url: http://grafana/render
+`,
+			expected: "missing name in email attachment",
+		},
+		{
+			in: `
+to: 'to@email.com'
+attachments:
+- name: graph
+`,
+			expected: `missing url in email attachment "graph"`,
+		},
+		{
+			in: `
+to: 'to@email.com'
+attachments:
+- name: graph
+  url: http://grafana/render
+  max_size: 0
+`,
+			expected: `max_size of email attachment "graph" must be positive`,
+		},
+		{
+			in: `
+to: 'to@email.com'
+attachments:
+- name: graph
+  url: http://grafana/render
+- name: graph
+  url: http://grafana/render
+`,
+			expected: `duplicate attachment name "graph" in email config`,
+		},
+	} {
+		var cfg EmailConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
 func TestPagerdutyRoutingKeyIsPresent(t *testing.T) {
 	in := `
 routing_key: ''
@@ -91,6 +248,57 @@ service_key: ''
 	}
 }
 
+func TestPagerdutyConfigIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+routing_key: 'abc'
+severity_mapping:
+  page: critical
+`,
+			expected: "severity_mapping requires a severity_label in PagerDuty config",
+		},
+		{
+			in: `
+routing_key: 'abc'
+severity_label: severity
+severity_mapping:
+  page: urgent
+`,
+			expected: `invalid PagerDuty severity "urgent" for "page", must be one of ["critical" "error" "warning" "info"]`,
+		},
+		{
+			in: `
+routing_key: 'abc'
+links:
+- text: 'Runbook'
+`,
+			expected: "missing href in PagerDuty link",
+		},
+		{
+			in: `
+routing_key: 'abc'
+images:
+- alt: 'Graph'
+`,
+			expected: "missing src in PagerDuty image",
+		},
+	} {
+		var cfg PagerdutyConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
 func TestHipchatRoomIDIsPresent(t *testing.T) {
 	in := `
 room_id: ''
@@ -145,6 +353,155 @@ http_config:
 	}
 }
 
+func TestWebhookCloudEventsModeIsValid(t *testing.T) {
+	in := `
+url: 'http://example.com'
+cloudevents:
+  mode: batched
+`
+	var cfg WebhookConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "unknown cloudevents mode \"batched\""
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestWebhookSigning(t *testing.T) {
+	in := `
+url: 'http://example.com'
+signing:
+  secret: s3cr3t
+`
+	var cfg WebhookConfig
+	if err := yaml.UnmarshalStrict([]byte(in), &cfg); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cfg.Signing.SignatureHeader != "X-Alertmanager-Signature" || cfg.Signing.TimestampHeader != "X-Alertmanager-Timestamp" {
+		t.Errorf("Unexpected default headers %q and %q", cfg.Signing.SignatureHeader, cfg.Signing.TimestampHeader)
+	}
+
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in:       "url: 'http://example.com'\nsigning:\n  signature_header: X-Signature\n",
+			expected: "missing secret in webhook signing config",
+		},
+		{
+			in:       "url: 'http://example.com'\nsigning:\n  secret: s3cr3t\n  timestamp_header: x-alertmanager-signature\n",
+			expected: "signature and timestamp headers must differ in webhook signing config",
+		},
+	} {
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
+func TestWebhookMethodIsValid(t *testing.T) {
+	in := `
+url: 'http://example.com'
+method: get
+`
+	var cfg WebhookConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "unsupported method \"GET\" in webhook config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestWebhookRetryBackoffIsValid(t *testing.T) {
+	in := `
+url: 'http://example.com'
+retry:
+  initial_backoff: 1m
+  max_backoff: 10s
+`
+	var cfg WebhookConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "initial_backoff must not exceed max_backoff in retry config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestWebhookPayloadIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in: `
+url: 'http://example.com'
+version: 3
+`,
+			expected: "unsupported payload version \"3\" in webhook config",
+		},
+		{
+			in: `
+url: 'http://example.com'
+version: 5
+payload: tiny
+`,
+			expected: "unknown payload \"tiny\" in webhook config",
+		},
+		{
+			in: `
+url: 'http://example.com'
+version: 5
+max_alerts: -1
+`,
+			expected: "max_alerts must not be negative in webhook config",
+		},
+		{
+			in: `
+url: 'http://example.com'
+max_alerts: 10
+`,
+			expected: "max_alerts and the compact payload require payload version 5 in webhook config",
+		},
+		{
+			in: `
+url: 'http://example.com'
+payload: compact
+`,
+			expected: "max_alerts and the compact payload require payload version 5 in webhook config",
+		},
+	} {
+		var cfg WebhookConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
+
 func TestWebhookHttpConfigIsOptional(t *testing.T) {
 	in := `
 url: 'http://example.com'
@@ -232,6 +589,62 @@ routing_key: ''
 	}
 }
 
+func TestVictorOpsRoutingKeyTemplate(t *testing.T) {
+	in := `
+routing_key: '{{ .GroupLabels.team | toUpper }}'
+`
+	var cfg VictorOpsConfig
+	if err := yaml.UnmarshalStrict([]byte(in), &cfg); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	in = `
+routing_key: '{{ .GroupLabels.team '
+`
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "invalid template in routing_key: template: routing_key:1: unclosed action"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestVictorOpsCustomFieldsValidation(t *testing.T) {
+	in := `
+routing_key: 'test'
+custom_fields:
+  entity_state: 'state_message'
+`
+	var cfg VictorOpsConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "custom field \"entity_state\" conflicts with a fixed field of VictorOps alerts"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+
+	in = `
+routing_key: 'test'
+custom_fields:
+  my_special_field: 'special_label'
+`
+	err = yaml.UnmarshalStrict([]byte(in), &cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v := cfg.CustomFields["my_special_field"]; v != "special_label" {
+		t.Errorf("unexpected custom field %q", v)
+	}
+}
+
 func TestPushoverUserKeyIsPresent(t *testing.T) {
 	in := `
 user_key: ''
@@ -324,6 +737,33 @@ fields:
 	}
 }
 
+func TestSlackFollowUpIsValid(t *testing.T) {
+	for in, expected := range map[string]string{
+		`
+channel: '#alerts'
+follow_up: edit
+`: "unknown follow_up \"edit\" in Slack config",
+		`
+channel: '#alerts'
+follow_up: thread
+`: "follow_up \"thread\" requires a bot_token in Slack config",
+		`
+bot_token: xoxb-token
+follow_up: update
+`: "missing channel in Slack config with bot_token",
+	} {
+		var cfg SlackConfig
+		err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", expected)
+		}
+		if err.Error() != expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+		}
+	}
+}
+
 func TestSlackFieldConfigUnmarshalling(t *testing.T) {
 	in := `
 fields:
@@ -380,6 +820,246 @@ fields:
 	}
 }
 
+func TestStatuspagePageIDIsPresent(t *testing.T) {
+	in := `
+api_key: 'secret'
+page_id: ''
+`
+	var cfg StatuspageConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing page ID in Statuspage config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestStatuspageComponentStatus(t *testing.T) {
+	in := `
+api_key: 'secret'
+page_id: 'page'
+component_status: 'broken'
+`
+	var cfg StatuspageConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "unknown component status \"broken\" in Statuspage config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestKubernetesInClusterDefaults(t *testing.T) {
+	var cfg KubernetesConfig
+	if err := yaml.UnmarshalStrict([]byte("{}"), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.APIServer != "https://kubernetes.default.svc/" {
+		t.Errorf("unexpected API server %q", cfg.APIServer)
+	}
+	if cfg.HTTPConfig == nil || cfg.HTTPConfig.BearerTokenFile != "/var/run/secrets/kubernetes.io/serviceaccount/token" {
+		t.Errorf("expected service account credentials, got %v", cfg.HTTPConfig)
+	}
+}
+
+func TestTelegramChatIDIsPresent(t *testing.T) {
+	in := `
+bot_token: 'secret'
+`
+	var cfg TelegramConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing chat ID in Telegram config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestTelegramParseMode(t *testing.T) {
+	in := `
+bot_token: 'secret'
+chat_id: -1001234567890
+parse_mode: 'BBCode'
+`
+	var cfg TelegramConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "unknown parse mode \"BBCode\" in Telegram config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestGoogleChatWebhookURLIsPresent(t *testing.T) {
+	in := `
+title: 'Alerts'
+`
+	var cfg GoogleChatConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing webhook URL in Google Chat config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestDiscordWebhookURLIsPresent(t *testing.T) {
+	in := `
+username: 'Alertmanager'
+`
+	var cfg DiscordConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing webhook URL in Discord config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestJiraIssueTypeIsPresent(t *testing.T) {
+	in := `
+api_url: 'https://example.atlassian.net/rest/api/2'
+project: 'OPS'
+`
+	var cfg JiraConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing issue_type in Jira config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestSNSRegionFromTopicARN(t *testing.T) {
+	in := `
+topic_arn: 'arn:aws:sns:eu-west-1:123456789012:alerts'
+`
+	var cfg SNSConfig
+	if err := yaml.UnmarshalStrict([]byte(in), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.SigV4.Region != "eu-west-1" {
+		t.Errorf("unexpected region %q", cfg.SigV4.Region)
+	}
+	if cfg.APIURL != "https://sns.eu-west-1.amazonaws.com/" {
+		t.Errorf("unexpected API URL %q", cfg.APIURL)
+	}
+}
+
+func TestSNSTargetIsPresent(t *testing.T) {
+	in := `
+sigv4:
+  region: 'eu-west-1'
+`
+	var cfg SNSConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "exactly one of topic_arn and phone_number must be set in SNS config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
 func newBoolPointer(b bool) *bool {
 	return &b
 }
+
+func TestExecCommandIsPresent(t *testing.T) {
+	in := `
+args: ['--verbose']
+`
+	var cfg ExecConfig
+	err := yaml.UnmarshalStrict([]byte(in), &cfg)
+
+	expected := "missing command in exec config"
+
+	if err == nil {
+		t.Fatalf("no error returned, expected:\n%v", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("\nexpected:\n%v\ngot:\n%v", expected, err.Error())
+	}
+}
+
+func TestMQTTDefaults(t *testing.T) {
+	in := `
+url: 'ssl://broker.example.com'
+`
+	var cfg MQTTConfig
+	if err := yaml.UnmarshalStrict([]byte(in), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.QoS != 1 {
+		t.Errorf("unexpected QoS %d", cfg.QoS)
+	}
+	if cfg.Topic != `{{ template "mqtt.default.topic" . }}` {
+		t.Errorf("unexpected topic %q", cfg.Topic)
+	}
+}
+
+func TestMQTTInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{
+			in:       `topic: 'alerts'`,
+			expected: "missing url in MQTT config",
+		},
+		{
+			in:       `url: 'http://broker.example.com'`,
+			expected: `unsupported scheme "http" of url in MQTT config`,
+		},
+		{
+			in: `
+url: 'tcp://broker.example.com:1883'
+qos: 3
+`,
+			expected: "qos must be 0, 1 or 2 in MQTT config",
+		},
+	} {
+		var cfg MQTTConfig
+		err := yaml.UnmarshalStrict([]byte(tc.in), &cfg)
+		if err == nil {
+			t.Fatalf("no error returned, expected:\n%v", tc.expected)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("\nexpected:\n%v\ngot:\n%v", tc.expected, err.Error())
+		}
+	}
+}
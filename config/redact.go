@@ -0,0 +1,293 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	commoncfg "github.com/prometheus/common/config"
+	"gopkg.in/yaml.v2"
+)
+
+// RedactionMode determines how secrets are rendered when the configuration
+// is exposed, e.g. by the status API.
+type RedactionMode string
+
+const (
+	// RedactionFull replaces every secret with a fixed placeholder.
+	RedactionFull RedactionMode = "full"
+	// RedactionHash replaces every secret with a short fingerprint of its
+	// value, so that credential changes can be detected without revealing them.
+	// The fingerprints are keyed with a random key of the process, so they can
+	// only be compared within one run of an Alertmanager, not across restarts
+	// or instances.
+	RedactionHash RedactionMode = "hash"
+)
+
+const secretPlaceholder = "<secret>"
+
+// redactionKey is the key of the secret fingerprints. Unlike a plain hash,
+// fingerprints keyed with it cannot be matched against guessed values.
+var redactionKey = newRedactionKey()
+
+func newRedactionKey() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("generating the secret redaction key: %v", err))
+	}
+	return key
+}
+
+// SecretRedaction configures how secrets are rendered in the configuration
+// exposed by the API.
+type SecretRedaction struct {
+	Mode RedactionMode `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// VisibleFields lists the names of secret fields, e.g. "routing_key",
+	// whose values are displayed as is.
+	VisibleFields []string `yaml:"visible_fields,omitempty" json:"visible_fields,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *SecretRedaction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SecretRedaction
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	switch r.Mode {
+	case "":
+		r.Mode = RedactionFull
+	case RedactionFull, RedactionHash:
+	default:
+		return fmt.Errorf("unknown secret redaction mode %q", r.Mode)
+	}
+	return nil
+}
+
+func (r *SecretRedaction) visible(field string) bool {
+	if r == nil {
+		return false
+	}
+	for _, f := range r.VisibleFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// render returns the representation of the secret value s stored in the
+// given field.
+func (r *SecretRedaction) render(field, s string) string {
+	if r.visible(field) {
+		return s
+	}
+	if r != nil && r.Mode == RedactionHash {
+		mac := hmac.New(sha256.New, redactionKey)
+		mac.Write([]byte(s))
+		return fmt.Sprintf("<secret:%x>", mac.Sum(nil)[:6])
+	}
+	return secretPlaceholder
+}
+
+// RedactedYAML returns the YAML representation of the configuration with
+// secrets rendered according to the global secret redaction settings.
+func (c *Config) RedactedYAML() (string, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	var v yaml.MapSlice
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return "", err
+	}
+	secrets := map[string]string{}
+	collectSecrets(reflect.ValueOf(c), "", "yaml", secrets)
+
+	r := c.redaction()
+	out := redactTree(v, "", func(path string, key string) (string, bool) {
+		s, ok := secrets[path]
+		if !ok {
+			return "", false
+		}
+		return r.render(key, s), true
+	})
+
+	b, err = yaml.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// RedactedJSON returns the JSON representation of the configuration with
+// secrets rendered according to the global secret redaction settings.
+func (c *Config) RedactedJSON() (json.RawMessage, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	secrets := map[string]string{}
+	collectSecrets(reflect.ValueOf(c), "", "json", secrets)
+
+	r := c.redaction()
+	out := redactTree(v, "", func(path string, key string) (string, bool) {
+		s, ok := secrets[path]
+		if !ok {
+			return "", false
+		}
+		return r.render(key, s), true
+	})
+	return json.Marshal(out)
+}
+
+func (c *Config) redaction() *SecretRedaction {
+	if c.Global == nil {
+		return nil
+	}
+	return c.Global.SecretRedaction
+}
+
+var (
+	secretType       = reflect.TypeOf(Secret(""))
+	commonSecretType = reflect.TypeOf(commoncfg.Secret(""))
+)
+
+// collectSecrets walks v and stores the values of all non-empty secrets by
+// the path under which they appear when v is encoded with the given struct tag.
+func collectSecrets(v reflect.Value, path, tag string, secrets map[string]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectSecrets(v.Elem(), path, tag, secrets)
+		}
+	case reflect.String:
+		if (v.Type() == secretType || v.Type() == commonSecretType) && v.String() != "" {
+			secrets[path] = v.String()
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectSecrets(v.Index(i), joinPath(path, strconv.Itoa(i)), tag, secrets)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				continue
+			}
+			collectSecrets(v.MapIndex(k), joinPath(path, k.String()), tag, secrets)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			name, inline, skip := fieldKey(f, tag)
+			if skip {
+				continue
+			}
+			if inline {
+				collectSecrets(v.Field(i), path, tag, secrets)
+				continue
+			}
+			collectSecrets(v.Field(i), joinPath(path, name), tag, secrets)
+		}
+	}
+}
+
+// fieldKey returns the key under which the struct field is encoded by the
+// YAML or JSON encoder and whether it is inlined into its parent.
+func fieldKey(f reflect.StructField, tag string) (name string, inline, skip bool) {
+	t := f.Tag.Get(tag)
+	if t == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(t, ",")
+	name = parts[0]
+
+	switch tag {
+	case "yaml":
+		for _, p := range parts[1:] {
+			if p == "inline" {
+				return "", true, false
+			}
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+	case "json":
+		if name == "" {
+			if f.Anonymous {
+				return "", true, false
+			}
+			name = f.Name
+		}
+	}
+	return name, false, false
+}
+
+func joinPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "/" + elem
+}
+
+// redactTree returns a copy of the decoded YAML or JSON value v in which all
+// values for which replace returns true are substituted.
+func redactTree(v interface{}, path string, replace func(path, key string) (string, bool)) interface{} {
+	switch vv := v.(type) {
+	case yaml.MapSlice:
+		res := make(yaml.MapSlice, 0, len(vv))
+		for _, item := range vv {
+			key := fmt.Sprint(item.Key)
+			p := joinPath(path, key)
+			if s, ok := replace(p, key); ok {
+				res = append(res, yaml.MapItem{Key: item.Key, Value: s})
+				continue
+			}
+			res = append(res, yaml.MapItem{Key: item.Key, Value: redactTree(item.Value, p, replace)})
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(vv))
+		for key, val := range vv {
+			p := joinPath(path, key)
+			if s, ok := replace(p, key); ok {
+				res[key] = s
+				continue
+			}
+			res[key] = redactTree(val, p, replace)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, 0, len(vv))
+		for i, val := range vv {
+			res = append(res, redactTree(val, joinPath(path, strconv.Itoa(i)), replace))
+		}
+		return res
+	}
+	return v
+}
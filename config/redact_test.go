@@ -0,0 +1,138 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const redactionConf = `
+global:
+  slack_api_url: 'https://hooks.slack.com/services/SLACKSECRET'
+  http_config:
+    bearer_token: BEARERSECRET
+  secret_redaction:
+    mode: %s
+    visible_fields: [%s]
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  pagerduty_configs:
+  - routing_key: ROUTINGKEY
+    http_config:
+      basic_auth:
+        username: user
+        password: BASICSECRET
+`
+
+func loadRedactionConf(t *testing.T, mode, visible string) *Config {
+	c, err := Load(fmt.Sprintf(redactionConf, mode, visible))
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	return c
+}
+
+func TestRedactionFull(t *testing.T) {
+	c := loadRedactionConf(t, "full", "")
+
+	y, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := c.RedactedJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The JSON encoder escapes angle brackets.
+	unescaped := strings.NewReplacer(`\u003c`, "<", `\u003e`, ">").Replace(string(j))
+
+	for _, out := range []string{y, unescaped} {
+		for _, s := range []string{"SLACKSECRET", "BEARERSECRET", "ROUTINGKEY", "BASICSECRET"} {
+			if strings.Contains(out, s) {
+				t.Errorf("Secret %q not redacted in:\n%s", s, out)
+			}
+		}
+		if !strings.Contains(out, "<secret>") {
+			t.Errorf("Expected redaction placeholder in:\n%s", out)
+		}
+		if !strings.Contains(out, "user") {
+			t.Errorf("Expected non-secret field to be rendered in:\n%s", out)
+		}
+	}
+}
+
+func TestRedactionHash(t *testing.T) {
+	c := loadRedactionConf(t, "hash", "")
+
+	y, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(y, "ROUTINGKEY") {
+		t.Fatalf("Secret not redacted in:\n%s", y)
+	}
+
+	other := loadRedactionConf(t, "hash", "")
+	other.Receivers[0].PagerdutyConfigs[0].RoutingKey = "OTHERKEY"
+	y2, err := other.RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(y, "<secret:") {
+		t.Fatalf("Expected secret fingerprint in:\n%s", y)
+	}
+	if y == y2 {
+		t.Fatalf("Expected different fingerprints for different secrets")
+	}
+
+	same, err := loadRedactionConf(t, "hash", "").RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y != same {
+		t.Fatalf("Expected equal fingerprints for equal secrets")
+	}
+	sum := sha256.Sum256([]byte("ROUTINGKEY"))
+	if strings.Contains(y, fmt.Sprintf("%x", sum[:6])) {
+		t.Fatalf("Expected a keyed fingerprint in:\n%s", y)
+	}
+}
+
+func TestRedactionVisibleFields(t *testing.T) {
+	c := loadRedactionConf(t, "full", "routing_key")
+
+	y, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(y, "ROUTINGKEY") {
+		t.Errorf("Expected visible field to be rendered in:\n%s", y)
+	}
+	if strings.Contains(y, "BASICSECRET") {
+		t.Errorf("Secret not redacted in:\n%s", y)
+	}
+}
+
+func TestRedactionUnknownMode(t *testing.T) {
+	_, err := Load(fmt.Sprintf(redactionConf, "bogus", ""))
+	if err == nil {
+		t.Fatal("Expected error for unknown redaction mode")
+	}
+}
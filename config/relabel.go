@@ -0,0 +1,115 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// RelabelAction is the action applied by a group relabel configuration.
+type RelabelAction string
+
+const (
+	// RelabelReplace sets the target label to the replacement expanded with
+	// the capture groups of the regex if the regex matches.
+	RelabelReplace RelabelAction = "replace"
+	// RelabelLowercase sets the target label to the lowercased source value.
+	RelabelLowercase RelabelAction = "lowercase"
+	// RelabelUppercase sets the target label to the uppercased source value.
+	RelabelUppercase RelabelAction = "uppercase"
+)
+
+// DefaultGroupRelabelConfig is the default group relabel configuration.
+var DefaultGroupRelabelConfig = GroupRelabelConfig{
+	Separator:   ";",
+	Regex:       Regexp{regexp.MustCompile("^(?:(.*))$")},
+	Replacement: "$1",
+	Action:      RelabelReplace,
+}
+
+// GroupRelabelConfig transforms the labels of an alert before it is grouped.
+// It works like the relabel_config of Prometheus, but the source values can
+// also be taken from annotations and only the labels used to group the alert
+// change; the alert itself keeps its labels.
+type GroupRelabelConfig struct {
+	// SourceLabels and SourceAnnotations are concatenated with the separator
+	// in this order to form the value the regex is matched against.
+	SourceLabels      model.LabelNames `yaml:"source_labels,omitempty" json:"source_labels,omitempty"`
+	SourceAnnotations model.LabelNames `yaml:"source_annotations,omitempty" json:"source_annotations,omitempty"`
+	Separator         string           `yaml:"separator,omitempty" json:"separator,omitempty"`
+	Regex             Regexp           `yaml:"regex,omitempty" json:"regex,omitempty"`
+	TargetLabel       model.LabelName  `yaml:"target_label" json:"target_label"`
+	Replacement       string           `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+	Action            RelabelAction    `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GroupRelabelConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultGroupRelabelConfig
+	type plain GroupRelabelConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.SourceLabels) == 0 && len(c.SourceAnnotations) == 0 {
+		return fmt.Errorf("missing source_labels or source_annotations in group relabel config")
+	}
+	if c.TargetLabel == "" {
+		return fmt.Errorf("missing target_label in group relabel config")
+	}
+	switch c.Action {
+	case RelabelReplace, RelabelLowercase, RelabelUppercase:
+	default:
+		return fmt.Errorf("unknown group relabel action %q", c.Action)
+	}
+	return nil
+}
+
+// Apply returns the labels with the target label set to the transformed
+// source value. The target label is removed if the value is empty. The
+// labels are not changed if the regex of a replace action does not match.
+func (c *GroupRelabelConfig) Apply(labels, annotations model.LabelSet) model.LabelSet {
+	values := make([]string, 0, len(c.SourceLabels)+len(c.SourceAnnotations))
+	for _, ln := range c.SourceLabels {
+		values = append(values, string(labels[ln]))
+	}
+	for _, an := range c.SourceAnnotations {
+		values = append(values, string(annotations[an]))
+	}
+	val := strings.Join(values, c.Separator)
+
+	switch c.Action {
+	case RelabelReplace:
+		indexes := c.Regex.FindStringSubmatchIndex(val)
+		if indexes == nil {
+			return labels
+		}
+		val = string(c.Regex.ExpandString(nil, c.Replacement, val, indexes))
+	case RelabelLowercase:
+		val = strings.ToLower(val)
+	case RelabelUppercase:
+		val = strings.ToUpper(val)
+	}
+
+	res := labels.Clone()
+	if val == "" {
+		delete(res, c.TargetLabel)
+	} else {
+		res[c.TargetLabel] = model.LabelValue(val)
+	}
+	return res
+}
@@ -0,0 +1,230 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretRef references a secret stored outside of the configuration file.
+// Exactly one of its fields is set.
+type SecretRef struct {
+	// File is the path of a file containing the secret, relative to the
+	// configuration file. Trailing newlines are removed.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	// Env is the name of an environment variable containing the secret.
+	Env string `yaml:"env,omitempty" json:"env,omitempty"`
+	// Vault references a key of a secret in HashiCorp Vault.
+	Vault *VaultSecretRef `yaml:"vault,omitempty" json:"vault,omitempty"`
+}
+
+// VaultSecretRef references a key of a secret in HashiCorp Vault. The
+// server and token are taken from the VAULT_ADDR and VAULT_TOKEN environment
+// variables.
+type VaultSecretRef struct {
+	// Path is the API path of the secret without the version prefix, e.g.
+	// "secret/data/alertmanager" for the KV version 2 secrets engine.
+	Path string `yaml:"path" json:"path"`
+	Key  string `yaml:"key" json:"key"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *SecretRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SecretRef
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	n := 0
+	for _, set := range []bool{r.File != "", r.Env != "", r.Vault != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return errors.New("secret reference requires exactly one of file, env and vault")
+	}
+	if r.Vault != nil && (r.Vault.Path == "" || r.Vault.Key == "") {
+		return errors.New("vault secret reference requires a path and a key")
+	}
+	return nil
+}
+
+func (r *SecretRef) String() string {
+	switch {
+	case r.File != "":
+		return "file " + r.File
+	case r.Env != "":
+		return "environment variable " + r.Env
+	default:
+		return fmt.Sprintf("Vault secret %s key %s", r.Vault.Path, r.Vault.Key)
+	}
+}
+
+// loadedSecret is a secret loaded from a reference.
+type loadedSecret struct {
+	ref   SecretRef
+	value string
+}
+
+var (
+	// resolverMtx serializes loading configurations, whose secret references
+	// are resolved by resolver while they are unmarshaled.
+	resolverMtx sync.Mutex
+	resolver    *secretResolver
+)
+
+// vaultTimeout is the timeout of the requests to Vault.
+const vaultTimeout = 10 * time.Second
+
+// secretResolver loads referenced secrets.
+type secretResolver struct {
+	baseDir string
+	// policy is the crypto policy of the configuration, which restricts the
+	// connections to Vault.
+	policy *CryptoPolicy
+	client *http.Client
+	// vault caches the Vault secrets read by the resolver.
+	vault map[string]map[string]interface{}
+	// loaded records the resolved references in order.
+	loaded []*loadedSecret
+}
+
+func newSecretResolver(baseDir string, policy *CryptoPolicy) *secretResolver {
+	return &secretResolver{
+		baseDir: baseDir,
+		policy:  policy,
+		vault:   map[string]map[string]interface{}{},
+	}
+}
+
+// resolve returns the value of the referenced secret.
+func (sr *secretResolver) resolve(ref SecretRef) (string, error) {
+	if ref.File != "" && sr.baseDir != "" && !filepath.IsAbs(ref.File) {
+		ref.File = filepath.Join(sr.baseDir, ref.File)
+	}
+
+	var (
+		v   string
+		err error
+	)
+	switch {
+	case ref.File != "":
+		var b []byte
+		b, err = ioutil.ReadFile(ref.File)
+		v = strings.TrimRight(string(b), "\r\n")
+	case ref.Env != "":
+		var ok bool
+		if v, ok = os.LookupEnv(ref.Env); !ok {
+			err = errors.New("not set")
+		}
+	case ref.Vault != nil:
+		v, err = sr.readVault(ref.Vault)
+	}
+	if err != nil {
+		return "", fmt.Errorf("loading secret from %s: %s", &ref, err)
+	}
+	sr.loaded = append(sr.loaded, &loadedSecret{ref: ref, value: v})
+	return v, nil
+}
+
+func (sr *secretResolver) readVault(ref *VaultSecretRef) (string, error) {
+	data, ok := sr.vault[ref.Path]
+	if !ok {
+		var err error
+		if data, err = sr.readVaultSecret(ref.Path); err != nil {
+			return "", err
+		}
+		sr.vault[ref.Path] = data
+	}
+	v, ok := data[ref.Key]
+	if !ok {
+		return "", errors.New("key not found")
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.New("value is not a string")
+	}
+	return s, nil
+}
+
+func (sr *secretResolver) readVaultSecret(path string) (map[string]interface{}, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("VAULT_ADDR is not set")
+	}
+	if err := sr.policy.CheckURL(addr); err != nil {
+		return nil, fmt.Errorf("VAULT_ADDR violates the crypto policy: %s", err)
+	}
+	if sr.client == nil {
+		c, err := NewHTTPClient(nil, sr.policy, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.Timeout = vaultTimeout
+		sr.client = c
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var res struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	// The KV version 2 secrets engine nests the data next to its metadata.
+	if inner, ok := res.Data["data"].(map[string]interface{}); ok {
+		if _, ok := res.Data["metadata"]; ok {
+			return inner, nil
+		}
+	}
+	return res.Data, nil
+}
+
+// SecretsChanged loads the secrets referenced by the configuration again and
+// returns true if any of them changed since the configuration was loaded.
+func (c *Config) SecretsChanged() (bool, error) {
+	sr := newSecretResolver("", c.Global.CryptoPolicy)
+	for _, s := range c.secrets {
+		v, err := sr.resolve(s.ref)
+		if err != nil {
+			return false, err
+		}
+		if v != s.value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
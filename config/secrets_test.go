@@ -0,0 +1,182 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const secretRefsConf = `
+global:
+  slack_api_url:
+    file: slack_url
+  smtp_smarthost: 'localhost:25'
+  smtp_from: 'alertmanager@example.org'
+  smtp_auth_password:
+    env: AM_TEST_SMTP_PASSWORD
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+  slack_configs:
+  - channel: '#team-x'
+  email_configs:
+  - to: 'team-x@example.org'
+  pagerduty_configs:
+  - routing_key:
+      vault:
+        path: secret/data/alertmanager
+        key: routing_key
+`
+
+func TestSecretRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	urlFile := filepath.Join(dir, "slack_url")
+	if err := ioutil.WriteFile(urlFile, []byte("https://hooks.slack.com/services/SLACKSECRET\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	confFile := filepath.Join(dir, "alertmanager.yml")
+	if err := ioutil.WriteFile(confFile, []byte(secretRefsConf), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("AM_TEST_SMTP_PASSWORD", "SMTPSECRET")
+	defer os.Unsetenv("AM_TEST_SMTP_PASSWORD")
+
+	routingKey := "ROUTINGKEY"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/alertmanager" || r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"routing_key":"` + routingKey + `"},"metadata":{"version":1}}}`))
+	}))
+	defer srv.Close()
+	os.Setenv("VAULT_ADDR", srv.URL)
+	defer os.Unsetenv("VAULT_ADDR")
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	c, _, err := LoadFile(confFile)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	rcv := c.Receivers[0]
+	if u := string(rcv.SlackConfigs[0].APIURL); u != "https://hooks.slack.com/services/SLACKSECRET" {
+		t.Errorf("Unexpected Slack API URL %q", u)
+	}
+	if p := string(rcv.EmailConfigs[0].AuthPassword); p != "SMTPSECRET" {
+		t.Errorf("Unexpected SMTP password %q", p)
+	}
+	if k := string(rcv.PagerdutyConfigs[0].RoutingKey); k != "ROUTINGKEY" {
+		t.Errorf("Unexpected routing key %q", k)
+	}
+
+	y, err := c.RedactedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"SLACKSECRET", "SMTPSECRET", "ROUTINGKEY"} {
+		if strings.Contains(y, s) {
+			t.Errorf("Secret %q not redacted in:\n%s", s, y)
+		}
+	}
+
+	changed, err := c.SecretsChanged()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Errorf("Expected unchanged secrets")
+	}
+
+	if err := ioutil.WriteFile(urlFile, []byte("https://hooks.slack.com/services/ROTATED\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = c.SecretsChanged()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Errorf("Expected changed file secret to be detected")
+	}
+
+	c, _, err = LoadFile(confFile)
+	if err != nil {
+		t.Fatalf("Error parsing config: %s", err)
+	}
+	routingKey = "ROTATED"
+	changed, err = c.SecretsChanged()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Errorf("Expected changed Vault secret to be detected")
+	}
+}
+
+func TestSecretRefErrors(t *testing.T) {
+	for _, tc := range []struct {
+		ref string
+		err string
+	}{
+		{
+			ref: `{env: AM_TEST_UNSET}`,
+			err: "loading secret from environment variable AM_TEST_UNSET: not set",
+		},
+		{
+			ref: `{file: /nonexistent/secret}`,
+			err: "loading secret from file /nonexistent/secret",
+		},
+		{
+			ref: `{env: AM_TEST_UNSET, file: secret}`,
+			err: "secret reference requires exactly one of file, env and vault",
+		},
+		{
+			ref: `{vault: {path: secret/alertmanager}}`,
+			err: "vault secret reference requires a path and a key",
+		},
+		{
+			ref: `{command: cat}`,
+			err: "field command not found",
+		},
+	} {
+		_, err := Load(`
+global:
+  slack_api_url: ` + tc.ref + `
+route:
+  receiver: team-X
+receivers:
+- name: team-X
+`)
+		if err == nil {
+			t.Errorf("Expected error for %s", tc.ref)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("Expected error containing %q for %s, got %q", tc.err, tc.ref, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// SilenceLimitsConfig restricts the scope of the silences created and
+// updated through the API, to prevent accidentally muting all alerts.
+type SilenceLimitsConfig struct {
+	// RequiredLabels are labels every silence must have an equality matcher
+	// for, e.g. the cluster.
+	RequiredLabels model.LabelNames `yaml:"required_labels,omitempty" json:"required_labels,omitempty"`
+	// MaxDuration is the longest time from now a silence may mute alerts,
+	// including its renewals. Zero means unlimited.
+	MaxDuration model.Duration `yaml:"max_duration,omitempty" json:"max_duration,omitempty"`
+	// MaxMatchedAlerts is the largest number of firing alerts a silence may
+	// match unless it is forced. Zero means unlimited.
+	MaxMatchedAlerts int `yaml:"max_matched_alerts,omitempty" json:"max_matched_alerts,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SilenceLimitsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SilenceLimitsConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	for _, ln := range c.RequiredLabels {
+		if !ln.IsValid() {
+			return fmt.Errorf("invalid required label %q in silence limits", ln)
+		}
+	}
+	if c.MaxDuration < 0 {
+		return fmt.Errorf("max_duration of silence limits must not be negative")
+	}
+	if c.MaxMatchedAlerts < 0 {
+		return fmt.Errorf("max_matched_alerts of silence limits must not be negative")
+	}
+	return nil
+}
+
+// SilenceMetadataField is a structured field of silences, e.g. the ticket
+// of the change the silence belongs to. Once fields are configured,
+// silences created and updated through the API can only have the
+// configured fields.
+type SilenceMetadataField struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	// Regex the whole value has to match if it is set.
+	Regex *Regexp `yaml:"regex,omitempty" json:"regex,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SilenceMetadataField) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SilenceMetadataField
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Name == "" {
+		return fmt.Errorf("missing name in silence metadata field")
+	}
+	if !model.LabelName(c.Name).IsValid() {
+		return fmt.Errorf("invalid silence metadata field name %q", c.Name)
+	}
+	return nil
+}
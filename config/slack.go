@@ -0,0 +1,52 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// DefaultSlackInteractiveConfig defines default values for the Slack
+// interactive configuration.
+var DefaultSlackInteractiveConfig = SlackInteractiveConfig{
+	SilenceDuration: model.Duration(2 * time.Hour),
+	AckDuration:     model.Duration(time.Hour),
+}
+
+// SlackInteractiveConfig configures the handling of Slack slash commands and
+// interactive message buttons.
+type SlackInteractiveConfig struct {
+	// SigningSecret verifies that requests were sent by Slack.
+	SigningSecret Secret `yaml:"signing_secret" json:"signing_secret"`
+	// SilenceDuration is the duration of silences if none is given.
+	SilenceDuration model.Duration `yaml:"silence_duration,omitempty" json:"silence_duration,omitempty"`
+	// AckDuration is the duration of acknowledgements if none is given.
+	AckDuration model.Duration `yaml:"ack_duration,omitempty" json:"ack_duration,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SlackInteractiveConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultSlackInteractiveConfig
+	type plain SlackInteractiveConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.SigningSecret == "" {
+		return fmt.Errorf("missing signing_secret in Slack interactive config")
+	}
+	return nil
+}
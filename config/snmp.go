@@ -0,0 +1,178 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+var oidRE = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// OID is a numeric SNMP object identifier, e.g. "1.3.6.1.6.3.1.1.5.3".
+// A leading dot is stripped.
+type OID string
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (o *OID) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	s = strings.TrimPrefix(s, ".")
+	if !oidRE.MatchString(s) {
+		return fmt.Errorf("invalid OID %q", s)
+	}
+	*o = OID(s)
+	return nil
+}
+
+// SNMPTrapConfig configures how incoming SNMP traps are converted into
+// alerts.
+type SNMPTrapConfig struct {
+	// Communities accepted for SNMPv2c traps. If empty, SNMPv2c traps are
+	// rejected.
+	Communities []Secret `yaml:"communities,omitempty" json:"communities,omitempty"`
+	// Users accepted for SNMPv3 traps. If empty, SNMPv3 traps are rejected.
+	Users []*SNMPUser     `yaml:"users,omitempty" json:"users,omitempty"`
+	Rules []*SNMPTrapRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SNMPTrapConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SNMPTrapConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if len(c.Communities) == 0 && len(c.Users) == 0 {
+		return fmt.Errorf("no communities or users in snmp traps config")
+	}
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("no rules in snmp traps config")
+	}
+	names := map[string]struct{}{}
+	for _, u := range c.Users {
+		if _, ok := names[u.Name]; ok {
+			return fmt.Errorf("snmp user %q is not unique", u.Name)
+		}
+		names[u.Name] = struct{}{}
+	}
+	return nil
+}
+
+// SNMPUser is an SNMPv3 user of the user-based security model.
+type SNMPUser struct {
+	Name string `yaml:"name" json:"name"`
+	// AuthProtocol is one of "MD5" or "SHA". If empty, unauthenticated
+	// traps of the user are accepted.
+	AuthProtocol string `yaml:"auth_protocol,omitempty" json:"auth_protocol,omitempty"`
+	AuthPassword Secret `yaml:"auth_password,omitempty" json:"auth_password,omitempty"`
+	// PrivProtocol is one of "DES" or "AES". It requires an AuthProtocol.
+	PrivProtocol string `yaml:"priv_protocol,omitempty" json:"priv_protocol,omitempty"`
+	PrivPassword Secret `yaml:"priv_password,omitempty" json:"priv_password,omitempty"`
+	// EngineIDs are the hex encoded IDs of the SNMP engines sending traps of
+	// the user, e.g. "80001f8804746573". Traps of other engines are rejected.
+	// They are required with an AuthProtocol, as the keys are localized to
+	// each engine.
+	EngineIDs []string `yaml:"engine_ids,omitempty" json:"engine_ids,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (u *SNMPUser) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SNMPUser
+	if err := unmarshal((*plain)(u)); err != nil {
+		return err
+	}
+	if u.Name == "" {
+		return fmt.Errorf("missing name in snmp user")
+	}
+	switch u.AuthProtocol {
+	case "":
+		if u.PrivProtocol != "" {
+			return fmt.Errorf("snmp user %q: priv_protocol requires auth_protocol", u.Name)
+		}
+	case "MD5", "SHA":
+		if len(u.AuthPassword) < 8 {
+			return fmt.Errorf("snmp user %q: auth_password must be at least 8 characters", u.Name)
+		}
+	default:
+		return fmt.Errorf("snmp user %q: unknown auth_protocol %q", u.Name, u.AuthProtocol)
+	}
+	switch u.PrivProtocol {
+	case "":
+	case "DES", "AES":
+		if len(u.PrivPassword) < 8 {
+			return fmt.Errorf("snmp user %q: priv_password must be at least 8 characters", u.Name)
+		}
+	default:
+		return fmt.Errorf("snmp user %q: unknown priv_protocol %q", u.Name, u.PrivProtocol)
+	}
+	if u.AuthProtocol != "" && len(u.EngineIDs) == 0 {
+		return fmt.Errorf("snmp user %q: engine_ids are required with auth_protocol", u.Name)
+	}
+	for i, id := range u.EngineIDs {
+		// Engine IDs are 5 to 32 bytes long, RFC 3411, Section 5.
+		b, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(id), "0x"))
+		if err != nil || len(b) < 5 || len(b) > 32 {
+			return fmt.Errorf("snmp user %q: invalid engine ID %q", u.Name, id)
+		}
+		u.EngineIDs[i] = hex.EncodeToString(b)
+	}
+	return nil
+}
+
+// SNMPTrapRule converts traps with the given trap OID into alerts. Values of
+// variable bindings whose OID starts with one of the keys of VarBinds are
+// copied to the mapped label. Label and annotation values can reference the
+// mapped labels as $name, the trap OID as $trap_oid and the address of the
+// sender as $source.
+type SNMPTrapRule struct {
+	TrapOID OID `yaml:"trap_oid" json:"trap_oid"`
+	// ResolveOID is the trap OID that resolves the alert, e.g. linkUp for
+	// linkDown.
+	ResolveOID OID `yaml:"resolve_oid,omitempty" json:"resolve_oid,omitempty"`
+
+	VarBinds    map[OID]model.LabelName `yaml:"varbinds,omitempty" json:"varbinds,omitempty"`
+	Labels      map[string]string       `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string       `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *SNMPTrapRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain SNMPTrapRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if r.TrapOID == "" {
+		return fmt.Errorf("missing trap_oid in snmp trap rule")
+	}
+	if _, ok := r.Labels[model.AlertNameLabel]; !ok {
+		return fmt.Errorf("missing %s label in snmp trap rule", model.AlertNameLabel)
+	}
+	for k := range r.Labels {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for _, v := range r.VarBinds {
+		if !v.IsValid() {
+			return fmt.Errorf("invalid label name %q", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+// TenantHeader is the request header holding the tenant of API requests if
+// tenancy is enabled. It is set by the web config file for authenticated
+// clients, or by an authenticating proxy in front of the Alertmanager.
+const TenantHeader = "X-Scope-OrgID"
+
+// TenancyConfig partitions the alerts, silences and notification logs of a
+// shared Alertmanager by the value of a tenant label. The API restricts
+// requests to the tenant of their TenantHeader.
+type TenancyConfig struct {
+	// Label is the label holding the tenant of alerts.
+	Label model.LabelName `yaml:"label" json:"label"`
+	// AdminTenants are tenants whose requests are not restricted.
+	AdminTenants []string `yaml:"admin_tenants,omitempty" json:"admin_tenants,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TenancyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TenancyConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.Label == "" {
+		return fmt.Errorf("missing label in tenancy config")
+	}
+	if !c.Label.IsValid() {
+		return fmt.Errorf("invalid tenant label %q", c.Label)
+	}
+	return nil
+}
+
+// IsAdmin returns whether the requests of the tenant are not restricted.
+func (c *TenancyConfig) IsAdmin(tenant string) bool {
+	for _, t := range c.AdminTenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// setGroupBy adds the tenant label to the labels the routes group alerts
+// by, so that aggregation groups and thus their notification logs never
+// mix the alerts of several tenants. Routes without their own grouping
+// inherit it from their parent.
+func (c *TenancyConfig) setGroupBy(r *Route, root bool) {
+	if root || r.GroupBy != nil {
+		var found bool
+		for _, ln := range r.GroupBy {
+			if ln == c.Label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.GroupBy = append(r.GroupBy, c.Label)
+		}
+	}
+	for _, sr := range r.Routes {
+		c.setGroupBy(sr, false)
+	}
+}
+
+// checkGroupRelabelConfigs returns an error if a route changes the tenant
+// label before grouping, which would mix the alerts of several tenants.
+func (c *TenancyConfig) checkGroupRelabelConfigs(r *Route) error {
+	for _, rc := range r.GroupRelabelConfigs {
+		if rc.TargetLabel == c.Label {
+			return fmt.Errorf("group relabel config must not change the tenant label %q", c.Label)
+		}
+	}
+	for _, sr := range r.Routes {
+		if err := c.checkGroupRelabelConfigs(sr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScopeAlerts assigns the alerts posted by the tenant to it. It sets the
+// tenant label of alerts without one and returns an error if an alert
+// belongs to another tenant. The alerts of admin tenants are left as they
+// are.
+func (c *TenancyConfig) ScopeAlerts(tenant string, alerts []*types.Alert) error {
+	if c.IsAdmin(tenant) {
+		return nil
+	}
+	for _, a := range alerts {
+		if a.Labels == nil {
+			a.Labels = model.LabelSet{}
+		}
+		v, ok := a.Labels[c.Label]
+		if ok && string(v) != tenant {
+			return fmt.Errorf("alert %s does not belong to tenant %q", a.Name(), tenant)
+		}
+		a.Labels[c.Label] = model.LabelValue(tenant)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// MuteTimeInterval is a named set of time intervals during which the
+// notifications of the routes referencing it are muted.
+type MuteTimeInterval struct {
+	Name          string         `yaml:"name" json:"name"`
+	TimeIntervals []TimeInterval `yaml:"time_intervals" json:"time_intervals"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (mt *MuteTimeInterval) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain MuteTimeInterval
+	if err := unmarshal((*plain)(mt)); err != nil {
+		return err
+	}
+	if mt.Name == "" {
+		return fmt.Errorf("missing name in mute time interval")
+	}
+	if len(mt.TimeIntervals) == 0 {
+		return fmt.Errorf("missing time_intervals in mute time interval %q", mt.Name)
+	}
+	return nil
+}
+
+// ContainsTime returns whether t is within one of the time intervals.
+func (mt *MuteTimeInterval) ContainsTime(t time.Time) bool {
+	for _, ti := range mt.TimeIntervals {
+		if ti.ContainsTime(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeInterval describes recurring times of the week. A time matches if it
+// is within one of the time ranges on one of the weekdays. Missing times
+// match the whole day and missing weekdays match every day.
+type TimeInterval struct {
+	Times    []TimeRange `yaml:"times,omitempty" json:"times,omitempty"`
+	Weekdays []Weekday   `yaml:"weekdays,omitempty" json:"weekdays,omitempty"`
+	// TimeZone is the location the times and weekdays refer to.
+	TimeZone *Location `yaml:"time_zone,omitempty" json:"time_zone,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (ti *TimeInterval) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimeInterval
+	if err := unmarshal((*plain)(ti)); err != nil {
+		return err
+	}
+	if ti.TimeZone == nil {
+		ti.TimeZone = &Location{time.UTC}
+	}
+	return nil
+}
+
+// ContainsTime returns whether t is within the time interval. A time range
+// ending before it starts wraps around midnight and its part after midnight
+// belongs to the weekday it started on.
+func (ti TimeInterval) ContainsTime(t time.Time) bool {
+	if ti.TimeZone != nil {
+		t = t.In(ti.TimeZone.Location)
+	}
+	if len(ti.Times) == 0 {
+		return ti.onWeekday(t.Weekday())
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, tr := range ti.Times {
+		start, end := tr.StartTime.minutes(), tr.EndTime.minutes()
+		switch {
+		case start < end:
+			if minute >= start && minute < end && ti.onWeekday(t.Weekday()) {
+				return true
+			}
+		case minute >= start:
+			if ti.onWeekday(t.Weekday()) {
+				return true
+			}
+		case minute < end:
+			if ti.onWeekday((t.Weekday() + 6) % 7) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ti TimeInterval) onWeekday(wd time.Weekday) bool {
+	if len(ti.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range ti.Weekdays {
+		if time.Weekday(d) == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeRange is a range of the day from StartTime inclusive to EndTime
+// exclusive.
+type TimeRange struct {
+	StartTime TimeOfDay `yaml:"start_time" json:"start_time"`
+	EndTime   TimeOfDay `yaml:"end_time" json:"end_time"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (tr *TimeRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimeRange
+	if err := unmarshal((*plain)(tr)); err != nil {
+		return err
+	}
+	if tr.StartTime == tr.EndTime {
+		return fmt.Errorf("start_time and end_time of time range must differ")
+	}
+	return nil
+}
+
+func (t TimeOfDay) minutes() int {
+	return t.Hour*60 + t.Minute
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// TimingOverride changes the timers of the aggregation groups of a route
+// whose group labels match, e.g. to repeat notifications of critical groups
+// more often than those of warnings.
+type TimingOverride struct {
+	Match   map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+
+	GroupWait      *model.Duration `yaml:"group_wait,omitempty" json:"group_wait,omitempty"`
+	GroupInterval  *model.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
+	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (o *TimingOverride) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TimingOverride
+	if err := unmarshal((*plain)(o)); err != nil {
+		return err
+	}
+	if len(o.Match) == 0 && len(o.MatchRE) == 0 {
+		return fmt.Errorf("missing match or match_re in timing override")
+	}
+	for k := range o.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range o.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	if o.GroupWait == nil && o.GroupInterval == nil && o.RepeatInterval == nil {
+		return fmt.Errorf("timing override must set group_wait, group_interval or repeat_interval")
+	}
+	if o.GroupInterval != nil && time.Duration(*o.GroupInterval) == 0 {
+		return fmt.Errorf("group_interval cannot be zero")
+	}
+	if o.RepeatInterval != nil && time.Duration(*o.RepeatInterval) == 0 {
+		return fmt.Errorf("repeat_interval cannot be zero")
+	}
+	return nil
+}
+
+// UrgentGroupWait shortens the initial group_wait of the aggregation groups
+// of a route once they contain an alert whose labels match, e.g. to notify
+// about critical alerts immediately while warnings are batched.
+type UrgentGroupWait struct {
+	Match   map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	MatchRE map[string]Regexp `yaml:"match_re,omitempty" json:"match_re,omitempty"`
+
+	// GroupWait is the time to wait after a matching alert was added before
+	// the first notification of the group. It defaults to zero, i.e. the
+	// group is notified immediately.
+	GroupWait model.Duration `yaml:"group_wait,omitempty" json:"group_wait,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (u *UrgentGroupWait) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain UrgentGroupWait
+	if err := unmarshal((*plain)(u)); err != nil {
+		return err
+	}
+	if len(u.Match) == 0 && len(u.MatchRE) == 0 {
+		return fmt.Errorf("missing match or match_re in urgent_group_wait")
+	}
+	for k := range u.Match {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	for k := range u.MatchRE {
+		if !model.LabelNameRE.MatchString(k) {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+)
+
+// Actions taken on received alerts with invalid UTF-8 in their labels or
+// annotations.
+const (
+	InvalidUTF8Reject    = "reject"
+	InvalidUTF8Drop      = "drop"
+	InvalidUTF8Normalize = "normalize"
+)
+
+// AlertValidationConfig restricts the alerts accepted from senders, to
+// protect the Alertmanager from malformed or oversized alerts. Zero limits
+// mean no limit.
+type AlertValidationConfig struct {
+	MaxLabels                int `yaml:"max_labels,omitempty" json:"max_labels,omitempty"`
+	MaxLabelValueLength      int `yaml:"max_label_value_length,omitempty" json:"max_label_value_length,omitempty"`
+	MaxAnnotationValueLength int `yaml:"max_annotation_value_length,omitempty" json:"max_annotation_value_length,omitempty"`
+	// RequiredLabels are labels every alert must have.
+	RequiredLabels model.LabelNames `yaml:"required_labels,omitempty" json:"required_labels,omitempty"`
+	// InvalidUTF8 is either reject, which rejects alerts with invalid
+	// UTF-8, drop, which removes the labels and annotations with invalid
+	// UTF-8, or normalize, which replaces the invalid bytes with the
+	// Unicode replacement character.
+	InvalidUTF8 string `yaml:"invalid_utf8,omitempty" json:"invalid_utf8,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *AlertValidationConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain AlertValidationConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.MaxLabels < 0 || c.MaxLabelValueLength < 0 || c.MaxAnnotationValueLength < 0 {
+		return fmt.Errorf("alert validation limits must not be negative")
+	}
+	for _, ln := range c.RequiredLabels {
+		if !ln.IsValid() {
+			return fmt.Errorf("invalid required label %q in alert validation", ln)
+		}
+	}
+	switch c.InvalidUTF8 {
+	case "":
+		c.InvalidUTF8 = InvalidUTF8Reject
+	case InvalidUTF8Reject, InvalidUTF8Drop, InvalidUTF8Normalize:
+	default:
+		return fmt.Errorf("unknown invalid_utf8 action %q, must be one of %q, %q or %q", c.InvalidUTF8, InvalidUTF8Reject, InvalidUTF8Drop, InvalidUTF8Normalize)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deadman notifies receivers once alerts that are expected to fire
+// continuously, such as the Watchdog alert of Prometheus, stop arriving.
+// This pages even if the Prometheus server sending the alerts is down.
+package deadman
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	// AlertName is the name of the alert receivers are notified about when
+	// the expected alert is missing.
+	AlertName = "DeadMansSwitch"
+	// LabelName is the label holding the name of the dead man's switch.
+	LabelName = "dead_mans_switch"
+)
+
+// sendTimeout is the maximum time spent on sending a single notification.
+const sendTimeout = time.Minute
+
+type metrics struct {
+	triggered     *prometheus.GaugeVec
+	failuresTotal *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{}
+
+	m.triggered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "alertmanager_dead_mans_switch_triggered",
+		Help: "Whether the alert expected by the dead man's switch is missing.",
+	}, []string{"dead_mans_switch"})
+	m.failuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_dead_mans_switch_notification_failures_total",
+		Help: "How many notifications of dead man's switches failed to be sent.",
+	}, []string{"dead_mans_switch"})
+
+	if r != nil {
+		r.MustRegister(m.triggered, m.failuresTotal)
+	}
+	return m
+}
+
+// deadMansSwitch is the runtime state of a configured dead man's switch.
+type deadMansSwitch struct {
+	conf     *config.DeadMansSwitchConfig
+	matchers types.Matchers
+	stage    notify.Stage
+
+	// lastSeen is the time a matching firing alert was last received.
+	lastSeen time.Time
+	// triggeredAt is the time the expected alert was found missing, zero
+	// while it keeps firing.
+	triggeredAt time.Time
+	// notifiedAt is the time the receiver was last notified about the
+	// missing alert.
+	notifiedAt time.Time
+}
+
+// Monitor tracks the alerts expected by the configured dead man's switches
+// and notifies their receivers once the alerts stop arriving.
+type Monitor struct {
+	alerts  provider.Alerts
+	leader  func() bool
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+
+	mtx      sync.Mutex
+	switches map[string]*deadMansSwitch
+}
+
+// New returns a new Monitor. Receivers are only notified while leader
+// returns true, so that a single cluster member notifies them.
+func New(alerts provider.Alerts, leader func() bool, r prometheus.Registerer, l log.Logger) *Monitor {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if leader == nil {
+		leader = func() bool { return true }
+	}
+	return &Monitor{
+		alerts:   alerts,
+		leader:   leader,
+		logger:   l,
+		metrics:  newMetrics(r),
+		now:      time.Now,
+		switches: map[string]*deadMansSwitch{},
+	}
+}
+
+// ApplyConfig sets the dead man's switches of the configuration. The state
+// of switches that are still configured is kept. New switches expect their
+// alert within the timeout from now.
+func (m *Monitor) ApplyConfig(conf *config.Config, tmpl *template.Template) {
+	receivers := map[string]*config.Receiver{}
+	for _, rc := range conf.Receivers {
+		receivers[rc.Name] = rc
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := m.now()
+	switches := make(map[string]*deadMansSwitch, len(conf.DeadMansSwitches))
+
+	for _, dc := range conf.DeadMansSwitches {
+		var matchers types.Matchers
+		for ln, lv := range dc.Match {
+			matchers = append(matchers, types.NewMatcher(model.LabelName(ln), lv))
+		}
+		for ln, lv := range dc.MatchRE {
+			matchers = append(matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
+		}
+		sort.Sort(matchers)
+
+		rc := receivers[dc.Receiver]
+		var fs notify.FanoutStage
+		for _, i := range notify.BuildReceiverIntegrations(rc, tmpl, nil, m.logger) {
+			fs = append(fs, notify.NewRetryStage(i, rc.Name))
+		}
+
+		s := &deadMansSwitch{
+			conf:     dc,
+			matchers: matchers,
+			stage:    fs,
+			lastSeen: now,
+		}
+		if prev, ok := m.switches[dc.Name]; ok {
+			s.lastSeen = prev.lastSeen
+			s.triggeredAt = prev.triggeredAt
+			s.notifiedAt = prev.notifiedAt
+		}
+		switches[dc.Name] = s
+	}
+	for name := range m.switches {
+		if _, ok := switches[name]; !ok {
+			m.metrics.triggered.DeleteLabelValues(name)
+		}
+	}
+	m.switches = switches
+}
+
+// Run observes the received alerts and checks the dead man's switches at
+// the given interval until stopc is closed.
+func (m *Monitor) Run(interval time.Duration, stopc <-chan struct{}) {
+	it := m.alerts.Subscribe()
+	defer it.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			return
+		case a, ok := <-it.Next():
+			if err := it.Err(); err != nil {
+				level.Error(m.logger).Log("msg", "Error on alert update", "err", err)
+				continue
+			}
+			if !ok {
+				return
+			}
+			m.observe(a)
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// observe records the alert for the dead man's switches it matches if it
+// is firing.
+func (m *Monitor) observe(a *types.Alert) {
+	now := m.now()
+	if a.ResolvedAt(now) {
+		return
+	}
+	seen := a.UpdatedAt
+	if seen.IsZero() || seen.After(now) {
+		seen = now
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, s := range m.switches {
+		if s.matchers.Match(a.Labels) && seen.After(s.lastSeen) {
+			s.lastSeen = seen
+		}
+	}
+}
+
+// check notifies the receivers of the dead man's switches whose alert is
+// missing, repeating the notification at the repeat interval, and notifies
+// them once the alert arrives again.
+func (m *Monitor) check() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := m.now()
+	for name, s := range m.switches {
+		missing := now.Sub(s.lastSeen) >= time.Duration(s.conf.Timeout)
+
+		switch {
+		case missing:
+			if s.triggeredAt.IsZero() {
+				s.triggeredAt = s.lastSeen.Add(time.Duration(s.conf.Timeout))
+				m.metrics.triggered.WithLabelValues(name).Set(1)
+				level.Warn(m.logger).Log("msg", "Expected alert is missing", "dead_mans_switch", name, "last_seen", s.lastSeen)
+			}
+			if !s.notifiedAt.IsZero() && now.Sub(s.notifiedAt) < time.Duration(s.conf.RepeatInterval) {
+				continue
+			}
+		case !s.triggeredAt.IsZero():
+			m.metrics.triggered.WithLabelValues(name).Set(0)
+			level.Info(m.logger).Log("msg", "Expected alert arrived again", "dead_mans_switch", name)
+		default:
+			m.metrics.triggered.WithLabelValues(name).Set(0)
+			continue
+		}
+
+		if m.leader() {
+			if err := m.send(s, now, missing); err != nil {
+				m.metrics.failuresTotal.WithLabelValues(name).Inc()
+				level.Error(m.logger).Log("msg", "Notifying about dead man's switch failed", "dead_mans_switch", name, "err", err)
+				continue
+			}
+		}
+		if missing {
+			s.notifiedAt = now
+		} else {
+			s.triggeredAt, s.notifiedAt = time.Time{}, time.Time{}
+		}
+	}
+}
+
+// send notifies the receiver of the dead man's switch about the missing
+// alert, or that it arrived again if missing is false.
+func (m *Monitor) send(s *deadMansSwitch, now time.Time, missing bool) error {
+	a := s.alert(missing)
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	ctx = notify.WithReceiverName(ctx, s.conf.Receiver)
+	ctx = notify.WithGroupKey(ctx, LabelName+"/"+s.conf.Name)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{LabelName: model.LabelValue(s.conf.Name)})
+	if missing {
+		ctx = notify.WithFiringAlerts(ctx, []uint64{uint64(a.Fingerprint())})
+	} else {
+		ctx = notify.WithResolvedAlerts(ctx, []uint64{uint64(a.Fingerprint())})
+	}
+	ctx = notify.WithNow(ctx, now)
+
+	_, _, err := s.stage.Exec(ctx, log.With(m.logger, "dead_mans_switch", s.conf.Name), a)
+	return err
+}
+
+// alert returns the alert the receiver is notified about. It resolves with
+// the time the expected alert was last seen if missing is false.
+func (s *deadMansSwitch) alert(missing bool) *types.Alert {
+	annotations := model.LabelSet{
+		"summary": model.LabelValue(fmt.Sprintf(
+			"No alert matching %s was received for %s", s.matchers, s.conf.Timeout,
+		)),
+	}
+	for k, v := range s.conf.Annotations {
+		annotations[model.LabelName(k)] = model.LabelValue(v)
+	}
+	a := &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: AlertName,
+				LabelName:            model.LabelValue(s.conf.Name),
+			},
+			Annotations: annotations,
+			StartsAt:    s.triggeredAt,
+		},
+		UpdatedAt: s.lastSeen,
+	}
+	if !missing {
+		a.EndsAt = s.lastSeen
+	}
+	return a
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadman
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestCheck(t *testing.T) {
+	var got []template.Data
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data template.Data
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		got = append(got, data)
+	}))
+	defer srv.Close()
+
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+- name: deadman
+  webhook_configs:
+  - url: ` + srv.URL + `
+    send_resolved: true
+dead_mans_switches:
+- name: prometheus
+  receiver: deadman
+  match:
+    alertname: Watchdog
+  timeout: 5m
+  repeat_interval: 30m
+  annotations:
+    runbook: https://example.com/runbooks/watchdog
+`)
+	require.NoError(t, err)
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	// Notification templates determine the alert status with the current
+	// time, so the test starts in the past.
+	now := time.Now().Add(-2 * time.Hour)
+	m := New(nil, nil, nil, nil)
+	m.now = func() time.Time { return now }
+	m.ApplyConfig(conf, tmpl)
+
+	watchdog := func() *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "Watchdog"},
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   now.Add(4 * time.Minute),
+			},
+			UpdatedAt: now,
+		}
+	}
+
+	// The alert keeps arriving.
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Minute)
+		m.observe(watchdog())
+		m.observe(&types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Other"},
+			StartsAt: now,
+		}})
+		m.check()
+	}
+	require.Empty(t, got)
+
+	// The alert stops arriving.
+	lastSeen := now
+	now = now.Add(5 * time.Minute)
+	m.check()
+	require.Len(t, got, 1)
+	require.Equal(t, "firing", got[0].Status)
+	require.Equal(t, template.KV{LabelName: "prometheus"}, got[0].GroupLabels)
+	require.Equal(t, AlertName, got[0].Alerts[0].Labels["alertname"])
+	require.Equal(t, "https://example.com/runbooks/watchdog", got[0].Alerts[0].Annotations["runbook"])
+	require.True(t, lastSeen.Add(5*time.Minute).Equal(got[0].Alerts[0].StartsAt))
+
+	// The notification is repeated at the repeat interval.
+	now = now.Add(20 * time.Minute)
+	m.check()
+	require.Len(t, got, 1)
+	now = now.Add(10 * time.Minute)
+	m.check()
+	require.Len(t, got, 2)
+
+	// The receiver is notified once the alert arrives again.
+	now = now.Add(time.Minute)
+	m.observe(watchdog())
+	m.check()
+	require.Len(t, got, 3)
+	require.Equal(t, "resolved", got[2].Status)
+
+	m.check()
+	require.Len(t, got, 3)
+}
+
+func TestApplyConfigKeepsState(t *testing.T) {
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+dead_mans_switches:
+- name: prometheus
+  receiver: default
+  match_re:
+    alertname: Watchdog|DeadMansSwitch
+`)
+	require.NoError(t, err)
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	now := time.Now()
+	m := New(nil, nil, nil, nil)
+	m.now = func() time.Time { return now }
+	m.ApplyConfig(conf, tmpl)
+
+	lastSeen := now
+	now = now.Add(time.Hour)
+	m.ApplyConfig(conf, tmpl)
+	require.Equal(t, lastSeen, m.switches["prometheus"].lastSeen)
+
+	conf.DeadMansSwitches = nil
+	m.ApplyConfig(conf, tmpl)
+	require.Empty(t, m.switches)
+}
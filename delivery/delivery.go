@@ -0,0 +1,345 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package delivery records the results of the notifications of receivers
+// and reports receivers whose notifications fail too often, e.g. because
+// their credentials expired.
+package delivery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// AlertName is the name of the alert fired for failing receivers.
+const AlertName = "ReceiverDeliveryFailing"
+
+// webhookTimeout is the maximum time spent on a request to an admin webhook.
+const webhookTimeout = 30 * time.Second
+
+type metrics struct {
+	duration *prometheus.HistogramVec
+	failing  *prometheus.GaugeVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "alertmanager_receiver_delivery_duration_seconds",
+			Help:    "The duration of notifications including retries by receiver, integration and result.",
+			Buckets: []float64{.1, .5, 1, 5, 10, 30, 60},
+		}, []string{"receiver", "integration", "result"}),
+		failing: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "alertmanager_receiver_delivery_failing",
+			Help: "Whether the ratio of failed notifications of a receiver reached its threshold.",
+		}, []string{"receiver"}),
+	}
+	if r != nil {
+		r.MustRegister(m.duration, m.failing)
+	}
+	return m
+}
+
+// result is the result of a single notification.
+type result struct {
+	at     time.Time
+	failed bool
+}
+
+// receiver is the delivery state of a receiver.
+type receiver struct {
+	results []result
+	lastErr error
+	// failingSince is the time the receiver started failing, zero if it
+	// does not fail.
+	failingSince time.Time
+}
+
+// IntegrationStatus is the result of the last notification of an
+// integration of a receiver.
+type IntegrationStatus struct {
+	LastAttempt time.Time
+	// LastError is the error of the last failed notification, which may
+	// have been followed by successful ones.
+	LastError   string
+	LastErrorAt time.Time
+	// FailingSince is the time of the first failed notification since the
+	// last successful one, zero if the last notification succeeded.
+	FailingSince time.Time
+}
+
+// integrationKey identifies an integration of a receiver.
+type integrationKey struct {
+	receiver    string
+	integration string
+	idx         int
+}
+
+// Monitor records the results of notifications and reports the receivers
+// whose ratio of failed notifications reaches the configured threshold.
+type Monitor struct {
+	alerts  provider.Alerts
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+
+	mtx          sync.Mutex
+	confs        map[string]*config.DeliveryFailureConfig
+	receivers    map[string]*receiver
+	integrations map[integrationKey]*IntegrationStatus
+}
+
+// New returns a new Monitor inserting the alerts of failing receivers into
+// the given provider.
+func New(ap provider.Alerts, r prometheus.Registerer, l log.Logger) *Monitor {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Monitor{
+		alerts:       ap,
+		logger:       l,
+		metrics:      newMetrics(r),
+		now:          time.Now,
+		confs:        map[string]*config.DeliveryFailureConfig{},
+		receivers:    map[string]*receiver{},
+		integrations: map[integrationKey]*IntegrationStatus{},
+	}
+}
+
+// ApplyConfig sets the delivery failure configurations of the receivers.
+// The results of receivers that are still configured are kept.
+func (m *Monitor) ApplyConfig(c *config.Config) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var (
+		confs      = map[string]*config.DeliveryFailureConfig{}
+		configured = map[string]struct{}{}
+	)
+	for _, rc := range c.Receivers {
+		configured[rc.Name] = struct{}{}
+		if rc.DeliveryFailure != nil {
+			confs[rc.Name] = rc.DeliveryFailure
+		}
+	}
+	for k := range m.integrations {
+		if _, ok := configured[k.receiver]; !ok {
+			delete(m.integrations, k)
+		}
+	}
+	for name := range m.receivers {
+		if _, ok := confs[name]; !ok {
+			delete(m.receivers, name)
+			m.metrics.failing.DeleteLabelValues(name)
+		}
+	}
+	m.confs = confs
+}
+
+// Observe records the result of a notification of the receiver via the
+// integration with the index, which took the duration d and failed if err is
+// not nil.
+func (m *Monitor) Observe(name, integration string, idx int, d time.Duration, err error) {
+	res := "success"
+	if err != nil {
+		res = "failure"
+	}
+	m.metrics.duration.WithLabelValues(name, integration, res).Observe(d.Seconds())
+
+	m.mtx.Lock()
+	now := m.now()
+	key := integrationKey{receiver: name, integration: integration, idx: idx}
+	status, ok := m.integrations[key]
+	if !ok {
+		status = &IntegrationStatus{}
+		m.integrations[key] = status
+	}
+	status.LastAttempt = now
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorAt = now
+		if status.FailingSince.IsZero() {
+			status.FailingSince = now
+		}
+	} else {
+		status.FailingSince = time.Time{}
+	}
+
+	conf, ok := m.confs[name]
+	if !ok {
+		m.mtx.Unlock()
+		return
+	}
+	r, ok := m.receivers[name]
+	if !ok {
+		r = &receiver{}
+		m.receivers[name] = r
+	}
+
+	r.results = append(r.results, result{at: now, failed: err != nil})
+	if err != nil {
+		r.lastErr = err
+	}
+	for len(r.results) > 0 && now.Sub(r.results[0].at) > time.Duration(conf.Window) {
+		r.results = r.results[1:]
+	}
+
+	var failures int
+	for _, res := range r.results {
+		if res.failed {
+			failures++
+		}
+	}
+	var (
+		attempts   = len(r.results)
+		ratio      = float64(failures) / float64(attempts)
+		wasFailing = !r.failingSince.IsZero()
+		failing    = attempts >= conf.MinAttempts && ratio >= conf.Threshold
+	)
+	if failing && !wasFailing {
+		r.failingSince = now
+	}
+	since := r.failingSince
+	if !failing {
+		r.failingSince = time.Time{}
+	}
+	msg := &webhookMessage{
+		Receiver:     name,
+		Status:       string(model.AlertResolved),
+		FailureRatio: ratio,
+		Attempts:     attempts,
+		Failures:     failures,
+	}
+	if failing {
+		msg.Status = string(model.AlertFiring)
+		if r.lastErr != nil {
+			msg.LastError = r.lastErr.Error()
+		}
+	}
+	m.mtx.Unlock()
+
+	if failing != wasFailing {
+		level.Warn(m.logger).Log("msg", "Delivery state of receiver changed", "receiver", name, "status", msg.Status, "failure_ratio", ratio, "attempts", attempts)
+		if failing {
+			m.metrics.failing.WithLabelValues(name).Set(1)
+		} else {
+			m.metrics.failing.WithLabelValues(name).Set(0)
+		}
+		if conf.WebhookURL != "" {
+			go m.sendWebhook(conf, msg)
+		}
+	}
+	// The alert of a failing receiver is refreshed by every notification
+	// and resolves once the receiver stops failing or the window passes
+	// without notifications.
+	if conf.Alert && (failing || wasFailing) {
+		endsAt := now.Add(time.Duration(conf.Window))
+		if !failing {
+			endsAt = now
+		}
+		if err := m.alerts.Put(m.alert(name, since, endsAt, ratio, conf)); err != nil {
+			level.Error(m.logger).Log("msg", "Inserting delivery failure alert failed", "receiver", name, "err", err)
+		}
+	}
+}
+
+// IntegrationStatus returns the result of the last notification of the
+// integration of the receiver with the index. It returns false if the
+// integration did not send a notification yet.
+func (m *Monitor) IntegrationStatus(name, integration string, idx int) (IntegrationStatus, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	status, ok := m.integrations[integrationKey{receiver: name, integration: integration, idx: idx}]
+	if !ok {
+		return IntegrationStatus{}, false
+	}
+	return *status, true
+}
+
+// alert returns the alert of the failing receiver.
+func (m *Monitor) alert(name string, startsAt, endsAt time.Time, ratio float64, conf *config.DeliveryFailureConfig) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: AlertName,
+				"receiver":           model.LabelValue(name),
+			},
+			Annotations: model.LabelSet{
+				"summary": model.LabelValue(fmt.Sprintf("%.0f%% of the notifications of receiver %s failed within %s", ratio*100, name, conf.Window)),
+			},
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+		},
+		UpdatedAt: m.now(),
+	}
+}
+
+// webhookMessage is sent to admin webhooks when a receiver starts or stops
+// failing.
+type webhookMessage struct {
+	Receiver     string  `json:"receiver"`
+	Status       string  `json:"status"`
+	FailureRatio float64 `json:"failureRatio"`
+	Attempts     int     `json:"attempts"`
+	Failures     int     `json:"failures"`
+	LastError    string  `json:"lastError,omitempty"`
+}
+
+// sendWebhook sends the message to the admin webhook of the receiver.
+func (m *Monitor) sendWebhook(conf *config.DeliveryFailureConfig, msg *webhookMessage) {
+	if err := sendWebhook(conf, msg); err != nil {
+		level.Error(m.logger).Log("msg", "Sending delivery failure webhook failed", "receiver", msg.Receiver, "err", err)
+	}
+}
+
+func sendWebhook(conf *config.DeliveryFailureConfig, msg *webhookMessage) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return err
+	}
+	client, err := config.NewHTTPClient(conf.HTTPConfig, conf.CryptoPolicy, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	resp, err := ctxhttp.Post(ctx, client, conf.WebhookURL, "application/json", &buf)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestMonitor(t *testing.T) {
+	msgs := make(chan webhookMessage, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg webhookMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs <- msg
+	}))
+	defer srv.Close()
+
+	conf, err := config.Load(`
+route:
+  receiver: pager
+receivers:
+- name: pager
+  delivery_failure:
+    window: 10m
+    threshold: 0.5
+    min_attempts: 2
+    alert: true
+    webhook_url: ` + srv.URL + `
+- name: team
+`)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	m := New(alerts, nil, nil)
+	m.now = func() time.Time { return now }
+	m.ApplyConfig(conf)
+
+	fp := model.LabelSet{model.AlertNameLabel: AlertName, "receiver": "pager"}.Fingerprint()
+	unauthorized := errors.New("unexpected status code 401")
+
+	// Receivers without configuration are only measured.
+	m.Observe("team", "webhook", 0, time.Second, unauthorized)
+	m.Observe("team", "webhook", 0, time.Second, unauthorized)
+
+	// A single failure is below the minimum number of attempts.
+	m.Observe("pager", "pagerduty", 0, time.Second, unauthorized)
+	_, err = alerts.Get(fp)
+	require.Error(t, err)
+
+	now = now.Add(time.Minute)
+	m.Observe("pager", "pagerduty", 0, time.Second, nil)
+	a, err := alerts.Get(fp)
+	require.NoError(t, err)
+	require.False(t, a.Resolved())
+	require.Equal(t, now, a.StartsAt)
+
+	msg := <-msgs
+	require.Equal(t, "firing", msg.Status)
+	require.Equal(t, 0.5, msg.FailureRatio)
+	require.Equal(t, unauthorized.Error(), msg.LastError)
+
+	// The failure leaves the window.
+	now = now.Add(10 * time.Minute)
+	m.Observe("pager", "pagerduty", 0, time.Second, nil)
+	a, err = alerts.Get(fp)
+	require.NoError(t, err)
+	require.True(t, a.ResolvedAt(now))
+
+	msg = <-msgs
+	require.Equal(t, "resolved", msg.Status)
+	require.Equal(t, 0.0, msg.FailureRatio)
+}
+
+func TestIntegrationStatus(t *testing.T) {
+	conf, err := config.Load(`
+route:
+  receiver: team
+receivers:
+- name: team
+- name: other
+`)
+	require.NoError(t, err)
+
+	now := time.Now()
+	m := New(nil, nil, nil)
+	m.now = func() time.Time { return now }
+	m.ApplyConfig(conf)
+
+	_, ok := m.IntegrationStatus("team", "slack", 0)
+	require.False(t, ok)
+
+	unauthorized := errors.New("unexpected status code 401")
+	m.Observe("team", "slack", 0, time.Second, unauthorized)
+	m.Observe("team", "slack", 1, time.Second, nil)
+	m.Observe("other", "webhook", 0, time.Second, nil)
+	failedAt := now
+
+	now = now.Add(time.Hour)
+	m.Observe("team", "slack", 0, time.Second, unauthorized)
+
+	status, ok := m.IntegrationStatus("team", "slack", 0)
+	require.True(t, ok)
+	require.Equal(t, IntegrationStatus{
+		LastAttempt:  now,
+		LastError:    unauthorized.Error(),
+		LastErrorAt:  now,
+		FailingSince: failedAt,
+	}, status)
+
+	status, ok = m.IntegrationStatus("team", "slack", 1)
+	require.True(t, ok)
+	require.Equal(t, IntegrationStatus{LastAttempt: failedAt}, status)
+
+	// A successful notification ends the failure but keeps the last error.
+	now = now.Add(time.Minute)
+	m.Observe("team", "slack", 0, time.Second, nil)
+	status, _ = m.IntegrationStatus("team", "slack", 0)
+	require.True(t, status.FailingSince.IsZero())
+	require.Equal(t, unauthorized.Error(), status.LastError)
+
+	// The integrations of removed receivers are forgotten.
+	conf.Receivers = conf.Receivers[:1]
+	m.ApplyConfig(conf)
+	_, ok = m.IntegrationStatus("other", "webhook", 0)
+	require.False(t, ok)
+	_, ok = m.IntegrationStatus("team", "slack", 0)
+	require.True(t, ok)
+}
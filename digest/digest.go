@@ -0,0 +1,312 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package digest sends scheduled summaries of firing and recently resolved
+// alerts to receivers, independently of the notification pipeline.
+package digest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// LabelName is the group label holding the name of the digest in
+// notification templates.
+const LabelName = "digest"
+
+// sendTimeout is the maximum time spent on sending a single digest.
+const sendTimeout = time.Minute
+
+type metrics struct {
+	sentTotal     *prometheus.CounterVec
+	failuresTotal *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{}
+
+	m.sentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_digests_sent_total",
+		Help: "How many digests were sent.",
+	}, []string{"digest"})
+	m.failuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_digest_failures_total",
+		Help: "How many digests failed to be sent.",
+	}, []string{"digest"})
+
+	if r != nil {
+		r.MustRegister(m.sentTotal, m.failuresTotal)
+	}
+	return m
+}
+
+// digest is the runtime state of a configured digest.
+type digest struct {
+	conf     *config.DigestConfig
+	matchers types.Matchers
+	stage    notify.Stage
+
+	// since is the time of the previous digest. Alerts resolved after it
+	// are included in the next digest.
+	since time.Time
+	next  time.Time
+}
+
+// Scheduler sends the configured digests at their scheduled times.
+type Scheduler struct {
+	alerts  provider.Alerts
+	leader  func() bool
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+
+	mtx     sync.Mutex
+	digests map[string]*digest
+	// seen holds the latest version of all alerts received since the
+	// oldest digest was sent.
+	seen map[model.Fingerprint]*types.Alert
+}
+
+// New returns a new Scheduler. Digests are only sent while leader returns
+// true, so that a single cluster member sends them.
+func New(alerts provider.Alerts, leader func() bool, r prometheus.Registerer, l log.Logger) *Scheduler {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if leader == nil {
+		leader = func() bool { return true }
+	}
+	return &Scheduler{
+		alerts:  alerts,
+		leader:  leader,
+		logger:  l,
+		metrics: newMetrics(r),
+		now:     time.Now,
+		digests: map[string]*digest{},
+		seen:    map[model.Fingerprint]*types.Alert{},
+	}
+}
+
+// ApplyConfig sets the digests of the configuration. The schedule of
+// digests whose configuration did not change is kept.
+func (s *Scheduler) ApplyConfig(conf *config.Config, tmpl *template.Template) {
+	receivers := map[string]*config.Receiver{}
+	for _, rc := range conf.Receivers {
+		receivers[rc.Name] = rc
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	digests := make(map[string]*digest, len(conf.Digests))
+
+	for _, dc := range conf.Digests {
+		var matchers types.Matchers
+		for ln, lv := range dc.Match {
+			matchers = append(matchers, types.NewMatcher(model.LabelName(ln), lv))
+		}
+		for ln, lv := range dc.MatchRE {
+			matchers = append(matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
+		}
+		sort.Sort(matchers)
+
+		rc := receivers[dc.Receiver]
+		var fs notify.FanoutStage
+		for _, i := range notify.BuildReceiverIntegrations(rc, tmpl, nil, s.logger) {
+			fs = append(fs, notify.NewRetryStage(i, rc.Name))
+		}
+
+		d := &digest{
+			conf:     dc,
+			matchers: matchers,
+			stage:    fs,
+			since:    now,
+			next:     next(dc, now),
+		}
+		if prev, ok := s.digests[dc.Name]; ok {
+			d.since = prev.since
+			if sameSchedule(prev.conf, dc) {
+				d.next = prev.next
+			}
+		}
+		digests[dc.Name] = d
+	}
+	s.digests = digests
+}
+
+func sameSchedule(a, b *config.DigestConfig) bool {
+	if len(a.Times) != len(b.Times) || len(a.Weekdays) != len(b.Weekdays) {
+		return false
+	}
+	for i := range a.Times {
+		if a.Times[i] != b.Times[i] {
+			return false
+		}
+	}
+	for i := range a.Weekdays {
+		if a.Weekdays[i] != b.Weekdays[i] {
+			return false
+		}
+	}
+	return a.TimeZone.String() == b.TimeZone.String()
+}
+
+// next returns the first scheduled time of the digest after t.
+func next(dc *config.DigestConfig, t time.Time) time.Time {
+	t = t.In(dc.TimeZone.Location)
+
+	days := map[time.Weekday]bool{}
+	for _, wd := range dc.Weekdays {
+		days[time.Weekday(wd)] = true
+	}
+
+	var res time.Time
+	// The next scheduled time is at most a week ahead.
+	for i := 0; i <= 7 && res.IsZero(); i++ {
+		day := t.AddDate(0, 0, i)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+		for _, tod := range dc.Times {
+			c := time.Date(day.Year(), day.Month(), day.Day(), tod.Hour, tod.Minute, 0, 0, dc.TimeZone.Location)
+			if c.After(t) && (res.IsZero() || c.Before(res)) {
+				res = c
+			}
+		}
+	}
+	return res
+}
+
+// Run collects alerts and sends the digests when they are due until stopc
+// is closed.
+func (s *Scheduler) Run(stopc <-chan struct{}) {
+	it := s.alerts.Subscribe()
+	defer it.Close()
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			return
+		case a, ok := <-it.Next():
+			if err := it.Err(); err != nil {
+				level.Error(s.logger).Log("msg", "Error on alert update", "err", err)
+				continue
+			}
+			if !ok {
+				return
+			}
+			s.mtx.Lock()
+			s.seen[a.Fingerprint()] = a
+			s.mtx.Unlock()
+		case <-ticker.C:
+			s.sendDue()
+		}
+	}
+}
+
+// sendDue sends all digests whose scheduled time has passed.
+func (s *Scheduler) sendDue() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	for _, d := range s.digests {
+		if now.Before(d.next) {
+			continue
+		}
+		if s.leader() {
+			if err := s.send(d, now); err != nil {
+				s.metrics.failuresTotal.WithLabelValues(d.conf.Name).Inc()
+				level.Error(s.logger).Log("msg", "Sending digest failed", "digest", d.conf.Name, "err", err)
+			} else {
+				s.metrics.sentTotal.WithLabelValues(d.conf.Name).Inc()
+			}
+		}
+		d.since = now
+		d.next = next(d.conf, now)
+	}
+	s.gc()
+}
+
+// send sends the firing alerts of the digest and the ones resolved since the
+// previous digest. Nothing is sent if there are no such alerts.
+func (s *Scheduler) send(d *digest, now time.Time) error {
+	var (
+		alerts []*types.Alert
+		firing []uint64
+	)
+	for fp, a := range s.seen {
+		if !d.matchers.Match(a.Labels) {
+			continue
+		}
+		if a.ResolvedAt(now) {
+			if a.EndsAt.Before(d.since) {
+				continue
+			}
+		} else {
+			firing = append(firing, uint64(fp))
+		}
+		alerts = append(alerts, a)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Fingerprint() < alerts[j].Fingerprint()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	ctx = notify.WithReceiverName(ctx, d.conf.Receiver)
+	ctx = notify.WithGroupKey(ctx, LabelName+"/"+d.conf.Name)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{LabelName: model.LabelValue(d.conf.Name)})
+	ctx = notify.WithFiringAlerts(ctx, firing)
+	ctx = notify.WithNow(ctx, now)
+
+	_, _, err := d.stage.Exec(ctx, log.With(s.logger, "digest", d.conf.Name), alerts...)
+	return err
+}
+
+// gc removes resolved alerts that are not part of any upcoming digest.
+func (s *Scheduler) gc() {
+	now := s.now()
+	oldest := now
+	for _, d := range s.digests {
+		if d.since.Before(oldest) {
+			oldest = d.since
+		}
+	}
+	for fp, a := range s.seen {
+		if a.ResolvedAt(now) && a.EndsAt.Before(oldest) {
+			delete(s.seen, fp)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	daily := &config.DigestConfig{
+		Times:    []config.TimeOfDay{{Hour: 17}, {Hour: 9}},
+		TimeZone: &config.Location{Location: time.UTC},
+	}
+	weekly := &config.DigestConfig{
+		Times:    []config.TimeOfDay{{Hour: 9, Minute: 30}},
+		Weekdays: []config.Weekday{config.Weekday(time.Monday)},
+		TimeZone: &config.Location{Location: berlin},
+	}
+
+	for _, tc := range []struct {
+		conf *config.DigestConfig
+		t    time.Time
+		next time.Time
+	}{
+		{
+			conf: daily,
+			t:    time.Date(2018, 6, 1, 8, 0, 0, 0, time.UTC),
+			next: time.Date(2018, 6, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			conf: daily,
+			t:    time.Date(2018, 6, 1, 9, 0, 0, 0, time.UTC),
+			next: time.Date(2018, 6, 1, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			conf: daily,
+			t:    time.Date(2018, 6, 1, 18, 0, 0, 0, time.UTC),
+			next: time.Date(2018, 6, 2, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			// Friday, June 1st.
+			conf: weekly,
+			t:    time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+			next: time.Date(2018, 6, 4, 9, 30, 0, 0, berlin),
+		},
+		{
+			// Monday, June 4th, after the scheduled time.
+			conf: weekly,
+			t:    time.Date(2018, 6, 4, 9, 0, 0, 0, time.UTC),
+			next: time.Date(2018, 6, 11, 9, 30, 0, 0, berlin),
+		},
+	} {
+		require.True(t, tc.next.Equal(next(tc.conf, tc.t)), "expected %s, got %s", tc.next, next(tc.conf, tc.t))
+	}
+}
+
+func TestSendDue(t *testing.T) {
+	var got []template.Data
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data template.Data
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		got = append(got, data)
+	}))
+	defer srv.Close()
+
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+- name: digest
+  webhook_configs:
+  - url: ` + srv.URL + `
+    send_resolved: true
+digests:
+- name: low
+  receiver: digest
+  match:
+    severity: low
+  times: ["09:00"]
+`)
+	require.NoError(t, err)
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	// Notification templates determine the alert status with the current
+	// time, so the test starts in the past.
+	now := time.Now().Add(-2 * time.Hour)
+	s := New(nil, nil, nil, nil)
+	s.now = func() time.Time { return now }
+	s.ApplyConfig(conf, tmpl)
+	s.digests["low"].next = now.Add(time.Hour)
+
+	alert := func(name string, startsAt, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(name), "severity": "low"},
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+		}}
+	}
+	for _, a := range []*types.Alert{
+		alert("Firing", now.Add(-time.Hour), time.Time{}),
+		alert("RecentlyResolved", now.Add(-time.Hour), now.Add(30*time.Minute)),
+		alert("LongResolved", now.Add(-2*time.Hour), now.Add(-time.Hour)),
+		{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Critical", "severity": "critical"},
+			StartsAt: now.Add(-time.Hour),
+		}},
+	} {
+		s.seen[a.Fingerprint()] = a
+	}
+
+	// Not due yet.
+	s.sendDue()
+	require.Empty(t, got)
+
+	now = now.Add(90 * time.Minute)
+	s.sendDue()
+	require.Len(t, got, 1)
+	require.Equal(t, template.KV{"digest": "low"}, got[0].GroupLabels)
+
+	var names []string
+	for _, a := range got[0].Alerts {
+		names = append(names, a.Labels["alertname"]+"/"+a.Status)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"Firing/firing", "RecentlyResolved/resolved"}, names)
+
+	// Resolved alerts are only part of a single digest.
+	require.Len(t, s.seen, 2)
+	require.Equal(t, next(s.digests["low"].conf, now), s.digests["low"].next)
+}
@@ -15,21 +15,92 @@ package dispatch
 
 import (
 	"fmt"
+	"math/rand"
+	"runtime"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"golang.org/x/net/context"
 
+	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/provider"
 	"github.com/prometheus/alertmanager/types"
 )
 
+var numGroupLimitOverflows = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "dispatcher_group_limit_overflows_total",
+	Help:      "The total number of alerts exceeding a group limit by limit and overflow action.",
+}, []string{"limit", "action"})
+
+var (
+	shardAlertsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "dispatcher_shard_alerts_processed_total",
+		Help:      "The total number of alerts inserted into aggregation groups by dispatcher shard.",
+	}, []string{"shard"})
+	shardQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "alertmanager",
+		Name:      "dispatcher_shard_queue_length",
+		Help:      "The number of alerts waiting to be inserted into aggregation groups by dispatcher shard.",
+	}, []string{"shard"})
+	shardAggrGroups = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "alertmanager",
+		Name:      "dispatcher_shard_aggregation_groups",
+		Help:      "The number of aggregation groups by dispatcher shard.",
+	}, []string{"shard"})
+)
+
+func init() {
+	prometheus.Register(numGroupLimitOverflows)
+	prometheus.Register(shardAlertsProcessed)
+	prometheus.Register(shardQueueLength)
+	prometheus.Register(shardAggrGroups)
+}
+
+// shardQueueSize is the number of alerts a shard queues before the
+// dispatcher waits for it.
+const shardQueueSize = 1024
+
+// shard holds the aggregation groups whose labels hash to it. Alerts are
+// inserted into the groups of a shard by its own goroutine, so that shards
+// process alerts in parallel.
+type shard struct {
+	name  string
+	queue chan shardWork
+
+	mtx        sync.RWMutex
+	aggrGroups map[*Route]map[model.Fingerprint]*aggrGroup
+}
+
+// shardWork is an alert to insert into the group of the route with the
+// group labels.
+type shardWork struct {
+	alert       *types.Alert
+	route       *Route
+	groupLabels model.LabelSet
+}
+
+func newShards(n int) []*shard {
+	shards := make([]*shard, n)
+	for i := range shards {
+		shards[i] = &shard{
+			name:       strconv.Itoa(i),
+			queue:      make(chan shardWork, shardQueueSize),
+			aggrGroups: map[*Route]map[model.Fingerprint]*aggrGroup{},
+		}
+	}
+	return shards
+}
+
 // Dispatcher sorts incoming alerts into aggregation groups and
 // assigns the correct notifiers to each.
 type Dispatcher struct {
@@ -39,48 +110,112 @@ type Dispatcher struct {
 
 	marker  types.Marker
 	timeout func(time.Duration) time.Duration
+	// limits are the limits of all routes together, nil if unlimited.
+	limits *config.GroupLimitsConfig
+	// observer is notified of created and deleted groups, it may be nil.
+	observer GroupObserver
+
+	numShards int
+	shards    []*shard
+	mtx       sync.RWMutex
+
+	// numGroups and routeGroups count the groups of all shards together
+	// and of each route for the limits.
+	numGroups   int
+	routeGroups map[*Route]int
+	countMtx    sync.Mutex
+
+	// running is set once Run was started, which closes done on return.
+	running bool
+	done    chan struct{}
+	ctx     context.Context
+	cancel  func()
 
-	aggrGroups map[*Route]map[model.Fingerprint]*aggrGroup
-	mtx        sync.RWMutex
-
-	done   chan struct{}
-	ctx    context.Context
-	cancel func()
+	// flushes counts the notifications in flight, which are not started
+	// anymore once the dispatcher is draining.
+	flushes  sync.WaitGroup
+	draining bool
+	drainMtx sync.Mutex
 
 	logger log.Logger
 }
 
+// Options configures a Dispatcher.
+type Options struct {
+	// The alerts that are sorted into the aggregation groups of the routes
+	// and passed to the stage, and the marker their status is recorded in.
+	Alerts provider.Alerts
+	Route  *Route
+	Stage  notify.Stage
+	Marker types.Marker
+
+	// Timeout returns the timeout of the notifications of a group with the
+	// given group interval.
+	Timeout func(time.Duration) time.Duration
+	// Limits are the limits of all routes together, nil if unlimited.
+	Limits *config.GroupLimitsConfig
+
+	// The number of shards the aggregation groups are sharded into by their
+	// labels, one per CPU if it is not positive.
+	Shards int
+	// An optional observer of the created and deleted groups.
+	Observer GroupObserver
+
+	Logger log.Logger
+}
+
 // NewDispatcher returns a new Dispatcher.
-func NewDispatcher(
-	ap provider.Alerts,
-	r *Route,
-	s notify.Stage,
-	mk types.Marker,
-	to func(time.Duration) time.Duration,
-	l log.Logger,
-) *Dispatcher {
+func NewDispatcher(o Options) *Dispatcher {
+	if o.Shards <= 0 {
+		o.Shards = runtime.NumCPU()
+	}
+	if o.Logger == nil {
+		o.Logger = log.NewNopLogger()
+	}
 	disp := &Dispatcher{
-		alerts:  ap,
-		stage:   s,
-		route:   r,
-		marker:  mk,
-		timeout: to,
-		logger:  log.With(l, "component", "dispatcher"),
+		alerts:    o.Alerts,
+		stage:     o.Stage,
+		route:     o.Route,
+		marker:    o.Marker,
+		timeout:   o.Timeout,
+		limits:    o.Limits,
+		numShards: o.Shards,
+		observer:  o.Observer,
+		done:      make(chan struct{}),
+		logger:    log.With(o.Logger, "component", "dispatcher"),
 	}
+	// The context is created before Run, so that stopping the dispatcher
+	// right after starting it does not race with Run.
+	disp.ctx, disp.cancel = context.WithCancel(context.Background())
 	return disp
 }
 
+// GroupObserver is notified when the dispatcher creates and deletes
+// aggregation groups. Its methods are called with the groups locked and
+// must not block.
+type GroupObserver interface {
+	GroupCreated(groupKey, receiver string, labels model.LabelSet)
+	GroupDeleted(groupKey, receiver string, labels model.LabelSet)
+}
+
 // Run starts dispatching alerts incoming via the updates channel.
 func (d *Dispatcher) Run() {
-	d.done = make(chan struct{})
-
 	d.mtx.Lock()
-	d.aggrGroups = map[*Route]map[model.Fingerprint]*aggrGroup{}
+	d.running = true
+	d.shards = newShards(d.numShards)
 	d.mtx.Unlock()
 
-	d.ctx, d.cancel = context.WithCancel(context.Background())
-
+	var wg sync.WaitGroup
+	for _, s := range d.shards {
+		shardAggrGroups.WithLabelValues(s.name).Set(0)
+		wg.Add(1)
+		go func(s *shard) {
+			d.runShard(s)
+			wg.Done()
+		}(s)
+	}
 	d.run(d.alerts.Subscribe())
+	wg.Wait()
 	close(d.done)
 }
 
@@ -98,6 +233,8 @@ type APIAlert struct {
 	Status      types.AlertStatus `json:"status"`
 	Receivers   []string          `json:"receivers"`
 	Fingerprint string            `json:"fingerprint"`
+
+	Acknowledgement *types.Acknowledgement `json:"acknowledgement,omitempty"`
 }
 
 // AlertGroup is a list of alert blocks grouped by the same label set.
@@ -128,63 +265,147 @@ func matchesFilterLabels(a *APIAlert, matchers []*labels.Matcher) bool {
 func (d *Dispatcher) Groups(matchers []*labels.Matcher) AlertOverview {
 	overview := AlertOverview{}
 
-	d.mtx.RLock()
-	defer d.mtx.RUnlock()
-
 	seen := map[model.Fingerprint]*AlertGroup{}
 
-	for route, ags := range d.aggrGroups {
-		for _, ag := range ags {
-			alertGroup, ok := seen[ag.fingerprint()]
-			if !ok {
-				alertGroup = &AlertGroup{Labels: ag.labels}
-				alertGroup.GroupKey = ag.GroupKey()
+	d.forEachGroup(func(route *Route, ag *aggrGroup) {
+		alertGroup, ok := seen[ag.fingerprint()]
+		if !ok {
+			alertGroup = &AlertGroup{Labels: ag.labels}
+			alertGroup.GroupKey = ag.GroupKey()
 
-				seen[ag.fingerprint()] = alertGroup
+			seen[ag.fingerprint()] = alertGroup
+		}
+
+		now := time.Now()
+
+		var apiAlerts []*APIAlert
+		for _, a := range types.Alerts(ag.alertSlice()...) {
+			if !a.EndsAt.IsZero() && a.EndsAt.Before(now) {
+				continue
+			}
+			status := d.marker.Status(a.Fingerprint())
+			aa := &APIAlert{
+				Alert:       a,
+				Status:      status,
+				Fingerprint: a.Fingerprint().String(),
 			}
 
-			now := time.Now()
+			if !matchesFilterLabels(aa, matchers) {
+				continue
+			}
 
-			var apiAlerts []*APIAlert
-			for _, a := range types.Alerts(ag.alertSlice()...) {
-				if !a.EndsAt.IsZero() && a.EndsAt.Before(now) {
-					continue
-				}
-				status := d.marker.Status(a.Fingerprint())
-				aa := &APIAlert{
-					Alert:       a,
-					Status:      status,
-					Fingerprint: a.Fingerprint().String(),
-				}
+			apiAlerts = append(apiAlerts, aa)
+		}
+		if len(apiAlerts) == 0 {
+			return
+		}
 
-				if !matchesFilterLabels(aa, matchers) {
-					continue
-				}
+		alertGroup.Blocks = append(alertGroup.Blocks, &AlertBlock{
+			RouteOpts: &route.RouteOpts,
+			Alerts:    apiAlerts,
+		})
+
+		overview = append(overview, alertGroup)
+	})
+
+	sort.Sort(overview)
+
+	return overview
+}
 
-				apiAlerts = append(apiAlerts, aa)
+// AggregationGroup is the API representation of an aggregation group of the
+// dispatcher, the unit in which alerts are sent to a receiver.
+type AggregationGroup struct {
+	Labels    model.LabelSet `json:"labels"`
+	GroupKey  string         `json:"groupKey"`
+	Receiver  string         `json:"receiver"`
+	RouteOpts *RouteOpts     `json:"routeOpts"`
+	// LastFlush is the time at which the group was last sent to the
+	// notification pipeline, zero if it was not flushed yet.
+	LastFlush time.Time `json:"lastFlush"`
+	// NextFlush is the time at which the group is sent to the notification
+	// pipeline next.
+	NextFlush time.Time   `json:"nextFlush"`
+	Alerts    []*APIAlert `json:"alerts"`
+}
+
+// AggregationGroups returns the aggregation groups of the dispatcher with
+// the alerts matching the matchers. Groups without matching alerts are left
+// out.
+func (d *Dispatcher) AggregationGroups(matchers []*labels.Matcher) []*AggregationGroup {
+	res := []*AggregationGroup{}
+
+	now := time.Now()
+	d.forEachGroup(func(route *Route, ag *aggrGroup) {
+		var apiAlerts []*APIAlert
+		for _, a := range types.Alerts(ag.alertSlice()...) {
+			if !a.EndsAt.IsZero() && a.EndsAt.Before(now) {
+				continue
+			}
+			aa := &APIAlert{
+				Alert:       a,
+				Status:      d.marker.Status(a.Fingerprint()),
+				Receivers:   []string{route.RouteOpts.Receiver},
+				Fingerprint: a.Fingerprint().String(),
 			}
-			if len(apiAlerts) == 0 {
+			if !matchesFilterLabels(aa, matchers) {
 				continue
 			}
+			apiAlerts = append(apiAlerts, aa)
+		}
+		if len(apiAlerts) == 0 {
+			return
+		}
+		sort.Slice(apiAlerts, func(i, j int) bool {
+			return apiAlerts[i].Fingerprint < apiAlerts[j].Fingerprint
+		})
 
-			alertGroup.Blocks = append(alertGroup.Blocks, &AlertBlock{
-				RouteOpts: &route.RouteOpts,
-				Alerts:    apiAlerts,
-			})
+		lastFlush, nextFlush := ag.flushTimes()
+		res = append(res, &AggregationGroup{
+			Labels:    ag.labels,
+			GroupKey:  ag.GroupKey(),
+			Receiver:  route.RouteOpts.Receiver,
+			RouteOpts: ag.opts,
+			LastFlush: lastFlush,
+			NextFlush: nextFlush,
+			Alerts:    apiAlerts,
+		})
+	})
 
-			overview = append(overview, alertGroup)
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Receiver != res[j].Receiver {
+			return res[i].Receiver < res[j].Receiver
 		}
-	}
+		return res[i].GroupKey < res[j].GroupKey
+	})
+	return res
+}
 
-	sort.Sort(overview)
+// forEachGroup calls f with each aggregation group and its route, locking
+// one shard at a time.
+func (d *Dispatcher) forEachGroup(f func(*Route, *aggrGroup)) {
+	d.mtx.RLock()
+	shards := d.shards
+	d.mtx.RUnlock()
+
+	for _, s := range shards {
+		s.mtx.RLock()
+		for route, ags := range s.aggrGroups {
+			for _, ag := range ags {
+				f(route, ag)
+			}
+		}
+		s.mtx.RUnlock()
+	}
+}
 
-	return overview
+// shardFor returns the shard of the groups with the labels.
+func (d *Dispatcher) shardFor(groupLabels model.LabelSet) *shard {
+	return d.shards[uint64(groupLabels.Fingerprint())%uint64(len(d.shards))]
 }
 
+// run routes the alerts and queues them to the shards of their groups.
 func (d *Dispatcher) run(it provider.AlertIterator) {
-	cleanup := time.NewTicker(30 * time.Second)
-	defer cleanup.Stop()
-
 	defer it.Close()
 
 	for {
@@ -207,22 +428,53 @@ func (d *Dispatcher) run(it provider.AlertIterator) {
 			}
 
 			for _, r := range d.route.Match(alert.Labels) {
-				d.processAlert(alert, r)
+				groupLabels := r.GroupLabels(alert)
+				s := d.shardFor(groupLabels)
+				select {
+				case s.queue <- shardWork{alert: alert, route: r, groupLabels: groupLabels}:
+					shardQueueLength.WithLabelValues(s.name).Set(float64(len(s.queue)))
+				case <-d.ctx.Done():
+					return
+				}
 			}
 
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// runShard inserts the alerts queued to the shard into its groups and
+// deletes its empty groups until the dispatcher stops.
+func (d *Dispatcher) runShard(s *shard) {
+	cleanup := time.NewTicker(30 * time.Second)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case w := <-s.queue:
+			shardQueueLength.WithLabelValues(s.name).Set(float64(len(s.queue)))
+			d.insertAlert(s, w.alert, w.route, w.groupLabels)
+			shardAlertsProcessed.WithLabelValues(s.name).Inc()
+
 		case <-cleanup.C:
-			d.mtx.Lock()
+			s.mtx.Lock()
 
-			for _, groups := range d.aggrGroups {
+			for route, groups := range s.aggrGroups {
 				for _, ag := range groups {
 					if ag.empty() {
 						ag.stop()
 						delete(groups, ag.fingerprint())
+						d.releaseGroup(route)
+						shardAggrGroups.WithLabelValues(s.name).Dec()
+						if d.observer != nil {
+							d.observer.GroupDeleted(ag.GroupKey(), ag.opts.Receiver, ag.labels)
+						}
 					}
 				}
 			}
 
-			d.mtx.Unlock()
+			s.mtx.Unlock()
 
 		case <-d.ctx.Done():
 			return
@@ -230,7 +482,7 @@ func (d *Dispatcher) run(it provider.AlertIterator) {
 	}
 }
 
-// Stop the dispatcher.
+// Stop the dispatcher and wait for Run to return if it was started.
 func (d *Dispatcher) Stop() {
 	if d == nil || d.cancel == nil {
 		return
@@ -238,7 +490,49 @@ func (d *Dispatcher) Stop() {
 	d.cancel()
 	d.cancel = nil
 
-	<-d.done
+	d.mtx.RLock()
+	running := d.running
+	d.mtx.RUnlock()
+	if running {
+		<-d.done
+	}
+}
+
+// Shutdown stops the dispatcher once the notifications in flight completed
+// or the context is done, whichever happens first. No notifications are
+// started in the meantime.
+func (d *Dispatcher) Shutdown(ctx context.Context) {
+	if d == nil || d.cancel == nil {
+		return
+	}
+	d.drainMtx.Lock()
+	d.draining = true
+	d.drainMtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.flushes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		level.Warn(d.logger).Log("msg", "Canceling notifications in flight on shutdown", "err", ctx.Err())
+	}
+	d.Stop()
+}
+
+// startFlush counts a notification in flight. It returns false if the
+// dispatcher is draining and the notification must not be started.
+func (d *Dispatcher) startFlush() bool {
+	d.drainMtx.Lock()
+	defer d.drainMtx.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.flushes.Add(1)
+	return true
 }
 
 // notifyFunc is a function that performs notifcation for the alert
@@ -249,31 +543,71 @@ type notifyFunc func(context.Context, ...*types.Alert) bool
 // processAlert determines in which aggregation group the alert falls
 // and inserts it.
 func (d *Dispatcher) processAlert(alert *types.Alert, route *Route) {
-	groupLabels := model.LabelSet{}
+	groupLabels := route.GroupLabels(alert)
+	d.insertAlert(d.shardFor(groupLabels), alert, route, groupLabels)
+}
 
-	for ln, lv := range alert.Labels {
-		if _, ok := route.RouteOpts.GroupBy[ln]; ok {
-			groupLabels[ln] = lv
-		}
+// insertAlert inserts the alert into the group of the route with the group
+// labels in the shard, or into the catch-all group of the route if the
+// group would exceed the limits collapsing overflowing groups.
+func (d *Dispatcher) insertAlert(s *shard, alert *types.Alert, route *Route, groupLabels model.LabelSet) {
+	if d.insertShard(s, alert, route, groupLabels, true) {
+		return
 	}
+	// The catch-all group has no group labels and does not count against
+	// the limit.
+	groupLabels = model.LabelSet{}
+	d.insertShard(d.shardFor(groupLabels), alert, route, groupLabels, false)
+}
 
+// insertShard inserts the alert into the group of the route with the group
+// labels in the shard. New groups are checked against the group limits if
+// limited is set. It returns false if the alert must be inserted into the
+// catch-all group instead.
+func (d *Dispatcher) insertShard(s *shard, alert *types.Alert, route *Route, groupLabels model.LabelSet, limited bool) bool {
 	fp := groupLabels.Fingerprint()
 
-	d.mtx.Lock()
-	group, ok := d.aggrGroups[route]
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	group, ok := s.aggrGroups[route]
 	if !ok {
 		group = map[model.Fingerprint]*aggrGroup{}
-		d.aggrGroups[route] = group
+		s.aggrGroups[route] = group
 	}
-	d.mtx.Unlock()
 
-	// If the group does not exist, create it.
 	ag, ok := group[fp]
+	if !ok {
+		if limits := d.reserveGroup(route, limited); limits != nil {
+			numGroupLimitOverflows.WithLabelValues("max_groups", limits.Overflow).Inc()
+			if limits.Overflow == config.GroupOverflowCollapse {
+				return false
+			}
+			level.Debug(d.logger).Log("msg", "Dropping alert exceeding group limit", "alert", alert, "route", route.Key())
+			return true
+		}
+	}
+	if ok && !ag.contains(alert) && ag.size() >= d.maxAlertsPerGroup(route) {
+		numGroupLimitOverflows.WithLabelValues("max_alerts_per_group", config.GroupOverflowDrop).Inc()
+		level.Debug(d.logger).Log("msg", "Dropping alert exceeding group limit", "alert", alert, "aggrGroup", ag)
+		return true
+	}
+
+	// If the group does not exist, create it.
 	if !ok {
 		ag = newAggrGroup(d.ctx, groupLabels, route, d.timeout, d.logger)
 		group[fp] = ag
+		shardAggrGroups.WithLabelValues(s.name).Inc()
+		if d.observer != nil {
+			d.observer.GroupCreated(ag.GroupKey(), ag.opts.Receiver, ag.labels)
+		}
 
 		go ag.run(func(ctx context.Context, alerts ...*types.Alert) bool {
+			if !d.startFlush() {
+				return false
+			}
+			defer d.flushes.Done()
+
 			_, _, err := d.stage.Exec(ctx, d.logger, alerts...)
 			if err != nil {
 				level.Error(d.logger).Log("msg", "Notify for alerts failed", "num_alerts", len(alerts), "err", err)
@@ -283,6 +617,52 @@ func (d *Dispatcher) processAlert(alert *types.Alert, route *Route) {
 	}
 
 	ag.insert(alert)
+	return true
+}
+
+// reserveGroup counts a new group of the route unless limited is set and
+// the group would exceed the limits, which are the limits of the route
+// before the limits of all routes together. It returns the exceeded limits,
+// or nil if the group was counted.
+func (d *Dispatcher) reserveGroup(route *Route, limited bool) *config.GroupLimitsConfig {
+	d.countMtx.Lock()
+	defer d.countMtx.Unlock()
+
+	if d.routeGroups == nil {
+		d.routeGroups = map[*Route]int{}
+	}
+	if limited {
+		if l := route.RouteOpts.GroupLimits; l != nil && l.MaxGroups > 0 && d.routeGroups[route] >= l.MaxGroups {
+			return l
+		}
+		if l := d.limits; l != nil && l.MaxGroups > 0 && d.numGroups >= l.MaxGroups {
+			return l
+		}
+	}
+	d.routeGroups[route]++
+	d.numGroups++
+	return nil
+}
+
+// releaseGroup uncounts a deleted group of the route.
+func (d *Dispatcher) releaseGroup(route *Route) {
+	d.countMtx.Lock()
+	defer d.countMtx.Unlock()
+
+	d.routeGroups[route]--
+	d.numGroups--
+}
+
+// maxAlertsPerGroup returns the lowest limit of alerts per group of the
+// route and all routes together.
+func (d *Dispatcher) maxAlertsPerGroup(route *Route) int {
+	max := int(^uint(0) >> 1)
+	for _, l := range []*config.GroupLimitsConfig{route.RouteOpts.GroupLimits, d.limits} {
+		if l != nil && l.MaxAlertsPerGroup > 0 && l.MaxAlertsPerGroup < max {
+			max = l.MaxAlertsPerGroup
+		}
+	}
+	return max
 }
 
 // aggrGroup aggregates alert fingerprints into groups to which a
@@ -300,9 +680,12 @@ type aggrGroup struct {
 	next    *time.Timer
 	timeout func(time.Duration) time.Duration
 
-	mtx        sync.RWMutex
-	alerts     map[model.Fingerprint]*types.Alert
-	hasFlushed bool
+	mtx         sync.RWMutex
+	alerts      map[model.Fingerprint]*types.Alert
+	hasFlushed  bool
+	lastFlush   time.Time
+	nextFlush   time.Time
+	firingSince time.Time
 }
 
 // newAggrGroup returns a new aggregation group.
@@ -313,7 +696,7 @@ func newAggrGroup(ctx context.Context, labels model.LabelSet, r *Route, to func(
 	ag := &aggrGroup{
 		labels:   labels,
 		routeKey: r.Key(),
-		opts:     &r.RouteOpts,
+		opts:     r.RouteOpts.ForGroup(labels),
 		timeout:  to,
 		alerts:   map[model.Fingerprint]*types.Alert{},
 	}
@@ -323,11 +706,21 @@ func newAggrGroup(ctx context.Context, labels model.LabelSet, r *Route, to func(
 
 	// Set an initial one-time wait before flushing
 	// the first batch of notifications.
-	ag.next = time.NewTimer(ag.opts.GroupWait)
+	wait := ag.opts.GroupWait + ag.jitter()
+	ag.next = time.NewTimer(wait)
+	ag.nextFlush = time.Now().Add(wait)
 
 	return ag
 }
 
+// jitter returns a random delay of up to the flush jitter of the group.
+func (ag *aggrGroup) jitter() time.Duration {
+	if ag.opts.GroupFlushJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ag.opts.GroupFlushJitter)))
+}
+
 func (ag *aggrGroup) fingerprint() model.Fingerprint {
 	return ag.labels.Fingerprint()
 }
@@ -351,6 +744,23 @@ func (ag *aggrGroup) alertSlice() []*types.Alert {
 	return alerts
 }
 
+// nextFlushTime returns the time at which the group is flushed next.
+func (ag *aggrGroup) nextFlushTime() time.Time {
+	ag.mtx.RLock()
+	defer ag.mtx.RUnlock()
+
+	return ag.nextFlush
+}
+
+// flushTimes returns the times at which the group was flushed last, zero if
+// it was not flushed yet, and is flushed next.
+func (ag *aggrGroup) flushTimes() (last, next time.Time) {
+	ag.mtx.RLock()
+	defer ag.mtx.RUnlock()
+
+	return ag.lastFlush, ag.nextFlush
+}
+
 func (ag *aggrGroup) run(nf notifyFunc) {
 	ag.done = make(chan struct{})
 
@@ -374,11 +784,21 @@ func (ag *aggrGroup) run(nf notifyFunc) {
 			ctx = notify.WithGroupKey(ctx, ag.GroupKey())
 			ctx = notify.WithGroupLabels(ctx, ag.labels)
 			ctx = notify.WithReceiverName(ctx, ag.opts.Receiver)
+			ctx = notify.WithFailoverReceivers(ctx, ag.opts.FailoverReceivers)
 			ctx = notify.WithRepeatInterval(ctx, ag.opts.RepeatInterval)
+			ctx = notify.WithEnrichments(ctx, ag.opts.Enrichments)
+			ctx = notify.WithMuteTimeIntervals(ctx, ag.opts.MuteTimeIntervals)
+			ctx = notify.WithActiveTimeIntervals(ctx, ag.opts.ActiveTimeIntervals)
+			if r := ag.escalationReceiver(now); r != "" {
+				ctx = notify.WithEscalationReceiver(ctx, r)
+			}
 
 			// Wait the configured interval before calling flush again.
+			interval := ag.opts.GroupInterval + ag.jitter()
 			ag.mtx.Lock()
-			ag.next.Reset(ag.opts.GroupInterval)
+			ag.next.Reset(interval)
+			ag.lastFlush = now
+			ag.nextFlush = now.Add(interval)
 			ag.hasFlushed = true
 			ag.mtx.Unlock()
 
@@ -394,6 +814,40 @@ func (ag *aggrGroup) run(nf notifyFunc) {
 	}
 }
 
+// escalationReceiver returns the receiver the notifications of the group
+// flushed at now are escalated to, or an empty string if they are not
+// escalated. The group is escalated once it has been firing for the
+// configured number of repeat intervals, and stays escalated up to and
+// including the flush at which none of its alerts fire anymore, so that the
+// escalation receiver learns about the resolution.
+func (ag *aggrGroup) escalationReceiver(now time.Time) string {
+	e := ag.opts.Escalation
+	if e == nil {
+		return ""
+	}
+
+	ag.mtx.Lock()
+	defer ag.mtx.Unlock()
+
+	var firing bool
+	for _, a := range ag.alerts {
+		if !a.ResolvedAt(now) {
+			firing = true
+			break
+		}
+	}
+	since := ag.firingSince
+	if !firing {
+		ag.firingSince = time.Time{}
+	} else if since.IsZero() {
+		ag.firingSince, since = now, now
+	}
+	if since.IsZero() || now.Sub(since) < time.Duration(e.AfterRepeats)*ag.opts.RepeatInterval {
+		return ""
+	}
+	return e.Receiver
+}
+
 func (ag *aggrGroup) stop() {
 	// Calling cancel will terminate all in-process notifications
 	// and the run() loop.
@@ -408,11 +862,40 @@ func (ag *aggrGroup) insert(alert *types.Alert) {
 
 	ag.alerts[alert.Fingerprint()] = alert
 
+	if ag.hasFlushed {
+		return
+	}
 	// Immediately trigger a flush if the wait duration for this
 	// alert is already over.
-	if !ag.hasFlushed && alert.StartsAt.Add(ag.opts.GroupWait).Before(time.Now()) {
+	if alert.StartsAt.Add(ag.opts.GroupWait).Before(time.Now()) {
 		ag.next.Reset(0)
+		ag.nextFlush = time.Now()
+		return
 	}
+	// Firing urgent alerts shorten the wait, but never extend it.
+	if u := ag.opts.UrgentGroupWait; u != nil && !alert.Resolved() && u.Matchers.Match(alert.Labels) {
+		if at := time.Now().Add(u.GroupWait); at.Before(ag.nextFlush) {
+			ag.next.Reset(u.GroupWait)
+			ag.nextFlush = at
+		}
+	}
+}
+
+// contains returns whether the alert is in the aggregation group.
+func (ag *aggrGroup) contains(alert *types.Alert) bool {
+	ag.mtx.RLock()
+	defer ag.mtx.RUnlock()
+
+	_, ok := ag.alerts[alert.Fingerprint()]
+	return ok
+}
+
+// size returns the number of alerts in the aggregation group.
+func (ag *aggrGroup) size() int {
+	ag.mtx.RLock()
+	defer ag.mtx.RUnlock()
+
+	return len(ag.alerts)
 }
 
 func (ag *aggrGroup) empty() bool {
@@ -14,6 +14,7 @@
 package dispatch
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"sync"
@@ -25,6 +26,7 @@ import (
 	"github.com/prometheus/prometheus/pkg/labels"
 	"golang.org/x/net/context"
 
+	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
 )
@@ -315,3 +317,317 @@ func TestAggrGroup(t *testing.T) {
 
 	ag.stop()
 }
+
+func TestAggrGroupFlushJitter(t *testing.T) {
+	route := &Route{
+		RouteOpts: RouteOpts{
+			Receiver:         "n1",
+			GroupBy:          map[model.LabelName]struct{}{},
+			GroupWait:        time.Minute,
+			GroupInterval:    5 * time.Minute,
+			GroupFlushJitter: 30 * time.Second,
+		},
+	}
+
+	spread := map[time.Duration]struct{}{}
+	for i := 0; i < 10; i++ {
+		start := time.Now()
+		ag := newAggrGroup(context.Background(), model.LabelSet{"i": model.LabelValue(fmt.Sprint(i))}, route, nil, log.NewNopLogger())
+		wait := ag.nextFlushTime().Sub(start)
+		if wait < time.Minute || wait >= time.Minute+30*time.Second+time.Second {
+			t.Fatalf("expected first flush within the group wait and its jitter, got %v", wait)
+		}
+		spread[wait.Truncate(time.Millisecond)] = struct{}{}
+	}
+	if len(spread) == 1 {
+		t.Errorf("expected first flushes to be spread")
+	}
+}
+
+func TestAggrGroupUrgentGroupWait(t *testing.T) {
+	route := &Route{
+		RouteOpts: RouteOpts{
+			Receiver:      "n1",
+			GroupBy:       map[model.LabelName]struct{}{},
+			GroupWait:     time.Minute,
+			GroupInterval: 5 * time.Minute,
+			UrgentGroupWait: &UrgentGroupWait{
+				Matchers:  types.Matchers{types.NewMatcher("severity", "critical")},
+				GroupWait: 10 * time.Second,
+			},
+		},
+	}
+	newAlert := func(severity string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "a", "severity": model.LabelValue(severity)},
+			StartsAt: time.Now(),
+		}}
+	}
+
+	start := time.Now()
+	ag := newAggrGroup(context.Background(), model.LabelSet{}, route, nil, log.NewNopLogger())
+	ag.insert(newAlert("warning"))
+	if wait := ag.nextFlushTime().Sub(start); wait < time.Minute {
+		t.Fatalf("expected first flush after the group wait, got %v", wait)
+	}
+
+	ag.insert(newAlert("critical"))
+	if wait := ag.nextFlushTime().Sub(start); wait < 10*time.Second || wait > 11*time.Second {
+		t.Fatalf("expected first flush after the urgent group wait, got %v", wait)
+	}
+
+	// Urgent alerts never delay the first flush.
+	ag = newAggrGroup(context.Background(), model.LabelSet{}, route, nil, log.NewNopLogger())
+	ag.next.Reset(time.Second)
+	ag.nextFlush = start.Add(time.Second)
+	ag.insert(newAlert("critical"))
+	if at := ag.nextFlushTime(); !at.Equal(start.Add(time.Second)) {
+		t.Fatalf("expected first flush to stay unchanged, got %v", at.Sub(start))
+	}
+}
+
+func TestAggrGroupEscalation(t *testing.T) {
+	route := &Route{
+		RouteOpts: RouteOpts{
+			Receiver:       "n1",
+			GroupBy:        map[model.LabelName]struct{}{},
+			GroupWait:      time.Minute,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: time.Hour,
+			Escalation:     &config.EscalationConfig{Receiver: "n2", AfterRepeats: 2},
+		},
+	}
+	ag := newAggrGroup(context.Background(), model.LabelSet{}, route, nil, log.NewNopLogger())
+
+	start := time.Now()
+	a := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"a": "v"},
+		StartsAt: start,
+	}}
+	ag.insert(a)
+
+	for _, tc := range []struct {
+		after    time.Duration
+		resolved bool
+		expected string
+	}{
+		{after: 0, expected: ""},
+		{after: time.Hour, expected: ""},
+		{after: 2 * time.Hour, expected: "n2"},
+		{after: 3 * time.Hour, expected: "n2"},
+		// The resolution is escalated, after which the group starts over.
+		{after: 4 * time.Hour, resolved: true, expected: "n2"},
+		{after: 5 * time.Hour, resolved: true, expected: ""},
+		{after: 6 * time.Hour, expected: ""},
+		{after: 7 * time.Hour, expected: ""},
+		{after: 8 * time.Hour, expected: "n2"},
+	} {
+		now := start.Add(tc.after)
+		a.EndsAt = time.Time{}
+		if tc.resolved {
+			a.EndsAt = now.Add(-time.Minute)
+		}
+		if got := ag.escalationReceiver(now); got != tc.expected {
+			t.Errorf("after %v: expected escalation receiver %q, got %q", tc.after, tc.expected, got)
+		}
+	}
+}
+
+func TestAggregationGroups(t *testing.T) {
+	var (
+		r1 = &Route{RouteOpts: RouteOpts{Receiver: "team-Y", GroupWait: time.Minute}}
+		r2 = &Route{RouteOpts: RouteOpts{Receiver: "team-X", GroupWait: time.Minute}}
+		a1 = &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "a", "dc": "eu"},
+			StartsAt: time.Now(),
+		}}
+		a2 = &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "b", "dc": "us"},
+			StartsAt: time.Now(),
+		}}
+	)
+
+	d := &Dispatcher{
+		marker: types.NewMarker(),
+		shards: newShards(1),
+	}
+	aggrGroups := d.shards[0].aggrGroups
+	before := time.Now()
+	for _, r := range []*Route{r1, r2} {
+		aggrGroups[r] = map[model.Fingerprint]*aggrGroup{}
+		for _, a := range []*types.Alert{a1, a2} {
+			lset := model.LabelSet{"dc": a.Labels["dc"]}
+			ag := newAggrGroup(context.Background(), lset, r, nil, log.NewNopLogger())
+			ag.insert(a)
+			aggrGroups[r][lset.Fingerprint()] = ag
+		}
+	}
+
+	matcher, err := labels.NewMatcher(labels.MatchEqual, "dc", "eu")
+	if err != nil {
+		t.Fatalf("error making matcher: %v", err)
+	}
+	groups := d.AggregationGroups([]*labels.Matcher{matcher})
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	for i, receiver := range []string{"team-X", "team-Y"} {
+		g := groups[i]
+		if g.Receiver != receiver {
+			t.Errorf("expected receiver %q, got %q", receiver, g.Receiver)
+		}
+		if !reflect.DeepEqual(g.Labels, model.LabelSet{"dc": "eu"}) {
+			t.Errorf("unexpected group labels %v", g.Labels)
+		}
+		if len(g.Alerts) != 1 || g.Alerts[0].Fingerprint != a1.Fingerprint().String() {
+			t.Errorf("unexpected alerts %v", g.Alerts)
+		}
+		if g.NextFlush.Before(before.Add(time.Minute)) || g.NextFlush.After(time.Now().Add(time.Minute)) {
+			t.Errorf("unexpected next flush %s", g.NextFlush)
+		}
+		if !g.LastFlush.IsZero() {
+			t.Errorf("expected group not to be flushed yet, got last flush %s", g.LastFlush)
+		}
+	}
+}
+
+func TestGroupLimits(t *testing.T) {
+	newAlert := func(name, dc string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(name), "dc": model.LabelValue(dc)},
+			StartsAt: time.Now(),
+		}}
+	}
+	groupBy := map[model.LabelName]struct{}{"dc": struct{}{}}
+
+	for _, tc := range []struct {
+		name   string
+		route  *config.GroupLimitsConfig
+		global *config.GroupLimitsConfig
+		// groups are the number of alerts of the groups by group labels.
+		groups map[string]int
+	}{
+		{
+			name:   "unlimited",
+			groups: map[string]int{`{dc="eu"}`: 2, `{dc="us"}`: 1, `{dc="ap"}`: 1},
+		},
+		{
+			name:   "route drop",
+			route:  &config.GroupLimitsConfig{MaxGroups: 2, Overflow: config.GroupOverflowDrop},
+			groups: map[string]int{`{dc="eu"}`: 2, `{dc="us"}`: 1},
+		},
+		{
+			name:   "route collapse",
+			route:  &config.GroupLimitsConfig{MaxGroups: 1, Overflow: config.GroupOverflowCollapse},
+			groups: map[string]int{`{dc="eu"}`: 2, `{}`: 2},
+		},
+		{
+			name:   "global drop",
+			global: &config.GroupLimitsConfig{MaxGroups: 1, Overflow: config.GroupOverflowDrop},
+			groups: map[string]int{`{dc="eu"}`: 2},
+		},
+		{
+			name:   "alerts per group",
+			route:  &config.GroupLimitsConfig{MaxAlertsPerGroup: 2, Overflow: config.GroupOverflowDrop},
+			global: &config.GroupLimitsConfig{MaxAlertsPerGroup: 1, Overflow: config.GroupOverflowDrop},
+			groups: map[string]int{`{dc="eu"}`: 1, `{dc="us"}`: 1, `{dc="ap"}`: 1},
+		},
+	} {
+		route := &Route{RouteOpts: RouteOpts{GroupBy: groupBy, GroupWait: time.Hour, GroupLimits: tc.route}}
+		d := &Dispatcher{
+			stage: notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+				return ctx, alerts, nil
+			}),
+			limits: tc.global,
+			shards: newShards(4),
+			logger: log.NewNopLogger(),
+		}
+		d.ctx, d.cancel = context.WithCancel(context.Background())
+
+		for _, a := range []*types.Alert{
+			newAlert("a", "eu"),
+			newAlert("b", "eu"),
+			newAlert("a", "eu"),
+			newAlert("a", "us"),
+			newAlert("a", "ap"),
+		} {
+			d.processAlert(a, route)
+		}
+
+		groups := map[string]int{}
+		d.forEachGroup(func(r *Route, ag *aggrGroup) {
+			if r == route {
+				groups[ag.labels.String()] = ag.size()
+			}
+		})
+		d.cancel()
+
+		if !reflect.DeepEqual(tc.groups, groups) {
+			t.Errorf("%s: expected groups %v, got %v", tc.name, tc.groups, groups)
+		}
+	}
+}
+
+func TestDispatcherShutdown(t *testing.T) {
+	var (
+		started  = make(chan struct{})
+		release  = make(chan struct{})
+		canceled = make(chan bool, 1)
+	)
+	d := &Dispatcher{
+		stage: notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			close(started)
+			<-release
+			canceled <- ctx.Err() != nil
+			return ctx, alerts, nil
+		}),
+		shards: newShards(1),
+		done:   make(chan struct{}),
+		logger: log.NewNopLogger(),
+	}
+	close(d.done)
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	route := &Route{RouteOpts: RouteOpts{GroupBy: map[model.LabelName]struct{}{}, GroupInterval: time.Minute}}
+	d.processAlert(&types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "a"},
+		StartsAt: time.Now(),
+	}}, route)
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Shutdown(context.Background())
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatalf("expected shutdown to wait for the notification in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+	if <-canceled {
+		t.Errorf("expected the notification in flight not to be canceled")
+	}
+	if d.startFlush() {
+		t.Errorf("expected no notifications to start after shutdown")
+	}
+}
+
+func TestDispatcherStopWithoutRun(t *testing.T) {
+	d := NewDispatcher(Options{Shards: 1})
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked although Run was never started")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatch
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+var alertStateDesc = prometheus.NewDesc(
+	"alertmanager_alert_state",
+	"Number of firing alerts by alert name, severity, receiver and state.",
+	[]string{"alertname", "severity", "receiver", "state"},
+	nil,
+)
+
+// AlertStateCollector exports the currently firing alerts as labeled series.
+// As the number of series grows with the number of distinct alerts and
+// receivers, it is meant to be enabled explicitly.
+type AlertStateCollector struct {
+	alerts provider.Alerts
+	marker types.Marker
+
+	mtx   sync.RWMutex
+	route *Route
+}
+
+// NewAlertStateCollector returns a new AlertStateCollector.
+func NewAlertStateCollector(ap provider.Alerts, mk types.Marker) *AlertStateCollector {
+	return &AlertStateCollector{
+		alerts: ap,
+		marker: mk,
+	}
+}
+
+// SetRoute sets the routing tree used to determine the receivers of alerts.
+func (c *AlertStateCollector) SetRoute(r *Route) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.route = r
+}
+
+// Describe implements prometheus.Collector.
+func (c *AlertStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- alertStateDesc
+}
+
+type alertStateKey struct {
+	alertname, severity, receiver, state string
+}
+
+// Collect implements prometheus.Collector.
+func (c *AlertStateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mtx.RLock()
+	route := c.route
+	c.mtx.RUnlock()
+
+	if route == nil {
+		return
+	}
+
+	counts := map[alertStateKey]int{}
+
+	it := c.alerts.GetPending()
+	defer it.Close()
+
+	for a := range it.Next() {
+		if a.Resolved() {
+			continue
+		}
+		state := c.marker.Status(a.Fingerprint()).State
+		seen := map[string]struct{}{}
+
+		for _, r := range route.Match(a.Labels) {
+			// Multiple routes may lead to the same receiver.
+			if _, ok := seen[r.RouteOpts.Receiver]; ok {
+				continue
+			}
+			seen[r.RouteOpts.Receiver] = struct{}{}
+
+			counts[alertStateKey{
+				alertname: string(a.Labels[model.AlertNameLabel]),
+				severity:  string(a.Labels["severity"]),
+				receiver:  r.RouteOpts.Receiver,
+				state:     string(state),
+			}]++
+		}
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(
+			alertStateDesc,
+			prometheus.GaugeValue,
+			float64(n),
+			k.alertname, k.severity, k.receiver, k.state,
+		)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dispatch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestAlertStateCollector(t *testing.T) {
+	in := `
+receiver: 'default'
+routes:
+- match:
+    team: 'db'
+  receiver: 'db'
+  continue: true
+- match:
+    team: 'db'
+  receiver: 'db'
+`
+	var ctree config.Route
+	if err := yaml.UnmarshalStrict([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+
+	marker := types.NewMarker()
+	alerts, err := mem.NewAlerts(marker, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alerts.Close()
+
+	now := time.Now()
+	newAlert := func(name, team string, end time.Time) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{
+					"alertname": model.LabelValue(name),
+					"severity":  "critical",
+					"team":      model.LabelValue(team),
+				},
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   end,
+			},
+			UpdatedAt: now,
+		}
+	}
+	suppressed := newAlert("DBDown", "db", now.Add(time.Hour))
+	if err := alerts.Put(
+		newAlert("DBDown", "db", now.Add(time.Hour)),
+		newAlert("DBDown", "db2", now.Add(time.Hour)),
+		newAlert("Resolved", "db", now.Add(-time.Minute)),
+	); err != nil {
+		t.Fatal(err)
+	}
+	suppressed.Labels["instance"] = "b"
+	if err := alerts.Put(suppressed); err != nil {
+		t.Fatal(err)
+	}
+	marker.SetSilenced(suppressed.Fingerprint(), "silence")
+
+	c := NewAlertStateCollector(alerts, marker)
+	if got := collect(t, c); len(got) != 0 {
+		t.Fatalf("Expected no metrics without route, got %v", got)
+	}
+
+	c.SetRoute(NewRoute(&ctree, nil))
+
+	expected := map[alertStateKey]float64{
+		{"DBDown", "critical", "db", "active"}:           1,
+		{"DBDown", "critical", "db", "suppressed"}:       1,
+		{"DBDown", "critical", "default", "unprocessed"}: 1,
+	}
+	marker.SetActive(newAlert("DBDown", "db", now.Add(time.Hour)).Fingerprint())
+
+	if got := collect(t, c); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Unexpected metrics:\n%v\nexpected\n%v", got, expected)
+	}
+}
+
+func collect(t *testing.T, c prometheus.Collector) map[alertStateKey]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	res := map[alertStateKey]float64{}
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatal(err)
+		}
+		lbls := map[string]string{}
+		for _, lp := range pb.GetLabel() {
+			lbls[lp.GetName()] = lp.GetValue()
+		}
+		res[alertStateKey{lbls["alertname"], lbls["severity"], lbls["receiver"], lbls["state"]}] = pb.GetGauge().GetValue()
+	}
+	return res
+}
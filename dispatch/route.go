@@ -62,6 +62,14 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 
 	if cr.Receiver != "" {
 		opts.Receiver = cr.Receiver
+		opts.FailoverReceivers = nil
+		opts.Escalation = nil
+	}
+	if cr.FailoverReceivers != nil {
+		opts.FailoverReceivers = cr.FailoverReceivers
+	}
+	if cr.Escalation != nil {
+		opts.Escalation = cr.Escalation
 	}
 	if cr.GroupBy != nil {
 		opts.GroupBy = map[model.LabelName]struct{}{}
@@ -69,6 +77,9 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 			opts.GroupBy[ln] = struct{}{}
 		}
 	}
+	if cr.GroupRelabelConfigs != nil {
+		opts.GroupRelabelConfigs = cr.GroupRelabelConfigs
+	}
 	if cr.GroupWait != nil {
 		opts.GroupWait = time.Duration(*cr.GroupWait)
 	}
@@ -78,6 +89,30 @@ func NewRoute(cr *config.Route, parent *Route) *Route {
 	if cr.RepeatInterval != nil {
 		opts.RepeatInterval = time.Duration(*cr.RepeatInterval)
 	}
+	if cr.GroupFlushJitter != nil {
+		opts.GroupFlushJitter = time.Duration(*cr.GroupFlushJitter)
+	}
+	if cr.TimingOverrides != nil {
+		opts.TimingOverrides = make([]*TimingOverride, 0, len(cr.TimingOverrides))
+		for _, o := range cr.TimingOverrides {
+			opts.TimingOverrides = append(opts.TimingOverrides, newTimingOverride(o))
+		}
+	}
+	if cr.UrgentGroupWait != nil {
+		opts.UrgentGroupWait = newUrgentGroupWait(cr.UrgentGroupWait)
+	}
+	if cr.Enrichments != nil {
+		opts.Enrichments = cr.Enrichments
+	}
+	if cr.MuteTimeIntervals != nil {
+		opts.MuteTimeIntervals = cr.MuteTimeIntervals
+	}
+	if cr.ActiveTimeIntervals != nil {
+		opts.ActiveTimeIntervals = cr.ActiveTimeIntervals
+	}
+	if cr.GroupLimits != nil {
+		opts.GroupLimits = cr.GroupLimits
+	}
 
 	// Build matchers.
 	var matchers types.Matchers
@@ -111,6 +146,22 @@ func NewRoutes(croutes []*config.Route, parent *Route) []*Route {
 	return res
 }
 
+// GroupLabels returns the labels the alert is grouped by on the route.
+func (r *Route) GroupLabels(alert *types.Alert) model.LabelSet {
+	lset := alert.Labels
+	for _, rc := range r.RouteOpts.GroupRelabelConfigs {
+		lset = rc.Apply(lset, alert.Annotations)
+	}
+
+	groupLabels := model.LabelSet{}
+	for ln, lv := range lset {
+		if _, ok := r.RouteOpts.GroupBy[ln]; ok {
+			groupLabels[ln] = lv
+		}
+	}
+	return groupLabels
+}
+
 // Match does a depth-first left-to-right search through the route tree
 // and returns the matching routing nodes.
 func (r *Route) Match(lset model.LabelSet) []*Route {
@@ -155,14 +206,123 @@ type RouteOpts struct {
 	// The identifier of the associated notification configuration.
 	Receiver string
 
+	// Receivers notified in order if notifying the receiver fails.
+	FailoverReceivers []string
+
+	// Escalation of the groups that keep firing, nil if not escalated.
+	Escalation *config.EscalationConfig
+
 	// What labels to group alerts by for notifications.
 	GroupBy map[model.LabelName]struct{}
 
+	// Transformations of the alert labels applied before grouping.
+	GroupRelabelConfigs []*config.GroupRelabelConfig
+
 	// How long to wait to group matching alerts before sending
 	// a notification.
 	GroupWait      time.Duration
 	GroupInterval  time.Duration
 	RepeatInterval time.Duration
+
+	// Maximum random delay added to each flush of an aggregation group.
+	GroupFlushJitter time.Duration
+
+	// Timers of aggregation groups whose labels match, first match wins.
+	TimingOverrides []*TimingOverride
+
+	// Shortened initial wait of aggregation groups containing matching
+	// alerts, nil if the group wait always applies.
+	UrgentGroupWait *UrgentGroupWait
+
+	// Queries whose results are added to notifications.
+	Enrichments []*config.Enrichment
+
+	// Names of the mute time intervals during which no notifications are sent.
+	MuteTimeIntervals []string
+
+	// Names of the mute time intervals outside of which no notifications are
+	// sent.
+	ActiveTimeIntervals []string
+
+	// Limits of the aggregation groups of the route, nil if unlimited.
+	GroupLimits *config.GroupLimitsConfig
+}
+
+// TimingOverride changes the timers of the aggregation groups whose labels
+// match the matchers.
+type TimingOverride struct {
+	Matchers types.Matchers
+
+	GroupWait      *time.Duration
+	GroupInterval  *time.Duration
+	RepeatInterval *time.Duration
+}
+
+func newTimingOverride(c *config.TimingOverride) *TimingOverride {
+	o := &TimingOverride{}
+	for ln, lv := range c.Match {
+		o.Matchers = append(o.Matchers, types.NewMatcher(model.LabelName(ln), lv))
+	}
+	for ln, lv := range c.MatchRE {
+		o.Matchers = append(o.Matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
+	}
+	sort.Sort(o.Matchers)
+
+	for _, d := range []struct {
+		c *model.Duration
+		o **time.Duration
+	}{
+		{c.GroupWait, &o.GroupWait},
+		{c.GroupInterval, &o.GroupInterval},
+		{c.RepeatInterval, &o.RepeatInterval},
+	} {
+		if d.c != nil {
+			v := time.Duration(*d.c)
+			*d.o = &v
+		}
+	}
+	return o
+}
+
+// UrgentGroupWait shortens the initial wait of the aggregation groups
+// containing an alert that matches the matchers.
+type UrgentGroupWait struct {
+	Matchers  types.Matchers
+	GroupWait time.Duration
+}
+
+func newUrgentGroupWait(c *config.UrgentGroupWait) *UrgentGroupWait {
+	u := &UrgentGroupWait{GroupWait: time.Duration(c.GroupWait)}
+	for ln, lv := range c.Match {
+		u.Matchers = append(u.Matchers, types.NewMatcher(model.LabelName(ln), lv))
+	}
+	for ln, lv := range c.MatchRE {
+		u.Matchers = append(u.Matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
+	}
+	sort.Sort(u.Matchers)
+	return u
+}
+
+// ForGroup returns the options of the aggregation group with the labels,
+// with the timers of the first matching timing override.
+func (ro *RouteOpts) ForGroup(lset model.LabelSet) *RouteOpts {
+	for _, o := range ro.TimingOverrides {
+		if !o.Matchers.Match(lset) {
+			continue
+		}
+		opts := *ro
+		if o.GroupWait != nil {
+			opts.GroupWait = *o.GroupWait
+		}
+		if o.GroupInterval != nil {
+			opts.GroupInterval = *o.GroupInterval
+		}
+		if o.RepeatInterval != nil {
+			opts.RepeatInterval = *o.RepeatInterval
+		}
+		return &opts
+	}
+	return ro
 }
 
 func (ro *RouteOpts) String() string {
@@ -176,16 +336,22 @@ func (ro *RouteOpts) String() string {
 // MarshalJSON returns a JSON representation of the routing options.
 func (ro *RouteOpts) MarshalJSON() ([]byte, error) {
 	v := struct {
-		Receiver       string           `json:"receiver"`
-		GroupBy        model.LabelNames `json:"groupBy"`
-		GroupWait      time.Duration    `json:"groupWait"`
-		GroupInterval  time.Duration    `json:"groupInterval"`
-		RepeatInterval time.Duration    `json:"repeatInterval"`
+		Receiver          string                   `json:"receiver"`
+		FailoverReceivers []string                 `json:"failoverReceivers,omitempty"`
+		Escalation        *config.EscalationConfig `json:"escalation,omitempty"`
+		GroupBy           model.LabelNames         `json:"groupBy"`
+		GroupWait         time.Duration            `json:"groupWait"`
+		GroupInterval     time.Duration            `json:"groupInterval"`
+		RepeatInterval    time.Duration            `json:"repeatInterval"`
+		GroupFlushJitter  time.Duration            `json:"groupFlushJitter,omitempty"`
 	}{
-		Receiver:       ro.Receiver,
-		GroupWait:      ro.GroupWait,
-		GroupInterval:  ro.GroupInterval,
-		RepeatInterval: ro.RepeatInterval,
+		Receiver:          ro.Receiver,
+		FailoverReceivers: ro.FailoverReceivers,
+		Escalation:        ro.Escalation,
+		GroupWait:         ro.GroupWait,
+		GroupInterval:     ro.GroupInterval,
+		RepeatInterval:    ro.RepeatInterval,
+		GroupFlushJitter:  ro.GroupFlushJitter,
 	}
 	for ln := range ro.GroupBy {
 		v.GroupBy = append(v.GroupBy, ln)
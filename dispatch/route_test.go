@@ -22,6 +22,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
 )
 
 func TestRouteMatch(t *testing.T) {
@@ -258,3 +259,224 @@ routes:
 		}
 	}
 }
+
+func TestRouteFailoverReceivers(t *testing.T) {
+	in := `
+receiver: 'slack'
+failover_receivers: ['email']
+
+routes:
+- match:
+    team: 'A'
+- match:
+    team: 'B'
+  receiver: 'pager'
+- match:
+    team: 'C'
+  failover_receivers: ['pager', 'email']
+`
+	var ctree config.Route
+	if err := yaml.UnmarshalStrict([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for team, expected := range map[string][]string{
+		"A": {"email"},
+		"B": nil,
+		"C": {"pager", "email"},
+	} {
+		routes := tree.Match(model.LabelSet{"team": model.LabelValue(team)})
+		if len(routes) != 1 {
+			t.Fatalf("team %s: expected one route, got %d", team, len(routes))
+		}
+		if got := routes[0].RouteOpts.FailoverReceivers; !reflect.DeepEqual(expected, got) {
+			t.Errorf("team %s: expected failover receivers %q, got %q", team, expected, got)
+		}
+	}
+}
+
+func TestRouteGroupLabels(t *testing.T) {
+	in := `
+receiver: 'default'
+group_by: ['host', 'team', 'service']
+group_relabel_configs:
+- source_labels: ['instance']
+  regex: '([^:]+):.*'
+  target_label: 'host'
+- source_annotations: ['owner']
+  target_label: 'team'
+- source_labels: ['service']
+  action: 'lowercase'
+  target_label: 'service'
+
+routes:
+- match:
+    service: 'Frontend'
+  group_by: ['service']
+- match:
+    service: 'Backend'
+`
+	var ctree config.Route
+	if err := yaml.UnmarshalStrict([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for _, tc := range []struct {
+		labels, annotations model.LabelSet
+		expected            model.LabelSet
+	}{
+		{
+			labels:      model.LabelSet{"instance": "db-1:9100", "team": "ops", "service": "Database"},
+			annotations: model.LabelSet{"owner": "dba"},
+			expected:    model.LabelSet{"host": "db-1", "team": "dba", "service": "database"},
+		},
+		{
+			labels:   model.LabelSet{"instance": "db-1", "team": "ops", "service": "Backend"},
+			expected: model.LabelSet{"service": "backend"},
+		},
+		{
+			labels:   model.LabelSet{"instance": "web-1:9100", "team": "ops", "service": "Frontend"},
+			expected: model.LabelSet{"service": "frontend"},
+		},
+	} {
+		alert := &types.Alert{Alert: model.Alert{Labels: tc.labels, Annotations: tc.annotations}}
+		routes := tree.Match(tc.labels)
+		if len(routes) != 1 {
+			t.Fatalf("%v: expected one route, got %d", tc.labels, len(routes))
+		}
+		if got := routes[0].GroupLabels(alert); !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("%v: expected group labels %v, got %v", tc.labels, tc.expected, got)
+		}
+		if alert.Labels["service"] != tc.labels["service"] {
+			t.Errorf("%v: alert labels were changed", tc.labels)
+		}
+	}
+}
+
+func TestRouteTimingOverrides(t *testing.T) {
+	in := `
+receiver: 'default'
+group_by: ['alertname', 'severity']
+repeat_interval: 4h
+timing_overrides:
+- match:
+    severity: 'critical'
+  repeat_interval: 30m
+  group_interval: 1m
+- match_re:
+    severity: 'warn.*'
+  repeat_interval: 24h
+
+routes:
+- match:
+    team: 'A'
+- match:
+    team: 'B'
+  timing_overrides:
+  - match:
+      severity: 'critical'
+    group_wait: 0s
+`
+	var ctree config.Route
+	if err := yaml.UnmarshalStrict([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	type timers struct {
+		groupWait, groupInterval, repeatInterval time.Duration
+	}
+	for _, tc := range []struct {
+		labels   model.LabelSet
+		expected timers
+	}{
+		{
+			labels:   model.LabelSet{"team": "A", "severity": "critical"},
+			expected: timers{30 * time.Second, time.Minute, 30 * time.Minute},
+		},
+		{
+			labels:   model.LabelSet{"team": "A", "severity": "warning"},
+			expected: timers{30 * time.Second, 5 * time.Minute, 24 * time.Hour},
+		},
+		{
+			labels:   model.LabelSet{"team": "A", "severity": "info"},
+			expected: timers{30 * time.Second, 5 * time.Minute, 4 * time.Hour},
+		},
+		{
+			labels:   model.LabelSet{"team": "B", "severity": "critical"},
+			expected: timers{0, 5 * time.Minute, 4 * time.Hour},
+		},
+		{
+			labels:   model.LabelSet{"team": "B", "severity": "warning"},
+			expected: timers{30 * time.Second, 5 * time.Minute, 4 * time.Hour},
+		},
+	} {
+		routes := tree.Match(tc.labels)
+		if len(routes) != 1 {
+			t.Fatalf("%v: expected one route, got %d", tc.labels, len(routes))
+		}
+		alert := &types.Alert{Alert: model.Alert{Labels: tc.labels}}
+		opts := routes[0].RouteOpts.ForGroup(routes[0].GroupLabels(alert))
+		got := timers{opts.GroupWait, opts.GroupInterval, opts.RepeatInterval}
+		if got != tc.expected {
+			t.Errorf("%v: expected timers %v, got %v", tc.labels, tc.expected, got)
+		}
+	}
+	if tree.RouteOpts.RepeatInterval != 4*time.Hour {
+		t.Errorf("route options were changed by the overrides")
+	}
+}
+
+func TestRouteUrgentGroupWait(t *testing.T) {
+	in := `
+receiver: 'default'
+group_wait: 1m
+urgent_group_wait:
+  match:
+    severity: 'critical'
+
+routes:
+- match:
+    team: 'A'
+- match:
+    team: 'B'
+  urgent_group_wait:
+    match_re:
+      severity: 'critical|error'
+    group_wait: 5s
+`
+	var ctree config.Route
+	if err := yaml.UnmarshalStrict([]byte(in), &ctree); err != nil {
+		t.Fatal(err)
+	}
+	tree := NewRoute(&ctree, nil)
+
+	for _, tc := range []struct {
+		team, severity string
+		urgent         bool
+		wait           time.Duration
+	}{
+		{team: "A", severity: "critical", urgent: true, wait: 0},
+		{team: "A", severity: "error"},
+		{team: "B", severity: "error", urgent: true, wait: 5 * time.Second},
+		{team: "B", severity: "warning"},
+	} {
+		lset := model.LabelSet{"team": model.LabelValue(tc.team), "severity": model.LabelValue(tc.severity)}
+		routes := tree.Match(lset)
+		if len(routes) != 1 {
+			t.Fatalf("%v: expected one route, got %d", lset, len(routes))
+		}
+		u := routes[0].RouteOpts.UrgentGroupWait
+		if u == nil {
+			t.Fatalf("%v: expected urgent group wait to be inherited", lset)
+		}
+		if urgent := u.Matchers.Match(lset); urgent != tc.urgent {
+			t.Errorf("%v: expected urgent %t, got %t", lset, tc.urgent, urgent)
+		}
+		if tc.urgent && u.GroupWait != tc.wait {
+			t.Errorf("%v: expected urgent group wait %v, got %v", lset, tc.wait, u.GroupWait)
+		}
+	}
+}
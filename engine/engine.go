@@ -0,0 +1,210 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package engine runs the parts of an Alertmanager that are rebuilt for each
+// configuration: the inhibitor, the notification pipeline and the
+// dispatcher. The alertmanager binary and the amtest package share it.
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+
+	"github.com/prometheus/alertmanager/ack"
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/msgref"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Options configures an Engine.
+type Options struct {
+	// The alerts that are dispatched and inhibited, and the marker their
+	// status is recorded in.
+	Alerts provider.Alerts
+	Marker types.Marker
+
+	Silences        *silence.Silences
+	Acks            *ack.Acks
+	Refs            *msgref.Refs
+	NotificationLog notify.NotificationLog
+
+	// Optional observer of the deliveries, spooler of failed notifications
+	// and suppressions recording why notifications were not sent.
+	Deliveries   notify.DeliveryObserver
+	Spooler      *notify.Spooler
+	Suppressions *notify.Suppressions
+
+	// The optional peer of the cluster. Notifications wait PeerTimeout for
+	// each previous peer, or the longest peer wait of the receivers.
+	Peer        *cluster.Peer
+	PeerTimeout time.Duration
+
+	// The number of shards of the dispatcher, one per CPU if it is not
+	// positive, and an optional observer of its aggregation groups.
+	Shards   int
+	Observer dispatch.GroupObserver
+
+	Logger log.Logger
+}
+
+// Engine runs the inhibitor and the dispatcher of the configuration applied
+// last.
+type Engine struct {
+	o Options
+
+	mtx       sync.RWMutex
+	inhibitor *inhibit.Inhibitor
+	disp      *dispatch.Dispatcher
+}
+
+// New returns a new Engine. It does not run until a configuration is
+// applied.
+func New(o Options) *Engine {
+	if o.Logger == nil {
+		o.Logger = log.NewNopLogger()
+	}
+	return &Engine{o: o}
+}
+
+// ApplyConfig stops the inhibitor and the dispatcher of the previous
+// configuration and starts the ones of the configuration. The aggregation
+// groups start over. It returns the routing tree of the configuration.
+func (e *Engine) ApplyConfig(conf *config.Config, tmpl *template.Template) *dispatch.Route {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.inhibitor.Stop()
+	e.disp.Stop()
+
+	wait := func() time.Duration { return 0 }
+	if e.o.Peer != nil {
+		wait = clusterWait(e.o.Peer, e.o.PeerTimeout)
+	}
+
+	e.inhibitor = inhibit.NewInhibitor(e.o.Alerts, conf.InhibitRules, e.o.Marker, e.o.Logger)
+	pipeline := notify.BuildPipeline(
+		conf.Receivers,
+		conf.MuteTimeIntervals,
+		tmpl,
+		wait,
+		e.inhibitor,
+		e.o.Silences,
+		e.o.Acks,
+		e.o.Refs,
+		e.o.NotificationLog,
+		e.o.Deliveries,
+		e.o.Spooler,
+		e.o.Marker,
+		e.o.Suppressions,
+		e.o.Peer,
+		e.o.Logger,
+	)
+	routes := dispatch.NewRoute(conf.Route, nil)
+	e.disp = dispatch.NewDispatcher(dispatch.Options{
+		Alerts:   e.o.Alerts,
+		Route:    routes,
+		Stage:    pipeline,
+		Marker:   e.o.Marker,
+		Timeout:  notificationTimeout(e.o.Peer, conf.Receivers, e.o.PeerTimeout),
+		Limits:   conf.Global.GroupLimits,
+		Shards:   e.o.Shards,
+		Observer: e.o.Observer,
+		Logger:   e.o.Logger,
+	})
+
+	go e.disp.Run()
+	go e.inhibitor.Run()
+
+	return routes
+}
+
+// Groups returns the alert groups of the dispatcher matching the matchers.
+func (e *Engine) Groups(matchers []*labels.Matcher) dispatch.AlertOverview {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.disp.Groups(matchers)
+}
+
+// AggregationGroups returns the aggregation groups of the dispatcher
+// matching the matchers.
+func (e *Engine) AggregationGroups(matchers []*labels.Matcher) []*dispatch.AggregationGroup {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.disp.AggregationGroups(matchers)
+}
+
+// Inhibitions returns the current inhibitions of the alerts.
+func (e *Engine) Inhibitions() []*inhibit.Inhibition {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	return e.inhibitor.Inhibitions()
+}
+
+// Stop stops the inhibitor and the dispatcher.
+func (e *Engine) Stop() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.inhibitor.Stop()
+	e.disp.Stop()
+}
+
+// Shutdown stops the inhibitor and, once the notifications in flight
+// completed or the context is done, the dispatcher.
+func (e *Engine) Shutdown(ctx context.Context) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.inhibitor.Stop()
+	e.disp.Shutdown(ctx)
+}
+
+// clusterWait returns a function that inspects the current peer state and returns
+// a duration of one base timeout for each peer with a higher ID than ourselves.
+func clusterWait(p *cluster.Peer, timeout time.Duration) func() time.Duration {
+	return func() time.Duration {
+		return time.Duration(p.Position()) * timeout
+	}
+}
+
+// notificationTimeout returns the function computing the timeout of the
+// notifications of an aggregation group. It includes the longest wait for
+// the previous peers of any receiver.
+func notificationTimeout(p *cluster.Peer, receivers []*config.Receiver, peerTimeout time.Duration) func(time.Duration) time.Duration {
+	waitFunc := func() time.Duration { return 0 }
+	if p != nil {
+		for _, rc := range receivers {
+			if rc.Coordination != nil && time.Duration(rc.Coordination.PeerWait) > peerTimeout {
+				peerTimeout = time.Duration(rc.Coordination.PeerWait)
+			}
+		}
+		waitFunc = clusterWait(p, peerTimeout)
+	}
+	return func(d time.Duration) time.Duration {
+		if d < notify.MinTimeout {
+			d = notify.MinTimeout
+		}
+		return d + waitFunc()
+	}
+}
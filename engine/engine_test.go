@@ -0,0 +1,76 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestEngine(t *testing.T) {
+	marker := types.NewMarker()
+	alerts, err := mem.NewAlerts(marker, time.Hour)
+	require.NoError(t, err)
+	defer alerts.Close()
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	nl, err := nflog.New()
+	require.NoError(t, err)
+
+	e := New(Options{
+		Alerts:          alerts,
+		Marker:          marker,
+		Silences:        silences,
+		NotificationLog: nl,
+		Shards:          1,
+	})
+	// Stopping an engine that never ran returns.
+	e.Stop()
+
+	conf, err := config.Load(`
+route:
+  receiver: team
+receivers:
+- name: team
+`)
+	require.NoError(t, err)
+	tmpl, err := template.FromConfig(conf)
+	require.NoError(t, err)
+
+	routes := e.ApplyConfig(conf, tmpl)
+	require.Equal(t, "team", routes.RouteOpts.Receiver)
+	require.Len(t, e.AggregationGroups(nil), 0)
+	require.Len(t, e.Inhibitions(), 0)
+
+	// Applying another configuration replaces the dispatcher.
+	e.ApplyConfig(conf, tmpl)
+	e.Shutdown(context.Background())
+}
+
+func TestNotificationTimeout(t *testing.T) {
+	timeout := notificationTimeout(nil, nil, time.Minute)
+	require.Equal(t, notify.MinTimeout, timeout(time.Second))
+	require.Equal(t, time.Hour, timeout(time.Hour))
+}
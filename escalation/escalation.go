@@ -0,0 +1,145 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package escalation looks up who is on call for a schedule so that
+// notifications can mention or target the current on-call person.
+package escalation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+)
+
+const (
+	// cacheTTL is the time for which on-call lookups are cached.
+	cacheTTL = time.Minute
+	// lookupTimeout is the maximum duration of a single on-call lookup.
+	lookupTimeout = 10 * time.Second
+)
+
+// Provider returns the people currently on call for a schedule.
+type Provider interface {
+	OnCall(ctx context.Context, schedule string) ([]string, error)
+}
+
+// Static is a Provider with fixed schedules.
+type Static map[string][]string
+
+// OnCall implements the Provider interface.
+func (s Static) OnCall(_ context.Context, schedule string) ([]string, error) {
+	people, ok := s[schedule]
+	if !ok {
+		return nil, fmt.Errorf("unknown schedule %q", schedule)
+	}
+	return people, nil
+}
+
+type cacheEntry struct {
+	people []string
+	ts     time.Time
+}
+
+// Providers holds the configured escalation providers and caches their
+// lookups.
+type Providers struct {
+	logger log.Logger
+	now    func() time.Time
+
+	mtx       sync.Mutex
+	providers map[string]Provider
+	cache     map[string]cacheEntry
+}
+
+// New returns a new Providers without any configured provider.
+func New(l log.Logger) *Providers {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Providers{
+		logger:    l,
+		now:       time.Now,
+		providers: map[string]Provider{},
+		cache:     map[string]cacheEntry{},
+	}
+}
+
+// ApplyConfig replaces the providers with the configured ones.
+func (p *Providers) ApplyConfig(confs []*config.EscalationProviderConfig) {
+	providers := make(map[string]Provider, len(confs))
+	for _, c := range confs {
+		switch {
+		case c.Static != nil:
+			providers[c.Name] = Static(c.Static.Schedules)
+		case c.GrafanaOnCall != nil:
+			providers[c.Name] = NewGrafanaOnCall(c.GrafanaOnCall)
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.providers = providers
+	p.cache = map[string]cacheEntry{}
+}
+
+// OnCall returns the people on call for the schedule of the named provider.
+// If a lookup fails, the last successful result is returned if there is one.
+func (p *Providers) OnCall(provider, schedule string) ([]string, error) {
+	key := provider + "/" + schedule
+
+	p.mtx.Lock()
+	prov, ok := p.providers[provider]
+	entry, cached := p.cache[key]
+	p.mtx.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown escalation provider %q", provider)
+	}
+	now := p.now()
+	if cached && now.Sub(entry.ts) < cacheTTL {
+		return entry.people, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	people, err := prov.OnCall(ctx, schedule)
+	if err != nil {
+		if cached {
+			level.Warn(p.logger).Log("msg", "On-call lookup failed, using previous result", "provider", provider, "schedule", schedule, "err", err)
+			return entry.people, nil
+		}
+		return nil, fmt.Errorf("on-call lookup of %q failed: %s", key, err)
+	}
+
+	p.mtx.Lock()
+	p.cache[key] = cacheEntry{people: people, ts: now}
+	p.mtx.Unlock()
+
+	return people, nil
+}
+
+// FuncMap returns the template functions backed by the providers.
+func (p *Providers) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"oncall": p.OnCall,
+	}
+}
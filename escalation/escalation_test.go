@@ -0,0 +1,128 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+)
+
+type fakeProvider struct {
+	people []string
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) OnCall(context.Context, string) ([]string, error) {
+	f.calls++
+	return f.people, f.err
+}
+
+func TestProvidersOnCall(t *testing.T) {
+	now := time.Now()
+	p := New(nil)
+	p.now = func() time.Time { return now }
+
+	fake := &fakeProvider{people: []string{"alice"}}
+	p.providers = map[string]Provider{"fake": fake}
+
+	_, err := p.OnCall("unknown", "team-x")
+	require.EqualError(t, err, `unknown escalation provider "unknown"`)
+
+	people, err := p.OnCall("fake", "team-x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, people)
+
+	// Results are cached.
+	fake.people = []string{"bob"}
+	people, err = p.OnCall("fake", "team-x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice"}, people)
+	require.Equal(t, 1, fake.calls)
+
+	now = now.Add(2 * cacheTTL)
+	people, err = p.OnCall("fake", "team-x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bob"}, people)
+
+	// Failed lookups fall back to the previous result.
+	now = now.Add(2 * cacheTTL)
+	fake.err = errors.New("unavailable")
+	people, err = p.OnCall("fake", "team-x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bob"}, people)
+
+	_, err = p.OnCall("fake", "team-y")
+	require.Error(t, err)
+}
+
+func TestTemplateFunc(t *testing.T) {
+	p := New(nil)
+	p.ApplyConfig([]*config.EscalationProviderConfig{{
+		Name: "static",
+		Static: &config.StaticEscalationConfig{
+			Schedules: map[string][]string{"team-x": {"alice", "bob"}},
+		},
+	}})
+
+	tmpl, err := template.FromGlobs()
+	require.NoError(t, err)
+
+	_, err = tmpl.ExecuteTextString(`{{ oncall "static" "team-x" }}`, nil)
+	require.Error(t, err)
+
+	tmpl.Funcs(p.FuncMap())
+	s, err := tmpl.ExecuteTextString(`{{ oncall "static" "team-x" | join ", " }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "alice, bob", s)
+}
+
+func TestGrafanaOnCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "t0k3n", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/schedules/":
+			require.Equal(t, "team-x", r.URL.Query().Get("name"))
+			fmt.Fprint(w, `{"results":[{"id":"S1","name":"team-x","on_call_now":["U1","U2"]}]}`)
+		case "/api/v1/users/U1/":
+			fmt.Fprint(w, `{"id":"U1","username":"alice"}`)
+		case "/api/v1/users/U2/":
+			fmt.Fprint(w, `{"id":"U2","username":"bob"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGrafanaOnCall(&config.GrafanaOnCallEscalationConfig{
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+		APIURL:     srv.URL + "/",
+		APIToken:   "t0k3n",
+	})
+
+	people, err := g.OnCall(context.Background(), "team-x")
+	require.NoError(t, err)
+	require.Equal(t, []string{"alice", "bob"}, people)
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package escalation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// GrafanaOnCall is a Provider that looks up schedules via the Grafana OnCall
+// API.
+type GrafanaOnCall struct {
+	conf *config.GrafanaOnCallEscalationConfig
+}
+
+// NewGrafanaOnCall returns a new Grafana OnCall provider.
+func NewGrafanaOnCall(c *config.GrafanaOnCallEscalationConfig) *GrafanaOnCall {
+	return &GrafanaOnCall{conf: c}
+}
+
+// OnCall implements the Provider interface. It returns the usernames of the
+// users on call for the schedule with the given name.
+func (g *GrafanaOnCall) OnCall(ctx context.Context, schedule string) ([]string, error) {
+	c, err := config.NewHTTPClient(g.conf.HTTPConfig, g.conf.CryptoPolicy, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules struct {
+		Results []struct {
+			Name      string   `json:"name"`
+			OnCallNow []string `json:"on_call_now"`
+		} `json:"results"`
+	}
+	u := g.conf.APIURL + "api/v1/schedules/?" + url.Values{"name": {schedule}}.Encode()
+	if err := g.get(ctx, c, u, &schedules); err != nil {
+		return nil, err
+	}
+
+	for _, s := range schedules.Results {
+		if s.Name != schedule {
+			continue
+		}
+		people := make([]string, 0, len(s.OnCallNow))
+		for _, id := range s.OnCallNow {
+			var user struct {
+				Username string `json:"username"`
+			}
+			if err := g.get(ctx, c, g.conf.APIURL+"api/v1/users/"+url.PathEscape(id)+"/", &user); err != nil {
+				return nil, err
+			}
+			people = append(people, user.Username)
+		}
+		return people, nil
+	}
+	return nil, fmt.Errorf("unknown schedule %q", schedule)
+}
+
+func (g *GrafanaOnCall) get(ctx context.Context, c *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", string(g.conf.APIToken))
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -15,19 +15,65 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// verifySignature returns an error unless the signature is "sha256=" followed
+// by the hex encoded HMAC-SHA256 of the timestamp, a dot and the body, and
+// the Unix timestamp is at most window away from now.
+func verifySignature(secret, signature, timestamp string, body []byte, window time.Duration) error {
+	if signature == "" || timestamp == "" {
+		return errors.New("missing signature")
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > window || d < -window {
+		return fmt.Errorf("timestamp %s outside of the window of %s", timestamp, window)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	if !hmac.Equal([]byte(signature), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
 func main() {
+	var (
+		secret = flag.String("signing-secret", "", "Secret of the signing config of the webhook, the signatures are not verified if empty")
+		window = flag.Duration("replay-window", 5*time.Minute, "Maximum age of signed requests")
+	)
+	flag.Parse()
+
 	http.ListenAndServe(":5001", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		b, err := ioutil.ReadAll(r.Body)
 		if err != nil {
 			panic(err)
 		}
 		defer r.Body.Close()
+		if *secret != "" {
+			sig, ts := r.Header.Get("X-Alertmanager-Signature"), r.Header.Get("X-Alertmanager-Timestamp")
+			if err := verifySignature(*secret, sig, ts, b, *window); err != nil {
+				log.Println("Rejecting request:", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
 		var buf bytes.Buffer
 		if err := json.Indent(&buf, b, " >", "  "); err != nil {
 			panic(err)
@@ -0,0 +1,213 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heartbeat periodically pings external services while the
+// Alertmanager is healthy, so that they can page when it stops doing so.
+package heartbeat
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// maxSendTimeout is the maximum time spent on sending a single heartbeat.
+const maxSendTimeout = 30 * time.Second
+
+type metrics struct {
+	sentTotal     *prometheus.CounterVec
+	failuresTotal *prometheus.CounterVec
+	skippedTotal  *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{}
+
+	m.sentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_heartbeats_sent_total",
+		Help: "How many heartbeats were sent.",
+	}, []string{"heartbeat"})
+	m.failuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_heartbeat_failures_total",
+		Help: "How many heartbeats failed to be sent.",
+	}, []string{"heartbeat"})
+	m.skippedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_heartbeats_skipped_total",
+		Help: "How many heartbeats were skipped because the Alertmanager was unhealthy.",
+	}, []string{"heartbeat"})
+
+	if r != nil {
+		r.MustRegister(m.sentTotal, m.failuresTotal, m.skippedTotal)
+	}
+	return m
+}
+
+// heartbeat is the runtime state of a configured heartbeat.
+type heartbeat struct {
+	conf *config.HeartbeatConfig
+	next time.Time
+}
+
+// Watchdog sends the configured heartbeats at their intervals as long as the
+// Alertmanager is healthy.
+type Watchdog struct {
+	healthy func() error
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+
+	mtx        sync.Mutex
+	heartbeats map[string]*heartbeat
+}
+
+// New returns a new Watchdog. Heartbeats are skipped while healthy returns
+// an error. Every cluster member sends the heartbeats, so the external service
+// only pages once all of them stopped.
+func New(healthy func() error, r prometheus.Registerer, l log.Logger) *Watchdog {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if healthy == nil {
+		healthy = func() error { return nil }
+	}
+	return &Watchdog{
+		healthy:    healthy,
+		logger:     l,
+		metrics:    newMetrics(r),
+		now:        time.Now,
+		heartbeats: map[string]*heartbeat{},
+	}
+}
+
+// ApplyConfig sets the configured heartbeats. New heartbeats are sent right
+// away, the schedule of the others is kept.
+func (w *Watchdog) ApplyConfig(confs []*config.HeartbeatConfig) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	heartbeats := make(map[string]*heartbeat, len(confs))
+	for _, c := range confs {
+		hb := &heartbeat{conf: c, next: w.now()}
+		if prev, ok := w.heartbeats[c.Name]; ok {
+			hb.next = prev.next
+		}
+		heartbeats[c.Name] = hb
+	}
+	w.heartbeats = heartbeats
+}
+
+// Run sends the heartbeats when they are due until stopc is closed.
+func (w *Watchdog) Run(stopc <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		w.sendDue()
+
+		select {
+		case <-stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue sends all heartbeats whose interval has passed.
+func (w *Watchdog) sendDue() {
+	w.mtx.Lock()
+	now := w.now()
+	var due []*config.HeartbeatConfig
+	for _, hb := range w.heartbeats {
+		if now.Before(hb.next) {
+			continue
+		}
+		due = append(due, hb.conf)
+		hb.next = now.Add(time.Duration(hb.conf.Interval))
+	}
+	w.mtx.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+	if err := w.healthy(); err != nil {
+		level.Warn(w.logger).Log("msg", "Skipping heartbeats of unhealthy Alertmanager", "err", err)
+		for _, c := range due {
+			w.metrics.skippedTotal.WithLabelValues(c.Name).Inc()
+		}
+		return
+	}
+
+	for _, c := range due {
+		timeout := time.Duration(c.Interval)
+		if timeout > maxSendTimeout {
+			timeout = maxSendTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := send(ctx, c)
+		cancel()
+
+		if err != nil {
+			w.metrics.failuresTotal.WithLabelValues(c.Name).Inc()
+			level.Error(w.logger).Log("msg", "Sending heartbeat failed", "heartbeat", c.Name, "err", err)
+			continue
+		}
+		w.metrics.sentTotal.WithLabelValues(c.Name).Inc()
+	}
+}
+
+// send sends a single heartbeat.
+func send(ctx context.Context, c *config.HeartbeatConfig) error {
+	method, u := c.Method, c.URL
+	header := http.Header{}
+	if og := c.OpsGenie; og != nil {
+		method = "POST"
+		u = fmt.Sprintf("%sv2/heartbeats/%s/ping", og.APIURL, url.PathEscape(og.Heartbeat))
+		header.Set("Authorization", fmt.Sprintf("GenieKey %s", og.APIKey))
+	}
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	req.Header.Set("User-Agent", "Alertmanager")
+
+	client, err := config.NewHTTPClient(c.HTTPConfig, c.CryptoPolicy, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
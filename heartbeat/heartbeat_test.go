@@ -0,0 +1,123 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeat
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+func TestWatchdog(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+	}))
+	defer srv.Close()
+
+	var healthErr error
+	w := New(func() error { return healthErr }, nil, nil)
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	w.ApplyConfig([]*config.HeartbeatConfig{
+		{
+			Name:       "healthchecks",
+			HTTPConfig: &commoncfg.HTTPClientConfig{},
+			Interval:   model.Duration(time.Minute),
+			URL:        srv.URL + "/ping/abc",
+			Method:     "GET",
+		},
+		{
+			Name:       "opsgenie",
+			HTTPConfig: &commoncfg.HTTPClientConfig{},
+			Interval:   model.Duration(5 * time.Minute),
+			OpsGenie: &config.OpsGenieHeartbeatConfig{
+				APIURL:    srv.URL + "/",
+				APIKey:    "secret",
+				Heartbeat: "alertmanager",
+			},
+		},
+	})
+
+	// Both heartbeats are sent right away.
+	w.sendDue()
+	require.Len(t, requests, 2)
+	paths := map[string]*http.Request{}
+	for _, r := range requests {
+		paths[r.URL.Path] = r
+	}
+	require.Equal(t, "GET", paths["/ping/abc"].Method)
+	og := paths["/v2/heartbeats/alertmanager/ping"]
+	require.NotNil(t, og)
+	require.Equal(t, "POST", og.Method)
+	require.Equal(t, "GenieKey secret", og.Header.Get("Authorization"))
+
+	// Nothing is due before the interval passed.
+	requests = nil
+	now = now.Add(30 * time.Second)
+	w.sendDue()
+	require.Len(t, requests, 0)
+
+	now = now.Add(30 * time.Second)
+	w.sendDue()
+	require.Len(t, requests, 1)
+	require.Equal(t, "/ping/abc", requests[0].URL.Path)
+
+	// No heartbeats are sent while unhealthy.
+	requests = nil
+	healthErr = errors.New("unhealthy")
+	now = now.Add(time.Minute)
+	w.sendDue()
+	require.Len(t, requests, 0)
+
+	// The schedule is kept on reload.
+	healthErr = nil
+	w.ApplyConfig(w.configs())
+	now = now.Add(30 * time.Second)
+	w.sendDue()
+	require.Len(t, requests, 0)
+}
+
+func (w *Watchdog) configs() []*config.HeartbeatConfig {
+	var confs []*config.HeartbeatConfig
+	for _, hb := range w.heartbeats {
+		confs = append(confs, hb.conf)
+	}
+	return confs
+}
+
+func TestSendFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	err := send(context.Background(), &config.HeartbeatConfig{
+		Name:       "healthchecks",
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+		URL:        srv.URL,
+		Method:     "POST",
+	})
+	require.EqualError(t, err, "unexpected status code 404")
+}
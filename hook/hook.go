@@ -0,0 +1,342 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hook calls HTTP callbacks on lifecycle events of alerts and
+// aggregation groups, so that external systems such as incident trackers
+// can mirror the state of the Alertmanager without being a route target.
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// queueSize is the number of events waiting to be sent before further
+// events are dropped.
+const queueSize = 1024
+
+type metrics struct {
+	sentTotal     *prometheus.CounterVec
+	failuresTotal *prometheus.CounterVec
+	droppedTotal  prometheus.Counter
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{}
+
+	m.sentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_lifecycle_hook_events_sent_total",
+		Help: "How many lifecycle events were sent to the hook.",
+	}, []string{"hook"})
+	m.failuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_lifecycle_hook_failures_total",
+		Help: "How many lifecycle events failed to be sent to the hook.",
+	}, []string{"hook"})
+	m.droppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_lifecycle_hook_events_dropped_total",
+		Help: "How many lifecycle events were dropped because too many were waiting to be sent.",
+	})
+
+	if r != nil {
+		r.MustRegister(m.sentTotal, m.failuresTotal, m.droppedTotal)
+	}
+	return m
+}
+
+// Event is the JSON payload posted to the hooks.
+type Event struct {
+	Version   string    `json:"version"`
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	Alert     *Alert    `json:"alert,omitempty"`
+	Group     *Group    `json:"group,omitempty"`
+}
+
+// Alert is the alert of an alert event.
+type Alert struct {
+	Fingerprint  string         `json:"fingerprint"`
+	Labels       model.LabelSet `json:"labels"`
+	Annotations  model.LabelSet `json:"annotations"`
+	StartsAt     time.Time      `json:"startsAt"`
+	EndsAt       time.Time      `json:"endsAt"`
+	GeneratorURL string         `json:"generatorURL"`
+}
+
+// Group is the aggregation group of a group event.
+type Group struct {
+	GroupKey string         `json:"groupKey"`
+	Receiver string         `json:"receiver"`
+	Labels   model.LabelSet `json:"labels"`
+}
+
+// Manager detects the lifecycle events and sends them to the configured
+// hooks. Alerts are first seen once they fire and resolved once they
+// resolve or time out. The group events are reported by the dispatcher.
+type Manager struct {
+	alerts  provider.Alerts
+	leader  func() bool
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+	events  chan *Event
+
+	mtx   sync.Mutex
+	hooks []*config.LifecycleHookConfig
+	// firing holds the alerts seen firing, to report them as resolved once
+	// they time out.
+	firing map[model.Fingerprint]*types.Alert
+	// groups holds the keys of the existing groups, so that groups created
+	// again by the dispatcher after a reload are not reported twice.
+	groups map[string]struct{}
+}
+
+// New returns a new Manager. Hooks are only called while leader returns
+// true, so that a single cluster member calls them.
+func New(alerts provider.Alerts, leader func() bool, r prometheus.Registerer, l log.Logger) *Manager {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if leader == nil {
+		leader = func() bool { return true }
+	}
+	return &Manager{
+		alerts:  alerts,
+		leader:  leader,
+		logger:  l,
+		metrics: newMetrics(r),
+		now:     time.Now,
+		events:  make(chan *Event, queueSize),
+		firing:  map[model.Fingerprint]*types.Alert{},
+		groups:  map[string]struct{}{},
+	}
+}
+
+// ApplyConfig sets the configured hooks.
+func (m *Manager) ApplyConfig(hooks []*config.LifecycleHookConfig) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.hooks = hooks
+}
+
+// Run detects the alert events, checking for timed out alerts at the
+// interval, and sends all events until stopc is closed.
+func (m *Manager) Run(interval time.Duration, stopc <-chan struct{}) {
+	go m.deliver(stopc)
+
+	it := m.alerts.Subscribe()
+	defer it.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			return
+		case a, ok := <-it.Next():
+			if err := it.Err(); err != nil {
+				level.Error(m.logger).Log("msg", "Error on alert update", "err", err)
+				continue
+			}
+			if !ok {
+				return
+			}
+			m.observe(a)
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// observe reports the alert as first seen if it fires for the first time
+// and as resolved if it was seen firing before.
+func (m *Manager) observe(a *types.Alert) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	fp := a.Fingerprint()
+	_, known := m.firing[fp]
+	switch {
+	case a.ResolvedAt(m.now()):
+		if known {
+			delete(m.firing, fp)
+			m.enqueueAlert(config.HookAlertResolved, a)
+		}
+	case !known:
+		m.firing[fp] = a
+		m.enqueueAlert(config.HookAlertFirstSeen, a)
+	default:
+		m.firing[fp] = a
+	}
+}
+
+// check reports the firing alerts that timed out as resolved.
+func (m *Manager) check() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := m.now()
+	for fp, a := range m.firing {
+		if a.ResolvedAt(now) {
+			delete(m.firing, fp)
+			m.enqueueAlert(config.HookAlertResolved, a)
+		}
+	}
+}
+
+// GroupCreated implements the dispatch.GroupObserver interface.
+func (m *Manager) GroupCreated(groupKey, receiver string, labels model.LabelSet) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.groups[groupKey]; ok {
+		return
+	}
+	m.groups[groupKey] = struct{}{}
+	m.enqueueGroup(config.HookGroupCreated, groupKey, receiver, labels)
+}
+
+// GroupDeleted implements the dispatch.GroupObserver interface.
+func (m *Manager) GroupDeleted(groupKey, receiver string, labels model.LabelSet) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.groups, groupKey)
+	m.enqueueGroup(config.HookGroupDeleted, groupKey, receiver, labels)
+}
+
+func (m *Manager) enqueueAlert(event string, a *types.Alert) {
+	m.enqueue(&Event{
+		Event: event,
+		Alert: &Alert{
+			Fingerprint:  a.Fingerprint().String(),
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		},
+	})
+}
+
+func (m *Manager) enqueueGroup(event, groupKey, receiver string, labels model.LabelSet) {
+	m.enqueue(&Event{
+		Event: event,
+		Group: &Group{GroupKey: groupKey, Receiver: receiver, Labels: labels},
+	})
+}
+
+// enqueue queues the event for sending without blocking. Events are not
+// queued without a hook for them.
+func (m *Manager) enqueue(e *Event) {
+	var wanted bool
+	for _, h := range m.hooks {
+		if h.HasEvent(e.Event) {
+			wanted = true
+			break
+		}
+	}
+	if !wanted {
+		return
+	}
+
+	e.Version = "1"
+	e.Timestamp = m.now()
+	select {
+	case m.events <- e:
+	default:
+		m.metrics.droppedTotal.Inc()
+		level.Warn(m.logger).Log("msg", "Dropping lifecycle event, too many events waiting to be sent", "event", e.Event)
+	}
+}
+
+// deliver sends the queued events in order until stopc is closed.
+func (m *Manager) deliver(stopc <-chan struct{}) {
+	for {
+		select {
+		case <-stopc:
+			return
+		case e := <-m.events:
+			if !m.leader() {
+				continue
+			}
+			m.mtx.Lock()
+			hooks := m.hooks
+			m.mtx.Unlock()
+
+			for _, h := range hooks {
+				if !h.HasEvent(e.Event) {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.Timeout))
+				err := send(ctx, h, e)
+				cancel()
+
+				if err != nil {
+					m.metrics.failuresTotal.WithLabelValues(h.Name).Inc()
+					level.Error(m.logger).Log("msg", "Calling lifecycle hook failed", "hook", h.Name, "event", e.Event, "err", err)
+					continue
+				}
+				m.metrics.sentTotal.WithLabelValues(h.Name).Inc()
+			}
+		}
+	}
+}
+
+// send posts a single event to the hook.
+func send(ctx context.Context, h *config.LifecycleHookConfig, e *Event) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(e); err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", h.URL, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Alertmanager")
+
+	client, err := config.NewHTTPClient(h.HTTPConfig, h.CryptoPolicy, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func newManager(events ...string) *Manager {
+	m := New(nil, nil, nil, nil)
+	m.ApplyConfig([]*config.LifecycleHookConfig{{
+		Name:   "tracker",
+		URL:    "http://tracker.example.com",
+		Events: events,
+	}})
+	return m
+}
+
+func queued(m *Manager) []*Event {
+	var res []*Event
+	for {
+		select {
+		case e := <-m.events:
+			res = append(res, e)
+		default:
+			return res
+		}
+	}
+}
+
+func TestAlertEvents(t *testing.T) {
+	now := time.Now()
+	m := newManager(config.HookAlertFirstSeen, config.HookAlertResolved)
+	m.now = func() time.Time { return now }
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency"},
+			StartsAt: now.Add(-time.Minute),
+			EndsAt:   now.Add(5 * time.Minute),
+		},
+	}
+
+	m.observe(alert)
+	m.observe(alert)
+	events := queued(m)
+	require.Len(t, events, 1)
+	require.Equal(t, config.HookAlertFirstSeen, events[0].Event)
+	require.Equal(t, alert.Fingerprint().String(), events[0].Alert.Fingerprint)
+	require.Nil(t, events[0].Group)
+
+	// The alert is resolved once it times out.
+	m.check()
+	require.Len(t, queued(m), 0)
+
+	now = now.Add(10 * time.Minute)
+	m.check()
+	events = queued(m)
+	require.Len(t, events, 1)
+	require.Equal(t, config.HookAlertResolved, events[0].Event)
+
+	// Resolved alerts that were never seen firing are not reported.
+	m.observe(alert)
+	require.Len(t, queued(m), 0)
+}
+
+func TestGroupEvents(t *testing.T) {
+	m := newManager(config.HookGroupCreated, config.HookGroupDeleted)
+
+	labels := model.LabelSet{"alertname": "HighLatency"}
+	m.GroupCreated("{}:{alertname=\"HighLatency\"}", "team-X", labels)
+	// Groups created again after a reload are not reported twice.
+	m.GroupCreated("{}:{alertname=\"HighLatency\"}", "team-X", labels)
+	m.GroupDeleted("{}:{alertname=\"HighLatency\"}", "team-X", labels)
+
+	events := queued(m)
+	require.Len(t, events, 2)
+	require.Equal(t, config.HookGroupCreated, events[0].Event)
+	require.Equal(t, &Group{GroupKey: "{}:{alertname=\"HighLatency\"}", Receiver: "team-X", Labels: labels}, events[0].Group)
+	require.Equal(t, config.HookGroupDeleted, events[1].Event)
+}
+
+func TestUnwantedEventsAreNotQueued(t *testing.T) {
+	m := newManager(config.HookGroupCreated)
+
+	m.GroupDeleted("{}:{}", "team-X", model.LabelSet{})
+	m.observe(&types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency"},
+			StartsAt: time.Now(),
+		},
+	})
+	require.Len(t, queued(m), 0)
+}
+
+func TestSend(t *testing.T) {
+	var got Event
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	h := &config.LifecycleHookConfig{
+		Name:       "tracker",
+		URL:        srv.URL,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+	}
+	e := &Event{
+		Version: "1",
+		Event:   config.HookGroupCreated,
+		Group:   &Group{GroupKey: "{}:{}", Receiver: "team-X", Labels: model.LabelSet{}},
+	}
+
+	require.NoError(t, send(context.Background(), h, e))
+	require.Equal(t, config.HookGroupCreated, got.Event)
+	require.Equal(t, "team-X", got.Group.Receiver)
+
+	status = http.StatusInternalServerError
+	require.EqualError(t, send(context.Background(), h, e), "unexpected status code 500")
+}
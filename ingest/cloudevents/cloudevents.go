@@ -0,0 +1,375 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudevents converts CloudEvents posted to the API into alerts.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// maxRequestSize is the maximum size of an accepted request.
+const maxRequestSize = 4 << 20
+
+const (
+	contentTypeStructured = "application/cloudevents+json"
+	contentTypeBatch      = "application/cloudevents-batch+json"
+)
+
+type metrics struct {
+	received  prometheus.Counter
+	unmatched prometheus.Counter
+	alerts    *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		received: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cloudevents_received_total",
+			Help: "The total number of CloudEvents received.",
+		}),
+		unmatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_cloudevents_unmatched_total",
+			Help: "The total number of received CloudEvents that matched no rule.",
+		}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_cloudevents_alerts_total",
+			Help: "The total number of alerts created from CloudEvents by status.",
+		}, []string{"status"}),
+	}
+	if r != nil {
+		r.MustRegister(m.received, m.unmatched, m.alerts)
+	}
+	return m
+}
+
+// Event is a CloudEvent. Attrs holds the context attributes, including
+// extensions, by name.
+type Event struct {
+	Attrs map[string]string
+	// Data holds the data of the event if it is JSON.
+	Data interface{}
+}
+
+// Adapter accepts CloudEvents over HTTP and converts those matching a rule
+// of the cloudevents_ingest configuration into alerts.
+type Adapter struct {
+	alerts  provider.Alerts
+	logger  log.Logger
+	metrics *metrics
+
+	mtx            sync.RWMutex
+	rules          []*config.CloudEventsIngestRule
+	tenancy        *config.TenancyConfig
+	resolveTimeout time.Duration
+}
+
+// New returns a new Adapter inserting alerts into the given provider.
+func New(ap provider.Alerts, r prometheus.Registerer, l log.Logger) *Adapter {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Adapter{
+		alerts:  ap,
+		logger:  l,
+		metrics: newMetrics(r),
+	}
+}
+
+// ApplyConfig updates the conversion rules of the adapter.
+func (a *Adapter) ApplyConfig(c *config.Config) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.rules = nil
+	if c.CloudEventsIngest != nil {
+		a.rules = c.CloudEventsIngest.Rules
+	}
+	a.tenancy = c.Tenancy
+	a.resolveTimeout = time.Duration(c.Global.ResolveTimeout)
+}
+
+// Register registers the endpoint receiving CloudEvents.
+func (a *Adapter) Register(r *route.Router) {
+	r.Post("/cloudevents", a.handle)
+}
+
+// handle accepts events in the structured, batched and binary content modes
+// of the HTTP protocol binding of CloudEvents. Like the alerts posted to the
+// API, the alerts are assigned to the tenant of the request if it has one.
+func (a *Adapter) handle(w http.ResponseWriter, r *http.Request) {
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(b) > maxRequestSize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	events, err := parseRequest(r.Header, b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var alerts []*types.Alert
+	for _, e := range events {
+		a.metrics.received.Inc()
+		alert, err := a.Convert(e)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("event %q: %s", e.Attrs["id"], err), http.StatusBadRequest)
+			return
+		}
+		if alert == nil {
+			a.metrics.unmatched.Inc()
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+
+	a.mtx.RLock()
+	tc := a.tenancy
+	a.mtx.RUnlock()
+	if tenant := r.Header.Get(config.TenantHeader); tc != nil && tenant != "" {
+		if err := tc.ScopeAlerts(tenant, alerts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	for _, alert := range alerts {
+		if alert.Resolved() {
+			a.metrics.alerts.WithLabelValues(string(model.AlertResolved)).Inc()
+		} else {
+			a.metrics.alerts.WithLabelValues(string(model.AlertFiring)).Inc()
+		}
+	}
+	if err := a.alerts.Put(alerts...); err != nil {
+		level.Error(a.logger).Log("msg", "Failed to insert alerts", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseRequest returns the events of the request.
+func parseRequest(h http.Header, body []byte) ([]*Event, error) {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+
+	switch mediaType {
+	case contentTypeStructured:
+		e, err := parseStructured(body)
+		if err != nil {
+			return nil, err
+		}
+		return []*Event{e}, nil
+	case contentTypeBatch:
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, err
+		}
+		events := make([]*Event, 0, len(raw))
+		for _, b := range raw {
+			e, err := parseStructured(b)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, e)
+		}
+		return events, nil
+	}
+
+	// In the binary content mode, the attributes are headers prefixed with
+	// ce- and the body is the data.
+	e := &Event{Attrs: map[string]string{}}
+	for k, v := range h {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "ce-") && len(v) > 0 {
+			e.Attrs[strings.TrimPrefix(k, "ce-")] = v[0]
+		}
+	}
+	if h.Get("Content-Type") != "" {
+		e.Attrs["datacontenttype"] = h.Get("Content-Type")
+	}
+	if isJSON(mediaType) && len(body) > 0 {
+		if err := json.Unmarshal(body, &e.Data); err != nil {
+			return nil, fmt.Errorf("invalid data: %s", err)
+		}
+	}
+	return []*Event{e}, validate(e)
+}
+
+// parseStructured parses an event in the structured content mode.
+func parseStructured(b []byte) (*Event, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	e := &Event{Attrs: map[string]string{}}
+	for k, v := range raw {
+		switch k {
+		case "data":
+			e.Data = v
+		case "data_base64":
+		default:
+			e.Attrs[k] = scalar(v)
+		}
+	}
+	return e, validate(e)
+}
+
+// validate checks that the required attributes of the event are set.
+func validate(e *Event) error {
+	for _, k := range []string{"specversion", "id", "source", "type"} {
+		if e.Attrs[k] == "" {
+			return fmt.Errorf("missing %s attribute in event", k)
+		}
+	}
+	return nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "" || mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Convert returns the alert created by the first rule matching the event or
+// nil if no rule matches.
+func (a *Adapter) Convert(e *Event) (*types.Alert, error) {
+	a.mtx.RLock()
+	rules, resolveTimeout := a.rules, a.resolveTimeout
+	a.mtx.RUnlock()
+
+	for _, r := range rules {
+		vars := make(map[string]string, len(e.Attrs))
+		for k, v := range e.Attrs {
+			vars[k] = v
+		}
+		if !match(r.Type, e.Attrs["type"], vars) || !match(r.Source, e.Attrs["source"], vars) {
+			continue
+		}
+		flatten("data", e.Data, vars)
+
+		now := time.Now()
+		startsAt := now
+		if t, err := time.Parse(time.RFC3339Nano, e.Attrs["time"]); err == nil && t.Before(now) {
+			startsAt = t
+		}
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:       model.LabelSet{},
+				Annotations:  model.LabelSet{},
+				StartsAt:     startsAt,
+				GeneratorURL: expand(r.GeneratorURL, vars),
+			},
+			UpdatedAt: now,
+		}
+		for k, v := range r.Labels {
+			if v = expand(v, vars); v != "" {
+				alert.Labels[model.LabelName(k)] = model.LabelValue(v)
+			}
+		}
+		for k, v := range r.Annotations {
+			alert.Annotations[model.LabelName(k)] = model.LabelValue(expand(v, vars))
+		}
+
+		if r.Resolve != nil && r.Resolve.Regex.MatchString(vars[r.Resolve.Field]) {
+			alert.EndsAt = now
+		} else {
+			alert.EndsAt = now.Add(resolveTimeout)
+			alert.Timeout = true
+		}
+		if err := alert.Validate(); err != nil {
+			return nil, err
+		}
+		return alert, nil
+	}
+	return nil, nil
+}
+
+// match reports whether s matches the pattern and adds named capture groups
+// to vars. Unset patterns match everything.
+func match(re config.Regexp, s string, vars map[string]string) bool {
+	if re.Regexp == nil {
+		return true
+	}
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			vars[name] = m[i]
+		}
+	}
+	return true
+}
+
+// flatten adds the scalar values of the JSON value v to vars, named by
+// their dot-separated path below prefix.
+func flatten(prefix string, v interface{}, vars map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, cv := range v {
+			flatten(prefix+"."+k, cv, vars)
+		}
+	case []interface{}:
+		for i, cv := range v {
+			flatten(prefix+"."+strconv.Itoa(i), cv, vars)
+		}
+	case nil:
+	default:
+		vars[prefix] = scalar(v)
+	}
+}
+
+// scalar returns the string representation of a scalar JSON value.
+func scalar(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func expand(s string, vars map[string]string) string {
+	return strings.TrimSpace(os.Expand(s, func(k string) string { return vars[k] }))
+}
@@ -0,0 +1,207 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const testConfig = `
+global:
+  resolve_timeout: 10m
+route:
+  receiver: default
+receivers:
+- name: default
+cloudevents_ingest:
+  rules:
+  - type: 'com\.example\.monitor\.(?P<kind>\w+)'
+    resolve:
+      field: data.status
+      regex: ok
+    labels:
+      alertname: Monitor$kind
+      instance: ${data.host}
+      severity: ${data.severity}
+    annotations:
+      summary: $subject
+    generator_url: https://monitor.example.com/checks/${data.check.id}
+`
+
+func newAdapter(t *testing.T) (*Adapter, *mem.Alerts) {
+	conf, err := config.Load(testConfig)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	a := New(alerts, nil, nil)
+	a.ApplyConfig(conf)
+	return a, alerts
+}
+
+func post(t *testing.T, a *Adapter, header http.Header, body string) *httptest.ResponseRecorder {
+	router := route.New()
+	a.Register(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/cloudevents", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestStructured(t *testing.T) {
+	a, alerts := newAdapter(t)
+
+	w := post(t, a, http.Header{"Content-Type": {contentTypeStructured}}, `{
+		"specversion": "1.0",
+		"id": "1",
+		"source": "/monitor",
+		"type": "com.example.monitor.check",
+		"subject": "db1 is down",
+		"data": {"host": "db1", "severity": "critical", "status": "failing", "check": {"id": 42}}
+	}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	it := alerts.GetPending()
+	defer it.Close()
+	var got []*types.Alert
+	for a := range it.Next() {
+		got = append(got, a)
+	}
+	require.Len(t, got, 1)
+	require.Equal(t, model.LabelSet{
+		"alertname": "Monitorcheck",
+		"instance":  "db1",
+		"severity":  "critical",
+	}, got[0].Labels)
+	require.Equal(t, model.LabelValue("db1 is down"), got[0].Annotations["summary"])
+	require.Equal(t, "https://monitor.example.com/checks/42", got[0].GeneratorURL)
+	require.False(t, got[0].Resolved())
+}
+
+func TestTenancy(t *testing.T) {
+	conf, err := config.Load(testConfig + `
+tenancy:
+  label: instance
+  admin_tenants: [ops]
+`)
+	require.NoError(t, err)
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+	a := New(alerts, nil, nil)
+	a.ApplyConfig(conf)
+
+	event := func(host string) string {
+		return `{
+			"specversion": "1.0",
+			"id": "1",
+			"source": "/monitor",
+			"type": "com.example.monitor.check",
+			"data": {"host": "` + host + `"}
+		}`
+	}
+	header := func(tenant string) http.Header {
+		h := http.Header{"Content-Type": {contentTypeStructured}}
+		h.Set(config.TenantHeader, tenant)
+		return h
+	}
+
+	// Alerts of other tenants are rejected.
+	w := post(t, a, header("db2"), event("db1"))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), `does not belong to tenant "db2"`)
+
+	for _, tc := range []struct {
+		tenant, host string
+	}{
+		{"db1", "db1"},
+		{"ops", "db2"},
+	} {
+		w = post(t, a, header(tc.tenant), event(tc.host))
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	it := alerts.GetPending()
+	defer it.Close()
+	var hosts []string
+	for a := range it.Next() {
+		hosts = append(hosts, string(a.Labels["instance"]))
+	}
+	sort.Strings(hosts)
+	require.Equal(t, []string{"db1", "db2"}, hosts)
+}
+
+func TestBatchAndResolve(t *testing.T) {
+	a, _ := newAdapter(t)
+
+	events, err := parseRequest(http.Header{"Content-Type": {contentTypeBatch}}, []byte(`[
+		{"specversion": "1.0", "id": "1", "source": "/monitor", "type": "com.example.monitor.check", "data": {"host": "db1", "status": "ok"}},
+		{"specversion": "1.0", "id": "2", "source": "/other", "type": "com.example.other"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	alert, err := a.Convert(events[0])
+	require.NoError(t, err)
+	require.True(t, alert.Resolved())
+
+	alert, err = a.Convert(events[1])
+	require.NoError(t, err)
+	require.Nil(t, alert)
+}
+
+func TestBinary(t *testing.T) {
+	events, err := parseRequest(http.Header{
+		"Content-Type":   {"application/json"},
+		"Ce-Specversion": {"1.0"},
+		"Ce-Id":          {"1"},
+		"Ce-Source":      {"/monitor"},
+		"Ce-Type":        {"com.example.monitor.check"},
+		"Ce-Region":      {"eu"},
+	}, []byte(`{"host": "db1"}`))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "eu", events[0].Attrs["region"])
+	require.Equal(t, map[string]interface{}{"host": "db1"}, events[0].Data)
+}
+
+func TestInvalid(t *testing.T) {
+	a, _ := newAdapter(t)
+
+	// The required id attribute is missing.
+	w := post(t, a, http.Header{"Content-Type": {contentTypeStructured}}, `{"specversion": "1.0", "source": "/monitor", "type": "com.example.monitor.check"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "missing id attribute")
+
+	// Events matching no rule are accepted and dropped.
+	w = post(t, a, http.Header{"Content-Type": {contentTypeStructured}}, `{"specversion": "1.0", "id": "1", "source": "/monitor", "type": "com.example.monitor.", "data": {}}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+}
@@ -0,0 +1,392 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package email converts incoming emails into alerts.
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	// maxMessageSize is the maximum size of an accepted email.
+	maxMessageSize = 1 << 20
+	// maxLineLength is the maximum length of a command line including the
+	// trailing CRLF. RFC 5321 limits it to 512 bytes but some clients send
+	// longer ones.
+	maxLineLength = 1000
+)
+
+var errLineTooLong = errors.New("line too long")
+
+type metrics struct {
+	received  prometheus.Counter
+	unmatched prometheus.Counter
+	alerts    *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		received: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_email_gateway_messages_received_total",
+			Help: "The total number of emails received by the email gateway.",
+		}),
+		unmatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_email_gateway_messages_unmatched_total",
+			Help: "The total number of received emails that matched no rule.",
+		}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_email_gateway_alerts_total",
+			Help: "The total number of alerts created from emails by status.",
+		}, []string{"status"}),
+	}
+	if r != nil {
+		r.MustRegister(m.received, m.unmatched, m.alerts)
+	}
+	return m
+}
+
+// Gateway accepts emails via SMTP and converts those matching a rule of the
+// email gateway configuration into alerts.
+type Gateway struct {
+	alerts  provider.Alerts
+	logger  log.Logger
+	metrics *metrics
+
+	mtx            sync.RWMutex
+	rules          []*config.EmailGatewayRule
+	resolveTimeout time.Duration
+}
+
+// New returns a new Gateway inserting alerts into the given provider.
+func New(ap provider.Alerts, r prometheus.Registerer, l log.Logger) *Gateway {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Gateway{
+		alerts:  ap,
+		logger:  l,
+		metrics: newMetrics(r),
+	}
+}
+
+// ApplyConfig updates the conversion rules of the gateway.
+func (g *Gateway) ApplyConfig(c *config.Config) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.rules = nil
+	if c.EmailGateway != nil {
+		g.rules = c.EmailGateway.Rules
+	}
+	g.resolveTimeout = time.Duration(c.Global.ResolveTimeout)
+}
+
+// ListenAndServe accepts SMTP connections on the given address.
+func (g *Gateway) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return g.Serve(l)
+}
+
+// Serve accepts SMTP connections on the listener until it is closed.
+func (g *Gateway) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go g.handle(conn)
+	}
+}
+
+// handle implements the minimal subset of SMTP (RFC 5321) required to
+// receive mail.
+func (g *Gateway) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) bool {
+		conn.SetDeadline(time.Now().Add(5 * time.Minute))
+		return tp.PrintfLine("%d %s", code, msg) == nil
+	}
+	if !reply(220, "Alertmanager email gateway") {
+		return
+	}
+
+	var haveFrom, haveRcpt bool
+	for {
+		line, err := readLine(tp.R)
+		if err == errLineTooLong {
+			// The rest of the line cannot be told apart from the next
+			// command.
+			reply(500, "Line too long")
+			return
+		}
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "HELO", "EHLO":
+			haveFrom, haveRcpt = false, false
+			reply(250, "Hello")
+		case "MAIL":
+			haveFrom, haveRcpt = true, false
+			reply(250, "OK")
+		case "RCPT":
+			if !haveFrom {
+				reply(503, "Need MAIL before RCPT")
+				continue
+			}
+			haveRcpt = true
+			reply(250, "OK")
+		case "DATA":
+			if !haveRcpt {
+				reply(503, "Need RCPT before DATA")
+				continue
+			}
+			if !reply(354, "End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			dr := tp.DotReader()
+			b, err := ioutil.ReadAll(io.LimitReader(dr, maxMessageSize+1))
+			if err != nil {
+				return
+			}
+			haveFrom, haveRcpt = false, false
+			if len(b) > maxMessageSize {
+				// Read the rest of the message up to the final dot, which
+				// would otherwise be read as commands.
+				if _, err := io.Copy(ioutil.Discard, dr); err != nil {
+					return
+				}
+				reply(552, "Message too large")
+				continue
+			}
+			if err := g.receive(bytes.NewReader(b)); err != nil {
+				level.Warn(g.logger).Log("msg", "Failed to process email", "err", err)
+				reply(554, "Transaction failed")
+				continue
+			}
+			reply(250, "OK")
+		case "RSET":
+			haveFrom, haveRcpt = false, false
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// readLine reads a line without the trailing CRLF. It fails with
+// errLineTooLong for lines longer than maxLineLength.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > maxLineLength {
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// receive converts the raw email into an alert and inserts it.
+func (g *Gateway) receive(r io.Reader) error {
+	g.metrics.received.Inc()
+
+	msg, err := mail.ReadMessage(bufio.NewReader(r))
+	if err != nil {
+		return err
+	}
+	alert, err := g.Convert(msg)
+	if err != nil {
+		return err
+	}
+	if alert == nil {
+		g.metrics.unmatched.Inc()
+		return nil
+	}
+	if alert.Resolved() {
+		g.metrics.alerts.WithLabelValues(string(model.AlertResolved)).Inc()
+	} else {
+		g.metrics.alerts.WithLabelValues(string(model.AlertFiring)).Inc()
+	}
+	return g.alerts.Put(alert)
+}
+
+// Convert returns the alert created by the first rule matching the email or
+// nil if no rule matches.
+func (g *Gateway) Convert(msg *mail.Message) (*types.Alert, error) {
+	g.mtx.RLock()
+	rules, resolveTimeout := g.rules, g.resolveTimeout
+	g.mtx.RUnlock()
+
+	body, err := textBody(msg)
+	if err != nil {
+		return nil, err
+	}
+	subject := decodeHeader(msg.Header.Get("Subject"))
+	vars := map[string]string{
+		"from":    msg.Header.Get("From"),
+		"subject": subject,
+		"body":    body,
+	}
+
+	for _, r := range rules {
+		if !match(r.From, vars["from"], vars) ||
+			!match(r.Subject, subject, vars) ||
+			!match(r.Body, body, vars) {
+			continue
+		}
+
+		now := time.Now()
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{},
+				Annotations: model.LabelSet{},
+				StartsAt:    now,
+			},
+			UpdatedAt: now,
+		}
+		for h, ln := range r.Headers {
+			if v := decodeHeader(msg.Header.Get(h)); v != "" {
+				alert.Labels[ln] = model.LabelValue(v)
+			}
+		}
+		for k, v := range r.Labels {
+			if v = expand(v, vars); v != "" {
+				alert.Labels[model.LabelName(k)] = model.LabelValue(v)
+			}
+		}
+		for k, v := range r.Annotations {
+			alert.Annotations[model.LabelName(k)] = model.LabelValue(expand(v, vars))
+		}
+
+		if r.Resolve.Regexp != nil && r.Resolve.MatchString(subject) {
+			alert.EndsAt = now
+		} else {
+			alert.EndsAt = now.Add(resolveTimeout)
+			alert.Timeout = true
+		}
+		if err := alert.Validate(); err != nil {
+			return nil, err
+		}
+		return alert, nil
+	}
+	return nil, nil
+}
+
+// match reports whether s matches the pattern and adds named capture groups
+// to vars. Unset patterns match everything.
+func match(re config.Regexp, s string, vars map[string]string) bool {
+	if re.Regexp == nil {
+		return true
+	}
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			vars[name] = m[i]
+		}
+	}
+	return true
+}
+
+func expand(s string, vars map[string]string) string {
+	return strings.TrimSpace(os.Expand(s, func(k string) string { return vars[k] }))
+}
+
+func decodeHeader(s string) string {
+	dec := new(mime.WordDecoder)
+	if d, err := dec.DecodeHeader(s); err == nil {
+		return d
+	}
+	return s
+}
+
+// textBody returns the plain text body of the message decoded according to
+// its Content-Transfer-Encoding. For multipart messages the first text/plain
+// part is used.
+func textBody(msg *mail.Message) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		b, err := ioutil.ReadAll(decodeTransfer(msg.Body, msg.Header.Get("Content-Transfer-Encoding")))
+		return string(b), err
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return "", fmt.Errorf("no text/plain part in multipart message")
+		}
+		if err != nil {
+			return "", err
+		}
+		if mt, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type")); mt == "text/plain" {
+			// The multipart reader only decodes quoted-printable parts.
+			b, err := ioutil.ReadAll(decodeTransfer(p, p.Header.Get("Content-Transfer-Encoding")))
+			return string(b), err
+		}
+	}
+}
+
+// decodeTransfer returns a reader of r decoded according to the
+// Content-Transfer-Encoding. Bodies in other encodings, e.g. 7bit and
+// 8bit, are returned as is.
+func decodeTransfer(r io.Reader, enc string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(enc)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
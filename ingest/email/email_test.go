@@ -0,0 +1,214 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const testConfig = `
+global:
+  resolve_timeout: 10m
+route:
+  receiver: default
+receivers:
+- name: default
+email_gateway:
+  rules:
+  - subject: '(?P<host>\S+) is (?P<state>DOWN|UP)'
+    resolve: '.* is UP'
+    body: '(?s).*Severity: (?P<severity>\w+).*'
+    headers:
+      X-Monitor: monitor
+    labels:
+      alertname: HostDown
+      instance: $host
+      severity: $severity
+    annotations:
+      summary: $subject
+`
+
+func newGateway(t *testing.T) (*Gateway, *mem.Alerts) {
+	conf, err := config.Load(testConfig)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	g := New(alerts, nil, nil)
+	g.ApplyConfig(conf)
+	return g, alerts
+}
+
+func readMessage(t *testing.T, s string) *mail.Message {
+	msg, err := mail.ReadMessage(strings.NewReader(s))
+	require.NoError(t, err)
+	return msg
+}
+
+func TestConvert(t *testing.T) {
+	g, _ := newGateway(t)
+
+	alert, err := g.Convert(readMessage(t, "From: nagios@example.com\r\n"+
+		"Subject: db1 is DOWN\r\n"+
+		"X-Monitor: nagios\r\n"+
+		"\r\n"+
+		"Host check failed.\r\nSeverity: critical\r\n"))
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	require.Equal(t, model.LabelSet{
+		"alertname": "HostDown",
+		"instance":  "db1",
+		"severity":  "critical",
+		"monitor":   "nagios",
+	}, alert.Labels)
+	require.Equal(t, model.LabelSet{"summary": "db1 is DOWN"}, alert.Annotations)
+	require.True(t, alert.Timeout)
+	require.False(t, alert.Resolved())
+	require.WithinDuration(t, time.Now().Add(10*time.Minute), alert.EndsAt, time.Minute)
+
+	alert, err = g.Convert(readMessage(t, "Subject: db1 is UP\r\n\r\nSeverity: critical\r\n"))
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	require.False(t, alert.Timeout)
+	require.True(t, alert.Resolved())
+
+	alert, err = g.Convert(readMessage(t, "Subject: weekly report\r\n\r\nAll good.\r\n"))
+	require.NoError(t, err)
+	require.Nil(t, alert)
+}
+
+func TestConvertMultipart(t *testing.T) {
+	g, _ := newGateway(t)
+
+	alert, err := g.Convert(readMessage(t, "Subject: =?utf-8?q?web1_is_DOWN?=\r\n"+
+		"Content-Type: multipart/alternative; boundary=b\r\n"+
+		"\r\n"+
+		"--b\r\nContent-Type: text/html\r\n\r\n<p>Severity: html</p>\r\n"+
+		"--b\r\nContent-Type: text/plain\r\n\r\nSeverity: warning\r\n"+
+		"--b--\r\n"))
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	require.Equal(t, model.LabelValue("web1"), alert.Labels["instance"])
+	require.Equal(t, model.LabelValue("warning"), alert.Labels["severity"])
+}
+
+func TestConvertTransferEncoding(t *testing.T) {
+	g, _ := newGateway(t)
+
+	for _, msg := range []string{
+		"Subject: db1 is DOWN\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+			"SG9zdCBjaGVjayBmYWls\r\nZWQuClNldmVyaXR5OiBjcml0aWNhbAo=\r\n",
+		"Subject: db1 is DOWN\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n" +
+			"Host check=\r\n failed.\r\nSeverity=3A critical\r\n",
+		"Subject: db1 is DOWN\r\nContent-Type: multipart/alternative; boundary=b\r\n\r\n" +
+			"--b\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+			"U2V2ZXJpdHk6IGNyaXRpY2FsCg==\r\n--b--\r\n",
+	} {
+		alert, err := g.Convert(readMessage(t, msg))
+		require.NoError(t, err)
+		require.NotNil(t, alert, msg)
+		require.Equal(t, model.LabelValue("critical"), alert.Labels["severity"], msg)
+	}
+}
+
+func TestServe(t *testing.T) {
+	g, alerts := newGateway(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go g.Serve(l)
+
+	err = smtp.SendMail(l.Addr().String(), nil, "nagios@example.com", []string{"alertmanager@example.com"}, []byte(
+		"Subject: db1 is DOWN\r\n\r\nSeverity: critical\r\n"))
+	require.NoError(t, err)
+
+	it := alerts.GetPending()
+	defer it.Close()
+
+	var got []*types.Alert
+	for a := range it.Next() {
+		got = append(got, a)
+	}
+	require.Len(t, got, 1)
+	require.Equal(t, model.LabelValue("db1"), got[0].Labels["instance"])
+}
+
+func TestServeLimits(t *testing.T) {
+	g, alerts := newGateway(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go g.Serve(l)
+
+	conn, err := textproto.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	cmd := func(code int, format string, args ...interface{}) {
+		id, err := conn.Cmd(format, args...)
+		require.NoError(t, err)
+		conn.StartResponse(id)
+		defer conn.EndResponse(id)
+		_, _, err = conn.ReadResponse(code)
+		require.NoError(t, err, format)
+	}
+	_, _, err = conn.ReadResponse(220)
+	require.NoError(t, err)
+
+	// The rest of a message that is too large is not read as commands, which
+	// would send the message embedded in it.
+	cmd(250, "HELO localhost")
+	cmd(250, "MAIL FROM:<nagios@example.com>")
+	cmd(250, "RCPT TO:<alertmanager@example.com>")
+	cmd(354, "DATA")
+	w := conn.DotWriter()
+	_, err = w.Write([]byte(strings.Repeat("x", maxMessageSize) + "\r\n" +
+		"MAIL FROM:<nagios@example.com>\r\n" +
+		"RCPT TO:<alertmanager@example.com>\r\n" +
+		"DATA\r\n" +
+		"Subject: db1 is DOWN\r\n\r\nSeverity: critical\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, _, err = conn.ReadResponse(552)
+	require.NoError(t, err)
+	cmd(250, "NOOP")
+
+	it := alerts.GetPending()
+	defer it.Close()
+	for a := range it.Next() {
+		t.Fatalf("Unexpected alert %v", a)
+	}
+
+	// Overlong lines close the connection.
+	cmd(500, "NOOP %s", strings.Repeat("x", maxLineLength))
+	_, err = conn.ReadLine()
+	require.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,334 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package enrich adds labels and annotations to received alerts before they
+// are routed.
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	// maxResponseSize is the maximum size of a lookup response.
+	maxResponseSize = 1 << 20
+	// cacheIdleTimeout is the time after which cached lookups that were not
+	// used anymore are removed.
+	cacheIdleTimeout = time.Hour
+	// gcInterval is the minimum time between removals of idle lookups.
+	gcInterval = time.Minute
+)
+
+type metrics struct {
+	enriched  *prometheus.CounterVec
+	lookups   *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	cacheHits *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		enriched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enricher_alerts_enriched_total",
+			Help: "The total number of received alerts labels or annotations were added to by an alert enricher.",
+		}, []string{"enricher"}),
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enricher_lookups_total",
+			Help: "The total number of requests of alert enrichers to their HTTP service.",
+		}, []string{"enricher"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enricher_lookup_failures_total",
+			Help: "The total number of failed requests of alert enrichers to their HTTP service.",
+		}, []string{"enricher"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enricher_cache_hits_total",
+			Help: "The total number of lookups of alert enrichers answered from the cache.",
+		}, []string{"enricher"}),
+	}
+	if r != nil {
+		r.MustRegister(m.enriched, m.lookups, m.failures, m.cacheHits)
+	}
+	return m
+}
+
+// result holds the labels and annotations an alert enricher adds to an
+// alert.
+type result struct {
+	Labels      model.LabelSet `json:"labels,omitempty"`
+	Annotations model.LabelSet `json:"annotations,omitempty"`
+}
+
+// rule is a configured alert enricher.
+type rule struct {
+	conf     *config.AlertEnricherConfig
+	matchers types.Matchers
+	// static is the result of an enricher without URL.
+	static *result
+	client *http.Client
+}
+
+type cacheEntry struct {
+	res *result
+	// ts is the time of the lookup and used the last time the entry was
+	// needed.
+	ts   time.Time
+	used time.Time
+}
+
+// Enricher is a provider.Alerts that adds the labels and annotations of the
+// configured alert enrichers to alerts before putting them into the wrapped
+// provider. Lookups are cached and failed lookups never keep alerts from
+// being put: the previous result of a lookup is used if there is one,
+// otherwise the alert is put as it is.
+type Enricher struct {
+	provider.Alerts
+
+	logger  log.Logger
+	metrics *metrics
+	now     func() time.Time
+
+	mtx    sync.Mutex
+	rules  []*rule
+	cache  map[string]*cacheEntry
+	lastGC time.Time
+}
+
+// New returns a new Enricher putting alerts into the given provider.
+func New(ap provider.Alerts, r prometheus.Registerer, l log.Logger) *Enricher {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Enricher{
+		Alerts:  ap,
+		logger:  l,
+		metrics: newMetrics(r),
+		now:     time.Now,
+		cache:   map[string]*cacheEntry{},
+	}
+}
+
+// ApplyConfig replaces the alert enrichers with the configured ones. Cached
+// lookups are kept for enrichers whose URL did not change.
+func (e *Enricher) ApplyConfig(c *config.Config) {
+	rules := make([]*rule, 0, len(c.AlertEnrichers))
+	for _, ec := range c.AlertEnrichers {
+		r := &rule{conf: ec}
+		for ln, lv := range ec.Match {
+			r.matchers = append(r.matchers, types.NewMatcher(model.LabelName(ln), lv))
+		}
+		for ln, lv := range ec.MatchRE {
+			r.matchers = append(r.matchers, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
+		}
+		if ec.URL == "" {
+			r.static = &result{Labels: ec.Labels, Annotations: ec.Annotations}
+		} else {
+			client, err := config.NewHTTPClient(ec.HTTPConfig, ec.CryptoPolicy, nil)
+			if err != nil {
+				level.Error(e.logger).Log("msg", "Disabling alert enricher", "enricher", ec.Name, "err", err)
+				continue
+			}
+			r.client = client
+		}
+		rules = append(rules, r)
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	e.rules = rules
+}
+
+// Put adds the labels and annotations of the matching alert enrichers to the
+// alerts and puts them into the wrapped provider. Enrichers are applied in
+// the order of the configuration and match the labels added by the previous
+// ones. Labels and annotations the alerts already have are not changed.
+func (e *Enricher) Put(alerts ...*types.Alert) error {
+	e.mtx.Lock()
+	rules := e.rules
+	e.mtx.Unlock()
+
+	if len(rules) > 0 {
+		// After a lookup failed, only cached results of the enricher are used
+		// for the other alerts so that an unavailable service does not delay
+		// them all by its timeout.
+		failed := map[*rule]bool{}
+		for _, a := range alerts {
+			e.enrich(a, rules, failed)
+		}
+		e.gc()
+	}
+	return e.Alerts.Put(alerts...)
+}
+
+func (e *Enricher) enrich(a *types.Alert, rules []*rule, failed map[*rule]bool) {
+	for _, r := range rules {
+		if !r.matchers.Match(a.Labels) {
+			continue
+		}
+		res := r.static
+		if r.client != nil {
+			var err error
+			res, err = e.lookup(r, a.Labels, failed[r])
+			if err != nil {
+				failed[r] = true
+				if res != nil {
+					level.Warn(e.logger).Log("msg", "Alert enrichment failed, using previous result", "enricher", r.conf.Name, "err", err)
+				} else {
+					level.Warn(e.logger).Log("msg", "Alert enrichment failed, alert is not enriched", "enricher", r.conf.Name, "alert", a, "err", err)
+				}
+			}
+		}
+		if res == nil {
+			continue
+		}
+		labels, annotations := merge(a.Labels, res.Labels), merge(a.Annotations, res.Annotations)
+		if len(labels) != len(a.Labels) || len(annotations) != len(a.Annotations) {
+			a.Labels, a.Annotations = labels, annotations
+			e.metrics.enriched.WithLabelValues(r.conf.Name).Inc()
+		}
+	}
+}
+
+// lookup returns the result of the enricher for the labels. A cached result
+// is used while it is fresh, if cachedOnly is set and if the lookup fails.
+func (e *Enricher) lookup(r *rule, lset model.LabelSet, cachedOnly bool) (*result, error) {
+	key := r.conf.URL + "\xff" + lset.Fingerprint().String()
+	now := e.now()
+
+	e.mtx.Lock()
+	entry, cached := e.cache[key]
+	if cached {
+		entry.used = now
+	}
+	e.mtx.Unlock()
+
+	if cached && (cachedOnly || now.Sub(entry.ts) < time.Duration(r.conf.CacheTTL)) {
+		e.metrics.cacheHits.WithLabelValues(r.conf.Name).Inc()
+		return entry.res, nil
+	}
+	if cachedOnly {
+		return nil, nil
+	}
+
+	e.metrics.lookups.WithLabelValues(r.conf.Name).Inc()
+	res, err := r.query(lset)
+	if err != nil {
+		e.metrics.failures.WithLabelValues(r.conf.Name).Inc()
+		if cached {
+			return entry.res, err
+		}
+		return nil, err
+	}
+
+	e.mtx.Lock()
+	e.cache[key] = &cacheEntry{res: res, ts: now, used: now}
+	e.mtx.Unlock()
+
+	return res, nil
+}
+
+// query sends the labels to the service of the enricher and returns the
+// labels and annotations of its response.
+func (r *rule) query(lset model.LabelSet) (*result, error) {
+	b, err := json.Marshal(struct {
+		Labels model.LabelSet `json:"labels"`
+	}{lset})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", r.conf.URL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Alertmanager")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.conf.Timeout))
+	defer cancel()
+
+	resp, err := ctxhttp.Do(ctx, r.client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	var res result
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("invalid response: %s", err)
+	}
+	if err := res.Labels.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid labels in response: %s", err)
+	}
+	return &res, nil
+}
+
+// gc removes the cached lookups that were not used for the idle timeout.
+func (e *Enricher) gc() {
+	now := e.now()
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if now.Sub(e.lastGC) < gcInterval {
+		return
+	}
+	e.lastGC = now
+	for k, entry := range e.cache {
+		if now.Sub(entry.used) > cacheIdleTimeout {
+			delete(e.cache, k)
+		}
+	}
+}
+
+// merge returns the label set with the added labels that it does not have
+// yet, ignoring empty values. The label set is not modified.
+func merge(ls, add model.LabelSet) model.LabelSet {
+	var res model.LabelSet
+	for ln, lv := range add {
+		if _, ok := ls[ln]; ok || lv == "" {
+			continue
+		}
+		if res == nil {
+			res = ls.Clone()
+		}
+		res[ln] = lv
+	}
+	if res == nil {
+		return ls
+	}
+	return res
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func newEnricher(t *testing.T, url string) *Enricher {
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+alert_enrichers:
+- name: cmdb
+  match_re:
+    service: .+
+  url: ` + url + `
+  timeout: 1s
+  cache_ttl: 1m
+- name: runbooks
+  match:
+    team: frontend
+  labels:
+    severity: warning
+  annotations:
+    runbook: https://wiki.example.com/frontend
+`)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	e := New(alerts, nil, nil)
+	e.ApplyConfig(conf)
+	return e
+}
+
+func newAlert(labels model.LabelSet) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:   labels,
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		},
+	}
+}
+
+func TestEnricherPut(t *testing.T) {
+	var requests, failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			Labels model.LabelSet `json:"labels"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Labels["service"] != "web" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"labels": {"team": "frontend", "service": "other"}, "annotations": {"owner": "alice"}}`))
+	}))
+	defer srv.Close()
+
+	e := newEnricher(t, srv.URL)
+
+	// The labels of the service are added and used by the next enricher, but
+	// labels of the alert are not overwritten.
+	web := newAlert(model.LabelSet{"alertname": "Down", "service": "web", "severity": "critical"})
+	db := newAlert(model.LabelSet{"alertname": "Down", "service": "db"})
+	require.NoError(t, e.Put(web, db))
+	require.Equal(t, model.LabelSet{"alertname": "Down", "service": "web", "severity": "critical", "team": "frontend"}, web.Labels)
+	require.Equal(t, model.LabelSet{"owner": "alice", "runbook": "https://wiki.example.com/frontend"}, web.Annotations)
+	require.Equal(t, model.LabelSet{"alertname": "Down", "service": "db"}, db.Labels)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	stored, err := e.Get(web.Fingerprint())
+	require.NoError(t, err)
+	require.Equal(t, web.Labels, stored.Labels)
+
+	// Cached lookups are used while they are fresh.
+	web = newAlert(model.LabelSet{"alertname": "Down", "service": "web", "severity": "critical"})
+	require.NoError(t, e.Put(web))
+	require.Equal(t, model.LabelValue("frontend"), web.Labels["team"])
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// Failed lookups fall back to the previous result or leave the alert
+	// unchanged, and the service is not asked again in the same batch.
+	e.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	atomic.StoreInt32(&failing, 1)
+	web = newAlert(model.LabelSet{"alertname": "Down", "service": "web", "severity": "critical"})
+	api := newAlert(model.LabelSet{"alertname": "Down", "service": "api"})
+	require.NoError(t, e.Put(web, api))
+	require.Equal(t, model.LabelValue("frontend"), web.Labels["team"])
+	require.Equal(t, model.LabelSet{"alertname": "Down", "service": "api"}, api.Labels)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestEnricherTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	e := newEnricher(t, srv.URL)
+
+	a := newAlert(model.LabelSet{"alertname": "Down", "service": "web"})
+	start := time.Now()
+	require.NoError(t, e.Put(a, newAlert(model.LabelSet{"alertname": "Down", "service": "db"})))
+	require.True(t, time.Since(start) < 2*time.Second, "lookups were not skipped after the timeout")
+	require.Equal(t, model.LabelSet{"alertname": "Down", "service": "web"}, a.Labels)
+}
+
+func TestEnricherCacheGC(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"labels": {"team": "frontend"}}`))
+	}))
+	defer srv.Close()
+
+	e := newEnricher(t, srv.URL)
+	require.NoError(t, e.Put(newAlert(model.LabelSet{"alertname": "Down", "service": "web"})))
+	require.Len(t, e.cache, 1)
+
+	e.now = func() time.Time { return time.Now().Add(cacheIdleTimeout + time.Minute) }
+	require.NoError(t, e.Put(newAlert(model.LabelSet{"alertname": "Down"})))
+	require.Len(t, e.cache, 0)
+}
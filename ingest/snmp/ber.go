@@ -0,0 +1,168 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snmp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// BER tags of the types used in SNMP messages (RFC 3416).
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagIPAddress   = 0x40
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+	tagOpaque      = 0x44
+	tagCounter64   = 0x46
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	tagTrapV2 = 0xa7
+)
+
+// tlv is a decoded BER type-length-value. The value shares the memory of
+// the decoded message.
+type tlv struct {
+	tag   byte
+	value []byte
+}
+
+// next decodes the first TLV of b and returns it with the remaining bytes.
+func next(b []byte) (tlv, []byte, error) {
+	if len(b) < 2 {
+		return tlv{}, nil, fmt.Errorf("truncated BER value")
+	}
+	tag, l := b[0], int(b[1])
+	b = b[2:]
+	if tag&0x1f == 0x1f {
+		return tlv{}, nil, fmt.Errorf("unsupported multi-byte BER tag")
+	}
+	if l&0x80 != 0 {
+		n := l & 0x7f
+		if n == 0 || n > 4 || len(b) < n {
+			return tlv{}, nil, fmt.Errorf("invalid BER length")
+		}
+		l = 0
+		for _, c := range b[:n] {
+			l = l<<8 | int(c)
+		}
+		b = b[n:]
+	}
+	if l < 0 || l > len(b) {
+		return tlv{}, nil, fmt.Errorf("truncated BER value")
+	}
+	return tlv{tag: tag, value: b[:l]}, b[l:], nil
+}
+
+// expect decodes the first TLV of b and checks its tag.
+func expect(b []byte, tag byte) ([]byte, []byte, error) {
+	v, rest, err := next(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.tag != tag {
+		return nil, nil, fmt.Errorf("unexpected BER tag %#x, expected %#x", v.tag, tag)
+	}
+	return v.value, rest, nil
+}
+
+func decodeInt(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, fmt.Errorf("invalid integer length %d", len(b))
+	}
+	var v int64
+	if b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v, nil
+}
+
+func decodeUint(b []byte) (uint64, error) {
+	if len(b) == 0 || len(b) > 9 || (len(b) == 9 && b[0] != 0) {
+		return 0, fmt.Errorf("invalid unsigned integer length %d", len(b))
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func decodeOID(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", fmt.Errorf("empty OID")
+	}
+	var (
+		parts []string
+		v     uint64
+	)
+	for i, c := range b {
+		v = v<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return "", fmt.Errorf("truncated OID")
+			}
+			continue
+		}
+		if parts == nil {
+			// The first sub-identifier encodes the first two arcs.
+			first := v / 40
+			if first > 2 {
+				first = 2
+			}
+			parts = append(parts, strconv.FormatUint(first, 10), strconv.FormatUint(v-first*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(v, 10))
+		}
+		v = 0
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// decodeValue returns the string representation of a variable binding value.
+func decodeValue(v tlv) (string, error) {
+	switch v.tag {
+	case tagInteger:
+		i, err := decodeInt(v.value)
+		return strconv.FormatInt(i, 10), err
+	case tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+		u, err := decodeUint(v.value)
+		return strconv.FormatUint(u, 10), err
+	case tagOctetString, tagOpaque:
+		return string(v.value), nil
+	case tagOID:
+		return decodeOID(v.value)
+	case tagIPAddress:
+		if len(v.value) != 4 {
+			return "", fmt.Errorf("invalid IP address length %d", len(v.value))
+		}
+		return net.IP(v.value).String(), nil
+	case tagNull, tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported value type %#x", v.tag)
+}
@@ -0,0 +1,357 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snmp converts incoming SNMPv2c and SNMPv3 traps into alerts.
+package snmp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const (
+	oidSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID = "1.3.6.1.6.3.1.1.4.1.0"
+)
+
+type metrics struct {
+	received  prometheus.Counter
+	invalid   prometheus.Counter
+	unmatched prometheus.Counter
+	alerts    *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		received: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_snmp_traps_received_total",
+			Help: "The total number of SNMP traps received.",
+		}),
+		invalid: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_snmp_traps_invalid_total",
+			Help: "The total number of received SNMP traps that could not be decoded or authenticated.",
+		}),
+		unmatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_snmp_traps_unmatched_total",
+			Help: "The total number of received SNMP traps that matched no rule.",
+		}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_snmp_trap_alerts_total",
+			Help: "The total number of alerts created from SNMP traps by status.",
+		}, []string{"status"}),
+	}
+	if r != nil {
+		r.MustRegister(m.received, m.invalid, m.unmatched, m.alerts)
+	}
+	return m
+}
+
+// Trap is a decoded SNMP trap.
+type Trap struct {
+	// OID is the value of the snmpTrapOID.0 variable binding.
+	OID      string
+	VarBinds []VarBind
+}
+
+// VarBind is a variable binding of a trap.
+type VarBind struct {
+	OID   string
+	Value string
+}
+
+// Listener receives SNMP traps over UDP and converts those matching a rule
+// of the SNMP trap configuration into alerts.
+type Listener struct {
+	alerts  provider.Alerts
+	logger  log.Logger
+	metrics *metrics
+
+	mtx            sync.RWMutex
+	conf           *config.SNMPTrapConfig
+	resolveTimeout time.Duration
+	keys           *keyCache
+	// engines outlives configuration reloads, so that messages cannot be
+	// replayed after a reload.
+	engines *engineTimes
+}
+
+// New returns a new Listener inserting alerts into the given provider.
+func New(ap provider.Alerts, r prometheus.Registerer, l log.Logger) *Listener {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	return &Listener{
+		alerts:  ap,
+		logger:  l,
+		metrics: newMetrics(r),
+		keys:    newKeyCache(),
+		engines: newEngineTimes(),
+	}
+}
+
+// ApplyConfig updates the accepted credentials and the conversion rules of
+// the listener.
+func (l *Listener) ApplyConfig(c *config.Config) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.conf = c.SNMPTraps
+	l.resolveTimeout = time.Duration(c.Global.ResolveTimeout)
+	l.keys = newKeyCache()
+}
+
+// ListenAndServe receives traps on the given UDP address.
+func (l *Listener) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return l.Serve(conn)
+}
+
+// Serve receives traps on the connection until it is closed.
+func (l *Listener) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if err := l.receive(buf[:n], addr); err != nil {
+			level.Warn(l.logger).Log("msg", "Failed to process SNMP trap", "source", addr, "err", err)
+		}
+	}
+}
+
+func (l *Listener) receive(msg []byte, addr net.Addr) error {
+	l.metrics.received.Inc()
+
+	l.mtx.RLock()
+	conf, keys := l.conf, l.keys
+	l.mtx.RUnlock()
+
+	if conf == nil {
+		return fmt.Errorf("no snmp traps config")
+	}
+	trap, err := decode(msg, conf, keys, l.engines)
+	if err != nil {
+		l.metrics.invalid.Inc()
+		return err
+	}
+
+	source := addr.String()
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	alert, err := l.Convert(trap, source)
+	if err != nil {
+		return err
+	}
+	if alert == nil {
+		l.metrics.unmatched.Inc()
+		return nil
+	}
+	if alert.Resolved() {
+		l.metrics.alerts.WithLabelValues(string(model.AlertResolved)).Inc()
+	} else {
+		l.metrics.alerts.WithLabelValues(string(model.AlertFiring)).Inc()
+	}
+	return l.alerts.Put(alert)
+}
+
+// Convert returns the alert created by the first rule matching the trap or
+// nil if no rule matches.
+func (l *Listener) Convert(trap *Trap, source string) (*types.Alert, error) {
+	l.mtx.RLock()
+	conf, resolveTimeout := l.conf, l.resolveTimeout
+	l.mtx.RUnlock()
+
+	if conf == nil {
+		return nil, nil
+	}
+
+	for _, r := range conf.Rules {
+		resolved := r.ResolveOID != "" && trap.OID == string(r.ResolveOID)
+		if trap.OID != string(r.TrapOID) && !resolved {
+			continue
+		}
+
+		now := time.Now()
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{},
+				Annotations: model.LabelSet{},
+				StartsAt:    now,
+			},
+			UpdatedAt: now,
+		}
+		vars := map[string]string{
+			"trap_oid": trap.OID,
+			"source":   source,
+		}
+		for _, vb := range trap.VarBinds {
+			for prefix, ln := range r.VarBinds {
+				if vb.OID == string(prefix) || strings.HasPrefix(vb.OID, string(prefix)+".") {
+					alert.Labels[ln] = model.LabelValue(vb.Value)
+					vars[string(ln)] = vb.Value
+				}
+			}
+		}
+		for k, v := range r.Labels {
+			if v = expand(v, vars); v != "" {
+				alert.Labels[model.LabelName(k)] = model.LabelValue(v)
+			}
+		}
+		for k, v := range r.Annotations {
+			alert.Annotations[model.LabelName(k)] = model.LabelValue(expand(v, vars))
+		}
+
+		if resolved {
+			alert.EndsAt = now
+		} else {
+			alert.EndsAt = now.Add(resolveTimeout)
+			alert.Timeout = true
+		}
+		if err := alert.Validate(); err != nil {
+			return nil, err
+		}
+		return alert, nil
+	}
+	return nil, nil
+}
+
+func expand(s string, vars map[string]string) string {
+	return strings.TrimSpace(os.Expand(s, func(k string) string { return vars[k] }))
+}
+
+// decode decodes and authenticates an SNMP message containing a trap.
+func decode(msg []byte, conf *config.SNMPTrapConfig, keys *keyCache, engines *engineTimes) (*Trap, error) {
+	body, _, err := expect(msg, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	v, body, err := expect(body, tagInteger)
+	if err != nil {
+		return nil, err
+	}
+	version, err := decodeInt(v)
+	if err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case 1:
+		community, body, err := expect(body, tagOctetString)
+		if err != nil {
+			return nil, err
+		}
+		if !validCommunity(conf.Communities, community) {
+			return nil, fmt.Errorf("unknown community")
+		}
+		return decodePDU(body)
+	case 3:
+		scopedPDU, err := decodeUSM(msg, body, conf.Users, keys, engines)
+		if err != nil {
+			return nil, err
+		}
+		sp, _, err := expect(scopedPDU, tagSequence)
+		if err != nil {
+			return nil, err
+		}
+		// Skip contextEngineID and contextName.
+		for i := 0; i < 2; i++ {
+			if _, sp, err = expect(sp, tagOctetString); err != nil {
+				return nil, err
+			}
+		}
+		return decodePDU(sp)
+	}
+	return nil, fmt.Errorf("unsupported SNMP version %d", version)
+}
+
+func validCommunity(communities []config.Secret, community []byte) bool {
+	for _, c := range communities {
+		if subtle.ConstantTimeCompare([]byte(c), community) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodePDU decodes an SNMPv2-Trap-PDU.
+func decodePDU(b []byte) (*Trap, error) {
+	pdu, _, err := expect(b, tagTrapV2)
+	if err != nil {
+		return nil, err
+	}
+	// Skip request-id, error-status and error-index.
+	for i := 0; i < 3; i++ {
+		if _, pdu, err = expect(pdu, tagInteger); err != nil {
+			return nil, err
+		}
+	}
+	vbs, _, err := expect(pdu, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	trap := &Trap{}
+	for len(vbs) > 0 {
+		var vb []byte
+		if vb, vbs, err = expect(vbs, tagSequence); err != nil {
+			return nil, err
+		}
+		o, vb, err := expect(vb, tagOID)
+		if err != nil {
+			return nil, err
+		}
+		oid, err := decodeOID(o)
+		if err != nil {
+			return nil, err
+		}
+		v, _, err := next(vb)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		switch oid {
+		case oidSysUpTime:
+		case oidSnmpTrapOID:
+			trap.OID = value
+		default:
+			trap.VarBinds = append(trap.VarBinds, VarBind{OID: oid, Value: value})
+		}
+	}
+	if trap.OID == "" {
+		return nil, fmt.Errorf("missing snmpTrapOID.0 variable binding")
+	}
+	return trap, nil
+}
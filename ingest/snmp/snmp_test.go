@@ -0,0 +1,382 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snmp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const testConfig = `
+global:
+  resolve_timeout: 10m
+route:
+  receiver: default
+receivers:
+- name: default
+snmp_traps:
+  communities: [public]
+  users:
+  - name: noauth
+  - name: authmd5
+    auth_protocol: MD5
+    auth_password: authpassword
+    engine_ids: ['80001F880474657374']
+  - name: privaes
+    auth_protocol: SHA
+    auth_password: authpassword
+    priv_protocol: AES
+    priv_password: privpassword
+    engine_ids: ['80001F880474657374']
+  - name: privdes
+    auth_protocol: MD5
+    auth_password: authpassword
+    priv_protocol: DES
+    priv_password: privpassword
+    engine_ids: ['80001F880474657374']
+  rules:
+  - trap_oid: .1.3.6.1.6.3.1.1.5.3
+    resolve_oid: .1.3.6.1.6.3.1.1.5.4
+    varbinds:
+      1.3.6.1.2.1.2.2.1.1: ifIndex
+      1.3.6.1.2.1.2.2.1.2: ifDescr
+    labels:
+      alertname: LinkDown
+      instance: $source
+    annotations:
+      summary: Interface $ifDescr is down
+`
+
+const (
+	oidLinkDown = "1.3.6.1.6.3.1.1.5.3"
+	oidLinkUp   = "1.3.6.1.6.3.1.1.5.4"
+)
+
+func newListener(t *testing.T) (*Listener, *mem.Alerts) {
+	conf, err := config.Load(testConfig)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	l := New(alerts, nil, nil)
+	l.ApplyConfig(conf)
+	return l, alerts
+}
+
+func ber(tag byte, parts ...[]byte) []byte {
+	v := bytes.Join(parts, nil)
+	switch l := len(v); {
+	case l < 0x80:
+		return append([]byte{tag, byte(l)}, v...)
+	case l < 0x100:
+		return append([]byte{tag, 0x81, byte(l)}, v...)
+	default:
+		return append([]byte{tag, 0x82, byte(l >> 8), byte(l)}, v...)
+	}
+}
+
+func berInt(i int) []byte {
+	return ber(tagInteger, []byte{byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)})
+}
+
+func berOID(oid string) []byte {
+	var arcs []uint64
+	for _, s := range strings.Split(oid, ".") {
+		a, _ := strconv.ParseUint(s, 10, 64)
+		arcs = append(arcs, a)
+	}
+	b := []byte{byte(arcs[0]*40 + arcs[1])}
+	for _, a := range arcs[2:] {
+		var enc []byte
+		for enc = []byte{byte(a & 0x7f)}; a >= 0x80; {
+			a >>= 7
+			enc = append([]byte{byte(a&0x7f) | 0x80}, enc...)
+		}
+		b = append(b, enc...)
+	}
+	return ber(tagOID, b)
+}
+
+func trapPDU(trapOID string) []byte {
+	return ber(tagTrapV2, berInt(1), berInt(0), berInt(0), ber(tagSequence,
+		ber(tagSequence, berOID(oidSysUpTime), ber(tagTimeTicks, []byte{0x01, 0x00})),
+		ber(tagSequence, berOID(oidSnmpTrapOID), berOID(trapOID)),
+		ber(tagSequence, berOID("1.3.6.1.2.1.2.2.1.1.3"), berInt(3)),
+		ber(tagSequence, berOID("1.3.6.1.2.1.2.2.1.2.3"), ber(tagOctetString, []byte("eth0"))),
+	))
+}
+
+func v2cMessage(community, trapOID string) []byte {
+	return ber(tagSequence, berInt(1), ber(tagOctetString, []byte(community)), trapPDU(trapOID))
+}
+
+var (
+	testEngineID = []byte{0x80, 0x00, 0x1f, 0x88, 0x04, 't', 'e', 's', 't'}
+	testSalt     = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+)
+
+// v3Message returns an SNMPv3 trap message of the user. Authentication and
+// encryption are applied according to the user's protocols.
+func v3Message(t *testing.T, u *config.SNMPUser, authPassword string) []byte {
+	return v3MessageFrom(t, u, authPassword, testEngineID, 7, 1234)
+}
+
+// v3MessageFrom returns an SNMPv3 trap message of the user sent by the
+// engine with the given boots and time.
+func v3MessageFrom(t *testing.T, u *config.SNMPUser, authPassword string, engineID []byte, boots, engineTime int) []byte {
+	scoped := ber(tagSequence, ber(tagOctetString, engineID), ber(tagOctetString), trapPDU(oidLinkDown))
+
+	var (
+		flags      byte
+		authParams []byte
+		privParams []byte
+		msgData    = scoped
+	)
+	if u.AuthProtocol != "" {
+		flags |= flagAuth
+		authParams = make([]byte, authParamsLen)
+	}
+	if u.PrivProtocol != "" {
+		flags |= flagPriv
+		privParams = testSalt
+		key := localizeKey(hashFunc(u.AuthProtocol), string(u.PrivPassword), engineID)
+
+		switch u.PrivProtocol {
+		case "AES":
+			block, err := aes.NewCipher(key[:16])
+			require.NoError(t, err)
+			iv := make([]byte, aes.BlockSize)
+			binary.BigEndian.PutUint32(iv[0:], uint32(boots))
+			binary.BigEndian.PutUint32(iv[4:], uint32(engineTime))
+			copy(iv[8:], testSalt)
+			enc := make([]byte, len(scoped))
+			cipher.NewCFBEncrypter(block, iv).XORKeyStream(enc, scoped)
+			msgData = ber(tagOctetString, enc)
+		case "DES":
+			block, err := des.NewCipher(key[:8])
+			require.NoError(t, err)
+			iv := make([]byte, des.BlockSize)
+			for i := range iv {
+				iv[i] = key[8+i] ^ testSalt[i]
+			}
+			padded := append(scoped, make([]byte, des.BlockSize-len(scoped)%des.BlockSize)...)
+			enc := make([]byte, len(padded))
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(enc, padded)
+			msgData = ber(tagOctetString, enc)
+		}
+	}
+
+	usm := ber(tagSequence,
+		ber(tagOctetString, engineID),
+		berInt(boots),
+		berInt(engineTime),
+		ber(tagOctetString, []byte(u.Name)),
+		ber(tagOctetString, authParams),
+		ber(tagOctetString, privParams),
+	)
+	msg := ber(tagSequence,
+		berInt(3),
+		ber(tagSequence, berInt(42), berInt(65507), ber(tagOctetString, []byte{flags}), berInt(usmSecurityModel)),
+		ber(tagOctetString, usm),
+		msgData,
+	)
+
+	if u.AuthProtocol != "" {
+		h := hashFunc(u.AuthProtocol)
+		mac := hmac.New(h, localizeKey(h, authPassword, engineID))
+		mac.Write(msg)
+		placeholder := ber(tagOctetString, make([]byte, authParamsLen))
+		i := bytes.Index(msg, placeholder)
+		copy(msg[i+2:], mac.Sum(nil)[:authParamsLen])
+	}
+	return msg
+}
+
+func TestLocalizeKey(t *testing.T) {
+	// Test vectors of RFC 3414, Appendix A.3.
+	engineID := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2}
+	require.Equal(t, "526f5eed9fcce26f8964c2930787d82b",
+		hex.EncodeToString(localizeKey(hashFunc("MD5"), "maplesyrup", engineID)))
+	require.Equal(t, "6695febc9288e36282235fc7151f128497b38f3f",
+		hex.EncodeToString(localizeKey(hashFunc("SHA"), "maplesyrup", engineID)))
+}
+
+func TestDecode(t *testing.T) {
+	l, _ := newListener(t)
+	users := map[string]*config.SNMPUser{}
+	for _, u := range l.conf.Users {
+		users[u.Name] = u
+	}
+
+	for _, tc := range []struct {
+		name string
+		msg  []byte
+		err  bool
+	}{
+		{name: "v2c", msg: v2cMessage("public", oidLinkDown)},
+		{name: "v2c unknown community", msg: v2cMessage("private", oidLinkDown), err: true},
+		{name: "v3 noAuthNoPriv", msg: v3Message(t, users["noauth"], "")},
+		{name: "v3 authNoPriv", msg: v3Message(t, users["authmd5"], "authpassword")},
+		{name: "v3 authNoPriv wrong password", msg: v3Message(t, users["authmd5"], "wrongpassword"), err: true},
+		{name: "v3 authPriv AES", msg: v3Message(t, users["privaes"], "authpassword")},
+		{name: "v3 authPriv DES", msg: v3Message(t, users["privdes"], "authpassword")},
+		{name: "v3 unknown user", msg: v3Message(t, &config.SNMPUser{Name: "unknown"}, ""), err: true},
+		{
+			name: "v3 unknown engine",
+			msg:  v3MessageFrom(t, users["authmd5"], "authpassword", []byte{0x80, 0, 0x1f, 0x88, 4, 'o', 't', 'h', 'e', 'r'}, 7, 1234),
+			err:  true,
+		},
+		{
+			name: "v3 engine boots exhausted",
+			msg:  v3MessageFrom(t, users["authmd5"], "authpassword", testEngineID, maxEngineBoots, 1234),
+			err:  true,
+		},
+		{
+			name: "v3 insufficient security level",
+			msg:  v3Message(t, &config.SNMPUser{Name: "privaes", AuthProtocol: "SHA"}, "authpassword"),
+			err:  true,
+		},
+		{name: "truncated", msg: v2cMessage("public", oidLinkDown)[:20], err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			trap, err := decode(tc.msg, l.conf, l.keys, l.engines)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &Trap{
+				OID: oidLinkDown,
+				VarBinds: []VarBind{
+					{OID: "1.3.6.1.2.1.2.2.1.1.3", Value: "3"},
+					{OID: "1.3.6.1.2.1.2.2.1.2.3", Value: "eth0"},
+				},
+			}, trap)
+		})
+	}
+}
+
+func TestDecodeTimeliness(t *testing.T) {
+	l, _ := newListener(t)
+	u := l.conf.Users[1]
+	now := time.Now()
+	l.engines.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		name              string
+		boots, engineTime int
+		elapsed           time.Duration
+		err               bool
+	}{
+		{name: "first message", boots: 7, engineTime: 1000},
+		{name: "same message again", boots: 7, engineTime: 1000},
+		{name: "within time window", boots: 7, engineTime: 900},
+		{name: "outside of time window", boots: 7, engineTime: 849, err: true},
+		{name: "previous boots", boots: 6, engineTime: 5000, err: true},
+		{name: "time window advances", boots: 7, engineTime: 1100, elapsed: 5 * time.Minute, err: true},
+		{name: "later message", boots: 7, engineTime: 1300},
+		{name: "reboot", boots: 8, engineTime: 1},
+		{name: "before reboot", boots: 7, engineTime: 1400, err: true},
+	} {
+		now = now.Add(tc.elapsed)
+		_, err := decode(v3MessageFrom(t, u, "authpassword", testEngineID, tc.boots, tc.engineTime), l.conf, l.keys, l.engines)
+		if tc.err {
+			require.Equal(t, errNotInTimeWindow, err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	l, _ := newListener(t)
+
+	trap := &Trap{
+		OID: oidLinkDown,
+		VarBinds: []VarBind{
+			{OID: "1.3.6.1.2.1.2.2.1.1.3", Value: "3"},
+			{OID: "1.3.6.1.2.1.2.2.1.2.3", Value: "eth0"},
+			{OID: "1.3.6.1.2.1.2.2.1.7.3", Value: "1"},
+		},
+	}
+	alert, err := l.Convert(trap, "192.0.2.1")
+	require.NoError(t, err)
+	require.NotNil(t, alert)
+	require.Equal(t, model.LabelSet{
+		"alertname": "LinkDown",
+		"instance":  "192.0.2.1",
+		"ifIndex":   "3",
+		"ifDescr":   "eth0",
+	}, alert.Labels)
+	require.Equal(t, model.LabelSet{"summary": "Interface eth0 is down"}, alert.Annotations)
+	require.True(t, alert.Timeout)
+	require.False(t, alert.Resolved())
+
+	trap.OID = oidLinkUp
+	resolved, err := l.Convert(trap, "192.0.2.1")
+	require.NoError(t, err)
+	require.NotNil(t, resolved)
+	require.Equal(t, alert.Labels, resolved.Labels)
+	require.True(t, resolved.Resolved())
+
+	trap.OID = "1.3.6.1.4.1.9999.1"
+	alert, err = l.Convert(trap, "192.0.2.1")
+	require.NoError(t, err)
+	require.Nil(t, alert)
+}
+
+func TestServe(t *testing.T) {
+	l, alerts := newListener(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	go l.Serve(conn)
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write(v2cMessage("public", oidLinkDown))
+	require.NoError(t, err)
+
+	var got []*types.Alert
+	for i := 0; i < 100 && len(got) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		it := alerts.GetPending()
+		for a := range it.Next() {
+			got = append(got, a)
+		}
+		it.Close()
+	}
+	require.Len(t, got, 1)
+	require.Equal(t, model.LabelValue("127.0.0.1"), got[0].Labels["instance"])
+}
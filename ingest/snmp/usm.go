@@ -0,0 +1,308 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snmp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+const (
+	usmSecurityModel = 3
+	authParamsLen    = 12
+
+	flagAuth = 0x01
+	flagPriv = 0x02
+
+	// timeWindow is the number of seconds the engine time of a message may
+	// lag behind the notion of the time of its engine, RFC 3414, Section
+	// 2.2.3.
+	timeWindow = 150
+	// maxEngineBoots is the maximum of the boots of an engine, which must
+	// be reconfigured once it is reached.
+	maxEngineBoots = 2147483647
+)
+
+var errNotInTimeWindow = errors.New("message not in time window")
+
+// decodeUSM authenticates and decrypts an SNMPv3 message according to the
+// user-based security model (RFC 3414, RFC 3826) and returns its scoped PDU.
+// Traps are sent by the authoritative engine, so the notion of its time is
+// taken from the authenticated messages themselves.
+func decodeUSM(msg, body []byte, users []*config.SNMPUser, keys *keyCache, engines *engineTimes) ([]byte, error) {
+	header, body, err := expect(body, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	// Skip msgID and msgMaxSize.
+	for i := 0; i < 2; i++ {
+		if _, header, err = expect(header, tagInteger); err != nil {
+			return nil, err
+		}
+	}
+	flags, header, err := expect(header, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) != 1 {
+		return nil, fmt.Errorf("invalid message flags")
+	}
+	sm, _, err := expect(header, tagInteger)
+	if err != nil {
+		return nil, err
+	}
+	if sec, err := decodeInt(sm); err != nil || sec != usmSecurityModel {
+		return nil, fmt.Errorf("unsupported security model")
+	}
+
+	secParams, body, err := expect(body, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	usm, _, err := expect(secParams, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	var fields [6][]byte
+	for i, tag := range []byte{tagOctetString, tagInteger, tagInteger, tagOctetString, tagOctetString, tagOctetString} {
+		if fields[i], usm, err = expect(usm, tag); err != nil {
+			return nil, err
+		}
+	}
+	engineID, boots, engineTime, userName, authParams, privParams := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	var user *config.SNMPUser
+	for _, u := range users {
+		if u.Name == string(userName) {
+			user = u
+			break
+		}
+	}
+	if user == nil {
+		return nil, fmt.Errorf("unknown user %q", userName)
+	}
+
+	auth, priv := flags[0]&flagAuth != 0, flags[0]&flagPriv != 0
+	if auth != (user.AuthProtocol != "") || priv != (user.PrivProtocol != "") {
+		return nil, fmt.Errorf("unexpected security level for user %q", user.Name)
+	}
+	// Keys are only localized to configured engines, so that senders
+	// cannot make the expensive derivation run for arbitrary engine IDs.
+	if (auth || len(user.EngineIDs) > 0) && !knownEngine(user, engineID) {
+		return nil, fmt.Errorf("unknown engine ID %x for user %q", engineID, user.Name)
+	}
+	if !auth {
+		return body, nil
+	}
+
+	h := hashFunc(user.AuthProtocol)
+	authKey := keys.get(user.Name+"/auth", engineID, func() []byte {
+		return localizeKey(h, string(user.AuthPassword), engineID)
+	})
+	if len(authParams) != authParamsLen {
+		return nil, fmt.Errorf("invalid authentication parameters")
+	}
+	// The MAC is computed over the whole message with the authentication
+	// parameters zeroed. As authParams is a sub-slice of msg, its offset
+	// follows from the capacities.
+	off := cap(msg) - cap(authParams)
+	zeroed := append([]byte(nil), msg...)
+	copy(zeroed[off:off+authParamsLen], make([]byte, authParamsLen))
+	mac := hmac.New(h, authKey)
+	mac.Write(zeroed)
+	if !hmac.Equal(mac.Sum(nil)[:authParamsLen], authParams) {
+		return nil, fmt.Errorf("authentication failed for user %q", user.Name)
+	}
+
+	b, err := decodeUint(boots)
+	if err != nil {
+		return nil, err
+	}
+	t, err := decodeUint(engineTime)
+	if err != nil {
+		return nil, err
+	}
+	if err := engines.check(engineID, b, t); err != nil {
+		return nil, err
+	}
+	if !priv {
+		return body, nil
+	}
+
+	encrypted, _, err := expect(body, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	privKey := keys.get(user.Name+"/priv", engineID, func() []byte {
+		return localizeKey(h, string(user.PrivPassword), engineID)
+	})
+	if len(privParams) != 8 {
+		return nil, fmt.Errorf("invalid privacy parameters")
+	}
+	plain := make([]byte, len(encrypted))
+
+	switch user.PrivProtocol {
+	case "DES":
+		if len(encrypted)%des.BlockSize != 0 {
+			return nil, fmt.Errorf("invalid encrypted PDU length")
+		}
+		block, err := des.NewCipher(privKey[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, des.BlockSize)
+		for i := range iv {
+			iv[i] = privKey[8+i] ^ privParams[i]
+		}
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, encrypted)
+	case "AES":
+		block, err := aes.NewCipher(privKey[:16])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint32(iv[0:], uint32(b))
+		binary.BigEndian.PutUint32(iv[4:], uint32(t))
+		copy(iv[8:], privParams)
+		cipher.NewCFBDecrypter(block, iv).XORKeyStream(plain, encrypted)
+	}
+	return plain, nil
+}
+
+// knownEngine returns whether the engine ID is one of the configured engine
+// IDs of the user.
+func knownEngine(user *config.SNMPUser, engineID []byte) bool {
+	id := hex.EncodeToString(engineID)
+	for _, e := range user.EngineIDs {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+func hashFunc(protocol string) func() hash.Hash {
+	if protocol == "SHA" {
+		return sha1.New
+	}
+	return md5.New
+}
+
+// localizeKey derives the key of a password localized to an SNMP engine as
+// described in RFC 3414, Appendix A.2.
+func localizeKey(h func() hash.Hash, password string, engineID []byte) []byte {
+	d := h()
+	buf := make([]byte, 64)
+	for i := 0; i < 1<<20; i += len(buf) {
+		for j := range buf {
+			buf[j] = password[(i+j)%len(password)]
+		}
+		d.Write(buf)
+	}
+	ku := d.Sum(nil)
+
+	d.Reset()
+	d.Write(ku)
+	d.Write(engineID)
+	d.Write(ku)
+	return d.Sum(nil)
+}
+
+// keyCache caches localized keys as deriving them is expensive. It holds
+// the keys of the configured engines only.
+type keyCache struct {
+	mtx  sync.Mutex
+	keys map[string][]byte
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{keys: map[string][]byte{}}
+}
+
+func (c *keyCache) get(name string, engineID []byte, derive func() []byte) []byte {
+	k := name + "/" + string(engineID)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if key, ok := c.keys[k]; ok {
+		return key
+	}
+	key := derive()
+	c.keys[k] = key
+	return key
+}
+
+// engineTime is the notion of the time of an authoritative engine.
+type engineTime struct {
+	boots uint64
+	// latest is the latest engine time received, which was received at
+	// the local time.
+	latest uint64
+	at     time.Time
+}
+
+// engineTimes holds the notion of the time of the engines sending traps to
+// reject replayed messages.
+type engineTimes struct {
+	mtx     sync.Mutex
+	now     func() time.Time
+	engines map[string]*engineTime
+}
+
+func newEngineTimes() *engineTimes {
+	return &engineTimes{now: time.Now, engines: map[string]*engineTime{}}
+}
+
+// check returns an error if an authenticated message with the engine boots
+// and time is outside the time window of the engine. Otherwise the notion
+// of the time of the engine is updated, RFC 3414, Section 3.2.7 b.
+func (e *engineTimes) check(engineID []byte, boots, t uint64) error {
+	if boots >= maxEngineBoots {
+		return errNotInTimeWindow
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	now := e.now()
+	et, ok := e.engines[string(engineID)]
+	if ok {
+		// The time of the engine advances with the local time.
+		current := et.latest
+		if elapsed := now.Sub(et.at); elapsed > 0 {
+			current += uint64(elapsed / time.Second)
+		}
+		if boots < et.boots || (boots == et.boots && t+timeWindow < current) {
+			return errNotInTimeWindow
+		}
+	}
+	if !ok || boots > et.boots || (boots == et.boots && t > et.latest) {
+		e.engines[string(engineID)] = &engineTime{boots: boots, latest: t, at: now}
+	}
+	return nil
+}
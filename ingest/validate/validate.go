@@ -0,0 +1,265 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validate rejects received alerts that break the configured
+// validation rules before they are routed.
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Reasons for rejecting alerts.
+const (
+	reasonInvalid                = "invalid"
+	reasonInvalidUTF8            = "invalid_utf8"
+	reasonTooManyLabels          = "too_many_labels"
+	reasonLabelValueTooLong      = "label_value_too_long"
+	reasonAnnotationValueTooLong = "annotation_value_too_long"
+	reasonMissingRequiredLabel   = "missing_required_label"
+)
+
+type metrics struct {
+	rejected   *prometheus.CounterVec
+	normalized prometheus.Counter
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_rejected_total",
+			Help: "The total number of received alerts rejected by the alert validation by reason.",
+		}, []string{"reason"}),
+		normalized: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_utf8_normalized_total",
+			Help: "The total number of received alerts whose labels or annotations with invalid UTF-8 were dropped or normalized.",
+		}),
+	}
+	for _, reason := range []string{
+		reasonInvalid,
+		reasonInvalidUTF8,
+		reasonTooManyLabels,
+		reasonLabelValueTooLong,
+		reasonAnnotationValueTooLong,
+		reasonMissingRequiredLabel,
+	} {
+		m.rejected.WithLabelValues(reason)
+	}
+	if r != nil {
+		r.MustRegister(m.rejected, m.normalized)
+	}
+	return m
+}
+
+// quarantineEntry is the record of a rejected alert in the quarantine log.
+type quarantineEntry struct {
+	Time   time.Time    `json:"time"`
+	Reason string       `json:"reason"`
+	Error  string       `json:"error"`
+	Alert  *model.Alert `json:"alert"`
+}
+
+// Validator is a provider.Alerts that checks alerts against the configured
+// alert validation before putting them into the wrapped provider. Rejected
+// alerts are counted by reason and written to the quarantine log as JSON
+// lines if there is one.
+type Validator struct {
+	provider.Alerts
+
+	logger  log.Logger
+	metrics *metrics
+
+	mtx  sync.Mutex
+	conf *config.AlertValidationConfig
+
+	quarantineMtx sync.Mutex
+	quarantine    *json.Encoder
+}
+
+// New returns a new Validator putting alerts into the given provider and
+// writing rejected alerts to the quarantine writer, which may be nil.
+func New(ap provider.Alerts, quarantine io.Writer, r prometheus.Registerer, l log.Logger) *Validator {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	v := &Validator{
+		Alerts:  ap,
+		logger:  l,
+		metrics: newMetrics(r),
+	}
+	if quarantine != nil {
+		v.quarantine = json.NewEncoder(quarantine)
+	}
+	return v
+}
+
+// ApplyConfig replaces the validation rules with the configured ones.
+func (v *Validator) ApplyConfig(c *config.Config) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	v.conf = c.AlertValidation
+}
+
+// Put puts the alerts passing Check into the wrapped provider. Rejected
+// alerts are logged and only errors of the wrapped provider are returned,
+// so that rejected alerts never keep the others from being put.
+func (v *Validator) Put(alerts ...*types.Alert) error {
+	valid := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if err := v.Check(a); err != nil {
+			level.Warn(v.logger).Log("msg", "Rejecting invalid alert", "alert", a, "err", err)
+			continue
+		}
+		valid = append(valid, a)
+	}
+	return v.Alerts.Put(valid...)
+}
+
+// Check drops or normalizes the labels and annotations of the alert with
+// invalid UTF-8 as configured and returns an error if the alert is invalid
+// or breaks a validation rule. Rejected alerts are counted and quarantined.
+func (v *Validator) Check(a *types.Alert) error {
+	v.mtx.Lock()
+	conf := v.conf
+	v.mtx.Unlock()
+
+	reason, err := v.check(conf, a)
+	if err != nil {
+		v.metrics.rejected.WithLabelValues(reason).Inc()
+		v.quarantineAlert(a, reason, err)
+	}
+	return err
+}
+
+func (v *Validator) check(conf *config.AlertValidationConfig, a *types.Alert) (string, error) {
+	if conf != nil && conf.InvalidUTF8 != config.InvalidUTF8Reject {
+		drop := conf.InvalidUTF8 == config.InvalidUTF8Drop
+		labels, annotations := normalize(a.Labels, drop), normalize(a.Annotations, drop)
+		if labels || annotations {
+			v.metrics.normalized.Inc()
+		}
+	}
+	if ln, ok := invalidUTF8(a.Labels); ok {
+		return reasonInvalidUTF8, fmt.Errorf("invalid UTF-8 in value of label %q", ln)
+	}
+	if an, ok := invalidUTF8(a.Annotations); ok {
+		return reasonInvalidUTF8, fmt.Errorf("invalid UTF-8 in value of annotation %q", an)
+	}
+	if err := a.Validate(); err != nil {
+		return reasonInvalid, err
+	}
+	if conf == nil {
+		return "", nil
+	}
+
+	if conf.MaxLabels > 0 && len(a.Labels) > conf.MaxLabels {
+		return reasonTooManyLabels, fmt.Errorf("alert has %d labels, more than the limit of %d", len(a.Labels), conf.MaxLabels)
+	}
+	if ln, ok := tooLong(a.Labels, conf.MaxLabelValueLength); ok {
+		return reasonLabelValueTooLong, fmt.Errorf("value of label %q is longer than the limit of %d bytes", ln, conf.MaxLabelValueLength)
+	}
+	if an, ok := tooLong(a.Annotations, conf.MaxAnnotationValueLength); ok {
+		return reasonAnnotationValueTooLong, fmt.Errorf("value of annotation %q is longer than the limit of %d bytes", an, conf.MaxAnnotationValueLength)
+	}
+	for _, ln := range conf.RequiredLabels {
+		if a.Labels[ln] == "" {
+			return reasonMissingRequiredLabel, fmt.Errorf("missing required label %q", ln)
+		}
+	}
+	return "", nil
+}
+
+// quarantineAlert writes the rejected alert to the quarantine log.
+func (v *Validator) quarantineAlert(a *types.Alert, reason string, err error) {
+	if v.quarantine == nil {
+		return
+	}
+
+	v.quarantineMtx.Lock()
+	defer v.quarantineMtx.Unlock()
+
+	e := &quarantineEntry{
+		Time:   time.Now(),
+		Reason: reason,
+		Error:  err.Error(),
+		Alert:  &a.Alert,
+	}
+	if err := v.quarantine.Encode(e); err != nil {
+		level.Error(v.logger).Log("msg", "Writing rejected alert to the quarantine log failed", "err", err)
+	}
+}
+
+// normalize removes the values with invalid UTF-8 from the label set if drop
+// is set and replaces their invalid bytes with the Unicode replacement
+// character otherwise. It reports whether the label set changed.
+func normalize(ls model.LabelSet, drop bool) bool {
+	changed := false
+	for ln, lv := range ls {
+		if utf8.ValidString(string(lv)) {
+			continue
+		}
+		changed = true
+		if drop {
+			delete(ls, ln)
+			continue
+		}
+		var b strings.Builder
+		// Ranging over a string yields the replacement character for
+		// each invalid byte.
+		for _, r := range string(lv) {
+			b.WriteRune(r)
+		}
+		ls[ln] = model.LabelValue(b.String())
+	}
+	return changed
+}
+
+// invalidUTF8 returns a name of the label set whose value is not valid UTF-8.
+func invalidUTF8(ls model.LabelSet) (model.LabelName, bool) {
+	for ln, lv := range ls {
+		if !utf8.ValidString(string(lv)) {
+			return ln, true
+		}
+	}
+	return "", false
+}
+
+// tooLong returns a name of the label set whose value is longer than max
+// bytes. Zero means no limit.
+func tooLong(ls model.LabelSet, max int) (model.LabelName, bool) {
+	if max <= 0 {
+		return "", false
+	}
+	for ln, lv := range ls {
+		if len(lv) > max {
+			return ln, true
+		}
+	}
+	return "", false
+}
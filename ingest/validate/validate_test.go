@@ -0,0 +1,145 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func newValidator(t *testing.T, invalidUTF8 string, quarantine io.Writer) (*Validator, *mem.Alerts) {
+	conf, err := config.Load(`
+route:
+  receiver: default
+receivers:
+- name: default
+alert_validation:
+  max_labels: 3
+  max_label_value_length: 10
+  max_annotation_value_length: 20
+  required_labels: [severity]
+  invalid_utf8: ` + invalidUTF8 + `
+`)
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(types.NewMarker(), 30*time.Minute)
+	require.NoError(t, err)
+
+	v := New(alerts, quarantine, nil, nil)
+	v.ApplyConfig(conf)
+	return v, alerts
+}
+
+func newAlert(labels, annotations model.LabelSet) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    time.Now(),
+			EndsAt:      time.Now().Add(time.Hour),
+		},
+	}
+}
+
+func TestValidatorCheck(t *testing.T) {
+	v, _ := newValidator(t, config.InvalidUTF8Reject, nil)
+
+	for _, tc := range []struct {
+		alert  *types.Alert
+		reason string
+	}{
+		{
+			alert: newAlert(model.LabelSet{"alertname": "a", "severity": "page"}, model.LabelSet{"summary": "disk full"}),
+		},
+		{
+			alert:  newAlert(model.LabelSet{"alertname": "a", "severity": "page", "dc": "eu", "host": "a"}, nil),
+			reason: reasonTooManyLabels,
+		},
+		{
+			alert:  newAlert(model.LabelSet{"alertname": "a", "severity": "page-everyone"}, nil),
+			reason: reasonLabelValueTooLong,
+		},
+		{
+			alert:  newAlert(model.LabelSet{"alertname": "a", "severity": "page"}, model.LabelSet{"summary": "the disk of the host is full"}),
+			reason: reasonAnnotationValueTooLong,
+		},
+		{
+			alert:  newAlert(model.LabelSet{"alertname": "a"}, nil),
+			reason: reasonMissingRequiredLabel,
+		},
+		{
+			alert:  newAlert(model.LabelSet{"alertname": "a\xff", "severity": "page"}, nil),
+			reason: reasonInvalidUTF8,
+		},
+		{
+			alert:  &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a", "severity": "page"}}},
+			reason: reasonInvalid,
+		},
+	} {
+		reason, err := v.check(v.conf, tc.alert)
+		require.Equal(t, tc.reason, reason, "alert %v", tc.alert)
+		require.Equal(t, tc.reason == "", err == nil, "alert %v", tc.alert)
+	}
+}
+
+func TestValidatorNormalize(t *testing.T) {
+	v, _ := newValidator(t, config.InvalidUTF8Normalize, nil)
+
+	a := newAlert(model.LabelSet{"alertname": "a\xff", "severity": "page"}, model.LabelSet{"summary": "\xfe\xffdown"})
+	require.NoError(t, v.Check(a))
+	require.Equal(t, model.LabelValue("a�"), a.Labels["alertname"])
+	require.Equal(t, model.LabelValue("��down"), a.Annotations["summary"])
+
+	v, _ = newValidator(t, config.InvalidUTF8Drop, nil)
+
+	a = newAlert(model.LabelSet{"alertname": "a", "severity": "page", "host": "\xff"}, model.LabelSet{"summary": "\xffdown"})
+	require.NoError(t, v.Check(a))
+	require.Equal(t, model.LabelSet{"alertname": "a", "severity": "page"}, a.Labels)
+	require.Equal(t, model.LabelSet{}, a.Annotations)
+
+	// The required labels are checked after dropping.
+	a = newAlert(model.LabelSet{"alertname": "a", "severity": "\xff"}, nil)
+	require.Error(t, v.Check(a))
+}
+
+func TestValidatorPut(t *testing.T) {
+	var quarantine bytes.Buffer
+	v, alerts := newValidator(t, config.InvalidUTF8Reject, &quarantine)
+
+	valid := newAlert(model.LabelSet{"alertname": "a", "severity": "page"}, nil)
+	rejected := newAlert(model.LabelSet{"alertname": "b"}, nil)
+	require.NoError(t, v.Put(valid, rejected))
+
+	_, err := alerts.Get(valid.Fingerprint())
+	require.NoError(t, err)
+	_, err = alerts.Get(rejected.Fingerprint())
+	require.Equal(t, provider.ErrNotFound, err)
+
+	var e quarantineEntry
+	require.NoError(t, json.NewDecoder(&quarantine).Decode(&e))
+	require.Equal(t, reasonMissingRequiredLabel, e.Reason)
+	require.Equal(t, `missing required label "severity"`, e.Error)
+	require.Equal(t, rejected.Labels, e.Alert.Labels)
+}
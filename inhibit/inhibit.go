@@ -14,13 +14,19 @@
 package inhibit
 
 import (
+	"bytes"
 	"context"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/oklog/pkg/group"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/prometheus/alertmanager/config"
@@ -28,11 +34,23 @@ import (
 	"github.com/prometheus/alertmanager/types"
 )
 
+var evaluationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "alertmanager",
+	Name:      "inhibitor_evaluation_duration_seconds",
+	Help:      "Duration of the evaluations of the inhibition rules for an alert.",
+	Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 8),
+})
+
+func init() {
+	prometheus.Register(evaluationDuration)
+}
+
 // An Inhibitor determines whether a given label set is muted
 // based on the currently active alerts and a set of inhibition rules.
 type Inhibitor struct {
 	alerts provider.Alerts
 	rules  []*InhibitRule
+	confs  []*config.InhibitRule
 	marker types.Marker
 	logger log.Logger
 
@@ -44,6 +62,7 @@ type Inhibitor struct {
 func NewInhibitor(ap provider.Alerts, rs []*config.InhibitRule, mk types.Marker, logger log.Logger) *Inhibitor {
 	ih := &Inhibitor{
 		alerts: ap,
+		confs:  rs,
 		marker: mk,
 		logger: logger,
 	}
@@ -83,7 +102,9 @@ func (ih *Inhibitor) run(ctx context.Context) {
 			// Update the inhibition rules' cache.
 			for _, r := range ih.rules {
 				if r.SourceMatchers.Match(a.Labels) {
-					r.set(a)
+					if err := r.set(a); err != nil {
+						level.Error(ih.logger).Log("msg", "Error expanding target matchers of inhibition source", "alert", a.Name(), "err", err)
+					}
 				}
 			}
 		}
@@ -132,20 +153,101 @@ func (ih *Inhibitor) Stop() {
 	}
 }
 
-// Mutes returns true iff the given label set is muted.
+// Mutes returns true iff the given label set is muted by a rule that is not
+// scoped to receivers.
 func (ih *Inhibitor) Mutes(lset model.LabelSet) bool {
+	return ih.MutesReceiver("", lset)
+}
+
+// MutesReceiver returns true iff the given label set is muted for the
+// notifications of the receiver. Only rules that are not scoped to
+// receivers mark the alert as inhibited.
+func (ih *Inhibitor) MutesReceiver(receiver string, lset model.LabelSet) bool {
+	_, ok := ih.MutingRule(receiver, lset)
+	return ok
+}
+
+// MutingRule is like MutesReceiver but also returns the index of the first
+// rule muting the label set in the configuration.
+func (ih *Inhibitor) MutingRule(receiver string, lset model.LabelSet) (int, bool) {
+	start := time.Now()
+	defer func() {
+		evaluationDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	fp := lset.Fingerprint()
 
-	for _, r := range ih.rules {
-		// Only inhibit if target matchers match but source matchers don't.
-		if inhibitedByFP, eq := r.hasEqual(lset); !r.SourceMatchers.Match(lset) && r.TargetMatchers.Match(lset) && eq {
+	for i, r := range ih.rules {
+		if len(r.Receivers) > 0 {
+			continue
+		}
+		if inhibitedByFP, ok := r.mutes(lset); ok {
 			ih.marker.SetInhibited(fp, inhibitedByFP.String())
-			return true
+			return i, true
 		}
 	}
 	ih.marker.SetInhibited(fp)
 
-	return false
+	if receiver == "" {
+		return -1, false
+	}
+	for i, r := range ih.rules {
+		if _, ok := r.Receivers[receiver]; !ok {
+			continue
+		}
+		if _, ok := r.mutes(lset); ok {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// An Inhibition is an alert inhibited by a source alert through an
+// inhibition rule.
+type Inhibition struct {
+	Target *types.Alert
+	Source *types.Alert
+	Rule   *config.InhibitRule
+	// RuleIndex is the index of the rule in the configuration.
+	RuleIndex int
+}
+
+// Inhibitions returns the current inhibitions of the pending alerts, one
+// per inhibited alert and rule. Rules scoped to receivers are included.
+func (ih *Inhibitor) Inhibitions() []*Inhibition {
+	if ih == nil {
+		return nil
+	}
+
+	it := ih.alerts.GetPending()
+	defer it.Close()
+
+	var res []*Inhibition
+	for a := range it.Next() {
+		if a.Resolved() {
+			continue
+		}
+		for i, r := range ih.rules {
+			fp, ok := r.mutes(a.Labels)
+			if !ok {
+				continue
+			}
+			src, ok := r.source(fp)
+			if !ok {
+				continue
+			}
+			res = append(res, &Inhibition{
+				Target:    a,
+				Source:    src,
+				Rule:      ih.confs[i],
+				RuleIndex: i,
+			})
+		}
+	}
+	if err := it.Err(); err != nil {
+		level.Error(ih.logger).Log("msg", "Error iterating alerts", "err", err)
+	}
+	return res
 }
 
 // An InhibitRule specifies that a class of (source) alerts should inhibit
@@ -160,13 +262,27 @@ type InhibitRule struct {
 	// The set of Filters which define the group of target alerts (which are
 	// inhibited by the source alerts).
 	TargetMatchers types.Matchers
+	// Templates of regular expressions the target alerts have to match,
+	// expanded with the labels of each source alert.
+	TargetTemplates map[model.LabelName]*template.Template
 	// A set of label names whose label values need to be identical in source and
 	// target alerts in order for the inhibition to take effect.
 	Equal map[model.LabelName]struct{}
+	// The equal label names in sorted order.
+	equal model.LabelNames
+	// The receivers the rule is scoped to. It applies to all receivers if
+	// empty.
+	Receivers map[string]struct{}
 
 	mtx sync.RWMutex
 	// Cache of alerts matching source labels.
 	scache map[model.Fingerprint]*types.Alert
+	// Cache of the expanded target templates of the source alerts.
+	tcache map[model.Fingerprint]types.Matchers
+	// Index of the cached source alerts by the values of their equal labels,
+	// so that the source alerts possibly inhibiting a target alert are
+	// found without scanning the cache.
+	sindex map[string]map[model.Fingerprint]struct{}
 }
 
 // NewInhibitRule returns a new InihibtRule based on a configuration definition.
@@ -190,43 +306,166 @@ func NewInhibitRule(cr *config.InhibitRule) *InhibitRule {
 		targetm = append(targetm, types.NewRegexMatcher(model.LabelName(ln), lv.Regexp))
 	}
 
+	// The templates are validated when loading the configuration.
+	targett := map[model.LabelName]*template.Template{}
+	for ln, lv := range cr.TargetMatchRETemplate {
+		targett[model.LabelName(ln)] = template.Must(template.New(ln).Parse(lv))
+	}
+
 	equal := map[model.LabelName]struct{}{}
 	for _, ln := range cr.Equal {
 		equal[ln] = struct{}{}
 	}
+	equalNames := make(model.LabelNames, 0, len(equal))
+	for ln := range equal {
+		equalNames = append(equalNames, ln)
+	}
+	sort.Sort(equalNames)
+
+	receivers := map[string]struct{}{}
+	for _, name := range cr.Receivers {
+		receivers[name] = struct{}{}
+	}
 
 	return &InhibitRule{
-		SourceMatchers: sourcem,
-		TargetMatchers: targetm,
-		Equal:          equal,
-		scache:         map[model.Fingerprint]*types.Alert{},
+		SourceMatchers:  sourcem,
+		TargetMatchers:  targetm,
+		TargetTemplates: targett,
+		Equal:           equal,
+		equal:           equalNames,
+		Receivers:       receivers,
+		scache:          map[model.Fingerprint]*types.Alert{},
+		tcache:          map[model.Fingerprint]types.Matchers{},
+		sindex:          map[string]map[model.Fingerprint]struct{}{},
 	}
 }
 
 // set the alert in the source cache.
-func (r *InhibitRule) set(a *types.Alert) {
+func (r *InhibitRule) set(a *types.Alert) error {
+	ms, err := r.expandTargetTemplates(a.Labels)
+	if err != nil {
+		return err
+	}
+
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	r.scache[a.Fingerprint()] = a
+	r.cache(a.Fingerprint(), a, ms)
+	return nil
+}
+
+// cache adds the source alert with the fingerprint and its expanded target
+// matchers to the cache and the index. The caller must hold the lock.
+func (r *InhibitRule) cache(fp model.Fingerprint, a *types.Alert, ms types.Matchers) {
+	if prev, ok := r.scache[fp]; ok {
+		r.unindex(fp, prev)
+	}
+	r.scache[fp] = a
+	r.tcache[fp] = ms
+
+	key := r.equalKey(a.Labels)
+	fps, ok := r.sindex[key]
+	if !ok {
+		fps = map[model.Fingerprint]struct{}{}
+		r.sindex[key] = fps
+	}
+	fps[fp] = struct{}{}
+}
+
+// uncache removes the source alert with the fingerprint from the cache and
+// the index. The caller must hold the lock.
+func (r *InhibitRule) uncache(fp model.Fingerprint) {
+	if a, ok := r.scache[fp]; ok {
+		r.unindex(fp, a)
+	}
+	delete(r.scache, fp)
+	delete(r.tcache, fp)
+}
+
+func (r *InhibitRule) unindex(fp model.Fingerprint, a *types.Alert) {
+	key := r.equalKey(a.Labels)
+	delete(r.sindex[key], fp)
+	if len(r.sindex[key]) == 0 {
+		delete(r.sindex, key)
+	}
+}
+
+// equalKey returns the index key of the values of the equal labels in the
+// label set. Missing labels have the empty value, as in the comparison of
+// source and target alerts.
+func (r *InhibitRule) equalKey(lset model.LabelSet) string {
+	if len(r.equal) == 0 {
+		return ""
+	}
+	vals := make([]string, len(r.equal))
+	for i, ln := range r.equal {
+		vals[i] = string(lset[ln])
+	}
+	return strings.Join(vals, string([]byte{model.SeparatorByte}))
+}
+
+// source returns the source alert with the fingerprint from the cache.
+func (r *InhibitRule) source(fp model.Fingerprint) (*types.Alert, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	a, ok := r.scache[fp]
+	return a, ok
+}
+
+// expandTargetTemplates returns the target matchers of the templates
+// expanded with the labels of a source alert.
+func (r *InhibitRule) expandTargetTemplates(lset model.LabelSet) (types.Matchers, error) {
+	if len(r.TargetTemplates) == 0 {
+		return nil, nil
+	}
+	data := struct {
+		Labels map[string]string
+	}{
+		Labels: make(map[string]string, len(lset)),
+	}
+	for ln, lv := range lset {
+		data.Labels[string(ln)] = regexp.QuoteMeta(string(lv))
+	}
+
+	var ms types.Matchers
+	for ln, t := range r.TargetTemplates {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile("^(?:" + buf.String() + ")$")
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, types.NewRegexMatcher(ln, re))
+	}
+	return ms, nil
+}
+
+// mutes returns the fingerprint of the source alert inhibiting the given
+// label set, if any.
+func (r *InhibitRule) mutes(lset model.LabelSet) (model.Fingerprint, bool) {
+	// Only inhibit if target matchers match but source matchers don't.
+	if r.SourceMatchers.Match(lset) || !r.TargetMatchers.Match(lset) {
+		return model.Fingerprint(0), false
+	}
+	return r.hasEqual(lset)
 }
 
 // hasEqual checks whether the source cache contains alerts matching
-// the equal labels for the given label set.
+// the equal labels and expanded target templates for the given label set.
 func (r *InhibitRule) hasEqual(lset model.LabelSet) (model.Fingerprint, bool) {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
 
-Outer:
-	for fp, a := range r.scache {
+	for fp := range r.sindex[r.equalKey(lset)] {
 		// The cache might be stale and contain resolved alerts.
-		if a.Resolved() {
+		if r.scache[fp].Resolved() {
 			continue
 		}
-		for n := range r.Equal {
-			if a.Labels[n] != lset[n] {
-				continue Outer
-			}
+		if !r.tcache[fp].Match(lset) {
+			continue
 		}
 		return fp, true
 	}
@@ -240,7 +479,7 @@ func (r *InhibitRule) gc() {
 
 	for fp, a := range r.scache {
 		if a.Resolved() {
-			delete(r.scache, fp)
+			r.uncache(fp)
 		}
 	}
 }
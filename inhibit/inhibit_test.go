@@ -14,6 +14,7 @@
 package inhibit
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -120,15 +121,9 @@ func TestInhibitRuleHasEqual(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		r := &InhibitRule{
-			Equal:  map[model.LabelName]struct{}{},
-			scache: map[model.Fingerprint]*types.Alert{},
-		}
-		for _, ln := range c.equal {
-			r.Equal[ln] = struct{}{}
-		}
+		r := NewInhibitRule(&config.InhibitRule{Equal: c.equal})
 		for k, v := range c.initial {
-			r.scache[k] = v
+			r.cache(k, v, nil)
 		}
 
 		if _, have := r.hasEqual(c.input); have != c.result {
@@ -162,7 +157,7 @@ func TestInhibitRuleMatches(t *testing.T) {
 			EndsAt:   now.Add(time.Hour),
 		},
 	}
-	ir.scache = map[model.Fingerprint]*types.Alert{1: &sourceAlert}
+	ir.cache(1, &sourceAlert, nil)
 
 	cases := []struct {
 		target   model.LabelSet
@@ -202,6 +197,128 @@ func TestInhibitRuleMatches(t *testing.T) {
 	}
 }
 
+func TestInhibitRuleTargetTemplate(t *testing.T) {
+	t.Parallel()
+
+	// A datacenter-down alert inhibits all alerts of instances in the datacenter.
+	cr := config.InhibitRule{
+		SourceMatch:           map[string]string{"alertname": "DatacenterDown"},
+		TargetMatchRETemplate: map[string]string{"instance": "{{ .Labels.dc }}-.*"},
+	}
+	ih := NewInhibitor(nil, []*config.InhibitRule{&cr}, types.NewMarker(), nopLogger)
+	now := time.Now()
+	err := ih.rules[0].set(&types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "DatacenterDown", "dc": "eu.1"},
+			StartsAt: now.Add(-time.Minute),
+			EndsAt:   now.Add(time.Hour),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for target, expected := range map[model.LabelName]bool{
+		"eu.1-db1": true,
+		"eu.2-db1": false,
+		// The label value of the source is matched literally.
+		"euX1-db1": false,
+	} {
+		lset := model.LabelSet{"alertname": "InstanceDown", "instance": model.LabelValue(target)}
+		if actual := ih.Mutes(lset); actual != expected {
+			t.Errorf("Expected (*Inhibitor).Mutes(%v) to return %t but got %t", lset, expected, actual)
+		}
+	}
+}
+
+func TestInhibitorMutesReceiver(t *testing.T) {
+	t.Parallel()
+
+	cr := config.InhibitRule{
+		SourceMatch: map[string]string{"s": "1"},
+		TargetMatch: map[string]string{"t": "1"},
+		Receivers:   []string{"pager"},
+	}
+	m := types.NewMarker()
+	ih := NewInhibitor(nil, []*config.InhibitRule{&cr}, m, nopLogger)
+	now := time.Now()
+	ih.rules[0].set(&types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"s": "1"},
+			StartsAt: now.Add(-time.Minute),
+			EndsAt:   now.Add(time.Hour),
+		},
+	})
+
+	target := model.LabelSet{"t": "1"}
+	if !ih.MutesReceiver("pager", target) {
+		t.Errorf("Expected target to be muted for receiver pager")
+	}
+	if ih.MutesReceiver("email", target) {
+		t.Errorf("Expected target not to be muted for receiver email")
+	}
+	if ih.Mutes(target) {
+		t.Errorf("Expected target not to be muted without receiver")
+	}
+	if ids, inhibited := m.Inhibited(target.Fingerprint()); inhibited {
+		t.Errorf("Expected target not to be marked as inhibited, got %v", ids)
+	}
+}
+
+func TestInhibitorInhibitions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newAlert := func(lset model.LabelSet) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:   lset,
+				StartsAt: now.Add(-time.Minute),
+				EndsAt:   now.Add(time.Hour),
+			},
+		}
+	}
+	var (
+		source = newAlert(model.LabelSet{"s": "1"})
+		target = newAlert(model.LabelSet{"t": "1"})
+		other  = newAlert(model.LabelSet{"o": "1"})
+	)
+	crs := []*config.InhibitRule{
+		{
+			SourceMatch: map[string]string{"s": "2"},
+			TargetMatch: map[string]string{"t": "1"},
+		},
+		{
+			SourceMatch: map[string]string{"s": "1"},
+			TargetMatch: map[string]string{"t": "1"},
+			Receivers:   []string{"pager"},
+		},
+	}
+	ih := NewInhibitor(newFakeAlerts([]*types.Alert{source, target, other}), crs, types.NewMarker(), nopLogger)
+	for _, r := range ih.rules {
+		if r.SourceMatchers.Match(source.Labels) {
+			r.set(source)
+		}
+	}
+
+	inhibitions := ih.Inhibitions()
+	if len(inhibitions) != 1 {
+		t.Fatalf("Expected 1 inhibition, got %d", len(inhibitions))
+	}
+	inh := inhibitions[0]
+	if inh.Target != target || inh.Source != source {
+		t.Errorf("Expected target %v inhibited by %v, got %v inhibited by %v", target, source, inh.Target, inh.Source)
+	}
+	if inh.RuleIndex != 1 || inh.Rule != crs[1] {
+		t.Errorf("Expected inhibition by rule 1, got rule %d", inh.RuleIndex)
+	}
+
+	var nilInhibitor *Inhibitor
+	if inhibitions := nilInhibitor.Inhibitions(); len(inhibitions) != 0 {
+		t.Errorf("Expected no inhibitions of a nil inhibitor, got %d", len(inhibitions))
+	}
+}
+
 func TestInhibitRuleGC(t *testing.T) {
 	// TODO(fabxc): add now() injection function to Resolved() to remove
 	// dependency on machine time in this test.
@@ -227,13 +344,19 @@ func TestInhibitRuleGC(t *testing.T) {
 		2: newAlert(-10, 10),
 	}
 
-	r := &InhibitRule{scache: before}
+	r := NewInhibitRule(&config.InhibitRule{})
+	for fp, a := range before {
+		r.cache(fp, a, nil)
+	}
 	r.gc()
 
 	if !reflect.DeepEqual(r.scache, after) {
 		t.Errorf("Unexpected cache state after GC")
 		t.Errorf(pretty.Compare(r.scache, after))
 	}
+	if n := len(r.sindex[""]); n != len(after) {
+		t.Errorf("Expected %d indexed source alerts after GC, got %d", len(after), n)
+	}
 }
 
 type fakeAlerts struct {
@@ -248,9 +371,19 @@ func newFakeAlerts(alerts []*types.Alert) *fakeAlerts {
 	}
 }
 
-func (f *fakeAlerts) GetPending() provider.AlertIterator          { return nil }
 func (f *fakeAlerts) Get(model.Fingerprint) (*types.Alert, error) { return nil, nil }
 func (f *fakeAlerts) Put(...*types.Alert) error                   { return nil }
+func (f *fakeAlerts) Resolve(time.Time, ...model.Fingerprint) ([]*types.Alert, error) {
+	return nil, nil
+}
+func (f *fakeAlerts) GetPending() provider.AlertIterator {
+	ch := make(chan *types.Alert, len(f.alerts))
+	for _, a := range f.alerts {
+		ch <- a
+	}
+	close(ch)
+	return provider.NewAlertIterator(ch, make(chan struct{}), nil)
+}
 func (f *fakeAlerts) Subscribe() provider.AlertIterator {
 	ch := make(chan *types.Alert)
 	done := make(chan struct{})
@@ -383,3 +516,54 @@ func TestInhibit(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkMutes(b *testing.B) {
+	now := time.Now()
+	for _, n := range []int{100, 1000, 10000} {
+		for _, nrules := range []int{1, 10, 50} {
+			var crs []*config.InhibitRule
+			for i := 0; i < nrules; i++ {
+				crs = append(crs, &config.InhibitRule{
+					SourceMatch: map[string]string{"alertname": fmt.Sprintf("Source%d", i)},
+					TargetMatch: map[string]string{"severity": "warning"},
+					Equal:       model.LabelNames{"cluster", "instance"},
+				})
+			}
+			ih := NewInhibitor(nil, crs, types.NewMarker(), nopLogger)
+			// The source alerts are spread over the rules and none of them
+			// inhibits the targets, which is the worst case of the lookup.
+			for i := 0; i < n; i++ {
+				r := ih.rules[i%nrules]
+				err := r.set(&types.Alert{
+					Alert: model.Alert{
+						Labels: model.LabelSet{
+							"alertname": model.LabelValue(fmt.Sprintf("Source%d", i%nrules)),
+							"cluster":   model.LabelValue(fmt.Sprintf("cluster%d", i%10)),
+							"instance":  model.LabelValue(fmt.Sprintf("source%d", i)),
+						},
+						StartsAt: now.Add(-time.Minute),
+						EndsAt:   now.Add(time.Hour),
+					},
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			targets := make([]model.LabelSet, 100)
+			for i := range targets {
+				targets[i] = model.LabelSet{
+					"alertname": "InstanceDown",
+					"severity":  "warning",
+					"cluster":   model.LabelValue(fmt.Sprintf("cluster%d", i%10)),
+					"instance":  model.LabelValue(fmt.Sprintf("target%d", i)),
+				}
+			}
+
+			b.Run(fmt.Sprintf("sources=%d/rules=%d", n, nrules), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					ih.Mutes(targets[i%len(targets)])
+				}
+			})
+		}
+	}
+}
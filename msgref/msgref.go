@@ -0,0 +1,315 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgref stores references to messages that receivers sent for
+// aggregation groups, e.g. the timestamps of Slack messages, so that later
+// notifications of the group can update or reply to them. References are
+// replicated across the cluster and can be snapshotted to disk.
+package msgref
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/prometheus/alertmanager/storage"
+)
+
+// Ref references a message sent to a channel.
+type Ref struct {
+	// Key identifies the aggregation group and receiver the message was
+	// sent for.
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+	// ID identifies the message within the channel.
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Validate returns an error if the reference is invalid.
+func (r *Ref) Validate() error {
+	if r.Key == "" {
+		return errors.New("key missing")
+	}
+	if r.UpdatedAt.IsZero() || r.ExpiresAt.IsZero() {
+		return errors.New("timestamps missing")
+	}
+	return nil
+}
+
+// Expired returns whether the reference is no longer valid at the time.
+func (r *Ref) Expired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
+
+// Refs holds message references keyed by their key.
+type Refs struct {
+	logger    log.Logger
+	now       func() time.Time
+	retention time.Duration
+
+	mtx       sync.RWMutex
+	st        state
+	broadcast func([]byte)
+}
+
+type state map[string]*Ref
+
+// merge adds the reference to the state if it is newer than the existing
+// one.
+func (s state) merge(r *Ref) {
+	if prev, ok := s[r.Key]; ok && !prev.UpdatedAt.Before(r.UpdatedAt) {
+		return
+	}
+	s[r.Key] = r
+}
+
+func (s state) MarshalBinary() ([]byte, error) {
+	refs := make([]*Ref, 0, len(s))
+	for _, r := range s {
+		refs = append(refs, r)
+	}
+	return json.Marshal(refs)
+}
+
+func decodeState(b []byte) (state, error) {
+	var refs []*Ref
+	if err := json.Unmarshal(b, &refs); err != nil {
+		return nil, err
+	}
+	st := state{}
+	for _, r := range refs {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		st.merge(r)
+	}
+	return st, nil
+}
+
+// Options exposes configuration options for creating a new Refs object.
+type Options struct {
+	// A snapshot file from which the initial state is loaded.
+	SnapshotFile string
+
+	// Retention time of references. Messages are no longer updated once
+	// their reference expired.
+	Retention time.Duration
+
+	// A logger used by background processing.
+	Logger log.Logger
+}
+
+// New returns a new Refs object with the given configuration.
+func New(o Options) (*Refs, error) {
+	r := &Refs{
+		logger:    log.NewNopLogger(),
+		now:       utcNow,
+		retention: o.Retention,
+		st:        state{},
+		broadcast: func([]byte) {},
+	}
+	if o.Logger != nil {
+		r.logger = o.Logger
+	}
+
+	if o.SnapshotFile != "" {
+		b, err := ioutil.ReadFile(o.SnapshotFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if r.st, err = decodeState(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// Get returns the valid reference with the key.
+func (r *Refs) Get(key string) (*Ref, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	ref, ok := r.st[key]
+	if !ok || ref.Expired(r.now()) {
+		return nil, false
+	}
+	return ref, true
+}
+
+// Set stores a reference to the message with the ID in the channel. It is
+// valid for the retention time.
+func (r *Refs) Set(key, channel, id string) error {
+	now := r.now()
+	return r.setState(&Ref{
+		Key:       key,
+		Channel:   channel,
+		ID:        id,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(r.retention),
+	})
+}
+
+// Expire invalidates the reference with the key so that the next
+// notification sends a new message.
+func (r *Refs) Expire(key string) error {
+	now := r.now()
+
+	r.mtx.RLock()
+	prev, ok := r.st[key]
+	r.mtx.RUnlock()
+
+	if !ok || prev.Expired(now) {
+		return nil
+	}
+	ref := *prev
+	ref.UpdatedAt = now
+	ref.ExpiresAt = now
+
+	return r.setState(&ref)
+}
+
+func (r *Refs) setState(ref *Ref) error {
+	if err := ref.Validate(); err != nil {
+		return err
+	}
+	b, err := json.Marshal([]*Ref{ref})
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	r.st.merge(ref)
+	broadcast := r.broadcast
+	r.mtx.Unlock()
+
+	broadcast(b)
+	return nil
+}
+
+// GC removes expired references. Expired references are kept for the
+// retention time so that their expiration is replicated. It returns the
+// number of removed references.
+func (r *Refs) GC() int {
+	now := r.now()
+	var n int
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for k, ref := range r.st {
+		if !ref.ExpiresAt.Add(r.retention).After(now) {
+			delete(r.st, k)
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot writes the full internal state into the writer and returns the
+// number of bytes written.
+func (r *Refs) Snapshot(w io.Writer) (int64, error) {
+	b, err := r.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Maintenance garbage collects the references and snapshots them to the
+// file at the given interval until stopc is closed.
+func (r *Refs) Maintenance(interval time.Duration, snapf string, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	f := func() error {
+		r.GC()
+		if snapf == "" {
+			return nil
+		}
+		f, err := storage.OpenReplace(snapf)
+		if err != nil {
+			return err
+		}
+		if _, err := r.Snapshot(f); err != nil {
+			f.Abort()
+			return err
+		}
+		return f.Close()
+	}
+
+Loop:
+	for {
+		select {
+		case <-stopc:
+			break Loop
+		case <-t.C:
+			if err := f(); err != nil {
+				level.Info(r.logger).Log("msg", "Running maintenance failed", "err", err)
+			}
+		}
+	}
+	// No need for final maintenance if we don't want to snapshot.
+	if snapf == "" {
+		return
+	}
+	if err := f(); err != nil {
+		level.Info(r.logger).Log("msg", "Creating shutdown snapshot failed", "err", err)
+	}
+}
+
+// MarshalBinary serializes all references.
+func (r *Refs) MarshalBinary() ([]byte, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return r.st.MarshalBinary()
+}
+
+// Merge merges references received from the cluster with the local state.
+func (r *Refs) Merge(b []byte) error {
+	st, err := decodeState(b)
+	if err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, ref := range st {
+		r.st.merge(ref)
+	}
+	return nil
+}
+
+// SetBroadcast sets the provided function as the one creating data to be
+// broadcast.
+func (r *Refs) SetBroadcast(f func([]byte)) {
+	r.mtx.Lock()
+	r.broadcast = f
+	r.mtx.Unlock()
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgref
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRefs(t *testing.T, now time.Time) *Refs {
+	r, err := New(Options{Retention: time.Hour})
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+	return r
+}
+
+func TestRefsSetExpire(t *testing.T) {
+	now := utcNow()
+	r := newTestRefs(t, now)
+
+	var broadcasts int
+	r.SetBroadcast(func([]byte) { broadcasts++ })
+
+	require.Error(t, r.Set("", "C1", "1.0"))
+	require.NoError(t, r.Set("group", "C1", "1.0"))
+
+	ref, ok := r.Get("group")
+	require.True(t, ok)
+	require.Equal(t, &Ref{
+		Key:       "group",
+		Channel:   "C1",
+		ID:        "1.0",
+		UpdatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, ref)
+	_, ok = r.Get("other")
+	require.False(t, ok)
+
+	r.now = func() time.Time { return now.Add(time.Second) }
+	require.NoError(t, r.Expire("group"))
+	_, ok = r.Get("group")
+	require.False(t, ok)
+	require.NoError(t, r.Expire("group"))
+	require.Equal(t, 2, broadcasts)
+}
+
+func TestRefsGC(t *testing.T) {
+	now := utcNow()
+	r := newTestRefs(t, now)
+
+	r.st = state{
+		"1": {Key: "1", ExpiresAt: now.Add(time.Minute)},
+		"2": {Key: "2", ExpiresAt: now.Add(-time.Minute)},
+		"3": {Key: "3", ExpiresAt: now.Add(-2 * time.Hour)},
+	}
+	require.Equal(t, 1, r.GC())
+	require.Len(t, r.st, 2)
+	require.Contains(t, r.st, "1")
+	require.Contains(t, r.st, "2")
+}
+
+func TestRefsMerge(t *testing.T) {
+	now := utcNow()
+	a := newTestRefs(t, now)
+	b := newTestRefs(t, now.Add(time.Minute))
+
+	require.NoError(t, a.Set("group", "C1", "1.0"))
+	require.NoError(t, b.Set("group", "C1", "2.0"))
+
+	// The newer reference wins regardless of the merge order.
+	ab, err := a.MarshalBinary()
+	require.NoError(t, err)
+	bb, err := b.MarshalBinary()
+	require.NoError(t, err)
+
+	require.NoError(t, a.Merge(bb))
+	require.NoError(t, b.Merge(ab))
+	for _, refs := range []*Refs{a, b} {
+		ref, ok := refs.Get("group")
+		require.True(t, ok)
+		require.Equal(t, "2.0", ref.ID)
+	}
+
+	require.Error(t, a.Merge([]byte("garbage")))
+}
+
+func TestRefsSnapshot(t *testing.T) {
+	now := utcNow()
+	r := newTestRefs(t, now)
+	require.NoError(t, r.Set("group", "C1", "1.0"))
+
+	f, err := ioutil.TempFile("", "snapshot")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	var buf bytes.Buffer
+	_, err = r.Snapshot(&buf)
+	require.NoError(t, err)
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	r2, err := New(Options{SnapshotFile: f.Name()})
+	require.NoError(t, err)
+	require.Equal(t, r.st, r2.st)
+}
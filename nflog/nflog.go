@@ -19,11 +19,15 @@ package nflog
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -32,6 +36,9 @@ import (
 	"github.com/matttproud/golang_protobuf_extensions/pbutil"
 	pb "github.com/prometheus/alertmanager/nflog/nflogpb"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/storage"
 )
 
 // ErrNotFound is returned for empty query results.
@@ -79,6 +86,8 @@ type Log struct {
 
 	runInterval time.Duration
 	snapf       string
+	storage     storage.Backend
+	storageKey  string
 	stopc       chan struct{}
 	done        func()
 
@@ -87,6 +96,10 @@ type Log struct {
 	mtx       sync.RWMutex
 	st        state
 	broadcast func([]byte)
+
+	// history holds the most recent notification attempts of this
+	// Alertmanager by the same key. It is not shared with the cluster.
+	history map[string][]*Attempt
 }
 
 type metrics struct {
@@ -96,6 +109,7 @@ type metrics struct {
 	queriesTotal     prometheus.Counter
 	queryErrorsTotal prometheus.Counter
 	queryDuration    prometheus.Histogram
+	gcRemovedTotal   prometheus.Counter
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
@@ -125,6 +139,10 @@ func newMetrics(r prometheus.Registerer) *metrics {
 		Name: "alertmanager_nflog_query_duration_seconds",
 		Help: "Duration of notification log query evaluation.",
 	})
+	m.gcRemovedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_nflog_gc_removed_total",
+		Help: "How many notification log entries were removed by garbage collection.",
+	})
 
 	if r != nil {
 		r.MustRegister(
@@ -133,6 +151,7 @@ func newMetrics(r prometheus.Registerer) *metrics {
 			m.queriesTotal,
 			m.queryErrorsTotal,
 			m.queryDuration,
+			m.gcRemovedTotal,
 		)
 	}
 	return m
@@ -202,6 +221,21 @@ func WithSnapshot(sf string) Option {
 	}
 }
 
+// WithStorage configures the log to be initialized from the snapshot stored
+// under the key in the storage backend. If maintenance is configured, a
+// snapshot will be stored periodically and on shutdown as well.
+// It must not be combined with WithSnapshot.
+func WithStorage(b storage.Backend, key string) Option {
+	return func(l *Log) error {
+		l.storage = b
+		l.storageKey = key
+		return nil
+	}
+}
+
+// storageTimeout bounds the requests to the storage backend.
+const storageTimeout = time.Minute
+
 func utcNow() time.Time {
 	return time.Now().UTC()
 }
@@ -272,6 +306,7 @@ func New(opts ...Option) (*Log, error) {
 		now:       utcNow,
 		st:        state{},
 		broadcast: func([]byte) {},
+		history:   map[string][]*Attempt{},
 	}
 	for _, o := range opts {
 		if err := o(l); err != nil {
@@ -281,6 +316,9 @@ func New(opts ...Option) (*Log, error) {
 	if l.metrics == nil {
 		l.metrics = newMetrics(nil)
 	}
+	if l.snapf != "" && l.storage != nil {
+		return nil, fmt.Errorf("only one of snapshot file and storage must be set")
+	}
 
 	if l.snapf != "" {
 		if f, err := os.Open(l.snapf); !os.IsNotExist(err) {
@@ -294,6 +332,21 @@ func New(opts ...Option) (*Log, error) {
 			}
 		}
 	}
+	if l.storage != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		if r, err := l.storage.Get(ctx, l.storageKey); err != storage.ErrNotFound {
+			if err != nil {
+				return l, err
+			}
+			defer r.Close()
+
+			if err := l.loadSnapshot(r); err != nil {
+				return l, err
+			}
+		}
+	}
 
 	go l.run()
 
@@ -325,6 +378,11 @@ func (l *Log) run() {
 		if _, err := l.GC(); err != nil {
 			return err
 		}
+		if l.storage != nil {
+			var err error
+			size, err = l.storeSnapshot()
+			return err
+		}
 		if l.snapf == "" {
 			return nil
 		}
@@ -350,7 +408,7 @@ Loop:
 		}
 	}
 	// No need to run final maintenance if we don't want to snapshot.
-	if l.snapf == "" {
+	if l.snapf == "" && l.storage == nil {
 		return
 	}
 	if err := f(); err != nil {
@@ -358,6 +416,19 @@ Loop:
 	}
 }
 
+// storeSnapshot writes a snapshot to the storage backend.
+func (l *Log) storeSnapshot() (int64, error) {
+	var buf bytes.Buffer
+	size, err := l.Snapshot(&buf)
+	if err != nil {
+		return size, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	return size, l.storage.Put(ctx, l.storageKey, &buf)
+}
+
 func receiverKey(r *pb.Receiver) string {
 	return fmt.Sprintf("%s/%s/%d", r.GroupName, r.Integration, r.Idx)
 }
@@ -368,6 +439,41 @@ func stateKey(k string, r *pb.Receiver) string {
 	return fmt.Sprintf("%s:%s", k, receiverKey(r))
 }
 
+// IdempotencyKey returns the key identifying the notification of the
+// receiver about the firing and resolved alerts of the group. prev is the
+// timestamp of the previous notification of the group in the log, or the
+// zero time if there is none. Peers notifying about the same alerts after
+// the same previous notification derive the same key, so that the systems
+// receiving the notifications can drop the duplicates sent during a network
+// partition of the cluster. Repeated notifications about the same alerts
+// follow another previous notification and get a new key.
+func IdempotencyKey(receiver, gkey string, prev time.Time, firingAlerts, resolvedAlerts []uint64) string {
+	h := sha256.New()
+	h.Write([]byte(receiver))
+	h.Write([]byte{0xff})
+	h.Write([]byte(gkey))
+	h.Write([]byte{0xff})
+	if !prev.IsZero() {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(prev.UnixNano()))
+		h.Write(b)
+	}
+	for _, alerts := range [][]uint64{firingAlerts, resolvedAlerts} {
+		sorted := append([]uint64(nil), alerts...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		h.Write([]byte{0xff})
+		b := make([]byte, 8)
+		for _, a := range sorted {
+			binary.BigEndian.PutUint64(b, a)
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Log records a notification of the receiver about the firing and resolved
+// alerts of the group.
 func (l *Log) Log(r *pb.Receiver, gkey string, firingAlerts, resolvedAlerts []uint64) error {
 	// Write all st with the same timestamp.
 	now := l.now()
@@ -426,9 +532,93 @@ func (l *Log) GC() (int, error) {
 		}
 	}
 
+	for k, as := range l.history {
+		i := 0
+		for ; i < len(as); i++ {
+			if !as[i].Timestamp.Add(l.retention).After(now) {
+				break
+			}
+		}
+		if i == 0 {
+			delete(l.history, k)
+		} else {
+			l.history[k] = as[:i]
+		}
+	}
+	l.metrics.gcRemovedTotal.Add(float64(n))
+
 	return n, nil
 }
 
+// maxAttempts is the number of notification attempts kept in the history
+// for each group key and receiver.
+const maxAttempts = 20
+
+// Attempt is a single attempt to send a notification for a group of alerts
+// to a receiver.
+type Attempt struct {
+	GroupKey  string       `json:"groupKey"`
+	Receiver  *pb.Receiver `json:"receiver"`
+	Timestamp time.Time    `json:"timestamp"`
+	// Fingerprints of the alerts the notification was sent for.
+	FiringAlerts   []uint64 `json:"firingAlerts"`
+	ResolvedAlerts []uint64 `json:"resolvedAlerts"`
+	// Error is empty if the notification was sent successfully.
+	Error string `json:"error,omitempty"`
+}
+
+// HasAlert returns whether the notification included the alert with the
+// fingerprint.
+func (a *Attempt) HasAlert(fp uint64) bool {
+	for _, f := range a.FiringAlerts {
+		if f == fp {
+			return true
+		}
+	}
+	for _, f := range a.ResolvedAlerts {
+		if f == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// LogAttempt adds a notification attempt to the history. Its timestamp is
+// set to the current time.
+func (l *Log) LogAttempt(a *Attempt) {
+	a.Timestamp = l.now()
+	key := stateKey(a.GroupKey, a.Receiver)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	as := append([]*Attempt{a}, l.history[key]...)
+	if len(as) > maxAttempts {
+		as = as[:maxAttempts]
+	}
+	l.history[key] = as
+}
+
+// History returns the notification attempts for the group key, most recent
+// first. If the group key is empty, the attempts of all groups are returned.
+func (l *Log) History(gkey string) []*Attempt {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	var res []*Attempt
+	for _, as := range l.history {
+		for _, a := range as {
+			if gkey == "" || a.GroupKey == gkey {
+				res = append(res, a)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Timestamp.After(res[j].Timestamp)
+	})
+	return res
+}
+
 // Query implements the Log interface.
 func (l *Log) Query(params ...QueryParam) ([]*pb.Entry, error) {
 	start := time.Now()
@@ -478,6 +668,32 @@ func (l *Log) loadSnapshot(r io.Reader) error {
 	return nil
 }
 
+// ReadSnapshot reads a snapshot generated by Snapshot() and returns the last
+// successful notification of each group and receiver as attempts, most
+// recent first. It allows inspecting the notification log of an
+// Alertmanager that is not running.
+func ReadSnapshot(r io.Reader) ([]*Attempt, error) {
+	st, err := decodeState(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Attempt, 0, len(st))
+	for _, e := range st {
+		res = append(res, &Attempt{
+			GroupKey:       string(e.Entry.GroupKey),
+			Receiver:       e.Entry.Receiver,
+			Timestamp:      e.Entry.Timestamp,
+			FiringAlerts:   e.Entry.FiringAlerts,
+			ResolvedAlerts: e.Entry.ResolvedAlerts,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Timestamp.After(res[j].Timestamp)
+	})
+	return res, nil
+}
+
 // Snapshot implements the Log interface.
 func (l *Log) Snapshot(w io.Writer) (int64, error) {
 	start := time.Now()
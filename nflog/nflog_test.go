@@ -123,6 +123,73 @@ func TestLogSnapshot(t *testing.T) {
 	}
 }
 
+func TestIdempotencyKey(t *testing.T) {
+	prev := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	key := IdempotencyKey("team-X", "{}:{alertname=\"a\"}", prev, []uint64{1, 2}, []uint64{3})
+
+	// The key does not depend on the order of the alerts.
+	require.Equal(t, key, IdempotencyKey("team-X", "{}:{alertname=\"a\"}", prev, []uint64{2, 1}, []uint64{3}))
+
+	for _, other := range []string{
+		IdempotencyKey("team-Y", "{}:{alertname=\"a\"}", prev, []uint64{1, 2}, []uint64{3}),
+		IdempotencyKey("team-X", "{}:{alertname=\"b\"}", prev, []uint64{1, 2}, []uint64{3}),
+		IdempotencyKey("team-X", "{}:{alertname=\"a\"}", prev, []uint64{1}, []uint64{2, 3}),
+		IdempotencyKey("team-X", "{}:{alertname=\"a\"}", prev, []uint64{1, 2, 3}, nil),
+		// Repeated notifications get a new key.
+		IdempotencyKey("team-X", "{}:{alertname=\"a\"}", prev.Add(time.Hour), []uint64{1, 2}, []uint64{3}),
+		IdempotencyKey("team-X", "{}:{alertname=\"a\"}", time.Time{}, []uint64{1, 2}, []uint64{3}),
+	} {
+		require.NotEqual(t, key, other)
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	now := utcNow()
+	l := &Log{
+		st:      state{},
+		metrics: newMetrics(nil),
+	}
+	for i, e := range []*pb.Entry{
+		{
+			GroupKey:     []byte("1"),
+			Receiver:     &pb.Receiver{GroupName: "abc", Integration: "email"},
+			Timestamp:    now.Add(-time.Hour),
+			FiringAlerts: []uint64{1, 2},
+		},
+		{
+			GroupKey:       []byte("2"),
+			Receiver:       &pb.Receiver{GroupName: "abc", Integration: "webhook"},
+			Timestamp:      now,
+			ResolvedAlerts: []uint64{3},
+		},
+	} {
+		l.st[stateKey(string(e.GroupKey), e.Receiver)] = &pb.MeshEntry{Entry: e, ExpiresAt: now.Add(time.Duration(i) * time.Hour)}
+	}
+	var buf bytes.Buffer
+	_, err := l.Snapshot(&buf)
+	require.NoError(t, err)
+
+	attempts, err := ReadSnapshot(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []*Attempt{
+		{
+			GroupKey:       "2",
+			Receiver:       &pb.Receiver{GroupName: "abc", Integration: "webhook"},
+			Timestamp:      now,
+			ResolvedAlerts: []uint64{3},
+		},
+		{
+			GroupKey:     "1",
+			Receiver:     &pb.Receiver{GroupName: "abc", Integration: "email"},
+			Timestamp:    now.Add(-time.Hour),
+			FiringAlerts: []uint64{1, 2},
+		},
+	}, attempts)
+
+	_, err = ReadSnapshot(bytes.NewReader([]byte("invalid")))
+	require.Error(t, err)
+}
+
 func TestReplaceFile(t *testing.T) {
 	dir, err := ioutil.TempDir("", "replace_file")
 	require.NoError(t, err, "creating temp dir failed")
@@ -307,3 +374,36 @@ func TestStateDecodingError(t *testing.T) {
 	_, err = decodeState(bytes.NewReader(msg))
 	require.Equal(t, ErrInvalidState, err)
 }
+
+func TestLogHistory(t *testing.T) {
+	now := utcNow()
+	l, err := New(WithNow(func() time.Time { return now }), WithRetention(time.Hour))
+	require.NoError(t, err)
+
+	recv := &pb.Receiver{GroupName: "test", Integration: "webhook"}
+	for i := 0; i < maxAttempts+5; i++ {
+		now = now.Add(time.Minute)
+		l.LogAttempt(&Attempt{GroupKey: "1", Receiver: recv, FiringAlerts: []uint64{1}})
+	}
+	now = now.Add(time.Minute)
+	l.LogAttempt(&Attempt{GroupKey: "2", Receiver: recv, ResolvedAlerts: []uint64{2}, Error: "failed"})
+
+	h := l.History("1")
+	require.Equal(t, maxAttempts, len(h), "history must be bounded")
+	require.True(t, h[0].Timestamp.After(h[1].Timestamp), "most recent attempt must be first")
+	require.True(t, h[0].HasAlert(1))
+	require.False(t, h[0].HasAlert(2))
+
+	h = l.History("")
+	require.Equal(t, maxAttempts+1, len(h))
+	require.Equal(t, "2", h[0].GroupKey)
+	require.Equal(t, "failed", h[0].Error)
+	require.True(t, h[0].HasAlert(2))
+
+	// Only attempts within the retention survive garbage collection.
+	now = now.Add(time.Hour - 11*time.Minute + time.Second)
+	_, err = l.GC()
+	require.NoError(t, err)
+	require.Equal(t, 10, len(l.History("1")))
+	require.Equal(t, 1, len(l.History("2")))
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/types"
+)
+
+var numDigestedNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "notifications_digested_total",
+	Help:      "The total number of group notifications accumulated in the digests of a receiver.",
+}, []string{"receiver", "integration"})
+
+func init() {
+	prometheus.Register(numDigestedNotifications)
+}
+
+// digestGroup is the last notification of a group accumulated in a digest.
+type digestGroup struct {
+	key      string
+	labels   model.LabelSet
+	alerts   []*types.Alert
+	firing   []uint64
+	resolved []uint64
+	at       time.Time
+}
+
+// sentBy reports whether the entry, logged after the group was accumulated,
+// covers all alerts of the group. This is the case if the digest of another
+// peer already sent the group.
+func (g *digestGroup) sentBy(e *nflogpb.Entry) bool {
+	if e == nil || e.Timestamp.Before(g.at) {
+		return false
+	}
+	return containsAll(e.FiringAlerts, g.firing) && containsAll(e.ResolvedAlerts, g.resolved)
+}
+
+func containsAll(set, fps []uint64) bool {
+	m := make(map[uint64]struct{}, len(set))
+	for _, fp := range set {
+		m[fp] = struct{}{}
+	}
+	for _, fp := range fps {
+		if _, ok := m[fp]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// withDigestGroups populates a context with the groups of a digest.
+func withDigestGroups(ctx context.Context, groups []*digestGroup) context.Context {
+	return context.WithValue(ctx, keyDigestGroups, groups)
+}
+
+func digestGroups(ctx context.Context) []*digestGroup {
+	v, _ := ctx.Value(keyDigestGroups).([]*digestGroup)
+	return v
+}
+
+// DigestStage accumulates the notifications of all groups passed to the
+// inner stage over the digest window of a receiver. Accumulated
+// notifications are not sent and not passed on. At the end of the window, a
+// single notification with the alerts of all accumulated groups is sent
+// instead, and only once it was sent, the notifications of the groups are
+// recorded in the notification log.
+// Until then, the groups pass the deduplication again, e.g. after a restart
+// or if sending the digest failed, and are accumulated in the next digest.
+// A later notification of a group replaces the earlier one, as it holds
+// the current state of all alerts of the group.
+type DigestStage struct {
+	stage       Stage
+	nflog       NotificationLog
+	recv        *nflogpb.Receiver
+	receiver    string
+	integration string
+	window      time.Duration
+	logger      log.Logger
+	now         func() time.Time
+
+	mtx    sync.Mutex
+	groups map[string]*digestGroup
+	timer  *time.Timer
+}
+
+// NewDigestStage returns a new DigestStage sending digests through s and
+// recording the notifications of their groups in the notification log.
+func NewDigestStage(s Stage, nl NotificationLog, recv *nflogpb.Receiver, window time.Duration, l log.Logger) *DigestStage {
+	return &DigestStage{
+		stage:       s,
+		nflog:       nl,
+		recv:        recv,
+		receiver:    recv.GroupName,
+		integration: recv.Integration,
+		window:      window,
+		logger:      l,
+		now:         time.Now,
+		groups:      map[string]*digestGroup{},
+	}
+}
+
+// Exec implements the Stage interface.
+func (s *DigestStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, _ := GroupKey(ctx)
+	g := &digestGroup{
+		key:    gkey,
+		alerts: alerts,
+	}
+	g.labels, _ = GroupLabels(ctx)
+	g.firing, _ = FiringAlerts(ctx)
+	g.resolved, _ = ResolvedAlerts(ctx)
+	g.at = s.now()
+
+	s.mtx.Lock()
+	s.groups[gkey] = g
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.window, s.flush)
+	}
+	s.mtx.Unlock()
+
+	numDigestedNotifications.WithLabelValues(s.receiver, s.integration).Inc()
+	level.Debug(l).Log("msg", "Notification accumulated in digest", "receiver", s.receiver, "integration", s.integration, "window", s.window)
+
+	return ctx, nil, nil
+}
+
+// flush sends the notification of the accumulated groups.
+func (s *DigestStage) flush() {
+	s.mtx.Lock()
+	pending := s.groups
+	s.groups = map[string]*digestGroup{}
+	s.timer = nil
+	s.mtx.Unlock()
+
+	l := log.With(s.logger, "receiver", s.receiver, "integration", s.integration)
+
+	groups := make([]*digestGroup, 0, len(pending))
+	for _, g := range pending {
+		entries, err := s.nflog.Query(nflog.QGroupKey(g.key), nflog.QReceiver(s.recv))
+		if err != nil && err != nflog.ErrNotFound {
+			level.Warn(l).Log("msg", "Querying the notification log failed", "err", err)
+		}
+		if len(entries) == 1 && g.sentBy(entries[0]) {
+			continue
+		}
+		groups = append(groups, g)
+	}
+
+	if len(groups) == 0 {
+		return
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].key < groups[j].key })
+
+	var (
+		alerts           []*types.Alert
+		firing, resolved []uint64
+		seen             = map[model.Fingerprint]struct{}{}
+	)
+	for _, g := range groups {
+		// Alerts routed to the receiver by several routes are listed once.
+		for _, a := range g.alerts {
+			fp := a.Fingerprint()
+			if _, ok := seen[fp]; ok {
+				continue
+			}
+			seen[fp] = struct{}{}
+			alerts = append(alerts, a)
+		}
+		firing = append(firing, g.firing...)
+		resolved = append(resolved, g.resolved...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MinTimeout)
+	defer cancel()
+
+	ctx = WithReceiverName(ctx, s.receiver)
+	ctx = WithGroupKey(ctx, "digest/"+s.receiver)
+	ctx = WithGroupLabels(ctx, model.LabelSet{})
+	ctx = WithFiringAlerts(ctx, firing)
+	ctx = WithResolvedAlerts(ctx, resolved)
+	ctx = WithNow(ctx, s.now())
+	ctx = withDigestGroups(ctx, groups)
+
+	if _, _, err := s.stage.Exec(ctx, l, alerts...); err != nil {
+		level.Error(l).Log("msg", "Sending digest failed", "groups", len(groups), "err", err)
+		return
+	}
+	for _, g := range groups {
+		if err := s.nflog.Log(s.recv, g.key, g.firing, g.resolved); err != nil {
+			level.Error(l).Log("msg", "Recording digested notification failed", "group", g.key, "err", err)
+		}
+	}
+}
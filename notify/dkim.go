@@ -0,0 +1,199 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// dkimWSP matches the whitespace that the relaxed canonicalization reduces
+// to a single space.
+var dkimWSP = regexp.MustCompile(`[ \t]+`)
+
+// dkimSigner signs emails with DKIM (RFC 6376 and RFC 8463) using the
+// relaxed canonicalization of the header and the body.
+type dkimSigner struct {
+	key      crypto.Signer
+	algo     string
+	selector string
+	domain   string
+	headers  []string
+	now      func() time.Time
+}
+
+// newDKIMSigner reads the private key of the DKIM configuration.
+func newDKIMSigner(c *config.EmailDKIMConfig) (*dkimSigner, error) {
+	b, err := ioutil.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading DKIM key: %s", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded DKIM key in %s", c.KeyFile)
+	}
+	var key interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing DKIM key %s: %s", c.KeyFile, err)
+	}
+
+	s := &dkimSigner{
+		selector: c.Selector,
+		domain:   c.Domain,
+		headers:  c.Headers,
+		now:      time.Now,
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s.key, s.algo = k, "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.key, s.algo = k, "ed25519-sha256"
+	default:
+		return nil, fmt.Errorf("unsupported DKIM key type %T", key)
+	}
+	return s, nil
+}
+
+// sign returns the DKIM-Signature header field of the message, including
+// the trailing CRLF. The lines of the message must end with CRLF.
+func (s *dkimSigner) sign(msg []byte) (string, error) {
+	var header, body []byte
+	if i := bytes.Index(msg, []byte("\r\n\r\n")); i >= 0 {
+		header, body = msg[:i+2], msg[i+4:]
+	} else {
+		header = msg
+	}
+	bh := sha256.Sum256(dkimRelaxedBody(body))
+
+	// The last unsigned instance of a header is signed first if it occurs
+	// several times (RFC 6376, section 5.4.2).
+	fields := dkimHeaderFields(header)
+	signed := make([]bool, len(fields))
+	h := sha256.New()
+	var names []string
+	for _, name := range s.headers {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if signed[i] || !strings.EqualFold(dkimFieldName(fields[i]), name) {
+				continue
+			}
+			signed[i] = true
+			h.Write([]byte(dkimRelaxedHeader(fields[i])))
+			names = append(names, strings.ToLower(name))
+			break
+		}
+	}
+
+	value := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.algo, s.domain, s.selector, s.now().Unix(), strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bh[:]))
+	// The signature header itself is signed without its trailing CRLF.
+	h.Write([]byte(strings.TrimSuffix(dkimRelaxedHeader("DKIM-Signature: "+value), "\r\n")))
+
+	opts := crypto.Hash(0)
+	if s.algo == "rsa-sha256" {
+		opts = crypto.SHA256
+	}
+	sig, err := s.key.Sign(rand.Reader, h.Sum(nil), opts)
+	if err != nil {
+		return "", fmt.Errorf("signing email: %s", err)
+	}
+
+	// The signature is folded to keep the lines short.
+	b := base64.StdEncoding.EncodeToString(sig)
+	var folded []string
+	for len(b) > 72 {
+		folded = append(folded, b[:72])
+		b = b[72:]
+	}
+	folded = append(folded, b)
+	return "DKIM-Signature: " + value + strings.Join(folded, "\r\n ") + "\r\n", nil
+}
+
+// dkimHeaderFields splits the header into its fields, keeping the folded
+// lines of each field.
+func dkimHeaderFields(header []byte) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// dkimFieldName returns the name of the header field.
+func dkimFieldName(field string) string {
+	if i := strings.Index(field, ":"); i >= 0 {
+		return strings.TrimSpace(field[:i])
+	}
+	return strings.TrimSpace(field)
+}
+
+// dkimRelaxedHeader returns the header field in the relaxed canonical form
+// (RFC 6376, section 3.4.2).
+func dkimRelaxedHeader(field string) string {
+	var value string
+	if i := strings.Index(field, ":"); i >= 0 {
+		value = field[i+1:]
+	}
+	value = strings.Replace(strings.Replace(value, "\r\n", "", -1), "\n", "", -1)
+	value = strings.TrimSpace(dkimWSP.ReplaceAllString(value, " "))
+	return strings.ToLower(dkimFieldName(field)) + ":" + value + "\r\n"
+}
+
+// dkimRelaxedBody returns the body in the relaxed canonical form (RFC 6376,
+// section 3.4.4).
+func dkimRelaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(dkimWSP.ReplaceAllString(l, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// crlfLines returns the text with all line endings replaced by CRLF, as
+// they are sent over SMTP.
+func crlfLines(b []byte) []byte {
+	b = bytes.Replace(b, []byte("\r\n"), []byte("\n"), -1)
+	return bytes.Replace(b, []byte("\n"), []byte("\r\n"), -1)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// EnrichStage runs the enrichment queries of the route against Prometheus
+// and adds their results to the context for the notification templates.
+// Failed queries do not fail the notification but are reported in the
+// result.
+type EnrichStage struct {
+	tmpl *template.Template
+}
+
+// NewEnrichStage returns a new EnrichStage.
+func NewEnrichStage(tmpl *template.Template) *EnrichStage {
+	return &EnrichStage{tmpl: tmpl}
+}
+
+// Exec implements the Stage interface.
+func (s *EnrichStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	enrichments, ok := Enrichments(ctx)
+	if !ok || len(enrichments) == 0 {
+		return ctx, alerts, nil
+	}
+
+	data := s.tmpl.Data(receiverName(ctx, l), groupLabels(ctx, l), alerts...)
+	results := make(map[string]*template.Enrichment, len(enrichments))
+
+	for _, e := range enrichments {
+		var err error
+		query := tmplText(s.tmpl, data, &err)(e.Query)
+
+		r := &template.Enrichment{Query: query}
+		if err == nil {
+			r.GraphURL = e.PrometheusURL + "/graph?" + url.Values{
+				"g0.expr": {query},
+				"g0.tab":  {"0"},
+			}.Encode()
+			r.Samples, err = queryPrometheus(ctx, e, query)
+		}
+		if err != nil {
+			level.Warn(l).Log("msg", "Enrichment query failed", "enrichment", e.Name, "err", err)
+			r.Error = err.Error()
+		}
+		if len(r.Samples) > 0 {
+			r.Value = r.Samples[0].Value
+		}
+		results[e.Name] = r
+	}
+	return withEnrichmentResults(ctx, results), alerts, nil
+}
+
+// queryResponse is the response of the Prometheus instant query API.
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+func queryPrometheus(ctx context.Context, e *config.Enrichment, query string) ([]template.Sample, error) {
+	c, err := config.NewHTTPClient(e.HTTPConfig, e.CryptoPolicy, nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", e.PrometheusURL+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgentHeader)
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var qr queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&qr); err != nil {
+		return nil, fmt.Errorf("unexpected response with status code %v from Prometheus", resp.StatusCode)
+	}
+	if qr.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", qr.Error)
+	}
+
+	switch qr.Data.ResultType {
+	case "vector":
+		var v model.Vector
+		if err := json.Unmarshal(qr.Data.Result, &v); err != nil {
+			return nil, err
+		}
+		samples := make([]template.Sample, 0, len(v))
+		for _, s := range v {
+			labels := make(template.KV, len(s.Metric))
+			for k, v := range s.Metric {
+				labels[string(k)] = string(v)
+			}
+			samples = append(samples, template.Sample{Labels: labels, Value: s.Value.String()})
+		}
+		return samples, nil
+	case "scalar":
+		var s model.Scalar
+		if err := json.Unmarshal(qr.Data.Result, &s); err != nil {
+			return nil, err
+		}
+		return []template.Sample{{Labels: template.KV{}, Value: s.Value.String()}}, nil
+	}
+	return nil, fmt.Errorf("unsupported result type %q", qr.Data.ResultType)
+}
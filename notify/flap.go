@@ -0,0 +1,140 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// FlappingAnnotation is added to flapping alerts if the flap detection of
+// the receiver annotates them.
+const FlappingAnnotation = "flapping"
+
+var numFlappingAlertsHeld = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "flapping_alerts_held_total",
+	Help:      "The total number of times a flapping alert was left out of a notification.",
+}, []string{"receiver"})
+
+func init() {
+	prometheus.Register(numFlappingAlertsHeld)
+}
+
+// flapState is the state history of an alert.
+type flapState struct {
+	resolved bool
+	startsAt time.Time
+	changes  []time.Time
+	seen     time.Time
+}
+
+// FlapDetectionStage tracks how often alerts change between firing and
+// resolved and holds back or annotates the alerts that change too often.
+type FlapDetectionStage struct {
+	receiver string
+	conf     config.FlapDetectionConfig
+	now      func() time.Time
+
+	mtx    sync.Mutex
+	alerts map[model.Fingerprint]*flapState
+}
+
+// NewFlapDetectionStage returns a new FlapDetectionStage for the receiver.
+func NewFlapDetectionStage(receiver string, conf config.FlapDetectionConfig) *FlapDetectionStage {
+	return &FlapDetectionStage{
+		receiver: receiver,
+		conf:     conf,
+		now:      time.Now,
+		alerts:   map[model.Fingerprint]*flapState{},
+	}
+}
+
+// Exec implements the Stage interface.
+func (s *FlapDetectionStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var (
+		now    = s.now()
+		window = time.Duration(s.conf.Window)
+		res    = make([]*types.Alert, 0, len(alerts))
+	)
+	for _, a := range alerts {
+		if !s.update(a, now) {
+			res = append(res, a)
+			continue
+		}
+		switch s.conf.Action {
+		case config.FlapActionAnnotate:
+			fa := *a
+			fa.Annotations = a.Annotations.Clone()
+			if fa.Annotations == nil {
+				fa.Annotations = model.LabelSet{}
+			}
+			fa.Annotations[FlappingAnnotation] = "true"
+			res = append(res, &fa)
+		default:
+			numFlappingAlertsHeld.WithLabelValues(s.receiver).Inc()
+			level.Debug(l).Log("msg", "Holding notification of flapping alert", "alert", a.Name(), "receiver", s.receiver)
+		}
+	}
+
+	for fp, st := range s.alerts {
+		if now.Sub(st.seen) > window {
+			delete(s.alerts, fp)
+		}
+	}
+	return ctx, res, nil
+}
+
+// update records the current state of the alert and returns whether it is
+// flapping.
+func (s *FlapDetectionStage) update(a *types.Alert, now time.Time) bool {
+	resolved := a.ResolvedAt(now)
+
+	st, ok := s.alerts[a.Fingerprint()]
+	if !ok {
+		st = &flapState{resolved: resolved, startsAt: a.StartsAt}
+		s.alerts[a.Fingerprint()] = st
+	}
+	switch {
+	case st.resolved != resolved:
+		st.changes = append(st.changes, now)
+	case !resolved && !a.StartsAt.Equal(st.startsAt):
+		// The alert resolved and fired again since it was last seen.
+		st.changes = append(st.changes, now, now)
+	}
+	st.resolved = resolved
+	st.startsAt = a.StartsAt
+	st.seen = now
+
+	cutoff := now.Add(-time.Duration(s.conf.Window))
+	i := 0
+	for i < len(st.changes) && st.changes[i].Before(cutoff) {
+		i++
+	}
+	st.changes = st.changes[i:]
+
+	return len(st.changes) >= s.conf.Threshold
+}
@@ -17,9 +17,11 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
@@ -29,7 +31,10 @@ import (
 	"net/smtp"
 	"net/textproto"
 	"net/url"
+	"os/exec"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -37,10 +42,14 @@ import (
 	commoncfg "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/version"
+	"github.com/satori/go.uuid"
 	"golang.org/x/net/context"
 	"golang.org/x/net/context/ctxhttp"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/msgref"
+	"github.com/prometheus/alertmanager/pkg/mqtt"
+	"github.com/prometheus/alertmanager/pkg/sigv4"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 )
@@ -49,6 +58,12 @@ type notifierConfig interface {
 	SendResolved() bool
 }
 
+// retryPolicyConfig is implemented by notifier configurations that can
+// override the retry policy.
+type retryPolicyConfig interface {
+	RetryPolicy() *config.RetryConfig
+}
+
 // A Notifier notifies about alerts under constraints of the given context.
 // It returns an error if unsuccessful and a flag whether the error is
 // recoverable. This information is useful for a retry logic.
@@ -65,6 +80,17 @@ type Integration struct {
 	idx      int
 }
 
+// Name returns the name of the integration, e.g. slack.
+func (i *Integration) Name() string {
+	return i.name
+}
+
+// Index returns the index of the integration among the integrations of the
+// same name of its receiver.
+func (i *Integration) Index() int {
+	return i.idx
+}
+
 // Notify implements the Notifier interface.
 func (i *Integration) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
 	var res []*types.Alert
@@ -88,8 +114,9 @@ func (i *Integration) Notify(ctx context.Context, alerts ...*types.Alert) (bool,
 }
 
 // BuildReceiverIntegrations builds a list of integration notifiers off of a
-// receivers config.
-func BuildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, logger log.Logger) []Integration {
+// receivers config. The message references are used by notifiers that
+// follow up on sent messages and may be nil.
+func BuildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, refs *msgref.Refs, logger log.Logger) []Integration {
 	var (
 		integrations []Integration
 		add          = func(name string, i int, n Notifier, nc notifierConfig) {
@@ -101,6 +128,7 @@ func BuildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, log
 			})
 		}
 	)
+	tmpl = tmpl.Receiver(nc.Name)
 
 	for i, c := range nc.WebhookConfigs {
 		n := NewWebhook(c, tmpl, logger)
@@ -123,7 +151,7 @@ func BuildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, log
 		add("wechat", i, n, c)
 	}
 	for i, c := range nc.SlackConfigs {
-		n := NewSlack(c, tmpl, logger)
+		n := NewSlack(c, tmpl, refs, logger)
 		add("slack", i, n, c)
 	}
 	for i, c := range nc.HipchatConfigs {
@@ -138,10 +166,57 @@ func BuildReceiverIntegrations(nc *config.Receiver, tmpl *template.Template, log
 		n := NewPushover(c, tmpl, logger)
 		add("pushover", i, n, c)
 	}
+	for i, c := range nc.StatuspageConfigs {
+		n := NewStatuspage(c, tmpl, logger)
+		add("statuspage", i, n, c)
+	}
+	for i, c := range nc.GrafanaOnCallConfigs {
+		n := NewGrafanaOnCall(c, tmpl, logger)
+		add("grafana_oncall", i, n, c)
+	}
+	for i, c := range nc.KubernetesConfigs {
+		n := NewKubernetes(c, tmpl, logger)
+		add("kubernetes", i, n, c)
+	}
+	for i, c := range nc.MSTeamsConfigs {
+		n := NewMSTeams(c, tmpl, logger)
+		add("msteams", i, n, c)
+	}
+	for i, c := range nc.TelegramConfigs {
+		n := NewTelegram(c, tmpl, logger)
+		add("telegram", i, n, c)
+	}
+	for i, c := range nc.GoogleChatConfigs {
+		n := NewGoogleChat(c, tmpl, logger)
+		add("googlechat", i, n, c)
+	}
+	for i, c := range nc.DiscordConfigs {
+		n := NewDiscord(c, tmpl, logger)
+		add("discord", i, n, c)
+	}
+	for i, c := range nc.JiraConfigs {
+		n := NewJira(c, tmpl, logger)
+		add("jira", i, n, c)
+	}
+	for i, c := range nc.SNSConfigs {
+		n := NewSNS(c, tmpl, logger)
+		add("sns", i, n, c)
+	}
+	for i, c := range nc.ExecConfigs {
+		n := NewExec(c, tmpl, logger)
+		add("exec", i, n, c)
+	}
+	for i, c := range nc.MQTTConfigs {
+		n := NewMQTT(c, tmpl, logger)
+		add("mqtt", i, n, c)
+	}
 	return integrations
 }
 
-const contentTypeJSON = "application/json"
+const (
+	contentTypeJSON            = "application/json"
+	contentTypeCloudEventsJSON = "application/cloudevents+json"
+)
 
 var userAgentHeader = fmt.Sprintf("Alertmanager/%s", version.Version)
 
@@ -150,6 +225,7 @@ type Webhook struct {
 	conf   *config.WebhookConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
 // NewWebhook returns a new Webhook.
@@ -164,40 +240,99 @@ type WebhookMessage struct {
 	// The protocol version.
 	Version  string `json:"version"`
 	GroupKey string `json:"groupKey"`
+	// The number of alerts left out of the message by max_alerts.
+	TruncatedAlerts int `json:"truncatedAlerts,omitempty"`
+}
+
+// WebhookCompactMessage defines the compact JSON object send to webhook
+// endpoints, which identifies the alerts by their fingerprints.
+type WebhookCompactMessage struct {
+	Version         string                `json:"version"`
+	GroupKey        string                `json:"groupKey"`
+	Receiver        string                `json:"receiver"`
+	Status          string                `json:"status"`
+	Alerts          []WebhookCompactAlert `json:"alerts"`
+	GroupLabels     template.KV           `json:"groupLabels"`
+	CommonLabels    template.KV           `json:"commonLabels"`
+	ExternalURL     string                `json:"externalURL"`
+	TruncatedAlerts int                   `json:"truncatedAlerts,omitempty"`
+}
+
+// WebhookCompactAlert is an alert of a compact webhook message.
+type WebhookCompactAlert struct {
+	Status      string `json:"status"`
+	Fingerprint string `json:"fingerprint"`
 }
 
 // Notify implements the Notifier interface.
 func (w *Webhook) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
-	data := w.tmpl.Data(receiverName(ctx, w.logger), groupLabels(ctx, w.logger), alerts...)
+	data := templateData(ctx, w.tmpl, w.logger, alerts...)
 
 	groupKey, ok := GroupKey(ctx)
 	if !ok {
 		level.Error(w.logger).Log("msg", "group key missing")
 	}
 
-	msg := &WebhookMessage{
-		Version:  "4",
-		Data:     data,
-		GroupKey: groupKey,
+	var (
+		payload     = w.message(data, groupKey, alerts)
+		contentType = contentTypeJSON
+		event       *cloudEvent
+	)
+	if w.conf.CloudEvents != nil {
+		var err error
+		if event, err = w.cloudEvent(ctx, data); err != nil {
+			return false, err
+		}
+		if w.conf.CloudEvents.Mode == config.CloudEventsStructured {
+			event.Data = payload
+			payload = event
+			contentType = contentTypeCloudEventsJSON
+		}
 	}
 
 	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
 		return false, err
 	}
 
-	req, err := http.NewRequest("POST", w.conf.URL, &buf)
+	method := w.conf.Method
+	if method == "" {
+		method = "POST"
+	}
+	body := buf.Bytes()
+	req, err := http.NewRequest(method, w.conf.URL, &buf)
 	if err != nil {
 		return true, err
 	}
-	req.Header.Set("Content-Type", contentTypeJSON)
+	for name, value := range w.conf.Headers {
+		req.Header.Set(name, string(value))
+	}
+	if w.conf.Signing != nil {
+		// Every attempt is signed again, so that retries are not rejected
+		// as replays.
+		signWebhook(req.Header, w.conf.Signing, body, time.Now())
+	}
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", userAgentHeader)
+	if key, ok := IdempotencyKey(ctx); ok {
+		// The peers of a cluster send the same key for the same
+		// notification, so that the webhook can drop duplicates.
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if event != nil && w.conf.CloudEvents.Mode == config.CloudEventsBinary {
+		event.setHeaders(req.Header)
+	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(w.conf.HTTPConfig)
+	c, err := w.client.get(w.conf.HTTPConfig, &w.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
 
+	if w.conf.Timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(w.conf.Timeout))
+		defer cancel()
+	}
 	resp, err := ctxhttp.Do(ctx, c, req)
 	if err != nil {
 		return true, err
@@ -207,11 +342,122 @@ func (w *Webhook) Notify(ctx context.Context, alerts ...*types.Alert) (bool, err
 	return w.retry(resp.StatusCode)
 }
 
+// cloudEvent is a CloudEvents 1.0 event carrying a webhook message.
+type cloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data,omitempty"`
+}
+
+// message returns the payload of the webhook for the configured version and
+// schema, with the alerts truncated to max_alerts.
+func (w *Webhook) message(data *template.Data, groupKey string, alerts []*types.Alert) interface{} {
+	version := w.conf.Version
+	if version == "" {
+		version = "4"
+	}
+	var truncated int
+	if w.conf.MaxAlerts > 0 && len(alerts) > w.conf.MaxAlerts {
+		truncated = len(alerts) - w.conf.MaxAlerts
+		alerts = alerts[:w.conf.MaxAlerts]
+		d := *data
+		d.Alerts = d.Alerts[:w.conf.MaxAlerts]
+		data = &d
+	}
+
+	if w.conf.Payload == config.WebhookPayloadCompact {
+		msg := &WebhookCompactMessage{
+			Version:         version,
+			GroupKey:        groupKey,
+			Receiver:        data.Receiver,
+			Status:          data.Status,
+			Alerts:          make([]WebhookCompactAlert, 0, len(alerts)),
+			GroupLabels:     data.GroupLabels,
+			CommonLabels:    data.CommonLabels,
+			ExternalURL:     data.ExternalURL,
+			TruncatedAlerts: truncated,
+		}
+		for _, a := range types.Alerts(alerts...) {
+			msg.Alerts = append(msg.Alerts, WebhookCompactAlert{
+				Status:      string(a.Status()),
+				Fingerprint: a.Fingerprint().String(),
+			})
+		}
+		return msg
+	}
+	return &WebhookMessage{
+		Version:         version,
+		Data:            data,
+		GroupKey:        groupKey,
+		TruncatedAlerts: truncated,
+	}
+}
+
+func (w *Webhook) cloudEvent(ctx context.Context, data *template.Data) (*cloudEvent, error) {
+	// The event of a notification has the same ID on all peers and retries,
+	// so that consumers can drop duplicates. Only notifications that are not
+	// deduplicated, like receiver tests, have no idempotency key.
+	id, ok := IdempotencyKey(ctx)
+	if !ok {
+		id = uuid.NewV4().String()
+	}
+
+	var (
+		err   error
+		tmpl  = tmplText(w.tmpl, data, &err)
+		event = &cloudEvent{
+			SpecVersion:     "1.0",
+			ID:              id,
+			Source:          tmpl(w.conf.CloudEvents.Source),
+			Type:            tmpl(w.conf.CloudEvents.Type),
+			Subject:         tmpl(w.conf.CloudEvents.Subject),
+			Time:            time.Now().UTC(),
+			DataContentType: contentTypeJSON,
+		}
+	)
+	if err != nil {
+		return nil, err
+	}
+	if event.Source == "" || event.Type == "" {
+		return nil, fmt.Errorf("cloudevents source and type must not be empty")
+	}
+	return event, nil
+}
+
+// setHeaders sets the context attributes of the event as headers for the
+// binary content mode.
+func (e *cloudEvent) setHeaders(h http.Header) {
+	h.Set("ce-specversion", e.SpecVersion)
+	h.Set("ce-id", e.ID)
+	h.Set("ce-source", e.Source)
+	h.Set("ce-type", e.Type)
+	h.Set("ce-time", e.Time.Format(time.RFC3339Nano))
+	if e.Subject != "" {
+		h.Set("ce-subject", e.Subject)
+	}
+}
+
 func (w *Webhook) retry(statusCode int) (bool, error) {
 	// Webhooks are assumed to respond with 2xx response codes on a successful
-	// request and 5xx response codes are assumed to be recoverable.
+	// request and 5xx response codes are assumed to be recoverable, unless
+	// the retry policy lists the recoverable status codes.
 	if statusCode/100 != 2 {
-		return (statusCode/100 == 5), fmt.Errorf("unexpected status code %v from %s", statusCode, w.conf.URL)
+		retry := statusCode/100 == 5
+		if r := w.conf.Retry; r != nil && len(r.StatusCodes) > 0 {
+			retry = false
+			for _, code := range r.StatusCodes {
+				if code == statusCode {
+					retry = true
+					break
+				}
+			}
+		}
+		return retry, fmt.Errorf("unexpected status code %v from %s", statusCode, w.conf.URL)
 	}
 
 	return false, nil
@@ -222,6 +468,7 @@ type Email struct {
 	conf   *config.EmailConfig
 	tmpl   *template.Template
 	logger log.Logger
+	tokens *oauth2TokenSource
 }
 
 // NewEmail returns a new Email notifier.
@@ -235,13 +482,32 @@ func NewEmail(c *config.EmailConfig, t *template.Template, l log.Logger) *Email
 	if _, ok := c.Headers["From"]; !ok {
 		c.Headers["From"] = c.From
 	}
-	return &Email{conf: c, tmpl: t, logger: l}
+	n := &Email{conf: c, tmpl: t, logger: l}
+	if c.AuthOAuth2 != nil {
+		n.tokens = newOAuth2TokenSource(c.AuthOAuth2, c.CryptoPolicy)
+	}
+	return n
 }
 
 // auth resolves a string of authentication mechanisms.
-func (n *Email) auth(mechs string) (smtp.Auth, error) {
+func (n *Email) auth(ctx context.Context, mechs string) (smtp.Auth, error) {
 	username := n.conf.AuthUsername
 
+	// XOAUTH2 is preferred if configured as providers are disabling the
+	// other mechanisms.
+	if n.tokens != nil {
+		for _, mech := range strings.Split(mechs, " ") {
+			if mech != "XOAUTH2" {
+				continue
+			}
+			token, err := n.tokens.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return XOAuth2Auth(username, token), nil
+		}
+	}
+
 	for _, mech := range strings.Split(mechs, " ") {
 		switch mech {
 		case "CRAM-MD5":
@@ -275,8 +541,159 @@ func (n *Email) auth(mechs string) (smtp.Auth, error) {
 	return nil, nil
 }
 
+// emailAttachment is the content of an attachment fetched for an alert.
+type emailAttachment struct {
+	cid         string
+	contentType string
+	inline      bool
+	data        []byte
+}
+
+// filename returns the file name of the attachment with an extension
+// matching its content type.
+func (a *emailAttachment) filename() string {
+	mediaType, _, err := mime.ParseMediaType(a.contentType)
+	if err != nil {
+		return a.cid
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return a.cid + exts[0]
+	}
+	return a.cid
+}
+
+// fetchAttachments fetches the attachments of the alerts. Attachments that
+// cannot be fetched are left out so that the email is still sent.
+func (n *Email) fetchAttachments(ctx context.Context, as ...*types.Alert) []*emailAttachment {
+	if len(n.conf.Attachments) == 0 {
+		return nil
+	}
+	var (
+		data = templateData(ctx, n.tmpl, n.logger, as...)
+		res  []*emailAttachment
+	)
+	for _, conf := range n.conf.Attachments {
+		c, err := config.NewHTTPClient(conf.HTTPConfig, n.conf.CryptoPolicy, n.conf.HTTPTransport)
+		if err != nil {
+			level.Warn(n.logger).Log("msg", "Creating HTTP client for email attachment failed", "attachment", conf.Name, "err", err)
+			continue
+		}
+		for i, a := range data.Alerts {
+			cid := fmt.Sprintf("%s-%d", conf.Name, i)
+			u, err := n.tmpl.ExecuteTextString(conf.URL, a)
+			if err != nil {
+				level.Warn(n.logger).Log("msg", "Executing email attachment URL template failed", "attachment", cid, "err", err)
+				continue
+			}
+			if u = strings.TrimSpace(u); u == "" {
+				continue
+			}
+			att, err := fetchAttachment(ctx, c, u, conf)
+			if err != nil {
+				level.Warn(n.logger).Log("msg", "Fetching email attachment failed", "attachment", cid, "err", err)
+				continue
+			}
+			att.cid = cid
+			att.inline = conf.Inline
+			res = append(res, att)
+		}
+	}
+	return res
+}
+
+func fetchAttachment(ctx context.Context, c *http.Client, u string, conf *config.EmailAttachment) (*emailAttachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(conf.Timeout))
+	defer cancel()
+
+	resp, err := ctxhttp.Get(ctx, c, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, conf.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > conf.MaxSize {
+		return nil, fmt.Errorf("content exceeds the maximum size of %d bytes", conf.MaxSize)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(b)
+	}
+	return &emailAttachment{contentType: contentType, data: b}, nil
+}
+
+// attachEmailParts wraps the body of an email into a multipart/related part
+// holding the inline attachments, which is wrapped into a multipart/mixed
+// part holding the other attachments. It returns the content type and the
+// body of the result.
+func attachEmailParts(contentType string, body []byte, attachments []*emailAttachment) (string, []byte) {
+	for _, inline := range []bool{true, false} {
+		var parts []*emailAttachment
+		for _, a := range attachments {
+			if a.inline == inline {
+				parts = append(parts, a)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		// Writing to the buffer does not fail.
+		buffer := &bytes.Buffer{}
+		multipartWriter := multipart.NewWriter(buffer)
+		w, _ := multipartWriter.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+		w.Write(body)
+
+		disposition, subtype := "attachment", "mixed"
+		if inline {
+			disposition, subtype = "inline", "related"
+		}
+		for _, a := range parts {
+			h := textproto.MIMEHeader{
+				"Content-Type":              {a.contentType},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.filename()})},
+			}
+			if inline {
+				h.Set("Content-ID", "<"+a.cid+">")
+			}
+			w, _ := multipartWriter.CreatePart(h)
+			// Lines of base64 encoded content must not exceed 76 characters.
+			enc := base64.StdEncoding.EncodeToString(a.data)
+			for len(enc) > 76 {
+				io.WriteString(w, enc[:76]+"\r\n")
+				enc = enc[76:]
+			}
+			io.WriteString(w, enc)
+		}
+		multipartWriter.Close()
+
+		contentType = fmt.Sprintf("multipart/%s; boundary=%s", subtype, multipartWriter.Boundary())
+		body = buffer.Bytes()
+	}
+	return contentType, body
+}
+
 // Notify implements the Notifier interface.
 func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	// Attachments are fetched before connecting to the smarthost, which
+	// might close idle connections.
+	attachments := n.fetchAttachments(ctx, as...)
+
+	var signer *dkimSigner
+	if n.conf.DKIM != nil {
+		var err error
+		if signer, err = newDKIMSigner(n.conf.DKIM); err != nil {
+			return false, err
+		}
+	}
+
 	// We need to know the hostname for both auth and TLS.
 	var c *smtp.Client
 	host, port, err := net.SplitHostPort(n.conf.Smarthost)
@@ -285,7 +702,9 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	}
 
 	if port == "465" {
-		conn, err := tls.Dial("tcp", n.conf.Smarthost, &tls.Config{ServerName: host})
+		tlsConf := &tls.Config{ServerName: host}
+		n.conf.CryptoPolicy.Apply(tlsConf)
+		conn, err := tls.Dial("tcp", n.conf.Smarthost, tlsConf)
 		if err != nil {
 			return true, err
 		}
@@ -316,13 +735,14 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 			return true, fmt.Errorf("require_tls: true (default), but %q does not advertise the STARTTLS extension", n.conf.Smarthost)
 		}
 		tlsConf := &tls.Config{ServerName: host}
+		n.conf.CryptoPolicy.Apply(tlsConf)
 		if err := c.StartTLS(tlsConf); err != nil {
 			return true, fmt.Errorf("starttls failed: %s", err)
 		}
 	}
 
 	if ok, mech := c.Extension("AUTH"); ok {
-		auth, err := n.auth(mech)
+		auth, err := n.auth(ctx, mech)
 		if err != nil {
 			return true, err
 		}
@@ -334,7 +754,7 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	}
 
 	var (
-		data = n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+		data = templateData(ctx, n.tmpl, n.logger, as...)
 		tmpl = tmplText(n.tmpl, data, &err)
 		from = tmpl(n.conf.From)
 		to   = tmpl(n.conf.To)
@@ -363,32 +783,19 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 		}
 	}
 
-	// Send the email body.
-	wc, err := c.Data()
-	if err != nil {
-		return true, err
-	}
-	defer wc.Close()
-
+	// The message is assembled before it is sent, so that it can be signed.
+	msg := &bytes.Buffer{}
 	for header, t := range n.conf.Headers {
 		value, err := n.tmpl.ExecuteTextString(t, data)
 		if err != nil {
 			return false, fmt.Errorf("executing %q header template: %s", header, err)
 		}
-		fmt.Fprintf(wc, "%s: %s\r\n", header, mime.QEncoding.Encode("utf-8", value))
+		fmt.Fprintf(msg, "%s: %s\r\n", header, mime.QEncoding.Encode("utf-8", value))
 	}
 
 	buffer := &bytes.Buffer{}
 	multipartWriter := multipart.NewWriter(buffer)
 
-	fmt.Fprintf(wc, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
-	fmt.Fprintf(wc, "Content-Type: multipart/alternative;  boundary=%s\r\n", multipartWriter.Boundary())
-	fmt.Fprintf(wc, "MIME-Version: 1.0\r\n")
-
-	// TODO: Add some useful headers here, such as URL of the alertmanager
-	// and active/resolved.
-	fmt.Fprintf(wc, "\r\n")
-
 	if len(n.conf.Text) > 0 {
 		// Text template
 		w, err := multipartWriter.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
@@ -424,7 +831,38 @@ func (n *Email) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	}
 
 	multipartWriter.Close()
-	wc.Write(buffer.Bytes())
+
+	contentType, body := attachEmailParts("multipart/alternative;  boundary="+multipartWriter.Boundary(), buffer.Bytes(), attachments)
+
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+
+	// TODO: Add some useful headers here, such as URL of the alertmanager
+	// and active/resolved.
+	fmt.Fprintf(msg, "\r\n")
+	msg.Write(body)
+
+	message := msg.Bytes()
+	if signer != nil {
+		// The signature covers the message as it is sent over SMTP.
+		message = crlfLines(message)
+		sig, err := signer.sign(message)
+		if err != nil {
+			return false, err
+		}
+		message = append([]byte(sig), message...)
+	}
+
+	// Send the email body.
+	wc, err := c.Data()
+	if err != nil {
+		return true, err
+	}
+	defer wc.Close()
+	if _, err := wc.Write(message); err != nil {
+		return true, err
+	}
 
 	return false, nil
 }
@@ -434,6 +872,7 @@ type PagerDuty struct {
 	conf   *config.PagerdutyConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
 // NewPagerDuty returns a new PagerDuty notifier.
@@ -458,6 +897,19 @@ type pagerDutyMessage struct {
 	Client      string            `json:"client,omitempty"`
 	ClientURL   string            `json:"client_url,omitempty"`
 	Details     map[string]string `json:"details,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+	Images      []pagerDutyImage  `json:"images,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+type pagerDutyImage struct {
+	Src  string `json:"src"`
+	Alt  string `json:"alt,omitempty"`
+	Href string `json:"href,omitempty"`
 }
 
 type pagerDutyPayload struct {
@@ -471,12 +923,12 @@ type pagerDutyPayload struct {
 	CustomDetails map[string]string `json:"custom_details,omitempty"`
 }
 
-func (n *PagerDuty) notifyV1(ctx context.Context, c *http.Client, eventType, key string, tmpl func(string) string, details map[string]string, as ...*types.Alert) (bool, error) {
+func (n *PagerDuty) notifyV1(ctx context.Context, c *http.Client, eventType, dedupKey, serviceKey string, tmpl func(string) string, details map[string]string, as ...*types.Alert) (bool, error) {
 
 	msg := &pagerDutyMessage{
-		ServiceKey:  tmpl(string(n.conf.ServiceKey)),
+		ServiceKey:  serviceKey,
 		EventType:   eventType,
-		IncidentKey: hashKey(key),
+		IncidentKey: dedupKey,
 		Description: tmpl(n.conf.Description),
 		Details:     details,
 	}
@@ -502,17 +954,21 @@ func (n *PagerDuty) notifyV1(ctx context.Context, c *http.Client, eventType, key
 	return n.retryV1(resp.StatusCode)
 }
 
-func (n *PagerDuty) notifyV2(ctx context.Context, c *http.Client, eventType, key string, tmpl func(string) string, details map[string]string, as ...*types.Alert) (bool, error) {
+func (n *PagerDuty) notifyV2(ctx context.Context, c *http.Client, eventType, dedupKey, routingKey string, tmpl func(string) string, details map[string]string, as ...*types.Alert) (bool, error) {
 	if n.conf.Severity == "" {
 		n.conf.Severity = "error"
 	}
 
 	var payload *pagerDutyPayload
 	if eventType == pagerDutyEventTrigger {
+		severity := n.labelSeverity(as...)
+		if severity == "" {
+			severity = tmpl(n.conf.Severity)
+		}
 		payload = &pagerDutyPayload{
 			Summary:       tmpl(n.conf.Description),
 			Source:        tmpl(n.conf.Client),
-			Severity:      tmpl(n.conf.Severity),
+			Severity:      severity,
 			CustomDetails: details,
 			Class:         tmpl(n.conf.Class),
 			Component:     tmpl(n.conf.Component),
@@ -521,15 +977,25 @@ func (n *PagerDuty) notifyV2(ctx context.Context, c *http.Client, eventType, key
 	}
 
 	msg := &pagerDutyMessage{
-		RoutingKey:  tmpl(string(n.conf.RoutingKey)),
+		RoutingKey:  routingKey,
 		EventAction: eventType,
-		DedupKey:    hashKey(key),
+		DedupKey:    dedupKey,
 		Payload:     payload,
 	}
 
 	if eventType == pagerDutyEventTrigger {
 		msg.Client = tmpl(n.conf.Client)
 		msg.ClientURL = tmpl(n.conf.ClientURL)
+		for _, l := range n.conf.Links {
+			if href := tmpl(l.Href); href != "" {
+				msg.Links = append(msg.Links, pagerDutyLink{Href: href, Text: tmpl(l.Text)})
+			}
+		}
+		for _, i := range n.conf.Images {
+			if src := tmpl(i.Src); src != "" {
+				msg.Images = append(msg.Images, pagerDutyImage{Src: src, Alt: tmpl(i.Alt), Href: tmpl(i.Href)})
+			}
+		}
 	}
 
 	var buf bytes.Buffer
@@ -558,7 +1024,7 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	var err error
 	var (
 		alerts    = types.Alerts(as...)
-		data      = n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+		data      = templateData(ctx, n.tmpl, n.logger, as...)
 		tmpl      = tmplText(n.tmpl, data, &err)
 		eventType = pagerDutyEventTrigger
 	)
@@ -573,19 +1039,62 @@ func (n *PagerDuty) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		details[k] = tmpl(v)
 	}
 
+	dedupKey := hashKey(key)
+	if n.conf.DedupKey != "" {
+		if k := tmpl(n.conf.DedupKey); k != "" {
+			dedupKey = k
+		}
+	}
+
 	if err != nil {
 		return false, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
 
 	if n.conf.ServiceKey != "" {
-		return n.notifyV1(ctx, c, eventType, key, tmpl, details, as...)
+		serviceKey, err := tmplIdentity(n.tmpl, data, "service_key", string(n.conf.ServiceKey))
+		if err != nil {
+			return false, err
+		}
+		return n.notifyV1(ctx, c, eventType, dedupKey, serviceKey, tmpl, details, as...)
+	}
+	routingKey, err := tmplIdentity(n.tmpl, data, "routing_key", string(n.conf.RoutingKey))
+	if err != nil {
+		return false, err
+	}
+	return n.notifyV2(ctx, c, eventType, dedupKey, routingKey, tmpl, details, as...)
+}
+
+// labelSeverity returns the highest severity of the firing alerts taken from
+// their severity label, or an empty string if no alert has a valid severity.
+// Label values are mapped through the severity mapping first.
+func (n *PagerDuty) labelSeverity(as ...*types.Alert) string {
+	if n.conf.SeverityLabel == "" {
+		return ""
+	}
+	best := len(config.PagerdutySeverities)
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		v := string(a.Labels[n.conf.SeverityLabel])
+		if m, ok := n.conf.SeverityMapping[v]; ok {
+			v = m
+		}
+		for i, sev := range config.PagerdutySeverities {
+			if v == sev && i < best {
+				best = i
+			}
+		}
 	}
-	return n.notifyV2(ctx, c, eventType, key, tmpl, details, as...)
+	if best == len(config.PagerdutySeverities) {
+		return ""
+	}
+	return config.PagerdutySeverities[best]
 }
 
 func (n *PagerDuty) retryV1(statusCode int) (bool, error) {
@@ -614,14 +1123,18 @@ func (n *PagerDuty) retryV2(statusCode int) (bool, error) {
 type Slack struct {
 	conf   *config.SlackConfig
 	tmpl   *template.Template
+	refs   *msgref.Refs
 	logger log.Logger
+	client sharedClient
 }
 
-// NewSlack returns a new Slack notification handler.
-func NewSlack(c *config.SlackConfig, t *template.Template, l log.Logger) *Slack {
+// NewSlack returns a new Slack notification handler. The references to
+// sent messages are required to send follow-ups other than new messages.
+func NewSlack(c *config.SlackConfig, t *template.Template, refs *msgref.Refs, l log.Logger) *Slack {
 	return &Slack{
 		conf:   c,
 		tmpl:   t,
+		refs:   refs,
 		logger: l,
 	}
 }
@@ -633,7 +1146,20 @@ type slackReq struct {
 	IconEmoji   string            `json:"icon_emoji,omitempty"`
 	IconURL     string            `json:"icon_url,omitempty"`
 	LinkNames   bool              `json:"link_names,omitempty"`
+	Text        string            `json:"text,omitempty"`
 	Attachments []slackAttachment `json:"attachments"`
+
+	// Set for follow-ups to existing messages sent with the Web API.
+	ThreadTS string `json:"thread_ts,omitempty"`
+	TS       string `json:"ts,omitempty"`
+}
+
+// slackResponse is the response of the Slack Web API.
+type slackResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
 }
 
 // slackAttachment is used to display a richly-formatted message block.
@@ -647,6 +1173,8 @@ type slackAttachment struct {
 	Actions   []config.SlackAction `json:"actions,omitempty"`
 	Footer    string               `json:"footer"`
 
+	CallbackID string `json:"callback_id,omitempty"`
+
 	Color    string   `json:"color,omitempty"`
 	MrkdwnIn []string `json:"mrkdwn_in,omitempty"`
 }
@@ -655,7 +1183,7 @@ type slackAttachment struct {
 func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	var err error
 	var (
-		data     = n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+		data     = templateData(ctx, n.tmpl, n.logger, as...)
 		tmplText = tmplText(n.tmpl, data, &err)
 	)
 
@@ -668,6 +1196,8 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 		Footer:    tmplText(n.conf.Footer),
 		Color:     tmplText(n.conf.Color),
 		MrkdwnIn:  []string{"fallback", "pretext", "text"},
+
+		CallbackID: tmplText(n.conf.CallbackID),
 	}
 
 	var numFields = len(n.conf.Fields)
@@ -701,13 +1231,14 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 				Text:  tmplText(action.Text),
 				URL:   tmplText(action.URL),
 				Style: tmplText(action.Style),
+				Name:  tmplText(action.Name),
+				Value: tmplText(action.Value),
 			}
 		}
 		attachment.Actions = actions
 	}
 
 	req := &slackReq{
-		Channel:     tmplText(n.conf.Channel),
 		Username:    tmplText(n.conf.Username),
 		IconEmoji:   tmplText(n.conf.IconEmoji),
 		IconURL:     tmplText(n.conf.IconURL),
@@ -717,14 +1248,23 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if n.conf.Channel != "" {
+		if req.Channel, err = tmplIdentity(n.tmpl, data, "channel", n.conf.Channel); err != nil {
+			return false, err
+		}
+	}
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(req); err != nil {
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
 		return false, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
-	if err != nil {
+	if n.conf.BotToken != "" {
+		return n.notifyWebAPI(ctx, c, req, types.Alerts(as...).Status() == model.AlertResolved)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(req); err != nil {
 		return false, err
 	}
 
@@ -737,6 +1277,93 @@ func (n *Slack) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	return n.retry(resp.StatusCode)
 }
 
+// notifyWebAPI sends the notification with the Slack Web API. Follow-ups
+// of a group update or reply to the first message of the group until the
+// group resolves.
+func (n *Slack) notifyWebAPI(ctx context.Context, c *http.Client, req *slackReq, resolved bool) (bool, error) {
+	gkey, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+	var (
+		key      = gkey + ":" + req.Channel
+		ref      *msgref.Ref
+		hasRef   bool
+		method   = "chat.postMessage"
+		followUp = n.conf.FollowUp != config.SlackFollowUpNew && n.refs != nil
+	)
+	if followUp {
+		ref, hasRef = n.refs.Get(key)
+	}
+	if hasRef {
+		req.Channel = ref.Channel
+		switch n.conf.FollowUp {
+		case config.SlackFollowUpUpdate:
+			method = "chat.update"
+			req.TS = ref.ID
+			if resolved {
+				// Strike through the title of resolved groups.
+				a := &req.Attachments[0]
+				if a.TitleLink != "" {
+					req.Text = fmt.Sprintf("~<%s|%s>~", a.TitleLink, a.Title)
+				} else {
+					req.Text = fmt.Sprintf("~%s~", a.Title)
+				}
+				a.Title, a.TitleLink = "", ""
+			}
+		case config.SlackFollowUpThread:
+			req.ThreadTS = ref.ID
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(req); err != nil {
+		return false, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, n.conf.WebAPIURL+method, &buf)
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Content-Type", contentTypeJSON)
+	httpReq.Header.Set("Authorization", "Bearer "+string(n.conf.BotToken))
+
+	resp, err := c.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	if retry, err := n.retry(resp.StatusCode); err != nil {
+		return retry, err
+	}
+	var sr slackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return true, err
+	}
+	if !sr.OK {
+		return sr.Error == "ratelimited", fmt.Errorf("%s failed: %s", method, sr.Error)
+	}
+
+	if !followUp {
+		return false, nil
+	}
+	// Errors of the references are only logged as the notification was
+	// already sent.
+	switch {
+	case resolved && hasRef:
+		err = n.refs.Expire(key)
+	case !resolved && !hasRef:
+		err = n.refs.Set(key, sr.Channel, sr.TS)
+	}
+	if err != nil {
+		level.Warn(n.logger).Log("msg", "Storing Slack message reference failed", "err", err)
+	}
+	return false, nil
+}
+
 func (n *Slack) retry(statusCode int) (bool, error) {
 	// Only 5xx response codes are recoverable and 2xx codes are successful.
 	// https://api.slack.com/incoming-webhooks#handling_errors
@@ -753,6 +1380,7 @@ type Hipchat struct {
 	conf   *config.HipchatConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
 // NewHipchat returns a new Hipchat notification handler.
@@ -777,7 +1405,7 @@ func (n *Hipchat) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	var err error
 	var msg string
 	var (
-		data     = n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+		data     = templateData(ctx, n.tmpl, n.logger, as...)
 		tmplText = tmplText(n.tmpl, data, &err)
 		tmplHTML = tmplHTML(n.tmpl, data, &err)
 		url      = fmt.Sprintf("%sv2/room/%s/notification?auth_token=%s", n.conf.APIURL, n.conf.RoomID, n.conf.AuthToken)
@@ -805,7 +1433,7 @@ func (n *Hipchat) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		return false, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
@@ -836,6 +1464,7 @@ type Wechat struct {
 	conf   *config.WechatConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 
 	accessToken   string
 	accessTokenAt time.Time
@@ -878,7 +1507,7 @@ func (n *Wechat) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	}
 
 	level.Debug(n.logger).Log("msg", "Notifying Wechat", "incident", key)
-	data := n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+	data := templateData(ctx, n.tmpl, n.logger, as...)
 
 	var err error
 	tmpl := tmplText(n.tmpl, data, &err)
@@ -886,7 +1515,7 @@ func (n *Wechat) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 		return false, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
@@ -996,6 +1625,7 @@ type OpsGenie struct {
 	conf   *config.OpsGenieConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
 // NewOpsGenie returns a new OpsGenie notifier.
@@ -1026,7 +1656,7 @@ func (n *OpsGenie) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		return retry, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
@@ -1059,7 +1689,7 @@ func (n *OpsGenie) createRequest(ctx context.Context, as ...*types.Alert) (*http
 	if !ok {
 		return nil, false, fmt.Errorf("group key missing")
 	}
-	data := n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+	data := templateData(ctx, n.tmpl, n.logger, as...)
 
 	level.Debug(n.logger).Log("msg", "Notifying OpsGenie", "incident", key)
 
@@ -1142,6 +1772,7 @@ type VictorOps struct {
 	conf   *config.VictorOpsConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
 // NewVictorOps returns a new VictorOps notifier.
@@ -1158,14 +1789,6 @@ const (
 	victorOpsEventResolve = "RECOVERY"
 )
 
-type victorOpsMessage struct {
-	MessageType       string `json:"message_type"`
-	EntityID          string `json:"entity_id"`
-	EntityDisplayName string `json:"entity_display_name"`
-	StateMessage      string `json:"state_message"`
-	MonitoringTool    string `json:"monitoring_tool"`
-}
-
 // Notify implements the Notifier interface.
 func (n *VictorOps) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
 	victorOpsAllowedEvents := map[string]bool{
@@ -1182,9 +1805,8 @@ func (n *VictorOps) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	var err error
 	var (
 		alerts       = types.Alerts(as...)
-		data         = n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+		data         = templateData(ctx, n.tmpl, n.logger, as...)
 		tmpl         = tmplText(n.tmpl, data, &err)
-		apiURL       = fmt.Sprintf("%s%s/%s", n.conf.APIURL, n.conf.APIKey, tmpl(n.conf.RoutingKey))
 		messageType  = tmpl(n.conf.MessageType)
 		stateMessage = tmpl(n.conf.StateMessage)
 	)
@@ -1202,24 +1824,33 @@ func (n *VictorOps) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		level.Debug(n.logger).Log("msg", "Truncated stateMessage due to VictorOps stateMessage limit", "truncated_state_message", stateMessage, "incident", key)
 	}
 
-	msg := &victorOpsMessage{
-		MessageType:       messageType,
-		EntityID:          hashKey(key),
-		EntityDisplayName: tmpl(n.conf.EntityDisplayName),
-		StateMessage:      stateMessage,
-		MonitoringTool:    tmpl(n.conf.MonitoringTool),
+	msg := map[string]string{
+		"message_type":        messageType,
+		"entity_id":           hashKey(key),
+		"entity_display_name": tmpl(n.conf.EntityDisplayName),
+		"state_message":       stateMessage,
+		"monitoring_tool":     tmpl(n.conf.MonitoringTool),
+	}
+	for k, v := range n.conf.CustomFields {
+		msg[k] = tmpl(v)
 	}
 
 	if err != nil {
 		return false, fmt.Errorf("templating error: %s", err)
 	}
 
+	routingKey, err := tmplIdentity(n.tmpl, data, "routing_key", n.conf.RoutingKey)
+	if err != nil {
+		return false, err
+	}
+	apiURL := fmt.Sprintf("%s%s/%s", n.conf.APIURL, n.conf.APIKey, url.PathEscape(routingKey))
+
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
 		return false, err
 	}
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
@@ -1246,25 +1877,508 @@ func (n *VictorOps) retry(statusCode int) (bool, error) {
 	return false, nil
 }
 
-// Pushover implements a Notifier for Pushover notifications.
-type Pushover struct {
-	conf   *config.PushoverConfig
+// Statuspage implements a Notifier for Statuspage incidents.
+type Statuspage struct {
+	conf   *config.StatuspageConfig
 	tmpl   *template.Template
 	logger log.Logger
+	client sharedClient
 }
 
-// NewPushover returns a new Pushover notifier.
-func NewPushover(c *config.PushoverConfig, t *template.Template, l log.Logger) *Pushover {
-	return &Pushover{conf: c, tmpl: t, logger: l}
+// NewStatuspage returns a new Statuspage notifier.
+func NewStatuspage(c *config.StatuspageConfig, t *template.Template, l log.Logger) *Statuspage {
+	return &Statuspage{
+		conf:   c,
+		tmpl:   t,
+		logger: l,
+	}
 }
 
-// Notify implements the Notifier interface.
-func (n *Pushover) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	key, ok := GroupKey(ctx)
-	if !ok {
+const (
+	statuspageIncidentInvestigating = "investigating"
+	statuspageIncidentResolved      = "resolved"
+	statuspageComponentOperational  = "operational"
+)
+
+type statuspageIncident struct {
+	ID           string            `json:"id,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Status       string            `json:"status,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	ComponentIDs []string          `json:"component_ids,omitempty"`
+	Components   map[string]string `json:"components,omitempty"`
+}
+
+type statuspageIncidentMessage struct {
+	Incident statuspageIncident `json:"incident"`
+}
+
+// Notify implements the Notifier interface.
+//
+// Incidents are identified by their name. A new incident is created if
+// no unresolved incident with the same name exists, otherwise the existing
+// one is updated.
+func (n *Statuspage) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	var err error
+	var (
+		alerts = types.Alerts(as...)
+		data   = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl   = tmplText(n.tmpl, data, &err)
+		name   = tmpl(n.conf.Name)
+		body   = tmpl(n.conf.Body)
+	)
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if name == "" {
+		return false, fmt.Errorf("empty Statuspage incident name")
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	incident, retry, err := n.findIncident(ctx, c, name)
+	if err != nil {
+		return retry, err
+	}
+
+	msg := statuspageIncidentMessage{
+		Incident: statuspageIncident{
+			Body:       body,
+			Components: n.components(as),
+		},
+	}
+
+	if alerts.Status() == model.AlertResolved {
+		if incident == nil {
+			// Nothing to resolve.
+			return false, nil
+		}
+		msg.Incident.Status = statuspageIncidentResolved
+		return n.send(ctx, c, "PATCH", n.incidentsURL()+"/"+incident.ID, msg)
+	}
+
+	if incident != nil {
+		return n.send(ctx, c, "PATCH", n.incidentsURL()+"/"+incident.ID, msg)
+	}
+
+	msg.Incident.Name = name
+	msg.Incident.Status = statuspageIncidentInvestigating
+	for id := range msg.Incident.Components {
+		msg.Incident.ComponentIDs = append(msg.Incident.ComponentIDs, id)
+	}
+	sort.Strings(msg.Incident.ComponentIDs)
+
+	return n.send(ctx, c, "POST", n.incidentsURL(), msg)
+}
+
+func (n *Statuspage) incidentsURL() string {
+	return fmt.Sprintf("%spages/%s/incidents", n.conf.APIURL, url.PathEscape(n.conf.PageID))
+}
+
+// components returns the status of all components affected by the alerts.
+// Components of resolved alerts are operational unless a firing alert
+// affects them as well.
+func (n *Statuspage) components(as []*types.Alert) map[string]string {
+	components := map[string]string{}
+
+	for _, a := range as {
+		v := string(a.Labels[model.LabelName(n.conf.ComponentLabel)])
+		if v == "" {
+			continue
+		}
+		id := v
+		if len(n.conf.Components) > 0 {
+			var ok bool
+			if id, ok = n.conf.Components[v]; !ok {
+				continue
+			}
+		}
+		if a.Resolved() {
+			if _, ok := components[id]; !ok {
+				components[id] = statuspageComponentOperational
+			}
+			continue
+		}
+		components[id] = n.conf.ComponentStatus
+	}
+	return components
+}
+
+// findIncident returns the unresolved incident with the given name, if any.
+func (n *Statuspage) findIncident(ctx context.Context, c *http.Client, name string) (*statuspageIncident, bool, error) {
+	req, err := http.NewRequest("GET", n.incidentsURL()+"/unresolved", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", n.conf.APIKey))
+	req.Header.Set("User-Agent", userAgentHeader)
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if retry, err := n.retry(resp.StatusCode); err != nil {
+		return nil, retry, err
+	}
+
+	var incidents []statuspageIncident
+	if err := json.NewDecoder(resp.Body).Decode(&incidents); err != nil {
+		return nil, false, fmt.Errorf("decoding Statuspage incidents: %s", err)
+	}
+	for _, inc := range incidents {
+		if inc.Name == name {
+			return &inc, false, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (n *Statuspage) send(ctx context.Context, c *http.Client, method, url string, msg statuspageIncidentMessage) (bool, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Authorization", fmt.Sprintf("OAuth %s", n.conf.APIKey))
+	req.Header.Set("User-Agent", userAgentHeader)
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *Statuspage) retry(statusCode int) (bool, error) {
+	// Statuspage rate limits requests with 420 and 429 responses.
+	if statusCode/100 == 5 || statusCode == 420 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// GrafanaOnCall implements a Notifier for the formatted webhook integration
+// of Grafana OnCall.
+type GrafanaOnCall struct {
+	conf   *config.GrafanaOnCallConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewGrafanaOnCall returns a new Grafana OnCall notifier.
+func NewGrafanaOnCall(c *config.GrafanaOnCallConfig, t *template.Template, l log.Logger) *GrafanaOnCall {
+	return &GrafanaOnCall{conf: c, tmpl: t, logger: l}
+}
+
+const (
+	grafanaOnCallStateAlerting = "alerting"
+	grafanaOnCallStateOK       = "ok"
+)
+
+type grafanaOnCallMessage struct {
+	AlertUID              string `json:"alert_uid"`
+	Title                 string `json:"title"`
+	Message               string `json:"message"`
+	ImageURL              string `json:"image_url,omitempty"`
+	State                 string `json:"state"`
+	LinkToUpstreamDetails string `json:"link_to_upstream_details,omitempty"`
+}
+
+// Notify implements the Notifier interface.
+func (n *GrafanaOnCall) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		alerts = types.Alerts(as...)
+		data   = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl   = tmplText(n.tmpl, data, &err)
+	)
+	msg := &grafanaOnCallMessage{
+		AlertUID:              hashKey(key),
+		Title:                 tmpl(n.conf.Title),
+		Message:               tmpl(n.conf.Message),
+		ImageURL:              tmpl(n.conf.ImageURL),
+		State:                 grafanaOnCallStateAlerting,
+		LinkToUpstreamDetails: tmpl(n.conf.LinkToUpstreamDetails),
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if alerts.Status() == model.AlertResolved {
+		msg.State = grafanaOnCallStateOK
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return false, err
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := ctxhttp.Post(ctx, c, string(n.conf.URL), contentTypeJSON, &buf)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *GrafanaOnCall) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Kubernetes implements a Notifier that creates Kubernetes events for the
+// objects referenced by alert labels.
+type Kubernetes struct {
+	conf   *config.KubernetesConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewKubernetes returns a new Kubernetes notifier.
+func NewKubernetes(c *config.KubernetesConfig, t *template.Template, l log.Logger) *Kubernetes {
+	return &Kubernetes{conf: c, tmpl: t, logger: l}
+}
+
+// kubernetesObjectLabels maps alert labels to the kinds of objects they
+// reference, in order of precedence.
+var kubernetesObjectLabels = []struct {
+	label      model.LabelName
+	kind       string
+	apiVersion string
+	namespaced bool
+}{
+	{"deployment", "Deployment", "apps/v1", true},
+	{"statefulset", "StatefulSet", "apps/v1", true},
+	{"daemonset", "DaemonSet", "apps/v1", true},
+	{"job_name", "Job", "batch/v1", true},
+	{"pod", "Pod", "v1", true},
+	{"node", "Node", "v1", false},
+}
+
+type kubernetesObjectReference struct {
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+}
+
+type kubernetesEvent struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels,omitempty"`
+	} `json:"metadata"`
+	InvolvedObject kubernetesObjectReference `json:"involvedObject"`
+	Reason         string                    `json:"reason"`
+	Message        string                    `json:"message"`
+	Type           string                    `json:"type"`
+	FirstTimestamp time.Time                 `json:"firstTimestamp"`
+	LastTimestamp  time.Time                 `json:"lastTimestamp"`
+	Count          int                       `json:"count"`
+	Source         struct {
+		Component string `json:"component"`
+	} `json:"source"`
+}
+
+// object returns the object referenced by the labels. Alerts that only carry
+// a namespace reference the namespace itself.
+func (n *Kubernetes) object(ls model.LabelSet) (kubernetesObjectReference, bool) {
+	ns := string(ls[model.LabelName(n.conf.NamespaceLabel)])
+	for _, o := range kubernetesObjectLabels {
+		name := string(ls[o.label])
+		if name == "" || (o.namespaced && ns == "") {
+			continue
+		}
+		ref := kubernetesObjectReference{Kind: o.kind, Name: name, APIVersion: o.apiVersion}
+		if o.namespaced {
+			ref.Namespace = ns
+		}
+		return ref, true
+	}
+	if ns != "" {
+		return kubernetesObjectReference{Kind: "Namespace", Name: ns, APIVersion: "v1"}, true
+	}
+	return kubernetesObjectReference{}, false
+}
+
+// Notify implements the Notifier interface.
+func (n *Kubernetes) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var (
+		objects []kubernetesObjectReference
+		alerts  = map[kubernetesObjectReference][]*types.Alert{}
+	)
+	for _, a := range as {
+		ref, ok := n.object(a.Labels)
+		if !ok {
+			level.Debug(n.logger).Log("msg", "Alert does not reference a Kubernetes object", "alert", a)
+			continue
+		}
+		if _, ok := alerts[ref]; !ok {
+			objects = append(objects, ref)
+		}
+		alerts[ref] = append(alerts[ref], a)
+	}
+	if len(objects) == 0 {
+		return false, nil
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	for _, ref := range objects {
+		if retry, err := n.send(ctx, c, key, ref, alerts[ref]...); err != nil {
+			return retry, err
+		}
+	}
+	return false, nil
+}
+
+// send creates the event for the object or updates it if it already exists.
+func (n *Kubernetes) send(ctx context.Context, c *http.Client, key string, ref kubernetesObjectReference, as ...*types.Alert) (bool, error) {
+	var err error
+	var (
+		data = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl = tmplText(n.tmpl, data, &err)
+		now  = time.Now()
+	)
+	ev := &kubernetesEvent{
+		APIVersion:     "v1",
+		Kind:           "Event",
+		InvolvedObject: ref,
+		Reason:         tmpl(n.conf.Reason),
+		Message:        tmpl(n.conf.Message),
+		Type:           "Warning",
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if types.Alerts(as...).Status() == model.AlertResolved {
+		ev.Type = "Normal"
+	}
+	// Events of cluster-scoped objects are stored in the default namespace.
+	ev.Metadata.Namespace = ref.Namespace
+	if ev.Metadata.Namespace == "" {
+		ev.Metadata.Namespace = "default"
+	}
+	ev.Metadata.Name = fmt.Sprintf("%s.%s", ref.Name, hashKey(key + "/" + ref.Kind + "/" + ref.Name)[:16])
+	ev.Metadata.Labels = map[string]string{"app.kubernetes.io/managed-by": "alertmanager"}
+	ev.Source.Component = "alertmanager"
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(ev); err != nil {
+		return false, err
+	}
+	u := fmt.Sprintf("%sapi/v1/namespaces/%s/events", n.conf.APIServer, url.PathEscape(ev.Metadata.Namespace))
+
+	resp, err := ctxhttp.Post(ctx, c, u, contentTypeJSON, &buf)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		return n.retry(resp.StatusCode)
+	}
+
+	// The event exists already, update it in place.
+	buf.Reset()
+	patch := map[string]interface{}{
+		"reason":        ev.Reason,
+		"message":       ev.Message,
+		"type":          ev.Type,
+		"lastTimestamp": ev.LastTimestamp,
+	}
+	if err := json.NewEncoder(&buf).Encode(patch); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("PATCH", u+"/"+url.PathEscape(ev.Metadata.Name), &buf)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, err = ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *Kubernetes) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Pushover implements a Notifier for Pushover notifications.
+type Pushover struct {
+	conf   *config.PushoverConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewPushover returns a new Pushover notifier.
+func NewPushover(c *config.PushoverConfig, t *template.Template, l log.Logger) *Pushover {
+	return &Pushover{conf: c, tmpl: t, logger: l}
+}
+
+// Notify implements the Notifier interface.
+func (n *Pushover) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
 		return false, fmt.Errorf("group key missing")
 	}
-	data := n.tmpl.Data(receiverName(ctx, n.logger), groupLabels(ctx, n.logger), as...)
+	data := templateData(ctx, n.tmpl, n.logger, as...)
 
 	level.Debug(n.logger).Log("msg", "Notifying Pushover", "incident", key)
 
@@ -1316,7 +2430,7 @@ func (n *Pushover) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	u.RawQuery = parameters.Encode()
 	level.Debug(n.logger).Log("msg", "Sending Pushover message", "incident", key, "url", u.String())
 
-	c, err := commoncfg.NewHTTPClientFromConfig(n.conf.HTTPConfig)
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
 	if err != nil {
 		return false, err
 	}
@@ -1343,13 +2457,90 @@ func (n *Pushover) retry(statusCode int) (bool, error) {
 	return false, nil
 }
 
-func tmplText(tmpl *template.Template, data *template.Data, err *error) func(string) string {
-	return func(name string) (s string) {
-		if *err != nil {
-			return
+// sharedClient holds the HTTP client of an integration. With keep-alive
+// enabled in the HTTP transport configuration the client is created once
+// and reused for all notifications of the integration, so that their
+// connections are pooled.
+//
+// With OAuth 2.0 configured the access tokens are cached across clients
+// until they expire.
+type sharedClient struct {
+	mtx    sync.Mutex
+	client *http.Client
+	tokens *oauth2TokenSource
+}
+
+// get returns the HTTP client of the integration.
+func (s *sharedClient) get(cfg *commoncfg.HTTPClientConfig, nc *config.NotifierConfig) (*http.Client, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+	c, err := config.NewHTTPClient(cfg, nc.CryptoPolicy, nc.HTTPTransport)
+	if err != nil {
+		return nil, err
+	}
+	if nc.OAuth2 != nil {
+		if s.tokens == nil {
+			s.tokens = newOAuth2TokenSource(nc.OAuth2, nc.CryptoPolicy)
 		}
-		s, *err = tmpl.ExecuteTextString(name, data)
-		return s
+		c.Transport = &oauth2RoundTripper{tokens: s.tokens, rt: c.Transport}
+	}
+	if nc.HTTPTransport != nil && nc.HTTPTransport.KeepAlive {
+		s.client = c
+	}
+	return c, nil
+}
+
+// templateData returns the template data of a notification for the alerts.
+func templateData(ctx context.Context, tmpl *template.Template, l log.Logger, alerts ...*types.Alert) *template.Data {
+	data := tmpl.Data(receiverName(ctx, l), groupLabels(ctx, l), alerts...)
+	data.Enrichments = enrichmentResults(ctx)
+	for _, g := range digestGroups(ctx) {
+		gd := tmpl.Data(receiverName(ctx, l), g.labels, g.alerts...)
+		data.Groups = append(data.Groups, template.Group{
+			Labels: gd.GroupLabels,
+			Status: gd.Status,
+			Alerts: gd.Alerts,
+		})
+	}
+	return data
+}
+
+// identityError is returned by integrations that fail to template a field
+// identifying the recipient of the notification.
+type identityError struct {
+	field string
+	err   error
+}
+
+func (e *identityError) Error() string {
+	return fmt.Sprintf("templating %s failed: %s", e.field, e.err)
+}
+
+// tmplIdentity templates the text of a field identifying the recipient of
+// the notification, e.g. a routing key derived from the group labels.
+// Unlike other fields, it fails if the text renders empty.
+func tmplIdentity(tmpl *template.Template, data *template.Data, field, text string) (string, error) {
+	s, err := tmpl.ExecuteTextString(text, data)
+	if err == nil && strings.TrimSpace(s) == "" {
+		err = fmt.Errorf("the template rendered an empty value")
+	}
+	if err != nil {
+		return "", &identityError{field: field, err: err}
+	}
+	return s, nil
+}
+
+func tmplText(tmpl *template.Template, data *template.Data, err *error) func(string) string {
+	return func(name string) (s string) {
+		if *err != nil {
+			return
+		}
+		s, *err = tmpl.ExecuteTextString(name, data)
+		return s
 	}
 }
 
@@ -1363,6 +2554,137 @@ func tmplHTML(tmpl *template.Template, data *template.Data, err *error) func(str
 	}
 }
 
+type xoauth2Auth struct {
+	username, token string
+}
+
+// XOAuth2Auth returns an smtp.Auth that implements the XOAUTH2 mechanism
+// with an OAuth 2.0 access token.
+func XOAuth2Auth(username, token string) smtp.Auth {
+	return &xoauth2Auth{username, token}
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// The server describes a failed authentication in a challenge that
+		// is answered with an empty response to receive the error.
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// oauth2TokenSource obtains access tokens with the OAuth 2.0 client
+// credentials grant and caches them until shortly before they expire.
+type oauth2TokenSource struct {
+	conf   *config.OAuth2Config
+	policy *config.CryptoPolicy
+	now    func() time.Time
+
+	mtx       sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newOAuth2TokenSource(c *config.OAuth2Config, policy *config.CryptoPolicy) *oauth2TokenSource {
+	return &oauth2TokenSource{conf: c, policy: policy, now: time.Now}
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token returns a valid access token.
+func (s *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.token != "" && s.now().Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "client_credentials")
+	params.Set("client_id", s.conf.ClientID)
+	params.Set("client_secret", string(s.conf.ClientSecret))
+	if len(s.conf.Scopes) > 0 {
+		params.Set("scope", strings.Join(s.conf.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, s.conf.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	c, err := config.NewHTTPClient(&commoncfg.HTTPClientConfig{}, s.policy, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("unexpected status code %d from token URL: %s", resp.StatusCode, body)
+	}
+	var tr oauth2TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	if tr.AccessToken == "" {
+		return "", fmt.Errorf("no access token returned from token URL")
+	}
+
+	s.token = tr.AccessToken
+	// Renew the token a minute before it expires. Tokens without expiry
+	// are not cached.
+	s.expiresAt = s.now().Add(time.Duration(tr.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// reset drops the cached access token, e.g. after it was rejected.
+func (s *oauth2TokenSource) reset() {
+	s.mtx.Lock()
+	s.token = ""
+	s.mtx.Unlock()
+}
+
+// oauth2RoundTripper sets an access token of the token source as bearer
+// token of the requests. A token rejected with 401 Unauthorized is dropped,
+// so that the retry of the notification obtains a new one.
+type oauth2RoundTripper struct {
+	tokens *oauth2TokenSource
+	rt     http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (rt *oauth2RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.tokens.Token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain oauth2 access token: %s", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	next := rt.rt
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.tokens.reset()
+	}
+	return resp, err
+}
+
 type loginAuth struct {
 	username, password string
 }
@@ -1397,3 +2719,1122 @@ func hashKey(s string) string {
 	h.Write([]byte(s))
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
+
+// MSTeams implements a Notifier for Microsoft Teams incoming webhooks.
+type MSTeams struct {
+	conf   *config.MSTeamsConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewMSTeams returns a new Microsoft Teams notifier.
+func NewMSTeams(c *config.MSTeamsConfig, t *template.Template, l log.Logger) *MSTeams {
+	return &MSTeams{conf: c, tmpl: t, logger: l}
+}
+
+const (
+	msTeamsColorRed   = "8C1A1A"
+	msTeamsColorGreen = "2DC72D"
+)
+
+// msTeamsMessageCard is a legacy actionable message card, which is what
+// incoming webhooks accept.
+type msTeamsMessageCard struct {
+	Type            string                 `json:"@type"`
+	Context         string                 `json:"@context"`
+	ThemeColor      string                 `json:"themeColor"`
+	Summary         string                 `json:"summary"`
+	Title           string                 `json:"title"`
+	Text            string                 `json:"text"`
+	PotentialAction []msTeamsOpenURIAction `json:"potentialAction,omitempty"`
+}
+
+type msTeamsOpenURIAction struct {
+	Type    string          `json:"@type"`
+	Name    string          `json:"name"`
+	Targets []msTeamsTarget `json:"targets"`
+}
+
+type msTeamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+// Notify implements the Notifier interface.
+func (n *MSTeams) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	var err error
+	var (
+		alerts = types.Alerts(as...)
+		data   = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl   = tmplText(n.tmpl, data, &err)
+	)
+	card := &msTeamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: msTeamsColorRed,
+		Title:      tmpl(n.conf.Title),
+		Summary:    tmpl(n.conf.Summary),
+		Text:       tmpl(n.conf.Text),
+	}
+	if data.ExternalURL != "" {
+		card.PotentialAction = []msTeamsOpenURIAction{{
+			Type:    "OpenUri",
+			Name:    "View in Alertmanager",
+			Targets: []msTeamsTarget{{OS: "default", URI: tmpl(`{{ template "__alertmanagerURL" . }}`)}},
+		}}
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if alerts.Status() == model.AlertResolved {
+		card.ThemeColor = msTeamsColorGreen
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(card); err != nil {
+		return false, err
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := ctxhttp.Post(ctx, c, string(n.conf.WebhookURL), contentTypeJSON, &buf)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *MSTeams) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Telegram implements a Notifier for Telegram bots.
+type Telegram struct {
+	conf   *config.TelegramConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewTelegram returns a new Telegram notifier.
+func NewTelegram(c *config.TelegramConfig, t *template.Template, l log.Logger) *Telegram {
+	return &Telegram{conf: c, tmpl: t, logger: l}
+}
+
+// telegramMaxMessageLength is the maximum number of characters of a message.
+const telegramMaxMessageLength = 4096
+
+type telegramMessage struct {
+	ChatID              int64  `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode,omitempty"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+}
+
+// Notify implements the Notifier interface.
+func (n *Telegram) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		data = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl = tmplText(n.tmpl, data, &err)
+	)
+	text := tmpl(n.conf.Message)
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if r := []rune(text); len(r) > telegramMaxMessageLength {
+		text = string(r[:telegramMaxMessageLength-1]) + "…"
+		level.Debug(n.logger).Log("msg", "Truncated message due to Telegram message limit", "truncated_message", text, "incident", key)
+	}
+
+	msg := &telegramMessage{
+		ChatID:              n.conf.ChatID,
+		Text:                text,
+		ParseMode:           n.conf.ParseMode,
+		DisableNotification: n.conf.DisableNotifications,
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return false, err
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	u := fmt.Sprintf("%sbot%s/sendMessage", n.conf.APIURL, n.conf.BotToken)
+	resp, err := ctxhttp.Post(ctx, c, u, contentTypeJSON, &buf)
+	if err != nil {
+		// Do not leak the bot token, which is part of the URL.
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = strings.Replace(ue.URL, string(n.conf.BotToken), "<secret>", -1)
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *Telegram) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// GoogleChat implements a Notifier for Google Chat incoming webhooks.
+type GoogleChat struct {
+	conf   *config.GoogleChatConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewGoogleChat returns a new Google Chat notifier.
+func NewGoogleChat(c *config.GoogleChatConfig, t *template.Template, l log.Logger) *GoogleChat {
+	return &GoogleChat{conf: c, tmpl: t, logger: l}
+}
+
+// googleChatMessage is a message with a single card of the Google Chat API.
+type googleChatMessage struct {
+	Text    string           `json:"text,omitempty"`
+	CardsV2 []googleChatCard `json:"cardsV2"`
+}
+
+type googleChatCard struct {
+	CardID string             `json:"cardId"`
+	Card   googleChatCardBody `json:"card"`
+}
+
+type googleChatCardBody struct {
+	Header   googleChatCardHeader `json:"header"`
+	Sections []googleChatSection  `json:"sections"`
+}
+
+type googleChatCardHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type googleChatSection struct {
+	Widgets []googleChatWidget `json:"widgets"`
+}
+
+type googleChatWidget struct {
+	TextParagraph *googleChatTextParagraph `json:"textParagraph,omitempty"`
+	ButtonList    *googleChatButtonList    `json:"buttonList,omitempty"`
+}
+
+type googleChatTextParagraph struct {
+	Text string `json:"text"`
+}
+
+type googleChatButtonList struct {
+	Buttons []googleChatButton `json:"buttons"`
+}
+
+type googleChatButton struct {
+	Text    string            `json:"text"`
+	OnClick googleChatOnClick `json:"onClick"`
+}
+
+type googleChatOnClick struct {
+	OpenLink struct {
+		URL string `json:"url"`
+	} `json:"openLink"`
+}
+
+// Notify implements the Notifier interface.
+func (n *GoogleChat) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		alerts = types.Alerts(as...)
+		data   = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl   = tmplText(n.tmpl, data, &err)
+	)
+	section := googleChatSection{
+		Widgets: []googleChatWidget{{
+			TextParagraph: &googleChatTextParagraph{Text: tmpl(n.conf.Text)},
+		}},
+	}
+	if data.ExternalURL != "" {
+		button := googleChatButton{Text: "View in Alertmanager"}
+		button.OnClick.OpenLink.URL = tmpl(`{{ template "__alertmanagerURL" . }}`)
+		section.Widgets = append(section.Widgets, googleChatWidget{
+			ButtonList: &googleChatButtonList{Buttons: []googleChatButton{button}},
+		})
+	}
+	msg := &googleChatMessage{
+		CardsV2: []googleChatCard{{
+			CardID: hashKey(key),
+			Card: googleChatCardBody{
+				Header: googleChatCardHeader{
+					Title:    tmpl(n.conf.Title),
+					Subtitle: strings.ToUpper(string(alerts.Status())),
+				},
+				Sections: []googleChatSection{section},
+			},
+		}},
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return false, err
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := ctxhttp.Post(ctx, c, string(n.conf.WebhookURL), contentTypeJSON, &buf)
+	if err != nil {
+		// Do not leak the key and token of the webhook, which are part of
+		// the URL.
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = "<secret>"
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *GoogleChat) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Discord implements a Notifier for Discord webhooks.
+type Discord struct {
+	conf   *config.DiscordConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewDiscord returns a new Discord notifier.
+func NewDiscord(c *config.DiscordConfig, t *template.Template, l log.Logger) *Discord {
+	return &Discord{conf: c, tmpl: t, logger: l}
+}
+
+const (
+	// discordMaxTitleLength and discordMaxDescriptionLength are the maximum
+	// number of characters of the title and the description of an embed.
+	discordMaxTitleLength       = 256
+	discordMaxDescriptionLength = 4096
+
+	discordColorRed   = 0xCC2A36
+	discordColorGreen = 0x2DC72D
+)
+
+type discordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+	Color       int    `json:"color"`
+}
+
+// Notify implements the Notifier interface.
+func (n *Discord) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		alerts = types.Alerts(as...)
+		data   = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl   = tmplText(n.tmpl, data, &err)
+	)
+	embed := discordEmbed{
+		Title:       tmpl(n.conf.Title),
+		Description: tmpl(n.conf.Message),
+		Color:       discordColorRed,
+	}
+	if data.ExternalURL != "" {
+		embed.URL = tmpl(`{{ template "__alertmanagerURL" . }}`)
+	}
+	msg := &discordMessage{
+		Username:  tmpl(n.conf.Username),
+		AvatarURL: tmpl(n.conf.AvatarURL),
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if alerts.Status() == model.AlertResolved {
+		embed.Color = discordColorGreen
+	}
+	if r := []rune(embed.Title); len(r) > discordMaxTitleLength {
+		embed.Title = string(r[:discordMaxTitleLength-1]) + "…"
+		level.Debug(n.logger).Log("msg", "Truncated title due to Discord embed limit", "truncated_title", embed.Title, "incident", key)
+	}
+	if r := []rune(embed.Description); len(r) > discordMaxDescriptionLength {
+		embed.Description = string(r[:discordMaxDescriptionLength-1]) + "…"
+		level.Debug(n.logger).Log("msg", "Truncated message due to Discord embed limit", "truncated_message", embed.Description, "incident", key)
+	}
+	msg.Embeds = []discordEmbed{embed}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
+		return false, err
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := ctxhttp.Post(ctx, c, string(n.conf.WebhookURL), contentTypeJSON, &buf)
+	if err != nil {
+		// Do not leak the token of the webhook, which is part of the URL.
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = "<secret>"
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	return n.retry(resp.StatusCode)
+}
+
+func (n *Discord) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Jira implements a Notifier for Jira issues. The open issue of a group is
+// found by a label derived from the group key, so that later notifications
+// of the group update it instead of opening another one.
+type Jira struct {
+	conf   *config.JiraConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+}
+
+// NewJira returns a new Jira notifier.
+func NewJira(c *config.JiraConfig, t *template.Template, l log.Logger) *Jira {
+	return &Jira{conf: c, tmpl: t, logger: l}
+}
+
+const (
+	// jiraMaxSummaryLength and jiraMaxDescriptionLength are the maximum
+	// number of characters of the summary and the description of an issue.
+	jiraMaxSummaryLength     = 255
+	jiraMaxDescriptionLength = 32767
+)
+
+type jiraIssue struct {
+	Key    string     `json:"key,omitempty"`
+	Fields jiraFields `json:"fields"`
+}
+
+type jiraFields struct {
+	// Project and IssueType are only set when creating issues.
+	Project     *jiraRef `json:"project,omitempty"`
+	IssueType   *jiraRef `json:"issuetype,omitempty"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+}
+
+type jiraRef struct {
+	ID   string `json:"id,omitempty"`
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type jiraSearch struct {
+	JQL        string   `json:"jql"`
+	MaxResults int      `json:"maxResults"`
+	Fields     []string `json:"fields"`
+}
+
+// jiraGroupLabel returns the label identifying the issues of the group.
+func jiraGroupLabel(key string) string {
+	return "alertmanager-" + hashKey(key)
+}
+
+// Notify implements the Notifier interface.
+func (n *Jira) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		alerts  = types.Alerts(as...)
+		data    = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl    = tmplText(n.tmpl, data, &err)
+		project = tmpl(n.conf.Project)
+		fields  = jiraFields{
+			Summary:     tmpl(n.conf.Summary),
+			Description: tmpl(n.conf.Description),
+			Labels:      []string{jiraGroupLabel(key)},
+		}
+		comment = tmpl(n.conf.ResolveComment)
+	)
+	for _, l := range n.conf.Labels {
+		if l = tmpl(l); l != "" {
+			fields.Labels = append(fields.Labels, l)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if r := []rune(fields.Summary); len(r) > jiraMaxSummaryLength {
+		fields.Summary = string(r[:jiraMaxSummaryLength-1]) + "…"
+		level.Debug(n.logger).Log("msg", "Truncated summary due to Jira limit", "truncated_summary", fields.Summary, "incident", key)
+	}
+	if r := []rune(fields.Description); len(r) > jiraMaxDescriptionLength {
+		fields.Description = string(r[:jiraMaxDescriptionLength-1]) + "…"
+		level.Debug(n.logger).Log("msg", "Truncated description due to Jira limit", "incident", key)
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+
+	issueKey, retry, err := n.search(ctx, c, project, fields.Labels[0])
+	if err != nil {
+		return retry, err
+	}
+	resolved := alerts.Status() == model.AlertResolved
+
+	if issueKey == "" {
+		// There is no open issue to resolve.
+		if resolved {
+			return false, nil
+		}
+		issueType, err := tmplIdentity(n.tmpl, data, "issue_type", n.conf.IssueType)
+		if err != nil {
+			return false, err
+		}
+		fields.Project = &jiraRef{Key: project}
+		fields.IssueType = &jiraRef{Name: issueType}
+		var created jiraIssue
+		if retry, err := n.request(ctx, c, http.MethodPost, "issue", &jiraIssue{Fields: fields}, &created); err != nil {
+			return retry, err
+		}
+		level.Debug(n.logger).Log("msg", "Created Jira issue", "issue", created.Key, "incident", key)
+		return false, nil
+	}
+
+	if retry, err := n.request(ctx, c, http.MethodPut, "issue/"+issueKey, &jiraIssue{Fields: fields}, nil); err != nil {
+		return retry, err
+	}
+	if !resolved {
+		return false, nil
+	}
+	// The comment is added before the transition, as resolved issues are
+	// no longer found when the notification is retried.
+	if comment != "" {
+		body := struct {
+			Body string `json:"body"`
+		}{comment}
+		if retry, err := n.request(ctx, c, http.MethodPost, "issue/"+issueKey+"/comment", &body, nil); err != nil {
+			return retry, err
+		}
+	}
+	if n.conf.ResolveTransition != "" {
+		return n.transition(ctx, c, issueKey)
+	}
+	return false, nil
+}
+
+// search returns the key of the open issue of the project with the label,
+// or an empty key if there is none.
+func (n *Jira) search(ctx context.Context, c *http.Client, project, label string) (string, bool, error) {
+	var res struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	retry, err := n.request(ctx, c, http.MethodPost, "search", &jiraSearch{
+		JQL:        fmt.Sprintf("project = %q AND labels = %q AND statusCategory != Done ORDER BY created DESC", project, label),
+		MaxResults: 1,
+		Fields:     []string{"status"},
+	}, &res)
+	if err != nil || len(res.Issues) == 0 {
+		return "", retry, err
+	}
+	return res.Issues[0].Key, false, nil
+}
+
+// transition applies the resolve transition to the issue.
+func (n *Jira) transition(ctx context.Context, c *http.Client, issueKey string) (bool, error) {
+	path := "issue/" + issueKey + "/transitions"
+	var res struct {
+		Transitions []jiraRef `json:"transitions"`
+	}
+	if retry, err := n.request(ctx, c, http.MethodGet, path, nil, &res); err != nil {
+		return retry, err
+	}
+	for _, t := range res.Transitions {
+		if !strings.EqualFold(t.Name, n.conf.ResolveTransition) {
+			continue
+		}
+		body := struct {
+			Transition jiraRef `json:"transition"`
+		}{jiraRef{ID: t.ID}}
+		return n.request(ctx, c, http.MethodPost, path, &body, nil)
+	}
+	return false, fmt.Errorf("transition %q is not available for issue %s", n.conf.ResolveTransition, issueKey)
+}
+
+// request sends the body as JSON to the path of the API and decodes the
+// response into res unless it is nil.
+func (n *Jira) request(ctx context.Context, c *http.Client, method, path string, body, res interface{}) (bool, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return false, err
+		}
+	}
+	req, err := http.NewRequest(method, n.conf.APIURL+path, &buf)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Accept", contentTypeJSON)
+	req.Header.Set("User-Agent", userAgentHeader)
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if retry, err := n.retry(resp.StatusCode); err != nil {
+		return retry, fmt.Errorf("%s %s: %s", method, path, err)
+	}
+	if res == nil {
+		return false, nil
+	}
+	return false, json.NewDecoder(resp.Body).Decode(res)
+}
+
+func (n *Jira) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// SNS implements a Notifier for AWS SNS.
+type SNS struct {
+	conf   *config.SNSConfig
+	tmpl   *template.Template
+	logger log.Logger
+	client sharedClient
+	stsURL string
+
+	// roleCredentials are the cached credentials of the assumed role.
+	mtx             sync.Mutex
+	roleCredentials sigv4.Credentials
+}
+
+// NewSNS returns a new SNS notifier.
+func NewSNS(c *config.SNSConfig, t *template.Template, l log.Logger) *SNS {
+	return &SNS{
+		conf:   c,
+		tmpl:   t,
+		logger: l,
+		stsURL: fmt.Sprintf("https://sts.%s.amazonaws.com/", c.SigV4.Region),
+	}
+}
+
+const (
+	snsMaxSubjectLength = 100
+	snsMaxMessageLength = 256 * 1024
+)
+
+// credentials returns the configured credentials or, if a role is set, the
+// temporary credentials of the role.
+func (n *SNS) credentials(ctx context.Context, c *http.Client) (sigv4.Credentials, error) {
+	creds := sigv4.Credentials{
+		AccessKeyID:     n.conf.SigV4.AccessKey,
+		SecretAccessKey: string(n.conf.SigV4.SecretKey),
+	}
+	if creds.AccessKeyID == "" {
+		var err error
+		if creds, err = sigv4.EnvCredentials(); err != nil {
+			return sigv4.Credentials{}, err
+		}
+	}
+	if n.conf.SigV4.RoleARN == "" {
+		return creds, nil
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	// Renew the credentials of the role well before they expire.
+	if n.roleCredentials.Expires.Sub(time.Now()) > 5*time.Minute {
+		return n.roleCredentials, nil
+	}
+	rc, err := sigv4.AssumeRole(ctx, c, n.stsURL, n.conf.SigV4.Region, creds, n.conf.SigV4.RoleARN, "alertmanager")
+	if err != nil {
+		return sigv4.Credentials{}, fmt.Errorf("assume role %q: %s", n.conf.SigV4.RoleARN, err)
+	}
+	n.roleCredentials = rc
+	return rc, nil
+}
+
+// snsSubject returns the subject restricted to what SNS accepts: printable
+// ASCII characters without line breaks.
+func snsSubject(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		if r < ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(s)
+	if len(s) > snsMaxSubjectLength {
+		s = s[:snsMaxSubjectLength-3] + "..."
+	}
+	return s
+}
+
+// Notify implements the Notifier interface.
+func (n *SNS) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	key, ok := GroupKey(ctx)
+	if !ok {
+		return false, fmt.Errorf("group key missing")
+	}
+
+	var err error
+	var (
+		data       = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl       = tmplText(n.tmpl, data, &err)
+		subject    = tmpl(n.conf.Subject)
+		message    = tmpl(n.conf.Message)
+		attributes = map[string]string{}
+	)
+	for name, value := range n.conf.Attributes {
+		if v := tmpl(value); v != "" {
+			attributes[name] = v
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+
+	if len(message) > snsMaxMessageLength {
+		message = message[:snsMaxMessageLength-3] + "..."
+		level.Debug(n.logger).Log("msg", "Truncated message due to SNS message limit", "incident", key)
+	}
+	params := url.Values{
+		"Action":  {"Publish"},
+		"Version": {"2010-03-31"},
+		"Message": {message},
+	}
+	if n.conf.TopicARN != "" {
+		params.Set("TopicArn", n.conf.TopicARN)
+		// The subject is only used for email subscriptions.
+		if subject = snsSubject(subject); subject != "" {
+			params.Set("Subject", subject)
+		}
+	} else {
+		params.Set("PhoneNumber", n.conf.PhoneNumber)
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		prefix := fmt.Sprintf("MessageAttributes.entry.%d.", i+1)
+		params.Set(prefix+"Name", name)
+		params.Set(prefix+"Value.DataType", "String")
+		params.Set(prefix+"Value.StringValue", attributes[name])
+	}
+
+	c, err := n.client.get(n.conf.HTTPConfig, &n.conf.NotifierConfig)
+	if err != nil {
+		return false, err
+	}
+	creds, err := n.credentials(ctx, c)
+	if err != nil {
+		return true, err
+	}
+
+	body := []byte(params.Encode())
+	req, err := http.NewRequest("POST", n.conf.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sigv4.Sign(req, body, creds, n.conf.SigV4.Region, "sns", time.Now())
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	retry, err := n.retry(resp.StatusCode)
+	if err != nil {
+		b, _ := ioutil.ReadAll(resp.Body)
+		err = sigv4.ParseError(resp.StatusCode, b)
+		// Throttled requests are rejected as client errors.
+		if e, ok := err.(*sigv4.ErrorResponse); ok && e.Code == "Throttling" {
+			retry = true
+		}
+	}
+	return retry, err
+}
+
+func (n *SNS) retry(statusCode int) (bool, error) {
+	if statusCode/100 == 5 || statusCode == http.StatusTooManyRequests {
+		return true, fmt.Errorf("unexpected status code %v", statusCode)
+	} else if statusCode/100 != 2 {
+		return false, fmt.Errorf("unexpected status code %v", statusCode)
+	}
+
+	return false, nil
+}
+
+// Exec implements a Notifier that runs a local command.
+type Exec struct {
+	conf   *config.ExecConfig
+	tmpl   *template.Template
+	logger log.Logger
+	// sem limits the concurrent runs of the command if not nil.
+	sem chan struct{}
+}
+
+// NewExec returns a new Exec notifier.
+func NewExec(c *config.ExecConfig, t *template.Template, l log.Logger) *Exec {
+	n := &Exec{conf: c, tmpl: t, logger: l}
+	if c.MaxConcurrency > 0 {
+		n.sem = make(chan struct{}, c.MaxConcurrency)
+	}
+	return n
+}
+
+// execMaxOutput is the maximum number of bytes of the output of a failed
+// command that are added to the error.
+const execMaxOutput = 512
+
+// Notify implements the Notifier interface.
+func (n *Exec) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	data := templateData(ctx, n.tmpl, n.logger, as...)
+
+	groupKey, ok := GroupKey(ctx)
+	if !ok {
+		level.Error(n.logger).Log("msg", "group key missing")
+	}
+
+	var stdin bytes.Buffer
+	if err := json.NewEncoder(&stdin).Encode(&WebhookMessage{
+		Version:  "4",
+		Data:     data,
+		GroupKey: groupKey,
+	}); err != nil {
+		return false, err
+	}
+
+	if n.sem != nil {
+		select {
+		case n.sem <- struct{}{}:
+			defer func() { <-n.sem }()
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(n.conf.Timeout))
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, n.conf.Command, n.conf.Args...)
+	cmd.Stdin = &stdin
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Children of killed commands may keep the output open.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+
+	// The command is killed once the context is done.
+	err := cmd.Wait()
+	numExecExits.WithLabelValues(fmt.Sprint(cmd.ProcessState.ExitCode())).Inc()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		out := output.String()
+		if len(out) > execMaxOutput {
+			out = out[:execMaxOutput] + "..."
+		}
+		// Failed runs are retried as the command may depend on resources
+		// that are temporarily unavailable.
+		return true, fmt.Errorf("command %s failed: %v: %s", n.conf.Command, err, strings.TrimSpace(out))
+	}
+	return false, nil
+}
+
+// MQTT implements a Notifier for MQTT brokers.
+type MQTT struct {
+	conf   *config.MQTTConfig
+	tmpl   *template.Template
+	logger log.Logger
+	// addr is the host and port of the broker and key identifies the
+	// pooled connection to it.
+	addr     string
+	key      string
+	clientID string
+}
+
+// NewMQTT returns a new MQTT notifier.
+func NewMQTT(c *config.MQTTConfig, t *template.Template, l log.Logger) *MQTT {
+	n := &MQTT{conf: c, tmpl: t, logger: l}
+
+	// The configuration was validated to contain a URL with a host.
+	u, _ := url.Parse(c.URL)
+	n.addr = u.Host
+	if u.Port() == "" {
+		if config.MQTTSecureSchemes[u.Scheme] {
+			n.addr = net.JoinHostPort(u.Hostname(), "8883")
+		} else {
+			n.addr = net.JoinHostPort(u.Hostname(), "1883")
+		}
+	}
+
+	n.key = fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%+v\x00%s", c.URL, c.ClientID, c.Username, c.Password, c.TLSConfig, c.KeepAlive)
+	n.clientID = c.ClientID
+	if n.clientID == "" {
+		// Client identifiers of up to 23 characters are accepted by all
+		// brokers.
+		h := sha256.Sum256([]byte(mqttInstance + n.key))
+		n.clientID = fmt.Sprintf("alertmanager-%x", h[:5])
+	}
+	return n
+}
+
+const (
+	mqttMinBackoff = time.Second
+	mqttMaxBackoff = time.Minute
+	// mqttMaxIdle is the time after which unused pooled connections, e.g.
+	// those of receivers removed by reloading the configuration, are closed.
+	mqttMaxIdle = 10 * time.Minute
+)
+
+// mqttInstance makes the default client identifiers unique to the process,
+// as brokers disconnect clients whose identifier is reused.
+var mqttInstance = uuid.NewV4().String()
+
+// mqttConns are the connections to MQTT brokers. They are shared by the
+// notifiers with the same connection settings and outlive reloads of the
+// configuration.
+var mqttConns = &mqttPool{conns: map[string]*mqttConn{}}
+
+type mqttPool struct {
+	mtx   sync.Mutex
+	conns map[string]*mqttConn
+}
+
+// mqttConn is a pooled connection and the backoff of reconnecting it.
+type mqttConn struct {
+	mtx      sync.Mutex
+	conn     *mqtt.Conn
+	failures int
+	retryAt  time.Time
+
+	// lastUsed is protected by the mutex of the pool.
+	lastUsed time.Time
+}
+
+// get returns the pooled connection of the key and closes the connections
+// that have not been used for a while.
+func (p *mqttPool) get(key string) *mqttConn {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := time.Now()
+	for k, c := range p.conns {
+		if k == key || now.Sub(c.lastUsed) < mqttMaxIdle || !c.mtx.TryLock() {
+			continue
+		}
+		if c.conn != nil {
+			c.conn.Close()
+		}
+		c.mtx.Unlock()
+		delete(p.conns, k)
+	}
+
+	c, ok := p.conns[key]
+	if !ok {
+		c = &mqttConn{}
+		p.conns[key] = c
+	}
+	c.lastUsed = now
+	return c
+}
+
+// Notify implements the Notifier interface.
+func (n *MQTT) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	var (
+		err     error
+		data    = templateData(ctx, n.tmpl, n.logger, as...)
+		tmpl    = tmplText(n.tmpl, data, &err)
+		topic   = tmpl(n.conf.Topic)
+		payload []byte
+	)
+	if n.conf.Payload != "" {
+		payload = []byte(tmpl(n.conf.Payload))
+	}
+	if err != nil {
+		return false, fmt.Errorf("templating error: %s", err)
+	}
+	if err := mqtt.ValidateTopic(topic); err != nil {
+		return false, err
+	}
+	if payload == nil {
+		groupKey, ok := GroupKey(ctx)
+		if !ok {
+			level.Error(n.logger).Log("msg", "group key missing")
+		}
+		if payload, err = json.Marshal(&WebhookMessage{
+			Version:  "4",
+			Data:     data,
+			GroupKey: groupKey,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	c := mqttConns.get(n.key)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// Brokers close connections that were idle for longer than the keep
+	// alive, which is only noticed when waiting for an acknowledgement.
+	if c.conn != nil && c.conn.Idle() >= time.Duration(n.conf.KeepAlive) {
+		c.conn.Close()
+		c.conn = nil
+	}
+	reused := c.conn != nil
+	if !reused {
+		if retry, err := n.connect(ctx, c); err != nil {
+			return retry, err
+		}
+	}
+
+	err = c.conn.Publish(ctx, topic, payload, byte(n.conf.QoS), n.conf.Retain)
+	if err != nil && reused {
+		level.Debug(n.logger).Log("msg", "Reconnecting to MQTT broker", "broker", n.addr, "err", err)
+		c.conn.Close()
+		c.conn = nil
+		if retry, err := n.connect(ctx, c); err != nil {
+			return retry, err
+		}
+		err = c.conn.Publish(ctx, topic, payload, byte(n.conf.QoS), n.conf.Retain)
+	}
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return true, err
+	}
+	return false, nil
+}
+
+// connect opens the connection of c unless the backoff after previous
+// failures has not passed yet.
+func (n *MQTT) connect(ctx context.Context, c *mqttConn) (bool, error) {
+	if d := time.Until(c.retryAt); d > 0 {
+		return true, fmt.Errorf("reconnecting to MQTT broker %s in %s", n.addr, d.Round(time.Millisecond))
+	}
+
+	opts := mqtt.Options{
+		ClientID:  n.clientID,
+		Username:  n.conf.Username,
+		Password:  string(n.conf.Password),
+		KeepAlive: time.Duration(n.conf.KeepAlive),
+	}
+	u, _ := url.Parse(n.conf.URL)
+	if config.MQTTSecureSchemes[u.Scheme] {
+		tlsConfig, err := commoncfg.NewTLSConfig(&n.conf.TLSConfig)
+		if err != nil {
+			return false, err
+		}
+		n.conf.CryptoPolicy.Apply(tlsConfig)
+		opts.TLSConfig = tlsConfig
+	}
+
+	conn, err := mqtt.Dial(ctx, n.addr, opts)
+	if err != nil {
+		backoff := mqttMaxBackoff
+		if c.failures < 6 {
+			backoff = mqttMinBackoff << uint(c.failures)
+		}
+		c.failures++
+		c.retryAt = time.Now().Add(backoff)
+		// Refused credentials or identifiers need a configuration change.
+		ce, ok := err.(*mqtt.ConnectError)
+		return !ok || ce.Temporary(), fmt.Errorf("connect to MQTT broker %s: %s", n.addr, err)
+	}
+	c.conn = conn
+	c.failures = 0
+	c.retryAt = time.Time{}
+	return false, nil
+}
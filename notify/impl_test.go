@@ -14,18 +14,41 @@
 package notify
 
 import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	commoncfg "github.com/prometheus/common/config"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/msgref"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
@@ -39,6 +62,349 @@ func TestWebhookRetry(t *testing.T) {
 	}
 }
 
+func TestWebhookRetryPolicy(t *testing.T) {
+	notifier := &Webhook{conf: &config.WebhookConfig{
+		URL:   "http://example.com/",
+		Retry: &config.RetryConfig{StatusCodes: []int{http.StatusConflict, http.StatusServiceUnavailable}},
+	}}
+	retryCodes := []int{http.StatusConflict, http.StatusServiceUnavailable}
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestWebhookRequest(t *testing.T) {
+	var req *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	ctx = WithIdempotencyKey(ctx, "5d41402abc4b2a76b9719d911017c592")
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+
+	conf := &config.WebhookConfig{
+		URL:        srv.URL,
+		Method:     "PUT",
+		Headers:    map[string]config.Secret{"Authorization": "Token s3cr3t"},
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+	}
+	notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+
+	_, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, "PUT", req.Method)
+	require.Equal(t, "Token s3cr3t", req.Header.Get("Authorization"))
+	require.Equal(t, contentTypeJSON, req.Header.Get("Content-Type"))
+	require.Equal(t, "5d41402abc4b2a76b9719d911017c592", req.Header.Get("Idempotency-Key"))
+
+	// Requests exceeding the timeout fail and are retried.
+	conf.URL = srv.URL + "/slow"
+	conf.Timeout = model.Duration(10 * time.Millisecond)
+	retry, err := notifier.Notify(ctx, alert)
+	require.Error(t, err)
+	require.True(t, retry)
+}
+
+func TestWebhookSigning(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	conf := &config.WebhookConfig{
+		URL:        srv.URL,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+		Signing: &config.WebhookSigningConfig{
+			Secret:          "s3cr3t",
+			SignatureHeader: "X-Signature",
+			TimestampHeader: "X-Timestamp",
+		},
+	}
+	notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+	_, err := notifier.Notify(WithGroupKey(context.Background(), "1"), alert)
+	require.NoError(t, err)
+
+	sig, ts := header.Get("X-Signature"), header.Get("X-Timestamp")
+	require.True(t, strings.HasPrefix(sig, "sha256="))
+	now := time.Now()
+	require.NoError(t, VerifyWebhookSignature("s3cr3t", sig, ts, body, time.Minute, now))
+
+	// Forged, tampered and replayed requests are rejected.
+	require.EqualError(t, VerifyWebhookSignature("other", sig, ts, body, time.Minute, now), "invalid signature")
+	require.EqualError(t, VerifyWebhookSignature("s3cr3t", sig, ts, append(body, ' '), time.Minute, now), "invalid signature")
+	require.Error(t, VerifyWebhookSignature("s3cr3t", sig, ts, body, time.Minute, now.Add(2*time.Minute)))
+	require.EqualError(t, VerifyWebhookSignature("s3cr3t", "", ts, body, time.Minute, now), "missing signature")
+}
+
+func TestWebhookKeepAlive(t *testing.T) {
+	var (
+		mtx   sync.Mutex
+		conns int
+	)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mtx.Lock()
+			conns++
+			mtx.Unlock()
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+
+	for _, tc := range []struct {
+		transport *config.HTTPTransportConfig
+		conns     int
+	}{
+		{
+			transport: nil,
+			conns:     3,
+		},
+		{
+			transport: &config.HTTPTransportConfig{KeepAlive: true, MaxIdleConnsPerHost: 1},
+			conns:     1,
+		},
+	} {
+		mtx.Lock()
+		conns = 0
+		mtx.Unlock()
+
+		conf := &config.WebhookConfig{
+			NotifierConfig: config.NotifierConfig{HTTPTransport: tc.transport},
+			URL:            srv.URL,
+			HTTPConfig:     &commoncfg.HTTPClientConfig{},
+		}
+		notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+		for i := 0; i < 3; i++ {
+			_, err := notifier.Notify(ctx, alert)
+			require.NoError(t, err)
+		}
+
+		mtx.Lock()
+		require.Equal(t, tc.conns, conns)
+		mtx.Unlock()
+	}
+}
+
+func TestWebhookOAuth2(t *testing.T) {
+	var tokens int
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "alerts:write", r.PostForm.Get("scope"))
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "Bearer", "expires_in": 3600}`, tokens)
+	}))
+	defer tokenSrv.Close()
+
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		if len(auth) == 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+	conf := &config.WebhookConfig{
+		NotifierConfig: config.NotifierConfig{
+			OAuth2: &config.OAuth2Config{
+				ClientID:     "alertmanager",
+				ClientSecret: "secret",
+				TokenURL:     tokenSrv.URL,
+				Scopes:       []string{"alerts:write"},
+			},
+		},
+		URL:        srv.URL,
+		HTTPConfig: &commoncfg.HTTPClientConfig{BearerToken: "static"},
+	}
+	notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+
+	// The token is reused until it is rejected.
+	_, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	_, err = notifier.Notify(ctx, alert)
+	require.Error(t, err)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bearer token1", "Bearer token1", "Bearer token2"}, auth)
+	require.Equal(t, 2, tokens)
+}
+
+func TestWebhookCloudEvents(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "Test"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+
+	ce := config.DefaultCloudEventsConfig
+	ce.Subject = "{{ .GroupLabels.alertname }}"
+	conf := &config.WebhookConfig{URL: srv.URL, CloudEvents: &ce, HTTPConfig: &commoncfg.HTTPClientConfig{}}
+	notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+
+	// Structured mode wraps the message into the event.
+	_, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, contentTypeCloudEventsJSON, req.Header.Get("Content-Type"))
+
+	var event struct {
+		cloudEvent
+		Data WebhookMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &event))
+	require.Equal(t, "1.0", event.SpecVersion)
+	require.NotEmpty(t, event.ID)
+	require.Equal(t, "http://am", event.Source)
+	require.Equal(t, "io.prometheus.alertmanager.notification", event.Type)
+	require.Equal(t, "Test", event.Subject)
+	require.Equal(t, contentTypeJSON, event.DataContentType)
+	require.Equal(t, "4", event.Data.Version)
+	require.Equal(t, "firing", event.Data.Status)
+
+	// Binary mode sends the message as is with the attributes as headers.
+	ce.Mode = config.CloudEventsBinary
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, contentTypeJSON, req.Header.Get("Content-Type"))
+	require.Equal(t, "1.0", req.Header.Get("ce-specversion"))
+	require.NotEmpty(t, req.Header.Get("ce-id"))
+	require.NotEqual(t, event.ID, req.Header.Get("ce-id"))
+	require.Equal(t, "http://am", req.Header.Get("ce-source"))
+	require.Equal(t, "io.prometheus.alertmanager.notification", req.Header.Get("ce-type"))
+	require.Equal(t, "Test", req.Header.Get("ce-subject"))
+
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal(body, &msg))
+	require.Equal(t, "4", msg.Version)
+
+	// The events of deduplicated notifications are identified by their
+	// idempotency key, which all peers share.
+	ctx = WithIdempotencyKey(ctx, "key")
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, "key", req.Header.Get("ce-id"))
+
+	ce.Mode = config.CloudEventsStructured
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &event))
+	require.Equal(t, "key", event.ID)
+}
+
+func TestWebhookPayload(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "Test"})
+	var alerts []*types.Alert
+	for _, instance := range []model.LabelValue{"a", "b", "c"} {
+		alerts = append(alerts, &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "Test", "instance": instance},
+				StartsAt: time.Now(),
+			},
+		})
+	}
+
+	conf := &config.WebhookConfig{
+		URL:        srv.URL,
+		HTTPConfig: &commoncfg.HTTPClientConfig{},
+		Version:    "5",
+		MaxAlerts:  2,
+		Payload:    config.WebhookPayloadFull,
+	}
+	notifier := NewWebhook(conf, createTmpl(t), log.NewNopLogger())
+
+	_, err := notifier.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal(body, &msg))
+	require.Equal(t, "5", msg.Version)
+	require.Equal(t, 1, msg.TruncatedAlerts)
+	require.Len(t, msg.Alerts, 2)
+	require.Equal(t, "b", msg.Alerts[1].Labels["instance"])
+
+	conf.Payload = config.WebhookPayloadCompact
+	_, err = notifier.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	var compact WebhookCompactMessage
+	require.NoError(t, json.Unmarshal(body, &compact))
+	require.Equal(t, 1, compact.TruncatedAlerts)
+	require.Equal(t, template.KV{"alertname": "Test"}, compact.CommonLabels)
+	require.Equal(t, []WebhookCompactAlert{
+		{Status: "firing", Fingerprint: alerts[0].Fingerprint().String()},
+		{Status: "firing", Fingerprint: alerts[1].Fingerprint().String()},
+	}, compact.Alerts)
+	require.NotContains(t, string(body), "instance")
+
+	// Without a limit no alerts are truncated.
+	conf.MaxAlerts = 0
+	_, err = notifier.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	compact = WebhookCompactMessage{}
+	require.NoError(t, json.Unmarshal(body, &compact))
+	require.Equal(t, 0, compact.TruncatedAlerts)
+	require.Len(t, compact.Alerts, 3)
+	require.NotContains(t, string(body), "truncatedAlerts")
+}
+
 func TestPagerDutyRetryV1(t *testing.T) {
 	notifier := new(PagerDuty)
 
@@ -59,6 +425,67 @@ func TestPagerDutyRetryV2(t *testing.T) {
 	}
 }
 
+func TestPagerDutyV2(t *testing.T) {
+	var msg pagerDutyMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg = pagerDutyMessage{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultPagerdutyConfig
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	conf.URL = srv.URL
+	conf.RoutingKey = "12345"
+	conf.DedupKey = `{{ .CommonLabels.incident }}`
+	conf.Links = []*config.PagerdutyLink{
+		{Href: `{{ .CommonAnnotations.runbook }}`, Text: "Runbook"},
+		{Href: `{{ .CommonAnnotations.dashboard }}`, Text: "Dashboard"},
+	}
+	conf.Images = []*config.PagerdutyImage{{Src: "https://example.org/graph.png", Alt: "Graph"}}
+	conf.SeverityLabel = "severity"
+	conf.SeverityMapping = map[string]string{"page": "critical", "ticket": "warning"}
+	notifier := NewPagerDuty(&conf, createTmpl(t), log.NewNopLogger())
+
+	newAlert := func(ls model.LabelSet, endsAt time.Time) *types.Alert {
+		return &types.Alert{
+			Alert: model.Alert{
+				Labels:      ls,
+				Annotations: model.LabelSet{"runbook": "https://example.org/runbook"},
+				StartsAt:    time.Now().Add(-time.Hour),
+				EndsAt:      endsAt,
+			},
+		}
+	}
+	firing := time.Now().Add(time.Hour)
+	ctx := WithGroupKey(context.Background(), "1")
+
+	_, err := notifier.Notify(ctx,
+		newAlert(model.LabelSet{"alertname": "a", "incident": "INC-1", "severity": "ticket"}, firing),
+		newAlert(model.LabelSet{"alertname": "b", "incident": "INC-1", "severity": "page"}, time.Now().Add(-time.Minute)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "INC-1", msg.DedupKey)
+	require.Equal(t, []pagerDutyLink{{Href: "https://example.org/runbook", Text: "Runbook"}}, msg.Links)
+	require.Equal(t, []pagerDutyImage{{Src: "https://example.org/graph.png", Alt: "Graph"}}, msg.Images)
+	// The resolved alert's severity is ignored.
+	require.Equal(t, "warning", msg.Payload.Severity)
+
+	// Without a mapped or valid severity label the severity template is used
+	// and the dedup key falls back to the hash of the group key.
+	_, err = notifier.Notify(ctx, newAlert(model.LabelSet{"alertname": "a", "severity": "unknown"}, firing))
+	require.NoError(t, err)
+	require.Equal(t, hashKey("1"), msg.DedupKey)
+	require.Equal(t, "error", msg.Payload.Severity)
+
+	_, err = notifier.Notify(ctx,
+		newAlert(model.LabelSet{"alertname": "a", "severity": "info"}, firing),
+		newAlert(model.LabelSet{"alertname": "b", "severity": "page"}, firing),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "critical", msg.Payload.Severity)
+}
+
 func TestSlackRetry(t *testing.T) {
 	notifier := new(Slack)
 	for statusCode, expected := range retryTests(defaultRetryCodes()) {
@@ -102,6 +529,86 @@ func TestPushoverRetry(t *testing.T) {
 	}
 }
 
+func TestStatuspageRetry(t *testing.T) {
+	notifier := new(Statuspage)
+
+	retryCodes := append(defaultRetryCodes(), 420, http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestGrafanaOnCallRetry(t *testing.T) {
+	notifier := new(GrafanaOnCall)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestMSTeamsRetry(t *testing.T) {
+	notifier := new(MSTeams)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestTelegramRetry(t *testing.T) {
+	notifier := new(Telegram)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestGoogleChatRetry(t *testing.T) {
+	notifier := new(GoogleChat)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestDiscordRetry(t *testing.T) {
+	notifier := new(Discord)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestJiraRetry(t *testing.T) {
+	notifier := new(Jira)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
+func TestSNSRetry(t *testing.T) {
+	notifier := new(SNS)
+
+	retryCodes := append(defaultRetryCodes(), http.StatusTooManyRequests)
+	for statusCode, expected := range retryTests(retryCodes) {
+		actual, _ := notifier.retry(statusCode)
+		require.Equal(t, expected, actual, fmt.Sprintf("error on status %d", statusCode))
+	}
+}
+
 func retryTests(retryCodes []int) map[int]bool {
 	tests := map[int]bool{
 		// 1xx
@@ -273,3 +780,1143 @@ func TestOpsGenie(t *testing.T) {
 	require.Equal(t, true, retry)
 	require.Equal(t, expectedBody, readBody(t, req))
 }
+
+func TestVictorOps(t *testing.T) {
+	var (
+		path string
+		msg  map[string]string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+	}))
+	defer srv.Close()
+
+	conf := &config.VictorOpsConfig{
+		HTTPConfig:   &commoncfg.HTTPClientConfig{},
+		APIKey:       "12345",
+		APIURL:       srv.URL + "/",
+		RoutingKey:   `{{ .GroupLabels.team }}`,
+		MessageType:  "CRITICAL",
+		CustomFields: map[string]string{"severity": `{{ .CommonLabels.severity }}`},
+	}
+	notifier := NewVictorOps(conf, createTmpl(t), log.NewNopLogger())
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"team": "db", "severity": "page"},
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		},
+	}
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"team": "db"})
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, "/12345/db", path)
+	require.Equal(t, "CRITICAL", msg["message_type"])
+	require.Equal(t, "page", msg["severity"])
+
+	// The routing key renders empty without the team label.
+	ctx = WithGroupLabels(ctx, model.LabelSet{})
+	_, err = notifier.Notify(ctx, alert)
+	require.Error(t, err)
+	require.IsType(t, &identityError{}, err)
+}
+
+func TestStatuspage(t *testing.T) {
+	type request struct {
+		method, path string
+		msg          statuspageIncidentMessage
+	}
+	var (
+		requests   []request
+		unresolved = "[]"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "OAuth s3cr3t", r.Header.Get("Authorization"))
+		if r.Method == "GET" {
+			require.Equal(t, "/pages/page1/incidents/unresolved", r.URL.Path)
+			fmt.Fprint(w, unresolved)
+			return
+		}
+		var msg statuspageIncidentMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		requests = append(requests, request{r.Method, r.URL.Path, msg})
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultStatuspageConfig
+	conf.APIURL = srv.URL + "/"
+	conf.APIKey = "s3cr3t"
+	conf.PageID = "page1"
+	conf.Components = map[string]string{"api": "cmp1", "web": "cmp2"}
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewStatuspage(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupLabels(context.Background(), model.LabelSet{"alertname": "HighLatency"})
+	newAlert := func(component string, resolved bool) *types.Alert {
+		a := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "HighLatency", "component": model.LabelValue(component)},
+				Annotations: model.LabelSet{"summary": "Latency is high"},
+				StartsAt:    time.Now().Add(-time.Hour),
+			},
+		}
+		if resolved {
+			a.EndsAt = time.Now().Add(-time.Minute)
+		}
+		return a
+	}
+
+	// A new incident is created for firing alerts.
+	retry, err := notifier.Notify(ctx, newAlert("api", false), newAlert("web", true), newAlert("unmapped", false))
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, []request{{
+		method: "POST",
+		path:   "/pages/page1/incidents",
+		msg: statuspageIncidentMessage{Incident: statuspageIncident{
+			Name:         "HighLatency",
+			Status:       "investigating",
+			Body:         "Latency is high",
+			ComponentIDs: []string{"cmp1", "cmp2"},
+			Components:   map[string]string{"cmp1": "major_outage", "cmp2": "operational"},
+		}},
+	}}, requests)
+
+	// The existing incident is resolved.
+	requests = nil
+	unresolved = `[{"id":"inc1","name":"HighLatency"}]`
+	retry, err = notifier.Notify(ctx, newAlert("api", true))
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, []request{{
+		method: "PATCH",
+		path:   "/pages/page1/incidents/inc1",
+		msg: statuspageIncidentMessage{Incident: statuspageIncident{
+			Status:     "resolved",
+			Body:       "This incident has been resolved.",
+			Components: map[string]string{"cmp1": "operational"},
+		}},
+	}}, requests)
+
+	// Resolving without an open incident does nothing.
+	requests = nil
+	unresolved = "[]"
+	_, err = notifier.Notify(ctx, newAlert("api", true))
+	require.NoError(t, err)
+	require.Len(t, requests, 0)
+}
+
+func TestGrafanaOnCall(t *testing.T) {
+	var msgs []grafanaOnCallMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/integrations/v1/formatted_webhook/t0k3n/", r.URL.Path)
+		var msg grafanaOnCallMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+	}))
+	defer srv.Close()
+
+	tmpl := createTmpl(t)
+	tmpl.Funcs(template.FuncMap{
+		"oncall": func(provider, schedule string) ([]string, error) {
+			return []string{"alice", "bob"}, nil
+		},
+	})
+
+	conf := config.DefaultGrafanaOnCallConfig
+	conf.URL = config.Secret(srv.URL + "/integrations/v1/formatted_webhook/t0k3n/")
+	conf.Message = `On call: {{ oncall "oncall" "team-x" | join ", " }}`
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewGrafanaOnCall(&conf, tmpl, log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency"},
+			StartsAt: time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	alert.EndsAt = time.Now().Add(-time.Minute)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	require.Len(t, msgs, 2)
+	require.Equal(t, hashKey("1"), msgs[0].AlertUID)
+	require.Equal(t, "[FIRING:1] HighLatency ", msgs[0].Title)
+	require.Equal(t, "On call: alice, bob", msgs[0].Message)
+	require.Equal(t, "alerting", msgs[0].State)
+	require.Equal(t, msgs[0].AlertUID, msgs[1].AlertUID)
+	require.Equal(t, "ok", msgs[1].State)
+}
+
+func TestMSTeams(t *testing.T) {
+	var cards []msTeamsMessageCard
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var card msTeamsMessageCard
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&card))
+		cards = append(cards, card)
+	}))
+	defer srv.Close()
+
+	tmpl := createTmpl(t)
+	tmpl.ExternalURL, _ = url.Parse("http://am.example.com")
+
+	conf := config.DefaultMSTeamsConfig
+	conf.WebhookURL = config.Secret(srv.URL)
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewMSTeams(&conf, tmpl, log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	ctx = WithReceiverName(ctx, "teams")
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "job": "web"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	alert.EndsAt = time.Now().Add(-time.Minute)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	require.Len(t, cards, 2)
+	require.Equal(t, "MessageCard", cards[0].Type)
+	require.Equal(t, "[FIRING:1] HighLatency (web)", cards[0].Title)
+	require.Equal(t, cards[0].Title, cards[0].Summary)
+	require.Equal(t, "**Alerts Firing:**\n\n- alertname=HighLatency job=web - Latency is high\n", cards[0].Text)
+	require.Equal(t, msTeamsColorRed, cards[0].ThemeColor)
+	require.Len(t, cards[0].PotentialAction, 1)
+	require.Equal(t, "http://am.example.com/#/alerts?receiver=teams", cards[0].PotentialAction[0].Targets[0].URI)
+	require.Equal(t, msTeamsColorGreen, cards[1].ThemeColor)
+}
+
+func TestTelegram(t *testing.T) {
+	var msgs []telegramMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/bott0k3n/sendMessage", r.URL.Path)
+		var msg telegramMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultTelegramConfig
+	conf.APIURL = srv.URL + "/"
+	conf.BotToken = "t0k3n"
+	conf.ChatID = -1001234567890
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewTelegram(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "path": "/a<b>"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	// Long messages are truncated to the limit.
+	conf.Message = strings.Repeat("ä", telegramMaxMessageLength+1)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	require.Len(t, msgs, 2)
+	require.Equal(t, int64(-1001234567890), msgs[0].ChatID)
+	require.Equal(t, "HTML", msgs[0].ParseMode)
+	require.Equal(t, "<b>[FIRING:1] HighLatency</b>\n\nalertname=HighLatency path=/a&lt;b&gt; \nLatency is high\n", msgs[0].Text)
+	require.Equal(t, telegramMaxMessageLength, len([]rune(msgs[1].Text)))
+	require.True(t, strings.HasSuffix(msgs[1].Text, "ä…"))
+}
+
+func TestGoogleChat(t *testing.T) {
+	var msgs []googleChatMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "k3y", r.URL.Query().Get("key"))
+		var msg googleChatMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultGoogleChatConfig
+	conf.WebhookURL = config.Secret(srv.URL + "/v1/spaces/AAA/messages?key=k3y")
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewGoogleChat(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "path": "/a<b>"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	require.Len(t, msgs, 1)
+	require.Len(t, msgs[0].CardsV2, 1)
+	card := msgs[0].CardsV2[0].Card
+	require.Equal(t, "[FIRING:1] HighLatency (/a<b>)", card.Header.Title)
+	require.Equal(t, "FIRING", card.Header.Subtitle)
+	require.Len(t, card.Sections, 1)
+	require.Equal(t, "<b>Alerts Firing:</b>\n\n• alertname=HighLatency path=/a&lt;b&gt; - Latency is high\n", card.Sections[0].Widgets[0].TextParagraph.Text)
+
+	// The webhook URL is not part of errors.
+	srv.Close()
+	_, err = notifier.Notify(ctx, alert)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "k3y")
+}
+
+func TestDiscord(t *testing.T) {
+	var msgs []discordMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/webhooks/1/t0k3n", r.URL.Path)
+		var msg discordMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		msgs = append(msgs, msg)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultDiscordConfig
+	conf.WebhookURL = config.Secret(srv.URL + "/api/webhooks/1/t0k3n")
+	conf.Username = "Alertmanager"
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewDiscord(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	// Resolved alerts are green and long messages are truncated.
+	alert.EndsAt = time.Now().Add(-time.Minute)
+	conf.Message = strings.Repeat("ä", discordMaxDescriptionLength+1)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	require.Len(t, msgs, 2)
+	require.Equal(t, "Alertmanager", msgs[0].Username)
+	require.Len(t, msgs[0].Embeds, 1)
+	require.Equal(t, "[FIRING:1] HighLatency ", msgs[0].Embeds[0].Title)
+	require.Equal(t, "**Alerts Firing:**\n\n- alertname=HighLatency - Latency is high\n", msgs[0].Embeds[0].Description)
+	require.Equal(t, discordColorRed, msgs[0].Embeds[0].Color)
+	require.Equal(t, discordColorGreen, msgs[1].Embeds[0].Color)
+	require.Equal(t, discordMaxDescriptionLength, len([]rune(msgs[1].Embeds[0].Description)))
+	require.True(t, strings.HasSuffix(msgs[1].Embeds[0].Description, "ä…"))
+}
+
+func TestJira(t *testing.T) {
+	var (
+		open     bool
+		requests []string
+		created  jiraIssue
+		updated  jiraIssue
+		comment  string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/search":
+			var search jiraSearch
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&search))
+			require.Equal(t, `project = "OPS" AND labels = "`+jiraGroupLabel("1")+`" AND statusCategory != Done ORDER BY created DESC`, search.JQL)
+			if open {
+				fmt.Fprint(w, `{"issues": [{"key": "OPS-1"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"issues": []}`)
+		case "POST /rest/api/2/issue":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			open = true
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": "10000", "key": "OPS-1"}`)
+		case "PUT /rest/api/2/issue/OPS-1":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /rest/api/2/issue/OPS-1/comment":
+			var c struct {
+				Body string `json:"body"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+			comment = c.Body
+			w.WriteHeader(http.StatusCreated)
+		case "GET /rest/api/2/issue/OPS-1/transitions":
+			fmt.Fprint(w, `{"transitions": [{"id": "11", "name": "In Progress"}, {"id": "31", "name": "Done"}]}`)
+		case "POST /rest/api/2/issue/OPS-1/transitions":
+			require.JSONEq(t, `{"transition": {"id": "31"}}`, readBody(t, r))
+			open = false
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultJiraConfig
+	conf.APIURL = srv.URL + "/rest/api/2/"
+	conf.Project = "OPS"
+	conf.IssueType = "Bug"
+	conf.Labels = []string{"{{ .CommonLabels.alertname }}", "{{ .CommonLabels.missing }}"}
+	conf.ResolveTransition = "done"
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewJira(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	// The first notification opens an issue.
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, &jiraRef{Key: "OPS"}, created.Fields.Project)
+	require.Equal(t, &jiraRef{Name: "Bug"}, created.Fields.IssueType)
+	require.Equal(t, "[FIRING:1] HighLatency ", created.Fields.Summary)
+	require.Equal(t, []string{jiraGroupLabel("1"), "HighLatency"}, created.Fields.Labels)
+
+	// Later notifications update it and resolve it.
+	alert.EndsAt = time.Now().Add(-time.Minute)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Nil(t, updated.Fields.Project)
+	require.Equal(t, "[RESOLVED] HighLatency ", updated.Fields.Summary)
+	require.Equal(t, "All alerts of the group resolved.", comment)
+	require.False(t, open)
+
+	// Resolved groups without open issue are not sent.
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"POST /rest/api/2/search",
+		"POST /rest/api/2/issue",
+		"POST /rest/api/2/search",
+		"PUT /rest/api/2/issue/OPS-1",
+		"POST /rest/api/2/issue/OPS-1/comment",
+		"GET /rest/api/2/issue/OPS-1/transitions",
+		"POST /rest/api/2/issue/OPS-1/transitions",
+		"POST /rest/api/2/search",
+	}, requests)
+}
+
+func TestSNS(t *testing.T) {
+	var (
+		publishes   []url.Values
+		assumeRoles int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.Form.Get("Action") {
+		case "AssumeRole":
+			assumeRoles++
+			require.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+			fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey>
+<SessionToken>token</SessionToken><Expiration>%s</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		case "Publish":
+			require.Contains(t, r.Header.Get("Authorization"), "Credential=ASIA/")
+			require.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/sns/aws4_request")
+			require.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+			publishes = append(publishes, r.PostForm)
+			if len(publishes) > 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`<ErrorResponse><Error><Code>Throttling</Code><Message>Rate exceeded</Message></Error></ErrorResponse>`))
+			}
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultSNSConfig
+	conf.APIURL = srv.URL + "/"
+	conf.TopicARN = "arn:aws:sns:eu-west-1:123456789012:alerts"
+	conf.SigV4 = config.SigV4Config{
+		Region:    "eu-west-1",
+		AccessKey: "AKID",
+		SecretKey: "secret",
+		RoleARN:   "arn:aws:iam::123456789012:role/alertmanager",
+	}
+	conf.Attributes = map[string]string{
+		"severity": "{{ .CommonLabels.severity }}",
+		"team":     "{{ .CommonLabels.team }}",
+	}
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewSNS(&conf, createTmpl(t), log.NewNopLogger())
+	notifier.stsURL = srv.URL + "/"
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency", "severity": "critical"},
+			StartsAt: time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	retry, err = notifier.Notify(ctx, alert)
+	require.EqualError(t, err, "Throttling: Rate exceeded")
+	require.True(t, retry)
+
+	// The credentials of the role are reused.
+	require.Equal(t, 1, assumeRoles)
+	require.Len(t, publishes, 2)
+	p := publishes[0]
+	require.Equal(t, "arn:aws:sns:eu-west-1:123456789012:alerts", p.Get("TopicArn"))
+	require.Equal(t, "[FIRING:1] HighLatency (critical)", p.Get("Subject"))
+	require.Equal(t, "severity", p.Get("MessageAttributes.entry.1.Name"))
+	require.Equal(t, "critical", p.Get("MessageAttributes.entry.1.Value.StringValue"))
+	require.Equal(t, "", p.Get("MessageAttributes.entry.2.Name"))
+}
+
+func TestSNSSubject(t *testing.T) {
+	require.Equal(t, "[FIRING:1] Disk full on db-1", snsSubject("[FIRING:1] Disk full\non db-1 ✗"))
+	require.Equal(t, strings.Repeat("a", 97)+"...", snsSubject(strings.Repeat("a", 120)))
+}
+
+func TestKubernetesObject(t *testing.T) {
+	conf := config.DefaultKubernetesConfig
+	notifier := NewKubernetes(&conf, nil, log.NewNopLogger())
+
+	for _, tc := range []struct {
+		labels model.LabelSet
+		ref    kubernetesObjectReference
+		ok     bool
+	}{
+		{
+			labels: model.LabelSet{"namespace": "prod", "deployment": "web", "pod": "web-1"},
+			ref:    kubernetesObjectReference{Kind: "Deployment", Namespace: "prod", Name: "web", APIVersion: "apps/v1"},
+			ok:     true,
+		},
+		{
+			labels: model.LabelSet{"namespace": "prod", "pod": "web-1"},
+			ref:    kubernetesObjectReference{Kind: "Pod", Namespace: "prod", Name: "web-1", APIVersion: "v1"},
+			ok:     true,
+		},
+		{
+			labels: model.LabelSet{"node": "node-1"},
+			ref:    kubernetesObjectReference{Kind: "Node", Name: "node-1", APIVersion: "v1"},
+			ok:     true,
+		},
+		{
+			labels: model.LabelSet{"namespace": "prod"},
+			ref:    kubernetesObjectReference{Kind: "Namespace", Name: "prod", APIVersion: "v1"},
+			ok:     true,
+		},
+		{
+			labels: model.LabelSet{"pod": "web-1"},
+		},
+	} {
+		ref, ok := notifier.object(tc.labels)
+		require.Equal(t, tc.ok, ok)
+		require.Equal(t, tc.ref, ref)
+	}
+}
+
+func TestKubernetes(t *testing.T) {
+	events := map[string]kubernetesEvent{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			require.Equal(t, "/api/v1/namespaces/prod/events", r.URL.Path)
+			var ev kubernetesEvent
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+			if _, ok := events[ev.Metadata.Name]; ok {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			events[ev.Metadata.Name] = ev
+			w.WriteHeader(http.StatusCreated)
+		case "PATCH":
+			require.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+			name := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/prod/events/")
+			ev, ok := events[name]
+			require.True(t, ok)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ev))
+			events[name] = ev
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultKubernetesConfig
+	conf.APIServer = srv.URL + "/"
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewKubernetes(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "HighLatency", "namespace": "prod", "deployment": "web"},
+			Annotations: model.LabelSet{"summary": "Latency is high"},
+			StartsAt:    time.Now().Add(-time.Hour),
+		},
+	}
+	unrelated := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency"},
+			StartsAt: time.Now().Add(-time.Hour),
+		},
+	}
+
+	retry, err := notifier.Notify(ctx, alert, unrelated)
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Len(t, events, 1)
+
+	var name string
+	for name = range events {
+	}
+	require.True(t, strings.HasPrefix(name, "web."))
+	ev := events[name]
+	require.Equal(t, kubernetesObjectReference{Kind: "Deployment", Namespace: "prod", Name: "web", APIVersion: "apps/v1"}, ev.InvolvedObject)
+	require.Equal(t, "Warning", ev.Type)
+	require.Equal(t, "AlertFiring", ev.Reason)
+	require.Equal(t, "[FIRING:1] HighLatency (web prod) - Latency is high", ev.Message)
+
+	alert.EndsAt = time.Now().Add(-time.Minute)
+	_, err = notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "Normal", events[name].Type)
+	require.Equal(t, "AlertResolved", events[name].Reason)
+}
+
+func TestEmailXOAuth2(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "alertmanager", r.PostForm.Get("client_id"))
+		require.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		require.Equal(t, "https://outlook.office365.com/.default", r.PostForm.Get("scope"))
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "Bearer", "expires_in": 3600}`, requests)
+	}))
+	defer srv.Close()
+
+	n := NewEmail(&config.EmailConfig{
+		AuthUsername: "alertmanager@example.com",
+		AuthPassword: "password",
+		AuthOAuth2: &config.OAuth2Config{
+			ClientID:     "alertmanager",
+			ClientSecret: "secret",
+			TokenURL:     srv.URL,
+			Scopes:       []string{"https://outlook.office365.com/.default"},
+		},
+		Headers: map[string]string{},
+	}, nil, log.NewNopLogger())
+	now := time.Now()
+	n.tokens.now = func() time.Time { return now }
+
+	// XOAUTH2 is preferred over the other mechanisms.
+	auth, err := n.auth(context.Background(), "LOGIN PLAIN XOAUTH2")
+	require.NoError(t, err)
+	mech, resp, err := auth.Start(nil)
+	require.NoError(t, err)
+	require.Equal(t, "XOAUTH2", mech)
+	require.Equal(t, "user=alertmanager@example.com\x01auth=Bearer token1\x01\x01", string(resp))
+
+	// The token is cached until shortly before it expires.
+	auth, err = n.auth(context.Background(), "XOAUTH2")
+	require.NoError(t, err)
+	require.Equal(t, "token1", auth.(*xoauth2Auth).token)
+	now = now.Add(time.Hour - time.Minute)
+	auth, err = n.auth(context.Background(), "XOAUTH2")
+	require.NoError(t, err)
+	require.Equal(t, "token2", auth.(*xoauth2Auth).token)
+
+	// Other mechanisms are used if the server does not offer XOAUTH2.
+	auth, err = n.auth(context.Background(), "LOGIN")
+	require.NoError(t, err)
+	require.IsType(t, &loginAuth{}, auth)
+}
+
+func TestDKIMCanonicalization(t *testing.T) {
+	// The examples of RFC 6376, section 3.4.5.
+	fields := dkimHeaderFields([]byte("A: X\r\nB : Y\t\r\n\tZ  \r\n"))
+	require.Equal(t, []string{"A: X\r\n", "B : Y\t\r\n\tZ  \r\n"}, fields)
+	require.Equal(t, "a:X\r\n", dkimRelaxedHeader(fields[0]))
+	require.Equal(t, "b:Y Z\r\n", dkimRelaxedHeader(fields[1]))
+	require.Equal(t, " C\r\nD E\r\n", string(dkimRelaxedBody([]byte(" C \r\nD \t E\r\n\r\n\r\n"))))
+	require.Empty(t, dkimRelaxedBody([]byte("\r\n\r\n")))
+	require.Equal(t, "a\r\nb\r\n", string(crlfLines([]byte("a\nb\r\n"))))
+}
+
+func TestDKIMSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dkim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	msg := []byte("From: Alertmanager <am@example.com>\r\n" +
+		"To: ops@example.com\r\n" +
+		"Subject:  [FIRING:1]   HighLatency\r\n" +
+		"X-Unsigned: foo\r\n" +
+		"\r\n" +
+		"Body  text \r\n\r\n")
+
+	for _, tc := range []struct {
+		block  *pem.Block
+		algo   string
+		verify func(digest, sig []byte) error
+	}{
+		{
+			block: &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+			algo:  "rsa-sha256",
+			verify: func(digest, sig []byte) error {
+				return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest, sig)
+			},
+		},
+		{
+			block: &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8},
+			algo:  "ed25519-sha256",
+			verify: func(digest, sig []byte) error {
+				if !ed25519.Verify(edKey.Public().(ed25519.PublicKey), digest, sig) {
+					return fmt.Errorf("invalid signature")
+				}
+				return nil
+			},
+		},
+	} {
+		keyFile := filepath.Join(dir, tc.algo+".pem")
+		require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(tc.block), 0600))
+
+		signer, err := newDKIMSigner(&config.EmailDKIMConfig{
+			KeyFile:  keyFile,
+			Selector: "alertmanager",
+			Domain:   "example.com",
+			Headers:  config.DefaultEmailDKIMConfig.Headers,
+		})
+		require.NoError(t, err)
+		signer.now = func() time.Time { return time.Unix(1500000000, 0) }
+
+		header, err := signer.sign(msg)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(header, "DKIM-Signature: v=1; a="+tc.algo+"; c=relaxed/relaxed; d=example.com; s=alertmanager; t=1500000000; h=from:to:subject; bh="))
+		for _, line := range strings.Split(header, "\r\n") {
+			require.True(t, len(line) <= 998)
+		}
+
+		// Verify the signature like a receiving server.
+		tags := map[string]string{}
+		for _, tag := range strings.Split(strings.Replace(strings.TrimPrefix(header, "DKIM-Signature: "), "\r\n ", "", -1), ";") {
+			kv := strings.SplitN(strings.TrimSpace(tag), "=", 2)
+			tags[kv[0]] = strings.TrimSpace(kv[1])
+		}
+		bh := sha256.Sum256([]byte("Body text\r\n"))
+		require.Equal(t, base64.StdEncoding.EncodeToString(bh[:]), tags["bh"])
+
+		h := sha256.New()
+		h.Write([]byte("from:Alertmanager <am@example.com>\r\nto:ops@example.com\r\nsubject:[FIRING:1] HighLatency\r\n"))
+		unsigned := header[:strings.Index(header, "b=")+2]
+		h.Write([]byte(strings.TrimSuffix(dkimRelaxedHeader(unsigned), "\r\n")))
+		sig, err := base64.StdEncoding.DecodeString(tags["b"])
+		require.NoError(t, err)
+		require.NoError(t, tc.verify(h.Sum(nil), sig))
+	}
+
+	_, err = newDKIMSigner(&config.EmailDKIMConfig{KeyFile: filepath.Join(dir, "missing.pem")})
+	require.Error(t, err)
+}
+
+func TestEmailAttachments(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("panel") {
+		case "cpu":
+			w.Write(png)
+		case "large":
+			w.Write(make([]byte, 2000))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewEmail(&config.EmailConfig{
+		Headers: map[string]string{},
+		Attachments: []*config.EmailAttachment{
+			{
+				Name:       "graph",
+				URL:        srv.URL + `/render?panel={{ .Labels.panel }}`,
+				Inline:     true,
+				MaxSize:    1000,
+				Timeout:    model.Duration(time.Second),
+				HTTPConfig: &commoncfg.HTTPClientConfig{},
+			},
+			{
+				Name:       "report",
+				URL:        `{{ if eq .Labels.panel "cpu" }}` + srv.URL + `/render?panel=cpu{{ end }}`,
+				MaxSize:    1000,
+				Timeout:    model.Duration(time.Second),
+				HTTPConfig: &commoncfg.HTTPClientConfig{},
+			},
+		},
+	}, createTmpl(t), log.NewNopLogger())
+
+	var alerts []*types.Alert
+	for _, panel := range []string{"cpu", "large", "missing"} {
+		alerts = append(alerts, &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(panel), "panel": model.LabelValue(panel)},
+			StartsAt: time.Now(),
+			EndsAt:   time.Now().Add(time.Hour),
+		}})
+	}
+
+	// Attachments that are too large or cannot be fetched are left out.
+	attachments := n.fetchAttachments(context.Background(), alerts...)
+	require.Len(t, attachments, 2)
+	require.Equal(t, "graph-0", attachments[0].cid)
+	require.True(t, attachments[0].inline)
+	require.Equal(t, "image/png", attachments[0].contentType)
+	require.Equal(t, "graph-0.png", attachments[0].filename())
+	require.Equal(t, png, attachments[0].data)
+	require.Equal(t, "report-0", attachments[1].cid)
+	require.False(t, attachments[1].inline)
+
+	contentType, body := attachEmailParts("text/html; charset=UTF-8", []byte("<img src=\"cid:graph-0\">"), attachments)
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	mixed := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	related, err := mixed.NextPart()
+	require.NoError(t, err)
+	mediaType, params, err = mime.ParseMediaType(related.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/related", mediaType)
+
+	parts := multipart.NewReader(related, params["boundary"])
+	html, err := parts.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "text/html; charset=UTF-8", html.Header.Get("Content-Type"))
+	image, err := parts.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "<graph-0>", image.Header.Get("Content-ID"))
+	require.Equal(t, `inline; filename=graph-0.png`, image.Header.Get("Content-Disposition"))
+	data, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, image))
+	require.NoError(t, err)
+	require.Equal(t, png, data)
+
+	report, err := mixed.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "attachment; filename=report-0.png", report.Header.Get("Content-Disposition"))
+	require.Empty(t, report.Header.Get("Content-ID"))
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	s := newOAuth2TokenSource(&config.OAuth2Config{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL}, nil)
+	_, err := s.Token(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected status code 401")
+}
+
+func TestSlackFollowUp(t *testing.T) {
+	for _, mode := range []string{config.SlackFollowUpUpdate, config.SlackFollowUpThread} {
+		var (
+			methods []string
+			reqs    []slackReq
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+			var req slackReq
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			methods = append(methods, r.URL.Path)
+			reqs = append(reqs, req)
+			json.NewEncoder(w).Encode(slackResponse{OK: true, Channel: "C123", TS: fmt.Sprintf("1.%d", len(reqs))})
+		}))
+		defer srv.Close()
+
+		refs, err := msgref.New(msgref.Options{Retention: time.Hour})
+		require.NoError(t, err)
+
+		conf := config.DefaultSlackConfig
+		conf.BotToken = "xoxb-token"
+		conf.WebAPIURL = srv.URL + "/api/"
+		conf.Channel = "#alerts"
+		conf.FollowUp = mode
+		conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+		notifier := NewSlack(&conf, createTmpl(t), refs, log.NewNopLogger())
+
+		ctx := WithGroupKey(context.Background(), "1")
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "HighLatency"},
+				StartsAt: time.Now().Add(-time.Hour),
+			},
+		}
+		for i := 0; i < 2; i++ {
+			_, err = notifier.Notify(ctx, alert)
+			require.NoError(t, err)
+		}
+		alert.EndsAt = time.Now().Add(-time.Minute)
+		_, err = notifier.Notify(ctx, alert)
+		require.NoError(t, err)
+
+		// The message of the group is forgotten once it resolved.
+		_, ok := refs.Get("1:#alerts")
+		require.False(t, ok, mode)
+
+		require.Len(t, reqs, 3, mode)
+		require.Equal(t, "/api/chat.postMessage", methods[0], mode)
+		require.Equal(t, "#alerts", reqs[0].Channel, mode)
+		for _, req := range reqs[1:] {
+			require.Equal(t, "C123", req.Channel, mode)
+		}
+		switch mode {
+		case config.SlackFollowUpUpdate:
+			require.Equal(t, []string{"/api/chat.postMessage", "/api/chat.update", "/api/chat.update"}, methods)
+			require.Equal(t, "1.1", reqs[1].TS)
+			require.Equal(t, "1.1", reqs[2].TS)
+			require.Equal(t, "", reqs[1].Text)
+			require.True(t, strings.HasPrefix(reqs[2].Text, "~") && strings.HasSuffix(reqs[2].Text, "~"), reqs[2].Text)
+			require.Equal(t, "", reqs[2].Attachments[0].Title)
+		case config.SlackFollowUpThread:
+			require.Equal(t, []string{"/api/chat.postMessage", "/api/chat.postMessage", "/api/chat.postMessage"}, methods)
+			require.Equal(t, "1.1", reqs[1].ThreadTS)
+			require.Equal(t, "1.1", reqs[2].ThreadTS)
+		}
+	}
+}
+
+func TestSlackWebAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(slackResponse{OK: false, Error: "channel_not_found"})
+	}))
+	defer srv.Close()
+
+	conf := config.DefaultSlackConfig
+	conf.BotToken = "xoxb-token"
+	conf.WebAPIURL = srv.URL + "/"
+	conf.Channel = "#alerts"
+	conf.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	notifier := NewSlack(&conf, createTmpl(t), nil, log.NewNopLogger())
+
+	retry, err := notifier.Notify(WithGroupKey(context.Background(), "1"), &types.Alert{
+		Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}},
+	})
+	require.False(t, retry)
+	require.EqualError(t, err, "chat.postMessage failed: channel_not_found")
+}
+
+func TestExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "payload.json")
+
+	conf := config.DefaultExecConfig
+	conf.Command = "/bin/sh"
+	conf.Args = []string{"-c", `cat > "$0"`, out}
+	notifier := NewExec(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "HighLatency"},
+			StartsAt: time.Now().Add(-time.Hour),
+		},
+	}
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	b, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal(b, &msg))
+	require.Equal(t, "1", msg.GroupKey)
+	require.Equal(t, "firing", msg.Status)
+	require.Len(t, msg.Alerts, 1)
+
+	conf.Args = []string{"-c", "echo no route to host >&2; exit 3"}
+	retry, err = NewExec(&conf, createTmpl(t), log.NewNopLogger()).Notify(ctx, alert)
+	require.True(t, retry)
+	require.EqualError(t, err, "command /bin/sh failed: exit status 3: no route to host")
+
+	conf.Args = []string{"-c", "sleep 5"}
+	conf.Timeout = model.Duration(50 * time.Millisecond)
+	retry, err = NewExec(&conf, createTmpl(t), log.NewNopLogger()).Notify(ctx, alert)
+	require.True(t, retry)
+	require.Contains(t, err.Error(), "context deadline exceeded")
+}
+
+func TestExecConcurrency(t *testing.T) {
+	conf := config.DefaultExecConfig
+	conf.Command = "/bin/sh"
+	conf.Args = []string{"-c", "sleep 5"}
+	notifier := NewExec(&conf, createTmpl(t), log.NewNopLogger())
+
+	// Runs wait for a free slot until their context is done.
+	notifier.sem <- struct{}{}
+	ctx, cancel := context.WithTimeout(WithGroupKey(context.Background(), "1"), 50*time.Millisecond)
+	defer cancel()
+	retry, err := notifier.Notify(ctx, &types.Alert{})
+	require.True(t, retry)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+// readMQTTPacket reads a packet from the connection of an MQTT client and
+// returns its fixed header and body.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var n, mult int = 0, 1
+	for {
+		d, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(d&0x7f) * mult
+		mult *= 128
+		if d&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func TestMQTT(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	type message struct {
+		topic   string
+		payload []byte
+	}
+	msgs := make(chan message, 1)
+	go func() {
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		r := bufio.NewReader(nc)
+		if _, _, err := readMQTTPacket(r); err != nil {
+			return
+		}
+		nc.Write([]byte{0x20, 2, 0, 0})
+		header, body, err := readMQTTPacket(r)
+		if err != nil || header>>4 != 3 {
+			return
+		}
+		// The topic is followed by the packet identifier of QoS 1.
+		n := binary.BigEndian.Uint16(body)
+		id := body[2+n : 4+n]
+		nc.Write([]byte{0x40, 2, id[0], id[1]})
+		msgs <- message{topic: string(body[2 : 2+n]), payload: body[4+n:]}
+	}()
+
+	conf := config.DefaultMQTTConfig
+	conf.URL = "tcp://" + l.Addr().String()
+	notifier := NewMQTT(&conf, createTmpl(t), log.NewNopLogger())
+	require.Len(t, notifier.clientID, 23)
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithReceiverName(ctx, "plant")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "ConveyorStopped"},
+			StartsAt: time.Now().Add(-time.Hour),
+		},
+	}
+	retry, err := notifier.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.False(t, retry)
+
+	m := <-msgs
+	require.Equal(t, "alertmanager/plant/ConveyorStopped", m.topic)
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal(m.payload, &msg))
+	require.Equal(t, "1", msg.GroupKey)
+	require.Len(t, msg.Alerts, 1)
+}
+
+func TestMQTTBackoff(t *testing.T) {
+	// Reserve a port nothing listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	conf := config.DefaultMQTTConfig
+	conf.URL = "tcp://" + addr
+	conf.Topic = "alerts"
+	notifier := NewMQTT(&conf, createTmpl(t), log.NewNopLogger())
+
+	ctx := WithGroupKey(context.Background(), "1")
+	retry, err := notifier.Notify(ctx, &types.Alert{})
+	require.Error(t, err)
+	require.True(t, retry)
+
+	// The broker is not dialed again until the backoff has passed.
+	retry, err = notifier.Notify(ctx, &types.Alert{})
+	require.True(t, retry)
+	require.Contains(t, err.Error(), "reconnecting to MQTT broker "+addr+" in")
+}
@@ -16,6 +16,7 @@ package notify
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,8 +28,10 @@ import (
 	"github.com/prometheus/common/model"
 	"golang.org/x/net/context"
 
+	"github.com/prometheus/alertmanager/ack"
 	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/msgref"
 	"github.com/prometheus/alertmanager/nflog"
 	"github.com/prometheus/alertmanager/nflog/nflogpb"
 	"github.com/prometheus/alertmanager/silence"
@@ -55,6 +58,36 @@ var (
 		Help:      "The latency of notifications in seconds.",
 		Buckets:   []float64{1, 5, 10, 15, 20},
 	}, []string{"integration"})
+
+	numExecExits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notifications_exec_exits_total",
+		Help:      "The total number of exited commands of exec notifications by exit code, which is -1 for killed commands.",
+	}, []string{"exit_code"})
+
+	numFailovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notifications_failovers_total",
+		Help:      "The total number of notifications passed on to a failover receiver after notifying the previous receiver of the chain failed.",
+	}, []string{"receiver", "failover_receiver"})
+
+	numEscalations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notifications_escalations_total",
+		Help:      "The total number of notifications passed on to the escalation receiver of a route after the group kept firing.",
+	}, []string{"receiver", "escalation_receiver"})
+
+	numTemplateFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notifications_template_fallbacks_total",
+		Help:      "The total number of notifications passed on to the template fallback receiver after templating the recipient of a notification failed.",
+	}, []string{"receiver", "fallback_receiver"})
+
+	numWithoutQuorum = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notifications_without_quorum_total",
+		Help:      "The total number of notifications of receivers in strict coordination mode that were not sent because the peer did not reach a quorum of the cluster.",
+	}, []string{"receiver"})
 )
 
 func init() {
@@ -67,6 +100,17 @@ func init() {
 	numNotifications.WithLabelValues("opsgenie")
 	numNotifications.WithLabelValues("webhook")
 	numNotifications.WithLabelValues("victorops")
+	numNotifications.WithLabelValues("statuspage")
+	numNotifications.WithLabelValues("grafana_oncall")
+	numNotifications.WithLabelValues("kubernetes")
+	numNotifications.WithLabelValues("msteams")
+	numNotifications.WithLabelValues("telegram")
+	numNotifications.WithLabelValues("googlechat")
+	numNotifications.WithLabelValues("discord")
+	numNotifications.WithLabelValues("jira")
+	numNotifications.WithLabelValues("sns")
+	numNotifications.WithLabelValues("exec")
+	numNotifications.WithLabelValues("mqtt")
 	numFailedNotifications.WithLabelValues("email")
 	numFailedNotifications.WithLabelValues("hipchat")
 	numFailedNotifications.WithLabelValues("pagerduty")
@@ -76,6 +120,17 @@ func init() {
 	numFailedNotifications.WithLabelValues("opsgenie")
 	numFailedNotifications.WithLabelValues("webhook")
 	numFailedNotifications.WithLabelValues("victorops")
+	numFailedNotifications.WithLabelValues("statuspage")
+	numFailedNotifications.WithLabelValues("grafana_oncall")
+	numFailedNotifications.WithLabelValues("kubernetes")
+	numFailedNotifications.WithLabelValues("msteams")
+	numFailedNotifications.WithLabelValues("telegram")
+	numFailedNotifications.WithLabelValues("googlechat")
+	numFailedNotifications.WithLabelValues("discord")
+	numFailedNotifications.WithLabelValues("jira")
+	numFailedNotifications.WithLabelValues("sns")
+	numFailedNotifications.WithLabelValues("exec")
+	numFailedNotifications.WithLabelValues("mqtt")
 	notificationLatencySeconds.WithLabelValues("email")
 	notificationLatencySeconds.WithLabelValues("hipchat")
 	notificationLatencySeconds.WithLabelValues("pagerduty")
@@ -85,10 +140,26 @@ func init() {
 	notificationLatencySeconds.WithLabelValues("opsgenie")
 	notificationLatencySeconds.WithLabelValues("webhook")
 	notificationLatencySeconds.WithLabelValues("victorops")
+	notificationLatencySeconds.WithLabelValues("statuspage")
+	notificationLatencySeconds.WithLabelValues("grafana_oncall")
+	notificationLatencySeconds.WithLabelValues("kubernetes")
+	notificationLatencySeconds.WithLabelValues("msteams")
+	notificationLatencySeconds.WithLabelValues("telegram")
+	notificationLatencySeconds.WithLabelValues("googlechat")
+	notificationLatencySeconds.WithLabelValues("discord")
+	notificationLatencySeconds.WithLabelValues("jira")
+	notificationLatencySeconds.WithLabelValues("sns")
+	notificationLatencySeconds.WithLabelValues("exec")
+	notificationLatencySeconds.WithLabelValues("mqtt")
 
 	prometheus.Register(numNotifications)
 	prometheus.Register(numFailedNotifications)
 	prometheus.Register(notificationLatencySeconds)
+	prometheus.Register(numExecExits)
+	prometheus.Register(numFailovers)
+	prometheus.Register(numEscalations)
+	prometheus.Register(numTemplateFallbacks)
+	prometheus.Register(numWithoutQuorum)
 }
 
 // MinTimeout is the minimum timeout that is set for the context of a call
@@ -107,6 +178,15 @@ const (
 	keyFiringAlerts
 	keyResolvedAlerts
 	keyNow
+	keyEnrichments
+	keyEnrichmentResults
+	keyAcknowledged
+	keyMuteTimeIntervals
+	keyFailoverReceivers
+	keyDigestGroups
+	keyActiveTimeIntervals
+	keyIdempotencyKey
+	keyEscalationReceiver
 )
 
 // WithReceiverName populates a context with a receiver name.
@@ -114,6 +194,32 @@ func WithReceiverName(ctx context.Context, rcv string) context.Context {
 	return context.WithValue(ctx, keyReceiverName, rcv)
 }
 
+// WithFailoverReceivers populates a context with the receivers notified in
+// order if notifying the receiver fails.
+func WithFailoverReceivers(ctx context.Context, rcvs []string) context.Context {
+	return context.WithValue(ctx, keyFailoverReceivers, rcvs)
+}
+
+// FailoverReceivers extracts the failover receivers from the context. Iff
+// none exists, the second argument is false.
+func FailoverReceivers(ctx context.Context) ([]string, bool) {
+	v, ok := ctx.Value(keyFailoverReceivers).([]string)
+	return v, ok
+}
+
+// WithEscalationReceiver populates a context with the receiver notified in
+// addition to the receiver as the group is escalated.
+func WithEscalationReceiver(ctx context.Context, rcv string) context.Context {
+	return context.WithValue(ctx, keyEscalationReceiver, rcv)
+}
+
+// EscalationReceiver extracts the escalation receiver from the context. Iff
+// none exists, the second argument is false.
+func EscalationReceiver(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(keyEscalationReceiver).(string)
+	return v, ok
+}
+
 // WithGroupKey populates a context with a group key.
 func WithGroupKey(ctx context.Context, s string) context.Context {
 	return context.WithValue(ctx, keyGroupKey, s)
@@ -209,6 +315,79 @@ func ResolvedAlerts(ctx context.Context) ([]uint64, bool) {
 	return v, ok
 }
 
+// WithIdempotencyKey populates a context with the idempotency key of the
+// notification.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyIdempotencyKey, key)
+}
+
+// IdempotencyKey extracts the idempotency key of the notification from the
+// context. Iff none exists, the second argument is false.
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(keyIdempotencyKey).(string)
+	return v, ok
+}
+
+// WithEnrichments populates a context with the enrichments of a route.
+func WithEnrichments(ctx context.Context, e []*config.Enrichment) context.Context {
+	return context.WithValue(ctx, keyEnrichments, e)
+}
+
+// Enrichments extracts the enrichments of a route from the context.
+// Iff none exists, the second argument is false.
+func Enrichments(ctx context.Context) ([]*config.Enrichment, bool) {
+	v, ok := ctx.Value(keyEnrichments).([]*config.Enrichment)
+	return v, ok
+}
+
+func withEnrichmentResults(ctx context.Context, r map[string]*template.Enrichment) context.Context {
+	return context.WithValue(ctx, keyEnrichmentResults, r)
+}
+
+func enrichmentResults(ctx context.Context) map[string]*template.Enrichment {
+	v, _ := ctx.Value(keyEnrichmentResults).(map[string]*template.Enrichment)
+	return v
+}
+
+// WithMuteTimeIntervals populates a context with the names of the mute time
+// intervals of a route.
+func WithMuteTimeIntervals(ctx context.Context, mt []string) context.Context {
+	return context.WithValue(ctx, keyMuteTimeIntervals, mt)
+}
+
+// MuteTimeIntervals extracts the names of the mute time intervals of a route
+// from the context. Iff none exists, the second argument is false.
+func MuteTimeIntervals(ctx context.Context) ([]string, bool) {
+	v, ok := ctx.Value(keyMuteTimeIntervals).([]string)
+	return v, ok
+}
+
+// WithActiveTimeIntervals populates a context with the names of the mute
+// time intervals outside of which a route does not send notifications.
+func WithActiveTimeIntervals(ctx context.Context, at []string) context.Context {
+	return context.WithValue(ctx, keyActiveTimeIntervals, at)
+}
+
+// ActiveTimeIntervals extracts the names of the active time intervals of a
+// route from the context. Iff none exists, the second argument is false.
+func ActiveTimeIntervals(ctx context.Context) ([]string, bool) {
+	v, ok := ctx.Value(keyActiveTimeIntervals).([]string)
+	return v, ok
+}
+
+// WithAcknowledged populates a context with whether all firing alerts are
+// acknowledged.
+func WithAcknowledged(ctx context.Context, acked bool) context.Context {
+	return context.WithValue(ctx, keyAcknowledged, acked)
+}
+
+// Acknowledged returns whether all firing alerts are acknowledged according
+// to the context.
+func Acknowledged(ctx context.Context) bool {
+	v, _ := ctx.Value(keyAcknowledged).(bool)
+	return v
+}
+
 // A Stage processes alerts under the constraints of the given context.
 type Stage interface {
 	Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error)
@@ -230,31 +409,77 @@ type NotificationLog interface {
 // BuildPipeline builds a map of receivers to Stages.
 func BuildPipeline(
 	confs []*config.Receiver,
+	muteTimeIntervals []*config.MuteTimeInterval,
 	tmpl *template.Template,
 	wait func() time.Duration,
 	muter types.Muter,
 	silences *silence.Silences,
+	acks *ack.Acks,
+	refs *msgref.Refs,
 	notificationLog NotificationLog,
+	deliveries DeliveryObserver,
+	spooler *Spooler,
 	marker types.Marker,
+	suppressions *Suppressions,
 	peer *cluster.Peer,
 	logger log.Logger,
 ) RoutingStage {
-	rs := RoutingStage{}
+	var (
+		rs      = RoutingStage{}
+		spooled = map[string]*spoolReceiver{}
+	)
 
 	ms := NewGossipSettleStage(peer)
-	is := NewInhibitStage(muter)
-	ss := NewSilenceStage(silences, marker)
+	sbs := NewStandbyStage(peer)
+	is := NewInhibitStage(muter, suppressions)
+	tms := NewTimeMuteStage(muteTimeIntervals, suppressions)
+	ss := NewSilenceStage(silences, marker, suppressions)
+	as := NewAckStage(acks)
 
 	for _, rc := range confs {
-		rs[rc.Name] = MultiStage{ms, is, ss, createStage(rc, tmpl, wait, notificationLog, logger)}
+		stages := MultiStage{ms, sbs, is, tms, ss}
+		if !rc.RepeatAcknowledged {
+			stages = append(stages, as)
+		}
+		if rc.FlapDetection != nil {
+			stages = append(stages, NewFlapDetectionStage(rc.Name, *rc.FlapDetection))
+		}
+		sr := spooler.receiver(rc, notificationLog)
+		if sr != nil {
+			spooled[rc.Name] = sr
+		}
+		var s Stage = createStage(rc, tmpl, wait, refs, notificationLog, deliveries, sr, peer, logger)
+		if rc.TemplateFallbackReceiver != "" {
+			s = NewTemplateFallbackStage(s, rs, rc.Name, rc.TemplateFallbackReceiver)
+		}
+		rs[rc.Name] = append(stages, s)
 	}
+	spooler.setReceivers(spooled)
 	return rs
 }
 
 // createStage creates a pipeline of stages for a receiver.
-func createStage(rc *config.Receiver, tmpl *template.Template, wait func() time.Duration, notificationLog NotificationLog, logger log.Logger) Stage {
-	var fs FanoutStage
-	for _, i := range BuildReceiverIntegrations(rc, tmpl, logger) {
+func createStage(rc *config.Receiver, tmpl *template.Template, wait func() time.Duration, refs *msgref.Refs, notificationLog NotificationLog, deliveries DeliveryObserver, spool *spoolReceiver, peer *cluster.Peer, logger log.Logger) Stage {
+	var (
+		fs  FanoutStage
+		sem chan struct{}
+		qs  Stage
+		es  = NewEnrichStage(tmpl)
+	)
+	if rc.MaxConcurrentNotifications > 0 {
+		sem = make(chan struct{}, rc.MaxConcurrentNotifications)
+	}
+	if c := rc.Coordination; c != nil && peer != nil {
+		if c.PeerWait > 0 {
+			wait = func() time.Duration {
+				return time.Duration(peer.Position()) * time.Duration(c.PeerWait)
+			}
+		}
+		if c.Mode == config.CoordinationStrict {
+			qs = NewQuorumStage(peer, rc.Name, time.Duration(c.QuorumTimeout))
+		}
+	}
+	for _, i := range BuildReceiverIntegrations(rc, tmpl, refs, logger) {
 		recv := &nflogpb.Receiver{
 			GroupName:   rc.Name,
 			Integration: i.name,
@@ -262,8 +487,32 @@ func createStage(rc *config.Receiver, tmpl *template.Template, wait func() time.
 		}
 		var s MultiStage
 		s = append(s, NewWaitStage(wait))
+		if qs != nil {
+			s = append(s, qs)
+		}
 		s = append(s, NewDedupStage(notificationLog, recv))
-		s = append(s, NewRetryStage(i, rc.Name))
+		// Prometheus is only queried for notifications that are sent.
+		s = append(s, es)
+		var rs Stage = NewRetryStage(i, rc.Name)
+		if spool != nil {
+			rs = spool.stage(rs, i)
+		}
+		if h, ok := notificationLog.(NotificationHistory); ok {
+			rs = NewHistoryStage(rs, h, recv)
+		}
+		if deliveries != nil {
+			rs = NewDeliveryStage(rs, deliveries, rc.Name, i.name, i.idx)
+		}
+		if sem != nil {
+			rs = NewConcurrencyLimitStage(rs, sem)
+		}
+		if rc.RateLimit != nil {
+			rs = NewRateLimitStage(rs, rc.Name, i.name, *rc.RateLimit, logger)
+		}
+		if rc.DigestWindow > 0 {
+			rs = NewDigestStage(rs, notificationLog, recv, time.Duration(rc.DigestWindow), logger)
+		}
+		s = append(s, rs)
 		s = append(s, NewSetNotifiesStage(notificationLog, recv))
 
 		fs = append(fs, s)
@@ -271,6 +520,67 @@ func createStage(rc *config.Receiver, tmpl *template.Template, wait func() time.
 	return fs
 }
 
+// IntegrationResult is the result of a notification of an integration.
+type IntegrationResult struct {
+	Integration string
+	Index       int
+	Duration    time.Duration
+	Err         error
+}
+
+// TestReceiver sends a test notification of the alerts through each
+// integration of the receiver. The notifications use the templates and HTTP
+// configurations of the integrations but are neither retried nor
+// deduplicated, muted or recorded in the notification log. Integrations not
+// sending resolved notifications ignore resolved alerts.
+func TestReceiver(ctx context.Context, rc *config.Receiver, tmpl *template.Template, alerts []*types.Alert, logger log.Logger) []IntegrationResult {
+	var (
+		groupLabels = model.LabelSet{}
+		firing      []uint64
+		resolved    []uint64
+		now         = time.Now()
+	)
+	for _, a := range alerts {
+		if name, ok := a.Labels[model.AlertNameLabel]; ok {
+			groupLabels[model.AlertNameLabel] = name
+		}
+		if a.ResolvedAt(now) {
+			resolved = append(resolved, uint64(a.Fingerprint()))
+		} else {
+			firing = append(firing, uint64(a.Fingerprint()))
+		}
+	}
+	ctx = WithReceiverName(ctx, rc.Name)
+	ctx = WithGroupKey(ctx, "{}/test:"+groupLabels.String())
+	ctx = WithGroupLabels(ctx, groupLabels)
+	ctx = WithNow(ctx, now)
+	ctx = WithFiringAlerts(ctx, firing)
+	ctx = WithResolvedAlerts(ctx, resolved)
+
+	var (
+		integrations = BuildReceiverIntegrations(rc, tmpl, nil, logger)
+		results      = make([]IntegrationResult, len(integrations))
+		wg           sync.WaitGroup
+	)
+	for i, integration := range integrations {
+		wg.Add(1)
+		go func(i int, integration Integration) {
+			defer wg.Done()
+
+			start := time.Now()
+			_, err := integration.Notify(ctx, alerts...)
+			results[i] = IntegrationResult{
+				Integration: integration.name,
+				Index:       integration.idx,
+				Duration:    time.Since(start),
+				Err:         err,
+			}
+		}(i, integration)
+	}
+	wg.Wait()
+	return results
+}
+
 // RoutingStage executes the inner stages based on the receiver specified in
 // the context.
 type RoutingStage map[string]Stage
@@ -282,6 +592,34 @@ func (rs RoutingStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.
 		return ctx, nil, fmt.Errorf("receiver missing")
 	}
 
+	escalation, ok := EscalationReceiver(ctx)
+	if !ok {
+		return rs.execReceiver(ctx, l, receiver, alerts...)
+	}
+
+	// The escalation receiver is notified even if notifying the receiver
+	// fails, which makes escalating all the more urgent.
+	ctx, res, err := rs.execReceiver(ctx, l, receiver, alerts...)
+
+	s, ok := rs[escalation]
+	if !ok {
+		return ctx, nil, fmt.Errorf("stage for receiver %q missing", escalation)
+	}
+	level.Debug(l).Log("msg", "Notifying escalation receiver", "receiver", receiver, "escalation_receiver", escalation)
+	numEscalations.WithLabelValues(receiver, escalation).Inc()
+	if _, _, eerr := s.Exec(WithReceiverName(ctx, escalation), l, alerts...); err == nil {
+		err = eerr
+	}
+	return ctx, res, err
+}
+
+// execReceiver notifies the receiver and, if configured in the context, its
+// failover receivers.
+func (rs RoutingStage) execReceiver(ctx context.Context, l log.Logger, receiver string, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if failover, _ := FailoverReceivers(ctx); len(failover) > 0 {
+		return rs.execFailover(ctx, l, append([]string{receiver}, failover...), alerts...)
+	}
+
 	s, ok := rs[receiver]
 	if !ok {
 		return ctx, nil, fmt.Errorf("stage for receiver missing")
@@ -290,6 +628,98 @@ func (rs RoutingStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.
 	return s.Exec(ctx, l, alerts...)
 }
 
+// execFailover notifies the receivers in order until notifying one of them
+// succeeds. The time left until the deadline of the context is split evenly
+// between the receivers that were not tried yet, so that the retries of a
+// failing receiver leave time for the next one.
+func (rs RoutingStage) execFailover(ctx context.Context, l log.Logger, receivers []string, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var err error
+	for i, receiver := range receivers {
+		s, ok := rs[receiver]
+		if !ok {
+			return ctx, nil, fmt.Errorf("stage for receiver %q missing", receiver)
+		}
+		if i > 0 {
+			level.Warn(l).Log("msg", "Notifying failover receiver", "receiver", receivers[i-1], "failover_receiver", receiver, "err", err)
+			numFailovers.WithLabelValues(receivers[i-1], receiver).Inc()
+		}
+
+		var (
+			rctx   context.Context
+			cancel context.CancelFunc
+		)
+		if deadline, ok := ctx.Deadline(); ok && i < len(receivers)-1 {
+			rctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(len(receivers)-i))
+		} else {
+			rctx, cancel = context.WithCancel(ctx)
+		}
+		var res []*types.Alert
+		_, res, err = s.Exec(WithReceiverName(rctx, receiver), l, alerts...)
+		cancel()
+		if err == nil {
+			return ctx, res, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return ctx, nil, err
+}
+
+// TemplateFallbackStage notifies the fallback receiver if an integration of
+// the inner stage fails to template the fields identifying the recipient
+// of the notification.
+type TemplateFallbackStage struct {
+	stage    Stage
+	rs       RoutingStage
+	receiver string
+	fallback string
+}
+
+// NewTemplateFallbackStage returns a new instance of a TemplateFallbackStage
+// notifying the stage of the fallback receiver in the routing stage.
+func NewTemplateFallbackStage(s Stage, rs RoutingStage, receiver, fallback string) *TemplateFallbackStage {
+	return &TemplateFallbackStage{
+		stage:    s,
+		rs:       rs,
+		receiver: receiver,
+		fallback: fallback,
+	}
+}
+
+// Exec implements the Stage interface.
+func (n TemplateFallbackStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, res, err := n.stage.Exec(ctx, l, alerts...)
+	if err == nil || !isIdentityError(err) {
+		return ctx, res, err
+	}
+	s, ok := n.rs[n.fallback]
+	if !ok {
+		return ctx, nil, fmt.Errorf("stage for receiver %q missing", n.fallback)
+	}
+	level.Warn(l).Log("msg", "Notifying template fallback receiver", "receiver", n.receiver, "fallback_receiver", n.fallback, "err", err)
+	numTemplateFallbacks.WithLabelValues(n.receiver, n.fallback).Inc()
+
+	_, res, err = s.Exec(WithReceiverName(ctx, n.fallback), l, alerts...)
+	return ctx, res, err
+}
+
+// isIdentityError returns whether the error or one of the errors of a
+// MultiError is an identityError.
+func isIdentityError(err error) bool {
+	switch e := err.(type) {
+	case *identityError:
+		return true
+	case *types.MultiError:
+		for _, err := range e.Errors() {
+			if isIdentityError(err) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // A MultiStage executes a series of stages sequencially.
 type MultiStage []Stage
 
@@ -355,42 +785,165 @@ func (n *GossipSettleStage) Exec(ctx context.Context, l log.Logger, alerts ...*t
 	return ctx, alerts, nil
 }
 
+// StandbyStage drops the alerts while the peer is a standby, so that only
+// the active peers of the cluster send notifications.
+type StandbyStage struct {
+	peer *cluster.Peer
+}
+
+// NewStandbyStage returns a new StandbyStage.
+func NewStandbyStage(p *cluster.Peer) *StandbyStage {
+	return &StandbyStage{peer: p}
+}
+
+func (n *StandbyStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if n.peer != nil && n.peer.Standby() {
+		level.Debug(l).Log("msg", "Not notifying as the peer is a standby", "alerts", len(alerts))
+		return ctx, nil, nil
+	}
+	return ctx, alerts, nil
+}
+
+// quorumPeer is implemented by the peer of a cluster.
+type quorumPeer interface {
+	Quorum(timeout time.Duration) bool
+}
+
+// QuorumStage drops the alerts unless the peer reaches a quorum of the
+// cluster, so that the peers of a minority partition do not notify the
+// receiver a second time.
+type QuorumStage struct {
+	peer     quorumPeer
+	receiver string
+	timeout  time.Duration
+}
+
+// NewQuorumStage returns a new QuorumStage for the receiver counting the
+// members the peer heard from within the timeout.
+func NewQuorumStage(p *cluster.Peer, receiver string, timeout time.Duration) *QuorumStage {
+	return &QuorumStage{peer: p, receiver: receiver, timeout: timeout}
+}
+
+func (n *QuorumStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if !n.peer.Quorum(n.timeout) {
+		level.Warn(l).Log("msg", "Not notifying as the peer does not reach a quorum of the cluster", "alerts", len(alerts))
+		numWithoutQuorum.WithLabelValues(n.receiver).Inc()
+		return ctx, nil, nil
+	}
+	return ctx, alerts, nil
+}
+
 // InhibitStage filters alerts through an inhibition muter.
 type InhibitStage struct {
-	muter types.Muter
+	muter        types.Muter
+	suppressions *Suppressions
 }
 
-// NewInhibitStage return a new InhibitStage.
-func NewInhibitStage(m types.Muter) *InhibitStage {
-	return &InhibitStage{muter: m}
+// NewInhibitStage return a new InhibitStage. The alerts it filters are
+// recorded in the suppressions, which may be nil.
+func NewInhibitStage(m types.Muter, s *Suppressions) *InhibitStage {
+	return &InhibitStage{muter: m, suppressions: s}
 }
 
 // Exec implements the Stage interface.
 func (n *InhibitStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	mutes := func(lset model.LabelSet) (int, bool) { return -1, n.muter.Mutes(lset) }
+	switch rm := n.muter.(type) {
+	case types.RuleMuter:
+		receiver, _ := ReceiverName(ctx)
+		mutes = func(lset model.LabelSet) (int, bool) { return rm.MutingRule(receiver, lset) }
+	case types.ReceiverMuter:
+		if receiver, ok := ReceiverName(ctx); ok {
+			mutes = func(lset model.LabelSet) (int, bool) { return -1, rm.MutesReceiver(receiver, lset) }
+		}
+	}
+
 	var filtered []*types.Alert
 	for _, a := range alerts {
 		// TODO(fabxc): increment total alerts counter.
 		// Do not send the alert if the silencer mutes it.
-		if !n.muter.Mutes(a.Labels) {
-			// TODO(fabxc): increment muted alerts counter.
-			filtered = append(filtered, a)
+		if rule, ok := mutes(a.Labels); ok {
+			n.suppressions.inhibited(rule)
+			continue
 		}
+		filtered = append(filtered, a)
 	}
 
 	return ctx, filtered, nil
 }
 
+// TimeMuteStage drops all alerts while one of the mute time intervals of the
+// route is active or none of its active time intervals is. The intervals are
+// evaluated in their own time zones at the time of the notification.
+type TimeMuteStage struct {
+	intervals    map[string]*config.MuteTimeInterval
+	suppressions *Suppressions
+}
+
+// NewTimeMuteStage returns a new TimeMuteStage. The alerts it drops are
+// recorded in the suppressions, which may be nil.
+func NewTimeMuteStage(mts []*config.MuteTimeInterval, s *Suppressions) *TimeMuteStage {
+	intervals := make(map[string]*config.MuteTimeInterval, len(mts))
+	for _, mt := range mts {
+		intervals[mt.Name] = mt
+	}
+	return &TimeMuteStage{intervals: intervals, suppressions: s}
+}
+
+// Exec implements the Stage interface.
+func (n *TimeMuteStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	muteNames, _ := MuteTimeIntervals(ctx)
+	activeNames, _ := ActiveTimeIntervals(ctx)
+	if len(muteNames) == 0 && len(activeNames) == 0 {
+		return ctx, alerts, nil
+	}
+	now, ok := Now(ctx)
+	if !ok {
+		return ctx, nil, fmt.Errorf("now time missing")
+	}
+
+	for _, name := range muteNames {
+		mt, ok := n.intervals[name]
+		if !ok {
+			return ctx, nil, fmt.Errorf("mute time interval %q does not exist", name)
+		}
+		if mt.ContainsTime(now) {
+			level.Debug(l).Log("msg", "Notifications muted by time interval", "interval", name)
+			n.suppressions.muted(name, len(alerts))
+			return ctx, nil, nil
+		}
+	}
+	if len(activeNames) == 0 {
+		return ctx, alerts, nil
+	}
+	for _, name := range activeNames {
+		mt, ok := n.intervals[name]
+		if !ok {
+			return ctx, nil, fmt.Errorf("active time interval %q does not exist", name)
+		}
+		if mt.ContainsTime(now) {
+			return ctx, alerts, nil
+		}
+	}
+	level.Debug(l).Log("msg", "Notifications muted outside of active time intervals", "intervals", strings.Join(activeNames, ","))
+	n.suppressions.inactive(len(alerts))
+	return ctx, nil, nil
+}
+
 // SilenceStage filters alerts through a silence muter.
 type SilenceStage struct {
-	silences *silence.Silences
-	marker   types.Marker
+	silences     *silence.Silences
+	marker       types.Marker
+	suppressions *Suppressions
 }
 
-// NewSilenceStage returns a new SilenceStage.
-func NewSilenceStage(s *silence.Silences, mk types.Marker) *SilenceStage {
+// NewSilenceStage returns a new SilenceStage. The alerts it filters are
+// recorded in the suppressions, which may be nil.
+func NewSilenceStage(s *silence.Silences, mk types.Marker, sup *Suppressions) *SilenceStage {
 	return &SilenceStage{
-		silences: s,
-		marker:   mk,
+		silences:     s,
+		marker:       mk,
+		suppressions: sup,
 	}
 }
 
@@ -418,12 +971,45 @@ func (n *SilenceStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.
 				ids[i] = s.Id
 			}
 			n.marker.SetSilenced(a.Labels.Fingerprint(), ids...)
+			n.suppressions.silenced(ids...)
 		}
 	}
 
 	return ctx, filtered, nil
 }
 
+// AckStage determines whether all firing alerts are acknowledged, in which
+// case repeat notifications are paused and the group is not escalated.
+type AckStage struct {
+	acks *ack.Acks
+}
+
+// NewAckStage returns a new AckStage.
+func NewAckStage(a *ack.Acks) *AckStage {
+	return &AckStage{acks: a}
+}
+
+// Exec implements the Stage interface.
+func (n *AckStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var firing int
+	for _, a := range alerts {
+		if a.Resolved() {
+			continue
+		}
+		if !n.acks.Acknowledged(a.Fingerprint()) {
+			return ctx, alerts, nil
+		}
+		firing++
+	}
+	if firing > 0 {
+		escalation, _ := EscalationReceiver(ctx)
+		if receiver, ok := ReceiverName(ctx); ok && receiver == escalation {
+			return ctx, nil, nil
+		}
+	}
+	return WithAcknowledged(ctx, firing > 0), alerts, nil
+}
+
 // WaitStage waits for a certain amount of time before continuing or until the
 // context is done.
 type WaitStage struct {
@@ -580,9 +1166,25 @@ func (n *DedupStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Al
 	case 2:
 		return ctx, nil, fmt.Errorf("unexpected entry result size %d", len(entries))
 	}
-	if ok, err := n.needsUpdate(entry, firingSet, resolvedSet, repeatInterval); err != nil {
+	if n.recv != nil {
+		// The previous notification is known to all peers, so that they
+		// derive the same key for the same notification.
+		var prev time.Time
+		if entry != nil {
+			prev = entry.Timestamp
+		}
+		ctx = WithIdempotencyKey(ctx, nflog.IdempotencyKey(n.recv.GroupName, gkey, prev, firing, resolved))
+	}
+	ok, err = n.needsUpdate(entry, firingSet, resolvedSet, repeatInterval)
+	if err != nil {
 		return ctx, nil, err
-	} else if ok {
+	}
+	// Acknowledged alerts only pause repeat notifications. Newly firing
+	// and resolved alerts are still notified.
+	if ok && entry != nil && len(firing) > 0 && entry.IsFiringSubset(firingSet) && Acknowledged(ctx) {
+		ok = false
+	}
+	if ok {
 		return ctx, alerts, nil
 	}
 	return ctx, nil, nil
@@ -619,10 +1221,10 @@ func (r RetryStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Ale
 	}
 
 	var (
-		i    = 0
-		b    = backoff.NewExponentialBackOff()
-		tick = backoff.NewTicker(b)
-		iErr error
+		i              = 0
+		b, maxAttempts = r.backoff()
+		tick           = backoff.NewTicker(b)
+		iErr           error
 	)
 	defer tick.Stop()
 
@@ -647,9 +1249,17 @@ func (r RetryStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Ale
 			if err != nil {
 				numFailedNotifications.WithLabelValues(r.integration.name).Inc()
 				level.Debug(l).Log("msg", "Notify attempt failed", "attempt", i, "integration", r.integration.name, "receiver", r.groupName, "err", err)
+				if _, ok := err.(*identityError); ok {
+					// Templating the recipient does not succeed by retrying
+					// and the error is kept for the template fallback.
+					return ctx, alerts, err
+				}
 				if !retry {
 					return ctx, alerts, fmt.Errorf("cancelling notify retry for %q due to unrecoverable error: %s", r.integration.name, err)
 				}
+				if maxAttempts > 0 && i >= maxAttempts {
+					return ctx, alerts, fmt.Errorf("cancelling notify retry for %q after %d attempts: %s", r.integration.name, i, err)
+				}
 
 				// Save this error to be able to return the last seen error by an
 				// integration upon context timeout.
@@ -668,6 +1278,24 @@ func (r RetryStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Ale
 	}
 }
 
+// backoff returns the backoff between attempts and the maximum number of
+// attempts of the integration, where 0 means unlimited.
+func (r RetryStage) backoff() (*backoff.ExponentialBackOff, int) {
+	b := backoff.NewExponentialBackOff()
+	rc, ok := r.integration.conf.(retryPolicyConfig)
+	if !ok || rc.RetryPolicy() == nil {
+		return b, 0
+	}
+	p := rc.RetryPolicy()
+	if p.InitialBackoff > 0 {
+		b.InitialInterval = time.Duration(p.InitialBackoff)
+	}
+	if p.MaxBackoff > 0 {
+		b.MaxInterval = time.Duration(p.MaxBackoff)
+	}
+	return b, p.MaxAttempts
+}
+
 // SetNotifiesStage sets the notification information about passed alerts. The
 // passed alerts should have already been sent to the receivers.
 type SetNotifiesStage struct {
@@ -702,3 +1330,113 @@ func (n SetNotifiesStage) Exec(ctx context.Context, l log.Logger, alerts ...*typ
 
 	return ctx, alerts, n.nflog.Log(n.recv, gkey, firing, resolved)
 }
+
+// NotificationHistory records the outcome of notification attempts.
+type NotificationHistory interface {
+	LogAttempt(a *nflog.Attempt)
+}
+
+// HistoryStage records every attempt of the inner stage to send a
+// notification in the notification history.
+type HistoryStage struct {
+	stage   Stage
+	history NotificationHistory
+	recv    *nflogpb.Receiver
+}
+
+// NewHistoryStage returns a new instance of a HistoryStage.
+func NewHistoryStage(s Stage, h NotificationHistory, recv *nflogpb.Receiver) *HistoryStage {
+	return &HistoryStage{
+		stage:   s,
+		history: h,
+		recv:    recv,
+	}
+}
+
+// Exec implements the Stage interface.
+func (n HistoryStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, res, err := n.stage.Exec(ctx, l, alerts...)
+
+	gkey, _ := GroupKey(ctx)
+	a := &nflog.Attempt{
+		GroupKey: gkey,
+		Receiver: n.recv,
+	}
+	for _, alert := range alerts {
+		if alert.Resolved() {
+			a.ResolvedAlerts = append(a.ResolvedAlerts, uint64(alert.Fingerprint()))
+		} else {
+			a.FiringAlerts = append(a.FiringAlerts, uint64(alert.Fingerprint()))
+		}
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	n.history.LogAttempt(a)
+
+	return ctx, res, err
+}
+
+// DeliveryObserver observes the results of notifications.
+type DeliveryObserver interface {
+	Observe(receiver, integration string, idx int, d time.Duration, err error)
+}
+
+// DeliveryStage reports the duration and result of the inner stage sending
+// a notification to the delivery observer.
+type DeliveryStage struct {
+	stage       Stage
+	observer    DeliveryObserver
+	receiver    string
+	integration string
+	idx         int
+}
+
+// NewDeliveryStage returns a new instance of a DeliveryStage.
+func NewDeliveryStage(s Stage, o DeliveryObserver, receiver, integration string, idx int) *DeliveryStage {
+	return &DeliveryStage{
+		stage:       s,
+		observer:    o,
+		receiver:    receiver,
+		integration: integration,
+		idx:         idx,
+	}
+}
+
+// Exec implements the Stage interface.
+func (n DeliveryStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	start := time.Now()
+	ctx, res, err := n.stage.Exec(ctx, l, alerts...)
+	n.observer.Observe(n.receiver, n.integration, n.idx, time.Since(start), err)
+
+	return ctx, res, err
+}
+
+// ConcurrencyLimitStage limits the number of notifications the inner stages
+// sharing the semaphore send at the same time. Notifications wait for a
+// free slot until the context is done.
+type ConcurrencyLimitStage struct {
+	stage Stage
+	sem   chan struct{}
+}
+
+// NewConcurrencyLimitStage returns a new instance of a ConcurrencyLimitStage
+// sending at most cap(sem) notifications at a time.
+func NewConcurrencyLimitStage(s Stage, sem chan struct{}) *ConcurrencyLimitStage {
+	return &ConcurrencyLimitStage{
+		stage: s,
+		sem:   sem,
+	}
+}
+
+// Exec implements the Stage interface.
+func (n ConcurrencyLimitStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	select {
+	case n.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx, nil, ctx.Err()
+	}
+	defer func() { <-n.sem }()
+
+	return n.stage.Exec(ctx, l, alerts...)
+}
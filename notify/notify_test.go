@@ -13,22 +13,32 @@
 package notify
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	commoncfg "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 
+	"github.com/prometheus/alertmanager/ack"
+	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/nflog"
 	"github.com/prometheus/alertmanager/nflog/nflogpb"
 	"github.com/prometheus/alertmanager/silence"
 	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/spool"
+	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 )
 
@@ -266,6 +276,169 @@ func TestDedupStage(t *testing.T) {
 	require.Equal(t, alerts, res, "unexpected alerts returned")
 }
 
+func TestAckStage(t *testing.T) {
+	acks, err := ack.New(ack.Options{})
+	require.NoError(t, err)
+
+	var (
+		a1 = &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"a": "1"}}}
+		a2 = &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"a": "2"}}}
+		r1 = &types.Alert{Alert: model.Alert{
+			Labels: model.LabelSet{"a": "3"},
+			EndsAt: utcNow().Add(-time.Minute),
+		}}
+	)
+	require.NoError(t, acks.Set(&types.Acknowledgement{
+		Fingerprint: a1.Fingerprint().String(),
+		CreatedBy:   "alice",
+		ExpiresAt:   utcNow().Add(time.Hour),
+	}))
+
+	stage := NewAckStage(acks)
+	for _, tc := range []struct {
+		alerts []*types.Alert
+		acked  bool
+	}{
+		{alerts: []*types.Alert{a1}, acked: true},
+		{alerts: []*types.Alert{a1, r1}, acked: true},
+		{alerts: []*types.Alert{a1, a2}, acked: false},
+		{alerts: []*types.Alert{r1}, acked: false},
+	} {
+		ctx, res, err := stage.Exec(context.Background(), log.NewNopLogger(), tc.alerts...)
+		require.NoError(t, err)
+		require.Equal(t, tc.alerts, res)
+		require.Equal(t, tc.acked, Acknowledged(ctx))
+	}
+
+	// Acknowledged groups are not escalated, but the receiver is still
+	// notified.
+	ctx := WithEscalationReceiver(context.Background(), "manager")
+	_, res, err := stage.Exec(WithReceiverName(ctx, "manager"), log.NewNopLogger(), a1)
+	require.NoError(t, err)
+	require.Empty(t, res)
+	_, res, err = stage.Exec(WithReceiverName(ctx, "manager"), log.NewNopLogger(), a1, a2)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	_, res, err = stage.Exec(WithReceiverName(ctx, "team-X"), log.NewNopLogger(), a1)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+}
+
+func TestBuildPipelineRepeatAcknowledged(t *testing.T) {
+	rs := BuildPipeline([]*config.Receiver{
+		{Name: "paused"},
+		{Name: "repeated", RepeatAcknowledged: true},
+	}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, log.NewNopLogger())
+
+	hasAckStage := func(name string) bool {
+		for _, s := range rs[name].(MultiStage) {
+			if _, ok := s.(*AckStage); ok {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, hasAckStage("paused"))
+	require.False(t, hasAckStage("repeated"))
+}
+
+func TestCreateStageEnrichesAfterDedup(t *testing.T) {
+	rc := &config.Receiver{
+		Name: "team-X",
+		WebhookConfigs: []*config.WebhookConfig{
+			{URL: "http://example.com", HTTPConfig: &commoncfg.HTTPClientConfig{}},
+		},
+	}
+	fs := createStage(rc, createTmpl(t), nil, nil, &testNflog{}, nil, nil, nil, log.NewNopLogger()).(FanoutStage)
+	require.Len(t, fs, 1)
+
+	dedup, enrich := -1, -1
+	for i, s := range fs[0].(MultiStage) {
+		switch s.(type) {
+		case *DedupStage:
+			dedup = i
+		case *EnrichStage:
+			enrich = i
+		}
+	}
+	require.NotEqual(t, -1, dedup)
+	require.True(t, enrich > dedup, "enrich stage at %d, dedup stage at %d", enrich, dedup)
+}
+
+func TestDedupStageIdempotencyKey(t *testing.T) {
+	now := utcNow()
+	nl := &testNflog{qerr: nflog.ErrNotFound}
+	s := &DedupStage{
+		nflog: nl,
+		recv:  &nflogpb.Receiver{GroupName: "team-X", Integration: "webhook"},
+		hash:  func(a *types.Alert) uint64 { return 1 },
+		now:   func() time.Time { return now },
+	}
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithRepeatInterval(ctx, time.Hour)
+	key := func() string {
+		ctx, _, err := s.Exec(ctx, log.NewNopLogger(), &types.Alert{})
+		require.NoError(t, err)
+		k, ok := IdempotencyKey(ctx)
+		require.True(t, ok)
+		return k
+	}
+
+	first := key()
+	require.Equal(t, first, key())
+
+	// Repeated notifications about the same alerts get a new key.
+	nl.qres, nl.qerr = []*nflogpb.Entry{{FiringAlerts: []uint64{1}, Timestamp: now.Add(-2 * time.Hour)}}, nil
+	repeated := key()
+	require.NotEqual(t, first, repeated)
+	nl.qres[0].Timestamp = now.Add(-time.Hour)
+	require.NotEqual(t, repeated, key())
+}
+
+func TestDedupStageAcknowledged(t *testing.T) {
+	now := utcNow()
+	s := &DedupStage{
+		hash: func(a *types.Alert) uint64 {
+			return uint64(a.Labels["a"][0] - '0')
+		},
+		now: func() time.Time {
+			return now
+		},
+		nflog: &testNflog{
+			qres: []*nflogpb.Entry{
+				{
+					FiringAlerts: []uint64{1, 2},
+					Timestamp:    now.Add(-2 * time.Hour),
+				},
+			},
+		},
+	}
+	alert := func(v model.LabelValue) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"a": v}}}
+	}
+
+	ctx := WithGroupKey(context.Background(), "1")
+	ctx = WithRepeatInterval(ctx, time.Hour)
+
+	// The repeat interval has passed.
+	alerts := []*types.Alert{alert("1"), alert("2")}
+	_, res, err := s.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+
+	// Acknowledged alerts are not repeated.
+	ctx = WithAcknowledged(ctx, true)
+	_, res, err = s.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	// New firing alerts are still notified.
+	alerts = append(alerts, alert("3"))
+	_, res, err = s.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+}
+
 func TestMultiStage(t *testing.T) {
 	var (
 		alerts1 = []*types.Alert{{}}
@@ -344,6 +517,116 @@ func TestRoutingStage(t *testing.T) {
 	}
 }
 
+func TestRoutingStageFailover(t *testing.T) {
+	var notified []string
+	record := func(err error) Stage {
+		return StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			name, _ := ReceiverName(ctx)
+			notified = append(notified, name)
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			if name != "email" {
+				// The time is split between the remaining receivers.
+				require.True(t, time.Until(deadline) <= 30*time.Second, name)
+			}
+			return ctx, alerts, err
+		})
+	}
+	stage := RoutingStage{
+		"slack":     record(errors.New("slack is down")),
+		"pagerduty": record(errors.New("pagerduty is down")),
+		"email":     record(nil),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctx = WithReceiverName(ctx, "slack")
+	ctx = WithFailoverReceivers(ctx, []string{"pagerduty", "email"})
+
+	alerts := []*types.Alert{{}}
+	_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+	require.Equal(t, []string{"slack", "pagerduty", "email"}, notified)
+
+	// The error of the last receiver is returned if all fail.
+	stage["email"] = record(errors.New("smtp is down"))
+	notified = nil
+	_, _, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.EqualError(t, err, "smtp is down")
+	require.Equal(t, []string{"slack", "pagerduty", "email"}, notified)
+}
+
+func TestRoutingStageEscalation(t *testing.T) {
+	var notified []string
+	record := func(err error) Stage {
+		return StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			name, _ := ReceiverName(ctx)
+			notified = append(notified, name)
+			return ctx, alerts, err
+		})
+	}
+	stage := RoutingStage{
+		"slack":   record(errors.New("slack is down")),
+		"email":   record(nil),
+		"manager": record(nil),
+	}
+
+	ctx := WithReceiverName(context.Background(), "slack")
+	ctx = WithFailoverReceivers(ctx, []string{"email"})
+	alerts := []*types.Alert{{}}
+
+	_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"slack", "email"}, notified)
+
+	// The escalation receiver is notified after the receiver and its
+	// failover receivers.
+	notified = nil
+	ctx = WithEscalationReceiver(ctx, "manager")
+	_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+	require.Equal(t, []string{"slack", "email", "manager"}, notified)
+
+	// The escalation receiver is notified even if all others fail.
+	stage["email"] = record(errors.New("smtp is down"))
+	notified = nil
+	_, _, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.EqualError(t, err, "smtp is down")
+	require.Equal(t, []string{"slack", "email", "manager"}, notified)
+}
+
+func TestTemplateFallbackStage(t *testing.T) {
+	var notified []string
+	record := func(err error) Stage {
+		return StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			name, _ := ReceiverName(ctx)
+			notified = append(notified, name)
+			return ctx, alerts, err
+		})
+	}
+	rs := RoutingStage{"fallback": record(nil)}
+	ctx := WithReceiverName(context.Background(), "victorops")
+	alerts := []*types.Alert{{}}
+
+	// Other errors are returned.
+	s := NewTemplateFallbackStage(record(errors.New("victorops is down")), rs, "victorops", "fallback")
+	_, _, err := s.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.EqualError(t, err, "victorops is down")
+	require.Equal(t, []string{"victorops"}, notified)
+
+	// Failing to template the recipient notifies the fallback receiver.
+	var me types.MultiError
+	me.Add(&identityError{field: "routing_key", err: errors.New("the template rendered an empty value")})
+	notified = nil
+	s = NewTemplateFallbackStage(record(&me), rs, "victorops", "fallback")
+	_, res, err := s.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+	require.Equal(t, []string{"victorops", "fallback"}, notified)
+}
+
 func TestIntegrationNoResolved(t *testing.T) {
 	res := []*types.Alert{}
 	r := notifierFunc(func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
@@ -400,6 +683,49 @@ func TestIntegrationSendResolved(t *testing.T) {
 	require.Equal(t, res, alerts)
 }
 
+type retryPolicyConfigFunc func() *config.RetryConfig
+
+func (f retryPolicyConfigFunc) SendResolved() bool {
+	return true
+}
+
+func (f retryPolicyConfigFunc) RetryPolicy() *config.RetryConfig {
+	return f()
+}
+
+func TestRetryStageMaxAttempts(t *testing.T) {
+	var attempts int
+	r := notifierFunc(func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+		attempts++
+		return true, fmt.Errorf("unavailable")
+	})
+	i := Integration{
+		notifier: r,
+		name:     "webhook",
+		conf: retryPolicyConfigFunc(func() *config.RetryConfig {
+			return &config.RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: model.Duration(time.Millisecond),
+				MaxBackoff:     model.Duration(time.Millisecond),
+			}
+		}),
+	}
+	s := NewRetryStage(i, "team-X")
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _, err := s.Exec(ctx, log.NewNopLogger(), alert)
+	require.EqualError(t, err, `cancelling notify retry for "webhook" after 3 attempts: unavailable`)
+	require.Equal(t, 3, attempts)
+}
+
 func TestSetNotifiesStage(t *testing.T) {
 	tnflog := &testNflog{}
 	s := &SetNotifiesStage{
@@ -458,6 +784,82 @@ func TestSetNotifiesStage(t *testing.T) {
 	require.NotNil(t, resctx)
 }
 
+type quorumFunc func(timeout time.Duration) bool
+
+func (f quorumFunc) Quorum(timeout time.Duration) bool {
+	return f(timeout)
+}
+
+func TestQuorumStage(t *testing.T) {
+	quorum := true
+	s := &QuorumStage{
+		peer: quorumFunc(func(timeout time.Duration) bool {
+			require.Equal(t, time.Minute, timeout)
+			return quorum
+		}),
+		receiver: "team-X",
+		timeout:  time.Minute,
+	}
+	alerts := []*types.Alert{{}, {}}
+
+	_, res, err := s.Exec(context.Background(), log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+
+	quorum = false
+	_, res, err = s.Exec(context.Background(), log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Nil(t, res)
+}
+
+type attemptsFunc func(a *nflog.Attempt)
+
+func (f attemptsFunc) LogAttempt(a *nflog.Attempt) {
+	f(a)
+}
+
+func TestHistoryStage(t *testing.T) {
+	var attempts []*nflog.Attempt
+	recv := &nflogpb.Receiver{GroupName: "test", Integration: "webhook"}
+	h := attemptsFunc(func(a *nflog.Attempt) {
+		attempts = append(attempts, a)
+	})
+
+	firing := &types.Alert{Alert: model.Alert{
+		Labels: model.LabelSet{"a": "1"},
+		EndsAt: time.Now().Add(time.Hour),
+	}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels: model.LabelSet{"a": "2"},
+		EndsAt: time.Now().Add(-time.Hour),
+	}}
+	ctx := WithGroupKey(context.Background(), "1")
+
+	s := NewHistoryStage(MultiStage{}, h, recv)
+	_, res, err := s.Exec(ctx, log.NewNopLogger(), firing, resolved)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Alert{firing, resolved}, res)
+
+	s = NewHistoryStage(failStage{}, h, recv)
+	_, _, err = s.Exec(ctx, log.NewNopLogger(), firing)
+	require.EqualError(t, err, "some error")
+
+	require.Equal(t, []*nflog.Attempt{
+		{
+			GroupKey:       "1",
+			Receiver:       recv,
+			FiringAlerts:   []uint64{uint64(firing.Fingerprint())},
+			ResolvedAlerts: []uint64{uint64(resolved.Fingerprint())},
+		},
+		{
+			GroupKey:     "1",
+			Receiver:     recv,
+			FiringAlerts: []uint64{uint64(firing.Fingerprint())},
+			Error:        "some error",
+		},
+	}, attempts)
+}
+
 func TestSilenceStage(t *testing.T) {
 	silences, err := silence.New(silence.Options{})
 	if err != nil {
@@ -471,7 +873,7 @@ func TestSilenceStage(t *testing.T) {
 	}
 
 	marker := types.NewMarker()
-	silencer := NewSilenceStage(silences, marker)
+	silencer := NewSilenceStage(silences, marker, nil)
 
 	in := []model.LabelSet{
 		{},
@@ -523,7 +925,7 @@ func TestInhibitStage(t *testing.T) {
 		return ok
 	})
 
-	inhibitor := NewInhibitStage(muter)
+	inhibitor := NewInhibitStage(muter, nil)
 
 	in := []model.LabelSet{
 		{},
@@ -563,3 +965,580 @@ func TestInhibitStage(t *testing.T) {
 		t.Fatalf("Muting failed, expected: %v\ngot %v", out, got)
 	}
 }
+
+// ruleMuter mutes label sets with a "mute" label by the rule whose index is
+// the value of the label.
+type ruleMuter struct{}
+
+func (ruleMuter) Mutes(lset model.LabelSet) bool { return ruleMuter{}.MutesReceiver("", lset) }
+
+func (ruleMuter) MutesReceiver(receiver string, lset model.LabelSet) bool {
+	_, ok := ruleMuter{}.MutingRule(receiver, lset)
+	return ok
+}
+
+func (ruleMuter) MutingRule(receiver string, lset model.LabelSet) (int, bool) {
+	v, ok := lset["mute"]
+	if !ok {
+		return -1, false
+	}
+	i, _ := strconv.Atoi(string(v))
+	return i, true
+}
+
+func TestSuppressions(t *testing.T) {
+	sup := NewSuppressions()
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"mute": "1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"mute": "1", "a": "b"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"mute": "0"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"a": "b"}}},
+	}
+
+	ctx := WithReceiverName(context.Background(), "team-X")
+	_, res, err := NewInhibitStage(ruleMuter{}, sup).Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	sid, err := silences.Set(&silencepb.Silence{
+		EndsAt:   utcNow().Add(time.Hour),
+		Matchers: []*silencepb.Matcher{{Name: "a", Pattern: "b"}},
+	})
+	require.NoError(t, err)
+	_, res, err = NewSilenceStage(silences, types.NewMarker(), sup).Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	stage := NewTimeMuteStage([]*config.MuteTimeInterval{{
+		Name:          "always",
+		TimeIntervals: []config.TimeInterval{{}},
+	}}, sup)
+	ctx = WithMuteTimeIntervals(WithNow(ctx, utcNow()), []string{"always"})
+	_, res, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Len(t, res, 0)
+
+	require.Equal(t, SuppressionCounts{
+		Silences: map[string]uint64{sid: 2},
+		InhibitRules: []InhibitRuleSuppressions{
+			{RuleIndex: 0, Count: 1},
+			{RuleIndex: 1, Count: 2},
+		},
+		MuteTimeIntervals: map[string]uint64{"always": 4},
+	}, sup.Counts())
+}
+
+func TestTimeMuteStage(t *testing.T) {
+	stage := NewTimeMuteStage([]*config.MuteTimeInterval{
+		{
+			Name: "weekends",
+			TimeIntervals: []config.TimeInterval{{
+				Weekdays: []config.Weekday{config.Weekday(time.Saturday), config.Weekday(time.Sunday)},
+			}},
+		},
+	}, nil)
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"a": "b"}}}}
+
+	// Without mute time intervals in the route all alerts pass.
+	ctx := WithNow(context.Background(), time.Date(2018, 6, 2, 12, 0, 0, 0, time.UTC))
+	_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+
+	// Saturday is muted.
+	ctx = WithMuteTimeIntervals(ctx, []string{"weekends"})
+	_, res, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(res))
+
+	// Monday is not.
+	ctx = WithNow(ctx, time.Date(2018, 6, 4, 12, 0, 0, 0, time.UTC))
+	_, res, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Equal(t, alerts, res)
+
+	ctx = WithMuteTimeIntervals(ctx, []string{"nights"})
+	_, _, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+	require.EqualError(t, err, "mute time interval \"nights\" does not exist")
+}
+
+func TestTimeMuteStageActiveTimeIntervals(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	stage := NewTimeMuteStage([]*config.MuteTimeInterval{
+		{
+			Name: "business-hours",
+			TimeIntervals: []config.TimeInterval{{
+				Times: []config.TimeRange{{
+					StartTime: config.TimeOfDay{Hour: 9},
+					EndTime:   config.TimeOfDay{Hour: 17},
+				}},
+				Weekdays: []config.Weekday{1, 2, 3, 4, 5},
+				TimeZone: &config.Location{Location: loc},
+			}},
+		},
+	}, nil)
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"a": "b"}}}}
+	ctx := WithActiveTimeIntervals(context.Background(), []string{"business-hours"})
+
+	for _, tc := range []struct {
+		now    time.Time
+		active bool
+	}{
+		// 13:30 UTC is 08:30 on Friday before the switch to daylight saving
+		// time and 09:30 on Monday after it.
+		{time.Date(2018, 3, 9, 13, 30, 0, 0, time.UTC), false},
+		{time.Date(2018, 3, 12, 13, 30, 0, 0, time.UTC), true},
+		{time.Date(2018, 3, 12, 21, 0, 0, 0, time.UTC), false},
+		// Saturday is outside of business hours.
+		{time.Date(2018, 3, 10, 15, 0, 0, 0, time.UTC), false},
+	} {
+		_, res, err := stage.Exec(WithNow(ctx, tc.now), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		if tc.active {
+			require.Equal(t, alerts, res, "%s", tc.now)
+		} else {
+			require.Len(t, res, 0, "%s", tc.now)
+		}
+	}
+
+	// Mute time intervals take precedence over active ones.
+	ctx = WithMuteTimeIntervals(ctx, []string{"business-hours"})
+	_, res, err := stage.Exec(WithNow(ctx, time.Date(2018, 3, 12, 13, 30, 0, 0, time.UTC)), log.NewNopLogger(), alerts...)
+	require.NoError(t, err)
+	require.Len(t, res, 0)
+
+	ctx = WithActiveTimeIntervals(context.Background(), []string{"on-call"})
+	_, _, err = stage.Exec(WithNow(ctx, time.Now()), log.NewNopLogger(), alerts...)
+	require.EqualError(t, err, "active time interval \"on-call\" does not exist")
+}
+
+func TestEnrichStage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/query", r.URL.Path)
+		switch r.URL.Query().Get("query") {
+		case `up{instance="db1"}`:
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","instance":"db1"},"value":[1530000000,"0"]}]}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := WithReceiverName(context.Background(), "test")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"instance": "db1"})
+	ctx = WithEnrichments(ctx, []*config.Enrichment{
+		{Name: "up", Query: `up{instance="{{ .GroupLabels.instance }}"}`, PrometheusURL: srv.URL, HTTPConfig: &commoncfg.HTTPClientConfig{}},
+		{Name: "broken", Query: `up{`, PrometheusURL: srv.URL, HTTPConfig: &commoncfg.HTTPClientConfig{}},
+	})
+
+	tmpl := createTmpl(t)
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"instance": "db1"}}}
+	ctx, res, err := NewEnrichStage(tmpl).Exec(ctx, log.NewNopLogger(), alert)
+	require.NoError(t, err)
+	require.Equal(t, []*types.Alert{alert}, res)
+
+	data := templateData(ctx, tmpl, log.NewNopLogger(), alert)
+	require.Equal(t, &template.Enrichment{
+		Query:    `up{instance="db1"}`,
+		Value:    "0",
+		Samples:  []template.Sample{{Labels: template.KV{"__name__": "up", "instance": "db1"}, Value: "0"}},
+		GraphURL: srv.URL + "/graph?g0.expr=up%7Binstance%3D%22db1%22%7D&g0.tab=0",
+	}, data.Enrichments["up"])
+	require.Equal(t, "query failed: parse error", data.Enrichments["broken"].Error)
+
+	s, err := tmpl.ExecuteTextString(`{{ .Enrichments.up.Value }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "0", s)
+}
+
+func TestConcurrencyLimitStage(t *testing.T) {
+	var (
+		sem     = make(chan struct{}, 2)
+		release = make(chan struct{})
+		mtx     sync.Mutex
+		running int
+		max     int
+	)
+	inner := StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		mtx.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mtx.Unlock()
+		<-release
+		mtx.Lock()
+		running--
+		mtx.Unlock()
+		return ctx, alerts, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := NewConcurrencyLimitStage(inner, sem).Exec(context.Background(), log.NewNopLogger())
+			require.NoError(t, err)
+		}()
+	}
+	// Only two notifications are in flight until one finishes.
+	for len(sem) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	require.Equal(t, 2, max)
+
+	// Waiting for a free slot ends with the context.
+	sem <- struct{}{}
+	sem <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := NewConcurrencyLimitStage(inner, sem).Exec(ctx, log.NewNopLogger())
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRateLimitStage(t *testing.T) {
+	var sent [][]*types.Alert
+	inner := StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		sent = append(sent, alerts)
+		return ctx, alerts, nil
+	})
+	s := NewRateLimitStage(inner, "team-X", "pagerduty", config.RateLimitConfig{
+		Max:    2,
+		Period: model.Duration(time.Hour),
+	}, log.NewNopLogger())
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	notify := func(group string, names ...string) []*types.Alert {
+		var alerts []*types.Alert
+		for _, n := range names {
+			alerts = append(alerts, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(n)}}})
+		}
+		_, res, err := s.Exec(WithGroupKey(context.Background(), group), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return res
+	}
+
+	notify("a", "A1")
+	notify("b", "B1")
+	require.Len(t, sent, 2)
+
+	// Suppressed notifications are passed on to be recorded as sent.
+	res := notify("c", "C1", "C2")
+	require.Len(t, res, 2)
+	notify("c", "C1", "C2", "C3")
+	require.Len(t, sent, 2)
+	require.NotNil(t, s.timer)
+	s.timer.Stop()
+
+	now = now.Add(time.Hour)
+	s.flush()
+	require.Len(t, sent, 3)
+	summary := sent[2][0]
+	require.Equal(t, model.LabelValue(RateLimitedAlertName), summary.Labels[model.AlertNameLabel])
+	require.Equal(t, model.LabelValue("team-X"), summary.Labels["receiver"])
+	require.Equal(t, model.LabelValue("3 alerts suppressed"), summary.Annotations["summary"])
+	require.Contains(t, string(summary.Annotations["description"]), "2 notifications for 3 alerts in 1 groups")
+
+	// The summary counts towards the limit of the new period.
+	notify("d", "D1")
+	require.Len(t, sent, 4)
+	notify("e", "E1")
+	require.Len(t, sent, 4)
+	s.timer.Stop()
+
+	// The limit is reset once the period is over.
+	now = now.Add(time.Hour)
+	notify("f", "F1")
+	require.Len(t, sent, 5)
+}
+
+func TestDigestStage(t *testing.T) {
+	var (
+		sent    [][]*types.Alert
+		sentCtx context.Context
+		sendErr error
+		logged  []string
+	)
+	inner := StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		sent = append(sent, alerts)
+		sentCtx = ctx
+		return ctx, alerts, sendErr
+	})
+	nl := &testNflog{
+		qerr: nflog.ErrNotFound,
+		logFunc: func(r *nflogpb.Receiver, gkey string, firingAlerts, resolvedAlerts []uint64) error {
+			logged = append(logged, gkey)
+			return nil
+		},
+	}
+	recv := &nflogpb.Receiver{GroupName: "team-X", Integration: "slack"}
+	s := NewDigestStage(inner, nl, recv, time.Hour, log.NewNopLogger())
+
+	notify := func(group string, firing []uint64, names ...string) []*types.Alert {
+		var alerts []*types.Alert
+		for _, n := range names {
+			alerts = append(alerts, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(n)}}})
+		}
+		ctx := WithGroupKey(context.Background(), group)
+		ctx = WithGroupLabels(ctx, model.LabelSet{"group": model.LabelValue(group)})
+		ctx = WithFiringAlerts(ctx, firing)
+		ctx = WithResolvedAlerts(ctx, nil)
+		_, res, err := s.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return res
+	}
+
+	// Accumulated notifications are not passed on to be recorded as sent.
+	res := notify("b", []uint64{1}, "B1")
+	require.Len(t, res, 0)
+	notify("a", []uint64{2}, "A1")
+	// A later notification of a group replaces the earlier one.
+	notify("b", []uint64{1, 3}, "B1", "B2")
+	require.Len(t, sent, 0)
+	require.NotNil(t, s.timer)
+	s.timer.Stop()
+
+	// The notifications are recorded once the digest was sent.
+	s.flush()
+	require.Len(t, sent, 1)
+	require.Equal(t, []string{"a", "b"}, logged)
+	require.Len(t, sent[0], 3)
+	require.Equal(t, model.LabelValue("A1"), sent[0][0].Labels["alertname"])
+
+	gkey, _ := GroupKey(sentCtx)
+	require.Equal(t, "digest/team-X", gkey)
+	firing, _ := FiringAlerts(sentCtx)
+	require.Equal(t, []uint64{2, 1, 3}, firing)
+
+	tmpl := createTmpl(t)
+	data := templateData(WithReceiverName(sentCtx, "team-X"), tmpl, log.NewNopLogger(), sent[0]...)
+	require.Len(t, data.Groups, 2)
+	require.Equal(t, template.KV{"group": "a"}, data.Groups[0].Labels)
+	require.Len(t, data.Groups[1].Alerts, 2)
+
+	subject, err := tmpl.ExecuteTextString(`{{ template "__subject" . }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "[DIGEST] 2 groups, 3 firing, 0 resolved", subject)
+
+	// Nothing is sent without accumulated notifications.
+	s.flush()
+	require.Len(t, sent, 1)
+	require.Nil(t, s.timer)
+
+	// Nothing is recorded if sending the digest fails.
+	logged = nil
+	sendErr = errors.New("send failed")
+	notify("a", []uint64{2}, "A1")
+	s.timer.Stop()
+	s.flush()
+	require.Len(t, sent, 2)
+	require.Len(t, logged, 0)
+	sendErr = nil
+
+	// Groups sent by the digest of another peer after they were accumulated
+	// are not sent again.
+	notify("a", []uint64{2}, "A1")
+	s.timer.Stop()
+	nl.qres, nl.qerr = []*nflogpb.Entry{{FiringAlerts: []uint64{2}, Timestamp: time.Now()}}, nil
+	s.flush()
+	require.Len(t, sent, 2)
+}
+
+func TestFlapDetectionStage(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	conf := config.FlapDetectionConfig{
+		Window:    model.Duration(time.Hour),
+		Threshold: 3,
+		Action:    config.FlapActionHold,
+	}
+	s := NewFlapDetectionStage("team-X", conf)
+	s.now = func() time.Time { return now }
+
+	var (
+		steady = &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Steady"},
+			StartsAt: now.Add(-time.Hour),
+		}}
+		flapping = &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Flapping"},
+			StartsAt: now.Add(-time.Minute),
+		}}
+	)
+	exec := func() []*types.Alert {
+		_, res, err := s.Exec(context.Background(), log.NewNopLogger(), steady, flapping)
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Len(t, exec(), 2)
+
+	// Resolve and fire again between two notifications.
+	now = now.Add(5 * time.Minute)
+	flapping.StartsAt = now.Add(-time.Minute)
+	require.Len(t, exec(), 2)
+
+	// Resolve, the third change within the window.
+	now = now.Add(5 * time.Minute)
+	flapping.EndsAt = now.Add(-time.Minute)
+	res := exec()
+	require.Equal(t, []*types.Alert{steady}, res)
+
+	// Once the changes leave the window, the alert is notified again.
+	now = now.Add(56 * time.Minute)
+	require.Len(t, exec(), 2)
+
+	// Annotated flapping alerts are copies.
+	conf.Action = config.FlapActionAnnotate
+	s = NewFlapDetectionStage("team-X", conf)
+	s.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Minute)
+		if flapping.Resolved() {
+			flapping.StartsAt, flapping.EndsAt = now, time.Time{}
+		} else {
+			flapping.EndsAt = now
+		}
+		res = exec()
+	}
+	require.Len(t, res, 2)
+	require.Equal(t, model.LabelValue("true"), res[1].Annotations[FlappingAnnotation])
+	require.Equal(t, model.LabelValue(""), flapping.Annotations[FlappingAnnotation])
+	require.Equal(t, steady, res[0])
+}
+
+func TestTestReceiver(t *testing.T) {
+	var msgs []map[string]interface{}
+	var mtx sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var msg map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		mtx.Lock()
+		msgs = append(msgs, msg)
+		mtx.Unlock()
+	}))
+	defer srv.Close()
+
+	rc := &config.Receiver{
+		Name: "team",
+		WebhookConfigs: []*config.WebhookConfig{
+			{URL: srv.URL, HTTPConfig: &commoncfg.HTTPClientConfig{}},
+			{URL: srv.URL + "/failing", HTTPConfig: &commoncfg.HTTPClientConfig{}},
+		},
+	}
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+
+	results := TestReceiver(context.Background(), rc, createTmpl(t), []*types.Alert{alert}, log.NewNopLogger())
+	require.Len(t, results, 2)
+	require.Equal(t, "webhook", results[0].Integration)
+	require.Equal(t, 0, results[0].Index)
+	require.NoError(t, results[0].Err)
+	require.Equal(t, 1, results[1].Index)
+	require.Error(t, results[1].Err)
+
+	require.Len(t, msgs, 1)
+	require.Equal(t, "team", msgs[0]["receiver"])
+	require.Equal(t, "firing", msgs[0]["status"])
+	require.Equal(t, `{}/test:{alertname="Test"}`, msgs[0]["groupKey"])
+}
+
+func TestSpoolStage(t *testing.T) {
+	sp, err := spool.New(spool.Options{})
+	require.NoError(t, err)
+	spooler := NewSpooler(sp, log.NewNopLogger())
+
+	var (
+		fail     = true
+		notified [][]*types.Alert
+	)
+	i := Integration{
+		notifier: notifierFunc(func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+			if fail {
+				return true, fmt.Errorf("unavailable")
+			}
+			notified = append(notified, alerts)
+			return false, nil
+		}),
+		conf: notifierConfigFunc(func() bool { return true }),
+		name: "webhook",
+	}
+	var logged []string
+	nflog := &testNflog{
+		logFunc: func(r *nflogpb.Receiver, gkey string, firingAlerts, resolvedAlerts []uint64) error {
+			logged = append(logged, gkey)
+			return nil
+		},
+	}
+	rc := &config.Receiver{
+		Name:  "team-X",
+		Spool: &config.SpoolConfig{MaxAge: model.Duration(time.Hour), MaxSize: 1 << 20},
+	}
+	sr := spooler.receiver(rc, nflog)
+	inner := StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		_, err := i.Notify(ctx, alerts...)
+		return ctx, alerts, err
+	})
+	s := sr.stage(inner, i)
+	spooler.setReceivers(map[string]*spoolReceiver{rc.Name: sr})
+
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "Test"},
+			StartsAt: time.Now(),
+		},
+	}
+	ctx := WithGroupKey(context.Background(), "{}:{alertname=\"Test\"}")
+	ctx = WithGroupLabels(ctx, model.LabelSet{"alertname": "Test"})
+	ctx = WithFiringAlerts(ctx, []uint64{uint64(alert.Fingerprint())})
+	ctx = WithResolvedAlerts(ctx, []uint64{})
+
+	_, _, err = s.Exec(ctx, log.NewNopLogger(), alert)
+	require.Error(t, err)
+	require.Len(t, sp.Entries(), 1)
+
+	// The spooled notification is kept while the integration fails.
+	spooler.replay(time.Second)
+	require.Len(t, sp.Entries(), 1)
+	require.Len(t, notified, 0)
+
+	fail = false
+	spooler.replay(time.Second)
+	require.Len(t, sp.Entries(), 0)
+	require.Equal(t, [][]*types.Alert{{alert}}, notified)
+	require.Equal(t, []string{"{}:{alertname=\"Test\"}"}, logged)
+
+	// A successful notification supersedes the spooled one.
+	fail = true
+	_, _, err = s.Exec(ctx, log.NewNopLogger(), alert)
+	require.Error(t, err)
+	require.Len(t, sp.Entries(), 1)
+	fail = false
+	_, _, err = s.Exec(ctx, log.NewNopLogger(), alert)
+	require.NoError(t, err)
+	require.Len(t, sp.Entries(), 0)
+
+	// Notifications of receivers no longer spooling are dropped.
+	fail = true
+	_, _, err = s.Exec(ctx, log.NewNopLogger(), alert)
+	require.Error(t, err)
+	spooler.setReceivers(map[string]*spoolReceiver{})
+	spooler.replay(time.Second)
+	require.Len(t, sp.Entries(), 0)
+}
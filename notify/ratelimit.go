@@ -0,0 +1,164 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// RateLimitedAlertName is the alert name of notifications summarizing the
+// notifications suppressed by a rate limit.
+const RateLimitedAlertName = "NotificationsRateLimited"
+
+var numRateLimitedNotifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "notifications_rate_limited_total",
+	Help:      "The total number of notifications suppressed by the rate limit of a receiver.",
+}, []string{"receiver", "integration"})
+
+func init() {
+	prometheus.Register(numRateLimitedNotifications)
+}
+
+// RateLimitStage limits the number of notifications sent by the inner stage
+// in a period. Notifications over the limit are not sent but passed on as
+// if they were, so that they are not retried. At the end of the period, a
+// single notification summarizing the suppressed ones is sent instead.
+type RateLimitStage struct {
+	stage       Stage
+	receiver    string
+	integration string
+	conf        config.RateLimitConfig
+	logger      log.Logger
+	now         func() time.Time
+
+	mtx        sync.Mutex
+	start      time.Time
+	sent       int
+	suppressed int
+	alerts     map[model.Fingerprint]struct{}
+	groups     map[string]struct{}
+	timer      *time.Timer
+}
+
+// NewRateLimitStage returns a new RateLimitStage sending notifications
+// through s.
+func NewRateLimitStage(s Stage, receiver, integration string, conf config.RateLimitConfig, l log.Logger) *RateLimitStage {
+	return &RateLimitStage{
+		stage:       s,
+		receiver:    receiver,
+		integration: integration,
+		conf:        conf,
+		logger:      l,
+		now:         time.Now,
+		alerts:      map[model.Fingerprint]struct{}{},
+		groups:      map[string]struct{}{},
+	}
+}
+
+// Exec implements the Stage interface.
+func (s *RateLimitStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.mtx.Lock()
+	now := s.now()
+	period := time.Duration(s.conf.Period)
+	if now.Sub(s.start) >= period {
+		s.start = now
+		s.sent = 0
+	}
+	if s.sent < s.conf.Max {
+		s.sent++
+		s.mtx.Unlock()
+		return s.stage.Exec(ctx, l, alerts...)
+	}
+
+	s.suppressed++
+	for _, a := range alerts {
+		s.alerts[a.Fingerprint()] = struct{}{}
+	}
+	if gkey, ok := GroupKey(ctx); ok {
+		s.groups[gkey] = struct{}{}
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.start.Add(period).Sub(now), s.flush)
+	}
+	s.mtx.Unlock()
+
+	numRateLimitedNotifications.WithLabelValues(s.receiver, s.integration).Inc()
+	level.Warn(l).Log("msg", "Notification suppressed by rate limit", "receiver", s.receiver, "integration", s.integration, "max", s.conf.Max, "period", s.conf.Period)
+
+	return ctx, alerts, nil
+}
+
+// flush sends a notification summarizing the suppressed notifications.
+// It counts towards the limit of the new period.
+func (s *RateLimitStage) flush() {
+	s.mtx.Lock()
+	now := s.now()
+	a := s.summary(now)
+	s.start = now
+	s.sent = 1
+	s.suppressed = 0
+	s.alerts = map[model.Fingerprint]struct{}{}
+	s.groups = map[string]struct{}{}
+	s.timer = nil
+	s.mtx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), MinTimeout)
+	defer cancel()
+
+	ctx = WithReceiverName(ctx, s.receiver)
+	ctx = WithGroupKey(ctx, RateLimitedAlertName+"/"+s.receiver)
+	ctx = WithGroupLabels(ctx, model.LabelSet{model.AlertNameLabel: RateLimitedAlertName})
+	ctx = WithFiringAlerts(ctx, []uint64{uint64(a.Fingerprint())})
+	ctx = WithResolvedAlerts(ctx, nil)
+	ctx = WithNow(ctx, now)
+
+	l := log.With(s.logger, "receiver", s.receiver, "integration", s.integration)
+	if _, _, err := s.stage.Exec(ctx, l, a); err != nil {
+		level.Error(l).Log("msg", "Sending rate limit summary failed", "err", err)
+	}
+}
+
+// summary returns the alert describing the suppressed notifications.
+func (s *RateLimitStage) summary(now time.Time) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: RateLimitedAlertName,
+				"receiver":           model.LabelValue(s.receiver),
+				"integration":        model.LabelValue(s.integration),
+			},
+			Annotations: model.LabelSet{
+				"summary": model.LabelValue(fmt.Sprintf("%d alerts suppressed", len(s.alerts))),
+				"description": model.LabelValue(fmt.Sprintf(
+					"%d notifications for %d alerts in %d groups were suppressed since %s because the receiver exceeded its limit of %d notifications per %s.",
+					s.suppressed, len(s.alerts), len(s.groups), s.start.Format(time.RFC3339), s.conf.Max, s.conf.Period,
+				)),
+			},
+			StartsAt: now,
+		},
+		UpdatedAt: now,
+	}
+}
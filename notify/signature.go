@@ -0,0 +1,70 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// signaturePrefix names the algorithm of webhook signatures.
+const signaturePrefix = "sha256="
+
+// WebhookSignature returns the signature of a webhook request with the body
+// signed at the Unix timestamp, which is "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the body.
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature returns an error unless the signature is valid for
+// the body and the timestamp, and the timestamp is at most window away from
+// now. Receivers of signed webhooks can use it to reject forged and replayed
+// requests.
+func VerifyWebhookSignature(secret, signature, timestamp string, body []byte, window time.Duration, now time.Time) error {
+	if signature == "" || timestamp == "" {
+		return errors.New("missing signature")
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > window || d < -window {
+		return fmt.Errorf("timestamp %s outside of the window of %s", timestamp, window)
+	}
+	if !hmac.Equal([]byte(signature), []byte(WebhookSignature(secret, timestamp, body))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// signWebhook sets the signature and timestamp headers of a webhook request
+// with the body.
+func signWebhook(h http.Header, conf *config.WebhookSigningConfig, body []byte, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	h.Set(conf.TimestampHeader, ts)
+	h.Set(conf.SignatureHeader, WebhookSignature(string(conf.Secret), ts, body))
+}
@@ -0,0 +1,250 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/spool"
+	"github.com/prometheus/alertmanager/types"
+)
+
+var numSpoolReplays = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "alertmanager",
+	Name:      "notification_spool_replayed_total",
+	Help:      "The total number of spooled notifications that were sent after the integration recovered.",
+}, []string{"receiver", "integration"})
+
+func init() {
+	prometheus.Register(numSpoolReplays)
+}
+
+// Spooler spools the notifications that integrations of receivers with a
+// spool configuration failed to send and replays them once the integration
+// recovers.
+type Spooler struct {
+	spool  *spool.Spool
+	logger log.Logger
+
+	mtx       sync.RWMutex
+	receivers map[string]*spoolReceiver
+}
+
+// spoolReceiver holds the spool configuration and the integrations of a
+// receiver of the current pipeline.
+type spoolReceiver struct {
+	name            string
+	conf            config.SpoolConfig
+	spool           *spool.Spool
+	notificationLog NotificationLog
+	integrations    map[string]Integration
+}
+
+// NewSpooler returns a new Spooler storing the notifications in s.
+func NewSpooler(s *spool.Spool, l log.Logger) *Spooler {
+	return &Spooler{
+		spool:     s,
+		logger:    l,
+		receivers: map[string]*spoolReceiver{},
+	}
+}
+
+func integrationKey(name string, idx int) string {
+	return fmt.Sprintf("%s/%d", name, idx)
+}
+
+// receiver returns the spool of the receiver for a new pipeline or nil if
+// the receiver does not spool notifications.
+func (s *Spooler) receiver(rc *config.Receiver, notificationLog NotificationLog) *spoolReceiver {
+	if s == nil || rc.Spool == nil {
+		return nil
+	}
+	return &spoolReceiver{
+		name:            rc.Name,
+		conf:            *rc.Spool,
+		spool:           s.spool,
+		notificationLog: notificationLog,
+		integrations:    map[string]Integration{},
+	}
+}
+
+// setReceivers replaces the receivers of the previous pipeline.
+func (s *Spooler) setReceivers(rs map[string]*spoolReceiver) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.receivers = rs
+	s.mtx.Unlock()
+}
+
+// stage returns a stage spooling the failed notifications of the inner
+// stage sending through the integration.
+func (r *spoolReceiver) stage(s Stage, i Integration) *SpoolStage {
+	r.integrations[integrationKey(i.name, i.idx)] = i
+	return &SpoolStage{
+		stage:       s,
+		spool:       r.spool,
+		receiver:    r.name,
+		integration: i.name,
+		idx:         i.idx,
+	}
+}
+
+// SpoolStage spools the notifications that the inner stage failed to send.
+// A successful notification removes the spooled one of the aggregation
+// group, as it is superseded.
+type SpoolStage struct {
+	stage       Stage
+	spool       *spool.Spool
+	receiver    string
+	integration string
+	idx         int
+}
+
+// Exec implements the Stage interface.
+func (s *SpoolStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, ok := GroupKey(ctx)
+	if !ok {
+		return ctx, nil, fmt.Errorf("group key missing")
+	}
+	e := &spool.Entry{
+		Receiver:    s.receiver,
+		Integration: s.integration,
+		Idx:         s.idx,
+		GroupKey:    gkey,
+		Alerts:      alerts,
+	}
+
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err == nil {
+		s.spool.Remove(e.Key())
+		return ctx, res, nil
+	}
+	if _, ok := err.(*identityError); ok {
+		// Replaying does not help if the recipient cannot be templated.
+		return ctx, res, err
+	}
+
+	e.GroupLabels, _ = GroupLabels(ctx)
+	e.Firing, _ = FiringAlerts(ctx)
+	e.Resolved, _ = ResolvedAlerts(ctx)
+	if serr := s.spool.Add(e); serr != nil {
+		level.Error(l).Log("msg", "Spooling failed notification failed", "integration", s.integration, "receiver", s.receiver, "err", serr)
+	}
+	return ctx, res, err
+}
+
+// Run replays the spooled notifications at the given interval until stopc
+// is closed.
+func (s *Spooler) Run(interval time.Duration, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			return
+		case <-t.C:
+			s.replay(interval)
+		}
+	}
+}
+
+// replay drops the notifications exceeding the spool limits of their
+// receiver and sends the remaining ones, oldest first. It stops replaying
+// through an integration after it failed again.
+func (s *Spooler) replay(timeout time.Duration) {
+	s.mtx.RLock()
+	receivers := s.receivers
+	s.mtx.RUnlock()
+
+	s.spool.Drop(func(name string) bool {
+		_, ok := receivers[name]
+		return ok
+	})
+	for _, r := range receivers {
+		s.spool.Truncate(r.name, time.Duration(r.conf.MaxAge), r.conf.MaxSize)
+	}
+
+	failed := map[string]bool{}
+	for _, e := range s.spool.Entries() {
+		r, ok := receivers[e.Receiver]
+		if !ok {
+			continue
+		}
+		ikey := e.Receiver + "/" + integrationKey(e.Integration, e.Idx)
+		if failed[ikey] {
+			continue
+		}
+		i, ok := r.integrations[integrationKey(e.Integration, e.Idx)]
+		if !ok {
+			// The integration was removed from the receiver.
+			s.spool.Done(e)
+			continue
+		}
+		if err := s.send(i, e, timeout); err != nil {
+			level.Debug(s.logger).Log("msg", "Replaying spooled notification failed", "integration", e.Integration, "receiver", e.Receiver, "err", err)
+			failed[ikey] = true
+			continue
+		}
+		numSpoolReplays.WithLabelValues(e.Receiver, e.Integration).Inc()
+		s.spool.Done(e)
+
+		if r.notificationLog == nil {
+			continue
+		}
+		// Log the notification so that it is not sent again with the next
+		// flush of the aggregation group.
+		recv := &nflogpb.Receiver{
+			GroupName:   e.Receiver,
+			Integration: e.Integration,
+			Idx:         uint32(e.Idx),
+		}
+		if err := r.notificationLog.Log(recv, e.GroupKey, e.Firing, e.Resolved); err != nil {
+			level.Error(s.logger).Log("msg", "Error on logging replayed notification", "integration", e.Integration, "receiver", e.Receiver, "err", err)
+		}
+	}
+}
+
+func (s *Spooler) send(i Integration, e *spool.Entry, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ctx = WithReceiverName(ctx, e.Receiver)
+	ctx = WithGroupKey(ctx, e.GroupKey)
+	ctx = WithGroupLabels(ctx, e.GroupLabels)
+	ctx = WithFiringAlerts(ctx, e.Firing)
+	ctx = WithResolvedAlerts(ctx, e.Resolved)
+	ctx = WithNow(ctx, time.Now())
+
+	now := time.Now()
+	_, err := i.Notify(ctx, e.Alerts...)
+	notificationLatencySeconds.WithLabelValues(i.name).Observe(time.Since(now).Seconds())
+	if err != nil {
+		numFailedNotifications.WithLabelValues(i.name).Inc()
+		return err
+	}
+	numNotifications.WithLabelValues(i.name).Inc()
+	return nil
+}
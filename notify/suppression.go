@@ -0,0 +1,170 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons for which alerts are left out of notifications.
+const (
+	SuppressedBySilence          = "silence"
+	SuppressedByInhibition       = "inhibition"
+	SuppressedByMuteTimeInterval = "mute_time_interval"
+)
+
+var (
+	numSuppressedAlerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "notification_alerts_suppressed_total",
+		Help:      "The total number of times an alert was left out of a notification, by the reason.",
+	}, []string{"reason"})
+	numInhibitRuleSuppressedAlerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "inhibit_rule_suppressed_alerts_total",
+		Help:      "The total number of times an alert was left out of a notification by the inhibition rule with the index.",
+	}, []string{"rule"})
+	numMuteTimeIntervalSuppressedAlerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "alertmanager",
+		Name:      "mute_time_interval_suppressed_alerts_total",
+		Help:      "The total number of times an alert was left out of a notification by the mute time interval.",
+	}, []string{"interval"})
+)
+
+func init() {
+	prometheus.Register(numSuppressedAlerts)
+	prometheus.Register(numInhibitRuleSuppressedAlerts)
+	prometheus.Register(numMuteTimeIntervalSuppressedAlerts)
+	numSuppressedAlerts.WithLabelValues(SuppressedBySilence)
+	numSuppressedAlerts.WithLabelValues(SuppressedByInhibition)
+	numSuppressedAlerts.WithLabelValues(SuppressedByMuteTimeInterval)
+}
+
+// Suppressions counts the times alerts were left out of notifications by
+// each silence, inhibition rule and mute time interval, to find the muting
+// decisions with a large impact and the rules that never match. An alert
+// left out of several notifications, e.g. of consecutive group intervals,
+// is counted for each of them. The counts are kept across reloads of the
+// configuration.
+//
+// The counts of the inhibition rules and mute time intervals are also
+// exported as metrics. Those of silences are not, as silence IDs are
+// unbounded.
+type Suppressions struct {
+	mtx               sync.Mutex
+	silences          map[string]uint64
+	inhibitRules      map[int]uint64
+	muteTimeIntervals map[string]uint64
+}
+
+// NewSuppressions returns new Suppressions.
+func NewSuppressions() *Suppressions {
+	return &Suppressions{
+		silences:          map[string]uint64{},
+		inhibitRules:      map[int]uint64{},
+		muteTimeIntervals: map[string]uint64{},
+	}
+}
+
+// silenced records an alert left out of a notification by the silences.
+func (s *Suppressions) silenced(ids ...string) {
+	numSuppressedAlerts.WithLabelValues(SuppressedBySilence).Inc()
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	for _, id := range ids {
+		s.silences[id]++
+	}
+	s.mtx.Unlock()
+}
+
+// inhibited records an alert left out of a notification by the inhibition
+// rule with the index. A negative index records the alert only as
+// inhibited.
+func (s *Suppressions) inhibited(rule int) {
+	numSuppressedAlerts.WithLabelValues(SuppressedByInhibition).Inc()
+	if rule < 0 {
+		return
+	}
+	numInhibitRuleSuppressedAlerts.WithLabelValues(strconv.Itoa(rule)).Inc()
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.inhibitRules[rule]++
+	s.mtx.Unlock()
+}
+
+// muted records n alerts left out of a notification by the mute time
+// interval.
+func (s *Suppressions) muted(interval string, n int) {
+	numSuppressedAlerts.WithLabelValues(SuppressedByMuteTimeInterval).Add(float64(n))
+	numMuteTimeIntervalSuppressedAlerts.WithLabelValues(interval).Add(float64(n))
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	s.muteTimeIntervals[interval] += uint64(n)
+	s.mtx.Unlock()
+}
+
+// inactive records n alerts left out of a notification as none of the
+// active time intervals of the route contained its time. They are not
+// attributed to any of the intervals.
+func (s *Suppressions) inactive(n int) {
+	numSuppressedAlerts.WithLabelValues(SuppressedByMuteTimeInterval).Add(float64(n))
+}
+
+// SuppressionCounts are the counts of Suppressions.
+type SuppressionCounts struct {
+	Silences          map[string]uint64         `json:"silences"`
+	InhibitRules      []InhibitRuleSuppressions `json:"inhibitRules"`
+	MuteTimeIntervals map[string]uint64         `json:"muteTimeIntervals"`
+}
+
+// InhibitRuleSuppressions is the count of the inhibition rule with the
+// index in the configuration.
+type InhibitRuleSuppressions struct {
+	RuleIndex int    `json:"ruleIndex"`
+	Count     uint64 `json:"count"`
+}
+
+// Counts returns a copy of the counts. The inhibition rules are ordered by
+// their index.
+func (s *Suppressions) Counts() SuppressionCounts {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	c := SuppressionCounts{
+		Silences:          make(map[string]uint64, len(s.silences)),
+		InhibitRules:      make([]InhibitRuleSuppressions, 0, len(s.inhibitRules)),
+		MuteTimeIntervals: make(map[string]uint64, len(s.muteTimeIntervals)),
+	}
+	for id, n := range s.silences {
+		c.Silences[id] = n
+	}
+	for i, n := range s.inhibitRules {
+		c.InhibitRules = append(c.InhibitRules, InhibitRuleSuppressions{RuleIndex: i, Count: n})
+	}
+	sort.Slice(c.InhibitRules, func(i, j int) bool { return c.InhibitRules[i].RuleIndex < c.InhibitRules[j].RuleIndex })
+	for name, n := range s.muteTimeIntervals {
+		c.MuteTimeIntervals[name] = n
+	}
+	return c
+}
@@ -0,0 +1,359 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mqtt implements the publishing side of MQTT 3.1.1. Connections
+// only publish messages and never subscribe to topics.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Packet types of the fixed header.
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetPubrec     = 5
+	packetPubrel     = 6
+	packetPubcomp    = 7
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+const (
+	protocolLevel = 4
+	// maxRemainingLength is the largest length the fixed header can encode.
+	maxRemainingLength = 268435455
+	// maxResponseLength limits the packets read from the broker, which
+	// only acknowledges the published messages.
+	maxResponseLength = 64 * 1024
+	// defaultTimeout bounds operations whose context has no deadline.
+	defaultTimeout = 30 * time.Second
+)
+
+// ErrClosed is returned when publishing on a closed connection.
+var ErrClosed = errors.New("connection closed")
+
+// ConnectError is returned if the broker refuses the connection.
+type ConnectError struct {
+	Code byte
+}
+
+func (e *ConnectError) Error() string {
+	var reason string
+	switch e.Code {
+	case 1:
+		reason = "unacceptable protocol version"
+	case 2:
+		reason = "identifier rejected"
+	case 3:
+		reason = "server unavailable"
+	case 4:
+		reason = "bad user name or password"
+	case 5:
+		reason = "not authorized"
+	default:
+		reason = fmt.Sprintf("return code %d", e.Code)
+	}
+	return "connection refused: " + reason
+}
+
+// Temporary returns true if connecting may succeed later without changing
+// the client options.
+func (e *ConnectError) Temporary() bool {
+	return e.Code == 3
+}
+
+// Options configure a connection.
+type Options struct {
+	ClientID string
+	Username string
+	Password string
+	// KeepAlive is the maximum time between two packets sent to the
+	// broker, after which the broker may close the connection.
+	KeepAlive time.Duration
+	// TLSConfig enables TLS if not nil.
+	TLSConfig *tls.Config
+}
+
+// Conn is a connection to a broker. It is safe for concurrent use, messages
+// are published one after another.
+type Conn struct {
+	opts Options
+
+	mtx      sync.Mutex
+	conn     net.Conn
+	r        *bufio.Reader
+	packetID uint16
+	lastSent time.Time
+	closed   bool
+}
+
+// Dial connects to the broker at the given address, i.e. host and port.
+func Dial(ctx context.Context, addr string, opts Options) (*Conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TLSConfig != nil {
+		cfg := opts.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			host, _, _ := net.SplitHostPort(addr)
+			cfg.ServerName = host
+		}
+		nc = tls.Client(nc, cfg)
+	}
+
+	c := &Conn{
+		opts: opts,
+		conn: nc,
+		r:    bufio.NewReader(nc),
+	}
+	c.setDeadline(ctx)
+	if err := c.connect(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+func (c *Conn) connect() error {
+	var flags byte = 0x02 // Clean session.
+	if c.opts.Username != "" {
+		flags |= 0x80
+		if c.opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	keepAlive := c.opts.KeepAlive / time.Second
+	if keepAlive > 0xffff {
+		keepAlive = 0xffff
+	}
+
+	var b []byte
+	b = appendString(b, "MQTT")
+	b = append(b, protocolLevel, flags)
+	b = binary.BigEndian.AppendUint16(b, uint16(keepAlive))
+	b = appendString(b, c.opts.ClientID)
+	if flags&0x80 != 0 {
+		b = appendString(b, c.opts.Username)
+	}
+	if flags&0x40 != 0 {
+		b = appendString(b, c.opts.Password)
+	}
+	if err := c.write(packetConnect<<4, b); err != nil {
+		return err
+	}
+
+	typ, body, err := c.read()
+	if err != nil {
+		return err
+	}
+	if typ != packetConnack || len(body) != 2 {
+		return fmt.Errorf("unexpected packet of type %d instead of CONNACK", typ)
+	}
+	if body[1] != 0 {
+		return &ConnectError{Code: body[1]}
+	}
+	return nil
+}
+
+// Publish sends the message to the topic and waits for the broker to
+// acknowledge it according to the quality of service, which is 0, 1 or 2.
+func (c *Conn) Publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	if err := ValidateTopic(topic); err != nil {
+		return err
+	}
+	if qos > 2 {
+		return fmt.Errorf("invalid quality of service %d", qos)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	c.setDeadline(ctx)
+
+	header := byte(packetPublish<<4) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	b := appendString(nil, topic)
+	var id uint16
+	if qos > 0 {
+		c.packetID++
+		if c.packetID == 0 {
+			c.packetID = 1
+		}
+		id = c.packetID
+		b = binary.BigEndian.AppendUint16(b, id)
+	}
+	b = append(b, payload...)
+	if err := c.write(header, b); err != nil {
+		return err
+	}
+
+	switch qos {
+	case 1:
+		return c.await(packetPuback, id)
+	case 2:
+		if err := c.await(packetPubrec, id); err != nil {
+			return err
+		}
+		if err := c.write(packetPubrel<<4|0x02, binary.BigEndian.AppendUint16(nil, id)); err != nil {
+			return err
+		}
+		return c.await(packetPubcomp, id)
+	}
+	return nil
+}
+
+// await reads packets until the acknowledgement of the given type for the
+// packet identifier arrives.
+func (c *Conn) await(typ byte, id uint16) error {
+	for {
+		t, body, err := c.read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t == typ && len(body) == 2 && binary.BigEndian.Uint16(body) == id:
+			return nil
+		case t == packetPingresp, t == typ:
+			// Late responses of previous packets are skipped.
+		default:
+			return fmt.Errorf("unexpected packet of type %d", t)
+		}
+	}
+}
+
+// Idle returns the time since the last packet was sent to the broker.
+func (c *Conn) Idle() time.Duration {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return time.Since(c.lastSent)
+}
+
+// Close disconnects from the broker.
+func (c *Conn) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.conn.SetDeadline(time.Now().Add(time.Second))
+	c.write(packetDisconnect<<4, nil)
+	return c.conn.Close()
+}
+
+func (c *Conn) write(header byte, body []byte) error {
+	if len(body) > maxRemainingLength {
+		return fmt.Errorf("packet of %d bytes exceeds the maximum size", len(body))
+	}
+	b := make([]byte, 0, 5+len(body))
+	b = append(b, header)
+	n := len(body)
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if n == 0 {
+			break
+		}
+	}
+	b = append(b, body...)
+	if _, err := c.conn.Write(b); err != nil {
+		return err
+	}
+	c.lastSent = time.Now()
+	return nil
+}
+
+func (c *Conn) read() (byte, []byte, error) {
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var n, mult int = 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("malformed remaining length")
+		}
+		d, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(d&0x7f) * mult
+		mult *= 128
+		if d&0x80 == 0 {
+			break
+		}
+	}
+	if n > maxResponseLength {
+		return 0, nil, fmt.Errorf("packet of %d bytes exceeds the maximum size", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// ValidateTopic returns an error if messages cannot be published to the
+// topic name.
+func ValidateTopic(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("empty topic")
+	}
+	if len(topic) > 0xffff {
+		return fmt.Errorf("topic exceeds %d bytes", 0xffff)
+	}
+	if strings.ContainsAny(topic, "+#\x00") {
+		return fmt.Errorf("topic %q contains wildcards or null characters", topic)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// message is a message received by the test broker.
+type message struct {
+	topic   string
+	payload string
+	qos     byte
+	retain  bool
+}
+
+// testBroker accepts a single connection, answers it with the return code
+// and acknowledges the published messages.
+func testBroker(t *testing.T, code byte) (string, <-chan message, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(chan message, 10)
+	clients := make(chan string, 1)
+	go func() {
+		defer l.Close()
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		c := &Conn{conn: nc, r: bufio.NewReader(nc)}
+
+		typ, body, err := c.read()
+		if err != nil || typ != packetConnect {
+			return
+		}
+		// Skip the protocol name, level, flags and keep alive.
+		clients <- string(body[12 : 12+binary.BigEndian.Uint16(body[10:])])
+		c.write(packetConnack<<4, []byte{0, code})
+
+		for {
+			header, err := c.r.Peek(1)
+			if err != nil {
+				return
+			}
+			typ, body, err := c.read()
+			if err != nil {
+				return
+			}
+			if typ != packetPublish {
+				continue
+			}
+			qos := header[0] >> 1 & 0x03
+			n := binary.BigEndian.Uint16(body)
+			m := message{
+				topic:  string(body[2 : 2+n]),
+				qos:    qos,
+				retain: header[0]&0x01 != 0,
+			}
+			body = body[2+n:]
+			var id []byte
+			if qos > 0 {
+				id, body = body[:2], body[2:]
+			}
+			m.payload = string(body)
+			switch qos {
+			case 1:
+				c.write(packetPuback<<4, id)
+			case 2:
+				c.write(packetPubrec<<4, id)
+				if typ, _, err := c.read(); err != nil || typ != packetPubrel {
+					return
+				}
+				c.write(packetPubcomp<<4, id)
+			}
+			msgs <- m
+		}
+	}()
+	return l.Addr().String(), msgs, clients
+}
+
+func TestPublish(t *testing.T) {
+	addr, msgs, clients := testBroker(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, addr, Options{ClientID: "test", KeepAlive: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if id := <-clients; id != "test" {
+		t.Errorf("unexpected client ID %q", id)
+	}
+
+	for _, expected := range []message{
+		{topic: "alerts/0", payload: "at most once", qos: 0},
+		{topic: "alerts/1", payload: "at least once", qos: 1, retain: true},
+		{topic: "alerts/2", payload: "exactly once", qos: 2},
+	} {
+		if err := c.Publish(ctx, expected.topic, []byte(expected.payload), expected.qos, expected.retain); err != nil {
+			t.Fatalf("publish with QoS %d: %v", expected.qos, err)
+		}
+		if m := <-msgs; m != expected {
+			t.Errorf("expected %+v, got %+v", expected, m)
+		}
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	addr, _, _ := testBroker(t, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Dial(ctx, addr, Options{ClientID: "test", Username: "user", Password: "wrong"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	ce, ok := err.(*ConnectError)
+	if !ok {
+		t.Fatalf("expected connect error, got %v", err)
+	}
+	if ce.Temporary() {
+		t.Errorf("bad credentials should not be temporary")
+	}
+	if err.Error() != "connection refused: bad user name or password" {
+		t.Errorf("unexpected error %q", err)
+	}
+}
+
+func TestValidateTopic(t *testing.T) {
+	for _, tc := range []struct {
+		topic string
+		valid bool
+	}{
+		{topic: "alerts/node/critical", valid: true},
+		{topic: "alerts/", valid: true},
+		{topic: "", valid: false},
+		{topic: "alerts/+/critical", valid: false},
+		{topic: "alerts/#", valid: false},
+	} {
+		err := ValidateTopic(tc.topic)
+		if tc.valid && err != nil {
+			t.Errorf("topic %q: unexpected error %v", tc.topic, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("topic %q: expected error", tc.topic)
+		}
+	}
+}
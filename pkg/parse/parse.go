@@ -15,24 +15,31 @@ package parse
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 )
 
-var (
-	re      = regexp.MustCompile(`(?:\s?)(\w+)(=|=~|!=|!~)(?:\"([^"=~!]+)\"|([^"=~!]+)|\"\")`)
-	typeMap = map[string]labels.MatchType{
-		"=":  labels.MatchEqual,
-		"!=": labels.MatchNotEqual,
-		"=~": labels.MatchRegexp,
-		"!~": labels.MatchNotRegexp,
-	}
-)
+var typeMap = map[string]labels.MatchType{
+	"=":  labels.MatchEqual,
+	"!=": labels.MatchNotEqual,
+	"=~": labels.MatchRegexp,
+	"!~": labels.MatchNotRegexp,
+}
 
+// Matchers parses a comma-separated list of matchers, optionally enclosed in
+// braces, e.g. {alertname="Foo", service=~"api|web"}.
+//
+// Label names consist of ASCII letters, digits and underscores and do not
+// start with a digit, like the label names of alerts and silences. They may
+// be enclosed in double quotes. Values are either enclosed in double quotes,
+// in which case they may contain any character and \", \\ and \n are
+// escapes, or run unquoted until the next comma.
 func Matchers(s string) ([]*labels.Matcher, error) {
-	matchers := []*labels.Matcher{}
+	s = strings.TrimSpace(s)
 	if strings.HasPrefix(s, "{") {
 		s = s[1:]
 	}
@@ -40,34 +47,35 @@ func Matchers(s string) ([]*labels.Matcher, error) {
 		s = s[:len(s)-1]
 	}
 
-	var insideQuotes bool
-	var token string
-	var tokens []string
-	for _, r := range s {
-		if !insideQuotes && r == ',' {
-			tokens = append(tokens, token)
-			token = ""
-			continue
+	matchers := []*labels.Matcher{}
+	p := &parser{input: s}
+	for {
+		p.skipSpace()
+		if p.done() {
+			break
 		}
-		token += string(r)
-		if r == '"' {
-			insideQuotes = !insideQuotes
+		name, value, matchType, err := p.matcher()
+		if err != nil {
+			return nil, err
 		}
-	}
-	if token != "" {
-		tokens = append(tokens, token)
-	}
-	for _, token := range tokens {
-		m, err := Matcher(token)
+		m, err := labels.NewMatcher(matchType, name, value)
 		if err != nil {
 			return nil, err
 		}
 		matchers = append(matchers, m)
-	}
 
+		p.skipSpace()
+		if p.done() {
+			break
+		}
+		if p.next() != ',' {
+			return nil, p.errorf("expected comma after matcher")
+		}
+	}
 	return matchers, nil
 }
 
+// Matcher parses a single matcher, e.g. instance=~"web-.*".
 func Matcher(s string) (*labels.Matcher, error) {
 	name, value, matchType, err := Input(s)
 	if err != nil {
@@ -81,25 +89,183 @@ func Matcher(s string) (*labels.Matcher, error) {
 	return m, nil
 }
 
+// Input parses a single matcher into its label name, value and match type.
+// Unlike in a list of matchers, an unquoted value may contain commas.
 func Input(s string) (name, value string, matchType labels.MatchType, err error) {
-	ms := re.FindStringSubmatch(s)
-	if len(ms) < 4 {
-		return "", "", labels.MatchEqual, fmt.Errorf("bad matcher format: %s", s)
+	p := &parser{input: s, single: true}
+	p.skipSpace()
+	name, value, matchType, err = p.matcher()
+	if err != nil {
+		return "", "", labels.MatchEqual, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		return "", "", labels.MatchEqual, p.errorf("unexpected input after matcher")
+	}
+	return name, value, matchType, nil
+}
+
+// FormatMatcher returns the matcher in the syntax accepted by Matcher. The
+// value is always quoted, the label name only if it is not a plain name.
+func FormatMatcher(m *labels.Matcher) string {
+	name := m.Name
+	if !isPlainName(name) {
+		name = quote(name)
+	}
+	return name + m.Type.String() + quote(m.Value)
+}
+
+// parser is a tokenizer of matchers.
+type parser struct {
+	input string
+	pos   int
+	// single is set if the input is a single matcher, whose unquoted value
+	// may contain commas.
+	single bool
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+// peek returns the next rune without consuming it.
+func (p *parser) peek() rune {
+	if p.done() {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(p.input[p.pos:])
+	return r
+}
+
+// next consumes the next rune.
+func (p *parser) next() rune {
+	if p.done() {
+		return utf8.RuneError
 	}
+	r, n := utf8.DecodeRuneInString(p.input[p.pos:])
+	p.pos += n
+	return r
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.peek()) {
+		p.next()
+	}
+}
 
-	var prs bool
-	name = ms[1]
-	matchType, prs = typeMap[ms[2]]
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bad matcher format: %s: %s at position %d", p.input, fmt.Sprintf(format, args...), p.pos)
+}
 
-	if ms[3] != "" {
-		value = ms[3]
+// matcher reads a label name, an operator and a value.
+func (p *parser) matcher() (name, value string, matchType labels.MatchType, err error) {
+	if p.peek() == '"' {
+		if name, err = p.quoted(); err != nil {
+			return "", "", labels.MatchEqual, err
+		}
 	} else {
-		value = ms[4]
+		start := p.pos
+		for !p.done() && isNameRune(p.peek()) {
+			p.next()
+		}
+		name = p.input[start:p.pos]
+	}
+	if name == "" {
+		return "", "", labels.MatchEqual, p.errorf("missing label name")
+	}
+	// Names with other characters are read in full to report them, but
+	// alerts and silences cannot have them.
+	if !model.LabelName(name).IsValid() {
+		return "", "", labels.MatchEqual, p.errorf("invalid label name %q, label names must match %s", name, model.LabelNameRE)
 	}
 
-	if name == "" || !prs {
-		return "", "", labels.MatchEqual, fmt.Errorf("failed to parse")
+	p.skipSpace()
+	var op string
+	for _, o := range []string{"=~", "!=", "!~", "="} {
+		if strings.HasPrefix(p.input[p.pos:], o) {
+			op = o
+			break
+		}
 	}
+	if op == "" {
+		return "", "", labels.MatchEqual, p.errorf("expected one of =, !=, =~ or !~ after label name %q", name)
+	}
+	p.pos += len(op)
+	matchType = typeMap[op]
 
+	p.skipSpace()
+	if p.peek() == '"' {
+		if value, err = p.quoted(); err != nil {
+			return "", "", labels.MatchEqual, err
+		}
+		return name, value, matchType, nil
+	}
+	start := p.pos
+	for !p.done() && (p.single || p.peek() != ',') {
+		if p.next() == '"' {
+			return "", "", labels.MatchEqual, p.errorf("unexpected quote in unquoted value")
+		}
+	}
+	value = strings.TrimSpace(p.input[start:p.pos])
+	if value == "" {
+		return "", "", labels.MatchEqual, p.errorf("missing value, empty values are written as \"\"")
+	}
 	return name, value, matchType, nil
 }
+
+// quoted reads a string enclosed in double quotes. Escaped quotes,
+// backslashes and newlines are unescaped, other escapes are kept as they
+// are, e.g. for regular expressions.
+func (p *parser) quoted() (string, error) {
+	p.next()
+	var b strings.Builder
+	for {
+		if p.done() {
+			return "", p.errorf("missing closing quote")
+		}
+		r := p.next()
+		switch r {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.done() {
+				return "", p.errorf("missing closing quote")
+			}
+			switch e := p.next(); e {
+			case '"', '\\':
+				b.WriteRune(e)
+			case 'n':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(e)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isPlainName returns whether the label name can be written unquoted.
+func isPlainName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// quote encloses the string in double quotes, escaping quotes, backslashes
+// and newlines.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
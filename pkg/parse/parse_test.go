@@ -145,3 +145,85 @@ func TestMatchers(t *testing.T) {
 	}
 
 }
+
+func TestMatchersQuoting(t *testing.T) {
+	newMatcher := func(t labels.MatchType, n, v string) *labels.Matcher {
+		m, err := labels.NewMatcher(t, n, v)
+		if err != nil {
+			panic(err)
+		}
+		return m
+	}
+	testCases := []struct {
+		input string
+		want  []*labels.Matcher
+	}{
+		{
+			input: `{summary="disk full, {sda1} at 95%"}`,
+			want:  []*labels.Matcher{newMatcher(labels.MatchEqual, "summary", "disk full, {sda1} at 95%")},
+		},
+		{
+			input: `{msg="say \"hi\"\nnow", path=~"C:\\\\temp\.log"}`,
+			want: []*labels.Matcher{
+				newMatcher(labels.MatchEqual, "msg", "say \"hi\"\nnow"),
+				newMatcher(labels.MatchRegexp, "path", `C:\\temp\.log`),
+			},
+		},
+		{
+			input: `{ service = "api" , "service_name"!~"web|db" }`,
+			want: []*labels.Matcher{
+				newMatcher(labels.MatchEqual, "service", "api"),
+				newMatcher(labels.MatchNotRegexp, "service_name", "web|db"),
+			},
+		},
+		{
+			input: `job=my job, instance=~web-.*`,
+			want: []*labels.Matcher{
+				newMatcher(labels.MatchEqual, "job", "my job"),
+				newMatcher(labels.MatchRegexp, "instance", "web-.*"),
+			},
+		},
+		{
+			input: `{}`,
+			want:  []*labels.Matcher{},
+		},
+	}
+	for i, tc := range testCases {
+		got, err := Matchers(tc.input)
+		if err != nil {
+			t.Fatalf("unexpected error (i=%d): %v", i, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("labels not equal (i=%d):\ngot  %v\nwant %v", i, got, tc.want)
+		}
+		// Formatted matchers are parsed into the same matchers.
+		for _, m := range got {
+			again, err := Matcher(FormatMatcher(m))
+			if err != nil {
+				t.Fatalf("unexpected error parsing %s: %v", FormatMatcher(m), err)
+			}
+			if !reflect.DeepEqual(again, m) {
+				t.Fatalf("formatted matcher not equal:\ngot  %v\nwant %v", again, m)
+			}
+		}
+	}
+
+	for _, input := range []string{
+		`foo`,
+		`foo=`,
+		`foo="bar`,
+		`foo="bar" baz`,
+		`foo=bar"baz"`,
+		`=bar`,
+		`""="bar"`,
+		`{foo="bar" baz="quux"}`,
+		// Alerts and silences cannot have these label names.
+		`sérvice="api"`,
+		`"service.name"="api"`,
+		`1foo="bar"`,
+	} {
+		if _, err := Matchers(input); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
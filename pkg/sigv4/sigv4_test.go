@@ -0,0 +1,87 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigv4
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var testCredentials = Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// TestSign uses the get-vanilla case of the AWS Signature Version 4 test
+// suite.
+func TestSign(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Sign(req, nil, testCredentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("expected Authorization header\n%s\ngot\n%s", expected, got)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("unexpected X-Amz-Date header %q", got)
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			t.Errorf("request not signed: %q", r.Header.Get("Authorization"))
+		}
+		r.ParseForm()
+		if r.Form.Get("RoleArn") == "arn:aws:iam::123456789012:role/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<ErrorResponse><Error><Code>AccessDenied</Code><Message>Not authorized</Message></Error></ErrorResponse>`))
+			return
+		}
+		w.Write([]byte(`<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIAEXAMPLE</AccessKeyId><SecretAccessKey>secret</SecretAccessKey>
+<SessionToken>token</SessionToken><Expiration>2018-06-01T12:00:00Z</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`))
+	}))
+	defer srv.Close()
+
+	c, err := AssumeRole(context.Background(), http.DefaultClient, srv.URL, "us-east-1", testCredentials, "arn:aws:iam::123456789012:role/alertmanager", "alertmanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Credentials{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Expires:         time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if c != expected {
+		t.Errorf("expected credentials %+v, got %+v", expected, c)
+	}
+
+	_, err = AssumeRole(context.Background(), http.DefaultClient, srv.URL, "us-east-1", testCredentials, "arn:aws:iam::123456789012:role/forbidden", "alertmanager")
+	if err == nil || err.Error() != "AccessDenied: Not authorized" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
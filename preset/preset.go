@@ -0,0 +1,310 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preset stores the filter presets of the web UI. Deleted presets are
+// kept until the retention period after their deletion has passed, so that
+// deletions are replicated across the cluster, and can be snapshotted to disk.
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/prometheus/alertmanager/storage"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// ErrNotFound is returned if a preset was not found.
+var ErrNotFound = errors.New("preset not found")
+
+// Presets holds filter presets keyed by their name.
+type Presets struct {
+	logger    log.Logger
+	now       func() time.Time
+	retention time.Duration
+
+	mtx       sync.RWMutex
+	st        state
+	broadcast func([]byte)
+}
+
+type state map[string]*types.FilterPreset
+
+// merge adds the preset to the state if it is newer than the existing one.
+// It returns true if the state changed.
+func (s state) merge(p *types.FilterPreset) bool {
+	if prev, ok := s[p.Name]; ok && !prev.UpdatedAt.Before(p.UpdatedAt) {
+		return false
+	}
+	s[p.Name] = p
+	return true
+}
+
+func (s state) MarshalBinary() ([]byte, error) {
+	presets := make([]*types.FilterPreset, 0, len(s))
+	for _, p := range s {
+		presets = append(presets, p)
+	}
+	return json.Marshal(presets)
+}
+
+func decodeState(b []byte) (state, error) {
+	var presets []*types.FilterPreset
+	if err := json.Unmarshal(b, &presets); err != nil {
+		return nil, err
+	}
+	st := state{}
+	for _, p := range presets {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		st.merge(p)
+	}
+	return st, nil
+}
+
+// Options exposes configuration options for creating a new Presets object.
+type Options struct {
+	// A snapshot file from which the initial state is loaded.
+	SnapshotFile string
+
+	// Retention time of deleted presets.
+	Retention time.Duration
+
+	// A logger used by background processing.
+	Logger  log.Logger
+	Metrics prometheus.Registerer
+}
+
+// New returns a new Presets object with the given configuration.
+func New(o Options) (*Presets, error) {
+	p := &Presets{
+		logger:    log.NewNopLogger(),
+		now:       utcNow,
+		retention: o.Retention,
+		st:        state{},
+		broadcast: func([]byte) {},
+	}
+	if o.Logger != nil {
+		p.logger = o.Logger
+	}
+	if o.Metrics != nil {
+		o.Metrics.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "alertmanager_filter_presets",
+			Help: "How many filter presets are saved.",
+		}, func() float64 {
+			return float64(len(p.List()))
+		}))
+	}
+
+	if o.SnapshotFile != "" {
+		b, err := ioutil.ReadFile(o.SnapshotFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if p.st, err = decodeState(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// Set creates or replaces the preset with the name of the given one.
+func (p *Presets) Set(preset *types.FilterPreset) error {
+	if err := preset.Validate(); err != nil {
+		return err
+	}
+	preset.UpdatedAt = p.now()
+	preset.Deleted = false
+
+	return p.setState(preset)
+}
+
+// Delete deletes the preset with the given name.
+func (p *Presets) Delete(name string) error {
+	p.mtx.RLock()
+	prev, ok := p.st[name]
+	p.mtx.RUnlock()
+
+	if !ok || prev.Deleted {
+		return ErrNotFound
+	}
+	preset := *prev
+	preset.UpdatedAt = p.now()
+	preset.Deleted = true
+
+	return p.setState(&preset)
+}
+
+func (p *Presets) setState(preset *types.FilterPreset) error {
+	b, err := json.Marshal([]*types.FilterPreset{preset})
+	if err != nil {
+		return err
+	}
+
+	p.mtx.Lock()
+	p.st.merge(preset)
+	broadcast := p.broadcast
+	p.mtx.Unlock()
+
+	broadcast(b)
+	return nil
+}
+
+// Get returns the preset with the given name.
+func (p *Presets) Get(name string) (*types.FilterPreset, bool) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	preset, ok := p.st[name]
+	if !ok || preset.Deleted {
+		return nil, false
+	}
+	return preset, true
+}
+
+// List returns all presets ordered by name.
+func (p *Presets) List() []*types.FilterPreset {
+	res := []*types.FilterPreset{}
+
+	p.mtx.RLock()
+	for _, preset := range p.st {
+		if !preset.Deleted {
+			res = append(res, preset)
+		}
+	}
+	p.mtx.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// GC removes deleted presets whose retention period has passed. It returns
+// the number of removed presets.
+func (p *Presets) GC() int {
+	now := p.now()
+	var n int
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for name, preset := range p.st {
+		if preset.Deleted && !preset.UpdatedAt.Add(p.retention).After(now) {
+			delete(p.st, name)
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot writes the full internal state into the writer and returns the
+// number of bytes written.
+func (p *Presets) Snapshot(w io.Writer) (int64, error) {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Maintenance garbage collects the presets and snapshots them to the file at
+// the given interval until stopc is closed.
+func (p *Presets) Maintenance(interval time.Duration, snapf string, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	f := func() error {
+		p.GC()
+		if snapf == "" {
+			return nil
+		}
+		f, err := storage.OpenReplace(snapf)
+		if err != nil {
+			return err
+		}
+		if _, err := p.Snapshot(f); err != nil {
+			f.Abort()
+			return err
+		}
+		return f.Close()
+	}
+
+Loop:
+	for {
+		select {
+		case <-stopc:
+			break Loop
+		case <-t.C:
+			if err := f(); err != nil {
+				level.Info(p.logger).Log("msg", "Running maintenance failed", "err", err)
+			}
+		}
+	}
+	// No need for final maintenance if we don't want to snapshot.
+	if snapf == "" {
+		return
+	}
+	if err := f(); err != nil {
+		level.Info(p.logger).Log("msg", "Creating shutdown snapshot failed", "err", err)
+	}
+}
+
+// MarshalBinary serializes all presets.
+func (p *Presets) MarshalBinary() ([]byte, error) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	return p.st.MarshalBinary()
+}
+
+// Merge merges presets received from the cluster with the local state.
+func (p *Presets) Merge(b []byte) error {
+	st, err := decodeState(b)
+	if err != nil {
+		return err
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, preset := range st {
+		p.st.merge(preset)
+	}
+	return nil
+}
+
+// SetBroadcast sets the provided function as the one creating data to be
+// broadcast.
+func (p *Presets) SetBroadcast(f func([]byte)) {
+	p.mtx.Lock()
+	p.broadcast = f
+	p.mtx.Unlock()
+}
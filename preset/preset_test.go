@@ -0,0 +1,157 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preset
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func newTestPresets(t *testing.T, now time.Time) *Presets {
+	p, err := New(Options{Retention: time.Hour})
+	require.NoError(t, err)
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func TestPresetsSetDelete(t *testing.T) {
+	now := utcNow()
+	p := newTestPresets(t, now)
+
+	var broadcasts int
+	p.SetBroadcast(func([]byte) { broadcasts++ })
+
+	for _, invalid := range []*types.FilterPreset{
+		{CreatedBy: "alice"},
+		{Name: "db/critical", CreatedBy: "alice"},
+		{Name: "db", Matchers: types.Matchers{{Name: "0team", Value: "db"}}, CreatedBy: "alice"},
+		{Name: "db", GroupBy: []string{"0team"}, CreatedBy: "alice"},
+		{Name: "db", Receiver: "(", CreatedBy: "alice"},
+		{Name: "db"},
+	} {
+		require.Error(t, p.Set(invalid))
+	}
+
+	require.NoError(t, p.Set(&types.FilterPreset{
+		Name:      "db",
+		Matchers:  types.Matchers{{Name: "team", Value: "db"}},
+		GroupBy:   []string{"cluster"},
+		Receiver:  "db-.*",
+		CreatedBy: "alice",
+	}))
+	require.NoError(t, p.Set(&types.FilterPreset{
+		Name:      "all",
+		CreatedBy: "bob",
+	}))
+	require.Equal(t, []*types.FilterPreset{
+		{Name: "all", CreatedBy: "bob", UpdatedAt: now},
+		{
+			Name:      "db",
+			Matchers:  types.Matchers{{Name: "team", Value: "db"}},
+			GroupBy:   []string{"cluster"},
+			Receiver:  "db-.*",
+			CreatedBy: "alice",
+			UpdatedAt: now,
+		},
+	}, p.List())
+
+	p.now = func() time.Time { return now.Add(time.Second) }
+	require.NoError(t, p.Delete("db"))
+	_, ok := p.Get("db")
+	require.False(t, ok)
+	require.Len(t, p.List(), 1)
+	require.Equal(t, ErrNotFound, p.Delete("db"))
+	require.Equal(t, ErrNotFound, p.Delete("unknown"))
+	require.Equal(t, 3, broadcasts)
+}
+
+func TestPresetsGC(t *testing.T) {
+	now := utcNow()
+	p := newTestPresets(t, now)
+
+	p.st = state{
+		"a": {Name: "a", UpdatedAt: now.Add(-2 * time.Hour)},
+		"b": {Name: "b", UpdatedAt: now.Add(-time.Minute), Deleted: true},
+		"c": {Name: "c", UpdatedAt: now.Add(-2 * time.Hour), Deleted: true},
+	}
+	require.Equal(t, 1, p.GC())
+	require.Len(t, p.st, 2)
+	require.Contains(t, p.st, "a")
+	require.Contains(t, p.st, "b")
+}
+
+func TestPresetsMerge(t *testing.T) {
+	now := utcNow()
+	a := newTestPresets(t, now)
+	b := newTestPresets(t, now.Add(time.Minute))
+
+	require.NoError(t, a.Set(&types.FilterPreset{Name: "db", CreatedBy: "alice"}))
+	require.NoError(t, b.Set(&types.FilterPreset{Name: "db", CreatedBy: "bob"}))
+
+	// The newer preset wins regardless of the merge order.
+	ab, err := a.MarshalBinary()
+	require.NoError(t, err)
+	bb, err := b.MarshalBinary()
+	require.NoError(t, err)
+
+	require.NoError(t, a.Merge(bb))
+	require.NoError(t, b.Merge(ab))
+	for _, presets := range []*Presets{a, b} {
+		preset, ok := presets.Get("db")
+		require.True(t, ok)
+		require.Equal(t, "bob", preset.CreatedBy)
+	}
+
+	// Deletions are replicated as well.
+	b.now = func() time.Time { return now.Add(2 * time.Minute) }
+	require.NoError(t, b.Delete("db"))
+	bb, err = b.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, a.Merge(bb))
+	require.Empty(t, a.List())
+
+	require.Error(t, a.Merge([]byte("garbage")))
+}
+
+func TestPresetsSnapshot(t *testing.T) {
+	now := utcNow()
+	p := newTestPresets(t, now)
+	require.NoError(t, p.Set(&types.FilterPreset{
+		Name:      "db",
+		Matchers:  types.Matchers{{Name: "team", Value: "db"}},
+		CreatedBy: "alice",
+	}))
+
+	f, err := ioutil.TempFile("", "snapshot")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	var buf bytes.Buffer
+	_, err = p.Snapshot(&buf)
+	require.NoError(t, err)
+	_, err = f.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	p2, err := New(Options{SnapshotFile: f.Name()})
+	require.NoError(t, err)
+	require.Equal(t, p.st, p2.st)
+}
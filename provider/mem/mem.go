@@ -14,6 +14,7 @@
 package mem
 
 import (
+	"os"
 	"sync"
 	"time"
 
@@ -32,6 +33,10 @@ type Alerts struct {
 
 	listeners map[int]chan *types.Alert
 	next      int
+
+	// The snapshot file and write-ahead log if the alerts are persisted.
+	snapf string
+	wal   *os.File
 }
 
 // NewAlerts returns a new alert provider.
@@ -60,9 +65,9 @@ func (a *Alerts) runGC() {
 		a.mtx.Lock()
 
 		for fp, alert := range a.alerts {
-			// As we don't persist alerts, we no longer consider them after
-			// they are resolved. Alerts waiting for resolved notifications are
-			// held in memory in aggregation groups redundantly.
+			// We no longer consider alerts after they are resolved. Alerts
+			// waiting for resolved notifications are held in memory in
+			// aggregation groups redundantly.
 			if alert.EndsAt.Before(time.Now()) {
 				delete(a.alerts, fp)
 				a.marker.Delete(fp)
@@ -167,15 +172,25 @@ func (a *Alerts) Get(fp model.Fingerprint) (*types.Alert, error) {
 	return alert, nil
 }
 
-// Put adds the given alert to the set.
+// Put adds the given alert to the set. If the alerts are persisted, they are
+// only added once they are written to the write-ahead log.
 func (a *Alerts) Put(alerts ...*types.Alert) error {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
+	var (
+		stored = make([]*types.Alert, 0, len(alerts))
+		fps    = make([]model.Fingerprint, 0, len(alerts))
+		merged = make(map[model.Fingerprint]*types.Alert, len(alerts))
+	)
 	for _, alert := range alerts {
 		fp := alert.Fingerprint()
 
-		if old, ok := a.alerts[fp]; ok {
+		old, ok := merged[fp]
+		if !ok {
+			old, ok = a.alerts[fp]
+		}
+		if ok {
 			// Merge alerts if there is an overlap in activity range.
 			if (alert.EndsAt.After(old.StartsAt) && alert.EndsAt.Before(old.EndsAt)) ||
 				(alert.StartsAt.After(old.StartsAt) && alert.StartsAt.Before(old.EndsAt)) {
@@ -183,12 +198,54 @@ func (a *Alerts) Put(alerts ...*types.Alert) error {
 			}
 		}
 
-		a.alerts[fp] = alert
+		merged[fp] = alert
+		stored = append(stored, alert)
+		fps = append(fps, fp)
+	}
+
+	if err := a.logAlerts(stored...); err != nil {
+		return err
+	}
+	for i, alert := range stored {
+		a.alerts[fps[i]] = alert
 
 		for _, ch := range a.listeners {
 			ch <- alert
 		}
 	}
-
 	return nil
 }
+
+// Resolve marks the firing alerts with the given fingerprints resolved at
+// the given time. Unlike putting resolved alerts, it overrides end times
+// explicitly set by the source of the alerts. Unknown and resolved alerts
+// are skipped.
+func (a *Alerts) Resolve(at time.Time, fps ...model.Fingerprint) ([]*types.Alert, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var resolved []*types.Alert
+	for _, fp := range fps {
+		old, ok := a.alerts[fp]
+		if !ok || !old.EndsAt.After(at) {
+			continue
+		}
+		alert := *old
+		alert.EndsAt = at
+		alert.UpdatedAt = at
+		alert.Timeout = false
+
+		resolved = append(resolved, &alert)
+	}
+
+	if err := a.logAlerts(resolved...); err != nil {
+		return nil, err
+	}
+	for _, alert := range resolved {
+		a.alerts[alert.Fingerprint()] = alert
+		for _, ch := range a.listeners {
+			ch <- alert
+		}
+	}
+	return resolved, nil
+}
@@ -15,12 +15,16 @@ package mem
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
 	"sync"
 
+	"github.com/go-kit/kit/log"
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/prometheus/alertmanager/provider"
 	"github.com/prometheus/alertmanager/types"
@@ -97,6 +101,54 @@ func TestAlertsPut(t *testing.T) {
 	}
 }
 
+func TestAlertsResolve(t *testing.T) {
+	alerts, err := NewAlerts(types.NewMarker(), 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	firing := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"bar": "firing"},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(time.Hour),
+		},
+		UpdatedAt: now.Add(-time.Minute),
+	}
+	resolved := &types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"bar": "resolved"},
+			StartsAt: now.Add(-time.Hour),
+			EndsAt:   now.Add(-time.Minute),
+		},
+		UpdatedAt: now.Add(-time.Minute),
+	}
+	if err := alerts.Put(firing, resolved); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	res, err := alerts.Resolve(now, firing.Fingerprint(), resolved.Fingerprint(), model.Fingerprint(1))
+	if err != nil {
+		t.Fatalf("Resolve failed: %s", err)
+	}
+	if len(res) != 1 || res[0].Fingerprint() != firing.Fingerprint() {
+		t.Fatalf("Unexpected resolved alerts: %v", res)
+	}
+
+	a, err := alerts.Get(firing.Fingerprint())
+	if err != nil {
+		t.Fatalf("retrieval error: %s", err)
+	}
+	// The end time set by the source is overridden.
+	if !a.EndsAt.Equal(now) || !a.UpdatedAt.Equal(now) {
+		t.Errorf("Unexpected end time %v of resolved alert", a.EndsAt)
+	}
+	if a, _ := alerts.Get(resolved.Fingerprint()); !a.EndsAt.Equal(resolved.EndsAt) {
+		t.Errorf("Unexpected end time %v of previously resolved alert", a.EndsAt)
+	}
+}
+
 func TestAlertsSubscribe(t *testing.T) {
 	marker := types.NewMarker()
 	alerts, err := NewAlerts(marker, 30*time.Minute)
@@ -285,3 +337,118 @@ func alertsEqual(a1, a2 *types.Alert) bool {
 	}
 	return a1.Timeout == a2.Timeout
 }
+
+func TestAlertsRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		snapf = filepath.Join(dir, "alerts")
+		walf  = filepath.Join(dir, "alerts.wal")
+		stopc = make(chan struct{})
+		done  = make(chan struct{})
+	)
+
+	marker := types.NewMarker()
+	alerts, err := NewAlerts(marker, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alerts.Restore(snapf, walf); err != nil {
+		t.Fatalf("Restoring empty alerts failed: %s", err)
+	}
+	go func() {
+		alerts.Maintenance(time.Hour, stopc, log.NewNopLogger())
+		close(done)
+	}()
+
+	// alert1 ends up in the shutdown snapshot.
+	if err := alerts.Put(alert1); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+	close(stopc)
+	<-done
+	alerts.Close()
+
+	// alert2 is only in the write-ahead log, which ends in a partial entry
+	// as if Alertmanager crashed while writing it.
+	alerts, err = NewAlerts(marker, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alerts.Restore(snapf, walf); err != nil {
+		t.Fatalf("Restoring alerts failed: %s", err)
+	}
+	if err := alerts.Put(alert2); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+	f, err := os.OpenFile(walf, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"labels":{"bar":`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	alerts.Close()
+
+	alerts, err = NewAlerts(marker, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alerts.Close()
+	if err := alerts.Restore(snapf, walf); err != nil {
+		t.Fatalf("Restoring alerts failed: %s", err)
+	}
+
+	for _, a := range []*types.Alert{alert1, alert2} {
+		res, err := alerts.Get(a.Fingerprint())
+		if err != nil {
+			t.Fatalf("Retrieval of restored alert %s failed: %s", a, err)
+		}
+		if !res.StartsAt.Equal(a.StartsAt) || !res.UpdatedAt.Equal(a.UpdatedAt) || !reflect.DeepEqual(res.Annotations, a.Annotations) {
+			t.Errorf("Unexpected restored alert %v, expected %v", res, a)
+		}
+	}
+	if n := len(alerts.alerts); n != 2 {
+		t.Errorf("Expected 2 restored alerts, got %d", n)
+	}
+
+	// Restoring writes a new snapshot and truncates the write-ahead log.
+	fi, err := os.Stat(walf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != 0 {
+		t.Errorf("Expected empty write-ahead log, got %d bytes", fi.Size())
+	}
+}
+
+func TestAlertsPutWALFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	alerts, err := NewAlerts(types.NewMarker(), 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alerts.Close()
+	if err := alerts.Restore(filepath.Join(dir, "alerts"), filepath.Join(dir, "alerts.wal")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Alerts that cannot be written to the write-ahead log are not stored.
+	alerts.wal.Close()
+	if err := alerts.Put(alert1); err == nil {
+		t.Fatalf("Expected insert to fail")
+	}
+	if _, err := alerts.Get(alert1.Fingerprint()); err != provider.ErrNotFound {
+		t.Errorf("Expected alert not to be stored, got %v", err)
+	}
+}
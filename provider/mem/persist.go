@@ -0,0 +1,158 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/prometheus/alertmanager/storage"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Restore loads the alerts from the snapshot file and replays the write-ahead
+// log on top of them. Afterwards, a new snapshot is written and all alerts put
+// into the provider are appended to the write-ahead log until the next
+// snapshot is taken by Maintenance.
+func (a *Alerts) Restore(snapf, walf string) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if err := a.readAlerts(snapf); err != nil {
+		return err
+	}
+	// The write-ahead log may end in a partially written entry, which is
+	// dropped by writing a new snapshot and truncating the log.
+	if err := a.readAlerts(walf); err != nil {
+		return err
+	}
+
+	wal, err := os.OpenFile(walf, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	a.snapf, a.wal = snapf, wal
+
+	return a.snapshot()
+}
+
+// readAlerts adds the alerts in the file to the provider. Reading stops
+// silently at the first entry that cannot be decoded.
+func (a *Alerts) readAlerts(filename string) error {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var alert types.Alert
+		if err := dec.Decode(&alert); err != nil {
+			return nil
+		}
+		a.alerts[alert.Fingerprint()] = &alert
+	}
+}
+
+// logAlerts appends the alerts to the write-ahead log and syncs it to disk.
+func (a *Alerts) logAlerts(alerts ...*types.Alert) error {
+	if a.wal == nil || len(alerts) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, alert := range alerts {
+		if err := enc.Encode(alert); err != nil {
+			return err
+		}
+	}
+	if _, err := a.wal.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return a.wal.Sync()
+}
+
+// snapshot writes all alerts to the snapshot file and truncates the
+// write-ahead log. The caller must hold the lock.
+func (a *Alerts) snapshot() error {
+	f, err := storage.OpenReplace(a.snapf)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, alert := range a.alerts {
+		if err := enc.Encode(alert); err != nil {
+			f.Abort()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return a.wal.Truncate(0)
+}
+
+// Maintenance snapshots the alerts at the given interval until stopc is
+// closed. It takes a final snapshot and closes the write-ahead log on
+// shutdown. It returns immediately if the alerts were not restored.
+func (a *Alerts) Maintenance(interval time.Duration, stopc <-chan struct{}, l log.Logger) {
+	a.mtx.RLock()
+	persisted := a.wal != nil
+	a.mtx.RUnlock()
+	if !persisted {
+		return
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	f := func() error {
+		a.mtx.Lock()
+		defer a.mtx.Unlock()
+
+		return a.snapshot()
+	}
+
+Loop:
+	for {
+		select {
+		case <-stopc:
+			break Loop
+		case <-t.C:
+			if err := f(); err != nil {
+				level.Info(l).Log("msg", "Creating alerts snapshot failed", "err", err)
+			}
+		}
+	}
+	if err := f(); err != nil {
+		level.Info(l).Log("msg", "Creating shutdown snapshot failed", "err", err)
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if err := a.wal.Close(); err != nil {
+		level.Info(l).Log("msg", "Closing alerts write-ahead log failed", "err", err)
+	}
+	a.wal = nil
+}
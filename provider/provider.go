@@ -15,6 +15,7 @@ package provider
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/prometheus/common/model"
 
@@ -85,4 +86,8 @@ type Alerts interface {
 	Get(model.Fingerprint) (*types.Alert, error)
 	// Put adds the given alert to the set.
 	Put(...*types.Alert) error
+	// Resolve marks the firing alerts with the given fingerprints resolved
+	// at the given time, regardless of the end time set by their source.
+	// It returns the alerts it resolved.
+	Resolve(at time.Time, fps ...model.Fingerprint) ([]*types.Alert, error)
 }
@@ -0,0 +1,205 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package silence
+
+import (
+	"regexp/syntax"
+
+	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/common/model"
+)
+
+// indexKey is a label value, or the prefix of label values, that a silence
+// requires alerts to have.
+type indexKey struct {
+	name   string
+	value  string
+	prefix bool
+}
+
+type idSet map[string]struct{}
+
+// index is an inverted index of silences by label values, so that the
+// silences possibly matching a label set are found without matching every
+// silence. A silence is indexed by one of its matchers that only matches
+// label values it can enumerate: an equality matcher, a regex matcher
+// listing literal alternatives or a regex matcher with a literal prefix.
+// Silences without such a matcher are candidates for every label set.
+type index struct {
+	equal     map[string]map[string]idSet
+	prefix    map[string]map[string]idSet
+	unindexed idSet
+	// keys holds the keys each silence is indexed by.
+	keys map[string][]indexKey
+}
+
+func newIndex() *index {
+	return &index{
+		equal:     map[string]map[string]idSet{},
+		prefix:    map[string]map[string]idSet{},
+		unindexed: idSet{},
+		keys:      map[string][]indexKey{},
+	}
+}
+
+// set indexes the silence, replacing a previous version with the same ID.
+func (ix *index) set(sil *pb.Silence) {
+	if ix == nil {
+		return
+	}
+	ix.delete(sil.Id)
+
+	keys := indexKeys(sil)
+	ix.keys[sil.Id] = keys
+	if len(keys) == 0 {
+		ix.unindexed[sil.Id] = struct{}{}
+		return
+	}
+	for _, k := range keys {
+		m := ix.equal
+		if k.prefix {
+			m = ix.prefix
+		}
+		values, ok := m[k.name]
+		if !ok {
+			values = map[string]idSet{}
+			m[k.name] = values
+		}
+		ids, ok := values[k.value]
+		if !ok {
+			ids = idSet{}
+			values[k.value] = ids
+		}
+		ids[sil.Id] = struct{}{}
+	}
+}
+
+// delete removes the silence with the ID from the index.
+func (ix *index) delete(id string) {
+	if ix == nil {
+		return
+	}
+	keys, ok := ix.keys[id]
+	if !ok {
+		return
+	}
+	delete(ix.keys, id)
+	delete(ix.unindexed, id)
+	for _, k := range keys {
+		m := ix.equal
+		if k.prefix {
+			m = ix.prefix
+		}
+		values := m[k.name]
+		delete(values[k.value], id)
+		if len(values[k.value]) == 0 {
+			delete(values, k.value)
+		}
+		if len(values) == 0 {
+			delete(m, k.name)
+		}
+	}
+}
+
+// candidates returns the IDs of the silences that may match the label set.
+// All other silences do not match it.
+func (ix *index) candidates(lset model.LabelSet) idSet {
+	res := make(idSet, len(ix.unindexed))
+	add := func(ids idSet) {
+		for id := range ids {
+			res[id] = struct{}{}
+		}
+	}
+	add(ix.unindexed)
+	for name, value := range lset {
+		v := string(value)
+		if values, ok := ix.equal[string(name)]; ok {
+			add(values[v])
+		}
+		if prefixes, ok := ix.prefix[string(name)]; ok {
+			for i := 1; i <= len(v); i++ {
+				add(prefixes[v[:i]])
+			}
+		}
+	}
+	return res
+}
+
+// indexKeys returns the keys to index the silence by, which are nil if it
+// has no matcher that only matches enumerable label values. An equality
+// matcher is preferred, otherwise the regex matcher with the fewest keys.
+func indexKeys(sil *pb.Silence) []indexKey {
+	var best []indexKey
+	for _, m := range sil.Matchers {
+		switch m.Type {
+		case pb.Matcher_EQUAL:
+			// Matching the empty value also matches absent labels.
+			if m.Pattern != "" {
+				return []indexKey{{name: m.Name, value: m.Pattern}}
+			}
+		case pb.Matcher_REGEXP:
+			if keys := regexKeys(m.Name, m.Pattern); keys != nil && (best == nil || len(keys) < len(best)) {
+				best = keys
+			}
+		}
+	}
+	return best
+}
+
+// regexKeys returns the keys of a regex matcher, which matches whole label
+// values. They are nil if the regex may match values that the keys do not
+// cover, including the empty value.
+func regexKeys(name, pattern string) []indexKey {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	re = re.Simplify()
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+
+	switch re.Op {
+	case syntax.OpLiteral:
+		if s, ok := literal(re); ok {
+			return []indexKey{{name: name, value: s}}
+		}
+	case syntax.OpAlternate:
+		keys := make([]indexKey, 0, len(re.Sub))
+		for _, sub := range re.Sub {
+			s, ok := literal(sub)
+			if !ok {
+				return nil
+			}
+			keys = append(keys, indexKey{name: name, value: s})
+		}
+		return keys
+	case syntax.OpConcat:
+		if s, ok := literal(re.Sub[0]); ok {
+			return []indexKey{{name: name, value: s, prefix: true}}
+		}
+	}
+	return nil
+}
+
+// literal returns the string matched by a case sensitive literal regex.
+func literal(re *syntax.Regexp) (string, bool) {
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	if re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 || len(re.Rune) == 0 {
+		return "", false
+	}
+	return string(re.Rune), true
+}
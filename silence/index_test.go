@@ -0,0 +1,221 @@
+// Copyright 2016 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package silence
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexKeys(t *testing.T) {
+	cases := []struct {
+		matchers []*pb.Matcher
+		keys     []indexKey
+	}{
+		{
+			matchers: []*pb.Matcher{
+				{Name: "path", Pattern: "/user/.+", Type: pb.Matcher_REGEXP},
+				{Name: "job", Pattern: "test", Type: pb.Matcher_EQUAL},
+			},
+			keys: []indexKey{{name: "job", value: "test"}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "path", Pattern: "/user/.+", Type: pb.Matcher_REGEXP}},
+			keys:     []indexKey{{name: "path", value: "/user/", prefix: true}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "job", Pattern: "(api|web)", Type: pb.Matcher_REGEXP}},
+			keys:     []indexKey{{name: "job", value: "api"}, {name: "job", value: "web"}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "instance", Pattern: "db1|db2", Type: pb.Matcher_REGEXP}},
+			keys:     []indexKey{{name: "instance", value: "db", prefix: true}},
+		},
+		{
+			matchers: []*pb.Matcher{
+				{Name: "job", Pattern: "a|b|c", Type: pb.Matcher_REGEXP},
+				{Name: "env", Pattern: "prod", Type: pb.Matcher_REGEXP},
+			},
+			keys: []indexKey{{name: "env", value: "prod"}},
+		},
+		{
+			// Matches absent labels.
+			matchers: []*pb.Matcher{{Name: "job", Pattern: "", Type: pb.Matcher_EQUAL}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "job", Pattern: "api|", Type: pb.Matcher_REGEXP}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "job", Pattern: "(?i)api", Type: pb.Matcher_REGEXP}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "job", Pattern: ".*api", Type: pb.Matcher_REGEXP}},
+		},
+		{
+			matchers: []*pb.Matcher{{Name: "job", Pattern: "api", Type: pb.Matcher_NOT_EQUAL}},
+		},
+	}
+	for i, c := range cases {
+		require.Equal(t, c.keys, indexKeys(&pb.Silence{Matchers: c.matchers}), "unexpected keys for case %d", i)
+	}
+}
+
+func TestIndexCandidates(t *testing.T) {
+	ix := newIndex()
+	ix.set(&pb.Silence{Id: "equal", Matchers: []*pb.Matcher{{Name: "job", Pattern: "api", Type: pb.Matcher_EQUAL}}})
+	ix.set(&pb.Silence{Id: "prefix", Matchers: []*pb.Matcher{{Name: "instance", Pattern: "db.*", Type: pb.Matcher_REGEXP}}})
+	ix.set(&pb.Silence{Id: "unindexed", Matchers: []*pb.Matcher{{Name: "job", Pattern: ".+", Type: pb.Matcher_REGEXP}}})
+
+	candidates := func(lset model.LabelSet) []string {
+		var ids []string
+		for id := range ix.candidates(lset) {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	require.Equal(t, []string{"equal", "prefix", "unindexed"}, candidates(model.LabelSet{"job": "api", "instance": "db1"}))
+	require.Equal(t, []string{"unindexed"}, candidates(model.LabelSet{"job": "web", "instance": "web1"}))
+
+	// Updating a silence replaces its keys.
+	ix.set(&pb.Silence{Id: "equal", Matchers: []*pb.Matcher{{Name: "job", Pattern: "web", Type: pb.Matcher_EQUAL}}})
+	require.Equal(t, []string{"equal", "unindexed"}, candidates(model.LabelSet{"job": "web"}))
+	require.Equal(t, []string{"unindexed"}, candidates(model.LabelSet{"job": "api"}))
+
+	ix.delete("equal")
+	ix.delete("prefix")
+	ix.delete("unindexed")
+	require.Empty(t, candidates(model.LabelSet{"job": "web", "instance": "db1"}))
+	require.Empty(t, ix.equal)
+	require.Empty(t, ix.prefix)
+	require.Empty(t, ix.keys)
+}
+
+// randomSilences adds n active silences with random matchers of the kinds
+// the index distinguishes. Few of them cannot be indexed.
+func randomSilences(t testing.TB, s *Silences, n int, r *rand.Rand) {
+	now := s.now()
+	for i := 0; i < n; i++ {
+		svc := fmt.Sprintf("service-%d", r.Intn(n))
+		var m []*pb.Matcher
+		switch k := r.Intn(20); {
+		case k < 6:
+			m = []*pb.Matcher{{Name: "service", Pattern: svc, Type: pb.Matcher_EQUAL}}
+		case k < 12:
+			m = []*pb.Matcher{{Name: "service", Pattern: svc + "|" + svc + "-canary", Type: pb.Matcher_REGEXP}}
+		case k < 19:
+			m = []*pb.Matcher{
+				{Name: "instance", Pattern: svc + "-.*", Type: pb.Matcher_REGEXP},
+				{Name: "severity", Pattern: "critical", Type: pb.Matcher_NOT_EQUAL},
+			}
+		default:
+			m = []*pb.Matcher{{Name: "service", Pattern: fmt.Sprintf(".*-%d", r.Intn(n)), Type: pb.Matcher_REGEXP}}
+		}
+		if _, err := s.Set(&pb.Silence{Matchers: m, StartsAt: now, EndsAt: now.Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func randomLabels(n int, r *rand.Rand) model.LabelSet {
+	svc := fmt.Sprintf("service-%d", r.Intn(n))
+	return model.LabelSet{
+		"service":  model.LabelValue(svc),
+		"instance": model.LabelValue(fmt.Sprintf("%s-%d", svc, r.Intn(3))),
+		"severity": "warning",
+	}
+}
+
+// scanQuery queries the silences matching the labels without the index.
+func scanQuery(s *Silences, lset model.LabelSet) ([]*pb.Silence, error) {
+	q := &query{}
+	QState(types.SilenceStateActive)(q)
+	QMatches(lset)(q)
+	q.labels = nil
+	return s.query(q, s.now())
+}
+
+func silenceIDs(sils []*pb.Silence) []string {
+	ids := make([]string, 0, len(sils))
+	for _, s := range sils {
+		ids = append(ids, s.Id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestQueryIndexConsistency(t *testing.T) {
+	s, err := New(Options{})
+	require.NoError(t, err)
+	r := rand.New(rand.NewSource(1))
+	randomSilences(t, s, 500, r)
+
+	// Expire some silences and replace others with new matchers.
+	sils, err := s.Query()
+	require.NoError(t, err)
+	for _, sil := range sils[:100] {
+		require.NoError(t, s.Expire(sil.Id))
+	}
+	for _, sil := range sils[100:200] {
+		sil.Matchers = []*pb.Matcher{{Name: "service", Pattern: "service-1", Type: pb.Matcher_EQUAL}}
+		require.NoError(t, s.Update(sil))
+	}
+
+	for i := 0; i < 200; i++ {
+		lset := randomLabels(500, r)
+		indexed, err := s.Query(QState(types.SilenceStateActive), QMatches(lset))
+		require.NoError(t, err)
+		scanned, err := scanQuery(s, lset)
+		require.NoError(t, err)
+		require.Equal(t, silenceIDs(scanned), silenceIDs(indexed), "different silences for %v", lset)
+	}
+}
+
+func BenchmarkQueryMatches(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		s, err := New(Options{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		r := rand.New(rand.NewSource(1))
+		randomSilences(b, s, n, r)
+		lsets := make([]model.LabelSet, 100)
+		for i := range lsets {
+			lsets[i] = randomLabels(n, r)
+		}
+
+		b.Run(fmt.Sprintf("index/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Query(QState(types.SilenceStateActive), QMatches(lsets[i%len(lsets)])); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := scanQuery(s, lsets[i%len(lsets)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
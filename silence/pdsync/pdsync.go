@@ -0,0 +1,332 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pdsync synchronizes PagerDuty maintenance windows into silences.
+package pdsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/silence"
+	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// CreatedBy is the author of all silences managed by the Syncer.
+const CreatedBy = "pagerduty-maintenance"
+
+// commentRE extracts the maintenance window and service ID from the comment
+// of a managed silence.
+var commentRE = regexp.MustCompile(`^\[pagerduty:([^/\]]+)/([^\]]+)\]`)
+
+type metrics struct {
+	syncsTotal        prometheus.Counter
+	syncFailuresTotal prometheus.Counter
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	m := &metrics{}
+
+	m.syncsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_pagerduty_maintenance_syncs_total",
+		Help: "How many synchronizations of PagerDuty maintenance windows were attempted.",
+	})
+	m.syncFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_pagerduty_maintenance_sync_failures_total",
+		Help: "How many synchronizations of PagerDuty maintenance windows failed.",
+	})
+
+	if r != nil {
+		r.MustRegister(m.syncsTotal, m.syncFailuresTotal)
+	}
+	return m
+}
+
+// Syncer periodically polls PagerDuty for maintenance windows of the mapped
+// services and creates, updates and expires silences accordingly.
+type Syncer struct {
+	silences *silence.Silences
+	leader   func() bool
+	logger   log.Logger
+	metrics  *metrics
+
+	mtx  sync.RWMutex
+	conf *config.PagerdutyMaintenanceConfig
+}
+
+// New returns a new Syncer. Synchronization only takes place while leader
+// returns true, so that a single cluster member manages the silences.
+func New(s *silence.Silences, leader func() bool, r prometheus.Registerer, l log.Logger) *Syncer {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+	if leader == nil {
+		leader = func() bool { return true }
+	}
+	return &Syncer{
+		silences: s,
+		leader:   leader,
+		logger:   l,
+		metrics:  newMetrics(r),
+	}
+}
+
+// ApplyConfig sets the configuration of the Syncer. A nil configuration
+// disables synchronization without touching existing silences.
+func (s *Syncer) ApplyConfig(c *config.PagerdutyMaintenanceConfig) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.conf = c
+}
+
+func (s *Syncer) config() *config.PagerdutyMaintenanceConfig {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.conf
+}
+
+// Run synchronizes maintenance windows at the configured interval until
+// stopc is closed.
+func (s *Syncer) Run(stopc <-chan struct{}) {
+	for {
+		interval := time.Minute
+
+		if c := s.config(); c != nil {
+			interval = time.Duration(c.PollInterval)
+
+			if s.leader() {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := s.Sync(ctx, c); err != nil {
+					level.Error(s.logger).Log("msg", "Synchronizing PagerDuty maintenance windows failed", "err", err)
+				}
+				cancel()
+			}
+		}
+
+		select {
+		case <-stopc:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+type maintenanceWindow struct {
+	ID          string             `json:"id"`
+	StartTime   time.Time          `json:"start_time"`
+	EndTime     time.Time          `json:"end_time"`
+	Description string             `json:"description"`
+	Services    []serviceReference `json:"services"`
+}
+
+type serviceReference struct {
+	ID string `json:"id"`
+}
+
+type maintenanceWindowsResponse struct {
+	MaintenanceWindows []maintenanceWindow `json:"maintenance_windows"`
+	More               bool                `json:"more"`
+}
+
+// Sync performs a single synchronization with the given configuration.
+func (s *Syncer) Sync(ctx context.Context, c *config.PagerdutyMaintenanceConfig) (err error) {
+	s.metrics.syncsTotal.Inc()
+	defer func() {
+		if err != nil {
+			s.metrics.syncFailuresTotal.Inc()
+		}
+	}()
+
+	windows, err := fetchWindows(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	services := make(map[string]*config.PagerdutyMaintenanceService, len(c.Services))
+	for _, svc := range c.Services {
+		services[svc.ServiceID] = svc
+	}
+
+	desired := map[string]*pb.Silence{}
+	for _, w := range windows {
+		for _, ws := range w.Services {
+			svc, ok := services[ws.ID]
+			if !ok {
+				continue
+			}
+			desired[key(w.ID, ws.ID)] = &pb.Silence{
+				Matchers:  matchers(svc),
+				StartsAt:  w.StartTime,
+				EndsAt:    w.EndTime,
+				CreatedBy: CreatedBy,
+				Comment:   strings.TrimSpace(fmt.Sprintf("[pagerduty:%s/%s] %s", w.ID, ws.ID, w.Description)),
+			}
+		}
+	}
+
+	existing, err := s.silences.Query(silence.QState(types.SilenceStateActive, types.SilenceStatePending))
+	if err != nil {
+		return errors.Wrap(err, "query silences")
+	}
+
+	for _, sil := range existing {
+		if sil.CreatedBy != CreatedBy {
+			continue
+		}
+		m := commentRE.FindStringSubmatch(sil.Comment)
+		if m == nil {
+			continue
+		}
+		k := key(m[1], m[2])
+
+		want, ok := desired[k]
+		if !ok {
+			level.Info(s.logger).Log("msg", "Expiring silence of ended maintenance window", "silence", sil.Id, "window", m[1], "service", m[2])
+			if err := s.silences.Expire(sil.Id); err != nil {
+				return errors.Wrapf(err, "expire silence %s", sil.Id)
+			}
+			continue
+		}
+		delete(desired, k)
+
+		// Silences cannot start in the past, so the start of active
+		// silences differs from the window start and must be retained.
+		if now := time.Now(); !sil.StartsAt.After(now) && !want.StartsAt.After(now) {
+			want.StartsAt = sil.StartsAt
+		}
+		if reflect.DeepEqual(sil.Matchers, want.Matchers) &&
+			sil.StartsAt.Equal(want.StartsAt) &&
+			sil.EndsAt.Equal(want.EndsAt) &&
+			sil.Comment == want.Comment {
+			continue
+		}
+		want.Id = sil.Id
+		if _, err := s.silences.Set(want); err != nil {
+			return errors.Wrapf(err, "update silence %s", sil.Id)
+		}
+	}
+
+	for k, sil := range desired {
+		if !sil.EndsAt.After(time.Now()) {
+			continue
+		}
+		id, err := s.silences.Set(sil)
+		if err != nil {
+			return errors.Wrapf(err, "create silence for %s", k)
+		}
+		level.Info(s.logger).Log("msg", "Created silence for maintenance window", "silence", id, "window", k)
+	}
+	return nil
+}
+
+func key(windowID, serviceID string) string {
+	return windowID + "/" + serviceID
+}
+
+// matchers returns the silence matchers for the service, sorted by name.
+func matchers(svc *config.PagerdutyMaintenanceService) []*pb.Matcher {
+	var ms []*pb.Matcher
+	for name, value := range svc.Match {
+		ms = append(ms, &pb.Matcher{
+			Type:    pb.Matcher_EQUAL,
+			Name:    name,
+			Pattern: value,
+		})
+	}
+	for name, re := range svc.MatchRE {
+		// Drop the anchors added when parsing the configuration as silence
+		// matchers are anchored anyway.
+		pattern := strings.TrimSuffix(strings.TrimPrefix(re.String(), "^(?:"), ")$")
+		ms = append(ms, &pb.Matcher{
+			Type:    pb.Matcher_REGEXP,
+			Name:    name,
+			Pattern: pattern,
+		})
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].Name != ms[j].Name {
+			return ms[i].Name < ms[j].Name
+		}
+		return ms[i].Type < ms[j].Type
+	})
+	return ms
+}
+
+// fetchWindows returns all ongoing and future maintenance windows of the
+// configured services.
+func fetchWindows(ctx context.Context, c *config.PagerdutyMaintenanceConfig) ([]maintenanceWindow, error) {
+	client, err := config.NewHTTPClient(c.HTTPConfig, c.CryptoPolicy, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []maintenanceWindow
+	for offset := 0; ; {
+		params := url.Values{}
+		for _, svc := range c.Services {
+			params.Add("service_ids[]", svc.ServiceID)
+		}
+		params.Set("filter", "open")
+		params.Set("limit", "100")
+		params.Set("offset", strconv.Itoa(offset))
+
+		req, err := http.NewRequest("GET", c.APIURL+"maintenance_windows?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+		req.Header.Set("Authorization", fmt.Sprintf("Token token=%s", c.APIKey))
+
+		resp, err := ctxhttp.Do(ctx, client, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 != 2 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unexpected status code %v from PagerDuty", resp.StatusCode)
+		}
+
+		var page maintenanceWindowsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "decode maintenance windows")
+		}
+
+		windows = append(windows, page.MaintenanceWindows...)
+		if !page.More || len(page.MaintenanceWindows) == 0 {
+			return windows, nil
+		}
+		offset += len(page.MaintenanceWindows)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pdsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/silence"
+	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+)
+
+func TestSync(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		windows []maintenanceWindow
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/maintenance_windows", r.URL.Path)
+		require.Equal(t, "Token token=secret", r.Header.Get("Authorization"))
+		require.Equal(t, []string{"PSVC1", "PSVC2"}, r.URL.Query()["service_ids[]"])
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		json.NewEncoder(w).Encode(maintenanceWindowsResponse{MaintenanceWindows: windows})
+	}))
+	defer srv.Close()
+
+	var c config.PagerdutyMaintenanceConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+api_key: secret
+api_url: `+srv.URL+`
+services:
+- service_id: PSVC1
+  match:
+    service: db
+  match_re:
+    instance: db-.*
+- service_id: PSVC2
+  match:
+    service: web
+`), &c))
+	c.HTTPConfig = &commoncfg.HTTPClientConfig{}
+
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	s := New(silences, nil, nil, nil)
+
+	now := time.Now()
+	window := maintenanceWindow{
+		ID:          "PW1",
+		StartTime:   now.Add(-time.Hour),
+		EndTime:     now.Add(time.Hour),
+		Description: "database upgrade",
+		Services:    []serviceReference{{ID: "PSVC1"}, {ID: "PUNMAPPED"}},
+	}
+	windows = []maintenanceWindow{window}
+
+	require.NoError(t, s.Sync(context.Background(), &c))
+
+	sils, err := silences.Query(silence.QState(types.SilenceStateActive))
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+
+	sil := sils[0]
+	require.Equal(t, CreatedBy, sil.CreatedBy)
+	require.Equal(t, "[pagerduty:PW1/PSVC1] database upgrade", sil.Comment)
+	require.Equal(t, []*pb.Matcher{
+		{Type: pb.Matcher_REGEXP, Name: "instance", Pattern: "db-.*"},
+		{Type: pb.Matcher_EQUAL, Name: "service", Pattern: "db"},
+	}, sil.Matchers)
+	require.True(t, sil.EndsAt.Equal(window.EndTime))
+
+	// Syncing again must not modify the silence.
+	require.NoError(t, s.Sync(context.Background(), &c))
+	sils, err = silences.Query(silence.QState(types.SilenceStateActive))
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	require.Equal(t, sil.Id, sils[0].Id)
+	require.Equal(t, sil.UpdatedAt, sils[0].UpdatedAt)
+
+	// An extended window extends the silence.
+	mtx.Lock()
+	windows[0].EndTime = now.Add(2 * time.Hour)
+	mtx.Unlock()
+
+	require.NoError(t, s.Sync(context.Background(), &c))
+	sils, err = silences.Query(silence.QState(types.SilenceStateActive))
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	require.Equal(t, sil.Id, sils[0].Id)
+	require.True(t, sils[0].EndsAt.Equal(now.Add(2*time.Hour)))
+
+	// Silences of removed windows are expired.
+	mtx.Lock()
+	windows = nil
+	mtx.Unlock()
+
+	require.NoError(t, s.Sync(context.Background(), &c))
+	sils, err = silences.Query(silence.QState(types.SilenceStateActive, types.SilenceStatePending))
+	require.NoError(t, err)
+	require.Len(t, sils, 0)
+}
+
+func TestSyncAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+
+	c := config.DefaultPagerdutyMaintenanceConfig
+	c.APIURL = srv.URL + "/"
+	c.HTTPConfig = &commoncfg.HTTPClientConfig{}
+	c.Services = []*config.PagerdutyMaintenanceService{{ServiceID: "PSVC1"}}
+
+	require.Error(t, New(silences, nil, nil, nil).Sync(context.Background(), &c))
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -35,6 +36,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/storage"
 )
 
 // ErrNotFound is returned if a silence was not found.
@@ -43,6 +47,9 @@ var ErrNotFound = fmt.Errorf("not found")
 // ErrInvalidState is returned if the state isn't valid.
 var ErrInvalidState = fmt.Errorf("invalid state")
 
+// storageTimeout bounds the requests to the storage backend.
+const storageTimeout = time.Minute
+
 func utcNow() time.Time {
 	return time.Now().UTC()
 }
@@ -77,6 +84,11 @@ func (c matcherCache) add(s *pb.Silence) (types.Matchers, error) {
 			mt.IsRegex = false
 		case pb.Matcher_REGEXP:
 			mt.IsRegex = true
+		case pb.Matcher_NOT_EQUAL:
+			mt.IsNegative = true
+		case pb.Matcher_NOT_REGEXP:
+			mt.IsRegex = true
+			mt.IsNegative = true
 		}
 		err := mt.Init()
 		if err != nil {
@@ -98,10 +110,16 @@ type Silences struct {
 	now       func() time.Time
 	retention time.Duration
 
+	storage    storage.Backend
+	storageKey string
+	marker     types.Marker
+	onActivate func(*pb.Silence)
+
 	mtx       sync.RWMutex
 	st        state
 	broadcast func([]byte)
 	mc        matcherCache
+	idx       *index
 }
 
 type metrics struct {
@@ -114,6 +132,92 @@ type metrics struct {
 	silencesActive   prometheus.GaugeFunc
 	silencesPending  prometheus.GaugeFunc
 	silencesExpired  prometheus.GaugeFunc
+	createdTotal     *prometheus.CounterVec
+	expiredTotal     *prometheus.CounterVec
+	activatedTotal   prometheus.Counter
+	renewedTotal     prometheus.Counter
+	gcRemovedTotal   prometheus.Counter
+}
+
+// Sources of silence changes. Local changes are made through this instance,
+// while gossip changes are received from other instances of the cluster.
+const (
+	sourceLocal  = "local"
+	sourceGossip = "gossip"
+)
+
+// mutedAlertsBuckets are the buckets of the number of alerts muted by a
+// silence.
+var mutedAlertsBuckets = []float64{1, 10, 100, 1000, 10000}
+
+// silencesCollector exports metrics computed from the current silences on
+// each scrape.
+type silencesCollector struct {
+	s               *Silences
+	activeByCreator *prometheus.Desc
+	mutedAlerts     *prometheus.Desc
+}
+
+func newSilencesCollector(s *Silences) *silencesCollector {
+	return &silencesCollector{
+		s: s,
+		activeByCreator: prometheus.NewDesc(
+			"alertmanager_silences_active_by_creator",
+			"How many silences are active by their creator.",
+			[]string{"created_by"}, nil,
+		),
+		mutedAlerts: prometheus.NewDesc(
+			"alertmanager_silences_muted_alerts",
+			"How many alerts are muted by each active silence.",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *silencesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeByCreator
+	ch <- c.mutedAlerts
+}
+
+// Collect implements prometheus.Collector.
+func (c *silencesCollector) Collect(ch chan<- prometheus.Metric) {
+	var muted map[string]int
+	if c.s.marker != nil {
+		muted = c.s.marker.CountSilencedBy()
+	}
+
+	c.s.mtx.RLock()
+	now := c.s.now()
+	byCreator := map[string]int{}
+	var (
+		count   uint64
+		sum     float64
+		buckets = make(map[float64]uint64, len(mutedAlertsBuckets))
+	)
+	for _, sil := range c.s.st {
+		if getState(sil.Silence, now) != types.SilenceStateActive {
+			continue
+		}
+		byCreator[sil.Silence.CreatedBy]++
+
+		n := float64(muted[sil.Silence.Id])
+		count++
+		sum += n
+		for _, b := range mutedAlertsBuckets {
+			if n <= b {
+				buckets[b]++
+			}
+		}
+	}
+	c.s.mtx.RUnlock()
+
+	for createdBy, n := range byCreator {
+		ch <- prometheus.MustNewConstMetric(c.activeByCreator, prometheus.GaugeValue, float64(n), createdBy)
+	}
+	if c.s.marker != nil {
+		ch <- prometheus.MustNewConstHistogram(c.mutedAlerts, count, sum, buckets)
+	}
 }
 
 func newSilenceMetricByState(s *Silences, st types.SilenceState) prometheus.GaugeFunc {
@@ -160,6 +264,26 @@ func newMetrics(r prometheus.Registerer, s *Silences) *metrics {
 		Name: "alertmanager_silences_query_duration_seconds",
 		Help: "Duration of silence query evaluation.",
 	})
+	m.createdTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_silences_created_total",
+		Help: "How many silences were created by source (local or gossip).",
+	}, []string{"source"})
+	m.expiredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_silences_expired_total",
+		Help: "How many silences were expired before their end by source (local or gossip).",
+	}, []string{"source"})
+	m.activatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_silences_activated_total",
+		Help: "How many silences scheduled to start in the future became active.",
+	})
+	m.renewedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_silences_renewed_total",
+		Help: "How many times renewable silences were extended because they muted alerts.",
+	})
+	m.gcRemovedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_silences_gc_removed_total",
+		Help: "How many silences were removed by garbage collection.",
+	})
 	if s != nil {
 		m.silencesActive = newSilenceMetricByState(s, types.SilenceStateActive)
 		m.silencesPending = newSilenceMetricByState(s, types.SilenceStatePending)
@@ -177,7 +301,15 @@ func newMetrics(r prometheus.Registerer, s *Silences) *metrics {
 			m.silencesActive,
 			m.silencesPending,
 			m.silencesExpired,
+			m.createdTotal,
+			m.expiredTotal,
+			m.activatedTotal,
+			m.renewedTotal,
+			m.gcRemovedTotal,
 		)
+		if s != nil {
+			r.MustRegister(newSilencesCollector(s))
+		}
 	}
 	return m
 }
@@ -190,10 +322,24 @@ type Options struct {
 	SnapshotFile   string
 	SnapshotReader io.Reader
 
+	// A storage backend and the key of the snapshot within it. The initial
+	// state is loaded from it and maintenance writes snapshots to it. It
+	// must not be combined with SnapshotFile or SnapshotReader.
+	Storage    storage.Backend
+	StorageKey string
+
 	// Retention time for newly created Silences. Silences may be
 	// garbage collected after the given duration after they ended.
 	Retention time.Duration
 
+	// An optional marker the number of alerts muted by each silence is
+	// taken from for the metrics and for renewing silences.
+	Marker types.Marker
+
+	// An optional function called with each silence scheduled to start in
+	// the future once it becomes active, see Activations.
+	OnActivate func(*pb.Silence)
+
 	// A logger used by background processing.
 	Logger  log.Logger
 	Metrics prometheus.Registerer
@@ -203,6 +349,9 @@ func (o *Options) validate() error {
 	if o.SnapshotFile != "" && o.SnapshotReader != nil {
 		return fmt.Errorf("only one of SnapshotFile and SnapshotReader must be set")
 	}
+	if o.Storage != nil && (o.SnapshotFile != "" || o.SnapshotReader != nil) {
+		return fmt.Errorf("Storage must not be combined with SnapshotFile or SnapshotReader")
+	}
 	return nil
 }
 
@@ -220,13 +369,31 @@ func New(o Options) (*Silences, error) {
 			o.SnapshotReader = r
 		}
 	}
+	if o.Storage != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+		defer cancel()
+
+		if r, err := o.Storage.Get(ctx, o.StorageKey); err != nil {
+			if err != storage.ErrNotFound {
+				return nil, err
+			}
+		} else {
+			defer r.Close()
+			o.SnapshotReader = r
+		}
+	}
 	s := &Silences{
-		mc:        matcherCache{},
-		logger:    log.NewNopLogger(),
-		retention: o.Retention,
-		now:       utcNow,
-		broadcast: func([]byte) {},
-		st:        state{},
+		mc:         matcherCache{},
+		idx:        newIndex(),
+		logger:     log.NewNopLogger(),
+		retention:  o.Retention,
+		now:        utcNow,
+		broadcast:  func([]byte) {},
+		st:         state{},
+		storage:    o.Storage,
+		storageKey: o.StorageKey,
+		marker:     o.Marker,
+		onActivate: o.OnActivate,
 	}
 	s.metrics = newMetrics(o.Metrics, s)
 
@@ -242,7 +409,8 @@ func New(o Options) (*Silences, error) {
 }
 
 // Maintenance garbage collects the silence state at the given interval. If the snapshot
-// file is set, a snapshot is written to it afterwards.
+// file is set, a snapshot is written to it afterwards. Otherwise, the snapshot is
+// written to the storage backend if one is configured.
 // Terminates on receiving from stopc.
 func (s *Silences) Maintenance(interval time.Duration, snapf string, stopc <-chan struct{}) {
 	t := time.NewTicker(interval)
@@ -262,7 +430,12 @@ func (s *Silences) Maintenance(interval time.Duration, snapf string, stopc <-cha
 			return err
 		}
 		if snapf == "" {
-			return nil
+			if s.storage == nil {
+				return nil
+			}
+			var err error
+			size, err = s.storeSnapshot()
+			return err
 		}
 		f, err := openReplace(snapf)
 		if err != nil {
@@ -286,7 +459,7 @@ Loop:
 		}
 	}
 	// No need for final maintenance if we don't want to snapshot.
-	if snapf == "" {
+	if snapf == "" && s.storage == nil {
 		return
 	}
 	if err := f(); err != nil {
@@ -294,6 +467,142 @@ Loop:
 	}
 }
 
+// Activations reports the silences scheduled to start in the future once
+// they become active, checking at the given interval. A silence is scheduled
+// if it was last set before its start. Each activation is logged, counted
+// and passed to the OnActivate function of the options.
+// Terminates on receiving from stopc.
+func (s *Silences) Activations(interval time.Duration, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	last := s.now()
+	for {
+		select {
+		case <-stopc:
+			return
+		case <-t.C:
+			last = s.activate(last)
+		}
+	}
+}
+
+// activate reports the scheduled silences that became active after the
+// given time and returns the time up to which they were reported.
+func (s *Silences) activate(since time.Time) time.Time {
+	now := s.now()
+
+	var activated []*pb.Silence
+	s.mtx.RLock()
+	for _, e := range s.st {
+		sil := e.Silence
+		if sil.StartsAt.After(since) && !sil.StartsAt.After(now) && sil.EndsAt.After(now) && sil.StartsAt.After(sil.UpdatedAt) {
+			activated = append(activated, cloneSilence(sil))
+		}
+	}
+	s.mtx.RUnlock()
+
+	sort.Slice(activated, func(i, j int) bool {
+		return activated[i].StartsAt.Before(activated[j].StartsAt)
+	})
+	for _, sil := range activated {
+		s.metrics.activatedTotal.Inc()
+		level.Info(s.logger).Log("msg", "Scheduled silence activated", "id", sil.Id, "created_by", sil.CreatedBy, "comment", sil.Comment, "ends_at", sil.EndsAt)
+		if s.onActivate != nil {
+			s.onActivate(sil)
+		}
+	}
+	return now
+}
+
+const (
+	// RenewInterval is the interval at which renewable silences are checked.
+	RenewInterval = time.Minute
+	// MinRenewIncrement is the smallest increment of renewable silences. A
+	// shorter one could let a silence expire between two checks.
+	MinRenewIncrement = 2 * RenewInterval
+)
+
+// Renewals extends the renewable silences that mute alerts, checking every
+// RenewInterval while leader returns true. A silence is renewed once less
+// than half of its increment, or less than two intervals, is left and then
+// ends an increment after the renewal, but never after its renew_until
+// time. The muted alerts are taken from the marker.
+// Terminates on receiving from stopc.
+func (s *Silences) Renewals(leader func() bool, stopc <-chan struct{}) {
+	t := time.NewTicker(RenewInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stopc:
+			return
+		case <-t.C:
+			// The renewals of the leader are gossiped to the other peers,
+			// which would otherwise all renew the same silences.
+			if !leader() {
+				continue
+			}
+			if _, err := s.renew(); err != nil {
+				level.Error(s.logger).Log("msg", "Renewing silences failed", "err", err)
+			}
+		}
+	}
+}
+
+// renew extends the renewable silences that are due and mute alerts and
+// returns how many were extended.
+func (s *Silences) renew() (int, error) {
+	if s.marker == nil {
+		return 0, nil
+	}
+	muted := s.marker.CountSilencedBy()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	var n int
+	for id, e := range s.st {
+		sil := e.Silence
+		if sil.RenewIncrement == nil || sil.RenewUntil == nil || muted[id] == 0 {
+			continue
+		}
+		if getState(sil, now) != types.SilenceStateActive || !sil.EndsAt.Before(*sil.RenewUntil) {
+			continue
+		}
+		inc := *sil.RenewIncrement
+		if left := sil.EndsAt.Sub(now); left >= inc/2 && left >= 2*RenewInterval {
+			continue
+		}
+		sil = cloneSilence(sil)
+		sil.EndsAt = now.Add(inc)
+		if sil.EndsAt.After(*sil.RenewUntil) {
+			sil.EndsAt = *sil.RenewUntil
+		}
+		if err := s.setSilence(sil); err != nil {
+			return n, err
+		}
+		s.metrics.renewedTotal.Inc()
+		level.Info(s.logger).Log("msg", "Renewable silence extended", "id", sil.Id, "ends_at", sil.EndsAt, "muted_alerts", muted[id])
+		n++
+	}
+	return n, nil
+}
+
+// storeSnapshot writes a snapshot to the storage backend.
+func (s *Silences) storeSnapshot() (int64, error) {
+	var buf bytes.Buffer
+	size, err := s.Snapshot(&buf)
+	if err != nil {
+		return size, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+
+	return size, s.storage.Put(ctx, s.storageKey, &buf)
+}
+
 // GC runs a garbage collection that removes silences that have ended longer
 // than the configured retention time ago.
 func (s *Silences) GC() (int, error) {
@@ -313,9 +622,11 @@ func (s *Silences) GC() (int, error) {
 		if !sil.ExpiresAt.After(now) {
 			delete(s.st, id)
 			delete(s.mc, sil.Silence)
+			s.idx.delete(id)
 			n++
 		}
 	}
+	s.metrics.gcRemovedTotal.Add(float64(n))
 
 	return n, nil
 }
@@ -325,11 +636,11 @@ func validateMatcher(m *pb.Matcher) error {
 		return fmt.Errorf("invalid label name %q", m.Name)
 	}
 	switch m.Type {
-	case pb.Matcher_EQUAL:
+	case pb.Matcher_EQUAL, pb.Matcher_NOT_EQUAL:
 		if !model.LabelValue(m.Pattern).IsValid() {
 			return fmt.Errorf("invalid label value %q", m.Pattern)
 		}
-	case pb.Matcher_REGEXP:
+	case pb.Matcher_REGEXP, pb.Matcher_NOT_REGEXP:
 		if _, err := regexp.Compile(m.Pattern); err != nil {
 			return fmt.Errorf("invalid regular expression %q: %s", m.Pattern, err)
 		}
@@ -346,10 +657,18 @@ func validateSilence(s *pb.Silence) error {
 	if len(s.Matchers) == 0 {
 		return errors.New("at least one matcher required")
 	}
+	positive := false
 	for i, m := range s.Matchers {
 		if err := validateMatcher(m); err != nil {
 			return fmt.Errorf("invalid label matcher %d: %s", i, err)
 		}
+		if m.Type == pb.Matcher_EQUAL || m.Type == pb.Matcher_REGEXP {
+			positive = true
+		}
+	}
+	// Silences of negative matchers only would silence almost all alerts.
+	if !positive {
+		return errors.New("at least one equality or regular expression matcher required")
 	}
 	if s.StartsAt.IsZero() {
 		return errors.New("invalid zero start timestamp")
@@ -363,6 +682,22 @@ func validateSilence(s *pb.Silence) error {
 	if s.UpdatedAt.IsZero() {
 		return errors.New("invalid zero update timestamp")
 	}
+	if (s.RenewIncrement == nil) != (s.RenewUntil == nil) {
+		return errors.New("renewal requires both an increment and a time to renew until")
+	}
+	if s.RenewIncrement != nil {
+		if *s.RenewIncrement < MinRenewIncrement {
+			return fmt.Errorf("renewal increment must be at least %s", MinRenewIncrement)
+		}
+		if s.EndsAt.After(*s.RenewUntil) {
+			return errors.New("end time must not be after the time to renew until")
+		}
+	}
+	for name := range s.Metadata {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid metadata field name %q", name)
+		}
+	}
 	return nil
 }
 
@@ -397,6 +732,7 @@ func (s *Silences) setSilence(sil *pb.Silence) error {
 	}
 
 	s.st.merge(msil)
+	s.idx.set(s.st[sil.Id].Silence)
 	s.broadcast(b)
 
 	return nil
@@ -416,6 +752,8 @@ func (s *Silences) Set(sil *pb.Silence) (string, error) {
 	}
 	if ok {
 		if canUpdate(prev, sil, now) {
+			sil.Version = prev.Version
+			sil.History = prev.History
 			return sil.Id, s.setSilence(sil)
 		}
 		if getState(prev, s.now()) != types.SilenceStateExpired {
@@ -427,12 +765,86 @@ func (s *Silences) Set(sil *pb.Silence) (string, error) {
 	}
 	// If we got here it's either a new silence or a replacing one.
 	sil.Id = uuid.NewV4().String()
+	sil.Version = 0
+	sil.History = nil
 
 	if sil.StartsAt.Before(now) {
 		sil.StartsAt = now
 	}
 
-	return sil.Id, s.setSilence(sil)
+	if err := s.setSilence(sil); err != nil {
+		return sil.Id, err
+	}
+	s.metrics.createdTotal.WithLabelValues(sourceLocal).Inc()
+	return sil.Id, nil
+}
+
+// Create adds the new silences at once: either all of them are created or,
+// if one of them is invalid, none. The silences must not have an ID. It
+// returns the IDs of the created silences in the order of the silences.
+func (s *Silences) Create(sils ...*pb.Silence) ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	for i, sil := range sils {
+		if sil.Id != "" {
+			return nil, errors.Errorf("silence %d must not have an ID", i)
+		}
+		sil.Id = uuid.NewV4().String()
+		sil.Version = 0
+		sil.History = nil
+		sil.UpdatedAt = now
+		if sil.StartsAt.Before(now) {
+			sil.StartsAt = now
+		}
+		if err := validateSilence(sil); err != nil {
+			return nil, errors.Wrapf(err, "silence %d invalid", i)
+		}
+	}
+
+	ids := make([]string, 0, len(sils))
+	for _, sil := range sils {
+		if err := s.setSilence(sil); err != nil {
+			return ids, err
+		}
+		s.metrics.createdTotal.WithLabelValues(sourceLocal).Inc()
+		ids = append(ids, sil.Id)
+	}
+	return ids, nil
+}
+
+// maxHistory is the number of previous versions kept for a silence.
+const maxHistory = 10
+
+// Update modifies the silence with the same ID in place. Unlike Set, the
+// matchers may be changed as well. The silence keeps its ID, its version is
+// incremented and the previous version is added to its history.
+func (s *Silences) Update(sil *pb.Silence) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	prev, ok := s.getSilence(sil.Id)
+	if !ok {
+		return ErrNotFound
+	}
+	now := s.now()
+	if getState(prev, now) == types.SilenceStateExpired {
+		return errors.Errorf("silence %s already expired", sil.Id)
+	}
+	if !canUpdateTimes(prev, sil, now) {
+		return errors.Errorf("invalid time range modification of silence %s", sil.Id)
+	}
+
+	old := cloneSilence(prev)
+	old.History = nil
+
+	sil.Version = prev.Version + 1
+	sil.History = append(append([]*pb.Silence{}, prev.History...), old)
+	if len(sil.History) > maxHistory {
+		sil.History = sil.History[len(sil.History)-maxHistory:]
+	}
+	return s.setSilence(sil)
 }
 
 // canUpdate returns true if silence a can be updated to b without
@@ -441,6 +853,12 @@ func canUpdate(a, b *pb.Silence, now time.Time) bool {
 	if !reflect.DeepEqual(a.Matchers, b.Matchers) {
 		return false
 	}
+	return canUpdateTimes(a, b, now)
+}
+
+// canUpdateTimes returns true if the time range of silence a can be
+// changed to the one of b.
+func canUpdateTimes(a, b *pb.Silence, now time.Time) bool {
 	// Allowed timestamp modifications depend on the current time.
 	switch st := getState(a, now); st {
 	case types.SilenceStateActive:
@@ -489,7 +907,11 @@ func (s *Silences) expire(id string) error {
 		sil.EndsAt = now
 	}
 
-	return s.setSilence(sil)
+	if err := s.setSilence(sil); err != nil {
+		return err
+	}
+	s.metrics.expiredTotal.WithLabelValues(sourceLocal).Inc()
+	return nil
 }
 
 // QueryParam expresses parameters along which silences are queried.
@@ -498,6 +920,8 @@ type QueryParam func(*query) error
 type query struct {
 	ids     []string
 	filters []silenceFilter
+	// labels is the label set the silences have to match, if set.
+	labels model.LabelSet
 }
 
 // silenceFilter is a function that returns true if a silence
@@ -534,6 +958,9 @@ func QMatches(set model.LabelSet) QueryParam {
 			return m.Match(set), nil
 		}
 		q.filters = append(q.filters, f)
+		if q.labels == nil {
+			q.labels = set
+		}
 		return nil
 	}
 }
@@ -612,21 +1039,29 @@ func (s *Silences) CountState(states ...types.SilenceState) (int, error) {
 }
 
 func (s *Silences) query(q *query, now time.Time) ([]*pb.Silence, error) {
-	// If we have an ID constraint, all silences are our base set.
-	// This and the use of post-filter functions is the
-	// the trivial solution for now.
+	// The base set are the silences with the given IDs, the silences that
+	// may match the given labels according to the index or else all
+	// silences. The filters are applied to the base set.
 	var res []*pb.Silence
 
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	if q.ids != nil {
+	switch {
+	case q.ids != nil:
 		for _, id := range q.ids {
 			if s, ok := s.st[id]; ok {
 				res = append(res, s.Silence)
 			}
 		}
-	} else {
+	case q.labels != nil && s.idx != nil:
+		// Only the silences that may match the labels are filtered.
+		for id := range s.idx.candidates(q.labels) {
+			if sil, ok := s.st[id]; ok {
+				res = append(res, sil.Silence)
+			}
+		}
+	default:
 		for _, sil := range s.st {
 			res = append(res, sil.Silence)
 		}
@@ -669,8 +1104,13 @@ func (s *Silences) loadSnapshot(r io.Reader) error {
 		}
 		st[e.Silence.Id] = e
 	}
+	idx := newIndex()
+	for _, e := range st {
+		idx.set(e.Silence)
+	}
 	s.mtx.Lock()
 	s.st = st
+	s.idx = idx
 	s.mtx.Unlock()
 
 	return nil
@@ -710,8 +1150,23 @@ func (s *Silences) Merge(b []byte) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	now := s.now()
 	for _, e := range st {
+		prev, ok := s.st[e.Silence.Id]
 		s.st.merge(e)
+
+		cur := s.st[e.Silence.Id]
+		if cur == prev {
+			continue
+		}
+		s.idx.set(cur.Silence)
+		expired := getState(cur.Silence, now) == types.SilenceStateExpired
+		switch {
+		case !ok && !expired:
+			s.metrics.createdTotal.WithLabelValues(sourceGossip).Inc()
+		case ok && expired && getState(prev.Silence, now) != types.SilenceStateExpired:
+			s.metrics.expiredTotal.WithLabelValues(sourceGossip).Inc()
+		}
 	}
 	return nil
 }
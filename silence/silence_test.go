@@ -15,6 +15,7 @@ package silence
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -24,7 +25,10 @@ import (
 
 	"github.com/matttproud/golang_protobuf_extensions/pbutil"
 	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/storage"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
@@ -51,6 +55,13 @@ func TestOptionsValidate(t *testing.T) {
 			},
 			err: "only one of SnapshotFile and SnapshotReader must be set",
 		},
+		{
+			options: &Options{
+				SnapshotFile: "test.bkp",
+				Storage:      storage.NewFile("data"),
+			},
+			err: "Storage must not be combined with SnapshotFile or SnapshotReader",
+		},
 	}
 
 	for _, c := range cases {
@@ -96,6 +107,109 @@ func TestSilencesGC(t *testing.T) {
 	require.Equal(t, want, s.st)
 }
 
+func TestSilencesActivations(t *testing.T) {
+	var activated []string
+	s, err := New(Options{
+		Retention:  time.Hour,
+		OnActivate: func(sil *pb.Silence) { activated = append(activated, sil.Id) },
+	})
+	require.NoError(t, err)
+
+	now := utcNow()
+	s.now = func() time.Time { return now }
+
+	matchers := []*pb.Matcher{{Name: "a", Pattern: "b"}}
+	scheduled, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now.Add(time.Minute), EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = s.Set(&pb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	expired, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now.Add(time.Minute), EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	now = now.Add(time.Second)
+	require.NoError(t, s.Expire(expired))
+
+	last := s.activate(now)
+	require.Equal(t, now, last)
+	require.Empty(t, activated)
+
+	now = now.Add(2 * time.Minute)
+	last = s.activate(last)
+	require.Equal(t, []string{scheduled}, activated)
+
+	// Silences are reported once.
+	now = now.Add(time.Minute)
+	s.activate(last)
+	require.Equal(t, []string{scheduled}, activated)
+}
+
+func TestSilencesRenewals(t *testing.T) {
+	marker := types.NewMarker()
+	s, err := New(Options{Retention: time.Hour, Marker: marker})
+	require.NoError(t, err)
+
+	now := utcNow()
+	s.now = func() time.Time { return now }
+
+	var (
+		matchers  = []*pb.Matcher{{Name: "a", Pattern: "b"}}
+		increment = time.Hour
+		until     = now.Add(150 * time.Minute)
+	)
+	renewable, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour), RenewIncrement: &increment, RenewUntil: &until})
+	require.NoError(t, err)
+	fixed, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	idle, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour), RenewIncrement: &increment, RenewUntil: &until})
+	require.NoError(t, err)
+	marker.SetSilenced(1, renewable, fixed)
+
+	endsAt := func(id string) time.Time {
+		sil, err := s.QueryOne(QIDs(id))
+		require.NoError(t, err)
+		return sil.EndsAt
+	}
+
+	// Silences are not renewed while more than half the increment is left.
+	n, err := s.renew()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	now = now.Add(45 * time.Minute)
+	n, err = s.renew()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, now.Add(time.Hour), endsAt(renewable))
+	require.Equal(t, now.Add(15*time.Minute), endsAt(fixed))
+	require.Equal(t, now.Add(15*time.Minute), endsAt(idle))
+
+	// Silences are not renewed beyond their maximum.
+	now = now.Add(45 * time.Minute)
+	n, err = s.renew()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, until, endsAt(renewable))
+
+	now = now.Add(50 * time.Minute)
+	n, err = s.renew()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	require.Equal(t, until, endsAt(renewable))
+
+	// Silences with short increments are renewed before they could expire
+	// until the next check.
+	increment = MinRenewIncrement
+	until = now.Add(time.Hour)
+	short, err := s.Set(&pb.Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(increment), RenewIncrement: &increment, RenewUntil: &until})
+	require.NoError(t, err)
+	marker.SetSilenced(1, short)
+
+	now = now.Add(RenewInterval / 2)
+	n, err = s.renew()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, now.Add(increment), endsAt(short))
+}
+
 func TestSilencesSnapshot(t *testing.T) {
 	// Check whether storing and loading the snapshot is symmetric.
 	now := utcNow()
@@ -127,6 +241,32 @@ func TestSilencesSnapshot(t *testing.T) {
 						StartsAt:  now.Add(time.Hour),
 						EndsAt:    now.Add(2 * time.Hour),
 						UpdatedAt: now,
+						Metadata:  map[string]string{"ticket": "OPS-123", "team": "db"},
+					},
+					ExpiresAt: now.Add(24 * time.Hour),
+				},
+				{
+					Silence: &pb.Silence{
+						Id: "7f8e1a52-5c3e-4bd2-9d0b-3e0c2a6b0f61",
+						Matchers: []*pb.Matcher{
+							{Name: "label1", Pattern: "val2", Type: pb.Matcher_EQUAL},
+						},
+						StartsAt:  now,
+						EndsAt:    now.Add(time.Hour),
+						UpdatedAt: now,
+						Version:   300,
+						History: []*pb.Silence{
+							{
+								Id: "7f8e1a52-5c3e-4bd2-9d0b-3e0c2a6b0f61",
+								Matchers: []*pb.Matcher{
+									{Name: "label1", Pattern: "val1", Type: pb.Matcher_EQUAL},
+								},
+								StartsAt:  now,
+								EndsAt:    now.Add(time.Hour),
+								UpdatedAt: now.Add(-time.Minute),
+								Version:   299,
+							},
+						},
 					},
 					ExpiresAt: now.Add(24 * time.Hour),
 				},
@@ -161,6 +301,118 @@ func TestSilencesSnapshot(t *testing.T) {
 	}
 }
 
+func TestSilencesStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silences")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := storage.NewFile(dir)
+
+	s1, err := New(Options{Storage: b, StorageKey: "silences"})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(s1.st), "state must be empty without stored snapshot")
+
+	_, err = s1.Set(&pb.Silence{
+		Matchers: []*pb.Matcher{{Name: "a", Pattern: "b"}},
+		StartsAt: utcNow().Add(time.Hour),
+		EndsAt:   utcNow().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = s1.storeSnapshot()
+	require.NoError(t, err)
+
+	s2, err := New(Options{Storage: b, StorageKey: "silences"})
+	require.NoError(t, err)
+	require.Equal(t, s1.st, s2.st, "state after loading snapshot did not match snapshotted state")
+}
+
+func TestSilencesMetrics(t *testing.T) {
+	marker := types.NewMarker()
+	s, err := New(Options{Marker: marker})
+	require.NoError(t, err)
+
+	now := utcNow()
+	s.now = func() time.Time { return now }
+
+	newSilence := func(createdBy string) *pb.Silence {
+		return &pb.Silence{
+			Matchers:  []*pb.Matcher{{Name: "a", Pattern: "b"}},
+			StartsAt:  now.Add(-time.Minute),
+			EndsAt:    now.Add(time.Hour),
+			CreatedBy: createdBy,
+		}
+	}
+	id1, err := s.Set(newSilence("alice"))
+	require.NoError(t, err)
+	_, err = s.Set(newSilence("alice"))
+	require.NoError(t, err)
+	id3, err := s.Set(newSilence("bob"))
+	require.NoError(t, err)
+	s.now = func() time.Time { return now.Add(time.Second) }
+	require.NoError(t, s.Expire(id3))
+
+	marker.SetSilenced(model.Fingerprint(1), id1)
+	marker.SetSilenced(model.Fingerprint(2), id1)
+	marker.SetSilenced(model.Fingerprint(3), "other")
+
+	// Silences expired at the current time are still active.
+	s.now = func() time.Time { return now.Add(2 * time.Second) }
+
+	// A silence created and one expired on another instance.
+	other := &pb.MeshSilence{Silence: newSilence("carol"), ExpiresAt: now.Add(time.Hour)}
+	other.Silence.Id = "other"
+	other.Silence.UpdatedAt = now
+	expired := cloneSilence(s.st[id1].Silence)
+	expired.EndsAt = now
+	expired.UpdatedAt = now.Add(2 * time.Second)
+	b, err := state{
+		"other": other,
+		id1:     &pb.MeshSilence{Silence: expired, ExpiresAt: now.Add(time.Hour)},
+	}.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, s.Merge(b))
+
+	counterValue := func(c *prometheus.CounterVec, source string) float64 {
+		var m dto.Metric
+		require.NoError(t, c.WithLabelValues(source).Write(&m))
+		return m.GetCounter().GetValue()
+	}
+	require.Equal(t, 3.0, counterValue(s.metrics.createdTotal, sourceLocal))
+	require.Equal(t, 1.0, counterValue(s.metrics.createdTotal, sourceGossip))
+	require.Equal(t, 1.0, counterValue(s.metrics.expiredTotal, sourceLocal))
+	require.Equal(t, 1.0, counterValue(s.metrics.expiredTotal, sourceGossip))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		newSilencesCollector(s).Collect(ch)
+		close(ch)
+	}()
+	byCreator := map[string]float64{}
+	var muted *dto.Histogram
+	for m := range ch {
+		var pb dto.Metric
+		require.NoError(t, m.Write(&pb))
+		if h := pb.GetHistogram(); h != nil {
+			muted = h
+			continue
+		}
+		byCreator[pb.GetLabel()[0].GetValue()] = pb.GetGauge().GetValue()
+	}
+	require.Equal(t, map[string]float64{"alice": 1, "carol": 1}, byCreator)
+	require.NotNil(t, muted)
+	require.Equal(t, uint64(2), muted.GetSampleCount())
+	require.Equal(t, 1.0, muted.GetSampleSum())
+
+	// The silence muting the alerts was expired by the merge.
+	s.now = func() time.Time { return now.Add(2 * time.Hour) }
+	n, err := s.GC()
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	var m dto.Metric
+	require.NoError(t, s.metrics.gcRemovedTotal.Write(&m))
+	require.Equal(t, 4.0, m.GetCounter().GetValue())
+}
+
 func TestSilencesSetSilence(t *testing.T) {
 	s, err := New(Options{
 		Retention: time.Minute,
@@ -402,6 +654,125 @@ func TestSilencesSetFail(t *testing.T) {
 	}
 }
 
+func TestSilencesCreate(t *testing.T) {
+	s, err := New(Options{Retention: time.Hour})
+	require.NoError(t, err)
+
+	now := utcNow()
+	s.now = func() time.Time { return now }
+
+	newSilence := func(name string) *pb.Silence {
+		return &pb.Silence{
+			Matchers: []*pb.Matcher{{Name: "instance", Pattern: name}},
+			StartsAt: now,
+			EndsAt:   now.Add(time.Hour),
+		}
+	}
+
+	// No silence is created if one of them is invalid.
+	_, err = s.Create(newSilence("a"), &pb.Silence{StartsAt: now, EndsAt: now.Add(time.Hour)})
+	require.EqualError(t, err, "silence 1 invalid: at least one matcher required")
+	require.Len(t, s.st, 0)
+
+	_, err = s.Create(newSilence("a"), &pb.Silence{Id: "some_id"})
+	require.EqualError(t, err, "silence 1 must not have an ID")
+	require.Len(t, s.st, 0)
+
+	ids, err := s.Create(newSilence("a"), newSilence("b"))
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	require.Len(t, s.st, 2)
+	for i, name := range []string{"a", "b"} {
+		sil, ok := s.getSilence(ids[i])
+		require.True(t, ok)
+		require.Equal(t, name, sil.Matchers[0].Pattern)
+	}
+}
+
+func TestSilencesUpdate(t *testing.T) {
+	s, err := New(Options{
+		Retention: time.Hour,
+	})
+	require.NoError(t, err)
+
+	now := utcNow()
+	s.now = func() time.Time { return now }
+
+	id, err := s.Set(&pb.Silence{
+		Matchers:  []*pb.Matcher{{Name: "a", Pattern: "b"}},
+		EndsAt:    now.Add(5 * time.Minute),
+		CreatedBy: "alice",
+	})
+	require.NoError(t, err)
+	created := cloneSilence(s.st[id].Silence)
+
+	now = now.Add(time.Minute)
+	err = s.Update(&pb.Silence{
+		Id:        id,
+		Matchers:  []*pb.Matcher{{Name: "a", Pattern: "c"}},
+		StartsAt:  created.StartsAt,
+		EndsAt:    now.Add(10 * time.Minute),
+		CreatedBy: "bob",
+	})
+	require.NoError(t, err)
+
+	want := &pb.MeshSilence{
+		Silence: &pb.Silence{
+			Id:        id,
+			Matchers:  []*pb.Matcher{{Name: "a", Pattern: "c"}},
+			StartsAt:  created.StartsAt,
+			EndsAt:    now.Add(10 * time.Minute),
+			UpdatedAt: now,
+			CreatedBy: "bob",
+			Version:   1,
+			History:   []*pb.Silence{created},
+		},
+		ExpiresAt: now.Add(10*time.Minute + s.retention),
+	}
+	require.Equal(t, want, s.st[id], "unexpected state after silence update")
+	require.Equal(t, 1, len(s.st), "update must not create a new silence")
+
+	// Updating in place with Set keeps the version and history.
+	now = now.Add(time.Minute)
+	sil := cloneSilence(s.st[id].Silence)
+	sil.Version, sil.History = 0, nil
+	sil.EndsAt = now.Add(time.Hour)
+	_, err = s.Set(sil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), s.st[id].Silence.Version)
+	require.Equal(t, []*pb.Silence{created}, s.st[id].Silence.History)
+
+	// The history is limited.
+	for i := 0; i < maxHistory+5; i++ {
+		now = now.Add(time.Second)
+		sil := cloneSilence(s.st[id].Silence)
+		sil.Comment = fmt.Sprintf("update %d", i)
+		require.NoError(t, s.Update(sil))
+	}
+	sil = s.st[id].Silence
+	require.Equal(t, uint64(maxHistory+6), sil.Version)
+	require.Equal(t, maxHistory, len(sil.History))
+	require.Equal(t, "update 4", sil.History[0].Comment)
+	for _, h := range sil.History {
+		require.Nil(t, h.History, "history entries must not have a history")
+	}
+
+	// The start time of an active silence cannot change.
+	sil = cloneSilence(sil)
+	sil.StartsAt = now.Add(time.Minute)
+	require.Error(t, s.Update(sil))
+
+	// Expired silences cannot be updated.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.Expire(id))
+	now = now.Add(time.Minute)
+	sil = cloneSilence(s.st[id].Silence)
+	sil.EndsAt = now.Add(time.Hour)
+	require.Error(t, s.Update(sil))
+
+	require.Equal(t, ErrNotFound, s.Update(&pb.Silence{Id: "unknown"}))
+}
+
 func TestQState(t *testing.T) {
 	now := utcNow()
 
@@ -496,6 +867,24 @@ func TestQMatches(t *testing.T) {
 			},
 			drop: false,
 		},
+		{
+			sil: &pb.Silence{
+				Matchers: []*pb.Matcher{
+					{Name: "job", Pattern: "test", Type: pb.Matcher_EQUAL},
+					{Name: "method", Pattern: "POST", Type: pb.Matcher_NOT_EQUAL},
+				},
+			},
+			drop: true,
+		},
+		{
+			sil: &pb.Silence{
+				Matchers: []*pb.Matcher{
+					{Name: "job", Pattern: "test", Type: pb.Matcher_EQUAL},
+					{Name: "instance", Pattern: "web-.+", Type: pb.Matcher_NOT_REGEXP},
+				},
+			},
+			drop: false,
+		},
 	}
 	for _, c := range cases {
 		drop, err := f(c.sil, &Silences{mc: matcherCache{}, st: state{}}, time.Time{})
@@ -864,6 +1253,9 @@ func TestValidateSilence(t *testing.T) {
 		now            = utcNow()
 		zeroTimestamp  = time.Time{}
 		validTimestamp = now
+		increment      = time.Hour
+		shortIncrement = time.Minute
+		renewUntil     = now.Add(time.Hour)
 	)
 	cases := []struct {
 		s   *pb.Silence
@@ -964,6 +1356,88 @@ func TestValidateSilence(t *testing.T) {
 			},
 			err: "invalid zero update timestamp",
 		},
+		{
+			s: &pb.Silence{
+				Id: "some_id",
+				Matchers: []*pb.Matcher{
+					&pb.Matcher{Name: "a", Pattern: "b", Type: pb.Matcher_NOT_EQUAL},
+					&pb.Matcher{Name: "c", Pattern: "d.*", Type: pb.Matcher_NOT_REGEXP},
+				},
+				StartsAt:  validTimestamp,
+				EndsAt:    validTimestamp,
+				UpdatedAt: validTimestamp,
+			},
+			err: "at least one equality or regular expression matcher required",
+		},
+		{
+			s: &pb.Silence{
+				Id: "some_id",
+				Matchers: []*pb.Matcher{
+					&pb.Matcher{Name: "a", Pattern: "b"},
+					&pb.Matcher{Name: "c", Pattern: "", Type: pb.Matcher_NOT_EQUAL},
+				},
+				StartsAt:  validTimestamp,
+				EndsAt:    validTimestamp,
+				UpdatedAt: validTimestamp,
+			},
+		},
+		{
+			s: &pb.Silence{
+				Id:             "some_id",
+				Matchers:       []*pb.Matcher{&pb.Matcher{Name: "a", Pattern: "b"}},
+				StartsAt:       validTimestamp,
+				EndsAt:         validTimestamp,
+				UpdatedAt:      validTimestamp,
+				RenewIncrement: &increment,
+				RenewUntil:     &renewUntil,
+			},
+		},
+		{
+			s: &pb.Silence{
+				Id:             "some_id",
+				Matchers:       []*pb.Matcher{&pb.Matcher{Name: "a", Pattern: "b"}},
+				StartsAt:       validTimestamp,
+				EndsAt:         validTimestamp,
+				UpdatedAt:      validTimestamp,
+				RenewIncrement: &increment,
+			},
+			err: "renewal requires both an increment and a time to renew until",
+		},
+		{
+			s: &pb.Silence{
+				Id:             "some_id",
+				Matchers:       []*pb.Matcher{&pb.Matcher{Name: "a", Pattern: "b"}},
+				StartsAt:       validTimestamp,
+				EndsAt:         validTimestamp,
+				UpdatedAt:      validTimestamp,
+				RenewIncrement: &shortIncrement,
+				RenewUntil:     &renewUntil,
+			},
+			err: "renewal increment must be at least 2m0s",
+		},
+		{
+			s: &pb.Silence{
+				Id:             "some_id",
+				Matchers:       []*pb.Matcher{&pb.Matcher{Name: "a", Pattern: "b"}},
+				StartsAt:       validTimestamp,
+				EndsAt:         renewUntil.Add(time.Second),
+				UpdatedAt:      validTimestamp,
+				RenewIncrement: &increment,
+				RenewUntil:     &renewUntil,
+			},
+			err: "end time must not be after the time to renew until",
+		},
+		{
+			s: &pb.Silence{
+				Id:        "some_id",
+				Matchers:  []*pb.Matcher{&pb.Matcher{Name: "a", Pattern: "b"}},
+				StartsAt:  validTimestamp,
+				EndsAt:    validTimestamp,
+				UpdatedAt: validTimestamp,
+				Metadata:  map[string]string{"change ticket": "OPS-123"},
+			},
+			err: "invalid metadata field name",
+		},
 	}
 	for _, c := range cases {
 		err := validateSilence(c.s)
@@ -1029,7 +1503,11 @@ func TestStateMerge(t *testing.T) {
 
 func TestStateCoding(t *testing.T) {
 	// Check whether encoding and decoding the data is symmetric.
-	now := utcNow()
+	var (
+		now        = utcNow()
+		increment  = time.Hour
+		renewUntil = now.Add(2 * time.Hour)
+	)
 
 	cases := []struct {
 		entries []*pb.MeshSilence
@@ -1061,6 +1539,45 @@ func TestStateCoding(t *testing.T) {
 					},
 					ExpiresAt: now.Add(24 * time.Hour),
 				},
+				{
+					Silence: &pb.Silence{
+						Id: "7f8e1a52-5c3e-4bd2-9d0b-3e0c2a6b0f61",
+						Matchers: []*pb.Matcher{
+							{Name: "label1", Pattern: "val2", Type: pb.Matcher_EQUAL},
+						},
+						StartsAt:  now,
+						EndsAt:    now.Add(time.Hour),
+						UpdatedAt: now,
+						Version:   300,
+						History: []*pb.Silence{
+							{
+								Id: "7f8e1a52-5c3e-4bd2-9d0b-3e0c2a6b0f61",
+								Matchers: []*pb.Matcher{
+									{Name: "label1", Pattern: "val1", Type: pb.Matcher_EQUAL},
+								},
+								StartsAt:  now,
+								EndsAt:    now.Add(time.Hour),
+								UpdatedAt: now.Add(-time.Minute),
+								Version:   299,
+							},
+						},
+					},
+					ExpiresAt: now.Add(24 * time.Hour),
+				},
+				{
+					Silence: &pb.Silence{
+						Id: "c0ffee52-5c3e-4bd2-9d0b-3e0c2a6b0f61",
+						Matchers: []*pb.Matcher{
+							{Name: "label1", Pattern: "val3", Type: pb.Matcher_EQUAL},
+						},
+						StartsAt:       now,
+						EndsAt:         now.Add(time.Hour),
+						UpdatedAt:      now,
+						RenewIncrement: &increment,
+						RenewUntil:     &renewUntil,
+					},
+					ExpiresAt: now.Add(24 * time.Hour),
+				},
 			},
 		},
 	}
@@ -44,17 +44,23 @@ const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 type Matcher_Type int32
 
 const (
-	Matcher_EQUAL  Matcher_Type = 0
-	Matcher_REGEXP Matcher_Type = 1
+	Matcher_EQUAL      Matcher_Type = 0
+	Matcher_REGEXP     Matcher_Type = 1
+	Matcher_NOT_EQUAL  Matcher_Type = 2
+	Matcher_NOT_REGEXP Matcher_Type = 3
 )
 
 var Matcher_Type_name = map[int32]string{
 	0: "EQUAL",
 	1: "REGEXP",
+	2: "NOT_EQUAL",
+	3: "NOT_REGEXP",
 }
 var Matcher_Type_value = map[string]int32{
-	"EQUAL":  0,
-	"REGEXP": 1,
+	"EQUAL":      0,
+	"REGEXP":     1,
+	"NOT_EQUAL":  2,
+	"NOT_REGEXP": 3,
 }
 
 func (x Matcher_Type) String() string {
@@ -107,6 +113,20 @@ type Silence struct {
 	// Comment for the silence.
 	CreatedBy string `protobuf:"bytes,8,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
 	Comment   string `protobuf:"bytes,9,opt,name=comment,proto3" json:"comment,omitempty"`
+	// The number of times the silence was updated in place.
+	Version uint64 `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	// Previous versions of the silence, oldest first. Their own history
+	// is always empty.
+	History []*Silence `protobuf:"bytes,11,rep,name=history" json:"history,omitempty"`
+	// A renewable silence is extended by the increment while it mutes alerts,
+	// but not beyond renew_until. Both are unset for other silences.
+	RenewIncrement *time.Duration `protobuf:"bytes,12,opt,name=renew_increment,json=renewIncrement,stdduration" json:"renew_increment,omitempty"`
+	RenewUntil     *time.Time     `protobuf:"bytes,13,opt,name=renew_until,json=renewUntil,stdtime" json:"renew_until,omitempty"`
+	// An identifier supplied by the client creating the silence, e.g. to
+	// find the silence again when it is applied from a file.
+	ExternalId string `protobuf:"bytes,14,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	// Structured fields describing the silence, e.g. a ticket or a team.
+	Metadata map[string]string `protobuf:"bytes,15,rep,name=metadata" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *Silence) Reset()                    { *m = Silence{} }
@@ -287,6 +307,66 @@ func (m *Silence) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintSilence(dAtA, i, uint64(len(m.Comment)))
 		i += copy(dAtA[i:], m.Comment)
 	}
+	if m.Version != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintSilence(dAtA, i, uint64(m.Version))
+	}
+	if len(m.History) > 0 {
+		for _, msg := range m.History {
+			dAtA[i] = 0x5a
+			i++
+			i = encodeVarintSilence(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.RenewIncrement != nil {
+		dAtA[i] = 0x62
+		i++
+		i = encodeVarintSilence(dAtA, i, uint64(types.SizeOfStdDuration(*m.RenewIncrement)))
+		n5, err := types.StdDurationMarshalTo(*m.RenewIncrement, dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
+	if m.RenewUntil != nil {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintSilence(dAtA, i, uint64(types.SizeOfStdTime(*m.RenewUntil)))
+		n6, err := types.StdTimeMarshalTo(*m.RenewUntil, dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n6
+	}
+	if len(m.ExternalId) > 0 {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintSilence(dAtA, i, uint64(len(m.ExternalId)))
+		i += copy(dAtA[i:], m.ExternalId)
+	}
+	if len(m.Metadata) > 0 {
+		for k, _ := range m.Metadata {
+			dAtA[i] = 0x7a
+			i++
+			v := m.Metadata[k]
+			mapSize := 1 + len(k) + sovSilence(uint64(len(k))) + 1 + len(v) + sovSilence(uint64(len(v)))
+			i = encodeVarintSilence(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintSilence(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintSilence(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
 	return i, nil
 }
 
@@ -401,6 +481,35 @@ func (m *Silence) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovSilence(uint64(l))
 	}
+	if m.Version != 0 {
+		n += 1 + sovSilence(uint64(m.Version))
+	}
+	if len(m.History) > 0 {
+		for _, e := range m.History {
+			l = e.Size()
+			n += 1 + l + sovSilence(uint64(l))
+		}
+	}
+	if m.RenewIncrement != nil {
+		l = types.SizeOfStdDuration(*m.RenewIncrement)
+		n += 1 + l + sovSilence(uint64(l))
+	}
+	if m.RenewUntil != nil {
+		l = types.SizeOfStdTime(*m.RenewUntil)
+		n += 1 + l + sovSilence(uint64(l))
+	}
+	l = len(m.ExternalId)
+	if l > 0 {
+		n += 1 + l + sovSilence(uint64(l))
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSilence(uint64(len(k))) + 1 + len(v) + sovSilence(uint64(len(v)))
+			n += mapEntrySize + 1 + sovSilence(uint64(mapEntrySize))
+		}
+	}
 	return n
 }
 
@@ -962,6 +1071,269 @@ func (m *Silence) Unmarshal(dAtA []byte) error {
 			}
 			m.Comment = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			m.Version = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Version |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field History", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSilence
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.History = append(m.History, &Silence{})
+			if err := m.History[len(m.History)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RenewIncrement", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSilence
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RenewIncrement == nil {
+				m.RenewIncrement = new(time.Duration)
+			}
+			if err := types.StdDurationUnmarshal(m.RenewIncrement, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RenewUntil", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSilence
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RenewUntil == nil {
+				m.RenewUntil = new(time.Time)
+			}
+			if err := types.StdTimeUnmarshal(m.RenewUntil, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExternalId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSilence
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ExternalId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSilence
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSilence
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSilence
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSilence
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSilence
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSilence
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthSilence
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSilence(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthSilence
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Metadata[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSilence(dAtA[iNdEx:])
@@ -1204,33 +1576,45 @@ var (
 func init() { proto.RegisterFile("silence.proto", fileDescriptorSilence) }
 
 var fileDescriptorSilence = []byte{
-	// 444 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x51, 0x4d, 0x6b, 0xdb, 0x40,
-	0x10, 0xf5, 0x2a, 0x8e, 0x65, 0x8d, 0x69, 0x30, 0x43, 0x69, 0x85, 0x21, 0xb6, 0xd1, 0xc9, 0xd0,
-	0x22, 0x83, 0x7b, 0xee, 0x41, 0x0e, 0xa6, 0x97, 0x06, 0x5a, 0x35, 0x85, 0xde, 0xca, 0xda, 0x9a,
-	0xda, 0x82, 0x48, 0xbb, 0x48, 0x63, 0xa8, 0x4f, 0x2d, 0xf4, 0x0f, 0xf4, 0x67, 0xf9, 0xd8, 0x5f,
-	0xd0, 0x0f, 0xff, 0x8b, 0xde, 0x8a, 0x56, 0x2b, 0x37, 0x21, 0x27, 0xdf, 0x66, 0x66, 0xdf, 0x9b,
-	0xb7, 0xef, 0x0d, 0x3c, 0x2a, 0xd3, 0x5b, 0xca, 0x57, 0x14, 0xea, 0x42, 0xb1, 0x42, 0xcf, 0xb6,
-	0x7a, 0x39, 0x18, 0xad, 0x95, 0x5a, 0xdf, 0xd2, 0xd4, 0x3c, 0x2c, 0xb7, 0x9f, 0xa6, 0x9c, 0x66,
-	0x54, 0xb2, 0xcc, 0x74, 0x8d, 0x1d, 0x3c, 0x5e, 0xab, 0xb5, 0x32, 0xe5, 0xb4, 0xaa, 0xea, 0x69,
-	0xf0, 0x4d, 0x80, 0x7b, 0x2d, 0x79, 0xb5, 0xa1, 0x02, 0x9f, 0x41, 0x9b, 0x77, 0x9a, 0x7c, 0x31,
-	0x16, 0x93, 0x8b, 0xd9, 0xd3, 0xf0, 0xb8, 0x3c, 0xb4, 0x88, 0xf0, 0x66, 0xa7, 0x29, 0x36, 0x20,
-	0x44, 0x68, 0xe7, 0x32, 0x23, 0xdf, 0x19, 0x8b, 0x89, 0x17, 0x9b, 0x1a, 0x7d, 0x70, 0xb5, 0x64,
-	0xa6, 0x22, 0xf7, 0xcf, 0xcc, 0xb8, 0x69, 0x83, 0x4b, 0x68, 0x57, 0x5c, 0xf4, 0xe0, 0x7c, 0xf1,
-	0xf6, 0x7d, 0xf4, 0xba, 0xdf, 0x42, 0x80, 0x4e, 0xbc, 0x78, 0xb5, 0xf8, 0xf0, 0xa6, 0x2f, 0x82,
-	0x2f, 0xe0, 0x5e, 0xa9, 0x2c, 0xa3, 0x9c, 0xf1, 0x09, 0x74, 0xe4, 0x96, 0x37, 0xaa, 0x30, 0xdf,
-	0xf0, 0x62, 0xdb, 0x55, 0xbb, 0x57, 0x35, 0xc4, 0x4a, 0x36, 0x2d, 0xce, 0xc1, 0x3b, 0x7a, 0x35,
-	0xba, 0xbd, 0xd9, 0x20, 0xac, 0xd3, 0x08, 0x9b, 0x34, 0xc2, 0x9b, 0x06, 0x31, 0xef, 0xee, 0x7f,
-	0x8e, 0x5a, 0xdf, 0x7f, 0x8d, 0x44, 0xfc, 0x9f, 0x16, 0xfc, 0x75, 0xc0, 0x7d, 0x57, 0xdb, 0xc5,
-	0x0b, 0x70, 0xd2, 0xc4, 0xaa, 0x3b, 0x69, 0x82, 0x21, 0x74, 0xb3, 0xda, 0x7f, 0xe9, 0x3b, 0xe3,
-	0xb3, 0x49, 0x6f, 0x86, 0x0f, 0xa3, 0x89, 0x8f, 0x18, 0x8c, 0xc0, 0x2b, 0x59, 0x16, 0x5c, 0x7e,
-	0x94, 0x7c, 0xd2, 0x7f, 0xba, 0x35, 0x2d, 0x62, 0x7c, 0x09, 0x2e, 0xe5, 0x89, 0x59, 0xd0, 0x3e,
-	0x61, 0x41, 0xa7, 0x22, 0x45, 0x8c, 0x57, 0x00, 0x5b, 0x9d, 0x48, 0xa6, 0xa4, 0xda, 0x70, 0x7e,
-	0x4a, 0x24, 0x96, 0x17, 0x71, 0x65, 0xdb, 0x26, 0x5c, 0xfa, 0xee, 0x03, 0xdb, 0xf6, 0x5c, 0xf1,
-	0x11, 0x83, 0x97, 0x00, 0xab, 0x82, 0x8c, 0xe8, 0x72, 0xe7, 0x77, 0x4d, 0x7c, 0x9e, 0x9d, 0xcc,
-	0x77, 0x77, 0xef, 0xe7, 0xdd, 0xbb, 0x5f, 0xf0, 0x55, 0x40, 0xef, 0x9a, 0xca, 0x4d, 0x93, 0xff,
-	0x73, 0x70, 0xad, 0x8e, 0x39, 0xc2, 0x7d, 0x5d, 0x0b, 0x8a, 0x1b, 0x48, 0xe5, 0x95, 0x3e, 0xeb,
-	0xb4, 0x20, 0x93, 0x96, 0x73, 0x8a, 0x57, 0xcb, 0x8b, 0x78, 0xde, 0xdf, 0xff, 0x19, 0xb6, 0xf6,
-	0x87, 0xa1, 0xf8, 0x71, 0x18, 0x8a, 0xdf, 0x87, 0xa1, 0x58, 0x76, 0x0c, 0xf5, 0xc5, 0xbf, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0xde, 0x36, 0xea, 0xdd, 0x71, 0x03, 0x00, 0x00,
+	// 630 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x4d, 0x6f, 0xd3, 0x4c,
+	0x10, 0xae, 0x1d, 0x27, 0x8e, 0x27, 0x6f, 0xd2, 0x68, 0x54, 0xbd, 0x2c, 0x91, 0x48, 0xa2, 0x9c,
+	0x22, 0x51, 0xb9, 0x52, 0xb9, 0x20, 0x28, 0x07, 0xa7, 0x44, 0x50, 0x89, 0xf2, 0x61, 0x5a, 0x89,
+	0x5b, 0xb4, 0x89, 0x97, 0xc4, 0x22, 0xfe, 0xd0, 0x7a, 0x5d, 0xea, 0x13, 0xfc, 0x04, 0x8e, 0x9c,
+	0xf9, 0x35, 0x3d, 0xf2, 0x0b, 0xf8, 0xc8, 0x91, 0x5f, 0x81, 0xbc, 0x5e, 0x07, 0x4a, 0x84, 0x50,
+	0x6e, 0x3b, 0x33, 0xcf, 0xb3, 0x33, 0xcf, 0xb3, 0xb3, 0xd0, 0x4c, 0xfc, 0x25, 0x0b, 0x67, 0xcc,
+	0x8e, 0x79, 0x24, 0x22, 0xb4, 0x54, 0x18, 0x4f, 0x3b, 0xdd, 0x79, 0x14, 0xcd, 0x97, 0xec, 0x40,
+	0x16, 0xa6, 0xe9, 0xeb, 0x03, 0x2f, 0xe5, 0x54, 0xf8, 0x51, 0x58, 0x40, 0x3b, 0xbd, 0x3f, 0xeb,
+	0xc2, 0x0f, 0x58, 0x22, 0x68, 0x10, 0x2b, 0xc0, 0xde, 0x3c, 0x9a, 0x47, 0xf2, 0x78, 0x90, 0x9f,
+	0x8a, 0xec, 0xe0, 0x93, 0x06, 0xe6, 0x29, 0x15, 0xb3, 0x05, 0xe3, 0x78, 0x1b, 0x0c, 0x91, 0xc5,
+	0x8c, 0x68, 0x7d, 0x6d, 0xd8, 0x3a, 0xbc, 0x61, 0xaf, 0x9b, 0xdb, 0x0a, 0x61, 0x9f, 0x65, 0x31,
+	0x73, 0x25, 0x08, 0x11, 0x8c, 0x90, 0x06, 0x8c, 0xe8, 0x7d, 0x6d, 0x68, 0xb9, 0xf2, 0x8c, 0x04,
+	0xcc, 0x98, 0x0a, 0xc1, 0x78, 0x48, 0x2a, 0x32, 0x5d, 0x86, 0x83, 0x23, 0x30, 0x72, 0x2e, 0x5a,
+	0x50, 0x1d, 0xbf, 0x38, 0x77, 0x9e, 0xb4, 0x77, 0x10, 0xa0, 0xe6, 0x8e, 0x1f, 0x8d, 0x5f, 0x3d,
+	0x6f, 0x6b, 0xd8, 0x04, 0xeb, 0xe9, 0xb3, 0xb3, 0x49, 0x51, 0xd2, 0xb1, 0x05, 0x90, 0x87, 0xaa,
+	0x5c, 0x19, 0xbc, 0x03, 0xf3, 0x38, 0x0a, 0x02, 0x16, 0x0a, 0xfc, 0x1f, 0x6a, 0x34, 0x15, 0x8b,
+	0x88, 0xcb, 0x29, 0x2d, 0x57, 0x45, 0x79, 0xeb, 0x59, 0x01, 0x51, 0x13, 0x95, 0x21, 0x8e, 0xc0,
+	0x5a, 0x5b, 0x21, 0xc7, 0x6a, 0x1c, 0x76, 0xec, 0xc2, 0x2c, 0xbb, 0x34, 0xcb, 0x3e, 0x2b, 0x11,
+	0xa3, 0xfa, 0xd5, 0x97, 0xde, 0xce, 0x87, 0xaf, 0x3d, 0xcd, 0xfd, 0x45, 0x1b, 0xfc, 0xa8, 0x82,
+	0xf9, 0xb2, 0x70, 0x03, 0x5b, 0xa0, 0xfb, 0x9e, 0xea, 0xae, 0xfb, 0x1e, 0xda, 0x50, 0x0f, 0x0a,
+	0x7b, 0x12, 0xa2, 0xf7, 0x2b, 0xc3, 0xc6, 0x21, 0x6e, 0x3a, 0xe7, 0xae, 0x31, 0xe8, 0x80, 0x95,
+	0x08, 0xca, 0x45, 0x32, 0xa1, 0x62, 0xab, 0x79, 0xea, 0x05, 0xcd, 0x11, 0xf8, 0x00, 0x4c, 0x16,
+	0x7a, 0xf2, 0x02, 0x63, 0x8b, 0x0b, 0x6a, 0x39, 0xc9, 0x11, 0x78, 0x0c, 0x90, 0xc6, 0x1e, 0x15,
+	0xcc, 0xcb, 0x6f, 0xa8, 0x6e, 0x63, 0x89, 0xe2, 0x39, 0x22, 0x97, 0xad, 0x1c, 0x4e, 0x88, 0xb9,
+	0x21, 0x5b, 0x3d, 0x97, 0xbb, 0xc6, 0xe0, 0x2d, 0x80, 0x19, 0x67, 0xb2, 0xe9, 0x34, 0x23, 0x75,
+	0x69, 0x9f, 0xa5, 0x32, 0xa3, 0xec, 0xf7, 0xf7, 0xb3, 0xae, 0xbf, 0x1f, 0x01, 0xf3, 0x82, 0xf1,
+	0xc4, 0x8f, 0x42, 0x02, 0x7d, 0x6d, 0x68, 0xb8, 0x65, 0x88, 0xfb, 0x60, 0x2e, 0xfc, 0x44, 0x44,
+	0x3c, 0x23, 0x8d, 0x8d, 0x09, 0xd4, 0x73, 0xb9, 0x25, 0x04, 0x1f, 0xc3, 0x2e, 0x67, 0x21, 0x7b,
+	0x3b, 0xf1, 0xc3, 0x19, 0x67, 0xb2, 0xd3, 0x7f, 0x52, 0xfa, 0xcd, 0x0d, 0xe9, 0x0f, 0xd5, 0xd7,
+	0x1a, 0x19, 0x1f, 0x73, 0xd5, 0x2d, 0xc9, 0x3b, 0x29, 0x69, 0xe8, 0x40, 0xa3, 0xb8, 0x29, 0x0d,
+	0x85, 0xbf, 0x24, 0xcd, 0x7f, 0x1a, 0x68, 0x48, 0xf3, 0x40, 0x92, 0xce, 0x73, 0x0e, 0xf6, 0xa0,
+	0xc1, 0x2e, 0xf3, 0x9f, 0x41, 0x97, 0x13, 0xdf, 0x23, 0x2d, 0x29, 0x19, 0xca, 0xd4, 0x89, 0x87,
+	0x47, 0x50, 0x0f, 0x98, 0xa0, 0x1e, 0x15, 0x94, 0xec, 0x4a, 0x71, 0xfd, 0x4d, 0x71, 0xf6, 0xa9,
+	0x82, 0x8c, 0x43, 0xc1, 0x33, 0x77, 0xcd, 0xe8, 0xdc, 0x87, 0xe6, 0xb5, 0x12, 0xb6, 0xa1, 0xf2,
+	0x86, 0x65, 0x6a, 0x6b, 0xf3, 0x23, 0xee, 0x41, 0xf5, 0x82, 0x2e, 0xd3, 0xf2, 0x03, 0x17, 0xc1,
+	0x3d, 0xfd, 0xae, 0x36, 0x78, 0xaf, 0x41, 0xe3, 0x94, 0x25, 0x8b, 0x72, 0xe1, 0xf7, 0xc1, 0x54,
+	0x9d, 0x25, 0xff, 0x2f, 0x36, 0xab, 0x54, 0xbe, 0x5c, 0xec, 0x32, 0xf6, 0x39, 0x93, 0xeb, 0xa9,
+	0x6f, 0xb3, 0x5c, 0x8a, 0xe7, 0x88, 0x51, 0xfb, 0xea, 0x7b, 0x77, 0xe7, 0x6a, 0xd5, 0xd5, 0x3e,
+	0xaf, 0xba, 0xda, 0xb7, 0x55, 0x57, 0x9b, 0xd6, 0x24, 0xf5, 0xce, 0xcf, 0x01, 0x00, 0x54, 0x9d,
+	0xc3, 0x07, 0x21, 0x05, 0x00, 0x00,
 }
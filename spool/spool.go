@@ -0,0 +1,357 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spool keeps notifications that failed after all retries on disk so
+// that they can be replayed once the integration recovers.
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/storage"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// Entry is a notification of an aggregation group that an integration of a
+// receiver failed to send.
+type Entry struct {
+	Receiver    string         `json:"receiver"`
+	Integration string         `json:"integration"`
+	Idx         int            `json:"idx"`
+	GroupKey    string         `json:"groupKey"`
+	GroupLabels model.LabelSet `json:"groupLabels"`
+	Firing      []uint64       `json:"firing,omitempty"`
+	Resolved    []uint64       `json:"resolved,omitempty"`
+	Alerts      []*types.Alert `json:"alerts"`
+	SpooledAt   time.Time      `json:"spooledAt"`
+
+	size int
+}
+
+// Key identifies the aggregation group and integration of the entry. A
+// newer notification replaces the spooled entry with the same key.
+func (e *Entry) Key() string {
+	return fmt.Sprintf("%s/%s/%d/%s", e.Receiver, e.Integration, e.Idx, e.GroupKey)
+}
+
+// Validate returns an error if the entry is invalid.
+func (e *Entry) Validate() error {
+	if e.Receiver == "" || e.Integration == "" {
+		return errors.New("receiver or integration missing")
+	}
+	if e.GroupKey == "" {
+		return errors.New("group key missing")
+	}
+	if len(e.Alerts) == 0 {
+		return errors.New("alerts missing")
+	}
+	if e.SpooledAt.IsZero() {
+		return errors.New("timestamp missing")
+	}
+	return nil
+}
+
+// Spool holds the failed notifications keyed by aggregation group and
+// integration.
+type Spool struct {
+	logger log.Logger
+	now    func() time.Time
+
+	mtx     sync.RWMutex
+	entries map[string]*Entry
+
+	spooled *prometheus.CounterVec
+	dropped *prometheus.CounterVec
+}
+
+// Options exposes configuration options for creating a new Spool object.
+type Options struct {
+	// A snapshot file from which the initial state is loaded.
+	SnapshotFile string
+
+	// A logger used by background processing.
+	Logger  log.Logger
+	Metrics prometheus.Registerer
+}
+
+// New returns a new Spool object with the given configuration.
+func New(o Options) (*Spool, error) {
+	s := &Spool{
+		logger:  log.NewNopLogger(),
+		now:     utcNow,
+		entries: map[string]*Entry{},
+		spooled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_notification_spool_added_total",
+			Help: "How many failed notifications were spooled.",
+		}, []string{"receiver"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_notification_spool_dropped_total",
+			Help: "How many spooled notifications were dropped because they exceeded the age or size of the spool.",
+		}, []string{"receiver"}),
+	}
+	if o.Logger != nil {
+		s.logger = o.Logger
+	}
+	if o.Metrics != nil {
+		o.Metrics.MustRegister(s.spooled, s.dropped, &depthCollector{s: s})
+	}
+
+	if o.SnapshotFile != "" {
+		b, err := ioutil.ReadFile(o.SnapshotFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			var entries []*Entry
+			if err := json.Unmarshal(b, &entries); err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if err := e.Validate(); err != nil {
+					return nil, err
+				}
+				if err := e.setSize(); err != nil {
+					return nil, err
+				}
+				s.entries[e.Key()] = e
+			}
+		}
+	}
+	return s, nil
+}
+
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+func (e *Entry) setSize() error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	e.size = len(b)
+	return nil
+}
+
+// Add spools the notification and replaces an older one of the same
+// aggregation group and integration.
+func (s *Spool) Add(e *Entry) error {
+	if e.SpooledAt.IsZero() {
+		e.SpooledAt = s.now()
+	}
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if err := e.setSize(); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	s.entries[e.Key()] = e
+	s.mtx.Unlock()
+
+	s.spooled.WithLabelValues(e.Receiver).Inc()
+	return nil
+}
+
+// Remove removes the spooled notification with the key, e.g. after a newer
+// notification of the aggregation group was sent.
+func (s *Spool) Remove(key string) {
+	s.mtx.Lock()
+	delete(s.entries, key)
+	s.mtx.Unlock()
+}
+
+// Done removes the entry after it was replayed unless it was replaced in
+// the meantime.
+func (s *Spool) Done(e *Entry) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.entries[e.Key()] == e {
+		delete(s.entries, e.Key())
+	}
+}
+
+// Entries returns the spooled notifications, oldest first.
+func (s *Spool) Entries() []*Entry {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].SpooledAt.Equal(res[j].SpooledAt) {
+			return res[i].Key() < res[j].Key()
+		}
+		return res[i].SpooledAt.Before(res[j].SpooledAt)
+	})
+	return res
+}
+
+// Truncate drops the notifications of the receiver that were spooled longer
+// than maxAge ago and then the oldest ones until the notifications of the
+// receiver fit into maxSize bytes. It returns the number of dropped
+// notifications.
+func (s *Spool) Truncate(receiver string, maxAge time.Duration, maxSize int64) int {
+	var (
+		entries []*Entry
+		size    int64
+		oldest  = s.now().Add(-maxAge)
+	)
+	for _, e := range s.Entries() {
+		if e.Receiver == receiver {
+			entries = append(entries, e)
+			size += int64(e.size)
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var n int
+	for _, e := range entries {
+		if !e.SpooledAt.Before(oldest) && size <= maxSize {
+			break
+		}
+		size -= int64(e.size)
+		if s.entries[e.Key()] == e {
+			delete(s.entries, e.Key())
+			n++
+		}
+	}
+	if n > 0 {
+		s.dropped.WithLabelValues(receiver).Add(float64(n))
+	}
+	return n
+}
+
+// Drop drops all notifications of receivers for which keep returns false,
+// e.g. because spooling was disabled for them. It returns the number of
+// dropped notifications.
+func (s *Spool) Drop(keep func(receiver string) bool) int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var n int
+	for k, e := range s.entries {
+		if !keep(e.Receiver) {
+			delete(s.entries, k)
+			s.dropped.WithLabelValues(e.Receiver).Inc()
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot writes the spooled notifications into the writer and returns the
+// number of bytes written.
+func (s *Spool) Snapshot(w io.Writer) (int64, error) {
+	b, err := json.Marshal(s.Entries())
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Maintenance snapshots the spooled notifications to the file at the given
+// interval until stopc is closed.
+func (s *Spool) Maintenance(interval time.Duration, snapf string, stopc <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	f := func() error {
+		f, err := storage.OpenReplace(snapf)
+		if err != nil {
+			return err
+		}
+		if _, err := s.Snapshot(f); err != nil {
+			f.Abort()
+			return err
+		}
+		return f.Close()
+	}
+
+Loop:
+	for {
+		select {
+		case <-stopc:
+			break Loop
+		case <-t.C:
+			if err := f(); err != nil {
+				level.Info(s.logger).Log("msg", "Running maintenance failed", "err", err)
+			}
+		}
+	}
+	if err := f(); err != nil {
+		level.Info(s.logger).Log("msg", "Creating shutdown snapshot failed", "err", err)
+	}
+}
+
+// depthCollector exposes the number and size of the spooled notifications
+// per receiver.
+type depthCollector struct {
+	s *Spool
+}
+
+var (
+	depthEntriesDesc = prometheus.NewDesc(
+		"alertmanager_notification_spool_entries",
+		"How many failed notifications are spooled.",
+		[]string{"receiver"}, nil,
+	)
+	depthBytesDesc = prometheus.NewDesc(
+		"alertmanager_notification_spool_bytes",
+		"Size of the spooled notifications in bytes.",
+		[]string{"receiver"}, nil,
+	)
+)
+
+func (c *depthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- depthEntriesDesc
+	ch <- depthBytesDesc
+}
+
+func (c *depthCollector) Collect(ch chan<- prometheus.Metric) {
+	var (
+		entries = map[string]int{}
+		size    = map[string]int{}
+	)
+	c.s.mtx.RLock()
+	for _, e := range c.s.entries {
+		entries[e.Receiver]++
+		size[e.Receiver] += e.size
+	}
+	c.s.mtx.RUnlock()
+
+	for rcv, n := range entries {
+		ch <- prometheus.MustNewConstMetric(depthEntriesDesc, prometheus.GaugeValue, float64(n), rcv)
+		ch <- prometheus.MustNewConstMetric(depthBytesDesc, prometheus.GaugeValue, float64(size[rcv]), rcv)
+	}
+}
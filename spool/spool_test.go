@@ -0,0 +1,124 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spool
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/types"
+)
+
+func testEntry(receiver, gkey string, spooledAt time.Time) *Entry {
+	return &Entry{
+		Receiver:    receiver,
+		Integration: "webhook",
+		GroupKey:    gkey,
+		GroupLabels: model.LabelSet{"alertname": "Test"},
+		Firing:      []uint64{1},
+		Alerts: []*types.Alert{{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "Test"},
+				StartsAt: spooledAt,
+			},
+		}},
+		SpooledAt: spooledAt,
+	}
+}
+
+func TestSpoolAdd(t *testing.T) {
+	s, err := New(Options{})
+	require.NoError(t, err)
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	a := testEntry("team-X", "{}:{a}", now)
+	b := testEntry("team-X", "{}:{b}", now.Add(-time.Minute))
+	require.NoError(t, s.Add(a))
+	require.NoError(t, s.Add(b))
+	require.Equal(t, []*Entry{b, a}, s.Entries())
+
+	// A newer notification of the group replaces the spooled one.
+	a2 := testEntry("team-X", "{}:{a}", now.Add(time.Minute))
+	require.NoError(t, s.Add(a2))
+	require.Equal(t, []*Entry{b, a2}, s.Entries())
+
+	// Replayed entries are not removed if they were replaced.
+	s.Done(a)
+	require.Len(t, s.Entries(), 2)
+	s.Done(a2)
+	s.Remove(b.Key())
+	require.Len(t, s.Entries(), 0)
+
+	require.Error(t, s.Add(&Entry{Receiver: "team-X", Integration: "webhook", GroupKey: "{}:{}"}))
+}
+
+func TestSpoolTruncate(t *testing.T) {
+	s, err := New(Options{})
+	require.NoError(t, err)
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	old := testEntry("team-X", "{}:{old}", now.Add(-2*time.Hour))
+	a := testEntry("team-X", "{}:{a}", now.Add(-30*time.Minute))
+	b := testEntry("team-X", "{}:{b}", now.Add(-20*time.Minute))
+	other := testEntry("team-Y", "{}:{old}", now.Add(-2*time.Hour))
+	for _, e := range []*Entry{old, a, b, other} {
+		require.NoError(t, s.Add(e))
+	}
+
+	// Entries older than the maximum age are dropped.
+	require.Equal(t, 1, s.Truncate("team-X", time.Hour, 1<<20))
+	require.Equal(t, []*Entry{other, a, b}, s.Entries())
+
+	// The oldest entries are dropped until the receiver fits its size.
+	require.Equal(t, 1, s.Truncate("team-X", time.Hour, int64(b.size)))
+	require.Equal(t, []*Entry{other, b}, s.Entries())
+
+	require.Equal(t, 1, s.Drop(func(receiver string) bool { return receiver == "team-X" }))
+	require.Equal(t, []*Entry{b}, s.Entries())
+}
+
+func TestSpoolSnapshot(t *testing.T) {
+	f, err := ioutil.TempFile("", "spool")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	s, err := New(Options{})
+	require.NoError(t, err)
+
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.Add(testEntry("team-X", "{}:{a}", now)))
+	require.NoError(t, s.Add(testEntry("team-Y", "{}:{b}", now)))
+
+	_, err = s.Snapshot(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s2, err := New(Options{SnapshotFile: f.Name()})
+	require.NoError(t, err)
+	require.Len(t, s2.Entries(), 2)
+	for i, e := range s2.Entries() {
+		exp := s.Entries()[i]
+		require.Equal(t, exp.Key(), e.Key())
+		require.Equal(t, exp.size, e.size)
+		require.Equal(t, exp.Alerts[0].Labels, e.Alerts[0].Labels)
+		require.True(t, exp.SpooledAt.Equal(e.SpooledAt))
+	}
+}
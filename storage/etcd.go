@@ -0,0 +1,117 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// EtcdConfig configures an etcd cluster.
+type EtcdConfig struct {
+	// Endpoints are the client URLs of the etcd members, which are tried
+	// in order.
+	Endpoints []string
+	// Prefix is prepended to the keys.
+	Prefix string
+}
+
+// Etcd stores snapshots as values in etcd. It uses the JSON gateway of the
+// v3 API. The size of a snapshot is limited by the maximum request size of
+// the etcd cluster.
+type Etcd struct {
+	conf   EtcdConfig
+	client *http.Client
+}
+
+// NewEtcd returns a backend storing snapshots in the configured etcd cluster.
+func NewEtcd(conf EtcdConfig, client *http.Client) *Etcd {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Etcd{conf: conf, client: client}
+}
+
+// etcdKeyValue is a key-value pair of the JSON gateway. Byte slices are
+// base64-encoded in JSON as expected by the gateway.
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type etcdRangeResponse struct {
+	KVs []etcdKeyValue `json:"kvs"`
+}
+
+// post sends the request to the endpoints until one of them responds.
+func (e *Etcd) post(ctx context.Context, path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if len(e.conf.Endpoints) == 0 {
+		return fmt.Errorf("no etcd endpoints configured")
+	}
+	for _, ep := range e.conf.Endpoints {
+		err = e.postEndpoint(ctx, strings.TrimSuffix(ep, "/")+path, body, resp)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	return err
+}
+
+func (e *Etcd) postEndpoint(ctx context.Context, url string, body []byte, v interface{}) error {
+	resp, err := ctxhttp.Post(ctx, e.client, url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("unexpected status code %v from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Get implements the Backend interface.
+func (e *Etcd) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	var resp etcdRangeResponse
+	if err := e.post(ctx, "/v3/kv/range", etcdKeyValue{Key: []byte(e.conf.Prefix + key)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.KVs) == 0 {
+		return nil, ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(resp.KVs[0].Value)), nil
+}
+
+// Put implements the Backend interface.
+func (e *Etcd) Put(ctx context.Context, key string, r io.Reader) error {
+	value, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var resp struct{}
+	return e.post(ctx, "/v3/kv/put", etcdKeyValue{Key: []byte(e.conf.Prefix + key), Value: value}, &resp)
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+
+	"github.com/prometheus/alertmanager/pkg/sigv4"
+)
+
+// S3Config configures a bucket of an S3-compatible object storage.
+type S3Config struct {
+	// Endpoint is the base URL of the object storage, for example
+	// https://s3.eu-west-1.amazonaws.com. Buckets are addressed by path.
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to the keys to form the object names.
+	Prefix string
+	Region string
+	// Credentials the requests are signed with.
+	Credentials sigv4.Credentials
+}
+
+// S3 stores snapshots as objects in an S3-compatible object storage.
+type S3 struct {
+	conf   S3Config
+	client *http.Client
+}
+
+// NewS3 returns a backend storing snapshots in the configured bucket.
+func NewS3(conf S3Config, client *http.Client) *S3 {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{conf: conf, client: client}
+}
+
+func (s *S3) url(key string) string {
+	return fmt.Sprintf("%s/%s/%s%s", strings.TrimSuffix(s.conf.Endpoint, "/"), s.conf.Bucket, s.conf.Prefix, key)
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// S3 requires the hash of the payload to be sent along.
+	h := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(h[:]))
+	sigv4.Sign(req, body, s.conf.Credentials, s.conf.Region, "s3", time.Now())
+
+	return ctxhttp.Do(ctx, s.client, req)
+}
+
+// Get implements the Backend interface.
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, "GET", key, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	defer resp.Body.Close()
+	return nil, fmt.Errorf("getting object %q: unexpected status code %v", key, resp.StatusCode)
+}
+
+// Put implements the Backend interface.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, "PUT", key, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("putting object %q: unexpected status code %v", key, resp.StatusCode)
+	}
+	return nil
+}
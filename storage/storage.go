@@ -0,0 +1,113 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage provides backends the state snapshots of the silences and
+// the notification log are persisted in.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNotFound is returned by Get if no snapshot is stored under the key.
+var ErrNotFound = errors.New("not found")
+
+// Backend stores snapshots under keys.
+type Backend interface {
+	// Get returns the snapshot stored under the key. The caller must close
+	// the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put atomically replaces the snapshot stored under the key.
+	Put(ctx context.Context, key string, r io.Reader) error
+}
+
+// File stores snapshots as files in a local directory.
+type File struct {
+	dir string
+}
+
+// NewFile returns a backend storing snapshots in the directory.
+func NewFile(dir string) *File {
+	return &File{dir: dir}
+}
+
+// Get implements the Backend interface.
+func (f *File) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	r, err := os.Open(filepath.Join(f.dir, key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// Put implements the Backend interface. The snapshot is written to a
+// temporary file that is moved into place afterwards.
+func (f *File) Put(_ context.Context, key string, r io.Reader) error {
+	rf, err := OpenReplace(filepath.Join(f.dir, key))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(rf, r); err != nil {
+		rf.Abort()
+		return err
+	}
+	return rf.Close()
+}
+
+// ReplaceFile is a temporary file that atomically replaces another file on
+// closing, so that readers never see a partially written file.
+type ReplaceFile struct {
+	*os.File
+	filename string
+}
+
+// OpenReplace creates a temporary file that replaces filename on closing.
+func OpenReplace(filename string) (*ReplaceFile, error) {
+	f, err := os.Create(fmt.Sprintf("%s.%x", filename, uint64(rand.Int63())))
+	if err != nil {
+		return nil, err
+	}
+	return &ReplaceFile{File: f, filename: filename}, nil
+}
+
+// Close syncs the temporary file to disk and moves it to the filename. The
+// temporary file is removed if that fails.
+func (f *ReplaceFile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.filename); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort closes and removes the temporary file, leaving the filename as it
+// was.
+func (f *ReplaceFile) Abort() {
+	f.File.Close()
+	os.Remove(f.File.Name())
+}
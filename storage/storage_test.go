@@ -0,0 +1,185 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+
+	"github.com/prometheus/alertmanager/pkg/sigv4"
+)
+
+// testBackend checks that a backend returns what was put into it.
+func testBackend(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	_, err := b.Get(ctx, "silences")
+	require.Equal(t, ErrNotFound, err)
+
+	for _, content := range []string{"first", "second"} {
+		require.NoError(t, b.Put(ctx, "silences", strings.NewReader(content)))
+
+		r, err := b.Get(ctx, "silences")
+		require.NoError(t, err)
+		res, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, content, string(res))
+	}
+
+	_, err = b.Get(ctx, "nflog")
+	require.Equal(t, ErrNotFound, err)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testBackend(t, NewFile(dir))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files), "temporary files must be moved into place")
+}
+
+func TestOpenReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "acks")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("old"), 0666))
+
+	f, err := OpenReplace(filename)
+	require.NoError(t, err)
+	_, err = f.WriteString("partial")
+	require.NoError(t, err)
+	f.Abort()
+
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "old", string(b), "aborted file must not replace the old one")
+
+	f, err = OpenReplace(filename)
+	require.NoError(t, err)
+	_, err = f.WriteString("new")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b, err = ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(b))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files), "temporary files must be removed")
+}
+
+func TestS3(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		objects = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case "GET":
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(b)
+		case "PUT":
+			b, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Path] = b
+		}
+	}))
+	defer srv.Close()
+
+	testBackend(t, NewS3(S3Config{
+		Endpoint:    srv.URL + "/",
+		Bucket:      "bucket",
+		Prefix:      "am/",
+		Region:      "us-east-1",
+		Credentials: sigv4.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+	}, nil))
+
+	_, ok := objects["/bucket/am/silences"]
+	require.True(t, ok, "object must be stored with bucket and prefix")
+}
+
+func TestEtcd(t *testing.T) {
+	var (
+		mtx sync.Mutex
+		kvs = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		var req etcdKeyValue
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			var resp etcdRangeResponse
+			if v, ok := kvs[string(req.Key)]; ok {
+				resp.KVs = append(resp.KVs, etcdKeyValue{Key: req.Key, Value: v})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/v3/kv/put":
+			kvs[string(req.Key)] = req.Value
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	// The first endpoint is unreachable and must be skipped.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	testBackend(t, NewEtcd(EtcdConfig{
+		Endpoints: []string{down.URL, srv.URL},
+		Prefix:    "am/",
+	}, nil))
+
+	require.True(t, bytes.Equal([]byte("second"), kvs["am/silences"]), "value must be stored under the prefixed key")
+}
@@ -68,7 +68,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _templateDefaultTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x1b\xff\x4f\xdb\xb8\xfe\xf7\xfc\x15\x9f\xcb\xe9\xe9\x86\xd4\x36\x85\xdd\x4d\x47\x69\x79\xea\x4a\x18\xd1\x2b\x29\x4a\xc3\x76\xd3\xe9\x84\xdc\xc4\x6d\xbd\x25\x76\xce\x76\x28\x3d\xd6\xff\xfd\xc9\x4e\xfa\x25\x34\x85\x82\x76\xd0\xf7\x0e\xd0\x46\xe2\xf8\xf3\xfd\xab\x63\xe7\xf6\x16\x42\x3c\x24\x14\x83\x79\x75\x85\x22\xcc\x65\x8c\x28\x1a\x61\x6e\xc2\x6c\xd6\x56\xf7\xe7\xd9\xfd\xed\x2d\x60\x1a\xc2\x6c\x66\x6c\x04\xb9\xf4\xba\x0a\xea\xf6\x16\x6a\xf6\x8d\xc4\x9c\xa2\xe8\xd2\xeb\xc2\x6c\x66\xfd\x68\xe9\x79\xe2\xdf\x1c\x07\x98\x5c\x63\xde\x52\x93\xbc\xfc\x26\x83\xc9\xb1\x17\xd1\x8b\x74\xf0\x05\x07\x52\xa1\xfd\x5d\x81\xf4\x25\x92\xa9\x80\x6f\x20\xd9\x65\x92\xcc\x41\xc9\x10\xf0\x9f\x8b\x87\xe6\x90\x70\x42\x47\x0a\xa6\xa1\x60\xb4\x14\xa2\x76\xaa\x47\xe1\x1b\x44\x98\xae\x52\xfc\x03\xd4\xa4\x0f\x9c\xa5\x49\x17\x0d\x70\x24\x6a\x7d\xc6\x25\x0e\x2f\x10\xe1\xa2\xf6\x11\x45\x29\x56\x04\xbf\x30\x42\xc1\x04\x85\x15\x32\x92\x23\x09\x6f\x14\xae\x5a\x87\xc5\x31\xa3\x19\xf0\x5e\x3e\xb6\x82\x6f\x0f\x66\xb3\x37\xb7\xb7\x30\x21\x72\x5c\x9c\x5c\xf3\x70\xcc\xae\x71\x91\xba\x8b\x62\x2c\x72\x35\x96\x51\x5f\x30\xbe\xb7\xb8\xda\x60\x9b\x10\x8b\x80\x93\x44\x12\x46\xcd\x7b\x74\x2c\xf1\x8d\xcc\xec\x78\x15\x11\x21\xf3\xa9\x1c\xd1\x11\x86\x1a\xcc\x66\x19\x5f\x0d\x63\x39\xb8\xae\x27\xa5\x95\xaa\x56\xa4\x62\x5f\xdd\xb5\x60\x21\x40\xce\x58\x46\xbc\x4d\x29\x93\x48\xf1\x54\x40\xb9\x32\xfc\x34\xbc\x7d\x96\xf2\x00\x37\x32\x63\x62\x8a\x39\x92\x8c\x67\xee\x67\x94\x28\xaa\xa0\x03\x11\xa1\xe0\x6b\x2d\xc4\x43\x94\x46\xb2\x26\x89\x8c\x70\xae\x05\x89\xe3\x24\x42\xb2\xe8\x8b\xb5\x4d\x2a\x2f\xe2\x49\x85\x0a\x81\xb8\x0c\x55\x31\xd0\xb6\xc4\x37\x44\x51\x34\x40\xc1\xd7\x35\x7c\xa5\xec\x2b\xa4\xf0\x0d\x1e\x9a\x18\x11\xfa\x75\x6b\x0e\x12\x8e\x95\xb3\x98\xdb\xcd\x5e\xc1\x7f\xaf\x02\x74\xda\xd8\x92\x03\x12\x30\x8a\x63\xf6\x85\x6c\xc9\x83\x9a\x9f\xf2\x68\x5b\x8e\xb7\x17\x6e\xc8\x98\xcc\x92\xe4\x06\x9f\x1a\x93\x24\x18\x23\xb9\x04\xe0\x2c\x7e\xba\x27\xdc\xc5\x16\x63\x21\xd0\xe8\x11\x5e\x5a\xe0\x2d\x51\xd4\xc2\x54\x4e\x17\xf8\xd6\x53\xc5\xe3\x3c\x7f\x1d\x63\x10\x11\x4c\xe5\xd3\x25\xde\x84\x71\x59\x64\x9e\xe6\x4f\xeb\x78\x09\x15\x12\xd1\x00\x8b\x12\xbc\x6b\xb9\xf1\x1e\xad\xb2\x44\x8c\x30\x25\xf8\xe9\x46\xba\x0f\xd9\xba\x85\xf2\x52\xb2\x21\x73\x96\xd6\x0e\xe3\x4e\xe5\x2a\x94\xc6\x3d\xa8\x43\x75\x36\x33\xb2\x41\xc8\x06\x75\x8e\xbe\x5f\x23\xc5\xfa\xaa\x89\x54\x57\x24\x2a\xa1\xe7\x61\xc1\xa2\x6b\x1c\xde\xa1\x38\x1f\xde\x9e\xe6\x1c\x62\x8d\x6a\x75\x1b\x95\x0a\x5d\x32\x1e\xef\x4d\x05\xab\x4f\xf0\x53\x02\xd3\x78\xb5\xdf\x3d\xf6\x6b\xaf\xea\x9f\x47\x6b\xf8\x4a\xed\xb3\x8a\xa0\x68\xa2\x6b\x12\x48\xc6\x59\x22\x96\x96\x97\x48\xe2\xab\xa2\xad\x5e\xcd\xf1\xb8\x70\x5a\xd7\x2a\xa6\x92\xc8\xe9\x55\x48\x44\x12\xa1\xe9\xd5\x86\xde\xe7\xe1\xdc\xb7\x8e\x39\x66\x94\x48\xa6\x14\x72\x25\x19\x8b\x1e\x59\x55\x56\x71\xe3\x18\x91\x68\xe9\x07\xcb\xe5\xc5\xa3\xb9\x2c\x62\x1a\xcb\x58\xb3\x65\x34\x7f\x38\xe9\x75\xfc\xcf\x17\x36\xa8\x21\xb8\xb8\x7c\xdf\x75\x3a\x60\x56\x2d\xeb\xd3\xdb\x8e\x65\x9d\xf8\x27\xf0\xdb\x99\x7f\xde\x85\xfd\x5a\x1d\x7c\x8e\xa8\x20\xca\xd9\x50\x64\x59\xb6\x6b\x82\x39\x96\x32\x69\x58\xd6\x64\x32\xa9\x4d\xde\xd6\x18\x1f\x59\xbe\x67\xdd\x28\x5c\xfb\x0a\x38\xbf\xac\xca\x15\xc8\x5a\x28\x43\xf3\xd8\x68\xfe\x50\xad\x1a\x7d\x39\x8d\x30\x20\x1a\x82\x26\x12\x62\x4e\x94\x41\x55\xf7\x01\x0a\xb5\x68\x58\xd6\x88\xc8\x71\x3a\xa8\x05\x2c\xb6\x94\x0c\xa3\x94\x5a\x1a\x1d\x0a\x32\x7c\x55\x2d\x5a\x75\xae\x0e\x61\x18\x86\x3f\xc6\x70\xee\xf8\xd0\x25\x01\xa6\x02\xc3\x9b\x73\xc7\xdf\x33\x8c\x0e\x4b\xa6\x9c\x8c\xc6\x12\xde\x04\x7b\x70\x50\xdf\xff\x19\xce\x33\x8c\x86\x71\x81\x79\x4c\x84\x20\x8c\x02\x11\x30\xc6\x1c\x0f\xa6\x30\xe2\x88\x4a\x1c\x56\x60\xc8\x31\x06\x36\x84\x60\x8c\xf8\x08\x57\x40\x32\x40\x74\x0a\x09\xe6\x82\x51\x60\x03\x89\x08\x55\xfe\x8f\x20\x60\xc9\xd4\x60\x43\x90\x63\x22\x40\xb0\xa1\x9c\x20\x9e\x49\x88\x84\x60\x01\x41\x12\x87\x10\xb2\x20\x8d\x31\xcd\x02\x17\x86\x24\xc2\x02\xde\xc8\x31\x06\xb3\x9f\x43\x98\x7b\x9a\x48\x88\x51\x64\x10\x0a\xea\xd9\xfc\x91\x5e\x99\xb1\x54\x02\xc7\x42\x72\xa2\xb5\x50\x01\x42\x83\x28\x0d\x15\x0f\xf3\xc7\x11\x89\x49\x4e\x41\x81\x6b\xc1\x85\x21\x19\xa4\x02\x57\x34\x9f\x15\x88\x59\x48\x86\xea\x2f\xd6\x62\x25\xe9\x20\x22\x62\x5c\x81\x90\x28\xd4\x83\x54\xe2\x0a\x08\x35\xa8\xf5\x58\x51\x72\x58\x8c\x83\xc0\x51\x64\x04\x2c\x21\x58\x80\x96\x75\xc9\x9d\x9e\xa3\x58\x4f\x94\x42\x65\xae\x22\xa1\x46\x26\x63\x16\x17\x25\x21\xc2\x18\xa6\x9c\x12\x31\xc6\x1a\x26\x64\x20\x98\xa6\xa8\xbc\x59\x8d\xa8\xe9\x43\x16\x45\x6c\xa2\x44\x0b\x18\x0d\x49\xbe\x18\xd3\x46\x46\x03\xb5\x20\x0d\x16\x76\xa5\x4c\x92\x20\x53\xb7\x36\x40\xb2\xb4\x6a\xfe\x48\x8c\x51\x14\xc1\x00\xe7\x0a\xc3\x21\x10\x0a\x68\x45\x1c\xae\xc8\xab\x16\x4b\x12\x14\x41\xc2\xb8\xa6\x77\x57\xcc\x9a\x61\xf8\x67\x36\xf4\x7b\xa7\xfe\xa7\xb6\x67\x83\xd3\x87\x0b\xaf\xf7\xd1\x39\xb1\x4f\xc0\x6c\xf7\xc1\xe9\x9b\x15\xf8\xe4\xf8\x67\xbd\x4b\x1f\x3e\xb5\x3d\xaf\xed\xfa\x9f\xa1\x77\x0a\x6d\xf7\x33\xfc\xc7\x71\x4f\x2a\x60\xff\x76\xe1\xd9\xfd\x3e\xf4\x3c\xc3\x39\xbf\xe8\x3a\xf6\x49\x05\x1c\xb7\xd3\xbd\x3c\x71\xdc\x0f\xf0\xfe\xd2\x07\xb7\xe7\x43\xd7\x39\x77\x7c\xfb\x04\xfc\x1e\x28\x82\x39\x2a\xc7\xee\x2b\x64\xe7\xb6\xd7\x39\x6b\xbb\x7e\xfb\xbd\xd3\x75\xfc\xcf\x15\xe3\xd4\xf1\x5d\x85\xf3\xb4\xe7\x41\x1b\x2e\xda\x9e\xef\x74\x2e\xbb\x6d\x0f\x2e\x2e\xbd\x8b\x5e\xdf\x86\xb6\x7b\x02\x6e\xcf\x75\xdc\x53\xcf\x71\x3f\xd8\xe7\xb6\xeb\xd7\xc0\x71\xc1\xed\x81\xfd\xd1\x76\x7d\xe8\x9f\xb5\xbb\x5d\x45\xca\x68\x5f\xfa\x67\x3d\x4f\xf1\x07\x9d\xde\xc5\x67\xcf\xf9\x70\xe6\xc3\x59\xaf\x7b\x62\x7b\x7d\x78\x6f\x43\xd7\x69\xbf\xef\xda\x19\x29\xf7\x33\x74\xba\x6d\xe7\xbc\x02\x27\xed\xf3\xf6\x07\x5b\x43\xf5\xfc\x33\xdb\x33\xd4\xb4\x8c\x3b\xf8\x74\x66\xab\x21\x45\xaf\xed\x42\xbb\xe3\x3b\x3d\x57\x89\xd1\xe9\xb9\xbe\xd7\xee\xf8\x15\xf0\x7b\x9e\xbf\x00\xfd\xe4\xf4\xed\x0a\xb4\x3d\xa7\xaf\x14\x72\xea\xf5\xce\x2b\x86\x52\x67\xef\x54\x4d\x71\x5c\x05\xe7\xda\x19\x16\xa5\x6a\x28\x58\xa4\xe7\xe9\xfb\xcb\xbe\xbd\x40\x08\x27\x76\xbb\xeb\xb8\x1f\xfa\x0a\x58\x89\x38\x9f\x5c\x33\xaa\xd5\x63\xa3\xa9\x53\xe0\x4d\x1c\x51\xd1\x2a\x49\x6c\xfb\x87\x87\x87\x59\x3e\x33\xb7\x9b\x24\x54\x72\x6b\x99\x43\x46\x65\x75\x88\x62\x12\x4d\x1b\xf0\xd3\x19\x8e\xae\xb1\x24\x01\x02\x17\xa7\xf8\xa7\x0a\x2c\x06\x2a\xd0\xe6\x04\x45\x15\x10\x88\x8a\xaa\xc0\x9c\x0c\x8f\x60\xc0\x6e\xaa\x82\xfc\xa5\x6a\x31\x0c\x18\x0f\x31\xaf\x0e\xd8\xcd\x11\x68\xa4\x82\xfc\x85\x1b\xb0\xff\x73\x72\x73\x04\x31\xe2\x23\x42\x1b\x50\x3f\x52\xb9\x75\x8c\x51\xf8\x92\xf4\x63\x2c\x11\xa8\x8a\xda\x32\xaf\x09\x9e\xa8\x28\x32\x55\xf4\x4a\x4c\x65\xcb\x9c\x90\x50\x8e\x5b\x21\xbe\x26\x01\xae\xea\x9b\x97\x53\x16\x58\x73\x76\x95\x31\xab\xf8\xcf\x94\x5c\xb7\xcc\x4e\xc6\x6a\xd5\x9f\x26\x78\x85\x71\xd5\x8a\x58\xca\xb8\x47\xba\x12\x08\x2c\x5b\x97\xfe\x69\xf5\xd7\x17\x66\x5f\xbf\xba\x78\x39\x73\xdf\xd7\x8b\x34\x2d\xcd\xdc\xb1\x61\x34\x2d\xe5\x94\xea\x62\xc0\xc2\x29\x10\x89\x63\x11\xb0\x04\xb7\x4c\x53\xdf\xc8\xa9\xba\xce\x23\x4a\x04\x63\x1c\x23\x1d\x51\xb6\xaa\xee\xe7\xf3\xde\xf7\x59\x85\xac\x4e\xf0\xe0\x2b\x91\xd5\xec\x41\xcc\x98\x1c\x6b\xa0\xac\x36\x10\x24\x70\xb8\x9c\xa4\x7c\x43\x43\x57\x51\xf8\x25\x15\xb2\x01\x94\x51\x7c\x04\x63\xac\x2a\x53\x03\xf6\xeb\xf5\x7f\x1d\x41\x44\x28\xae\x2e\x86\x6a\xef\x70\x7c\x04\x3a\x02\xb2\x09\xf0\x03\x89\x55\xb0\x20\x2a\x8f\x60\x80\x82\xaf\x23\xce\x52\x1a\x56\x03\x16\x31\xde\x80\x1f\x87\xef\xd4\xef\xaa\xfa\x21\x41\x61\xa8\xb9\x52\xde\x30\x18\xe9\x99\x2d\x33\x9f\x69\x2a\x7d\x4b\x34\x78\x6e\xf7\x58\x11\x69\x4b\x39\x4a\x79\x07\x68\x4a\xfe\x82\x79\x0c\x40\x71\xf0\xcc\x99\xf4\x1a\x73\x85\x24\xaa\xa2\x88\x8c\x68\x03\x24\x4b\x8a\x8a\xba\xd6\x0f\x5a\xa6\x64\x89\x79\xdc\xb4\x64\xb8\x64\x34\xcb\xac\xe6\xbb\x7a\xfd\x99\x43\xa5\x94\xe9\x7c\x69\xd5\x80\x41\xc4\x82\xaf\x05\xdf\x8e\xd1\x4d\x35\x77\x92\x77\xf5\x7a\x72\x53\x78\x18\x44\x18\x71\x45\x50\x8e\x0b\xe3\x9b\x02\x65\xa1\x1c\x40\xa9\x64\x77\x42\xa2\xa0\x2d\xad\x28\x80\x66\x48\xae\x9f\xdb\xad\x8a\xf2\xde\x55\xce\xfd\x42\xcc\xf9\x56\x46\xd6\xc1\x9c\xdb\x59\x69\xc2\x84\x00\x47\x51\x3e\xbb\x65\xd6\xb3\x7b\x91\xa0\x60\x7e\xff\xac\x82\xe6\x0f\x39\x0a\x49\x2a\x1a\xf0\x56\x8f\x95\x24\x80\xe1\xb0\x90\xc5\x32\xb0\x06\xec\x27\x37\x20\x58\x44\x42\xf8\x11\x1f\xaa\xdf\x62\x62\x18\x0e\x57\x74\xb1\x0b\xd9\x61\xc9\xc9\xf3\x65\x89\x77\x1b\x03\xae\xa0\x5d\x0d\x32\xc9\x4b\xcd\x2f\xf5\xfa\x11\xe8\x12\x95\xcf\x0f\x30\x95\x98\x97\xd9\x4b\xff\xab\x6b\xa3\xac\xdb\xcd\x7e\xf7\xcb\xc1\x41\xa7\xbc\x00\x1d\x28\xbf\x36\x21\x8f\xb7\x8c\xc0\xaa\xf5\x32\xd8\xf2\x88\x9c\xff\x2c\x77\x40\x17\x5b\x9f\xa0\x5f\x96\x94\xbe\x4b\xda\x83\x7d\x98\xcd\xc4\xe2\x85\x07\x0c\x19\x87\xe5\x2e\xdd\x86\x5d\x52\x98\xcd\xee\x50\x85\xd5\x3d\xbb\x56\x61\xc7\x6e\x6d\x5a\xfe\x6a\xa5\x60\xfc\x45\x0e\x5e\xdc\xf3\x57\x37\xdd\xa6\x98\x2d\x9d\x67\x3f\x73\x9e\xfb\x7c\x63\xe7\x73\xdf\x46\xb5\xef\x96\x13\xec\xba\x2b\xd4\xa1\x3e\xcf\x25\xf7\xb9\x43\x2e\x06\x82\x31\xc7\xc3\x96\xb9\xcd\x4b\xf7\x67\xf6\x87\x79\xd2\x3c\x3d\x3d\xcd\x93\x6f\x88\x03\xc6\xf5\x3b\xb9\xf9\xf2\xa0\xb0\x20\x38\x50\xcb\x81\x42\xde\x1e\xb0\x28\x2c\x4f\xdc\x41\xca\x85\xc2\x9e\x30\x92\x0d\x2c\x1a\x0a\x42\x35\xd2\xbc\xaf\xb8\x93\xe0\x7f\x51\x8c\x69\x7c\xfa\x25\xea\x90\xf1\xb8\x01\x01\x4a\x88\x44\x11\xf9\x0b\x97\x26\xfd\xb7\x3f\xff\x8a\x43\x54\x52\xaf\xd7\x66\xe4\xc3\x5a\xcb\x8d\xac\x90\x2f\x06\x17\xdd\x5b\x72\x93\x9b\xf7\xf8\x23\xc1\x13\x20\x14\x1e\x7c\x3b\xde\xb4\x50\xa9\x0f\xdf\x49\xbc\xe5\xe9\x37\xfb\x79\x68\xf3\xa3\xa4\x28\xbc\x86\xec\xdf\x13\xb2\x42\x72\x46\x47\x2f\xa7\xda\xdf\x37\x9f\xb3\xfa\x23\xdf\xf9\x6a\x5a\x19\x93\xdf\xc1\xeb\x4a\x1a\x86\xfc\xc9\xfc\x30\xd1\xdd\x2d\xb4\x57\x3f\xfc\x67\xf8\x61\xd6\x9a\x2e\x5c\xad\x39\x78\x39\x33\x83\x55\xae\xa3\x07\x4e\xd1\x6d\x3e\xea\xf6\xc2\xc2\x6c\x8e\x3b\x28\xa9\x05\xcb\x4d\xf4\xac\x12\xbc\xb8\x67\xac\x70\xb4\x2b\xee\xf1\xa0\x46\x1f\x3c\x1a\xf9\x3f\xea\x2c\xab\x1d\xe6\xdd\xb3\x9a\x2f\xd4\x50\xce\xdb\xad\xb5\x9e\x32\xa5\x21\xe6\xaa\xfb\x2b\xba\x53\x76\xda\x54\x35\x51\xbb\x97\x63\x9e\x56\x4d\xb7\x6c\xef\x56\xcf\x9a\x94\x9a\xf7\xb5\x2b\xdc\x99\x6a\xbc\x73\x9e\x09\xd0\x1c\xef\x20\x4f\x3b\xa7\xa7\xc7\x44\xf0\x7d\x1d\xf1\x6b\x60\xfd\x7f\xb6\xb9\xab\xcb\xad\xc5\x99\xbd\xe5\x82\x6b\x3e\xf4\x02\x4b\xae\xd5\x13\x84\xaf\xde\xf8\xcf\xf0\xc6\xd7\x45\xd7\xeb\xa2\xeb\x75\xd1\xb5\xeb\xce\xf2\xba\xe8\xda\x99\x96\x6d\x93\xa1\x9a\x96\xde\x8f\x3b\x7e\xc4\x56\xe8\x02\x64\x39\xf2\xec\x27\x31\x0a\x47\x93\x56\x4e\x9a\x2c\x0d\x7d\x78\x78\x78\xdf\x06\x77\x71\x67\x77\x7d\x4b\x72\x37\x9a\x86\x5d\x6a\x5f\x9e\xb3\x75\x39\xd8\xd8\xba\x94\x6e\xa2\x3d\x64\xf2\x95\xde\xe6\xce\xb9\x86\xe2\x29\xac\xd5\x74\x55\xfc\x9a\xfc\xf9\x1c\xe2\x60\x35\x5b\x69\x89\xb6\x4e\x55\x98\x4a\x18\x4c\xb7\xdb\x87\x5b\xcf\x1d\x6b\xe7\x1d\xee\x66\x86\xa6\x15\x92\xeb\xe3\xec\x7f\xa3\x98\x26\x76\xad\xad\xdd\x70\xbc\x2e\x13\x71\x99\xbf\x9a\xd6\x80\x85\x53\x35\x32\x96\x71\x74\x6c\x18\xe5\xdf\xef\x24\xa9\x18\xb3\x6b\xcc\xbf\xc3\xd7\xda\x6b\xa8\xfe\xfe\xef\xc1\xbe\xcf\xe7\x60\xdb\x7f\x0d\xf6\xfd\x3e\x06\x5b\xa1\xb9\x85\x26\x97\x9f\x5c\x3f\xe2\xb3\xca\xff\x06\x00\x00\xff\xff\x96\x70\x0b\x9a\x8b\x42\x00\x00")
+var _templateDefaultTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x3c\x7f\x6f\xda\xc8\xb6\xff\xfb\x53\x9c\xf5\xf6\xea\x36\x15\xbf\x92\xee\x56\xb7\x04\x78\xa2\x84\x24\xd6\x23\x10\x81\xd3\xde\x6a\xb5\x8a\x06\xfb\x00\xd3\xda\x1e\xef\xcc\x90\x84\x4d\x91\xde\x67\x79\x1f\xed\x7d\x92\xa7\xf1\x2f\x6c\x30\x84\xe6\x46\x09\xdb\x25\x51\x2b\x7b\xe6\xcc\xf9\x7d\xce\x9c\xf9\xe1\xdc\xdf\x83\x8d\x23\xea\x21\xe8\xd7\xd7\xc4\x41\x2e\x5d\xe2\x91\x31\x72\x1d\xe6\xf3\xa6\x7a\xbf\x08\xdf\xef\xef\x01\x3d\x1b\xe6\x73\x6d\xed\x90\xab\x7e\x47\x8d\xba\xbf\x87\x52\xfb\x4e\x22\xf7\x88\x73\xd5\xef\xc0\x7c\x5e\xfe\xb9\x1c\xa0\x16\xff\xc5\xd1\x42\x7a\x83\xbc\xae\x80\xfa\xd1\x4b\x38\x26\xc2\x9e\x45\x2f\xa6\xc3\x2f\x68\xc9\x08\x2d\x1d\x41\xe9\x8c\xb3\xa9\x2f\x60\x3e\xff\xed\xc4\x38\x6b\x0f\xcc\xdf\xe1\xfe\x1e\x1c\xf4\x52\x3d\x30\x0e\x60\x0a\xaa\xa7\x14\xc8\x20\x4a\xa7\x94\x53\x6f\x0c\xdf\x02\xd0\xf9\x1c\x46\xc1\x7b\x06\xa4\x8f\x82\x39\x37\x68\x2f\x80\x78\xd4\xa2\x64\x77\x04\x2a\xa2\x0a\x7e\x20\x89\x9c\x0a\xf8\x06\x92\x5d\xf9\x7e\xcc\x3f\x1d\x01\xfe\x91\x74\xea\x21\x01\xc5\x78\x75\x3d\x1b\x89\xd8\x81\x14\xa1\x04\x1d\x32\x44\x47\x94\x06\x8c\x4b\xb4\x2f\x09\xe5\xa2\xf4\x91\x38\x53\x54\x04\xbf\x30\xea\x81\x0e\x0a\xab\x1a\x40\x47\x30\x96\xf0\x5a\xe1\x2a\xb5\x98\xeb\x32\x2f\x1c\x7c\x10\xb5\xa5\xf0\x1d\xc0\x7c\xfe\xfa\xfe\x1e\x6e\xa9\x9c\x64\x81\x4b\x7d\x74\xd9\x0d\x66\xa0\x4b\x5d\xe2\xa2\x88\x6c\x99\x47\x3d\x61\xfc\x20\x79\x5a\x7d\xc8\x9a\xd2\xa6\x63\x14\xf2\x3a\xb0\xcd\xb5\x43\x45\x6c\x54\x4e\xbc\x31\x42\x69\x83\x72\x43\xe5\x6c\xa9\x97\xea\x93\x59\x5d\x7b\x50\x22\x14\x16\xa7\xbe\xa4\xcc\xcb\xe8\x44\xcb\x82\x49\xbc\x93\x61\x9c\xe4\x8b\x1d\x0a\x56\xd5\x16\x8d\xab\xa2\x2a\xe6\x8b\x81\x64\xca\x32\xea\xad\x0e\x89\x6d\x22\xc6\x42\x5e\x9b\x9e\xc7\x24\x51\x3c\x65\x50\xa6\x9a\x1f\x87\x77\xc0\xa6\xdc\xc2\x50\xbb\x67\xe8\x21\x27\x92\xf1\x30\xbc\x17\x40\xc9\x83\x96\xd1\x81\x70\x88\xf5\xb5\x64\xe3\x88\x4c\x1d\x59\x92\x54\x3a\x18\x69\x41\xa2\xeb\x3b\x44\x66\x63\xbd\x94\xc1\xb4\x16\xcf\x54\xa8\x14\xe3\xe6\xa1\xca\x26\xb2\x2d\xf1\x8d\x88\xe3\x0c\x89\xf5\x75\x05\x5f\x2e\xfb\x0a\x29\x7c\x83\x87\x00\x1d\xea\x7d\xdd\x9a\x03\x9f\xa3\x72\x16\x7d\x3b\xe8\x14\xfe\x8d\x0a\x08\xd2\xf2\x96\x1c\x50\x8b\x79\xe8\xb2\x2f\x74\x4b\x1e\x14\xfc\x94\x3b\x5b\x42\x7f\x87\x70\x23\xc6\x24\xf2\x2d\x81\x5d\x22\xad\x09\x72\x11\x81\x87\x2e\xff\x8a\x16\xe0\x95\x0f\xd5\x7a\x36\xb3\x65\x9d\x3f\x4c\xa2\xaf\x28\xcc\xe7\x85\x84\xd0\xfd\x3d\xbc\xf2\xe3\x80\xa8\xeb\xe1\x6b\x1c\x11\x7a\x12\x11\x6b\x9c\x7d\x42\x7d\x6b\x42\xe4\x42\x12\xce\xdc\x07\x2c\xb4\xc1\x3c\xcb\xd8\x5c\x14\x82\x8c\xbf\x23\x7c\x32\xbc\xf9\x2a\x20\xec\xa9\x9c\x25\xf8\x56\x73\xd8\x16\x38\x37\x62\xb4\x1c\x8a\x9e\xcc\x41\xb6\xa5\xc4\xeb\x30\x2e\xaa\x8b\xc7\x39\xfa\x2a\x5e\xea\x09\x49\x3c\x0b\x45\x0e\xde\x95\xa4\xbd\x41\xab\xcc\x17\x63\xf4\x28\x3e\xde\x48\x9b\x90\xad\x5a\x28\x9a\xbe\xd7\xa4\xf4\xdc\x59\x51\xcb\x56\x4f\xc5\xf9\x5c\x0b\x1f\xab\xda\x12\x87\x39\xd3\xf4\xa2\xb2\x8a\x38\x2e\x26\x08\x93\xf2\x23\x33\xe1\x1e\x40\x25\x80\x09\x1b\x21\x9c\x85\xab\xda\x83\x2a\xce\x20\x09\xb9\x2e\xa6\x54\x94\x43\x2f\x9e\xbd\x97\x28\xc6\xcd\xdb\xd3\x8c\x47\xac\x50\x2d\x6e\x63\x23\x11\x4c\x8e\xdf\xef\x9e\x19\x37\xba\xc5\xc7\x44\xba\xb6\x77\x88\xe7\x74\x88\x66\xda\xa0\xdc\xa9\x6a\xdb\x18\x3c\x8d\x20\x6b\xf3\x1b\x6a\x49\xc6\x99\x2f\x16\xae\x24\x89\xc4\xeb\xac\xf1\xf7\xf6\x7d\x36\xfb\x66\x19\x58\x6f\x26\xf4\x24\x95\xb3\x6b\x9b\x0a\xdf\x21\xb3\xeb\x35\x75\xe8\xc3\xe9\x7e\x15\xb3\xcb\x3c\x2a\x99\x52\xc8\xb5\x64\xcc\xc9\xc1\x9a\xf6\xb1\x95\x8c\x92\xc2\x2d\x82\x75\xaa\x9a\xf7\x12\xe4\x29\x3e\x13\xef\x08\x57\x1b\x91\xa7\xad\x29\x95\x72\x7d\x6c\xb1\x2c\x5e\xf8\x68\x34\x34\x60\x51\x11\x4b\x33\x97\xab\x80\x1c\x26\x87\xcc\x9e\xe9\x0f\x2c\xaa\xf3\xa3\x42\x4c\x5d\x97\xf0\x59\x44\x2b\xe4\xcd\x9c\x50\x01\xd4\xb3\xa8\x8d\x9e\x84\x09\x11\x30\x44\xf4\x92\x55\x5e\x29\x87\xbd\x34\x7f\x63\x4e\x46\xc4\x23\xd7\xcc\xb3\x88\xe3\x24\x3c\x3e\x7e\x11\xb3\x06\xe1\x3e\xe2\x5f\x3e\xe2\xd3\x66\xfa\x3a\x1d\x22\xf7\x50\xe2\x22\x32\x39\x12\xc1\xbc\x87\x3c\x33\x60\x3b\x94\x6f\xe1\x84\x41\x63\xcc\x40\xe2\x16\xb9\xfe\x91\x43\x38\xeb\x1b\x9b\xdc\x2d\x5e\xed\x2b\x72\x91\x35\xc3\x1d\x9f\xfc\x28\x89\xd6\xfe\x69\x56\x56\x1f\x32\x6a\x71\x85\x44\xe2\x8a\x27\x88\x83\x65\x4c\x11\x57\x4f\x82\x2b\xb5\xd0\x7c\xd0\x83\xdf\xbc\xc9\xfa\xf0\x9b\x37\xe9\x6d\x93\x65\x77\x2d\xc2\xa2\x73\x35\x4d\x46\xc1\x1b\x2f\x1d\xd3\x3b\x29\x90\xec\xa4\x3c\x60\x93\x55\x93\xac\xf1\xd6\xed\xc2\x25\x11\x2f\xee\xc8\x15\x30\xee\xdc\x39\x11\x93\xc7\xb4\xb9\x25\x3a\x38\xe6\xc4\xcd\x0b\x90\xda\xb0\xb1\x2b\x9b\xb4\xdf\x60\x22\x5d\x07\xe6\xf3\x5a\x79\xd8\x58\xd1\x79\x64\xc2\xef\x57\x74\x82\x76\x6b\x7d\x2b\xda\xa5\xc5\xb8\x64\x58\xee\xc6\x5d\x4a\xcf\x63\xc6\xc6\x4e\x76\x55\xf2\xf8\x78\xcf\x43\xf6\x1d\x61\x5a\x1b\x36\xb2\x71\x9a\xab\xd4\xd8\x76\xf3\xb9\xf6\x7f\xff\xf3\xbf\xf0\x4c\xfa\x2d\xc2\x7a\x05\x3f\x3e\x72\x17\x12\xc7\x3d\x6b\x64\x8e\xbb\x77\x5e\xea\xdc\x60\xb6\xa9\xb0\x18\xb7\x9f\xc0\xc3\x96\x31\x65\xa7\xcd\xfd\x44\xf0\xe3\x4d\x04\x5f\x28\x27\x89\xb9\x23\xb4\x8f\x72\x9d\x0c\xa2\xa7\xdc\x71\xdb\xec\x72\x3f\x54\xf5\xbc\x4e\x9d\xd1\x4a\xeb\xda\x62\xae\x1b\xed\x0c\x37\x1d\x07\x82\x12\x5d\x00\x1b\x81\x9c\x60\x78\x64\x9b\xb3\x28\xcb\x98\x5b\x78\xe9\x72\x31\xb2\xe9\x23\xac\x9d\xc6\xb3\x5f\x77\xed\xd6\xba\xcb\xfd\x43\xa6\x0a\x04\xe6\x53\x4b\x05\x74\x7a\xcb\xa3\xbc\x74\x77\xa0\xbc\xb0\xdb\xa6\xdd\x87\x0c\x15\x74\x09\x75\x9e\xc4\x97\xb2\x98\xd4\x54\xa8\xf8\xd5\x6a\x3f\x9d\xf4\x5a\xe6\xe7\xcb\x76\x38\xad\x5e\x5e\x7d\xe8\x18\x2d\xd0\x8b\xe5\xf2\xa7\xb7\xad\x72\xf9\xc4\x3c\x81\x7f\x9f\x9b\x17\x1d\x38\x2c\x55\xc0\xe4\xc4\x13\x54\x25\x47\xe2\x94\xcb\xed\xae\x0e\xfa\x44\x4a\xbf\x5a\x2e\xdf\xde\xde\x96\x6e\xdf\x96\x18\x1f\x97\xcd\x7e\xf9\x4e\xe1\x3a\x54\x83\xa3\xc7\xa2\x4c\x8d\x2c\xd9\xd2\xd6\x1b\x5a\xed\xa7\x62\x51\x1b\xc8\x99\x83\x40\x3c\x1b\x02\x22\x36\x72\xaa\x62\x77\xc4\x99\x0b\x0a\xb5\xa8\x96\xcb\x63\x2a\x27\xd3\x61\xc9\x62\x6e\x59\xc9\x30\x9e\x7a\xe5\x00\x1d\xb1\x42\x4e\x8a\x81\x68\xc5\x58\x1d\x42\xd3\x34\x73\x82\x70\x61\x98\xd0\xa1\x16\x7a\x02\xe1\xf5\x85\x61\x1e\x68\x5a\x8b\xf9\x33\x4e\xc7\x13\x09\xaf\xad\x03\x38\xaa\x1c\xfe\x02\x17\x21\x46\x4d\xbb\x44\xee\x52\x21\x28\xf3\x80\x0a\x98\x20\xc7\xe1\x0c\xc6\x9c\x78\x12\xed\x02\x8c\x38\xa2\x4a\x01\xd6\x84\xf0\x31\x16\x40\x32\x20\xde\x0c\x7c\xe4\x82\x79\xc0\x86\x92\x50\x4f\xa5\x3a\x02\x16\xf3\x67\x5a\x90\x2c\xa8\x00\xc1\x46\xf2\x96\xf0\x50\x42\x22\x04\xb3\x28\x91\x68\x83\xcd\xac\xa9\xca\x31\x41\xe8\xc2\x88\x3a\x28\xe0\xb5\x4a\x2f\xfa\x20\x1a\xa1\x1f\x04\x44\x6c\x24\x8e\x46\xbd\x20\xf5\xc4\x5d\xc1\x64\xcc\xa6\x52\x65\x21\xc9\x69\xa0\x85\x82\xda\x39\x72\xa6\xb6\xe2\x21\xee\x76\xa8\x4b\x23\x0a\x6a\x78\x20\xb8\xd0\x24\x83\xa9\xc0\x42\xc0\x67\x01\x5c\x66\xd3\xd1\xac\x00\x2e\x06\x62\xf9\xd3\xa1\x43\xc5\xa4\x00\x36\x15\x92\xd3\xe1\x54\x62\x01\x84\x6a\x0c\xf4\x58\x50\x72\x94\x19\x07\x81\x8e\xa3\x59\xcc\xa7\x98\x24\xc6\x98\xbb\x00\x46\xb1\xee\x2b\x85\xca\x48\x45\x42\xb5\xdc\x4e\x98\x9b\x95\x84\x0a\x6d\x34\xe5\x1e\x15\x13\xb4\x15\x84\xcd\x40\xb0\x80\xa2\xca\x8c\xaa\x45\x81\x8f\x98\xe3\xb0\x5b\x25\x9a\xc5\x3c\x9b\x46\x77\x06\x02\x23\x93\xa1\xba\x12\x62\x25\x76\xf5\x98\xa4\x56\xa8\x6e\xa9\xf6\xd3\xfc\x85\x55\xa3\x2e\x31\x21\x8e\x03\x43\x8c\x14\x86\x36\x50\x0f\x48\x4a\x1c\xae\xc8\xab\x03\x37\x49\x89\x03\x3e\xe3\x01\xbd\x65\x31\x4b\x9a\x66\x9e\xb7\x61\xd0\x3b\x35\x3f\x35\xfb\x6d\x30\x06\x70\xd9\xef\x7d\x34\x4e\xda\x27\xa0\x37\x07\x60\x0c\xf4\x02\x7c\x32\xcc\xf3\xde\x95\x09\x9f\x9a\xfd\x7e\xb3\x6b\x7e\x86\xde\x29\x34\xbb\x9f\xe1\xbf\x8d\xee\x49\x01\xda\xff\xbe\xec\xb7\x07\x03\xe8\xf5\x35\xe3\xe2\xb2\x63\xb4\x4f\x0a\x60\x74\x5b\x9d\xab\x13\xa3\x7b\x06\x1f\xae\x4c\xe8\xf6\x4c\xe8\x18\x17\x86\xd9\x3e\x01\xb3\x07\x8a\x60\x84\xca\x68\x0f\x14\xb2\x8b\x76\xbf\x75\xde\xec\x9a\xcd\x0f\x46\xc7\x30\x3f\x17\xb4\x53\xc3\xec\x2a\x9c\xa7\xbd\x3e\x34\xe1\xb2\xd9\x37\x8d\xd6\x55\xa7\xd9\x87\xcb\xab\xfe\x65\x6f\xd0\x86\x66\xf7\x04\xba\xbd\xae\xd1\x3d\xed\x1b\xdd\xb3\xf6\x45\xbb\x6b\x96\xc0\xe8\x42\xb7\x07\xed\x8f\xed\xae\x09\x83\xf3\x66\xa7\xa3\x48\x69\xcd\x2b\xf3\xbc\xd7\x57\xfc\x41\xab\x77\xf9\xb9\x6f\x9c\x9d\x9b\x70\xde\xeb\x9c\xb4\xfb\x03\xf8\xd0\x86\x8e\xd1\xfc\xd0\x69\x87\xa4\xba\x9f\xa1\xd5\x69\x1a\x17\x05\x38\x69\x5e\x34\xcf\x14\x77\x7d\xe8\x99\xe7\xed\xbe\xa6\xc0\x42\xee\xe0\xd3\x79\x5b\x35\x29\x7a\xcd\x2e\x34\x5b\xa6\xd1\xeb\x2a\x31\x5a\xbd\xae\xd9\x6f\xb6\xcc\x02\x98\xbd\xbe\x99\x0c\xfd\x64\x0c\xda\x05\x68\xf6\x8d\x81\x52\xc8\x69\xbf\x77\x51\xd0\x94\x3a\x7b\xa7\x0a\xc4\xe8\x42\xab\xd7\xed\xb6\x43\x2c\x4a\xd5\x90\xb1\x48\xaf\x1f\xbc\x5f\x0d\xda\x09\x42\x38\x69\x37\x3b\x46\xf7\x6c\xa0\x38\x50\x22\xc6\xc0\x25\xad\x58\x6c\x68\x35\x95\xab\xe0\xce\x75\x3c\x51\xcf\x49\x6c\x87\xef\xdf\xbf\x0f\xf3\x99\xbe\x1d\x90\x90\x33\x07\xeb\xfa\x88\x79\xb2\x38\x22\x2e\x75\x66\x55\xf8\xe7\x39\x3a\x37\x28\xa9\x45\xa0\x8b\x53\xfc\x67\x01\x92\x86\x02\x34\x39\x25\x4e\x01\x04\xf1\x44\x51\x20\xa7\xa3\x63\x18\xb2\xbb\xa2\xa0\x7f\xaa\xb2\x0b\x86\x8c\xdb\xc8\x8b\x43\x76\x77\x0c\x01\x52\x41\xff\xc4\x2a\x1c\xfe\xe2\xdf\x1d\x83\x4b\xf8\x98\x7a\x55\xa8\x1c\xab\xdc\x3a\x41\x62\xbf\x24\x7d\x17\x25\x01\x35\xb3\xd5\xf5\x1b\x8a\xb7\x2a\x8a\x74\xb0\x98\x27\xd1\x93\x75\xfd\x96\xda\x72\x52\xb7\xf1\x86\x5a\x58\x0c\x5e\x5e\x4e\x59\x50\x8e\xd9\x55\xc6\x2c\xe2\x1f\x53\x7a\x53\xd7\x5b\x21\xab\x45\x73\xe6\x63\x8a\x71\x55\x75\x96\x95\x71\x8f\x83\x99\x40\xa0\xac\x5f\x99\xa7\xc5\x7f\xbd\x30\xfb\xc1\x66\xc7\x8b\xb1\xd0\xd8\x54\x8b\xd4\xca\x01\x73\x0d\x4d\xab\x95\x95\x53\xaa\x07\x75\x6e\x02\x54\xa2\x2b\x2c\xe6\x63\x5d\xd7\x83\x17\x39\xf3\x31\x89\x28\x61\x4d\xd0\x25\x41\xd8\xb5\xd5\xec\x7e\x11\x57\xbf\xcf\x2a\x64\xf1\x16\x87\x5f\xa9\x2c\x86\x1d\x2e\x63\x72\x12\x68\x26\x9c\x1b\x28\x11\x68\x2f\x80\x94\x6f\x04\xa3\x8b\xc4\xfe\x32\x15\xb2\x0a\x1e\xf3\xf0\x18\x26\xa8\x26\xde\x2a\x1c\x56\x2a\xff\x38\x06\x87\x7a\x58\x4c\x9a\x4a\xef\xd0\x3d\x86\x20\x02\x42\x00\xf8\x89\xba\x2a\x58\x88\x27\x8f\x41\x5d\xf2\x52\x45\xb8\x67\x17\x2d\xe6\x30\x5e\x85\x9f\x47\xef\xd4\x6f\x5a\xfd\xe0\x13\x5b\x4d\xfb\xea\x59\x87\xe1\x38\x80\xac\xeb\x11\xa4\xae\xf4\x2d\xc9\xf0\xb9\xdd\x23\x25\xd2\x96\x72\xe4\xf2\x0e\x50\x93\xfc\x79\x39\x4f\x71\xd4\xd0\x00\x14\x07\xcf\x9c\x49\x6f\x90\x2b\xac\x4e\x91\x38\x74\xec\x55\x41\x32\x3f\xc3\x16\xdc\x04\x1d\x75\x5d\x32\x5f\x6f\xd4\xca\xd2\x5e\x30\x1a\xe8\xbd\xae\xbf\xab\x54\xf4\x1d\x60\x3a\x3a\x75\xae\xc2\xd0\x61\xd6\xd7\x8c\x6f\xbb\xe4\xae\x18\x39\xc9\xbb\x4a\xc5\xbf\xcb\x74\x5a\x0e\x12\xae\x08\xca\x49\xa6\x3d\xe5\x55\x99\xf6\x44\x39\x40\xa6\x92\x2d\x85\x44\x46\x5b\x81\xa2\x00\x6a\x36\xbd\x79\x5e\xfd\x2c\xcb\xbb\xac\x9c\xcd\x42\xc4\x7c\x2b\x23\x07\xc1\x1c\xd9\x59\xa5\x0c\x1d\x2c\x74\x9c\x08\xba\xae\x57\xc2\x77\xe1\x13\x2b\x7e\x7f\x56\x41\xa3\x4e\x4e\x6c\x3a\x15\x55\x78\xeb\xdf\xe5\x27\x80\xd1\x28\x25\x72\x3c\xac\x0a\x87\xfe\x1d\x08\xe6\x50\x1b\x7e\xc6\xf7\xea\x37\x9b\xd4\x46\xa3\x94\x2e\x76\x21\x3b\xc4\x3f\xcf\x99\x25\xde\xad\x0d\xb8\x8c\x76\x83\x21\xb7\xd1\x54\xf3\x6b\xa5\x72\x0c\xc1\x14\x15\xc1\x5b\xe8\x49\xe4\x79\xf6\x0a\xfe\x55\xa0\x92\x6b\xb7\xf6\xbb\x5f\x8f\x8e\x5a\x69\x45\x2c\x1c\xf5\xa8\xe2\xdf\x1d\xeb\x10\xc5\x5b\x48\x20\x6d\xbd\x70\x6c\x7e\x44\xc6\x3f\x8b\xe3\xad\xc5\x45\xf7\x60\x53\x25\x77\xf3\xe7\x00\x0e\x61\x3e\x17\xc9\x86\x07\x8c\x18\x4f\x6d\x06\xaf\x39\x02\x53\xfb\x1e\x31\xbd\xf8\x67\xdd\x16\xf1\x2a\x7b\xd1\xd6\x4a\xdc\xa2\x7e\x17\x39\x38\x79\xe7\x99\xf7\xbf\xa5\x9b\x6e\x33\x99\x2d\x9c\xe7\x30\x74\x9e\x4d\xbe\xb1\xf3\xb9\x6f\xad\xda\x77\xcb\x09\x76\xdd\x15\x2a\x50\x81\xa3\x87\xdd\x21\x12\x83\xc0\x84\xe3\xa8\xae\x2f\xad\x42\xd2\x3b\xb1\xc9\x85\xeb\x67\xf6\x87\x38\x69\x9e\x9e\x9e\x46\xc9\xd7\x46\x8b\xf1\x60\x4f\x2e\x5e\x1e\x64\x16\x04\x47\xe8\x2e\xe5\xed\x21\x73\xec\xfc\xc4\x6d\x4d\xb9\x50\x29\xd9\x67\x34\x6c\x48\x0a\x0a\xea\x05\x48\xa3\xba\x62\x29\xc1\xff\xaa\xa2\x32\xc0\x17\x6c\xa2\x8e\x18\x77\xab\x60\x11\x9f\x4a\xe2\xd0\x3f\x31\x37\xe9\xbf\xfd\xe5\x5f\x68\x93\x8c\xb1\x22\xac\xcb\x10\x51\x73\xa0\xe5\x6a\x38\x91\x27\x8d\x49\xf5\xe6\xdf\x45\xe6\x6d\x7c\xa4\x78\xab\xf6\xdf\x36\xd8\x2e\x5e\x46\x92\x5c\x1f\x5e\x4a\xbc\xf9\xe9\x37\x49\xdd\x1b\x4f\x2b\xe6\xf3\x7d\xc8\x3e\x53\xc8\x0a\xc9\x99\x37\x7e\x39\xd5\xfe\xb6\xfe\x12\xcd\xef\xd1\x51\x55\xad\x1c\x32\xf9\x04\x5e\x97\x53\x30\x44\x3d\x51\x99\x92\xe5\x64\xef\x87\x7f\x1b\x3f\x0c\x8f\x01\x13\x57\xab\x0d\x5f\xce\xcc\x6a\x1f\x31\xd6\x4b\xbe\x97\x3e\x74\xb3\x62\xe9\x8b\xcc\x17\x16\x66\x7d\xdc\xe5\xcd\x05\x8b\x63\x74\x75\xff\x60\x3e\x7f\x71\xcf\x48\x71\xb4\x2b\xee\xf1\xa0\x46\xe3\x6c\xb6\x60\xfd\xc7\x70\x96\x74\x85\xb9\xfc\x49\xf1\x0b\x15\x94\x71\xb9\xb5\x52\x53\x4e\x3d\x1b\xb9\xaa\xfe\x32\x22\x36\xc2\x8f\xa2\x55\x11\xf5\xc2\x9a\x7e\xb2\xd9\x54\x7b\x28\xa4\x57\x2f\x87\xe4\x9a\x77\x5f\x15\xee\x4c\x55\xb8\x73\x9e\x09\x50\x9b\xec\x20\x4f\x7f\xe9\x08\xde\x54\x11\xef\xcb\xdc\x1f\xb3\xcc\x4d\x2f\xb7\x92\x4b\x76\x8b\x05\x57\xdc\x94\x14\x3a\xff\xa1\x8b\xad\x77\xb0\x54\x91\xb2\xc4\xcd\x7e\xd1\xb5\x5f\x74\xed\x17\x5d\xfb\x45\xd7\x7e\xd1\xb5\x5f\x74\xed\x17\x5d\xeb\x16\x5d\x2b\xd0\xea\x3c\xae\xa1\x6d\x42\x9c\x45\x99\x0c\x59\xb4\x3c\xfb\x4d\x8c\xe4\x18\xa2\xf2\x8f\xcc\x4d\x93\x85\xa1\xdf\xbf\x7f\x9f\x3f\xd1\x85\x25\x57\x43\xdb\x7c\x24\xf9\x52\x96\x6e\x68\xbb\x5a\xbe\x3c\x67\xe9\x72\xb4\xb6\x74\xc9\x3d\x44\x7b\xc8\xe4\xa9\xda\x66\xe9\x5e\x43\xa6\xd4\xc9\xa4\xab\xec\x1f\x95\x7c\x3e\x87\x38\x4a\x67\xab\xc0\x89\xb7\x4e\x55\xea\x4f\x4e\x0c\x67\xdb\x9d\xc3\xad\xe6\x8e\xe5\xbc\xb1\x92\x19\x6a\x65\x9b\xde\x34\xc2\xff\xb5\x6c\x9a\xd8\xb5\xb2\x76\xd9\xb0\x11\xa3\xa1\x88\x8b\xfc\x55\x2b\xab\x5b\xac\xaa\x45\x5d\x07\x6e\x68\x5a\xfe\xf7\x3b\xfe\x54\x4c\xd8\x0d\xf2\xe4\xc3\x9b\xc7\x7f\x35\xba\x82\xea\x2f\xf8\x45\xd8\xd3\x7c\x10\x96\x52\x4e\x0e\xb5\x78\x4d\x97\xa5\x17\xb7\x6e\x4f\x31\x1e\xb1\x42\x73\x0b\xd3\x2c\xfe\xd4\xe0\xba\x70\x4a\xae\x24\xdc\xdf\x03\x7a\x36\xcc\xe7\xda\xff\x0f\x00\x06\x6a\x25\xf1\xe3\x56\x00\x00")
 
 func templateDefaultTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -83,7 +83,7 @@ func templateDefaultTmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "template/default.tmpl", size: 17035, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "template/default.tmpl", size: 22243, mode: os.FileMode(420), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
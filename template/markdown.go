@@ -0,0 +1,289 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	"fmt"
+	tmplhtml "html/template"
+	"regexp"
+	"strings"
+)
+
+// The template functions below convert a canonical Markdown body into the
+// formats of the receivers, so that a single template serves all of them.
+// They support the subset of Markdown commonly used in notifications:
+// headings, paragraphs, bullet and numbered lists, block quotes, fenced
+// code blocks, and inline emphasis, strikethrough, code and links.
+
+type markdownBlockKind int
+
+const (
+	mdParagraph markdownBlockKind = iota
+	mdHeading
+	mdBulletList
+	mdNumberedList
+	mdQuote
+	mdCode
+)
+
+// markdownBlock is a block of a Markdown document. The lines of lists are
+// their items.
+type markdownBlock struct {
+	kind  markdownBlockKind
+	level int
+	lines []string
+}
+
+var (
+	mdHeadingRE  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdBulletRE   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdNumberedRE = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuoteRE    = regexp.MustCompile(`^>\s?(.*)$`)
+
+	// mdInlineRE matches inline code, links, bold, strikethrough and italic
+	// text, in this order of precedence.
+	mdInlineRE = regexp.MustCompile("`([^`]+)`" +
+		`|\[([^\]]+)\]\(([^)\s]+)\)` +
+		`|\*\*(.+?)\*\*|__(.+?)__` +
+		`|~~(.+?)~~` +
+		`|\*([^*\s](?:[^*]*[^*\s])?)\*|\b_([^_\s](?:[^_]*[^_\s])?)_\b`)
+)
+
+// parseMarkdown splits the Markdown text into blocks.
+func parseMarkdown(text string) []markdownBlock {
+	var (
+		blocks []markdownBlock
+		cur    *markdownBlock
+	)
+	add := func(kind markdownBlockKind, level int, line string) {
+		if cur == nil || cur.kind != kind || kind == mdHeading {
+			blocks = append(blocks, markdownBlock{kind: kind, level: level})
+			cur = &blocks[len(blocks)-1]
+		}
+		cur.lines = append(cur.lines, line)
+	}
+
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			blocks = append(blocks, markdownBlock{kind: mdCode})
+			cur = &blocks[len(blocks)-1]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				cur.lines = append(cur.lines, lines[i])
+			}
+			cur = nil
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			cur = nil
+			continue
+		}
+		if m := mdHeadingRE.FindStringSubmatch(line); m != nil {
+			add(mdHeading, len(m[1]), m[2])
+			cur = nil
+			continue
+		}
+		if m := mdBulletRE.FindStringSubmatch(line); m != nil {
+			add(mdBulletList, 0, m[1])
+			continue
+		}
+		if m := mdNumberedRE.FindStringSubmatch(line); m != nil {
+			add(mdNumberedList, 0, m[1])
+			continue
+		}
+		if m := mdQuoteRE.FindStringSubmatch(line); m != nil {
+			add(mdQuote, 0, m[1])
+			continue
+		}
+		if cur != nil && (cur.kind == mdBulletList || cur.kind == mdNumberedList) && strings.HasPrefix(line, " ") {
+			// Indented lines continue the last list item.
+			cur.lines[len(cur.lines)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		add(mdParagraph, 0, strings.TrimSpace(line))
+	}
+	return blocks
+}
+
+// markdownFormat renders inline Markdown in the format of a receiver.
+type markdownFormat struct {
+	escape                     func(string) string
+	code, bold, strike, italic func(string) string
+	link                       func(text, url string) string
+}
+
+// inline converts the inline Markdown of the text.
+func (f markdownFormat) inline(text string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, m := range mdInlineRE.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(f.escape(text[last:m[0]]))
+		last = m[1]
+
+		group := func(i int) string { return text[m[2*i]:m[2*i+1]] }
+		switch {
+		case m[2] >= 0:
+			b.WriteString(f.code(f.escape(group(1))))
+		case m[4] >= 0:
+			b.WriteString(f.link(f.inline(group(2)), group(3)))
+		case m[8] >= 0:
+			b.WriteString(f.bold(f.inline(group(4))))
+		case m[10] >= 0:
+			b.WriteString(f.bold(f.inline(group(5))))
+		case m[12] >= 0:
+			b.WriteString(f.strike(f.inline(group(6))))
+		case m[14] >= 0:
+			b.WriteString(f.italic(f.inline(group(7))))
+		case m[16] >= 0:
+			b.WriteString(f.italic(f.inline(group(8))))
+		}
+	}
+	b.WriteString(f.escape(text[last:]))
+	return b.String()
+}
+
+// inlineLines converts the inline Markdown of each line.
+func (f markdownFormat) inlineLines(lines []string) []string {
+	res := make([]string, 0, len(lines))
+	for _, l := range lines {
+		res = append(res, f.inline(l))
+	}
+	return res
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var slackFormat = markdownFormat{
+	escape: slackEscaper.Replace,
+	code:   func(s string) string { return "`" + s + "`" },
+	bold:   func(s string) string { return "*" + s + "*" },
+	strike: func(s string) string { return "~" + s + "~" },
+	italic: func(s string) string { return "_" + s + "_" },
+	link: func(text, url string) string {
+		return "<" + slackEscaper.Replace(url) + "|" + text + ">"
+	},
+}
+
+// MarkdownToSlack converts Markdown into the mrkdwn format of Slack.
+func MarkdownToSlack(text string) string {
+	var out []string
+	for _, b := range parseMarkdown(text) {
+		lines := slackFormat.inlineLines(b.lines)
+		switch b.kind {
+		case mdHeading:
+			out = append(out, "*"+lines[0]+"*")
+		case mdBulletList:
+			for i, l := range lines {
+				lines[i] = "• " + l
+			}
+			out = append(out, strings.Join(lines, "\n"))
+		case mdNumberedList:
+			for i, l := range lines {
+				lines[i] = fmt.Sprintf("%d. %s", i+1, l)
+			}
+			out = append(out, strings.Join(lines, "\n"))
+		case mdQuote:
+			for i, l := range lines {
+				lines[i] = ">" + l
+			}
+			out = append(out, strings.Join(lines, "\n"))
+		case mdCode:
+			out = append(out, "```\n"+slackEscaper.Replace(strings.Join(b.lines, "\n"))+"\n```")
+		default:
+			out = append(out, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
+
+var htmlFormat = markdownFormat{
+	escape: tmplhtml.HTMLEscapeString,
+	code:   func(s string) string { return "<code>" + s + "</code>" },
+	bold:   func(s string) string { return "<strong>" + s + "</strong>" },
+	strike: func(s string) string { return "<del>" + s + "</del>" },
+	italic: func(s string) string { return "<em>" + s + "</em>" },
+	link: func(text, url string) string {
+		return `<a href="` + tmplhtml.HTMLEscapeString(url) + `">` + text + "</a>"
+	},
+}
+
+// MarkdownToHTML converts Markdown into HTML, e.g. for the body of emails.
+// The text of the Markdown is escaped.
+func MarkdownToHTML(text string) tmplhtml.HTML {
+	var out []string
+	for _, b := range parseMarkdown(text) {
+		lines := htmlFormat.inlineLines(b.lines)
+		switch b.kind {
+		case mdHeading:
+			out = append(out, fmt.Sprintf("<h%d>%s</h%d>", b.level, lines[0], b.level))
+		case mdBulletList, mdNumberedList:
+			tag := "ul"
+			if b.kind == mdNumberedList {
+				tag = "ol"
+			}
+			out = append(out, "<"+tag+">\n<li>"+strings.Join(lines, "</li>\n<li>")+"</li>\n</"+tag+">")
+		case mdQuote:
+			out = append(out, "<blockquote>"+strings.Join(lines, "<br>\n")+"</blockquote>")
+		case mdCode:
+			out = append(out, "<pre><code>"+tmplhtml.HTMLEscapeString(strings.Join(b.lines, "\n"))+"</code></pre>")
+		default:
+			out = append(out, "<p>"+strings.Join(lines, "<br>\n")+"</p>")
+		}
+	}
+	return tmplhtml.HTML(strings.Join(out, "\n"))
+}
+
+var textFormat = markdownFormat{
+	escape: func(s string) string { return s },
+	code:   func(s string) string { return s },
+	bold:   func(s string) string { return s },
+	strike: func(s string) string { return s },
+	italic: func(s string) string { return s },
+	link: func(text, url string) string {
+		if text == url {
+			return url
+		}
+		return text + " (" + url + ")"
+	},
+}
+
+// MarkdownToText converts Markdown into plain text, removing the markup
+// of emphasis and showing the URLs of links after their text.
+func MarkdownToText(text string) string {
+	var out []string
+	for _, b := range parseMarkdown(text) {
+		lines := textFormat.inlineLines(b.lines)
+		switch b.kind {
+		case mdBulletList:
+			for i, l := range lines {
+				lines[i] = "- " + l
+			}
+		case mdNumberedList:
+			for i, l := range lines {
+				lines[i] = fmt.Sprintf("%d. %s", i+1, l)
+			}
+		case mdQuote:
+			for i, l := range lines {
+				lines[i] = "> " + l
+			}
+		case mdCode:
+			lines = b.lines
+		}
+		out = append(out, strings.Join(lines, "\n"))
+	}
+	return strings.Join(out, "\n\n")
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package template
+
+import (
+	tmplhtml "html/template"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const markdownBody = `## Disk full on db-1
+
+The disk is **98%** full, see [the runbook](https://example.com/runbook?a=1&b=2).
+Affects _all_ replicas of ` + "`db_main`" + `.
+
+- ~~check backups~~
+- free space on <data>
+
+1. first
+2. second
+
+> Do not restart the database.
+
+` + "```" + `
+df -h /var/lib/**data**
+` + "```"
+
+func TestMarkdownToSlack(t *testing.T) {
+	require.Equal(t, `*Disk full on db-1*
+
+The disk is *98%* full, see <https://example.com/runbook?a=1&amp;b=2|the runbook>.
+Affects _all_ replicas of `+"`db_main`"+`.
+
+• ~check backups~
+• free space on &lt;data&gt;
+
+1. first
+2. second
+
+>Do not restart the database.
+
+`+"```"+`
+df -h /var/lib/**data**
+`+"```", MarkdownToSlack(markdownBody))
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	require.Equal(t, tmplhtml.HTML(`<h2>Disk full on db-1</h2>
+<p>The disk is <strong>98%</strong> full, see <a href="https://example.com/runbook?a=1&amp;b=2">the runbook</a>.<br>
+Affects <em>all</em> replicas of <code>db_main</code>.</p>
+<ul>
+<li><del>check backups</del></li>
+<li>free space on &lt;data&gt;</li>
+</ul>
+<ol>
+<li>first</li>
+<li>second</li>
+</ol>
+<blockquote>Do not restart the database.</blockquote>
+<pre><code>df -h /var/lib/**data**</code></pre>`), MarkdownToHTML(markdownBody))
+}
+
+func TestMarkdownToText(t *testing.T) {
+	require.Equal(t, `Disk full on db-1
+
+The disk is 98% full, see the runbook (https://example.com/runbook?a=1&b=2).
+Affects all replicas of db_main.
+
+- check backups
+- free space on <data>
+
+1. first
+2. second
+
+> Do not restart the database.
+
+df -h /var/lib/**data**`, MarkdownToText(markdownBody))
+}
+
+func TestMarkdownInline(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{in: "snake_case_name", out: "snake_case_name"},
+		{in: "**bold _and italic_**", out: "*bold _and italic_*"},
+		{in: "2 * 3 * 4", out: "2 * 3 * 4"},
+		{in: "`**not bold**`", out: "`**not bold**`"},
+	} {
+		require.Equal(t, tc.out, MarkdownToSlack(tc.in), tc.in)
+	}
+}
+
+func TestMarkdownFuncs(t *testing.T) {
+	tmpl, err := FromGlobs()
+	require.NoError(t, err)
+
+	data := &Data{CommonAnnotations: KV{"description": "**High** latency"}}
+
+	res, err := tmpl.ExecuteTextString(`{{ .CommonAnnotations.description | markdownToSlack }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "*High* latency", res)
+
+	res, err = tmpl.ExecuteHTMLString(`{{ .CommonAnnotations.description | markdownToHTML }}`, data)
+	require.NoError(t, err)
+	require.Equal(t, "<p><strong>High</strong> latency</p>", res)
+}
@@ -15,6 +15,8 @@ package template
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
 	"net/url"
 	"path/filepath"
 	"regexp"
@@ -27,6 +29,7 @@ import (
 
 	"github.com/prometheus/common/model"
 
+	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/template/internal/deftmpl"
 	"github.com/prometheus/alertmanager/types"
 )
@@ -36,15 +39,24 @@ type Template struct {
 	text *tmpltext.Template
 	html *tmplhtml.Template
 
+	// defined maps the templates defined by template files to the file
+	// defining them.
+	defined map[string]string
+	// receivers holds the templates of the receivers with their own
+	// template files.
+	receivers map[string]*Template
+
 	ExternalURL *url.URL
 }
 
 // FromGlobs calls ParseGlob on all path globs provided and returns the
-// resulting Template.
+// resulting Template. Template files may redefine the default templates, but
+// a template defined in more than one file is an error.
 func FromGlobs(paths ...string) (*Template, error) {
 	t := &Template{
-		text: tmpltext.New("").Option("missingkey=zero"),
-		html: tmplhtml.New("").Option("missingkey=zero"),
+		text:    tmpltext.New("").Option("missingkey=zero"),
+		html:    tmplhtml.New("").Option("missingkey=zero"),
+		defined: map[string]string{},
 	}
 	var err error
 
@@ -62,23 +74,160 @@ func FromGlobs(paths ...string) (*Template, error) {
 		return nil, err
 	}
 
+	if err := t.parseGlobs(paths); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FromConfig returns the template of the template files of the configuration
+// with the templates of the receivers that have their own template files.
+func FromConfig(conf *config.Config) (*Template, error) {
+	t, err := FromGlobs(conf.Templates...)
+	if err != nil {
+		return nil, err
+	}
+	for _, rc := range conf.Receivers {
+		if len(rc.Templates) == 0 {
+			continue
+		}
+		if err := t.AddReceiver(rc.Name, rc.Templates...); err != nil {
+			return nil, fmt.Errorf("receiver %q: %v", rc.Name, err)
+		}
+	}
+	return t, nil
+}
+
+// AddReceiver parses the template files matching the globs into templates
+// used only by the receiver, so the templates of different receivers cannot
+// replace each other. The templates of the receiver can use the templates of
+// t, but must not define templates of the same name unless they are default
+// templates.
+func (t *Template) AddReceiver(name string, paths ...string) error {
+	text, err := t.text.Clone()
+	if err != nil {
+		return err
+	}
+	html, err := t.html.Clone()
+	if err != nil {
+		return err
+	}
+	r := &Template{
+		text:    text,
+		html:    html,
+		defined: make(map[string]string, len(t.defined)),
+	}
+	for n, f := range t.defined {
+		r.defined[n] = f
+	}
+	if err := r.parseGlobs(paths); err != nil {
+		return err
+	}
+	if t.receivers == nil {
+		t.receivers = map[string]*Template{}
+	}
+	t.receivers[name] = r
+	return nil
+}
+
+// Receiver returns the template used by the receiver, which is t unless the
+// receiver has its own template files.
+func (t *Template) Receiver(name string) *Template {
+	if t == nil {
+		return nil
+	}
+	r, ok := t.receivers[name]
+	if !ok {
+		return t
+	}
+	return &Template{
+		text:        r.text,
+		html:        r.html,
+		defined:     r.defined,
+		ExternalURL: t.ExternalURL,
+	}
+}
+
+// parseGlobs parses the files matching the globs into the template. It fails
+// if a file defines a template already defined by another file, which would
+// otherwise silently replace the earlier definition.
+func (t *Template) parseGlobs(paths []string) error {
+	var (
+		files []string
+		seen  = map[string]struct{}{}
+	)
 	for _, tp := range paths {
 		// ParseGlob in the template packages errors if not at least one file is
 		// matched. We want to allow empty matches that may be populated later on.
 		p, err := filepath.Glob(tp)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if len(p) > 0 {
-			if t.text, err = t.text.ParseGlob(tp); err != nil {
-				return nil, err
+		for _, f := range p {
+			if _, ok := seen[f]; ok {
+				continue
 			}
-			if t.html, err = t.html.ParseGlob(tp); err != nil {
-				return nil, err
+			seen[f] = struct{}{}
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	for _, f := range files {
+		names, err := definitions(f)
+		if err != nil {
+			return err
+		}
+		for _, n := range names {
+			if other, ok := t.defined[n]; ok && other != f {
+				return fmt.Errorf("template %q is defined in both %s and %s", n, other, f)
 			}
+			t.defined[n] = f
 		}
 	}
-	return t, nil
+
+	var err error
+	if t.text, err = t.text.ParseFiles(files...); err != nil {
+		return err
+	}
+	if t.html, err = t.html.ParseFiles(files...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// definitions returns the names of the templates the file defines.
+func definitions(file string) ([]string, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Base(file)
+	tmpl, err := tmpltext.New(root).Funcs(tmpltext.FuncMap(DefaultFuncs)).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, t := range tmpl.Templates() {
+		if t.Name() != root {
+			names = append(names, t.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Funcs adds the functions of the map to the template, replacing existing
+// functions of the same name.
+func (t *Template) Funcs(fm FuncMap) *Template {
+	t.text = t.text.Funcs(tmpltext.FuncMap(fm))
+	t.html = t.html.Funcs(tmplhtml.FuncMap(fm))
+	for _, r := range t.receivers {
+		r.Funcs(fm)
+	}
+	return t
 }
 
 // ExecuteTextString needs a meaningful doc comment (TODO(fabxc)).
@@ -117,6 +266,34 @@ func (t *Template) ExecuteHTMLString(html string, data interface{}) (string, err
 	return buf.String(), err
 }
 
+// ExecuteTextTemplate executes the text template with the name on data.
+func (t *Template) ExecuteTextTemplate(name string, data interface{}) (string, error) {
+	tmpl, err := t.text.Clone()
+	if err != nil {
+		return "", err
+	}
+	if tmpl.Lookup(name) == nil {
+		return "", fmt.Errorf("template %q not defined", name)
+	}
+	var buf bytes.Buffer
+	err = tmpl.ExecuteTemplate(&buf, name, data)
+	return buf.String(), err
+}
+
+// ExecuteHTMLTemplate executes the HTML template with the name on data.
+func (t *Template) ExecuteHTMLTemplate(name string, data interface{}) (string, error) {
+	tmpl, err := t.html.Clone()
+	if err != nil {
+		return "", err
+	}
+	if tmpl.Lookup(name) == nil {
+		return "", fmt.Errorf("template %q not defined", name)
+	}
+	var buf bytes.Buffer
+	err = tmpl.ExecuteTemplate(&buf, name, data)
+	return buf.String(), err
+}
+
 type FuncMap map[string]interface{}
 
 var DefaultFuncs = FuncMap{
@@ -135,6 +312,16 @@ var DefaultFuncs = FuncMap{
 		re := regexp.MustCompile(pattern)
 		return re.ReplaceAllString(text, repl)
 	},
+	// The markdownTo functions convert a Markdown body into the format of
+	// a receiver.
+	"markdownToSlack": MarkdownToSlack,
+	"markdownToHTML":  MarkdownToHTML,
+	"markdownToText":  MarkdownToText,
+	// oncall returns the people on call for a schedule of an escalation
+	// provider. It is replaced once escalation providers are configured.
+	"oncall": func(provider, schedule string) ([]string, error) {
+		return nil, fmt.Errorf("unknown escalation provider %q", provider)
+	},
 }
 
 // Pair is a key/value string pair.
@@ -229,6 +416,38 @@ type Data struct {
 	CommonAnnotations KV `json:"commonAnnotations"`
 
 	ExternalURL string `json:"externalURL"`
+
+	// Enrichments holds the results of the route's enrichment queries by name.
+	Enrichments map[string]*Enrichment `json:"enrichments,omitempty"`
+
+	// Groups holds the groups summarized by a digest notification, whose
+	// alerts are the alerts of all groups.
+	Groups []Group `json:"groups,omitempty"`
+}
+
+// Group is a group of alerts summarized by a digest notification.
+type Group struct {
+	Labels KV     `json:"labels"`
+	Status string `json:"status"`
+	Alerts Alerts `json:"alerts"`
+}
+
+// Enrichment holds the result of a Prometheus query run when the
+// notification was created.
+type Enrichment struct {
+	Query string `json:"query"`
+	// Value is the value of the first sample of the result.
+	Value    string   `json:"value"`
+	Samples  []Sample `json:"samples"`
+	GraphURL string   `json:"graphURL"`
+	// Error is set if the query failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Sample is a single sample of a query result.
+type Sample struct {
+	Labels KV     `json:"labels"`
+	Value  string `json:"value"`
 }
 
 // Alert holds one alert for notification templates.
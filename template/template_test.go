@@ -14,10 +14,16 @@
 package template
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/config"
 )
 
 func TestPairNames(t *testing.T) {
@@ -118,3 +124,93 @@ func TestAlertsResolved(t *testing.T) {
 		}
 	}
 }
+
+func TestExecuteTemplate(t *testing.T) {
+	tmpl, err := FromGlobs()
+	require.NoError(t, err)
+
+	data := &Data{Status: "firing", GroupLabels: KV{"alertname": "Test"}}
+
+	res, err := tmpl.ExecuteTextTemplate("__subject", data)
+	require.NoError(t, err)
+	require.Equal(t, "[FIRING:0] Test ", res)
+
+	res, err = tmpl.ExecuteHTMLTemplate("__subject", data)
+	require.NoError(t, err)
+	require.Equal(t, "[FIRING:0] Test ", res)
+
+	_, err = tmpl.ExecuteTextTemplate("missing", data)
+	require.EqualError(t, err, `template "missing" not defined`)
+}
+
+func writeTemplates(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestTemplateConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTemplates(t, dir, map[string]string{
+		"a.tmpl": `{{ define "__subject" }}A{{ end }}{{ define "team" }}A{{ end }}`,
+		"b.tmpl": `{{ define "team" }}B{{ end }}`,
+	})
+
+	// Default templates can be redefined.
+	tmpl, err := FromGlobs(filepath.Join(dir, "a.tmpl"))
+	require.NoError(t, err)
+	res, err := tmpl.ExecuteTextTemplate("__subject", nil)
+	require.NoError(t, err)
+	require.Equal(t, "A", res)
+
+	// Files matched by several globs are parsed once.
+	_, err = FromGlobs(filepath.Join(dir, "a.tmpl"), filepath.Join(dir, "a.*"))
+	require.NoError(t, err)
+
+	// Templates defined in several files are rejected instead of silently
+	// replacing each other.
+	_, err = FromGlobs(filepath.Join(dir, "*.tmpl"))
+	require.EqualError(t, err, fmt.Sprintf(`template "team" is defined in both %s and %s`, filepath.Join(dir, "a.tmpl"), filepath.Join(dir, "b.tmpl")))
+}
+
+func TestReceiverTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTemplates(t, dir, map[string]string{
+		"common.tmpl": `{{ define "footer" }}common{{ end }}`,
+		"a.tmpl":      `{{ define "team" }}A {{ template "footer" }}{{ end }}`,
+		"b.tmpl":      `{{ define "team" }}B {{ template "footer" }}{{ end }}`,
+		"c.tmpl":      `{{ define "footer" }}C{{ end }}`,
+	})
+	conf := &config.Config{
+		Templates: []string{filepath.Join(dir, "common.tmpl")},
+		Receivers: []*config.Receiver{
+			{Name: "a", Templates: []string{filepath.Join(dir, "a.tmpl")}},
+			{Name: "b", Templates: []string{filepath.Join(dir, "b.tmpl")}},
+			{Name: "c"},
+		},
+	}
+	tmpl, err := FromConfig(conf)
+	require.NoError(t, err)
+
+	// Receivers with the same template names use their own definitions.
+	for recv, expected := range map[string]string{"a": "A common", "b": "B common"} {
+		res, err := tmpl.Receiver(recv).ExecuteTextTemplate("team", nil)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	}
+	_, err = tmpl.Receiver("c").ExecuteTextTemplate("team", nil)
+	require.Error(t, err)
+	_, err = tmpl.ExecuteTextTemplate("team", nil)
+	require.Error(t, err)
+
+	// Receivers cannot redefine the templates of the global template files.
+	conf.Receivers[2].Templates = []string{filepath.Join(dir, "c.tmpl")}
+	_, err = FromConfig(conf)
+	require.EqualError(t, err, fmt.Sprintf(`receiver "c": template "footer" is defined in both %s and %s`, filepath.Join(dir, "common.tmpl"), filepath.Join(dir, "c.tmpl")))
+}
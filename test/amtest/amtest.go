@@ -0,0 +1,276 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package amtest runs Alertmanagers in the process of Go tests, so that
+// receivers, templates and configurations can be tested end to end without
+// building and starting the alertmanager binary:
+//
+//	rcv := amtest.NewReceiver(t)
+//	defer rcv.Close()
+//
+//	am := amtest.Start(t, fmt.Sprintf(conf, rcv.URL()))
+//	defer am.Stop()
+//
+//	am.Push(&model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}})
+//	msgs := rcv.Wait(1, 10*time.Second)
+//
+// The Alertmanagers do not join a cluster and keep their state in memory.
+package amtest
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
+
+	"github.com/prometheus/alertmanager/ack"
+	amapi "github.com/prometheus/alertmanager/api"
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/engine"
+	"github.com/prometheus/alertmanager/msgref"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// retention is how long the silences, acknowledgements and notification log
+// entries of the Alertmanagers are kept, which outlasts any test.
+const retention = 24 * time.Hour
+
+// Alertmanager is an Alertmanager running in the test process. It serves
+// the API under URL and sends notifications like the alertmanager binary.
+type Alertmanager struct {
+	t      testing.TB
+	server *httptest.Server
+	client api.Client
+	logs   *buffer
+	logger log.Logger
+
+	marker       types.Marker
+	alerts       *mem.Alerts
+	silences     *silence.Silences
+	acks         *ack.Acks
+	refs         *msgref.Refs
+	nflog        *nflog.Log
+	suppressions *notify.Suppressions
+	api          *amapi.API
+	engine       *engine.Engine
+
+	mtx  sync.RWMutex
+	conf *config.Config
+}
+
+// Start starts an Alertmanager with the configuration. It fails the test if
+// the configuration is invalid. The Alertmanager must be stopped with Stop.
+func Start(t testing.TB, conf string) *Alertmanager {
+	logs := &buffer{}
+	am := &Alertmanager{
+		t:            t,
+		logs:         logs,
+		logger:       log.NewLogfmtLogger(logs),
+		marker:       types.NewMarker(),
+		suppressions: notify.NewSuppressions(),
+	}
+
+	var err error
+	if am.alerts, err = mem.NewAlerts(am.marker, 30*time.Minute); err != nil {
+		t.Fatalf("Creating alerts failed: %s", err)
+	}
+	if am.silences, err = silence.New(silence.Options{
+		Retention: retention,
+		Marker:    am.marker,
+		Logger:    log.With(am.logger, "component", "silences"),
+	}); err != nil {
+		t.Fatalf("Creating silences failed: %s", err)
+	}
+	if am.acks, err = ack.New(ack.Options{
+		Retention: retention,
+		Logger:    log.With(am.logger, "component", "acks"),
+	}); err != nil {
+		t.Fatalf("Creating acknowledgements failed: %s", err)
+	}
+	if am.refs, err = msgref.New(msgref.Options{
+		Retention: retention,
+		Logger:    log.With(am.logger, "component", "msgrefs"),
+	}); err != nil {
+		t.Fatalf("Creating message references failed: %s", err)
+	}
+	if am.nflog, err = nflog.New(
+		nflog.WithRetention(retention),
+		nflog.WithLogger(log.With(am.logger, "component", "nflog")),
+	); err != nil {
+		t.Fatalf("Creating notification log failed: %s", err)
+	}
+
+	am.engine = engine.New(engine.Options{
+		Alerts:          am.alerts,
+		Marker:          am.marker,
+		Silences:        am.silences,
+		Acks:            am.acks,
+		Refs:            am.refs,
+		NotificationLog: am.nflog,
+		Suppressions:    am.suppressions,
+		Shards:          1,
+		Logger:          am.logger,
+	})
+	am.api = amapi.New(amapi.Options{
+		Alerts:       am.alerts,
+		Silences:     am.silences,
+		Acks:         am.acks,
+		Groups:       am.engine.Groups,
+		AggrGroups:   am.engine.AggregationGroups,
+		AlertStatus:  am.marker.Status,
+		History:      am.nflog.History,
+		Inhibitions:  am.engine.Inhibitions,
+		Suppressions: am.suppressions,
+		Logger:       log.With(am.logger, "component", "api"),
+	})
+
+	router := route.New()
+	am.api.Register(router.WithPrefix("/api/v1"))
+	am.api.RegisterV2(router.WithPrefix("/api/v2"))
+	am.server = httptest.NewServer(router)
+
+	if am.client, err = api.NewClient(api.Config{Address: am.server.URL}); err != nil {
+		am.server.Close()
+		t.Fatalf("Creating API client failed: %s", err)
+	}
+
+	if err := am.load(conf); err != nil {
+		am.Stop()
+		t.Fatalf("Loading configuration failed: %s", err)
+	}
+	return am
+}
+
+// URL returns the base URL of the Alertmanager, under which the API is
+// served at /api/v1 and /api/v2.
+func (am *Alertmanager) URL() string {
+	return am.server.URL
+}
+
+// Client returns a client of the API of the Alertmanager, for example to
+// create silences with client.NewSilenceAPI.
+func (am *Alertmanager) Client() api.Client {
+	return am.client
+}
+
+// Push sends the alerts to the Alertmanager through its API. Alerts without
+// a start time start now. It fails the test if the alerts are rejected.
+func (am *Alertmanager) Push(alerts ...*model.Alert) {
+	cas := make([]client.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		ca := client.Alert{
+			Labels:       client.LabelSet{},
+			Annotations:  client.LabelSet{},
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		}
+		for n, v := range a.Labels {
+			ca.Labels[client.LabelName(n)] = client.LabelValue(v)
+		}
+		for n, v := range a.Annotations {
+			ca.Annotations[client.LabelName(n)] = client.LabelValue(v)
+		}
+		cas = append(cas, ca)
+	}
+	if err := client.NewAlertAPI(am.client).Push(context.Background(), cas...); err != nil {
+		am.t.Fatalf("Pushing alerts failed: %s", err)
+	}
+}
+
+// Reload replaces the configuration of the Alertmanager like a reload of the
+// configuration file. The aggregation groups start over, the alerts,
+// silences and notification log are kept. It fails the test if the
+// configuration is invalid.
+func (am *Alertmanager) Reload(conf string) {
+	if err := am.load(conf); err != nil {
+		am.t.Fatalf("Loading configuration failed: %s", err)
+	}
+}
+
+// Config returns the configuration loaded last.
+func (am *Alertmanager) Config() *config.Config {
+	am.mtx.RLock()
+	defer am.mtx.RUnlock()
+	return am.conf
+}
+
+// Stop stops the Alertmanager and waits for the notifications in flight.
+// The logs of the Alertmanager are added to the test if it failed.
+func (am *Alertmanager) Stop() {
+	am.server.Close()
+	am.engine.Stop()
+	am.alerts.Close()
+
+	if am.t.Failed() {
+		am.t.Logf("Alertmanager logs:\n%s", am.logs)
+	}
+}
+
+// load applies the configuration to the engine, like the reload of the
+// alertmanager binary.
+func (am *Alertmanager) load(s string) error {
+	conf, err := config.Load(s)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.FromConfig(conf)
+	if err != nil {
+		return err
+	}
+	if tmpl.ExternalURL, err = url.Parse(am.server.URL); err != nil {
+		return err
+	}
+	if err := am.api.Update(conf, tmpl, time.Duration(conf.Global.ResolveTimeout)); err != nil {
+		return err
+	}
+
+	am.engine.ApplyConfig(conf, tmpl)
+
+	am.mtx.Lock()
+	am.conf = conf
+	am.mtx.Unlock()
+	return nil
+}
+
+// buffer collects the logs of an Alertmanager.
+type buffer struct {
+	b   bytes.Buffer
+	mtx sync.Mutex
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.b.String()
+}
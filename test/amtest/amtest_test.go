@@ -0,0 +1,113 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amtest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/client"
+	"github.com/prometheus/alertmanager/test/amtest"
+	"github.com/prometheus/alertmanager/types"
+)
+
+const conf = `
+route:
+  receiver: default
+  group_by: [alertname]
+  group_wait: 100ms
+  group_interval: 100ms
+  repeat_interval: 1h
+receivers:
+- name: default
+  webhook_configs:
+  - url: %s
+    send_resolved: true
+`
+
+func TestAlertmanager(t *testing.T) {
+	rcv := amtest.NewReceiver(t)
+	defer rcv.Close()
+
+	am := amtest.Start(t, fmt.Sprintf(conf, rcv.URL()))
+	defer am.Stop()
+
+	am.Push(&model.Alert{Labels: model.LabelSet{"alertname": "HighLatency", "service": "api"}})
+
+	msgs := rcv.Wait(1, 5*time.Second)
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.Receiver != "default" || msg.Status != "firing" {
+		t.Errorf("Unexpected notification to %q with status %q", msg.Receiver, msg.Status)
+	}
+	if len(msg.Alerts) != 1 || msg.Alerts[0].Labels["service"] != "api" {
+		t.Errorf("Unexpected alerts %v", msg.Alerts)
+	}
+
+	// Silenced alerts are not notified.
+	_, err := client.NewSilenceAPI(am.Client()).Set(context.Background(), types.Silence{
+		Matchers:  types.Matchers{types.NewMatcher("alertname", "Silenced")},
+		StartsAt:  time.Now(),
+		EndsAt:    time.Now().Add(time.Hour),
+		CreatedBy: "amtest",
+		Comment:   "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	am.Push(&model.Alert{Labels: model.LabelSet{"alertname": "Silenced"}})
+
+	now := time.Now()
+	am.Push(&model.Alert{
+		Labels:   model.LabelSet{"alertname": "HighLatency", "service": "api"},
+		StartsAt: now.Add(-time.Minute),
+		EndsAt:   now,
+	})
+	msgs = rcv.Wait(2, 5*time.Second)
+	if msg := msgs[1]; msg.Status != "resolved" || msg.GroupLabels["alertname"] != "HighLatency" {
+		t.Errorf("Expected resolved notification of HighLatency, got %s of %v", msg.Status, msg.GroupLabels)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if n := len(rcv.Notifications()); n != 2 {
+		t.Errorf("Expected no notification of the silenced alert, got %d notifications", n)
+	}
+}
+
+func TestAlertmanagerReload(t *testing.T) {
+	rcv1 := amtest.NewReceiver(t)
+	defer rcv1.Close()
+	rcv2 := amtest.NewReceiver(t)
+	defer rcv2.Close()
+
+	am := amtest.Start(t, fmt.Sprintf(conf, rcv1.URL()))
+	defer am.Stop()
+
+	am.Reload(fmt.Sprintf(conf, rcv2.URL()))
+	if u := am.Config().Receivers[0].WebhookConfigs[0].URL; u != rcv2.URL() {
+		t.Errorf("Expected reloaded webhook URL %s, got %s", rcv2.URL(), u)
+	}
+
+	am.Push(&model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}})
+	rcv2.Wait(1, 5*time.Second)
+	if n := len(rcv1.Notifications()); n != 0 {
+		t.Errorf("Expected no notifications to the previous receiver, got %d", n)
+	}
+}
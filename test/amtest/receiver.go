@@ -0,0 +1,118 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+)
+
+// Receiver is a webhook receiver capturing the notifications sent to it, to
+// assert on them in tests. Alertmanagers send notifications to it with a
+// webhook_configs entry with its URL.
+type Receiver struct {
+	t      testing.TB
+	server *httptest.Server
+
+	mtx      sync.Mutex
+	status   int
+	messages []*notify.WebhookMessage
+	// added is closed and replaced when a notification is received.
+	added chan struct{}
+}
+
+// NewReceiver starts a receiver. It must be closed with Close.
+func NewReceiver(t testing.TB) *Receiver {
+	r := &Receiver{
+		t:      t,
+		status: http.StatusOK,
+		added:  make(chan struct{}),
+	}
+	r.server = httptest.NewServer(r)
+	return r
+}
+
+// URL returns the URL to send webhook notifications to.
+func (r *Receiver) URL() string {
+	return r.server.URL
+}
+
+// SetStatus sets the status code the receiver answers notifications with,
+// for example to test retries. Only notifications answered with a 2xx
+// status code are captured.
+func (r *Receiver) SetStatus(code int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.status = code
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var msg notify.WebhookMessage
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.status/100 == 2 {
+		r.messages = append(r.messages, &msg)
+		close(r.added)
+		r.added = make(chan struct{})
+	}
+	w.WriteHeader(r.status)
+}
+
+// Notifications returns the notifications received so far in the order they
+// arrived.
+func (r *Receiver) Notifications() []*notify.WebhookMessage {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]*notify.WebhookMessage(nil), r.messages...)
+}
+
+// Wait waits until the receiver received at least n notifications and
+// returns all notifications received. It fails the test if fewer arrive
+// within the timeout.
+func (r *Receiver) Wait(n int, timeout time.Duration) []*notify.WebhookMessage {
+	deadline := time.After(timeout)
+	for {
+		r.mtx.Lock()
+		msgs, added := append([]*notify.WebhookMessage(nil), r.messages...), r.added
+		r.mtx.Unlock()
+		if len(msgs) >= n {
+			return msgs
+		}
+
+		select {
+		case <-added:
+		case <-deadline:
+			r.t.Fatalf("Expected %d notifications within %s, got %d", n, timeout, len(msgs))
+			return msgs
+		}
+	}
+}
+
+// Close stops the receiver.
+func (r *Receiver) Close() {
+	r.server.Close()
+}
@@ -28,6 +28,10 @@ type Matcher struct {
 	Name    string `json:"name"`
 	Value   string `json:"value"`
 	IsRegex bool   `json:"isRegex"`
+	// IsNegative inverts the matcher, i.e. it matches the label sets whose
+	// value of the label is not equal to the value or does not match the
+	// regular expression.
+	IsNegative bool `json:"isNegative,omitempty"`
 
 	regex *regexp.Regexp
 }
@@ -45,10 +49,20 @@ func (m *Matcher) Init() error {
 }
 
 func (m *Matcher) String() string {
-	if m.IsRegex {
-		return fmt.Sprintf("%s=~%q", m.Name, m.Value)
-	}
-	return fmt.Sprintf("%s=%q", m.Name, m.Value)
+	return fmt.Sprintf("%s%s%q", m.Name, m.Operator(), m.Value)
+}
+
+// Operator returns the operator of the matcher, i.e. one of =, !=, =~ and !~.
+func (m *Matcher) Operator() string {
+	switch {
+	case m.IsRegex && m.IsNegative:
+		return "!~"
+	case m.IsRegex:
+		return "=~"
+	case m.IsNegative:
+		return "!="
+	}
+	return "="
 }
 
 // Validate returns true iff all fields of the matcher have valid values.
@@ -60,7 +74,7 @@ func (m *Matcher) Validate() error {
 		if _, err := regexp.Compile(m.Value); err != nil {
 			return fmt.Errorf("invalid regular expression %q", m.Value)
 		}
-	} else if !model.LabelValue(m.Value).IsValid() || len(m.Value) == 0 {
+	} else if !model.LabelValue(m.Value).IsValid() || (len(m.Value) == 0 && !m.IsNegative) {
 		return fmt.Errorf("invalid value %q", m.Value)
 	}
 	return nil
@@ -75,9 +89,9 @@ func (m *Matcher) Match(lset model.LabelSet) bool {
 	v := lset[model.LabelName(m.Name)]
 
 	if m.IsRegex {
-		return m.regex.MatchString(string(v))
+		return m.regex.MatchString(string(v)) != m.IsNegative
 	}
-	return string(v) == m.Value
+	return (string(v) == m.Value) != m.IsNegative
 }
 
 // NewMatcher returns a new matcher that compares against equality of
@@ -130,7 +144,10 @@ func (ms Matchers) Less(i, j int) bool {
 	if ms[i].Value < ms[j].Value {
 		return true
 	}
-	return !ms[i].IsRegex && ms[j].IsRegex
+	if ms[i].IsRegex != ms[j].IsRegex {
+		return !ms[i].IsRegex
+	}
+	return !ms[i].IsNegative && ms[j].IsNegative
 }
 
 // Equal returns whether both Matchers are equal.
@@ -14,6 +14,8 @@
 package types
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -44,6 +46,8 @@ type Marker interface {
 	SetSilenced(alert model.Fingerprint, ids ...string)
 
 	Count(...AlertState) int
+	// CountSilencedBy returns the number of alerts muted by each silence.
+	CountSilencedBy() map[string]int
 
 	Status(model.Fingerprint) AlertStatus
 	Delete(model.Fingerprint)
@@ -88,6 +92,20 @@ func (m *memMarker) Count(states ...AlertState) int {
 	return count
 }
 
+// CountSilencedBy implements Marker.
+func (m *memMarker) CountSilencedBy() map[string]int {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	counts := map[string]int{}
+	for _, s := range m.m {
+		for _, id := range s.SilencedBy {
+			counts[id]++
+		}
+	}
+	return counts
+}
+
 // SetSilenced sets the AlertStatus to suppressed and stores the associated silence IDs.
 func (m *memMarker) SetSilenced(alert model.Fingerprint, ids ...string) {
 	m.mtx.Lock()
@@ -322,6 +340,20 @@ type MuteFunc func(model.LabelSet) bool
 // Mutes implements the Muter interface.
 func (f MuteFunc) Mutes(lset model.LabelSet) bool { return f(lset) }
 
+// A ReceiverMuter is a Muter that can also mute label sets only for the
+// notifications of a receiver.
+type ReceiverMuter interface {
+	Muter
+	MutesReceiver(receiver string, lset model.LabelSet) bool
+}
+
+// A RuleMuter is a ReceiverMuter that also reports the index of the rule
+// muting a label set.
+type RuleMuter interface {
+	ReceiverMuter
+	MutingRule(receiver string, lset model.LabelSet) (int, bool)
+}
+
 // A Silence determines whether a given label set is muted.
 type Silence struct {
 	// A unique identifier across all connected instances.
@@ -349,6 +381,27 @@ type Silence struct {
 	CreatedBy string `json:"createdBy"`
 	Comment   string `json:"comment,omitempty"`
 
+	// ExternalID is an identifier supplied by the client, e.g. to find the
+	// silence again when it is applied from a file.
+	ExternalID string `json:"externalId,omitempty"`
+
+	// Metadata are structured fields describing the silence, e.g. the
+	// ticket of a change.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// The number of times the silence was updated in place and its
+	// previous versions, oldest first.
+	Version uint64     `json:"version,omitempty"`
+	History []*Silence `json:"history,omitempty"`
+
+	// Renewal optionally extends the end of the silence while it keeps
+	// muting alerts.
+	Renewal *SilenceRenewal `json:"renewal,omitempty"`
+
+	// Instances are the URLs of the Alertmanagers that returned the silence
+	// when several of them are queried together by a client.
+	Instances []string `json:"instances,omitempty"`
+
 	// timeFunc provides the time against which to evaluate
 	// the silence. Used for test injection.
 	now func() time.Time
@@ -356,6 +409,16 @@ type Silence struct {
 	Status SilenceStatus `json:"status"`
 }
 
+// SilenceRenewal describes how the end of a silence is extended while it
+// keeps muting alerts.
+type SilenceRenewal struct {
+	// Increment is the duration, such as "2h", the end of the silence is
+	// moved past the time of a renewal.
+	Increment string `json:"increment"`
+	// Until is the latest time the silence may be extended to.
+	Until time.Time `json:"until"`
+}
+
 // Expired return if the silence is expired
 // meaning that both StartsAt and EndsAt are equal
 func (s *Silence) Expired() bool {
@@ -364,6 +427,15 @@ func (s *Silence) Expired() bool {
 
 type SilenceStatus struct {
 	State SilenceState `json:"state"`
+	// Suppressed are the alerts the silence currently suppresses. It is
+	// only set by the API if requested.
+	Suppressed *SuppressedAlerts `json:"suppressed,omitempty"`
+}
+
+// SuppressedAlerts are the unresolved alerts a silence suppresses.
+type SuppressedAlerts struct {
+	Count        int      `json:"count"`
+	Fingerprints []string `json:"fingerprints"`
 }
 
 type SilenceState string
@@ -384,3 +456,86 @@ func CalcSilenceState(start, end time.Time) SilenceState {
 	}
 	return SilenceStateExpired
 }
+
+// An Acknowledgement marks a firing alert as being worked on. Unlike a
+// silence it does not hide the alert but pauses repeat notifications for it
+// until it expires.
+type Acknowledgement struct {
+	// Fingerprint of the acknowledged alert.
+	Fingerprint string `json:"fingerprint"`
+
+	// Information about who acknowledged the alert for which reason.
+	CreatedBy string `json:"createdBy"`
+	Comment   string `json:"comment,omitempty"`
+
+	// The last time the acknowledgement was updated.
+	UpdatedAt time.Time `json:"updatedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Validate returns an error if the acknowledgement is invalid.
+func (a *Acknowledgement) Validate() error {
+	if _, err := model.ParseFingerprint(a.Fingerprint); err != nil {
+		return fmt.Errorf("invalid fingerprint %q", a.Fingerprint)
+	}
+	if a.CreatedBy == "" {
+		return fmt.Errorf("creator information missing")
+	}
+	if a.ExpiresAt.IsZero() {
+		return fmt.Errorf("expiration time missing")
+	}
+	return nil
+}
+
+// Expired returns whether the acknowledgement has expired at the given time.
+func (a *Acknowledgement) Expired(t time.Time) bool {
+	return !a.ExpiresAt.After(t)
+}
+
+// A FilterPreset is a named filter of the alerts shown by the web UI, which
+// is shared by all users so that on-call dashboards can be pre-built.
+type FilterPreset struct {
+	Name string `json:"name"`
+
+	// Matchers the alerts must match.
+	Matchers Matchers `json:"matchers"`
+	// Labels the alerts are grouped by, they are not grouped if empty.
+	GroupBy []string `json:"groupBy,omitempty"`
+	// Regular expression the receivers of the alerts must match.
+	Receiver string `json:"receiver,omitempty"`
+
+	CreatedBy string `json:"createdBy"`
+
+	// The last time the preset was updated and whether it was deleted.
+	// Deleted presets are kept until the retention period has passed, so
+	// that their deletion is replicated across the cluster.
+	UpdatedAt time.Time `json:"updatedAt"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// Validate returns an error if the filter preset is invalid.
+func (p *FilterPreset) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name missing")
+	}
+	if strings.ContainsAny(p.Name, "/?#") {
+		return fmt.Errorf("invalid name %q", p.Name)
+	}
+	for _, m := range p.Matchers {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid matcher: %s", err)
+		}
+	}
+	for _, ln := range p.GroupBy {
+		if !model.LabelName(ln).IsValid() {
+			return fmt.Errorf("invalid group by label %q", ln)
+		}
+	}
+	if _, err := regexp.Compile(p.Receiver); err != nil {
+		return fmt.Errorf("invalid receiver regular expression %q", p.Receiver)
+	}
+	if p.CreatedBy == "" {
+		return fmt.Errorf("creator information missing")
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package web secures the HTTP server of the Alertmanager with TLS,
+// authentication and authorization configured in a web config file.
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// Role determines which requests an authenticated client may make.
+type Role string
+
+// Roles of clients. The write role includes the read role.
+const (
+	RoleNone  Role = "none"
+	RoleRead  Role = "read"
+	RoleWrite Role = "write"
+)
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	switch Role(s) {
+	case RoleNone, RoleRead, RoleWrite:
+		*r = Role(s)
+		return nil
+	}
+	return fmt.Errorf("unknown role %q", s)
+}
+
+// allows returns whether the role includes the other role.
+func (r Role) allows(other Role) bool {
+	switch other {
+	case RoleRead:
+		return r == RoleRead || r == RoleWrite
+	case RoleWrite:
+		return r == RoleWrite
+	}
+	return true
+}
+
+// Config is the configuration of the web config file.
+type Config struct {
+	TLSConfig *TLSConfig `yaml:"tls_server_config,omitempty"`
+
+	BasicAuthUsers []User       `yaml:"basic_auth_users,omitempty"`
+	BearerTokens   []Token      `yaml:"bearer_tokens,omitempty"`
+	ClientCerts    []ClientCert `yaml:"client_certs,omitempty"`
+	// AnonymousRole is the role of requests that carry no credentials.
+	AnonymousRole Role `yaml:"anonymous_role,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Config
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.AnonymousRole == "" {
+		c.AnonymousRole = RoleNone
+	}
+
+	names := map[string]struct{}{}
+	for _, u := range c.BasicAuthUsers {
+		if _, ok := names[u.Username]; ok {
+			return fmt.Errorf("duplicate user %q", u.Username)
+		}
+		names[u.Username] = struct{}{}
+	}
+	if len(c.ClientCerts) > 0 && (c.TLSConfig == nil || c.TLSConfig.ClientCAFile == "") {
+		return fmt.Errorf("client_certs require a client_ca_file in tls_server_config")
+	}
+	return nil
+}
+
+// TLSConfig configures the TLS of the HTTP server.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile is the CA certificate the certificates of clients are
+	// verified with. Clients without a certificate may still authenticate
+	// otherwise.
+	ClientCAFile string `yaml:"client_ca_file,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TLSConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain TLSConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("cert_file and key_file must be set in tls_server_config")
+	}
+	return nil
+}
+
+// User authenticates with basic authentication.
+type User struct {
+	Username string        `yaml:"username"`
+	Password config.Secret `yaml:"password"`
+	Role     Role          `yaml:"role"`
+	// Tenant restricts the user to the tenant if tenancy is enabled.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (u *User) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain User
+	if err := unmarshal((*plain)(u)); err != nil {
+		return err
+	}
+	if u.Username == "" || u.Password == "" {
+		return fmt.Errorf("username and password must be set for basic auth users")
+	}
+	if u.Role == "" {
+		u.Role = RoleRead
+	}
+	return nil
+}
+
+// Token authenticates with a bearer token. The name identifies the client
+// in the audit log.
+type Token struct {
+	Name  string        `yaml:"name"`
+	Token config.Secret `yaml:"token"`
+	Role  Role          `yaml:"role"`
+	// Tenant restricts the client to the tenant if tenancy is enabled.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (t *Token) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Token
+	if err := unmarshal((*plain)(t)); err != nil {
+		return err
+	}
+	if t.Name == "" || t.Token == "" {
+		return fmt.Errorf("name and token must be set for bearer tokens")
+	}
+	if t.Role == "" {
+		t.Role = RoleRead
+	}
+	return nil
+}
+
+// ClientCert authenticates clients presenting a certificate with the common
+// name that is signed by the client CA.
+type ClientCert struct {
+	CommonName string `yaml:"common_name"`
+	Role       Role   `yaml:"role"`
+	// Tenant restricts the client to the tenant if tenancy is enabled.
+	Tenant string `yaml:"tenant,omitempty"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *ClientCert) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain ClientCert
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.CommonName == "" {
+		return fmt.Errorf("common_name must be set for client certificates")
+	}
+	if c.Role == "" {
+		c.Role = RoleRead
+	}
+	return nil
+}
+
+// Load parses the YAML input s into a Config.
+func Load(s string) (*Config, error) {
+	cfg := &Config{AnonymousRole: RoleNone}
+	if err := yaml.UnmarshalStrict([]byte(s), cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFile parses the given YAML file into a Config. Relative paths of
+// certificate files are resolved against the directory of the file.
+func LoadFile(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if c := cfg.TLSConfig; c != nil {
+		dir := filepath.Dir(filename)
+		for _, f := range []*string{&c.CertFile, &c.KeyFile, &c.ClientCAFile} {
+			if *f != "" && !filepath.IsAbs(*f) {
+				*f = filepath.Join(dir, *f)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// tlsConfig returns the TLS configuration of the server or nil if TLS is
+// not configured.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig == nil {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLSConfig.CertFile, c.TLSConfig.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if c.TLSConfig.ClientCAFile != "" {
+		b, err := ioutil.ReadFile(c.TLSConfig.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in %s", c.TLSConfig.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
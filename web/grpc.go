@@ -0,0 +1,183 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+)
+
+// GRPCServer serves the gRPC API.
+type GRPCServer struct {
+	addr string
+	srv  *grpc.Server
+
+	mtx      sync.Mutex
+	stopping bool
+}
+
+// NewGRPCServer returns a gRPC server listening on the address with the TLS
+// configuration and the authorization of the config. The methods for which
+// readOnly returns true require the read role, all others the write role.
+// Of the options, only MaxRequestBodySize, which limits the size of received
+// messages, and RestrictTLS apply.
+func NewGRPCServer(addr string, c *Config, opts ServerOptions, readOnly func(fullMethod string) bool) (*GRPCServer, error) {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var sopts []grpc.ServerOption
+	if tlsConfig != nil {
+		if opts.RestrictTLS != nil {
+			tlsConfig = restrictTLS(tlsConfig, opts.RestrictTLS)
+		}
+		sopts = append(sopts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if opts.MaxRequestBodySize > 0 {
+		sopts = append(sopts, grpc.MaxRecvMsgSize(int(opts.MaxRequestBodySize)))
+	}
+
+	required := func(fullMethod string) Role {
+		if readOnly(fullMethod) {
+			return RoleRead
+		}
+		return RoleWrite
+	}
+	sopts = append(sopts,
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := c.authorizeGRPC(ctx, required(info.FullMethod))
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := c.authorizeGRPC(ss.Context(), required(info.FullMethod))
+			if err != nil {
+				return err
+			}
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+	return &GRPCServer{addr: addr, srv: grpc.NewServer(sopts...)}, nil
+}
+
+// Server returns the gRPC server to register services on.
+func (s *GRPCServer) Server() *grpc.Server {
+	return s.srv
+}
+
+// ListenAndServe listens on the address of the server and serves calls
+// until the server is shut down.
+func (s *GRPCServer) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	err = s.srv.Serve(l)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.stopping {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for the calls in flight to
+// complete until the context is done. Then the remaining calls, e.g. streams
+// that only end when their clients cancel them, are canceled.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	s.mtx.Lock()
+	s.stopping = true
+	s.mtx.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// serverStream is a grpc.ServerStream with another context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorizeGRPC authenticates the client of a call like Handler does for
+// HTTP requests. It returns an error unless the client has the required
+// role. Like the headers of HTTP requests, the metadata of the returned
+// context carry the name and tenant of the client in place of the ones the
+// client sent.
+func (c *Config) authorizeGRPC(ctx context.Context, required Role) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	if c.hasCredentials() {
+		delete(md, strings.ToLower(audit.ActorHeader))
+		delete(md, strings.ToLower(config.TenantHeader))
+	}
+
+	// The credentials of gRPC calls are the same as of HTTP requests.
+	r := &http.Request{Header: http.Header{}}
+	if v := md["authorization"]; len(v) > 0 {
+		r.Header.Set("Authorization", v[0])
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if ti, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &ti.State
+		}
+	}
+
+	name, tenant, role, ok := c.authenticate(r)
+	if !ok || (name == "" && !role.allows(required)) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	if !role.allows(required) {
+		return nil, status.Error(codes.PermissionDenied, "Forbidden")
+	}
+
+	if name != "" {
+		md[strings.ToLower(audit.ActorHeader)] = []string{name}
+	}
+	if tenant != "" {
+		md[strings.ToLower(config.TenantHeader)] = []string{tenant}
+	}
+	return metadata.NewIncomingContext(ctx, md), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+)
+
+func TestAuthorizeGRPC(t *testing.T) {
+	conf, err := Load(`
+basic_auth_users:
+- username: bob
+  password: secret
+  tenant: team-b
+bearer_tokens:
+- name: prometheus
+  token: t0ken
+  role: write
+anonymous_role: read
+`)
+	require.NoError(t, err)
+
+	basic := func(user, pass string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	}
+	for _, tc := range []struct {
+		auth     string
+		required Role
+
+		code   codes.Code
+		actor  string
+		tenant string
+	}{
+		{required: RoleRead, code: codes.OK},
+		{required: RoleWrite, code: codes.Unauthenticated},
+		{auth: "Bearer t0ken", required: RoleWrite, code: codes.OK, actor: "prometheus"},
+		{auth: "Bearer wrong", required: RoleRead, code: codes.Unauthenticated},
+		{auth: basic("bob", "secret"), required: RoleRead, code: codes.OK, actor: "bob", tenant: "team-b"},
+		{auth: basic("bob", "secret"), required: RoleWrite, code: codes.PermissionDenied},
+	} {
+		// Clients cannot choose their name or tenant.
+		md := metadata.Pairs(audit.ActorHeader, "mallory", config.TenantHeader, "team-a")
+		if tc.auth != "" {
+			md["authorization"] = []string{tc.auth}
+		}
+		ctx, err := conf.authorizeGRPC(metadata.NewIncomingContext(context.Background(), md), tc.required)
+		require.Equal(t, tc.code, grpc.Code(err), tc.auth)
+		if err != nil {
+			continue
+		}
+		md, _ = metadata.FromIncomingContext(ctx)
+		require.Equal(t, tc.actor, strings.Join(md[strings.ToLower(audit.ActorHeader)], ","), tc.auth)
+		require.Equal(t, tc.tenant, strings.Join(md[strings.ToLower(config.TenantHeader)], ","), tc.auth)
+	}
+}
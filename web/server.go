@@ -0,0 +1,179 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/prometheus/alertmanager/audit"
+)
+
+// ServerOptions configure the HTTP server independently of the web config
+// file.
+type ServerOptions struct {
+	// ReadTimeout, WriteTimeout and IdleTimeout are the timeouts of the
+	// http.Server. Zero disables them.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// MaxRequestBodySize is the maximum size of request bodies in bytes, 0
+	// if unlimited.
+	MaxRequestBodySize int64
+	// AccessLogger logs every request if not nil.
+	AccessLogger log.Logger
+	// RestrictTLS is applied to the TLS configuration of every connection
+	// if not nil, e.g. to enforce the crypto policy of the configuration.
+	RestrictTLS func(*tls.Config)
+}
+
+// Server serves the web interface and API.
+type Server struct {
+	srv *http.Server
+}
+
+// NewServer returns a server serving h on the address with the TLS
+// configuration and the authorization of the config.
+func NewServer(addr string, h http.Handler, c *Config, opts ServerOptions, public ...string) (*Server, error) {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil && opts.RestrictTLS != nil {
+		tlsConfig = restrictTLS(tlsConfig, opts.RestrictTLS)
+	}
+	h = c.Handler(h, public...)
+	if opts.MaxRequestBodySize > 0 {
+		h = limitBody(h, opts.MaxRequestBodySize)
+	}
+	if opts.AccessLogger != nil {
+		h = accessLog(h, opts.AccessLogger)
+	}
+	return &Server{
+		srv: &http.Server{
+			Addr:         addr,
+			Handler:      h,
+			TLSConfig:    tlsConfig,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+			IdleTimeout:  opts.IdleTimeout,
+		},
+	}, nil
+}
+
+// ListenAndServe listens on the address of the server and serves requests
+// until the server is shut down.
+func (s *Server) ListenAndServe() error {
+	var err error
+	if s.srv.TLSConfig == nil {
+		err = s.srv.ListenAndServe()
+	} else {
+		err = s.srv.ListenAndServeTLS("", "")
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for the requests in flight
+// to complete until the context is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// restrictTLS returns a TLS configuration applying restrict to a copy of cfg
+// for every connection, so that changes of the restriction apply to new
+// connections.
+func restrictTLS(cfg *tls.Config, restrict func(*tls.Config)) *tls.Config {
+	base := cfg.Clone()
+	// The configuration per connection replaces the one the server enables
+	// HTTP/2 in.
+	if len(base.NextProtos) == 0 {
+		base.NextProtos = []string{"h2", "http/1.1"}
+	}
+	res := cfg.Clone()
+	res.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		restrict(c)
+		return c, nil
+	}
+	return res
+}
+
+// limitBody rejects requests whose body exceeds the size in bytes.
+func limitBody(h http.Handler, size int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > size {
+			http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, size)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// accessLog logs the requests served by h with their response status, size
+// and latency.
+func accessLog(h http.Handler, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		kvs := []interface{}{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"size", rec.size,
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr,
+		}
+		// The authenticated client is set by the handler.
+		if actor := r.Header.Get(audit.ActorHeader); actor != "" {
+			kvs = append(kvs, "actor", actor)
+		}
+		level.Info(logger).Log(kvs...)
+	})
+}
+
+// responseRecorder records the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Flush implements the http.Flusher interface for streamed responses.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
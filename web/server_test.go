@@ -0,0 +1,83 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	s, err := NewServer(":0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write([]byte("ok"))
+	}), &Config{AnonymousRole: RoleWrite}, ServerOptions{
+		MaxRequestBodySize: 4,
+		AccessLogger:       log.NewLogfmtLogger(&buf),
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		body string
+		// chunked hides the size of the body from the server.
+		chunked bool
+		code    int
+	}{
+		{body: "1234", code: http.StatusOK},
+		{body: "12345", code: http.StatusRequestEntityTooLarge},
+		{body: "12345", chunked: true, code: http.StatusRequestEntityTooLarge},
+	} {
+		r := httptest.NewRequest("POST", "/api/v1/alerts", strings.NewReader(tc.body))
+		if tc.chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		s.srv.Handler.ServeHTTP(w, r)
+		require.Equal(t, tc.code, w.Code, tc.body)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "method=POST path=/api/v1/alerts status=200 size=2 duration=")
+	require.Contains(t, lines[1], "status=413")
+}
+
+func TestRestrictTLS(t *testing.T) {
+	minVersion := uint16(tls.VersionTLS12)
+	cfg := restrictTLS(&tls.Config{}, func(c *tls.Config) { c.MinVersion = minVersion })
+
+	c, err := cfg.GetConfigForClient(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), c.MinVersion)
+	require.Equal(t, []string{"h2", "http/1.1"}, c.NextProtos)
+
+	// Changes of the restriction apply to new connections.
+	minVersion = tls.VersionTLS13
+	c, err = cfg.GetConfigForClient(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), c.MinVersion)
+	require.Zero(t, cfg.MinVersion)
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+)
+
+// requiredRole returns the role needed for the request. Requests that do
+// not modify any state only require the read role.
+func requiredRole(r *http.Request) Role {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return RoleRead
+	}
+	return RoleWrite
+}
+
+// authenticate returns the name, tenant and role of the client. ok is false
+// if the request carries invalid credentials.
+func (c *Config) authenticate(r *http.Request) (name, tenant string, role Role, ok bool) {
+	if user, pass, hasAuth := r.BasicAuth(); hasAuth {
+		for _, u := range c.BasicAuthUsers {
+			if u.Username == user && secretEqual(string(u.Password), pass) {
+				return u.Username, u.Tenant, u.Role, true
+			}
+		}
+		return "", "", RoleNone, false
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token := strings.TrimPrefix(auth, "Bearer ")
+		for _, t := range c.BearerTokens {
+			if secretEqual(string(t.Token), token) {
+				return t.Name, t.Tenant, t.Role, true
+			}
+		}
+		return "", "", RoleNone, false
+	}
+
+	// Only certificates verified against the client CA are considered.
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		for _, cc := range c.ClientCerts {
+			if cc.CommonName == cn {
+				return cn, cc.Tenant, cc.Role, true
+			}
+		}
+	}
+	return "", "", c.AnonymousRole, true
+}
+
+// hasCredentials reports whether any credentials are configured. Without
+// them the Alertmanager is either open or behind an authenticating proxy,
+// whose headers are kept.
+func (c *Config) hasCredentials() bool {
+	return len(c.BasicAuthUsers) > 0 || len(c.BearerTokens) > 0 || len(c.ClientCerts) > 0
+}
+
+func secretEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Handler wraps h so that only authorized requests are passed on. Requests
+// to the public paths are never checked. If credentials are configured,
+// the audit.ActorHeader and config.TenantHeader of the request are replaced
+// by the name and tenant of the client, so that clients cannot choose them.
+func (c *Config) Handler(h http.Handler, public ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.hasCredentials() {
+			r.Header.Del(audit.ActorHeader)
+			r.Header.Del(config.TenantHeader)
+		}
+		for _, p := range public {
+			if r.URL.Path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(r.URL.Path, p)) {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		name, tenant, role, ok := c.authenticate(r)
+		if !ok || (name == "" && !role.allows(requiredRole(r))) {
+			if len(c.BasicAuthUsers) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Alertmanager"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !role.allows(requiredRole(r)) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if name != "" {
+			r.Header.Set(audit.ActorHeader, name)
+		}
+		if tenant != "" {
+			r.Header.Set(config.TenantHeader, tenant)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
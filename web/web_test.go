@@ -0,0 +1,177 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/prometheus/alertmanager/audit"
+	"github.com/prometheus/alertmanager/config"
+)
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		err string
+	}{
+		{
+			in: ``,
+		},
+		{
+			in: `
+basic_auth_users:
+- username: alice
+  password: secret
+  role: admin
+`,
+			err: `unknown role "admin"`,
+		},
+		{
+			in: `
+basic_auth_users:
+- username: alice
+  password: secret
+- username: alice
+  password: other
+`,
+			err: `duplicate user "alice"`,
+		},
+		{
+			in: `
+bearer_tokens:
+- token: secret
+`,
+			err: "name and token must be set for bearer tokens",
+		},
+		{
+			in: `
+client_certs:
+- common_name: prometheus
+`,
+			err: "client_certs require a client_ca_file in tls_server_config",
+		},
+	} {
+		_, err := Load(tc.in)
+		if tc.err == "" {
+			require.NoError(t, err)
+			continue
+		}
+		require.Error(t, err)
+		require.Contains(t, err.Error(), tc.err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	conf, err := Load(`
+basic_auth_users:
+- username: alice
+  password: secret
+  role: write
+- username: bob
+  password: secret
+  tenant: team-b
+bearer_tokens:
+- name: prometheus
+  token: t0ken
+  role: write
+anonymous_role: read
+`)
+	require.NoError(t, err)
+
+	var actor, tenant string
+	h := conf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = r.Header.Get(audit.ActorHeader)
+		tenant = r.Header.Get(config.TenantHeader)
+	}), "/-/healthy", "/api/v1/slack/")
+
+	for _, tc := range []struct {
+		method, path string
+		user, pass   string
+		token        string
+
+		code   int
+		actor  string
+		tenant string
+	}{
+		{method: "GET", path: "/api/v1/alerts", code: http.StatusOK},
+		{method: "POST", path: "/api/v1/alerts", code: http.StatusUnauthorized},
+		{method: "POST", path: "/-/healthy", code: http.StatusOK},
+		{method: "POST", path: "/api/v1/slack/command", code: http.StatusOK},
+		{method: "POST", path: "/api/v1/silences", user: "alice", pass: "secret", code: http.StatusOK, actor: "alice"},
+		{method: "POST", path: "/api/v1/silences", user: "alice", pass: "wrong", code: http.StatusUnauthorized},
+		{method: "GET", path: "/api/v1/silences", user: "bob", pass: "secret", code: http.StatusOK, actor: "bob", tenant: "team-b"},
+		{method: "DELETE", path: "/api/v1/silence/1", user: "bob", pass: "secret", code: http.StatusForbidden},
+		{method: "POST", path: "/api/v1/alerts", token: "t0ken", code: http.StatusOK, actor: "prometheus"},
+		{method: "GET", path: "/api/v1/alerts", token: "wrong", code: http.StatusUnauthorized},
+	} {
+		actor, tenant = "", ""
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		// Clients cannot choose their name or tenant.
+		r.Header.Set(audit.ActorHeader, "mallory")
+		r.Header.Set(config.TenantHeader, "team-a")
+		if tc.user != "" {
+			r.SetBasicAuth(tc.user, tc.pass)
+		}
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, tc.code, w.Code, "%s %s", tc.method, tc.path)
+		require.Equal(t, tc.actor, actor, "%s %s", tc.method, tc.path)
+		require.Equal(t, tc.tenant, tenant, "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestHandlerNoAnonymousAccess(t *testing.T) {
+	conf, err := Load(`
+basic_auth_users:
+- username: alice
+  password: secret
+`)
+	require.NoError(t, err)
+
+	h := conf.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/status", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, `Basic realm="Alertmanager"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestHandlerProxy(t *testing.T) {
+	// Without credentials the headers of an authenticating proxy are kept.
+	conf := &Config{AnonymousRole: RoleWrite}
+
+	var actor, tenant string
+	h := conf.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = r.Header.Get(audit.ActorHeader)
+		tenant = r.Header.Get(config.TenantHeader)
+	}))
+
+	r := httptest.NewRequest("POST", "/api/v1/silences", nil)
+	r.Header.Set(audit.ActorHeader, "alice")
+	r.Header.Set(config.TenantHeader, "team-a")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "alice", actor)
+	require.Equal(t, "team-a", tenant)
+}